@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/account"
+	"github.com/sudowanderer/dca-bot-go/internal/alert"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/threshold"
+)
+
+// alertDue records in state.status whether the condition of the alert key
+// holds and reports whether to send it, holding back an alert sent within
+// config.AlertCooldown. Without state.status, or when it cannot be read,
+// every alert whose condition holds is sent.
+func alertDue(ctx context.Context, payload *config.DCAPayload, key string, holds bool) bool {
+	st, err := newStatus(ctx, payload)
+	if err != nil {
+		run.Warn(ctx, "alerts", "state", err)
+		return holds
+	}
+	if st == nil {
+		return holds
+	}
+	ctx, end := run.StartSpan(ctx, "status.alerts")
+	defer end()
+
+	state, err := st.Alerts(ctx)
+	if err != nil {
+		run.Warn(ctx, "alerts", "state", err)
+		return holds
+	}
+	_, sent := state.Sent[key]
+	due := state.Due(key, holds, time.Now(), config.AlertCooldown)
+	if due || (sent && !holds) {
+		if err := st.SaveAlerts(ctx, state); err != nil {
+			run.Warn(ctx, "alerts", "state", err)
+		}
+	}
+	if holds && !due {
+		log.Printf("🔕 %s alert already sent within %s", key, config.AlertCooldown)
+	}
+	return due
+}
+
+// checkHoldings sends the holdings notification of strategy.holdingsAlert
+// after a buy when the held asset exceeds its threshold. The order already
+// went through, so failures are only logged.
+func checkHoldings(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) {
+	h := payload.Strategy.HoldingsAlert
+	code := h.Asset
+	if code == "" {
+		base, _, err := exchange.SplitSymbol(payload.Strategy.Symbol)
+		if err != nil {
+			run.Warn(ctx, "holdings", "check", err)
+			return
+		}
+		code = base
+	}
+	ctx, end := run.StartSpan(ctx, "holdings.check")
+	defer end()
+
+	balance, err := exc.GetBalance(ctx, code)
+	if err != nil {
+		run.Warn(ctx, "holdings", "balance", err)
+		return
+	}
+	event, ok := threshold.Holdings(ctx, payload.Strategy.Symbol, code, balance, h.Amount(), payload.Strategy.Withdrawal != nil)
+	subject := code
+	if payload.Account != "" {
+		subject += "@" + payload.Account
+	}
+	if !alertDue(ctx, payload, alert.Key(alert.KindHoldings, payload.Exchange.Name, subject), ok) {
+		return
+	}
+	log.Printf("🏦 %s", event.Summary)
+	dispatch(ctx, event)
+}
+
+// lowBalanceKey is the alert key of the strategy's low-balance notification
+func lowBalanceKey(payload *config.DCAPayload) string {
+	return alert.Key(alert.KindLowBalance, payload.Exchange.Name, account.RouteKey(payload))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/sudowanderer/dca-bot-go/internal/approval"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// ssmParameters keeps the approval parameter in SSM Parameter Store
+type ssmParameters struct{}
+
+func (ssmParameters) Get(ctx context.Context, name string) (string, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	out, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return "", approval.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
+
+func (ssmParameters) Put(ctx context.Context, name, value string) error {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	_, err = ssm.NewFromConfig(cfg).PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(name),
+		Value:     aws.String(value),
+		Type:      types.ParameterTypeString,
+		Overwrite: aws.Bool(true),
+	})
+	return err
+}
+
+// newApprovalGate builds the gate of flags.requireApproval; it is nil when
+// approvals are off
+func newApprovalGate(ctx context.Context, payload *config.DCAPayload) (*approval.Gate, error) {
+	cfg := payload.Flags.RequireApproval
+	if cfg == nil {
+		return nil, nil
+	}
+	gate := &approval.Gate{Parameters: ssmParameters{}, Parameter: cfg.Parameter}
+	st, err := newStatus(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if st != nil {
+		gate.State = st
+	}
+	return gate, nil
+}
+
+// checkApproval holds a live run whose strategy was not approved, sending
+// its hash and changes for approval. Dry runs, reconcile and report runs
+// place no orders, so they go ahead.
+func checkApproval(ctx context.Context, payload *config.DCAPayload) (*guard.Skip, error) {
+	if payload.Flags.DryRun || payload.Mode == config.ModeReconcile || payload.Mode == config.ModeReport {
+		return nil, nil
+	}
+	gate, err := newApprovalGate(ctx, payload)
+	if err != nil || gate == nil {
+		return nil, err
+	}
+	spanCtx, end := run.StartSpan(ctx, "approval.check")
+	d, err := gate.Check(spanCtx, payload)
+	end()
+	if err != nil {
+		return nil, err
+	}
+	if d.Approved {
+		log.Printf("✅ Strategy %s is approved", d.Hash)
+		return nil, nil
+	}
+	dispatch(ctx, approval.Event(d, payload.Strategy.Symbol, gate.Parameter))
+	return &guard.Skip{
+		Guard:  "approval",
+		Reason: fmt.Sprintf("strategy %s awaits approval in %s (%d changes)", d.Hash, gate.Parameter, len(d.Changes)),
+	}, nil
+}
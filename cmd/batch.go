@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sudowanderer/dca-bot-go/internal/batch"
+	"github.com/sudowanderer/dca-bot-go/internal/entrypoint"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// runAllCommand runs every payload file in a directory through the handler,
+// as a local run of each would, prints a summary table and fails when any
+// file did unless --continue-on-error is given
+//
+//	run-all [--dir payloads] [--parallel 3] [--continue-on-error] [--output report.json] [--timeout 15s]
+func runAllCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run-all", flag.ContinueOnError)
+	dir := fs.String("dir", "payloads", "directory of payload files (*.json, *.yaml)")
+	parallel := fs.Int("parallel", 1, "number of files to run at once")
+	continueOnError := fs.Bool("continue-on-error", false, "run every file and exit zero even when some fail")
+	output := fs.String("output", "", "write the batch report as JSON to a local file or s3:// URI")
+	timeout := fs.Duration("timeout", localTimeout, "bound each run like the Lambda function timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *parallel < 1 {
+		return fmt.Errorf("--parallel: must be at least 1, got %d", *parallel)
+	}
+
+	files, err := batch.Discover(*dir)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no payload files in %s", *dir)
+	}
+	// handler.ExecutionID prefixes the process-wide logger, so with
+	// --parallel the prefix of an interleaved line may name another run
+	log.Printf("🗂️ Running %d payload files from %s, %d at a time", len(files), *dir, *parallel)
+
+	h := newHandler(handler.Timeout(*timeout))
+	invoke := func(ctx context.Context, path string) (*result.ExecutionResult, error) {
+		data, err := batch.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		return entrypoint.Invoke(ctx, h, data)
+	}
+	report := batch.Run(ctx, files, invoke, batch.Options{Parallel: *parallel, ContinueOnError: *continueOnError})
+	report.Dir = *dir
+
+	// Write the report even when interrupted
+	writeCtx, cancel := run.WrapUp(ctx)
+	defer cancel()
+	if *output != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %w", err)
+		}
+		if err := writeLocation(writeCtx, *output, "application/json", append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	if err := report.WriteSummary(os.Stdout); err != nil {
+		return err
+	}
+	if cause := context.Cause(ctx); cause != nil {
+		return fmt.Errorf("interrupted: %w", cause)
+	}
+	if failed := report.Failed(); failed > 0 && !*continueOnError {
+		return fmt.Errorf("%d of %d payload files failed", failed, len(files))
+	}
+	return nil
+}
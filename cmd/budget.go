@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/budget"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/plan"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/taxexport"
+)
+
+// applyBudget sets the strategy's quoteAmount for this run from its monthly
+// budget and the month-to-date spend in its budget history, recording the
+// plan in res. It returns a skip once the month's budget is spent.
+func applyBudget(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult, now time.Time) (*guard.Skip, error) {
+	strategy := payload.Strategy
+	spent, err := budgetSpent(ctx, payload, now)
+	if err != nil {
+		return nil, err
+	}
+	budgetPlan, skip, err := plan.Budget(strategy, spent, now)
+	if err != nil {
+		return nil, err
+	}
+	_, quote, _ := exchange.SplitSymbol(strategy.Symbol)
+	res.Budget = &budgetPlan
+	log.Printf("📆 Monthly budget: %s of %s %s spent, %d run(s) left", budgetPlan.Spent, budgetPlan.Monthly, quote, budgetPlan.RunsLeft)
+	if skip != nil {
+		return skip, nil
+	}
+	payload.Strategy.QuoteAmount = budgetPlan.Amount.String()
+	payload.SetOrigin("strategy.quoteAmount", config.OriginDerived)
+	res.QuoteAmount = payload.Strategy.QuoteAmount
+	return nil, nil
+}
+
+// budgetSpent reads the month-to-date spend of payload's strategy at now
+// from its budget history. An exchange account counts only its own buys.
+func budgetSpent(ctx context.Context, payload *config.DCAPayload, now time.Time) (decimal.Decimal, error) {
+	strategy := payload.Strategy
+	loc, err := strategy.Location()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	_, end := run.StartSpan(ctx, "budget.history")
+	data, err := readLocation(ctx, strategy.BudgetHistory)
+	end()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to read budget history: %w", err)
+	}
+	history, err := taxexport.ReadHistory(bytes.NewReader(data))
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to read budget history: %w", err)
+	}
+	return monthToDate(history, strategy.Symbol, payload.Account, budget.MonthStart(now.In(loc)), now)
+}
+
+// monthToDate sums the quote amounts of the live buys of symbol for
+// account finished in [from, to), including fills imported by reconcile
+// runs
+func monthToDate(history []result.ExecutionResult, symbol, account string, from, to time.Time) (decimal.Decimal, error) {
+	spent := decimal.Zero
+	for _, res := range result.Runs(history) {
+		if res.DryRun || res.Status != result.StatusExecuted || res.Symbol != symbol || res.Account != account {
+			continue
+		}
+		if res.Order != nil && res.Order.Side == config.SideSell {
+			continue
+		}
+		if res.FinishedAt.Before(from) || !res.FinishedAt.Before(to) {
+			continue
+		}
+		amount, err := decimal.NewFromString(res.QuoteAmount)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("budget history %s: invalid quoteAmount %q", res.ExecutionID, res.QuoteAmount)
+		}
+		spent = spent.Add(amount)
+	}
+	return spent, nil
+}
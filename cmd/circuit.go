@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// checkCircuitBreaker runs strategy.circuitBreaker before a buy, reading
+// the window's one-minute candles. Where they cannot be read the buy goes
+// ahead with a warning: the breaker only stands down on a move it can see.
+// Under state.enrichWithContext the candles also give res its market
+// context.
+func checkCircuitBreaker(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) (*guard.Skip, error) {
+	cb := payload.Strategy.CircuitBreaker
+	base, _, err := exchange.SplitSymbol(payload.Strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end := run.StartSpan(ctx, "circuitBreaker.check")
+	defer end()
+
+	source := newKlineSource(payload.Exchange, exc)
+	if source == nil {
+		run.Warn(ctx, "circuitBreaker", "klines", fmt.Errorf("%s does not report candles; the price move was not checked", payload.Exchange.Name))
+		return nil, nil
+	}
+	now := time.Now()
+	klines, err := source.GetKlines(ctx, payload.Strategy.Symbol, time.Minute, now.Add(-cb.Window()), now)
+	if err != nil {
+		run.Warn(ctx, "circuitBreaker", "klines", err)
+		return nil, nil
+	}
+	if state := payload.State; state != nil && state.EnrichWithContext {
+		res.Market = market.Summarize(klines, time.Minute, now)
+	}
+	skip, err := guard.CircuitBreaker(cb, base, klines, now)
+	if err != nil {
+		run.Warn(ctx, "circuitBreaker", "check", err)
+		return nil, nil
+	}
+	return skip, nil
+}
+
+// newKlineSource returns what reports the venue's candles: the exchange
+// itself when it can, otherwise a public client for the exchanges in
+// config.CandleExchanges. It is nil for the others.
+func newKlineSource(venue config.ExchangeConfig, exc exchange.Exchange) exchange.KlineSource {
+	if source, ok := exc.(exchange.KlineSource); ok {
+		return source
+	}
+	if slices.Contains(config.CandleExchanges, strings.ToLower(venue.Name)) {
+		return exchange.NewBinanceKlines()
+	}
+	return nil
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/bootstrap"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/dynamo"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/kmspayload"
+	"github.com/sudowanderer/dca-bot-go/internal/plan"
+	"github.com/sudowanderer/dca-bot-go/internal/seal"
+	"github.com/sudowanderer/dca-bot-go/internal/taxexport"
+	"github.com/sudowanderer/dca-bot-go/internal/wizard"
+)
+
+// commands maps local subcommand names to their implementations
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"bootstrap":            bootstrapCommand,
+	"bot":                  botCommand,
+	"diff":                 diffCommand,
+	"encrypt-payload":      encryptPayloadCommand,
+	"export":               exportCommand,
+	"gen-payload":          genPayloadCommand,
+	"migrate-payload":      migratePayloadCommand,
+	"preview-notification": previewNotificationCommand,
+	"rekey":                rekeyCommand,
+	"run-all":              runAllCommand,
+	"schema":               schemaCommand,
+	"support-bundle":       supportBundleCommand,
+	"verify-exchange":      verifyExchangeCommand,
+}
+
+// runCommand dispatches a local subcommand
+func runCommand(ctx context.Context, name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		names := make([]string, 0, len(commands))
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown command %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return command(ctx, args)
+}
+
+// genPayloadCommand writes a payload file and a matching example EventBridge
+// event, asking for each value interactively unless --non-interactive is set
+//
+//	gen-payload [--out payload.json] [--event-out event.json] [--non-interactive --exchange binance ...]
+func genPayloadCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("gen-payload", flag.ContinueOnError)
+	out := fs.String("out", "payload.json", "file to write the payload to")
+	eventOut := fs.String("event-out", "eventbridge_event.json", "file to write the example EventBridge event to")
+	nonInteractive := fs.Bool("non-interactive", false, "take all answers from flags instead of prompting")
+
+	var a wizard.Answers
+	fs.StringVar(&a.Exchange, "exchange", "binance", "exchange name (binance)")
+	fs.StringVar(&a.Symbol, "symbol", "BTC-USDT", "trading pair")
+	fs.StringVar(&a.QuoteAmount, "amount", "10", "quote amount per run")
+	fs.StringVar(&a.BalanceThreshold, "balance-threshold", "", "low balance alert threshold")
+	fs.StringVar(&a.CredentialType, "credentials", "ssm", "credential source (ssm, env, inline)")
+	fs.StringVar(&a.APIKeyRef, "api-key", "", "API key SSM path, env var name or value")
+	fs.StringVar(&a.APISecretRef, "api-secret", "", "API secret SSM path, env var name or value")
+	fs.StringVar(&a.TelegramType, "telegram", "", "telegram bot token source (ssm, env, inline), empty for none")
+	fs.StringVar(&a.TelegramTokenRef, "telegram-token", "", "telegram bot token SSM path, env var name or value")
+	fs.StringVar(&a.TelegramChatID, "telegram-chat-id", "", "telegram chat ID")
+	fs.BoolVar(&a.DryRun, "dry-run", true, "generate a dry-run payload")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !*nonInteractive {
+		var err error
+		if a, err = wizard.Ask(os.Stdin, os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	payloadJSON, eventJSON, err := wizard.Render(a)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, append(payloadJSON, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	if err := os.WriteFile(*eventOut, append(eventJSON, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	fmt.Printf("✅ Wrote %s and %s\n", *out, *eventOut)
+	return nil
+}
+
+// encryptPayloadCommand wraps a payload file in a KMS-encrypted envelope
+//
+//	encrypt-payload --key <keyId> --in payload.json [--out envelope.json]
+func encryptPayloadCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("encrypt-payload", flag.ContinueOnError)
+	keyID := fs.String("key", "", "KMS key ID, ARN or alias to encrypt with")
+	in := fs.String("in", "", "payload JSON file to encrypt")
+	out := fs.String("out", "", "file to write the envelope to (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyID == "" || *in == "" {
+		return fmt.Errorf("--key and --in are required")
+	}
+
+	payload, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+
+	client, err := newKMSClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := kmspayload.Encrypt(ctx, client, *keyID, payload)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		fmt.Println(string(envelope))
+		return nil
+	}
+	return os.WriteFile(*out, append(envelope, '\n'), 0o600)
+}
+
+// migratePayloadCommand converts a legacy PayloadV2 file to the DCAPayload
+// format, listing the legacy fields it could not carry over
+//
+//	migrate-payload --in old.json --out new.json [--force]
+func migratePayloadCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("migrate-payload", flag.ContinueOnError)
+	in := fs.String("in", "", "legacy payload JSON file")
+	out := fs.String("out", "", "file to write the migrated payload to")
+	force := fs.Bool("force", false, "overwrite --out if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" || *out == "" {
+		return fmt.Errorf("--in and --out are required")
+	}
+
+	raw, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+	payload, notes, err := config.MigrateV2(raw)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(*out, flags, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", *out)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write payload: %w", err)
+	}
+
+	fmt.Printf("✅ Wrote %s\n", *out)
+	for _, note := range notes {
+		fmt.Printf("⚠️ Not migrated: %s\n", note)
+	}
+	return nil
+}
+
+// exportCommand writes the live buys from an execution history (one
+// ExecutionResult JSON per line) as a tax tool import file, or as a ledger
+// with the market around each buy. History and output may be local paths
+// or s3://bucket/key URIs.
+//
+//	export --format koinly --history history.jsonl [--from 2025-01-01] [--to 2025-12-31] [--out s3://bucket/taxes.csv]
+func exportCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "", "export format ("+strings.Join(taxexport.Formats(), ", ")+")")
+	history := fs.String("history", "", "execution history file or s3:// URI")
+	from := fs.String("from", "", "first day to include, YYYY-MM-DD (UTC)")
+	to := fs.String("to", "", "last day to include, YYYY-MM-DD (UTC)")
+	out := fs.String("out", "", "file or s3:// URI to write to (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format == "" || *history == "" {
+		return fmt.Errorf("--format and --history are required")
+	}
+
+	var start, end time.Time
+	var err error
+	if *from != "" {
+		if start, err = time.Parse(config.DateLayout, *from); err != nil {
+			return fmt.Errorf("invalid --from %q (want YYYY-MM-DD)", *from)
+		}
+	}
+	if *to != "" {
+		if end, err = time.Parse(config.DateLayout, *to); err != nil {
+			return fmt.Errorf("invalid --to %q (want YYYY-MM-DD)", *to)
+		}
+		end = end.AddDate(0, 0, 1) // --to is inclusive
+	}
+
+	data, err := readLocation(ctx, *history)
+	if err != nil {
+		return err
+	}
+	results, err := taxexport.ReadHistory(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	lots, err := taxexport.Lots(results, start, end)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := taxexport.Write(&buf, *format, lots); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := writeLocation(ctx, *out, "text/csv", buf.Bytes()); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Exported %d lots to %s\n", len(lots), *out)
+	return nil
+}
+
+// diffCommand compares two payloads: their effective configurations and
+// what a run of each would do against the same market. With --at the run
+// is planned at that time against the mock exchange's fixed prices;
+// otherwise now, against the market of the new payload's exchange.
+//
+//	diff --old a.json --new b.json [--at 2025-06-01]
+func diffCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	oldPath := fs.String("old", "", "payload before the edit")
+	newPath := fs.String("new", "", "payload after the edit")
+	at := fs.String("at", "", "plan the runs at this time, YYYY-MM-DD (UTC) or RFC 3339, against fixed prices")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *oldPath == "" || *newPath == "" {
+		return fmt.Errorf("--old and --new are required")
+	}
+
+	var payloads [2]*config.DCAPayload
+	for i, path := range []string{*oldPath, *newPath} {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read payload: %w", err)
+		}
+		if payloads[i], err = config.ParseDCAPayload(raw); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	now := time.Now()
+	var exc exchange.Exchange
+	if *at != "" {
+		t, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			if t, err = time.Parse(config.DateLayout, *at); err != nil {
+				return fmt.Errorf("invalid --at %q (want YYYY-MM-DD or RFC 3339)", *at)
+			}
+		}
+		now, exc = t, exchange.NewMockExchange()
+	} else {
+		var err error
+		if exc, err = newExchange(ctx, payloads[1]); err != nil {
+			return fmt.Errorf("failed to create exchange: %w", err)
+		}
+	}
+
+	symbols := []string{payloads[0].Strategy.Symbol}
+	if s := payloads[1].Strategy.Symbol; s != symbols[0] {
+		symbols = append(symbols, s)
+	}
+	market, err := plan.Gather(ctx, exc, symbols...)
+	if err != nil {
+		return err
+	}
+
+	var plans [2]*plan.Plan
+	for i, payload := range payloads {
+		spent := decimal.Zero
+		if payload.Strategy.Budgeted() {
+			if spent, err = budgetSpent(ctx, payload, now); err != nil {
+				fmt.Printf("⚠️ %v; planning as if nothing was spent this month\n", err)
+			}
+		}
+		if plans[i], err = plan.Evaluate(payload, market, spent, now); err != nil {
+			return err
+		}
+	}
+
+	comparison, err := plan.Compare(payloads[0], payloads[1], plans[0], plans[1])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Runs planned at %s\n\n%s", now.UTC().Format(time.RFC3339), comparison)
+	return nil
+}
+
+// bootstrapCommand checks the DynamoDB tables and S3 buckets a payload
+// refers to and lists the IAM permissions its runs need. Missing resources
+// are only created with --create; runs never create them.
+//
+//	bootstrap --event payload.json [--create]
+func bootstrapCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	event := fs.String("event", "", "payload to bootstrap")
+	create := fs.Bool("create", false, "create missing tables (on-demand) and buckets, and turn on time to live where needed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *event == "" {
+		return fmt.Errorf("--event is required")
+	}
+	raw, err := os.ReadFile(*event)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+	payload, err := config.ParseDCAPayload(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", *event, err)
+	}
+
+	broken, err := bootstrapResources(ctx, payload, *create)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("\nIAM permissions the function's role needs:")
+	for _, p := range bootstrap.Permissions(payload) {
+		fmt.Printf("  %s\n", p)
+	}
+	if broken > 0 {
+		return fmt.Errorf("%d resource(s) exist with the wrong shape; fix them or point the payload elsewhere", broken)
+	}
+	return nil
+}
+
+// bootstrapResources prints the state of the payload's tables and buckets,
+// creating the missing ones when create is set. broken counts those that
+// exist with a shape only the user can fix.
+func bootstrapResources(ctx context.Context, payload *config.DCAPayload, create bool) (broken int, err error) {
+	resources := bootstrap.Required(payload)
+	if len(resources) == 0 {
+		fmt.Println("The payload refers to no DynamoDB tables or S3 buckets")
+		return 0, nil
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	tables := bootstrap.DynamoTables{Client: dynamo.New(cfg)}
+	buckets := bootstrap.S3Buckets{Client: s3.NewFromConfig(cfg), Region: cfg.Region}
+
+	statuses, err := bootstrap.Check(ctx, resources, tables, buckets)
+	if err != nil {
+		return 0, err
+	}
+	if create {
+		// On failure the statuses still show what was created so far
+		statuses, err = bootstrap.Create(ctx, statuses, tables, buckets)
+	}
+
+	fmt.Printf("Resources in %s:\n", cfg.Region)
+	pending := 0
+	for _, s := range statuses {
+		icon := "✅"
+		switch {
+		case s.State == bootstrap.StateCreated:
+			icon = "🆕"
+		case s.State == bootstrap.StateMissing || s.Fixable:
+			icon = "❌"
+			pending++
+		case s.State == bootstrap.StateMismatch:
+			icon = "⚠️"
+			broken++
+		}
+		fmt.Printf("  %s %s\n", icon, s)
+	}
+	if err != nil {
+		return broken, err
+	}
+	if pending > 0 {
+		fmt.Println("Run again with --create to create the missing resources and turn on time to live.")
+	}
+	return broken, nil
+}
+
+// parseS3URI splits s3://bucket/key; ok is false for anything else
+func parseS3URI(uri string) (bucket, key string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "s3://")
+	if !found {
+		return "", "", false
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	return bucket, key, bucket != "" && key != ""
+}
+
+// readLocation reads a local file, decrypting it when it was encrypted
+// under the local encryption key, or an s3:// object
+func readLocation(ctx context.Context, location string) ([]byte, error) {
+	bucket, key, ok := parseS3URI(location)
+	if !ok {
+		sealKey, err := localKey()
+		if err != nil {
+			return nil, err
+		}
+		data, err := seal.ReadFile(location, sealKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", location, err)
+		}
+		return data, nil
+	}
+
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	defer obj.Body.Close()
+	return io.ReadAll(obj.Body)
+}
+
+// writeLocation writes a local file or an s3:// object
+func writeLocation(ctx context.Context, location, contentType string, data []byte) error {
+	bucket, key, ok := parseS3URI(location)
+	if !ok {
+		return os.WriteFile(location, data, 0o600)
+	}
+
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", location, err)
+	}
+	return nil
+}
+
+// writeHistory writes an execution history to a local file, encrypted
+// under the local encryption key when set, or to an s3:// object
+func writeHistory(ctx context.Context, location string, data []byte) error {
+	if _, _, ok := parseS3URI(location); ok {
+		return writeLocation(ctx, location, "application/x-ndjson", data)
+	}
+	key, err := localKey()
+	if err != nil {
+		return err
+	}
+	if err := seal.WriteFile(location, data, key); err != nil {
+		return fmt.Errorf("failed to write %s: %w", location, err)
+	}
+	return nil
+}
+
+// newS3Client creates an S3 client from the default AWS configuration
+func newS3Client(ctx context.Context) (*s3.Client, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return s3.NewFromConfig(cfg), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// loadAWSConfig loads the default AWS configuration, with the requests of
+// the clients built from it counted for the run's cost estimate
+func loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return cfg, err
+	}
+	cost.Instrument(&cfg)
+	return cfg, nil
+}
+
+// recordCost adds the estimated cost of the invocation to res: the AWS
+// requests counted so far, the Lambda duration up to the end of the run
+// and the fees of its orders
+func recordCost(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	table, err := cost.Default().With(payload.Flags.CostPrices)
+	if err != nil {
+		run.Warn(ctx, "cost", "estimate", err)
+		return
+	}
+	usage := cost.Usage{Calls: cost.MeterFrom(ctx).Calls(), Orders: res.Orders()}
+	if env.IsLambdaEnvironment() {
+		usage.MemoryMB = cost.LambdaMemory()
+		usage.Duration = res.FinishedAt.Sub(res.StartedAt)
+	}
+	res.Cost = table.Estimate(usage)
+	log.Printf("💸 Estimated cost: %s", res.Cost)
+}
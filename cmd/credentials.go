@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/credentials"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// resolveSecret reads the secret called name from a credential source; see
+// credentials.Secret for the config key it is named by
+func resolveSecret(ctx context.Context, source config.CredentialSource, name string) (string, error) {
+	ctx, end := run.StartSpan(ctx, "credentials")
+	defer end()
+
+	secret, err := credentials.Secret(ctx, newSSMClient, source, name)
+	return secret, failure.Mark(failure.CodeCredentialsFailed, err)
+}
+
+// newExchange creates the exchange client of payload, resolving the
+// credentials of its venue unless it is a dry run
+func newExchange(ctx context.Context, payload *config.DCAPayload) (exchange.Exchange, error) {
+	var creds exchange.Credentials
+	if !payload.Flags.DryRun {
+		var err error
+		if creds, err = exchangeCredentials(ctx, payload.Exchange); err != nil {
+			return nil, err
+		}
+	}
+	return exchange.NewExchange(payload, creds)
+}
+
+// exchangeCredentials resolves the API key and secret of venue, and the
+// passphrase on OKX, reading SSM parameters in a single call
+func exchangeCredentials(ctx context.Context, venue config.ExchangeConfig) (exchange.Credentials, error) {
+	ctx, end := run.StartSpan(ctx, "credentials")
+	defer end()
+
+	creds, err := credentials.Resolve(ctx, newSSMClient, venue.Credentials)
+	if err == nil && strings.ToLower(venue.Name) == "okx" && creds.Passphrase == "" {
+		err = fmt.Errorf("passphrase is required on okx")
+	}
+	if err != nil {
+		return exchange.Credentials{}, failure.Mark(failure.CodeCredentialsFailed, err)
+	}
+	return exchange.Credentials(creds), nil
+}
+
+// newSSMClient creates an SSM client from the default AWS configuration
+func newSSMClient(ctx context.Context) (credentials.SSMAPI, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return ssm.NewFromConfig(cfg), nil
+}
+
+// readParameter reads an SSM parameter, decrypting SecureStrings
+func readParameter(ctx context.Context, path string) (string, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	out, err := ssm.NewFromConfig(cfg).GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(path),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read SSM parameter %s: %w", path, err)
+	}
+	return aws.ToString(out.Parameter.Value), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/dip"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// applyDip multiplies the strategy's quoteAmount for this run by the
+// strategy.dipMultipliers tier the price's drop below the reference
+// matches, recording it in res. Where the reference or the price cannot be
+// read the run buys the base amount with a warning.
+func applyDip(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) error {
+	cfg := payload.Strategy.DipMultipliers
+	symbol := payload.Strategy.Symbol
+	amount, err := decimal.NewFromString(payload.Strategy.QuoteAmount)
+	if err != nil {
+		return fmt.Errorf("invalid quote amount: %w", err)
+	}
+	ctx, end := run.StartSpan(ctx, "dip.check")
+	defer end()
+
+	reference, source, err := dipReference(ctx, payload, exc)
+	if err != nil {
+		run.Warn(ctx, "dipMultipliers", "reference", fmt.Errorf("%w; buying the base amount", err))
+		return nil
+	}
+	ticker, err := readTicker(ctx, exc, symbol, res)
+	if err != nil {
+		run.Warn(ctx, "dipMultipliers", "ticker", fmt.Errorf("%w; buying the base amount", err))
+		return nil
+	}
+	_, quote, _ := exchange.SplitSymbol(symbol)
+	d, err := dip.Apply(*cfg, amount, reference, ticker.Last, sizing.QuotePlaces(asset.Canonical("", quote)))
+	if err != nil {
+		return err
+	}
+	d.ReferenceSource = source
+	if source == dip.SourceCandles {
+		d.LookbackDays = cfg.LookbackDays
+	}
+	res.Dip = &d
+	log.Printf("📉 Dip: %s", describeDip(ctx, symbol, d))
+
+	if !d.Amount.Equal(amount) {
+		payload.Strategy.QuoteAmount = d.Amount.String()
+		payload.SetOrigin("strategy.quoteAmount", config.OriginDerived)
+		res.QuoteAmount = payload.Strategy.QuoteAmount
+	}
+	return nil
+}
+
+// dipReference returns the price drops are measured from and its source:
+// strategy.dipMultipliers.referencePrice, or the highest price of the
+// lookback's daily candles
+func dipReference(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) (decimal.Decimal, string, error) {
+	cfg := payload.Strategy.DipMultipliers
+	if cfg.ReferencePrice != "" {
+		// Validated with the payload
+		return decimal.RequireFromString(cfg.ReferencePrice), dip.SourcePayload, nil
+	}
+	source := newKlineSource(payload.Exchange, exc)
+	if source == nil {
+		return decimal.Zero, "", fmt.Errorf("%s does not report candles", payload.Exchange.Name)
+	}
+	now := time.Now()
+	klines, err := source.GetKlines(ctx, payload.Strategy.Symbol, 24*time.Hour, now.AddDate(0, 0, -cfg.LookbackDays), now)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	high := dip.High(klines)
+	if !high.IsPositive() {
+		return decimal.Zero, "", fmt.Errorf("no candles of %s in the last %d days", payload.Strategy.Symbol, cfg.LookbackDays)
+	}
+	return high, dip.SourceCandles, nil
+}
+
+// describeDip renders how the amount was multiplied, e.g. "×2 at 23.53%
+// below the 30-day high 68,000.00: 200.00 USDT instead of 100.00 USDT"
+func describeDip(ctx context.Context, symbol string, d dip.Dip) string {
+	reference := "referencePrice " + describePrice(ctx, symbol, d.Reference)
+	if d.ReferenceSource == dip.SourceCandles {
+		reference = fmt.Sprintf("the %d-day high %s", d.LookbackDays, describePrice(ctx, symbol, d.Reference))
+	}
+	position := fmt.Sprintf("%s%% below %s", d.Drawdown.Round(2), reference)
+	if d.Drawdown.IsNegative() {
+		position = fmt.Sprintf("%s%% above %s", money.Percent{}.Sub(d.Drawdown).Round(2), reference)
+	}
+	if d.Tier.IsZero() {
+		return fmt.Sprintf("×1 at %s, no tier reached", position)
+	}
+	if !d.Multiplied() {
+		return fmt.Sprintf("×1 at %s: tier %s%% (×%s) reached, but maxQuoteAmount keeps the base amount %s", position, d.Tier, d.Multiplier, describeQuote(ctx, symbol, d.BaseAmount))
+	}
+	s := fmt.Sprintf("×%s at %s (tier %s%%): %s instead of %s", d.Multiplier, position, d.Tier, describeQuote(ctx, symbol, d.Amount), describeQuote(ctx, symbol, d.BaseAmount))
+	if d.Capped {
+		s += " (capped by maxQuoteAmount)"
+	}
+	return s
+}
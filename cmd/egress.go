@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/egress"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// checkEgress fails the run before it reaches an exchange when it does not
+// leave from or run where flags.expectedEgress says. Dry runs trade with
+// the mock exchange, so they are not checked.
+func checkEgress(ctx context.Context, payload *config.DCAPayload) error {
+	expected := payload.Flags.ExpectedEgress
+	if expected == nil || payload.Flags.DryRun {
+		return nil
+	}
+	ctx, end := run.StartSpan(ctx, "preflight.egress")
+	defer end()
+	if err := egress.Check(ctx, nil, *expected, egress.Region()); err != nil {
+		return failure.Mark(failure.CodeConfigInvalid, fmt.Errorf("egress check failed: %w", err))
+	}
+	log.Printf("🌐 Egress matches flags.expectedEgress")
+	return nil
+}
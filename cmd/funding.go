@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/route"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// checkFunding runs strategy.checkPendingDeposits before a buy of amount.
+// A free balance short of it skips the run while pending deposits will
+// cover it, and fails it when none will. The check only adds detail to a
+// buy that would fail anyway: where the balance or the deposits cannot be
+// read, the order goes ahead as without it.
+func checkFunding(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, amount decimal.Decimal) (*guard.Skip, error) {
+	quote, err := extractQuoteCurrency(payload.Strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end := run.StartSpan(ctx, "funding.check")
+	defer end()
+
+	free, err := exc.GetBalance(ctx, quote)
+	if err != nil {
+		run.Warn(ctx, "funding", "balance", err)
+		return nil, nil
+	}
+	if free.GreaterThanOrEqual(amount) {
+		return nil, nil
+	}
+
+	watcher, err := newDepositWatcher(ctx, payload.Exchange, exc)
+	if err != nil {
+		run.Warn(ctx, "funding", "deposits", fmt.Errorf("failed to create deposit client: %w", err))
+		return nil, nil
+	}
+	if watcher == nil {
+		log.Printf("⚠️ %s does not report pending deposits; placing the order with %s %s free", payload.Exchange.Name, free.String(), quote)
+		return nil, nil
+	}
+	deposits, err := watcher.GetPendingDeposits(ctx, quote)
+	if err != nil {
+		run.Warn(ctx, "funding", "deposits", err)
+		return nil, nil
+	}
+	skip, err := guard.Funding(quote, free, amount, deposits, time.Now())
+	if err != nil {
+		return nil, failure.Mark(failure.CodeOrderRejected, err)
+	}
+	return skip, nil
+}
+
+// newDepositWatcher returns what reports the venue's pending deposits: the
+// exchange itself when it can, as Binance does, otherwise a standalone
+// client for Kraken and Coinbase. It is nil for the others.
+func newDepositWatcher(ctx context.Context, venue config.ExchangeConfig, exc exchange.Exchange) (exchange.DepositWatcher, error) {
+	if routed, ok := exc.(*route.Exchange); ok {
+		exc = routed.Exchange
+	}
+	if watcher, ok := exc.(exchange.DepositWatcher); ok {
+		return watcher, nil
+	}
+	name := strings.ToLower(venue.Name)
+	if name != "kraken" && name != "coinbase" {
+		return nil, nil
+	}
+	creds, err := exchangeCredentials(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	if name == "kraken" {
+		return exchange.NewKrakenDeposits(creds.APIKey, creds.APISecret), nil
+	}
+	return exchange.NewCoinbaseDeposits(creds.APIKey, creds.APISecret), nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// withExtraHeaders makes the requests to the exchange in payload carry its
+// exchange.options.extraHeaders, resolving each value from its source
+func withExtraHeaders(ctx context.Context, payload *config.DCAPayload) (context.Context, error) {
+	options := payload.Exchange.Options
+	if options == nil || len(options.ExtraHeaders) == 0 {
+		return ctx, nil
+	}
+	header := make(http.Header, len(options.ExtraHeaders))
+	for name, source := range options.ExtraHeaders {
+		value, err := resolveSecret(ctx, source, "value")
+		if err != nil {
+			return ctx, fmt.Errorf("exchange.options.extraHeaders.%s: %w", name, err)
+		}
+		header.Set(name, value)
+	}
+	return useragent.WithExtra(ctx, header), nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/intent"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/route"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// withIdempotencyKey returns ctx for placing the run's side order, keyed so
+// every delivery of the same scheduled event uses the same client order ID:
+// by flags.idempotencyKey expanded for the time of the triggering event,
+// else the EventBridge event id, else the invocation ID that retries keep.
+// The symbol and side are part of the key; the amount is not, since a
+// redelivery may size the order differently.
+func withIdempotencyKey(ctx context.Context, payload *config.DCAPayload, side string) context.Context {
+	source := run.SourceFrom(ctx)
+	key := payload.OccurrenceKey(source.OccurredAt(time.Now()))
+	if key == "" {
+		key = source.EventID
+	}
+	if key == "" {
+		key = source.InvocationID
+	}
+	if key == "" {
+		return ctx
+	}
+	return run.WithIdempotencyKey(ctx, strings.Join([]string{key, payload.Strategy.Symbol, side}, "|"))
+}
+
+// writeIntent records in state.status the live order about to be placed,
+// so the next run can recover it should this one die before saving its
+// records. Nothing is written in a dry run, without state.status, or for
+// routed buys, whose legs trade other symbols. A failed write fails the
+// run rather than ordering without the record.
+func writeIntent(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, side string, amount decimal.Decimal) (*intent.Intent, error) {
+	if payload.Flags.DryRun {
+		return nil, nil
+	}
+	if _, ok := exc.(*route.Exchange); ok {
+		return nil, nil
+	}
+	st, err := newStatus(ctx, payload)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	in := &intent.Intent{
+		ExecutionID:   run.ID(ctx),
+		ClientOrderID: run.ClientOrderID(ctx, "dca"),
+		Exchange:      payload.Exchange.Name,
+		Symbol:        payload.Strategy.Symbol,
+		Side:          side,
+		Amount:        amount,
+		Phase:         intent.PhaseOrdering,
+		At:            time.Now().UTC(),
+	}
+	_, end := run.StartSpan(ctx, "intent.write")
+	err = st.SaveIntent(ctx, *in)
+	end()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write order intent: %w", err)
+	}
+	return in, nil
+}
+
+// settleIntent records what the order request returned: the order once the
+// exchange took it, resolved when the request failed. After a timeout the
+// order may have gone through, so the intent is left for the next run to
+// look up. Failures are only logged; the order already happened.
+func settleIntent(ctx context.Context, payload *config.DCAPayload, in *intent.Intent, order *exchange.Order, err error) {
+	if in == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		saved := *order
+		saved.Raw = nil
+		in.Phase, in.Order = intent.PhaseOrdered, &saved
+	case exchange.IsTimeout(err):
+		return
+	default:
+		*in = in.Resolved(intent.OutcomeFailed, time.Now())
+	}
+	st, err := newStatus(ctx, payload)
+	if err == nil {
+		err = st.SaveIntent(ctx, *in)
+	}
+	if err != nil {
+		run.Warn(ctx, "intent", "save", err)
+	}
+}
+
+// completeIntent resolves the intent of a run that placed an order once its
+// records are saved. It is best effort, like saving them; an intent left
+// ordered is recovered from the order it holds. An order pending settlement
+// stays ordered for the next run to record its fill.
+func completeIntent(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	if res.Order == nil || res.DryRun || res.PendingSettlement {
+		return
+	}
+	st, err := newStatus(ctx, payload)
+	if err != nil || st == nil {
+		return
+	}
+	in, err := st.Intent(ctx, res.Exchange, res.Symbol)
+	if err == nil && in != nil && in.Pending() && in.ExecutionID == res.ExecutionID {
+		err = st.SaveIntent(ctx, in.Resolved(intent.OutcomeCompleted, time.Now()))
+	}
+	if err != nil {
+		run.Warn(ctx, "intent", "resolve", err)
+	}
+}
+
+// recoverIntent finishes the order of an earlier run on this venue that
+// died before saving its records: a filled order gets its result published
+// and its notification sent, one that never reached the exchange is only
+// logged. The intent is resolved before either, so a recovery is never
+// repeated. While the order cannot be found the run is skipped rather
+// than risk buying twice, up to intent.TTL; an older intent is reported
+// and given up.
+func recoverIntent(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) (*guard.Skip, error) {
+	if payload.Flags.DryRun {
+		return nil, nil
+	}
+	st, err := newStatus(ctx, payload)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	in, err := st.Intent(ctx, payload.Exchange.Name, payload.Strategy.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order intent: %w", err)
+	}
+	if in == nil || !in.Pending() {
+		return nil, nil
+	}
+
+	log.Printf("♻️ Execution %s left order %s pending; looking it up", in.ExecutionID, in.ClientOrderID)
+	spanCtx, end := run.StartSpan(ctx, "intent.recover")
+	rec := intent.Resolve(spanCtx, exc, *in, time.Now())
+	end()
+	res.Recovery = &rec
+	if rec.Blocking() {
+		return &guard.Skip{
+			Guard:  "recovery",
+			Reason: fmt.Sprintf("order %s of execution %s is unresolved: %s", in.ClientOrderID, in.ExecutionID, rec.Reason),
+		}, nil
+	}
+
+	if err := st.SaveIntent(ctx, in.Resolved(rec.Outcome, time.Now())); err != nil {
+		return nil, fmt.Errorf("failed to resolve order intent: %w", err)
+	}
+	switch rec.Outcome {
+	case intent.OutcomeFilled:
+		finishRecovered(ctx, payload, rec)
+	case intent.OutcomeFailed:
+		run.Warn(ctx, "intent", "recover", fmt.Errorf("order %s of execution %s, pending settlement, did not fill: %s", in.ClientOrderID, in.ExecutionID, rec.Reason))
+	case intent.OutcomeNotPlaced:
+		log.Printf("♻️ Order %s of execution %s never reached the exchange", in.ClientOrderID, in.ExecutionID)
+	case intent.OutcomeStale:
+		run.Warn(ctx, "intent", "recover", fmt.Errorf("gave up on order %s of execution %s, %s; check the exchange and reconcile", in.ClientOrderID, in.ExecutionID, rec.Reason))
+	}
+	return nil, nil
+}
+
+// finishRecovered publishes the result the earlier run never did, marked
+// recovered, and sends its missed post-trade notification
+func finishRecovered(ctx context.Context, payload *config.DCAPayload, rec intent.Recovery) {
+	in, order := rec.Intent, rec.Order
+	prev := &result.ExecutionResult{
+		SchemaVersion: result.SchemaVersion,
+		ExecutionID:   in.ExecutionID,
+		Status:        result.StatusExecuted,
+		Mode:          config.ModeDCA,
+		Exchange:      in.Exchange,
+		Account:       payload.Account,
+		Symbol:        in.Symbol,
+		QuoteAmount:   order.Quantity.Mul(order.Price).String(),
+		Order:         order,
+		Recovered:     true,
+		StartedAt:     in.At,
+		FinishedAt:    time.Now().UTC(),
+	}
+	if in.Side == "buy" {
+		prev.QuoteAmount = in.Amount.String()
+	}
+	log.Printf("♻️ Recovered order %s of execution %s: %s %s %s @ %s", order.ID, in.ExecutionID,
+		in.Side, describeQuantity(ctx, in.Symbol, order.Quantity), in.Symbol, describePrice(ctx, in.Symbol, order.Price))
+	publishResult(ctx, payload, prev)
+
+	verb := "Bought"
+	if in.Side == "sell" {
+		verb = "Sold"
+	}
+	origin := "recovered from an interrupted run"
+	if in.Order != nil && !in.Order.Settled() {
+		origin = "settled after its run"
+	}
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPostTrade,
+		Symbol:   in.Symbol,
+		Notional: order.Quantity.Mul(order.Price),
+		Summary: fmt.Sprintf("♻️ %s %s %s for %s (%s)", verb,
+			describeQuantity(ctx, in.Symbol, order.Quantity), in.Symbol, describeQuote(ctx, in.Symbol, order.Quantity.Mul(order.Price)), origin),
+		Details: []notify.Detail{
+			{Label: "Order ID", Value: order.ID},
+			{Label: "Price", Value: describePrice(ctx, in.Symbol, order.Price)},
+			{Label: "Status", Value: order.Status},
+			{Label: "Execution ID", Value: in.ExecutionID},
+			{Label: "Ordered At", Value: in.At.Format(time.RFC3339)},
+		},
+	})
+}
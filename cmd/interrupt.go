@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// interruptGrace bounds how long a local run may take to wrap up after
+// Ctrl-C before the process exits anyway
+const interruptGrace = 30 * time.Second
+
+// interruptContext returns a context canceled on SIGINT or SIGTERM, for
+// local runs and subcommands. The first signal lets the run wrap up,
+// recording and notifying what it did; a second one, or the grace period
+// running out, exits at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		log.Printf("🛑 Interrupted; finishing up (Ctrl-C again to quit now)")
+		cancel()
+		// Back to the default handling, so a second signal kills the process
+		signal.Stop(signals)
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-time.After(interruptGrace):
+			log.Printf("⏱️ Did not finish within %s; quitting", interruptGrace)
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		close(done)
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/dynamo"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/execlock"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// localLocks holds the idempotency locks of local runs, which have no
+// other invocations to share them with
+var localLocks = execlock.NewMemoryStore()
+
+// windowLock is the idempotency lock a run took for its window
+type windowLock struct {
+	store       execlock.Store
+	key         string
+	executionID string
+}
+
+// takeLock takes the idempotency lock of the run's window before it
+// trades. A run whose window another run took is skipped, with that run's
+// lock and order in res. When the lock table cannot be reached the run
+// fails, or under idempotency.failOpen goes ahead unlocked with a warning.
+// Dry runs take no lock.
+func takeLock(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) (*windowLock, *guard.Skip, error) {
+	cfg := payload.Idempotency
+	if cfg == nil {
+		return nil, nil, nil
+	}
+	if payload.Flags.DryRun {
+		log.Printf("🧪 DRY RUN: not taking the idempotency lock")
+		return nil, nil, nil
+	}
+
+	now := time.Now()
+	lock := execlock.Lock{Key: payload.LockKey(now), ExecutionID: run.ID(ctx), At: now.UTC(), ExpiresAt: now.Add(cfg.TTL()).UTC()}
+	store, err := newLockStore(ctx, cfg)
+	var held *execlock.Lock
+	if err == nil {
+		_, end := run.StartSpan(ctx, "idempotency.lock")
+		held, err = store.Acquire(ctx, lock)
+		end()
+	}
+	switch {
+	case errors.Is(err, execlock.ErrHeld):
+		res.Duplicate = held
+		order := held.OrderID
+		if order == "" {
+			order = "not recorded yet"
+		}
+		return nil, &guard.Skip{Guard: "idempotency", Reason: fmt.Sprintf("already executed for this window (%s) by run %s, order %s", lock.Key, held.ExecutionID, order)}, nil
+	case err != nil && cfg.FailOpen:
+		run.Warn(ctx, "idempotency", "lock", fmt.Errorf("running without lock %s: %w", lock.Key, err))
+		return nil, nil, nil
+	case err != nil:
+		return nil, nil, fmt.Errorf("failed to take idempotency lock %s: %w", lock.Key, err)
+	}
+	log.Printf("🔒 Took idempotency lock key=%s ttl=%s", lock.Key, cfg.TTL())
+	return &windowLock{store: store, key: lock.Key, executionID: lock.ExecutionID}, nil, nil
+}
+
+// newLockStore returns the store of the idempotency locks: the DynamoDB
+// table wherever other invocations, workers or replicas may run the
+// strategy too, the process for local runs
+func newLockStore(ctx context.Context, cfg *config.IdempotencyConfig) (execlock.Store, error) {
+	if !sharedRuntime() {
+		return localLocks, nil
+	}
+	awsCfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if err := checkAWSAccess(ctx, awsCfg); err != nil {
+		rt, _ := env.DetectRuntime()
+		return nil, fmt.Errorf("idempotency locks are kept in the DynamoDB table %s, which %s cannot reach: %w", cfg.Table, rt, err)
+	}
+	return execlock.NewDynamoStore(dynamo.New(awsCfg), cfg.Table), nil
+}
+
+// checkAWSAccess reports what the AWS config lacks to call DynamoDB. Lambda
+// and AWS containers get a region and credentials from the platform; Cloud
+// Functions and Azure Functions need them set, e.g. AWS_REGION,
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+func checkAWSAccess(ctx context.Context, awsCfg aws.Config) error {
+	if awsCfg.Region == "" {
+		return fmt.Errorf("no AWS region; set AWS_REGION")
+	}
+	if awsCfg.Credentials == nil {
+		return fmt.Errorf("no AWS credentials; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if _, err := awsCfg.Credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("no AWS credentials; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY: %w", err)
+	}
+	return nil
+}
+
+// warnOutsideAWS notes at startup on Cloud Functions and Azure Functions
+// that the idempotency lock table needs AWS settings the platform does not
+// provide
+func warnOutsideAWS(rt env.Runtime) {
+	if rt != env.RuntimeGCF && rt != env.RuntimeAzure {
+		return
+	}
+	if os.Getenv("AWS_REGION") == "" {
+		log.Printf("⚠️ AWS_REGION is not set on %s: payloads with idempotency fail until AWS_REGION and AWS credentials are set for their DynamoDB table", rt)
+	}
+}
+
+// sharedRuntime reports whether state the runs share, such as locks and
+// rate-limit buckets, must live outside the process: under every runtime
+// but local runs, whose process is the only one. An undetectable runtime
+// counts as shared.
+func sharedRuntime() bool {
+	rt, err := env.DetectRuntime()
+	return err != nil || rt != env.RuntimeLocal
+}
+
+// finish records in the lock the order the run placed, so later runs of
+// the window return it. A run that placed none releases the lock for a
+// later run to trade, unless its order may have gone through. Failures are
+// warnings.
+func (l *windowLock) finish(ctx context.Context, res *result.ExecutionResult, runErr error) {
+	if l == nil {
+		return
+	}
+	switch {
+	case res.Order != nil:
+		saved := *res.Order
+		saved.Raw = nil
+		order, err := json.Marshal(saved)
+		if err == nil {
+			err = l.store.Complete(ctx, l.key, l.executionID, saved.ID, order)
+		}
+		if err != nil {
+			run.Warn(ctx, "idempotency", "complete", err)
+		}
+	case errors.Is(runErr, exchange.ErrOrderOutcomeUnknown), errors.Is(runErr, exchange.ErrOrderPlaced):
+		log.Printf("🔒 Keeping idempotency lock key=%s: the order may have gone through", l.key)
+	default:
+		if err := l.store.Release(ctx, l.key, l.executionID); err != nil {
+			run.Warn(ctx, "idempotency", "release", fmt.Errorf("lock %s stays taken until it expires: %w", l.key, err))
+		}
+	}
+}
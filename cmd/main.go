@@ -3,49 +3,477 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Lambda runtimes may not ship zoneinfo for strategy timezones
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/shopspring/decimal"
 	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/account"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/audit"
 	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/dust"
+	"github.com/sudowanderer/dca-bot-go/internal/egress"
+	"github.com/sudowanderer/dca-bot-go/internal/entrypoint"
 	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/kmspayload"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/publish"
+	"github.com/sudowanderer/dca-bot-go/internal/ratelimit"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/retry"
+	"github.com/sudowanderer/dca-bot-go/internal/route"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+	"github.com/sudowanderer/dca-bot-go/internal/stoploss"
+	"github.com/sudowanderer/dca-bot-go/internal/threshold"
 )
 
+// localTimeout bounds a local run the way the function timeout bounds a
+// Lambda run, unless --timeout is given
+const localTimeout = 5 * time.Minute
+
+// serverTimeout bounds a run on the HTTP server; a plain container has no
+// function timeout of its own
+const serverTimeout = 5 * time.Minute
+
+// eventBridgePayloadPathEnv names where in an EventBridge event the payload
+// is, as dotted keys; "detail" unless set
+const eventBridgePayloadPathEnv = "DCA_EVENTBRIDGE_PAYLOAD_PATH"
+
 func main() {
-	if env.IsLambdaEnvironment() {
-		// normal Lambda entrypoint
-		lambda.Start(handleRequest)
+	rt, err := env.DetectRuntime()
+	if err != nil {
+		log.Fatalf("failed to detect runtime: %v", err)
+	}
+	switch {
+	case rt == env.RuntimeLambda:
+		startLambda()
+	case rt == env.RuntimeSQSWorker:
+		runWorker()
+	case rt.Serves():
+		serve(rt)
+	default:
+		runLocal()
+	}
+}
+
+// startLambda runs the handler under the Lambda runtime
+func startLambda() {
+	unwrap := handler.EventBridgeUnwrapper
+	if path := os.Getenv(eventBridgePayloadPathEnv); path != "" {
+		unwrap = handler.EventBridgePathUnwrapper(path)
+	}
+	extra := []handler.Middleware{handler.UnwrapEnvelope(unwrap)}
+	if raw := os.Getenv(webhookPayloadEnv); raw != "" {
+		// Function URL invocations carry a TradingView alert, not a payload
+		trigger, err := newTradingViewTrigger(raw)
+		if err != nil {
+			log.Fatalf("failed to set up TradingView webhook: %v", err)
+		}
+		extra = append(extra, handler.UnwrapEnvelope(trigger.Unwrapper()))
+	}
+	invoke := entrypoint.Lambda(newHandler(extra...))
+	if raw := os.Getenv(telegramBotPayloadEnv); raw != "" {
+		// Function URL invocations from Telegram carry a bot command
+		h, err := withTelegramWebhook(raw, invoke)
+		if err != nil {
+			log.Fatalf("failed to set up Telegram webhook: %v", err)
+		}
+		lambda.Start(h)
+		return
+	}
+	lambda.Start(invoke)
+}
+
+// serve runs the handler behind an HTTP server on Cloud Functions, Azure
+// Functions and plain containers, until the platform stops it
+func serve(rt env.Runtime) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("🌐 Running on %s, reading payloads from HTTP requests", rt)
+	warnOutsideAWS(rt)
+	h := entrypoint.HTTP(newHandler(handler.Timeout(serverTimeout)))
+	if err := entrypoint.Serve(ctx, env.ListenAddr(rt), h); err != nil {
+		log.Fatalf("HTTP server failed: %v", err)
+	}
+}
+
+// runLocal runs a local subcommand, or the handler once on local_event.json
+func runLocal() {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	// --- local subcommands ---
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(ctx, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", os.Args[1], err)
+		}
 		return
 	}
 
 	// --- local testing mode ---
+	//
+	//	[--timeout 15s] [--out result.json]
+	fs := flag.NewFlagSet("local", flag.ExitOnError)
+	timeout := fs.Duration("timeout", localTimeout, "bound the run like the Lambda function timeout")
+	out := fs.String("out", "", "also write the result to this file")
+	fs.Parse(os.Args[1:])
+
 	log.Println("🌱 Running in local mode, reading local_event.json …")
 
-	data, err := os.ReadFile("local_event.json")
+	// The result goes to stdout, the log to stderr
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			log.Fatalf("failed to create result file: %v", err)
+		}
+		defer f.Close()
+		w = io.MultiWriter(os.Stdout, f)
+	}
+	if err := entrypoint.Local(ctx, newHandler(handler.Timeout(*timeout)), "local_event.json", w); err != nil {
+		log.Fatalf("error in handleRequest: %v", err)
+	}
+}
+
+// newHandler builds the middleware chain shared by every entrypoint; extra
+// middleware specific to one entrypoint runs inside it, just outside the
+// KMS unwrap
+func newHandler(extra ...handler.Middleware) handler.Handler {
+	chain := []handler.Middleware{
+		handler.ExecutionID(),
+		handler.RecordTiming(),
+		handler.MeterCosts(),
+		handler.CollectWarnings(),
+		handler.NotificationScope(),
+		handler.FlushNotifications(),
+		handler.NotifyOnError(notifyError),
+		handler.Log(),
+		handler.Classify(),
+		handler.Recover(),
+		handler.DetectSource(),
+	}
+	chain = append(chain, extra...)
+	// KMS envelopes are decrypted innermost: every middleware above, the
+	// extra ones included, only ever sees the envelope, and DetectSource
+	// reads the raw event as it needs to
+	chain = append(chain, handler.UnwrapEnvelope(kmspayload.Unwrapper(newKMSClient)))
+	return handler.Chain(handleRequest, chain...)
+}
+
+// newEventBridgeClient creates an EventBridge client from the default AWS configuration
+func newEventBridgeClient(ctx context.Context) (publish.EventBridgeAPI, error) {
+	cfg, err := loadAWSConfig(ctx)
 	if err != nil {
-		log.Fatalf("failed to read event file: %v", err)
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	return eventbridge.NewFromConfig(cfg), nil
+}
 
-	if err := handleRequest(context.Background(), data); err != nil {
-		log.Fatalf("error in handleRequest: %v", err)
+// newSchedulerClient creates an EventBridge Scheduler client from the default AWS configuration
+func newSchedulerClient(ctx context.Context) (retry.SchedulerAPI, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return retry.NewClient(cfg), nil
+}
+
+// newKMSClient creates a KMS client from the default AWS configuration
+func newKMSClient(ctx context.Context) (kmspayload.KMSAPI, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return kms.NewFromConfig(cfg), nil
+}
+
+// newAuditLog creates the audit log for integrations.auditLog; it is nil
+// when auditing is off
+func newAuditLog(ctx context.Context, cfg *config.AuditLogConfig) (*audit.Logger, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return audit.NewLogger(audit.NewS3Store(client, cfg.Bucket), cfg.Prefix, cfg.Async, cfg.Fatal()), nil
+}
+
+// closeAuditLog flushes the audit log. An incomplete log fails the run
+// unless integrations.auditLog.onFailure is "warn".
+func closeAuditLog(ctx context.Context, cfg *config.AuditLogConfig, l *audit.Logger, runErr error) error {
+	if l == nil {
+		return runErr
+	}
+	err := l.Close()
+	if err == nil {
+		return runErr
+	}
+	if !cfg.Fatal() {
+		run.Warn(ctx, "audit", "write", err)
+		return runErr
+	}
+	return errors.Join(runErr, fmt.Errorf("audit log incomplete: %w", err))
+}
+
+// notifyError reports a failed run
+func notifyError(ctx context.Context, err error) {
+	summary := "🚨 DCA run failed"
+	switch {
+	case run.IsSimulated(err):
+		summary = "🧪 SIMULATED: DCA run failed"
+	case failure.Classify(err) == failure.CodeCanceled:
+		summary = "🛑 DCA run canceled"
 	}
+	dispatch(ctx, notify.Event{
+		Type:    notify.EventError,
+		Summary: summary,
+		Details: failure.Details(err),
+	})
+}
+
+// newDispatcher creates the notification dispatcher for a notifications config
+func newDispatcher(ctx context.Context, cfg config.NotificationConfig) *notify.Dispatcher {
+	notifiers := []notify.Notifier{notify.LogNotifier{}}
+	if cfg.Telegram != nil {
+		if telegram := newTelegramNotifier(ctx, cfg.Telegram); telegram != nil {
+			notifiers = append(notifiers, telegram)
+		}
+	}
+	if cfg.Webhook != nil {
+		if webhook, err := newWebhook(ctx, cfg.Webhook); err != nil {
+			run.Warn(ctx, "webhook", "secrets", err)
+		} else {
+			notifiers = append(notifiers, webhook)
+		}
+	}
+	return notify.NewDispatcher(cfg, notifiers...)
+}
+
+// newWebhook creates the notifications.webhook notifier, resolving every
+// signing secret so that receivers on a previous one keep verifying
+func newWebhook(ctx context.Context, cfg *config.WebhookConfig) (*notify.Webhook, error) {
+	keys := make([]notify.WebhookKey, 0, len(cfg.Secrets))
+	for _, secret := range cfg.Secrets {
+		value, err := resolveSecret(ctx, secret.CredentialSource, "hmacSecret")
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", secret.KeyID, err)
+		}
+		keys = append(keys, notify.WebhookKey{ID: secret.KeyID, Secret: value})
+	}
+	return notify.NewWebhook(&http.Client{Timeout: config.WebhookTimeout}, cfg.URL, keys), nil
+}
+
+// routeStrategy sends the strategy's events with its notifications
+// override, if it has one, creating the notifier of its Telegram chat with
+// newTelegram. With exchange.accounts each account's events get their own
+// route.
+func routeStrategy(d *notify.Dispatcher, payload *config.DCAPayload, newTelegram func(*config.TelegramConfig) notify.Notifier) {
+	for _, a := range payload.Exchange.Accounts {
+		routeStrategy(d, payload.ForAccount(a), newTelegram)
+	}
+	override := payload.Strategy.Notifications
+	if override == nil {
+		return
+	}
+	var notifiers []notify.Notifier
+	if override.Telegram != nil {
+		if telegram := newTelegram(override.Telegram); telegram != nil {
+			notifiers = append(notifiers, telegram)
+		}
+	}
+	d.Route(account.RouteKey(payload), *override, notifiers...)
+}
+
+// dispatch sends a notification through the run's dispatcher, or with default
+// settings when the payload could not be parsed. Failing to notify never
+// fails the run.
+func dispatch(ctx context.Context, event notify.Event) {
+	dispatcher := notify.FromContext(ctx)
+	if dispatcher == nil {
+		dispatcher = newDispatcher(ctx, config.NotificationConfig{})
+	}
+	// Delivery failures are recorded as run warnings by the dispatcher
+	dispatcher.Dispatch(ctx, event)
 }
 
 func handleRequest(ctx context.Context, event json.RawMessage) error {
 	// Parse the new DCA payload format
+	_, end := run.StartSpan(ctx, "payload.parse")
 	payload, err := config.ParseDCAPayload(event)
+	end()
+	if err != nil {
+		return failure.Mark(failure.CodeConfigInvalid, fmt.Errorf("failed to parse payload: %w", err))
+	}
+	if err := exchange.CheckCapabilities(payload); err != nil {
+		return failure.Mark(failure.CodeConfigInvalid, fmt.Errorf("invalid payload: %w", err))
+	}
+
+	dispatcher := newDispatcher(ctx, payload.Notifications)
+	routeStrategy(dispatcher, payload, func(tg *config.TelegramConfig) notify.Notifier {
+		return newTelegramNotifier(ctx, tg)
+	})
+	if st, err := newStatus(ctx, payload); err != nil {
+		run.Warn(ctx, "notify", "dedup", err)
+	} else if st != nil {
+		dispatcher.SetDeliveryStore(st)
+	}
+	notify.SetDispatcher(ctx, dispatcher)
+	ctx = money.WithFormatter(ctx, money.New(payload.Notifications.Language, payload.Notifications.DisplayPrecision))
+	warnings := run.WarningsFrom(ctx)
+	warnings.SetStrict(payload.Flags.StrictMode)
+	for _, f := range payload.Lint() {
+		run.AddWarning(ctx, run.Warning{Subsystem: "config", Operation: "audit", Error: f.String()})
+	}
+	ctx = egress.WithCache(ctx)
+	usage := payload.Exchange.APIUsage
+	ctx = ratelimit.WithUsage(ctx, ratelimit.NewUsage(usage.Limit(payload.Exchange.Name), usage.Fraction()))
+	if stage := payload.Flags.SimulateFailure; stage != "" {
+		log.Printf("🧪 Simulating a failure at the %s stage (flags.simulateFailure)", stage)
+		ctx = run.WithSimulatedFailure(ctx, stage)
+	}
+
+	auditLog, err := newAuditLog(ctx, payload.Integrations.AuditLog)
+	if err != nil {
+		return fmt.Errorf("failed to start audit log: %w", err)
+	}
+	if auditLog != nil {
+		ctx = audit.WithLogger(ctx, auditLog)
+	}
+
+	res := result.New(ctx, payload)
+	err = execute(ctx, payload, res)
+	if err != nil {
+		res.Step = run.LastStep(ctx)
+	}
+	// An interrupted run still records its outcome and completes its intent
+	ctx, cancel := run.WrapUp(ctx)
+	defer cancel()
+	if err == nil {
+		// A simulated notification failure is only a warning where it happens
+		err = run.SimulatedFailure(ctx)
+	}
+	if err != nil && exchange.IsRetriable(err) {
+		err = deferRun(ctx, payload, res, err)
+	}
+	err = closeAuditLog(ctx, payload.Integrations.AuditLog, auditLog, err)
+	recordConfig(ctx, payload, res)
+	res.Warnings = warnings.List()
+	err = warnings.Promote(err)
+	res.Finish(err)
+	recordTiming(ctx, payload, res)
+	recordCost(ctx, payload, res)
+	result.Record(ctx, res)
+
+	// Publishing is best effort; it fails the run only in strict mode
+	publishResult(ctx, payload, res)
+	sendHeartbeat(ctx, payload, res)
+	saveStatus(ctx, payload, res)
+	completeIntent(ctx, payload, res)
+
+	return warnings.Promote(err)
+}
+
+// execute runs the strategy for a parsed payload, recording the outcome in res
+func execute(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	// An expired payload fails, and notifies; one not yet valid skips quietly
+	skip, err := guard.Validity(payload, time.Now())
+	if err != nil {
+		return failure.Mark(failure.CodeConfigInvalid, err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		return nil
+	}
+	// The pause switch set from Telegram skips every mode
+	skip, err = checkPaused(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("failed to read pause switch: %w", err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		sendSkipNotification(ctx, payload, skip)
+		monitorWhilePaused(ctx, payload)
+		return nil
+	}
+	// The approval request is the notification of a held run
+	skip, err = checkApproval(ctx, payload)
 	if err != nil {
-		return fmt.Errorf("failed to parse payload: %w", err)
+		return fmt.Errorf("approval check failed: %w", err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		return nil
+	}
+	if err := checkEgress(ctx, payload); err != nil {
+		return err
+	}
+	if len(payload.Exchange.Accounts) > 0 {
+		return executeAccounts(ctx, payload, res)
+	}
+
+	switch payload.Mode {
+	case config.ModeDust:
+		return executeDust(ctx, payload, res)
+	case config.ModeReconcile:
+		return executeReconcile(ctx, payload, res)
+	case config.ModeReport:
+		return executeReport(ctx, payload, res)
+	}
+	if payload.Strategy.Native() {
+		return executeNative(ctx, payload, res)
+	}
+
+	if payload.Strategy.Budgeted() {
+		skip, err := applyBudget(ctx, payload, res, time.Now())
+		if err != nil {
+			return fmt.Errorf("monthly budget failed: %w", err)
+		}
+		if skip != nil {
+			log.Printf("⏭️ Run %s", skip)
+			res.Skip = skip
+			sendSkipNotification(notify.WithStrategy(ctx, account.RouteKey(payload)), payload, skip)
+			return nil
+		}
 	}
 
 	log.Printf("📊 Parsed DCA configuration:")
 	log.Printf("   Exchange: %s", payload.Exchange.Name)
+	for _, venue := range payload.Failover {
+		log.Printf("   Failover Exchange: %s", venue.Name)
+	}
 	log.Printf("   Symbol: %s", payload.Strategy.Symbol)
-	log.Printf("   Quote Amount: %s", payload.Strategy.QuoteAmount)
+	log.Printf("   Side: %s", payload.Strategy.Side)
+	if payload.Strategy.PercentSized() {
+		log.Printf("   Quote Amount: %s%% of the free balance", payload.Strategy.QuoteAmountPercent)
+	} else {
+		log.Printf("   Quote Amount: %s", payload.Strategy.QuoteAmount)
+	}
 	log.Printf("   Balance Threshold: %s", payload.Strategy.BalanceThreshold)
 	log.Printf("   Order Type: %s", payload.Strategy.OrderType)
 	log.Printf("   Dry Run: %v", payload.Flags.DryRun)
@@ -64,129 +492,919 @@ func handleRequest(ctx context.Context, event json.RawMessage) error {
 	log.Printf("🚀 DCA Bot processing %s on %s (DryRun: %v)",
 		unified.Symbol, unified.Exchange, unified.DryRun)
 
-	// Create exchange instance
-	exchange, err := exchange.NewExchange(payload)
+	ctx, endStrategy := run.StartStrategySpan(ctx, account.RouteKey(payload))
+	defer endStrategy()
+	ctx = notify.WithStrategy(ctx, account.RouteKey(payload))
+
+	// A tampered or corrupted withdrawal address fails the run before any
+	// order; there is no withdrawal step yet to check it right before
+	if err := verifyWithdrawal(ctx, payload); err != nil {
+		return fmt.Errorf("withdrawal check failed: %w", err)
+	}
+
+	// Check calendar before touching the exchange; a skip is not a failure
+	_, end := run.StartSpan(ctx, "preflight")
+	skip, err = guard.Calendar(payload.Strategy, time.Now())
+	end()
+	if err != nil {
+		return fmt.Errorf("calendar check failed: %w", err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		sendSkipNotification(ctx, payload, skip)
+		return nil
+	}
+
+	// A late invocation is skipped with a warning: the slot it stood for is gone
+	skip, err = guard.Window(payload.Strategy, time.Now())
+	if err != nil {
+		return fmt.Errorf("execution window check failed: %w", err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		run.AddWarning(ctx, run.Warning{Subsystem: "schedule", Operation: "invocation", Error: skip.Reason})
+		sendSkipNotification(ctx, payload, skip)
+		return nil
+	}
+
+	if !payload.Flags.DryRun {
+		warnNativePlans(ctx, payload)
+	}
+
+	// One run per window of payload.idempotency
+	lock, skip, err := takeLock(ctx, payload, res)
+	if err != nil {
+		return err
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		return nil
+	}
+
+	// Run the strategy on the first available exchange
+	failovers, err := exchange.RunWithFailover(ctx, payload.Venues(), func(venue config.ExchangeConfig) error {
+		return executeOnVenue(ctx, payload.WithVenue(venue), res)
+	})
+	res.Failovers = failovers
+	lockCtx, cancel := run.WrapUp(ctx)
+	lock.finish(lockCtx, res, err)
+	cancel()
+	if len(failovers) > 0 && err == nil {
+		log.Printf("🔀 Executed on %s after failing over from %d exchange(s)", res.Exchange, len(failovers))
+	}
+	return err
+}
+
+// executeAccounts runs the strategy once per account in exchange.accounts,
+// each with its own pause switch and /status result. A failed account is
+// reported on its own route and does not stop the others.
+func executeAccounts(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	err := account.Run(ctx, payload, res, func(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+		err := execute(ctx, payload, res)
+		if err != nil {
+			notifyError(ctx, err)
+		}
+		return err
+	})
+	for i, a := range payload.Exchange.Accounts {
+		saveStatus(ctx, payload.ForAccount(a), res.Accounts[i])
+		completeIntent(ctx, payload.ForAccount(a), res.Accounts[i])
+	}
+	return err
+}
+
+// executeDust converts leftover balances to the dust target, recording what
+// was swept in res. In a dry run the balances are only listed.
+func executeDust(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	ctx = withRateLimit(ctx, payload)
+	ctx, err := withExtraHeaders(ctx, payload)
+	if err != nil {
+		return err
+	}
+	log.Printf("🧹 Dust sweep on %s to %s (DryRun: %v)", payload.Exchange.Name, payload.Dust.Target, payload.Flags.DryRun)
+
+	conv, err := newDustConverter(ctx, payload.Exchange)
+	if err != nil {
+		return fmt.Errorf("failed to create dust converter: %w", err)
+	}
+	report, err := dust.Sweep(ctx, conv, *payload.Dust, payload.Strategy.Symbol, payload.Flags.DryRun)
 	if err != nil {
-		return fmt.Errorf("failed to create exchange: %w", err)
+		return fmt.Errorf("dust sweep failed: %w", err)
+	}
+	res.Dust = report
+
+	if len(report.Selected) == 0 {
+		log.Printf("🧹 No dust to convert")
+		return nil
+	}
+
+	f := money.FromContext(ctx)
+	details := make([]notify.Detail, 0, len(report.Selected)+1)
+	for _, a := range report.Selected {
+		details = append(details, notify.Detail{Label: a.Asset, Value: fmt.Sprintf("%s (~%s)", f.Number(a.Free, a.Asset), f.Amount(a.Value, report.Target))})
+	}
+	details = append(details, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(report.DryRun)})
+
+	summary := fmt.Sprintf("🧹 Swept %d dust balance(s) into %s", len(report.Selected), f.Amount(report.Received, report.Target))
+	if report.DryRun {
+		summary = fmt.Sprintf("🧪 DRY RUN: would sweep %d dust balance(s) into %s", len(report.Selected), report.Target)
+	}
+	log.Printf("%s", summary)
+	for _, d := range details {
+		log.Printf("   %s: %s", d.Label, d.Value)
+	}
+	dispatch(ctx, notify.Event{
+		Type:    notify.EventPostTrade,
+		Summary: summary,
+		Details: details,
+	})
+	return nil
+}
+
+// newDustConverter creates the dust converter for an exchange. Dry runs use
+// it too, but only to list balances.
+func newDustConverter(ctx context.Context, venue config.ExchangeConfig) (exchange.DustConverter, error) {
+	switch venue.Name {
+	case "binance":
+		creds, err := exchangeCredentials(ctx, venue)
+		if err != nil {
+			return nil, err
+		}
+		return exchange.NewBinanceDust(creds.APIKey, creds.APISecret), nil
+	default:
+		return nil, fmt.Errorf("dust conversion is not supported on %s", venue.Name)
+	}
+}
+
+// executeOnVenue runs the strategy against the single exchange in payload
+func executeOnVenue(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	res.Exchange = payload.Exchange.Name
+	ctx = withRateLimit(ctx, payload)
+	ctx, err := withExtraHeaders(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	exc, err := prepareVenue(ctx, payload)
+	if errors.Is(err, exchange.ErrTradingSuspended) {
+		// A halt is not a failure of the run; it ends when the exchange resumes trading
+		skip := &guard.Skip{
+			Guard:  "trading",
+			Reason: fmt.Sprintf("trading suspended for %s on %s", payload.Strategy.Symbol, payload.Exchange.Name),
+		}
+		log.Printf("⏭️ Run %s", skip)
+		log.Printf("   %v", err)
+		res.Skip = skip
+		sendSkipNotification(ctx, payload, skip)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Finish the order of a run that died before saving its records
+	skip, err := recoverIntent(ctx, payload, exc, res)
+	if err != nil {
+		return fmt.Errorf("failed to recover previous order: %w", err)
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		sendSkipNotification(ctx, payload, skip)
+		return nil
 	}
 
 	// Run DCA strategy
-	if err := runDCAStrategy(ctx, payload, exchange); err != nil {
+	if err := runDCAStrategy(ctx, payload, exc, res); err != nil {
 		return fmt.Errorf("DCA strategy failed: %w", err)
 	}
 
 	return nil
 }
 
-// runDCAStrategy executes the DCA trading strategy
-func runDCAStrategy(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) error {
+// prepareVenue creates the exchange for the venue in payload and checks it
+// lists the strategy's symbol, routing around it when allowed
+func prepareVenue(ctx context.Context, payload *config.DCAPayload) (exchange.Exchange, error) {
+	ctx, end := run.StartSpan(ctx, "preflight")
+	defer end()
+
+	if err := run.Simulate(ctx, config.SimulateCredentials); err != nil {
+		return nil, fmt.Errorf("failed to create exchange: %w", err)
+	}
+
+	// Create exchange instance
+	exc, err := newExchange(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exchange: %w", err)
+	}
+
+	// Symbols are listed and halted per venue, so check before sizing anything
+	spanCtx, endCheck := run.StartSpan(ctx, "exchange.checkSymbol")
+	err = exchange.CheckTradable(spanCtx, exc, payload.Strategy.Symbol)
+	endCheck()
+	if errors.Is(err, exchange.ErrSymbolNotFound) && payload.Strategy.AllowRouting {
+		exc, err = routeExchange(ctx, payload, exc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("symbol %s not available on %s: %w", payload.Strategy.Symbol, payload.Exchange.Name, err)
+	}
+	return exc, nil
+}
+
+// deferRun hands a run that failed because the exchange was unavailable back
+// to its invocation source. EventBridge rule runs are re-scheduled when
+// integrations.retryScheduler is set; otherwise the error is returned so the
+// source's own retry or redrive runs it again.
+func deferRun(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult, runErr error) error {
+	source := run.SourceFrom(ctx)
+	cfg := payload.Integrations.RetryScheduler
+	if retry.Decide(source.Trigger, cfg) != retry.ActionSchedule {
+		log.Printf("🔁 Exchange unavailable; failing the %s invocation so it can be retried", source.Trigger)
+		return runErr
+	}
+
+	var functionARN string
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		functionARN = lc.InvokedFunctionArn
+	}
+	client, err := newSchedulerClient(ctx)
+	var at time.Time
+	if err == nil {
+		at, err = retry.NewScheduler(client, *cfg).Schedule(ctx, source.Event, functionARN, time.Now())
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to schedule retry: %v", err)
+		return runErr
+	}
+
+	res.Error = runErr.Error()
+	res.RetryAt = &at
+	state := "unavailable"
+	if exchange.IsMaintenance(runErr) {
+		state = "under maintenance"
+	}
+	log.Printf("🔁 Exchange %s; retry scheduled for %s", state, at.Format(time.RFC3339))
+	dispatch(ctx, notify.Event{
+		Type:    notify.EventError,
+		Symbol:  payload.Strategy.Symbol,
+		Summary: fmt.Sprintf("🛠️ %s: exchange %s, retry scheduled for %s UTC", payload.Strategy.Symbol, state, at.Format("15:04")),
+		Details: []notify.Detail{
+			{Label: "Exchange", Value: res.Exchange},
+			{Label: "Error", Value: runErr.Error()},
+		},
+	})
+	return nil
+}
+
+// routeExchange finds a route through one of the strategy's bridge assets
+// for a symbol exc does not list, and wraps exc to buy along it
+func routeExchange(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) (exchange.Exchange, error) {
+	base, quote, err := exchange.SplitSymbol(payload.Strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	_, canSell := exc.(exchange.MarketSeller)
+	r, err := route.Find(ctx, base, quote, payload.Strategy.RouteBridges, canSell, func(ctx context.Context, symbol string) (bool, error) {
+		return exchange.Listed(ctx, exc, symbol)
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔗 %s is not listed on %s; routing %s", payload.Strategy.Symbol, payload.Exchange.Name, r)
+	return route.NewExchange(exc, r), nil
+}
+
+// recordConfig adds the effective configuration to res, logging it when
+// flags.logConfig is set
+func recordConfig(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	snapshot, err := payload.Effective()
+	if err != nil {
+		run.Warn(ctx, "config", "snapshot", err)
+		return
+	}
+	res.Config = snapshot
+	if !payload.Flags.LogConfig {
+		return
+	}
+	log.Printf("🧾 Effective configuration:")
+	for _, line := range snapshot.Lines() {
+		log.Printf("   %s", line)
+	}
+}
+
+// recordTiming adds the run's timing breakdown to res, logging it as a
+// table when flags.logTiming is set
+func recordTiming(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	rec := run.RecorderFrom(ctx)
+	if rec == nil {
+		return
+	}
+	res.Timing = rec.Timing()
+	res.Timing.API = ratelimit.UsageFrom(ctx).Totals()
+	if !payload.Flags.LogTiming {
+		return
+	}
+	log.Printf("⏱️ Timing breakdown:")
+	for _, line := range res.Timing.Table() {
+		log.Printf("   %s", line)
+	}
+}
+
+// publishResult sends the run result to the configured integrations,
+// logging rather than returning failures
+func publishResult(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	eb := payload.Integrations.EventBridge
+	if eb == nil {
+		return
+	}
+
+	client, err := newEventBridgeClient(ctx)
+	if err == nil {
+		err = publish.NewEventBridge(client, *eb).Publish(ctx, res)
+	}
+	if err != nil {
+		run.Warn(ctx, "eventbridge", "publish", err)
+		return
+	}
+	log.Printf("📤 Published %s result to EventBridge bus %s", res.Status, eb.BusName)
+}
+
+// sendHeartbeat pings integrations.heartbeat with the run's outcome
+func sendHeartbeat(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	hb := payload.Integrations.Heartbeat
+	if hb == nil {
+		return
+	}
+
+	pingURL, err := resolveSecret(ctx, hb.URL, "url")
+	if err == nil {
+		client := &http.Client{Timeout: config.HeartbeatTimeout}
+		err = publish.NewHeartbeat(client, pingURL).Ping(ctx, res)
+	}
+	if err != nil {
+		run.Warn(ctx, "heartbeat", "ping", err)
+		return
+	}
+	log.Printf("💓 Sent %s heartbeat", res.Status)
+}
+
+// runDCAStrategy executes the DCA trading strategy, recording sizing and the order in res
+func runDCAStrategy(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) error {
+	if payload.Strategy.PercentSized() {
+		skip, err := applyPercent(ctx, payload, exc, res)
+		if err != nil {
+			return fmt.Errorf("percent sizing failed: %w", err)
+		}
+		if skip != nil {
+			log.Printf("⏭️ Run %s", skip)
+			res.Skip = skip
+			sendSkipNotification(ctx, payload, skip)
+			return nil
+		}
+	}
+	if payload.Flags.RampUp != nil {
+		record, err := applyRampUp(ctx, payload, res)
+		if err != nil {
+			return fmt.Errorf("ramp-up failed: %w", err)
+		}
+		defer record()
+	}
+
+	if payload.Strategy.Selling() {
+		return runSellStrategy(ctx, payload, exc, res)
+	}
 	log.Printf("🔍 Starting DCA strategy execution...")
 
+	if payload.Strategy.CircuitBreaker != nil {
+		skip, err := checkCircuitBreaker(ctx, payload, exc, res)
+		if err != nil {
+			return err
+		}
+		if skip != nil {
+			log.Printf("⏭️ Run %s", skip)
+			res.Skip = skip
+			sendSkipNotification(ctx, payload, skip)
+			return nil
+		}
+	}
+
+	// Never buy above the ceiling; a skip is not a failure
+	if payload.Strategy.MaxPrice != "" {
+		skip, err := checkMaxPrice(ctx, payload, exc, res)
+		if err != nil {
+			return err
+		}
+		if skip != nil {
+			log.Printf("⏭️ Run %s", skip)
+			res.Skip = skip
+			sendSkipNotification(ctx, payload, skip)
+			return nil
+		}
+	}
+
+	if payload.Strategy.DipMultipliers != nil {
+		if err := applyDip(ctx, payload, exc, res); err != nil {
+			return fmt.Errorf("dip multiplier failed: %w", err)
+		}
+	}
+
 	// Parse quote amount
-	quoteAmount, err := decimal.NewFromString(payload.Strategy.QuoteAmount)
+	requested, err := decimal.NewFromString(payload.Strategy.QuoteAmount)
 	if err != nil {
 		return fmt.Errorf("invalid quote amount: %w", err)
 	}
 
-	// Step 1: Place market buy order
+	// Apply fee handling to get the amount actually ordered
+	spanCtx, end := run.StartSpan(ctx, "sizing")
+	sz, err := sizeOrder(spanCtx, payload, exc, requested)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to size order: %w", err)
+	}
+	res.Sizing = sz
+	quoteAmount, err := applyTradingRules(ctx, payload, exc, sz.OrderAmount)
+	if err != nil {
+		return err
+	}
+	sz.OrderAmount = quoteAmount
+
+	if payload.Strategy.AutoTransfer {
+		if err := autoTransfer(ctx, payload, exc, quoteAmount, res); err != nil {
+			return err
+		}
+	}
+	if payload.Strategy.CheckPendingDeposits && !payload.Flags.DryRun {
+		skip, err := checkFunding(ctx, payload, exc, quoteAmount)
+		if err != nil {
+			return err
+		}
+		if skip != nil {
+			log.Printf("⏭️ Run %s", skip)
+			res.Skip = skip
+			sendSkipNotification(ctx, payload, skip)
+			return nil
+		}
+	}
+
+	// Step 1: Announce the order with the market it goes into; the
+	// heads-up is informational, nothing waits for a reply
+	observeTicker(ctx, exc, payload.Strategy.Symbol, res)
+	preTrade := append(sizingDetails(ctx, payload.Strategy.Symbol, res), tickerDetails(ctx, res)...)
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPreTrade,
+		Symbol:   payload.Strategy.Symbol,
+		Notional: quoteAmount,
+		Summary:  fmt.Sprintf("⏳ About to buy %s of %s", describeQuote(ctx, payload.Strategy.Symbol, quoteAmount), payload.Strategy.Symbol),
+		Details:  append(preTrade, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(payload.Flags.DryRun)}),
+	})
+	if err := waitAfterPreTrade(ctx, payload); err != nil {
+		return err
+	}
+
+	// Step 2: Place market buy order
 	if payload.Flags.DryRun {
-		log.Printf("🧪 DRY RUN: Simulating market buy order for %s %s", quoteAmount.String(), payload.Strategy.Symbol)
+		log.Printf("🧪 DRY RUN: Simulating market buy order for %s %s", describeQuote(ctx, payload.Strategy.Symbol, quoteAmount), payload.Strategy.Symbol)
 	} else {
-		log.Printf("📈 Placing market buy order: %s %s", quoteAmount.String(), payload.Strategy.Symbol)
+		log.Printf("📈 Placing market buy order: %s %s", describeQuote(ctx, payload.Strategy.Symbol, quoteAmount), payload.Strategy.Symbol)
 	}
 
-	order, err := exc.PlaceMarketBuyOrder(ctx, payload.Strategy.Symbol, quoteAmount)
-	if err != nil {
+	if err := run.Simulate(ctx, config.SimulateOrder); err != nil {
 		return fmt.Errorf("failed to place order: %w", err)
 	}
+	orderCtx := withIdempotencyKey(ctx, payload, "buy")
+	in, err := writeIntent(orderCtx, payload, exc, "buy", quoteAmount)
+	if err != nil {
+		return err
+	}
+	spanCtx, end = run.StartSpan(orderCtx, "exchange.placeOrder")
+	order, err := exchange.PlaceOnce(spanCtx, exc, payload.Strategy.Symbol, func(ctx context.Context) (*exchange.Order, error) {
+		return exchange.MarketBuy(ctx, exc, payload.Strategy.Symbol, exchange.QuoteSize(quoteAmount))
+	})
+	end()
+	var pending string
+	if err == nil && !order.Settled() && len(order.Legs) == 0 {
+		order, pending, err = awaitSettlement(ctx, payload, exc, order)
+	}
+	// Once the order went out, an interrupted run still finishes its
+	// records and notifications; the wait above leaves it pending
+	ctx, cancel := run.WrapUp(ctx)
+	defer cancel()
+	settleIntent(ctx, payload, in, order, err)
+	if err != nil {
+		return orderFailed(err)
+	}
+	res.Order = order
+	res.PendingSettlement = pending != ""
 
 	log.Printf("✅ Order executed successfully:")
 	log.Printf("   Order ID: %s", order.ID)
+	log.Printf("   Client Order ID: %s", order.ClientOrderID)
 	log.Printf("   Symbol: %s", order.Symbol)
-	log.Printf("   Quantity: %s", order.Quantity.String())
-	log.Printf("   Price: %s", order.Price.String())
+	log.Printf("   Quantity: %s", describeQuantity(ctx, order.Symbol, order.Quantity))
+	log.Printf("   Price: %s", describePrice(ctx, order.Symbol, order.Price))
 	log.Printf("   Status: %s", order.Status)
+	fees := feeDetails(ctx, order)
+	for _, d := range fees {
+		log.Printf("   %s: %s", d.Label, d.Value)
+	}
+	for i, leg := range order.Legs {
+		log.Printf("   Leg %d: %s %s %s @ %s (order %s)", i+1, leg.Side, describeQuantity(ctx, leg.Symbol, leg.Quantity), leg.Symbol, describePrice(ctx, leg.Symbol, leg.Price), leg.ID)
+	}
 
-	// Step 2: Check remaining balance and send notification if low
-	if payload.Strategy.BalanceThreshold != "" {
-		if err := checkBalanceAndNotify(ctx, payload, exc); err != nil {
-			log.Printf("⚠️ Balance check failed: %v", err)
-			// Don't return error - order was successful (or would be in dry run)
-		}
+	quote, _ := extractQuoteCurrency(order.Symbol)
+	feeInQuote := order.FeeAsset != "" && asset.Canonical("", order.FeeAsset) == asset.Canonical("", quote)
+	sz.DebitedAmount = sizing.DebitedAmount(order.Quantity, order.Price, order.FeeAmount, feeInQuote)
+	log.Printf("   Debited: %s (configured %s)", describeQuote(ctx, order.Symbol, sz.DebitedAmount), describeQuote(ctx, order.Symbol, requested))
+
+	details := []notify.Detail{
+		{Label: "Order ID", Value: order.ID},
+		{Label: "Price", Value: describePrice(ctx, order.Symbol, order.Price)},
+		{Label: "Status", Value: order.Status},
+	}
+	details = append(details, fees...)
+	details = append(details, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(payload.Flags.DryRun)})
+	if routed, ok := exc.(*route.Exchange); ok {
+		details = append(details, notify.Detail{Label: "Route", Value: routed.Route.String()})
 	}
+	details = append(details, sizingDetails(ctx, order.Symbol, res)...)
+	details = append(details, tickerDetails(ctx, res)...)
 
-	// TODO: Send success notification
+	// Step 3: Check the remaining balance; its projected runway goes into
+	// the notification, a low-balance alert follows it
+	check := evaluateBalance(ctx, payload, exc, requested)
+	res.Balance = check
+	details = append(details, projectRunway(ctx, payload, check, res)...)
+	outcome := fmt.Sprintf("Bought %s %s for %s", describeQuantity(ctx, order.Symbol, order.NetQuantity()), order.Symbol, describeQuote(ctx, order.Symbol, quoteAmount))
+	summary := "✅ " + outcome
+	if res.PendingSettlement {
+		outcome += ", pending settlement"
+		summary = "⏳ " + outcome
+		details = append(details,
+			notify.Detail{Label: "Settlement", Value: "Quantity and price are provisional until the exchange reports the fill; the next run records it"},
+			notify.Detail{Label: "Pending", Value: pending},
+		)
+	}
+	if order.Status == exchange.OrderStatusCanceled {
+		outcome += ", the rest canceled"
+		summary = "✂️ " + outcome
+		filled := order.Quantity.Mul(order.Price)
+		details = append(details, notify.Detail{
+			Label: "Canceled",
+			Value: fmt.Sprintf("Unfilled after %ds; %s filled for %s", payload.Strategy.CancelUnfilledAfterSeconds, describeQuantity(ctx, order.Symbol, order.Quantity), describeQuote(ctx, order.Symbol, filled)),
+		})
+	}
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPostTrade,
+		Symbol:   order.Symbol,
+		Notional: quoteAmount,
+		Summary:  summary,
+		Details:  details,
+	})
+
+	// Step 4: Protect the buy; a missing stop is loud but never undoes the
+	// buy. The stop of a buy pending settlement would cover a quantity not
+	// known yet, so it is left to the owner.
+	if payload.Strategy.StopLoss != nil && res.PendingSettlement {
+		run.Warn(ctx, "stoploss", "place", fmt.Errorf("order %s is pending settlement; no stop-loss placed", order.ID))
+	} else if payload.Strategy.StopLoss != nil {
+		spanCtx, end := run.StartSpan(ctx, "stoploss")
+		res.StopLoss = protectBuy(spanCtx, payload, exc, order)
+		end()
+	}
+
+	// Step 5: Send the low-balance and holdings notifications
+	notifyLowBalance(ctx, payload, check, outcome)
+	if payload.Strategy.HoldingsAlert != nil {
+		checkHoldings(ctx, payload, exc)
+	}
 
 	return nil
 }
 
-// checkBalanceAndNotify checks remaining balance and sends notification if below threshold
-func checkBalanceAndNotify(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) error {
-	// Extract quote currency from symbol (e.g., "BTC-USDT" -> "USDT")
-	quoteCurrency, err := extractQuoteCurrency(payload.Strategy.Symbol)
-	if err != nil {
-		return fmt.Errorf("failed to extract quote currency: %w", err)
+// waitAfterPreTrade holds the order for notifications.preTradeDelay after
+// the pre-trade notification, giving the owner a moment to react
+func waitAfterPreTrade(ctx context.Context, payload *config.DCAPayload) error {
+	delay := payload.Notifications.PreTradeDelay()
+	if delay <= 0 {
+		return nil
+	}
+	log.Printf("⏱️ Waiting %s after pre-trade notification", delay)
+	_, end := run.StartSpan(ctx, "pretrade.wait")
+	defer end()
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("cancelled during pre-trade delay: %w", ctx.Err())
 	}
+}
 
-	// Get current balance
-	balance, err := exc.GetBalance(ctx, quoteCurrency)
-	if err != nil {
-		return fmt.Errorf("failed to get balance: %w", err)
+// orderFailed wraps the error of an order request. After a timeout or an
+// interruption the order may have gone through, so it is marked to be
+// never retried, here or elsewhere; other errors that are not outages are
+// the exchange refusing the order.
+func orderFailed(err error) error {
+	switch {
+	case errors.Is(err, exchange.ErrOrderLookup):
+		// No order was sent; the lookup's own error tells its class
+		return fmt.Errorf("failed to place order: %w", err)
+	case exchange.IsTimeout(err), errors.Is(err, context.Canceled):
+		// The request may have reached the exchange before it was cut off
+		return fmt.Errorf("failed to place order: %w: %w", exchange.ErrOrderOutcomeUnknown, err)
+	case exchange.IsUnavailable(err):
+		return fmt.Errorf("failed to place order: %w", err)
+	default:
+		return fmt.Errorf("failed to place order: %w", failure.Mark(failure.CodeOrderRejected, err))
 	}
+}
 
-	log.Printf("💰 Current %s balance after order: %s", quoteCurrency, balance.String())
+// checkBalance runs the low-balance check after an order when a threshold
+// is configured; perRun is what one run spends of the watched balance and
+// outcome how the run ended, and returns the check. The order already went
+// through, so failures are only logged.
+func checkBalance(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, perRun decimal.Decimal, outcome string) *threshold.Check {
+	check := evaluateBalance(ctx, payload, exc, perRun)
+	notifyLowBalance(ctx, payload, check, outcome)
+	return check
+}
 
-	// Parse balance threshold
-	threshold, err := decimal.NewFromString(payload.Strategy.BalanceThreshold)
+// evaluateBalance reads the watched balance after an order and compares it
+// with the threshold, or with none when only its runway is projected. It
+// returns nil when neither is configured or the check failed, which is
+// only logged.
+func evaluateBalance(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, perRun decimal.Decimal) *threshold.Check {
+	t := payload.Strategy.Threshold()
+	if t.Amount == "" {
+		if !payload.Notifications.ProjectRunway || payload.Strategy.Selling() {
+			return nil
+		}
+		t.Amount = "0"
+	}
+	ctx, end := run.StartSpan(ctx, "balance.check")
+	defer end()
+	check, err := checkRemainingBalance(ctx, payload, exc, t, perRun)
 	if err != nil {
-		return fmt.Errorf("invalid balance threshold: %w", err)
+		run.Warn(ctx, "balance", "check", err)
+		return nil
 	}
+	return check
+}
 
-	// Check if balance is below threshold
-	if balance.LessThan(threshold) {
-		log.Printf("⚠️ Balance is below threshold: %s < %s", balance.String(), threshold.String())
-		// TODO: Send low balance notification via Telegram
-		return sendLowBalanceNotification(payload, quoteCurrency, balance, threshold)
+// protectBuy places the strategy's stop-loss below a filled buy, replacing
+// the stop of the previous run. Failures are reported, not returned.
+func protectBuy(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, order *exchange.Order) *stoploss.Result {
+	if !payload.Flags.AllowProtectiveOrders {
+		log.Printf("⚠️ strategy.stopLoss is set but flags.allowProtectiveOrders is not; no stop-loss placed")
+		return nil
+	}
+	if len(order.Legs) > 0 {
+		log.Printf("⚠️ Stop-loss is not supported for routed buys; %s is not listed directly", order.Symbol)
+		return &stoploss.Result{Error: "stop-loss is not supported for routed buys"}
 	}
 
-	log.Printf("✅ Balance is sufficient: %s >= %s (threshold)", balance.String(), threshold.String())
-	return nil
+	sl, err := stoploss.Protect(ctx, exc, *payload.Strategy.StopLoss, order)
+	if err != nil {
+		log.Printf("🚨 STOP-LOSS NOT PLACED for %s: %v", order.Symbol, err)
+		run.Warn(ctx, "stoploss", "place", err)
+		dispatch(ctx, notify.Event{
+			Type:    notify.EventError,
+			Symbol:  order.Symbol,
+			Summary: fmt.Sprintf("🚨 Bought %s but the stop-loss was NOT placed", order.Symbol),
+			Details: []notify.Detail{
+				{Label: "Error", Value: err.Error()},
+				{Label: "Order ID", Value: order.ID},
+			},
+		})
+		return sl
+	}
+
+	log.Printf("🛡️ Stop-loss placed: sell %s %s, stop %s, limit %s (order %s)",
+		describeQuantity(ctx, sl.Order.Symbol, sl.Order.Quantity), sl.Order.Symbol,
+		describePrice(ctx, sl.Order.Symbol, sl.Order.StopPrice), describePrice(ctx, sl.Order.Symbol, sl.Order.Price), sl.Order.ID)
+	if sl.Error != "" {
+		run.Warn(ctx, "stoploss", "replace", errors.New(sl.Error))
+	}
+	return sl
 }
 
-// sendLowBalanceNotification sends a notification about low balance
-func sendLowBalanceNotification(payload *config.DCAPayload, currency string, balance, threshold decimal.Decimal) error {
-	// TODO: Implement Telegram notification
-	log.Printf("📢 Would send low balance notification:")
-	log.Printf("   Currency: %s", currency)
-	log.Printf("   Current Balance: %s", balance.String())
-	log.Printf("   Threshold: %s", threshold.String())
-	log.Printf("   Symbol: %s", payload.Strategy.Symbol)
-	
-	if payload.Notifications.Telegram != nil {
-		log.Printf("   Telegram notification configured: %s", payload.Notifications.Telegram.Type)
+// sizeOrder applies the strategy's fee handling to the configured quote amount
+func sizeOrder(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, requested decimal.Decimal) (*sizing.Sizing, error) {
+	sz := &sizing.Sizing{
+		FeeHandling:     payload.Strategy.FeeHandling,
+		RequestedAmount: requested,
+		OrderAmount:     requested,
 	}
-	
-	return nil
+	if sz.FeeHandling != sizing.FeeDeduct {
+		return sz, nil
+	}
+
+	rate, source, err := estimateFeeRate(ctx, payload, exc)
+	if err != nil {
+		return nil, err
+	}
+	quote, err := extractQuoteCurrency(payload.Strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	orderAmount, err := sizing.DeductFee(requested, rate, sizing.QuotePlaces(asset.Canonical("", quote)))
+	if err != nil {
+		return nil, err
+	}
+
+	sz.FeeRate = rate
+	sz.FeeRateSource = source
+	sz.OrderAmount = orderAmount
+	log.Printf("💸 Deducting estimated fee (%s from %s): ordering %s instead of %s",
+		rate.String(), source, describeQuote(ctx, payload.Strategy.Symbol, orderAmount), describeQuote(ctx, payload.Strategy.Symbol, requested))
+	return sz, nil
 }
 
-// extractQuoteCurrency extracts the quote currency from a trading pair symbol
-func extractQuoteCurrency(symbol string) (string, error) {
-	// Handle different symbol formats: "BTC-USDT", "BTCUSDT", etc.
-	if strings.Contains(symbol, "-") {
-		parts := strings.Split(symbol, "-")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid symbol format: %s", symbol)
+// estimateFeeRate asks the exchange for the taker fee rate, falling back to strategy.feeRateBps
+func estimateFeeRate(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) (decimal.Decimal, string, error) {
+	if rater, ok := exc.(exchange.FeeRater); ok {
+		rate, err := rater.TakerFeeRate(ctx, payload.Strategy.Symbol)
+		if err == nil {
+			return rate, sizing.RateSourceExchange, nil
+		}
+		run.Warn(ctx, "exchange", "fee rate", err)
+	}
+	if payload.Strategy.FeeRateBps != "" {
+		bps, err := money.BasisPointsFromString(payload.Strategy.FeeRateBps)
+		if err != nil {
+			return decimal.Zero, "", fmt.Errorf("invalid feeRateBps: %w", err)
+		}
+		rate := bps.Fraction()
+		if routed, ok := exc.(*route.Exchange); ok {
+			// Every leg of a route pays the fee
+			rate = route.CompoundRate(rate, len(routed.Route.Legs))
 		}
-		return parts[1], nil
+		return rate, sizing.RateSourceConfig, nil
+	}
+	return decimal.Zero, "", fmt.Errorf("feeHandling %q needs a fee rate, but the exchange did not report one and strategy.feeRateBps is not set", sizing.FeeDeduct)
+}
+
+// checkRemainingBalance evaluates the remaining balance against t
+func checkRemainingBalance(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, t config.Threshold, perRun decimal.Decimal) (*threshold.Check, error) {
+	// Buys spend the quote currency (e.g., "BTC-USDT" -> "USDT"), sells the base
+	base, watched, err := exchange.SplitSymbol(payload.Strategy.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract quote currency: %w", err)
+	}
+	if payload.Strategy.Selling() {
+		watched = base
 	}
-	
-	// For symbols like "BTCUSDT", assume common quote currencies
-	commonQuotes := []string{"USDT", "USDC", "BUSD", "USD", "BTC", "ETH", "FDUSD"}
-	for _, quote := range commonQuotes {
-		if strings.HasSuffix(symbol, quote) {
-			return quote, nil
+
+	// Get current balance; only the free part can fund future orders
+	spanCtx, end := run.StartSpan(ctx, "exchange.balance")
+	detail, err := exc.GetBalanceDetail(spanCtx, watched)
+	end()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	log.Printf("💰 Current %s balance after order: %s", watched, describeBalance(ctx, detail))
+
+	check, err := threshold.Evaluate(ctx, newThresholdConverter(payload, exc), payload.Strategy.Symbol, detail, t)
+	if err != nil {
+		return nil, err
+	}
+	check.Selling = payload.Strategy.Selling()
+	check.PerRun = perRun
+	f := money.FromContext(ctx)
+	if check.RateSource != threshold.RateNone {
+		log.Printf("💱 %s balance is worth %s at %s (%s rate)", watched, f.Amount(check.Value, check.Currency), check.Rate.String(), check.RateSource)
+	}
+
+	// Resting orders of the symbol hold part of the balance; the estimate
+	// counts only the free part but says how much is committed. The lookup
+	// is dropped once the run's API usage is high.
+	if ratelimit.UsageFrom(ctx).Throttled() {
+		log.Printf("🐢 Skipping the open orders lookup: exchange API usage is high")
+	} else {
+		spanCtx, end = run.StartSpan(ctx, "exchange.openOrders")
+		open, err := exc.OpenOrders(spanCtx, payload.Strategy.Symbol)
+		end()
+		if err != nil {
+			run.Warn(ctx, "balance", "open orders", err)
+		} else {
+			check.Reserve(open)
 		}
 	}
-	
-	return "", fmt.Errorf("unable to extract quote currency from symbol: %s", symbol)
+	if runway := check.Runway(f); runway != "" {
+		log.Printf("🛣️ %s", runway)
+	}
+	return &check, nil
+}
+
+// notifyLowBalance sends the low-balance notification when check is below
+// the strategy's threshold, unless it was sent within config.AlertCooldown.
+// outcome says how the run ended, e.g. "Bought 0.0004 BTC-USDT for 25.00 USDT".
+func notifyLowBalance(ctx context.Context, payload *config.DCAPayload, check *threshold.Check, outcome string) {
+	if check == nil || payload.Strategy.BalanceThreshold == "" {
+		return
+	}
+	f := money.FromContext(ctx)
+	// Payloads carry one strategy today, so this is a single check
+	event, low := threshold.Aggregate(ctx, []threshold.Check{*check})
+	due := alertDue(ctx, payload, lowBalanceKey(payload), low)
+	if !low {
+		log.Printf("✅ Balance is sufficient: %s >= %s (threshold)", f.Amount(check.Value, check.Currency), f.Amount(check.Threshold, check.Currency))
+		return
+	}
+	log.Printf("⚠️ Balance is below threshold: %s < %s", f.Amount(check.Value, check.Currency), f.Amount(check.Threshold, check.Currency))
+	if due {
+		event.Details = append(event.Details, notify.Detail{Label: "Run", Value: outcome})
+		dispatch(ctx, event)
+	}
+}
+
+// newThresholdConverter prices balances in USD for thresholds set in USD,
+// or returns nil when the exchange cannot report prices
+func newThresholdConverter(payload *config.DCAPayload, exc exchange.Exchange) *threshold.Converter {
+	if routed, ok := exc.(*route.Exchange); ok {
+		exc = routed.Exchange
+	}
+	ticker, ok := exc.(exchange.PriceTicker)
+	if !ok {
+		return nil
+	}
+	tolerance, _ := decimal.NewFromString(payload.Strategy.Threshold().PegTolerance)
+	return &threshold.Converter{Prices: ticker, PegTolerance: tolerance}
+}
+
+// sendSkipNotification sends a notification about a skipped run
+func sendSkipNotification(ctx context.Context, payload *config.DCAPayload, skip *guard.Skip) {
+	dispatch(ctx, notify.Event{
+		Type:    notify.EventSkip,
+		Symbol:  payload.Strategy.Symbol,
+		Summary: fmt.Sprintf("⏭️ %s run %s", payload.Strategy.Symbol, skip),
+		Details: []notify.Detail{
+			{Label: "Skipped by", Value: skip.Guard},
+			{Label: "Reason", Value: skip.Reason},
+		},
+	})
+}
+
+// describeQuote renders a quote amount with its currency when known, e.g. "25.00 USDT"
+func describeQuote(ctx context.Context, symbol string, amount decimal.Decimal) string {
+	f := money.FromContext(ctx)
+	if quote, err := extractQuoteCurrency(symbol); err == nil {
+		return f.Amount(amount, asset.Canonical("", quote))
+	}
+	return f.Number(amount, "")
+}
+
+// describePrice renders a price of symbol in its quote currency, e.g. "62,626.35"
+func describePrice(ctx context.Context, symbol string, price decimal.Decimal) string {
+	var quote string
+	if q, err := extractQuoteCurrency(symbol); err == nil {
+		quote = asset.Canonical("", q)
+	}
+	return money.FromContext(ctx).Number(price, quote)
+}
+
+// describeQuantity renders a quantity of symbol's base asset, e.g. "0.00041"
+func describeQuantity(ctx context.Context, symbol string, quantity decimal.Decimal) string {
+	var base string
+	if b, _, err := exchange.SplitSymbol(symbol); err == nil {
+		base = asset.Canonical("", b)
+	}
+	return money.FromContext(ctx).Number(quantity, base)
+}
+
+// feeDetails returns the fee of order and, when it was charged in the
+// base asset, the quantity received net of it
+func feeDetails(ctx context.Context, order *exchange.Order) []notify.Detail {
+	if order.FeeAsset == "" {
+		return nil
+	}
+	fee := money.FromContext(ctx).Amount(order.FeeAmount, asset.Canonical("", order.FeeAsset))
+	if len(order.Fills) > 1 {
+		fee += fmt.Sprintf(" over %d fills", len(order.Fills))
+	}
+	details := []notify.Detail{{Label: "Fee", Value: fee}}
+	if net := order.NetQuantity(); !net.Equal(order.Quantity) {
+		details = append(details, notify.Detail{Label: "Net Quantity", Value: describeQuantity(ctx, order.Symbol, net)})
+	}
+	return details
+}
+
+// describeBalance renders the free balance, mentioning locked funds when they are significant
+// e.g. "12.00 USDT free, 200.00 USDT locked in open orders"
+func describeBalance(ctx context.Context, balance exchange.Balance) string {
+	f := money.FromContext(ctx)
+	code := asset.Canonical("", balance.Asset)
+	if balance.HasSignificantLocked() {
+		return fmt.Sprintf("%s free, %s locked in open orders", f.Amount(balance.Free, code), f.Amount(balance.Locked, code))
+	}
+	return f.Amount(balance.Free, code)
+}
+
+// extractQuoteCurrency extracts the quote currency from a trading pair symbol
+func extractQuoteCurrency(symbol string) (string, error) {
+	_, quote, err := exchange.SplitSymbol(symbol)
+	return quote, err
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/account"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/native"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/ratelimit"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// executeNative brings the exchange's recurring-buy plan in line with the
+// strategy instead of placing an order, recording the plan in res. In a
+// dry run the plans are only listed and the change described.
+func executeNative(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	res.Exchange = payload.Exchange.Name
+	ctx = withRateLimit(ctx, payload)
+	ctx, endStrategy := run.StartStrategySpan(ctx, account.RouteKey(payload))
+	defer endStrategy()
+	ctx = notify.WithStrategy(ctx, account.RouteKey(payload))
+	log.Printf("🔁 Native engine: %s on %s (DryRun: %v)", payload.Strategy.Symbol, payload.Exchange.Name, payload.Flags.DryRun)
+
+	want, err := native.Desired(payload.Strategy, time.Now())
+	if err != nil {
+		return err
+	}
+	buyer, err := newRecurringBuyer(ctx, payload.Exchange)
+	if err != nil {
+		return fmt.Errorf("failed to create recurring-buy client: %w", err)
+	}
+	spanCtx, end := run.StartSpan(ctx, "native.sync")
+	report, err := native.Sync(spanCtx, buyer, want, payload.Flags.DryRun)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to sync recurring-buy plan: %w", err)
+	}
+	res.Native = report
+
+	for _, dup := range report.Duplicates {
+		run.Warn(ctx, "native", "duplicate plan", fmt.Errorf("plan %s also buys %s: %s; remove it to avoid buying twice", dup.ID, dup.TargetAsset, describePlan(ctx, dup)))
+	}
+
+	plan := report.Plan
+	var summary string
+	switch {
+	case report.Action == native.ActionNone:
+		summary = fmt.Sprintf("🔁 Recurring-buy plan %s is up to date: %s", plan.ID, describePlan(ctx, plan))
+	case report.DryRun:
+		summary = fmt.Sprintf("🧪 DRY RUN: would %s the recurring-buy plan: %s", report.Action, describePlan(ctx, plan))
+	case report.Action == native.ActionCreate:
+		summary = fmt.Sprintf("🔁 Created recurring-buy plan %s: %s", plan.ID, describePlan(ctx, plan))
+	default:
+		summary = fmt.Sprintf("🔁 Updated recurring-buy plan %s: %s", plan.ID, describePlan(ctx, plan))
+	}
+
+	var details []notify.Detail
+	if plan.ID != "" {
+		details = append(details, notify.Detail{Label: "Plan ID", Value: plan.ID})
+	}
+	if prev := report.Previous; prev != nil {
+		details = append(details, notify.Detail{Label: "Was", Value: describePlan(ctx, *prev)})
+	}
+	if plan.Status != "" {
+		details = append(details, notify.Detail{Label: "Status", Value: plan.Status})
+	}
+	if !plan.NextExecution.IsZero() {
+		details = append(details, notify.Detail{Label: "Next Execution", Value: plan.NextExecution.Format(time.RFC3339)})
+	}
+	details = append(details, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(report.DryRun)})
+
+	log.Printf("%s", summary)
+	for _, d := range details {
+		log.Printf("   %s: %s", d.Label, d.Value)
+	}
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPostTrade,
+		Symbol:   payload.Strategy.Symbol,
+		Notional: plan.Amount,
+		Summary:  summary,
+		Details:  details,
+	})
+	return nil
+}
+
+// describePlan renders a recurring-buy plan, e.g. "50.00 USDT of BTC weekly
+// on Monday at 09:00 UTC"
+func describePlan(ctx context.Context, plan exchange.RecurringPlan) string {
+	f := money.FromContext(ctx)
+	return fmt.Sprintf("%s of %s %s", f.Amount(plan.Amount, asset.Canonical("", plan.SourceAsset)), plan.TargetAsset, plan.Cadence)
+}
+
+// newRecurringBuyer creates the recurring-buy client for an exchange. Dry
+// runs use it too, but only to list plans.
+func newRecurringBuyer(ctx context.Context, venue config.ExchangeConfig) (exchange.RecurringBuyer, error) {
+	switch strings.ToLower(venue.Name) {
+	case "binance":
+		creds, err := exchangeCredentials(ctx, venue)
+		if err != nil {
+			return nil, err
+		}
+		return exchange.NewBinanceAutoInvest(creds.APIKey, creds.APISecret), nil
+	default:
+		return nil, fmt.Errorf("recurring-buy plans are not supported on %s", venue.Name)
+	}
+}
+
+// warnNativePlans warns when a spot strategy runs on an exchange that also
+// holds a recurring-buy plan for its symbol, such as one left behind when
+// switching back from the native engine; both would buy. The lookup is
+// dropped once the run's API usage is high, and failures are only logged.
+func warnNativePlans(ctx context.Context, payload *config.DCAPayload) {
+	if !slices.Contains(config.NativeEngineExchanges, strings.ToLower(payload.Exchange.Name)) {
+		return
+	}
+	if ratelimit.UsageFrom(ctx).Throttled() {
+		log.Printf("🐢 Skipping the recurring-buy plan lookup: exchange API usage is high")
+		return
+	}
+	base, quote, err := exchange.SplitSymbol(payload.Strategy.Symbol)
+	if err != nil {
+		return
+	}
+	buyer, err := newRecurringBuyer(ctx, payload.Exchange)
+	if err != nil {
+		log.Printf("⚠️ Could not check for recurring-buy plans: %v", err)
+		return
+	}
+	spanCtx, end := run.StartSpan(ctx, "native.plans")
+	plans, err := buyer.RecurringPlans(spanCtx)
+	end()
+	if err != nil {
+		log.Printf("⚠️ Could not check for recurring-buy plans: %v", err)
+		return
+	}
+	for _, plan := range native.Matching(plans, quote, base) {
+		run.Warn(ctx, "native", "existing plan", fmt.Errorf("recurring-buy plan %s (%s) also buys %s: %s; remove it or set strategy.engine to %q to avoid buying twice",
+			plan.ID, plan.Status, base, describePlan(ctx, plan), config.EngineNative))
+	}
+}
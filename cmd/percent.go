@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/plan"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// applyPercent sets the strategy's quoteAmount for this run from its
+// percentage of the free quote balance on exc, recording the sizing in res.
+// It returns a skip when the balance is empty or the amount is below the
+// exchange's minimum order value.
+func applyPercent(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) (*guard.Skip, error) {
+	strategy := payload.Strategy
+	_, quote, err := exchange.SplitSymbol(strategy.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	spanCtx, end := run.StartSpan(ctx, "exchange.balance")
+	balance, err := exc.GetBalance(spanCtx, quote)
+	end()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s balance: %w", quote, err)
+	}
+	minNotional := decimal.Zero
+	if limiter, ok := exc.(exchange.NotionalLimiter); ok {
+		if minNotional, err = limiter.MinNotional(ctx, strategy.Symbol); err != nil {
+			return nil, fmt.Errorf("failed to get minimum order value: %w", err)
+		}
+	}
+
+	percent, skip, err := plan.Percent(strategy, balance, minNotional)
+	if err != nil {
+		return nil, err
+	}
+	res.Percent = &percent
+	log.Printf("💯 Percent sizing: %s", describePercent(ctx, strategy.Symbol, percent))
+	if skip != nil {
+		return skip, nil
+	}
+	payload.Strategy.QuoteAmount = percent.Amount.String()
+	payload.SetOrigin("strategy.quoteAmount", config.OriginDerived)
+	res.QuoteAmount = payload.Strategy.QuoteAmount
+	return nil, nil
+}
+
+// describePercent renders a percent sizing, e.g. "5% of 1,000.00 USDT free
+// is 50.00 USDT"
+func describePercent(ctx context.Context, symbol string, p sizing.Percent) string {
+	s := fmt.Sprintf("%s%% of %s free is %s", p.Percent, describeQuote(ctx, symbol, p.Balance), describeQuote(ctx, symbol, p.Amount))
+	if p.Capped {
+		s += " (capped by maxQuoteAmount)"
+	}
+	return s
+}
+
+// sizingDetails are the notification details of how the order was sized:
+// its percent of the balance, its ramp-up step and its dip multiplier;
+// none for plain runs
+func sizingDetails(ctx context.Context, symbol string, res *result.ExecutionResult) []notify.Detail {
+	var details []notify.Detail
+	if res.Percent != nil {
+		details = append(details, notify.Detail{Label: "Sizing", Value: describePercent(ctx, symbol, *res.Percent)})
+	}
+	if res.RampUp != nil {
+		details = append(details, notify.Detail{Label: "Ramp-up", Value: res.RampUp.String()})
+	}
+	if res.Dip != nil {
+		details = append(details, notify.Detail{Label: "Dip", Value: describeDip(ctx, symbol, *res.Dip)})
+	}
+	return details
+}
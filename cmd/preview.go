@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/account"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/kmspayload"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// previewNotificationCommand prints an event as every channel the payload
+// configures would deliver it, without trading or sending anything. The
+// event is the built-in sample of its type unless --data gives one.
+// Webhook secrets are not resolved; signatures are shown as placeholders.
+//
+//	preview-notification --event payload.json --type postTrade [--data sample.json]
+func previewNotificationCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("preview-notification", flag.ContinueOnError)
+	eventPath := fs.String("event", "local_event.json", "payload or event whose notifications to preview")
+	eventType := fs.String("type", "", "event type: "+strings.Join(config.NotificationEvents, ", "))
+	dataPath := fs.String("data", "", "JSON file with the event's symbol, notional, summary and details")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !slices.Contains(config.NotificationEvents, *eventType) {
+		return fmt.Errorf("--type must be one of %s", strings.Join(config.NotificationEvents, ", "))
+	}
+
+	raw, err := os.ReadFile(*eventPath)
+	if err != nil {
+		return fmt.Errorf("failed to read event: %w", err)
+	}
+	raw, _ = handler.EventBridgeUnwrapper(ctx, raw)
+	if raw, err = kmspayload.Unwrapper(newKMSClient)(ctx, raw); err != nil {
+		return fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	payload, err := config.ParseDCAPayload(raw)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	event, ok := notify.SampleEvent(notify.EventType(*eventType))
+	if *dataPath != "" {
+		data, err := os.ReadFile(*dataPath)
+		if err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+		if event, err = notify.ParseSampleData(notify.EventType(*eventType), data); err != nil {
+			return fmt.Errorf("%s: %w", *dataPath, err)
+		}
+	} else if !ok {
+		return errors.New("no sample data for " + *eventType)
+	}
+
+	d := newPreviewDispatcher(payload.Notifications)
+	routeStrategy(d, payload, previewTelegram)
+	ctx = notify.WithStrategy(run.WithID(ctx, "preview"), account.RouteKey(payload))
+	p, err := d.Preview(ctx, event)
+	if err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	fmt.Print(p)
+	return nil
+}
+
+// newPreviewDispatcher builds the channels newDispatcher would, with the
+// webhook's key IDs but none of its secrets
+func newPreviewDispatcher(cfg config.NotificationConfig) *notify.Dispatcher {
+	notifiers := []notify.Notifier{notify.LogNotifier{}}
+	if cfg.Telegram != nil {
+		notifiers = append(notifiers, previewTelegram(cfg.Telegram))
+	}
+	if cfg.Webhook != nil {
+		keys := make([]notify.WebhookKey, 0, len(cfg.Webhook.Secrets))
+		for _, secret := range cfg.Webhook.Secrets {
+			keys = append(keys, notify.WebhookKey{ID: secret.KeyID})
+		}
+		notifiers = append(notifiers, notify.NewWebhook(http.DefaultClient, cfg.Webhook.URL, keys))
+	}
+	return notify.NewDispatcher(cfg, notifiers...)
+}
+
+// previewTelegram is the notifier of a Telegram chat without its bot
+// token, which rendering does not need
+func previewTelegram(tg *config.TelegramConfig) notify.Notifier {
+	return notify.ForChannel("telegram", notify.NewTelegram(http.DefaultClient, "", tg.ChatID()))
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/threshold"
+)
+
+// projectRunway projects the remaining quote balance of a buy across the
+// strategy's next scheduled runs when notifications.projectRunway is set,
+// returning the notification detail. Runs sized by percent or ramped up
+// are assumed to keep spending this run's amount.
+func projectRunway(ctx context.Context, payload *config.DCAPayload, check *threshold.Check, res *result.ExecutionResult) []notify.Detail {
+	if !payload.Notifications.ProjectRunway || check == nil || payload.Strategy.Selling() {
+		return nil
+	}
+	// Both are validated with the payload
+	loc, _ := payload.Strategy.Location()
+	schedule, _ := payload.Strategy.ParsedSchedule()
+
+	now := time.Now().In(loc)
+	projection, ok := threshold.Project(*check, schedule.Next(now, threshold.ProjectionMaxRuns))
+	if !ok {
+		return nil
+	}
+	s := projection.Describe(now)
+	if projection.Reason != threshold.ShortNow && (res.Percent != nil || res.RampUp != nil || res.Dip != nil) {
+		s += fmt.Sprintf(", assuming %s per run", describeQuote(ctx, payload.Strategy.Symbol, check.PerRun))
+	}
+	log.Printf("🔮 Runway: %s", s)
+	return []notify.Detail{{Label: "Projection", Value: s}}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/rampup"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// applyRampUp scales the strategy's quoteAmount for this run while a
+// changed strategy ramps up, recording the step in res. The returned
+// record saves the progress once the run is over: a live order completes
+// the step, while a run that placed none repeats it next time.
+func applyRampUp(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) (record func(), err error) {
+	st, err := newStatus(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := payload.StrategyHash()
+	if err != nil {
+		return nil, err
+	}
+	_, end := run.StartSpan(ctx, "status.rampUp")
+	prev, err := st.RampUp(ctx)
+	end()
+	if err != nil {
+		return nil, err
+	}
+	state, step, err := rampup.Next(prev, hash, *payload.Flags.RampUp)
+	if err != nil {
+		return nil, err
+	}
+
+	if step != nil {
+		amount, err := decimal.NewFromString(payload.Strategy.QuoteAmount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quote amount: %w", err)
+		}
+		_, quote, _ := exchange.SplitSymbol(payload.Strategy.Symbol)
+		scaled := rampup.Scale(amount, step, sizing.QuotePlaces(asset.Canonical("", quote)))
+		log.Printf("🪜 Strategy changed: %s of %s", step, describeQuote(ctx, payload.Strategy.Symbol, amount))
+		payload.Strategy.QuoteAmount = scaled.String()
+		payload.SetOrigin("strategy.quoteAmount", config.OriginDerived)
+		res.QuoteAmount = payload.Strategy.QuoteAmount
+		res.RampUp = step
+	}
+
+	return func() {
+		// A dry run leaves the ramp where it was
+		if payload.Flags.DryRun {
+			return
+		}
+		next := state
+		if step != nil && res.Order != nil {
+			next.Completed++
+		}
+		if prev != nil && *prev == next {
+			return
+		}
+		if err := st.SaveRampUp(ctx, next); err != nil {
+			run.Warn(ctx, "status", "ramp-up save", err)
+		}
+	}, nil
+}
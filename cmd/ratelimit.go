@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/ratelimit"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// localRateLimits holds the state.sharedRateLimit buckets of local runs,
+// which have no other invocations to share them with
+var localRateLimits = ratelimit.NewMemoryStore()
+
+// withRateLimit makes the requests to the exchange in payload wait for
+// state.sharedRateLimit. Runs under every runtime but local share the
+// bucket through DynamoDB; when that cannot be set up the run goes ahead
+// unlimited with a warning.
+func withRateLimit(ctx context.Context, payload *config.DCAPayload) context.Context {
+	if payload.State == nil || payload.State.SharedRateLimit == nil {
+		return ctx
+	}
+	cfg := payload.State.SharedRateLimit
+
+	var store ratelimit.Store = localRateLimits
+	if sharedRuntime() {
+		awsCfg, err := loadAWSConfig(ctx)
+		if err != nil {
+			run.Warn(ctx, "ratelimit", "setup", fmt.Errorf("failed to load AWS config: %w", err))
+			return ctx
+		}
+		store = ratelimit.NewDynamoStore(awsCfg, cfg.Table)
+	}
+	limiter := ratelimit.New(store, cfg.Key(payload.Exchange.Name), ratelimit.PerMinute(cfg.RequestsPerMinute, cfg.Burst))
+	limiter.MaxWait = cfg.MaxWaitDuration()
+	return ratelimit.WithLimiter(ctx, limiter)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/reconcile"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/taxexport"
+)
+
+// executeReconcile compares the exchange's trades of the strategy's symbol
+// with the execution history and appends the fills it is missing, flagged
+// as reconciled. In a dry run nothing is written.
+func executeReconcile(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	ctx = withRateLimit(ctx, payload)
+	cfg := payload.Reconcile
+	symbol := payload.Strategy.Symbol
+	from, to, err := cfg.Range(time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("🔎 Reconciling %s on %s from %s through %s (DryRun: %v)", symbol, payload.Exchange.Name,
+		from.Format(config.DateLayout), to.AddDate(0, 0, -1).Format(config.DateLayout), payload.Flags.DryRun)
+
+	exc, err := newExchange(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create exchange: %w", err)
+	}
+	spanCtx, end := run.StartSpan(ctx, "exchange.myTrades")
+	trades, err := exc.GetMyTrades(spanCtx, symbol, from, to)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to get trade history: %w", err)
+	}
+
+	data, err := readLocation(ctx, cfg.History)
+	if err != nil {
+		return err
+	}
+	history, err := taxexport.ReadHistory(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	report := reconcile.Match(trades, historyEntries(history, symbol, from, to), payload.Flags.ImportForeignTrades)
+	report.Symbol, report.From, report.To, report.DryRun = symbol, from, to, payload.Flags.DryRun
+	res.Reconcile = report
+
+	if imports := report.Imports(); len(imports) > 0 && !report.DryRun {
+		records, err := reconciledRecords(ctx, payload, imports)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+			data = append(data, '\n')
+		}
+		if err := writeHistory(ctx, cfg.History, append(data, records...)); err != nil {
+			return err
+		}
+	}
+
+	lines := report.Lines()
+	for _, line := range lines {
+		log.Printf("🔎 %s", line)
+	}
+	if report.Clean() {
+		return nil
+	}
+
+	summary := fmt.Sprintf("🔎 %s: %d missing, %d foreign, %d not on exchange", symbol, len(report.Missing), len(report.Foreign), len(report.Unmatched))
+	details := make([]notify.Detail, 0, len(lines))
+	for i, line := range lines[1:] {
+		details = append(details, notify.Detail{Label: fmt.Sprint(i + 1), Value: line})
+	}
+	details = append(details, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(report.DryRun)})
+	dispatch(ctx, notify.Event{
+		Type:    notify.EventPostTrade,
+		Symbol:  symbol,
+		Summary: summary,
+		Details: details,
+	})
+	return nil
+}
+
+// historyEntries lists the orders of live executions of symbol finished in
+// [from, to), including route legs and protective stops
+func historyEntries(history []result.ExecutionResult, symbol string, from, to time.Time) []reconcile.Entry {
+	var entries []reconcile.Entry
+	for _, res := range history {
+		if res.DryRun || res.Status != result.StatusExecuted || res.Symbol != symbol {
+			continue
+		}
+		if at := res.FinishedAt.UTC(); at.Before(from) || !at.Before(to) {
+			continue
+		}
+		var orders []exchange.Order
+		if res.Order != nil {
+			orders = append(orders, *res.Order)
+			orders = append(orders, res.Order.Legs...)
+		}
+		if res.StopLoss != nil && res.StopLoss.Order != nil {
+			orders = append(orders, *res.StopLoss.Order)
+		}
+		for _, o := range orders {
+			if len(o.Legs) > 0 {
+				// A routed buy's own ID is synthetic; its legs are matched instead
+				continue
+			}
+			entries = append(entries, reconcile.Entry{OrderID: o.ID, ClientOrderID: o.ClientOrderID, ExecutionID: res.ExecutionID})
+		}
+	}
+	return entries
+}
+
+// reconciledRecords renders imported fills as execution history lines
+func reconciledRecords(ctx context.Context, payload *config.DCAPayload, fills []reconcile.Fill) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, f := range fills {
+		order := f.Order()
+		rec := result.ExecutionResult{
+			SchemaVersion: result.SchemaVersion,
+			ExecutionID:   fmt.Sprintf("%s-reconciled-%d", run.ID(ctx), i+1),
+			Status:        result.StatusExecuted,
+			Mode:          config.ModeDCA,
+			Exchange:      payload.Exchange.Name,
+			Symbol:        f.Symbol,
+			QuoteAmount:   order.Quantity.Mul(order.Price).String(),
+			Order:         order,
+			Reconciled:    true,
+			StartedAt:     f.Time.UTC(),
+			FinishedAt:    f.Time.UTC(),
+		}
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode reconciled fill %s: %w", f.OrderID, err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
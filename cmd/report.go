@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/report"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/taxexport"
+)
+
+// executeReport reports the holdings of the strategy's symbol without
+// trading. Only balances and prices are read from the exchange, so
+// read-only API keys are enough. A history that cannot be read only drops
+// the cost basis, the cost of the month's runs and the last buy's market
+// context.
+func executeReport(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) error {
+	ctx = withRateLimit(ctx, payload)
+	symbol := payload.Strategy.Symbol
+	log.Printf("📊 Reporting %s on %s", symbol, payload.Exchange.Name)
+
+	exc, err := newExchange(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create exchange: %w", err)
+	}
+	spanCtx, end := run.StartSpan(ctx, "exchange.report")
+	rep, err := report.Gather(spanCtx, exc, symbol)
+	end()
+	if err != nil {
+		return err
+	}
+
+	loc, err := payload.Strategy.Location()
+	if err != nil {
+		return err
+	}
+	if location := payload.Report.History; location != "" {
+		history, err := readReportHistory(ctx, location)
+		if err != nil {
+			run.Warn(ctx, "report", "history", err)
+		} else {
+			rep.SetOrders(reportOrders(history, symbol, payload.Account))
+			rep.RunCost = result.MonthCost(history, symbol, time.Now().In(loc))
+			rep.LastBuy = result.LastBuy(history, symbol, payload.Account)
+		}
+	} else {
+		log.Printf("ℹ️ report.history is not set; the report has no cost basis")
+	}
+
+	if payload.Strategy.Schedule != "" {
+		// Validated with the payload
+		schedule, _ := payload.Strategy.ParsedSchedule()
+		rep.NextRuns = schedule.Next(time.Now().In(loc), config.ReportUpcomingRuns)
+	}
+	res.Report = rep
+
+	event := rep.Event(money.FromContext(ctx), loc)
+	log.Printf("%s", event.Summary)
+	for _, d := range event.Details {
+		log.Printf("   %s: %s", d.Label, d.Value)
+	}
+	dispatch(ctx, event)
+	return nil
+}
+
+// readReportHistory reads the execution history at location, oldest first
+func readReportHistory(ctx context.Context, location string) ([]result.ExecutionResult, error) {
+	_, end := run.StartSpan(ctx, "report.history")
+	data, err := readLocation(ctx, location)
+	end()
+	if err != nil {
+		return nil, err
+	}
+	history, err := taxexport.ReadHistory(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(history, func(i, j int) bool { return history[i].FinishedAt.Before(history[j].FinishedAt) })
+	return history, nil
+}
+
+// reportOrders lists the filled orders of live executions of symbol for
+// account in history, in its order
+func reportOrders(history []result.ExecutionResult, symbol, account string) []exchange.Order {
+	var orders []exchange.Order
+	for _, res := range result.Runs(history) {
+		if res.DryRun || res.Status != result.StatusExecuted || res.Order == nil || res.Symbol != symbol || res.Account != account {
+			continue
+		}
+		orders = append(orders, *res.Order)
+	}
+	return orders
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// applyTradingRules checks a buy of quoteAmount against the symbol's
+// trading rules before anything is sent. An amount below the minimum order
+// value fails the run; one finer than the quote precision is rounded down.
+// Rules that cannot be read are a warning: the exchange still checks the
+// order itself.
+func applyTradingRules(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, quoteAmount decimal.Decimal) (decimal.Decimal, error) {
+	symbol := payload.Strategy.Symbol
+	spanCtx, end := run.StartSpan(ctx, "exchange.rules")
+	rules, err := exchange.Rules(spanCtx, exc, symbol)
+	end()
+	if err != nil {
+		run.Warn(ctx, "exchange", "trading rules", err)
+		return quoteAmount, nil
+	}
+	if rounded := rules.QuantizeQuote(quoteAmount); !rounded.Equal(quoteAmount) {
+		log.Printf("📏 Rounding %s down to %s, the precision %s accepts", quoteAmount.String(), rounded.String(), symbol)
+		quoteAmount = rounded
+	}
+	if err := rules.CheckNotional(symbol, quoteAmount); err != nil {
+		return decimal.Zero, failure.Mark(failure.CodeConfigInvalid, err)
+	}
+	return quoteAmount, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sudowanderer/dca-bot-go/internal/schema"
+)
+
+// schemaCommand prints the payload JSON Schema, for editors and CI checks
+// of payload files
+//
+//	schema [--out payload.schema.json]
+func schemaCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the schema to (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(schema.Payload(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	return os.WriteFile(*out, append(data, '\n'), 0o644)
+}
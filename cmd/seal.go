@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/seal"
+	"github.com/sudowanderer/dca-bot-go/internal/status"
+)
+
+// rekeyNewKeyEnv holds the key rekey re-encrypts local files under
+const rekeyNewKeyEnv = "DCA_LOCAL_ENCRYPTION_NEW_KEY"
+
+// localKey is the key local state files are encrypted with, nil when
+// DCA_LOCAL_ENCRYPTION_KEY is unset. Lambda never encrypts: its only local
+// disk is scratch space, and its state lives in S3.
+func localKey() ([]byte, error) {
+	if rt, err := env.DetectRuntime(); err == nil && rt == env.RuntimeLambda {
+		return nil, nil
+	}
+	return seal.KeyFromEnv(seal.KeyEnv)
+}
+
+// localStateFiles lists the local files payload keeps state in: its
+// status directory, per account when it has accounts, and its local
+// execution histories
+func localStateFiles(payload *config.DCAPayload) []string {
+	var files []string
+	addStatus := func(p *config.DCAPayload) {
+		if p.State != nil && p.State.Status != nil && p.State.Status.Dir != "" {
+			files = append(files, filepath.Join(p.State.Status.Dir, status.LastResultKey), filepath.Join(p.State.Status.Dir, status.PauseKey))
+		}
+	}
+	addStatus(payload)
+	for _, a := range payload.Exchange.Accounts {
+		addStatus(payload.ForAccount(a))
+	}
+
+	histories := []string{payload.Strategy.BudgetHistory}
+	if r := payload.Report; r != nil {
+		histories = append(histories, r.History)
+	}
+	if r := payload.Reconcile; r != nil {
+		histories = append(histories, r.History)
+	}
+	for _, h := range histories {
+		if _, _, isS3 := parseS3URI(h); h != "" && !isS3 && !slices.Contains(files, h) {
+			files = append(files, h)
+		}
+	}
+	return files
+}
+
+// rekeyCommand re-encrypts local state files from DCA_LOCAL_ENCRYPTION_KEY
+// to the key in DCA_LOCAL_ENCRYPTION_NEW_KEY: the payload's files and any
+// named. Plaintext files are encrypted; --decrypt writes every file back
+// as plaintext instead. Without a current key only plaintext files can be
+// read.
+//
+//	rekey [--payload local_event.json] [--decrypt] [file ...]
+func rekeyCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rekey", flag.ContinueOnError)
+	in := fs.String("payload", "", "payload whose local state files to rekey")
+	decrypt := fs.Bool("decrypt", false, "write the files back as plaintext")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	oldKey, err := seal.KeyFromEnv(seal.KeyEnv)
+	if err != nil {
+		return err
+	}
+	var newKey []byte
+	if !*decrypt {
+		if newKey, err = seal.KeyFromEnv(rekeyNewKeyEnv); err != nil {
+			return fmt.Errorf("%s: %w", rekeyNewKeyEnv, err)
+		}
+		if newKey == nil {
+			return fmt.Errorf("%s is required (or --decrypt)", rekeyNewKeyEnv)
+		}
+	}
+
+	files := fs.Args()
+	if *in != "" {
+		raw, err := os.ReadFile(*in)
+		if err != nil {
+			return fmt.Errorf("failed to read payload: %w", err)
+		}
+		payload, err := config.ParseDCAPayload(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *in, err)
+		}
+		files = append(localStateFiles(payload), files...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files to rekey; pass --payload or file names")
+	}
+
+	failed := 0
+	for _, path := range files {
+		err := seal.Rekey(path, oldKey, newKey)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			fmt.Printf("  ⏭️ %s: not there yet\n", path)
+		case err != nil:
+			fmt.Printf("  ❌ %s: %v\n", path, err)
+			failed++
+		case newKey == nil:
+			fmt.Printf("  🔓 %s\n", path)
+		default:
+			fmt.Printf("  🔐 %s\n", path)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d file(s) could not be rekeyed and were left as they were", failed, len(files))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/route"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// runSellStrategy executes a DCA-out run: it sells enough of the base asset
+// for quoteAmount of proceeds at the current price, unless the price is
+// below strategy.minPrice
+func runSellStrategy(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) error {
+	log.Printf("🔍 Starting DCA-out strategy execution...")
+
+	symbol := payload.Strategy.Symbol
+	base, quote, err := exchange.SplitSymbol(symbol)
+	if err != nil {
+		return err
+	}
+	proceeds, err := decimal.NewFromString(payload.Strategy.QuoteAmount)
+	if err != nil {
+		return fmt.Errorf("invalid quote amount: %w", err)
+	}
+	seller, ok := exc.(exchange.MarketSeller)
+	if !ok {
+		return fmt.Errorf("%s does not support market sells", payload.Exchange.Name)
+	}
+
+	spanCtx, end := run.StartSpan(ctx, "sizing")
+	sz, err := sizeSell(spanCtx, exc, symbol, proceeds)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to size order: %w", err)
+	}
+	log.Printf("💱 %s at %s: selling %s %s for ~%s", symbol, describePrice(ctx, symbol, sz.Price), describeQuantity(ctx, symbol, sz.OrderQuantity), base, describeQuote(ctx, symbol, proceeds))
+
+	// Never sell below the floor; a skip is not a failure
+	skip, err := guard.MinPrice(payload.Strategy, sz.Price)
+	if err != nil {
+		return err
+	}
+	if skip != nil {
+		log.Printf("⏭️ Run %s", skip)
+		res.Skip = skip
+		sendSkipNotification(ctx, payload, skip)
+		return nil
+	}
+	res.Sizing = sz
+
+	spanCtx, end = run.StartSpan(ctx, "exchange.balance")
+	available, err := exc.GetBalance(spanCtx, base)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to get balance: %w", err)
+	}
+	if available.LessThan(sz.OrderQuantity) {
+		// The exchange would refuse the order
+		return failure.Mark(failure.CodeOrderRejected, fmt.Errorf("insufficient %s to sell: %s free, %s needed", base, available.String(), sz.OrderQuantity.String()))
+	}
+
+	// Step 1: Announce the order; the heads-up is informational, nothing waits for a reply
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPreTrade,
+		Symbol:   symbol,
+		Notional: proceeds,
+		Summary:  fmt.Sprintf("⏳ About to sell %s %s of %s for ~%s", describeQuantity(ctx, symbol, sz.OrderQuantity), base, symbol, describeQuote(ctx, symbol, proceeds)),
+		Details: append([]notify.Detail{
+			{Label: "Price", Value: describePrice(ctx, symbol, sz.Price)},
+			{Label: "Dry Run", Value: fmt.Sprint(payload.Flags.DryRun)},
+		}, sizingDetails(ctx, symbol, res)...),
+	})
+	if err := waitAfterPreTrade(ctx, payload); err != nil {
+		return err
+	}
+
+	// Step 2: Place market sell order
+	if payload.Flags.DryRun {
+		log.Printf("🧪 DRY RUN: Simulating market sell order for %s %s", describeQuantity(ctx, symbol, sz.OrderQuantity), symbol)
+	} else {
+		log.Printf("📉 Placing market sell order: %s %s", describeQuantity(ctx, symbol, sz.OrderQuantity), symbol)
+	}
+
+	if err := run.Simulate(ctx, config.SimulateOrder); err != nil {
+		return fmt.Errorf("failed to place order: %w", err)
+	}
+	orderCtx := withIdempotencyKey(ctx, payload, "sell")
+	in, err := writeIntent(orderCtx, payload, exc, "sell", sz.OrderQuantity)
+	if err != nil {
+		return err
+	}
+	spanCtx, end = run.StartSpan(orderCtx, "exchange.placeOrder")
+	order, err := exchange.PlaceOnce(spanCtx, exc, symbol, func(ctx context.Context) (*exchange.Order, error) {
+		return seller.PlaceMarketSellOrder(ctx, symbol, sz.OrderQuantity)
+	})
+	end()
+	settleIntent(ctx, payload, in, order, err)
+	if err != nil {
+		return orderFailed(err)
+	}
+	res.Order = order
+
+	sz.ReceivedAmount = route.Received(order, route.Leg{Symbol: symbol, Side: route.SideSell, From: base, To: quote})
+	log.Printf("✅ Order executed successfully:")
+	log.Printf("   Order ID: %s", order.ID)
+	log.Printf("   Client Order ID: %s", order.ClientOrderID)
+	log.Printf("   Symbol: %s", order.Symbol)
+	log.Printf("   Quantity: %s", describeQuantity(ctx, symbol, order.Quantity))
+	log.Printf("   Price: %s", describePrice(ctx, symbol, order.Price))
+	log.Printf("   Status: %s", order.Status)
+	fees := feeDetails(ctx, order)
+	for _, d := range fees {
+		log.Printf("   %s: %s", d.Label, d.Value)
+	}
+	log.Printf("   Received: %s (target %s)", describeQuote(ctx, symbol, sz.ReceivedAmount), describeQuote(ctx, symbol, proceeds))
+
+	details := []notify.Detail{
+		{Label: "Order ID", Value: order.ID},
+		{Label: "Price", Value: describePrice(ctx, symbol, order.Price)},
+		{Label: "Status", Value: order.Status},
+	}
+	details = append(details, fees...)
+	details = append(details, notify.Detail{Label: "Dry Run", Value: fmt.Sprint(payload.Flags.DryRun)})
+	details = append(details, sizingDetails(ctx, symbol, res)...)
+	outcome := fmt.Sprintf("Sold %s %s for %s", describeQuantity(ctx, symbol, order.Quantity), symbol, describeQuote(ctx, symbol, sz.ReceivedAmount))
+	dispatch(ctx, notify.Event{
+		Type:     notify.EventPostTrade,
+		Symbol:   symbol,
+		Notional: sz.ReceivedAmount,
+		Summary:  "✅ " + outcome,
+		Details:  details,
+	})
+
+	// Step 3: Check the base asset left to sell
+	res.Balance = checkBalance(ctx, payload, exc, sz.OrderQuantity, outcome)
+
+	return nil
+}
+
+// sizeSell converts the requested proceeds to a base quantity at the
+// exchange's last price, rounded down to its lot step
+func sizeSell(ctx context.Context, exc exchange.Exchange, symbol string, proceeds decimal.Decimal) (*sizing.Sizing, error) {
+	ticker, ok := exc.(exchange.PriceTicker)
+	if !ok {
+		return nil, fmt.Errorf("selling needs an exchange that reports prices")
+	}
+	price, err := ticker.LastPrice(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s price: %w", symbol, err)
+	}
+
+	step := exchange.DefaultLotStep
+	if sizer, ok := exc.(exchange.LotSizer); ok {
+		if step, err = sizer.LotStep(ctx, symbol); err != nil {
+			return nil, fmt.Errorf("failed to get %s lot step: %w", symbol, err)
+		}
+	}
+	quantity, err := sizing.SellQuantity(proceeds, price, step)
+	if err != nil {
+		return nil, err
+	}
+
+	_, quote, _ := exchange.SplitSymbol(symbol)
+	return &sizing.Sizing{
+		FeeHandling:     sizing.FeeInclude,
+		RequestedAmount: proceeds,
+		OrderAmount:     quantity.Mul(price).Round(sizing.QuotePlaces(asset.Canonical("", quote))),
+		Price:           price,
+		OrderQuantity:   quantity,
+	}, nil
+}
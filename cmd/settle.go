@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/settle"
+)
+
+// awaitSettlement waits up to exchange.options.settlementTimeout for a
+// market buy the exchange acknowledged before it filled, looking it up
+// from exchange.options.settlementPollInterval on. It returns the
+// order as last seen and, while that is still not final, why; an order
+// that ended rejected or canceled without filling is an error. With
+// strategy.cancelUnfilledAfterSeconds the wait lasts that long instead,
+// and an order still not final is canceled, keeping what filled.
+func awaitSettlement(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, order *exchange.Order) (*exchange.Order, string, error) {
+	timeout := payload.Exchange.Options.Settlement()
+	cancelAfter := payload.Strategy.CancelUnfilledAfter()
+	if cancelAfter > 0 {
+		timeout = cancelAfter
+	}
+	log.Printf("⏳ Order %s is %s; waiting up to %s for it to settle", order.ID, order.Status, timeout)
+	ctx, end := run.StartSpan(ctx, "exchange.settle")
+	waiter := settle.Waiter{Timeout: timeout, Interval: payload.Exchange.Options.SettlementPoll()}
+	if options := payload.Exchange.Options; options != nil && options.UseWebsocketFills {
+		stream, err := newOrderStreamer(ctx, payload, exc)
+		if err != nil {
+			log.Printf("⚠️ Websocket fills unavailable, polling instead: %v", err)
+		}
+		waiter.Stream = stream
+	}
+	res := waiter.Wait(ctx, exc, order)
+	end()
+	if res.StreamErr != nil {
+		log.Printf("⚠️ Order stream ended without a final update, polled instead: %v", res.StreamErr)
+	}
+
+	order = res.Order
+	if res.Provisional && cancelAfter > 0 {
+		log.Printf("✋ Order %s still %s after %s; canceling it", order.ID, order.Status, timeout)
+		canceled, err := settle.Cancel(ctx, exc, order)
+		if err != nil {
+			run.Warn(ctx, "settle", "cancel", err)
+			return canceled, fmt.Sprintf("%s; %v", res.Reason, err), nil
+		}
+		if canceled.Status != exchange.OrderStatusFilled && canceled.Quantity.IsZero() {
+			return nil, "", fmt.Errorf("order %s did not fill within %s and was canceled", order.ID, timeout)
+		}
+		order, res.Provisional = canceled, false
+	}
+	switch {
+	case res.Provisional:
+		log.Printf("⚠️ Order %s pending settlement after %d lookups: %s", order.ID, res.Polls, res.Reason)
+		return order, res.Reason, nil
+	case order.Status != exchange.OrderStatusFilled && order.Quantity.IsZero():
+		return nil, "", fmt.Errorf("order %s was %s after the exchange accepted it", order.ID, order.Status)
+	}
+	if res.Streamed {
+		log.Printf("✅ Order %s settled %s on the user-data stream after %d lookups", order.ID, order.Status, res.Polls)
+	} else {
+		log.Printf("✅ Order %s settled %s after %d lookups", order.ID, order.Status, res.Polls)
+	}
+	return order, "", nil
+}
+
+// newOrderStreamer returns what follows the venue's orders over its
+// user-data websocket: the exchange itself when it can, otherwise a
+// standalone client for Binance or OKX. It is nil for dry runs, whose mock
+// orders no stream would report.
+func newOrderStreamer(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange) (exchange.OrderStreamer, error) {
+	if streamer, ok := exc.(exchange.OrderStreamer); ok {
+		return streamer, nil
+	}
+	venue := payload.Exchange
+	switch {
+	case payload.Flags.DryRun:
+		return nil, nil
+	case venue.Options.Sandbox:
+		return nil, fmt.Errorf("the standalone stream clients only reach the live venues, not options.sandbox")
+	case venue.Name != "binance" && venue.Name != "okx":
+		return nil, fmt.Errorf("websocket fills are not supported on %s", venue.Name)
+	}
+	creds, err := exchangeCredentials(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	if venue.Name == "binance" {
+		return exchange.NewBinanceOrderStream(creds.APIKey), nil
+	}
+	return exchange.NewOKXOrderStream(creds.APIKey, creds.APISecret, creds.Passphrase), nil
+}
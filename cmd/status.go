@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/status"
+)
+
+// newStatus opens state.status; it is nil when not configured
+func newStatus(ctx context.Context, payload *config.DCAPayload) (*status.Status, error) {
+	if payload.State == nil || payload.State.Status == nil {
+		return nil, nil
+	}
+	cfg := payload.State.Status
+	if cfg.Dir != "" {
+		key, err := localKey()
+		if err != nil {
+			return nil, err
+		}
+		return status.New(status.NewSealedFileStore(cfg.Dir, key), ""), nil
+	}
+	client, err := newS3Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status.New(status.NewS3Store(client, cfg.Bucket), cfg.Prefix), nil
+}
+
+// checkPaused skips the run while the pause switch set with /pause is on.
+// A pause whose end has passed is switched off and the run goes ahead. A
+// switch that cannot be read fails the run rather than trading past it.
+func checkPaused(ctx context.Context, payload *config.DCAPayload) (*guard.Skip, error) {
+	st, err := newStatus(ctx, payload)
+	if err != nil || st == nil {
+		return nil, err
+	}
+	loc, err := payload.Strategy.Location()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	_, end := run.StartSpan(ctx, "status.pause")
+	pause, err := st.CurrentPause(ctx, now)
+	end()
+	if err != nil && !pause.Expired(now) {
+		return nil, err
+	}
+	if err != nil {
+		// Over either way; the next run tries to switch it off again
+		run.Warn(ctx, "status", "pause", err)
+	}
+	if !pause.Active(now) {
+		return nil, nil
+	}
+	return &guard.Skip{Guard: "pause", Reason: pause.Reason(loc)}, nil
+}
+
+// monitorWhilePaused still evaluates the low-balance and holdings alerts of
+// a paused run, reading balances but placing nothing, so monitoring goes on
+// through the pause. Failures are only logged.
+func monitorWhilePaused(ctx context.Context, payload *config.DCAPayload) {
+	if payload.Mode != config.ModeDCA || len(payload.Exchange.Accounts) > 0 || payload.Strategy.Native() {
+		return
+	}
+	if payload.Strategy.BalanceThreshold == "" && payload.Strategy.HoldingsAlert == nil {
+		return
+	}
+	ctx = withRateLimit(ctx, payload)
+	ctx, err := withExtraHeaders(ctx, payload)
+	if err != nil {
+		run.Warn(ctx, "pause", "monitor", err)
+		return
+	}
+	exc, err := newExchange(ctx, payload)
+	if err != nil {
+		run.Warn(ctx, "pause", "monitor", err)
+		return
+	}
+	log.Printf("👀 Paused, still checking the balance alerts")
+	// A percent-sized buy has no fixed spend to project the runway with
+	perRun, _ := decimal.NewFromString(payload.Strategy.QuoteAmount)
+	notifyLowBalance(ctx, payload, evaluateBalance(ctx, payload, exc, perRun), "Paused; no order placed")
+	if payload.Strategy.HoldingsAlert != nil {
+		checkHoldings(ctx, payload, exc)
+	}
+}
+
+// saveStatus saves res as the last result for /status. It is best effort,
+// like publishing.
+func saveStatus(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult) {
+	st, err := newStatus(ctx, payload)
+	if err == nil && st == nil {
+		return
+	}
+	if err == nil {
+		err = st.SaveResult(ctx, res)
+	}
+	if err != nil {
+		run.Warn(ctx, "status", "save", err)
+		return
+	}
+	log.Printf("📝 Saved %s result for /status", res.Status)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/kmspayload"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/support"
+)
+
+// supportBundleCommand writes a redacted support bundle to attach to a bug
+// report: the effective configuration, build and runtime, lint findings,
+// which endpoints are reachable and, with --last-run, the result and
+// warnings of the last run saved to state.status. Credentials are never
+// resolved, so only inline ones can reach the bundle, and those are
+// redacted.
+//
+//	support-bundle --event payload.json [--last-run] [--out support-bundle.json]
+func supportBundleCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("support-bundle", flag.ContinueOnError)
+	eventPath := fs.String("event", "local_event.json", "payload or event to describe")
+	lastRun := fs.Bool("last-run", false, "include the last run's result from state.status")
+	out := fs.String("out", "support-bundle.json", "file to write the bundle to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(*eventPath)
+	if err != nil {
+		return fmt.Errorf("failed to read event: %w", err)
+	}
+	// Unwrap the event as the handler would, EventBridge first
+	raw, _ = handler.EventBridgeUnwrapper(ctx, raw)
+	if raw, err = kmspayload.Unwrapper(newKMSClient)(ctx, raw); err != nil {
+		return fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+
+	rt, err := env.DetectRuntime()
+	runtimeName := string(rt)
+	if err != nil {
+		runtimeName = "unknown: " + err.Error()
+	}
+	b, payload := support.New(raw, runtimeName, time.Now())
+	if payload != nil {
+		if *lastRun {
+			b.SetLastResult(lastResult(ctx, payload))
+		}
+		b.Probes = support.ProbeAll(ctx, &http.Client{Timeout: support.ProbeTimeout}, support.Endpoints(payload))
+	}
+
+	data, err := b.Encode()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	fmt.Printf("✅ Wrote %s; review it before attaching it to an issue\n", *out)
+	if b.ConfigError != "" {
+		fmt.Printf("⚠️ The payload did not parse: %s\n", b.ConfigError)
+	}
+	return nil
+}
+
+// lastResult reads the result the last run saved to state.status
+func lastResult(ctx context.Context, payload *config.DCAPayload) (*result.ExecutionResult, error) {
+	st, err := newStatus(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, errors.New("state.status is not configured")
+	}
+	return st.LastResult(ctx)
+}
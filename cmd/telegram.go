@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/telegram"
+)
+
+// telegramBotPayloadEnv holds the payload the bot answers commands for when
+// Telegram delivers updates to the Function URL. Its
+// notifications.telegram.commands block configures the allow-list.
+const telegramBotPayloadEnv = "DCA_TELEGRAM_BOT_PAYLOAD"
+
+// telegramHTTPTimeout bounds Bot API requests; long polls hold one open for
+// up to telegram.PollTimeout
+const telegramHTTPTimeout = telegram.PollTimeout + 10*time.Second
+
+// newTelegramBot builds the command bot and its Bot API client for a payload
+// with notifications.telegram.commands
+func newTelegramBot(ctx context.Context, payload *config.DCAPayload) (*telegram.Bot, *telegram.Client, error) {
+	tg := payload.Notifications.Telegram
+	if tg == nil || tg.Commands == nil {
+		return nil, nil, fmt.Errorf("notifications.telegram.commands is not configured")
+	}
+	token, err := resolveSecret(ctx, telegramSource(tg), "botToken")
+	if err != nil {
+		return nil, nil, fmt.Errorf("botToken: %w", err)
+	}
+	st, err := newStatus(ctx, payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open state.status: %w", err)
+	}
+
+	bot := &telegram.Bot{
+		Commands: tg.Commands,
+		Status:   st,
+		Exchange: payload.Exchange.Name,
+		Symbol:   payload.Strategy.Symbol,
+		Balances: func(ctx context.Context) (telegram.BalanceReader, error) {
+			return newExchange(ctx, payload)
+		},
+		Format: money.New(payload.Notifications.Language, payload.Notifications.DisplayPrecision),
+	}
+	gate, err := newApprovalGate(ctx, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if gate != nil {
+		bot.Approvals = gate
+	}
+	client := telegram.NewClient(&http.Client{Timeout: telegramHTTPTimeout}, token)
+	return bot, client, nil
+}
+
+// newTelegramNotifier creates the notifier of a Telegram chat, split for
+// long events. A bot token that cannot be resolved is a run warning and
+// leaves the chat out; the log still records every event.
+func newTelegramNotifier(ctx context.Context, tg *config.TelegramConfig) notify.Notifier {
+	token, err := resolveSecret(ctx, telegramSource(tg), "botToken")
+	if err != nil {
+		run.Warn(ctx, "telegram", "botToken", err)
+		return nil
+	}
+	client := &http.Client{Timeout: config.TelegramTimeout}
+	return notify.ForChannel("telegram", notify.NewTelegram(client, token, tg.ChatID()))
+}
+
+// telegramSource is the credential source of the bot's secrets
+func telegramSource(tg *config.TelegramConfig) config.CredentialSource {
+	return config.CredentialSource{Type: tg.Type, Config: tg.Config}
+}
+
+// withTelegramWebhook answers Telegram updates delivered to the Function URL
+// from DCA_TELEGRAM_BOT_PAYLOAD and hands every other event to next
+func withTelegramWebhook(raw string, next func(ctx context.Context, event json.RawMessage) (*result.ExecutionResult, error)) (func(ctx context.Context, event json.RawMessage) (any, error), error) {
+	payload, err := config.ParseDCAPayload([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", telegramBotPayloadEnv, err)
+	}
+	if tg := payload.Notifications.Telegram; tg == nil || tg.Commands == nil {
+		return nil, fmt.Errorf("%s has no notifications.telegram.commands block", telegramBotPayloadEnv)
+	}
+
+	return func(ctx context.Context, event json.RawMessage) (any, error) {
+		update, ok, err := telegram.WebhookUpdate(ctx, event, func(ctx context.Context) (string, error) {
+			return resolveSecret(ctx, telegramSource(payload.Notifications.Telegram), "webhookSecret")
+		})
+		if !ok {
+			return next(ctx, event)
+		}
+		if errors.Is(err, telegram.ErrUnauthorized) {
+			log.Printf("🚫 %v", err)
+			return events.LambdaFunctionURLResponse{StatusCode: http.StatusUnauthorized}, nil
+		}
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			return events.LambdaFunctionURLResponse{StatusCode: http.StatusBadRequest}, nil
+		}
+
+		bot, client, err := newTelegramBot(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+		if err := bot.Answer(ctx, client, update); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+		return events.LambdaFunctionURLResponse{StatusCode: http.StatusOK}, nil
+	}, nil
+}
+
+// botCommand answers Telegram commands by long polling until interrupted
+//
+//	bot --payload payload.json
+func botCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bot", flag.ContinueOnError)
+	in := fs.String("payload", "local_event.json", "payload with notifications.telegram.commands")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("failed to read payload: %w", err)
+	}
+	payload, err := config.ParseDCAPayload(data)
+	if err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+
+	bot, client, err := newTelegramBot(ctx, payload)
+	if err != nil {
+		return err
+	}
+	log.Printf("🤖 Answering Telegram commands for %s on %s; Ctrl-C to stop", payload.Strategy.Symbol, payload.Exchange.Name)
+	return bot.Poll(ctx, client)
+}
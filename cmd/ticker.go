@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/route"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// observeTicker reads the ticker of symbol before a buy, logs it and
+// records it in res. The buy does not depend on it: a failure is a
+// warning. Routed buys are skipped, their symbol has no book of its own,
+// and so are runs that read it already, for strategy.maxPrice or
+// strategy.dipMultipliers.
+func observeTicker(ctx context.Context, exc exchange.Exchange, symbol string, res *result.ExecutionResult) {
+	if _, ok := exc.(*route.Exchange); ok || res.Ticker != nil {
+		return
+	}
+	spanCtx, end := run.StartSpan(ctx, "exchange.ticker")
+	ticker, err := exc.GetTicker(spanCtx, symbol)
+	end()
+	if err != nil {
+		run.Warn(ctx, "ticker", "get", err)
+		return
+	}
+	res.Ticker = &ticker
+	log.Printf("📊 Market %s: %s", symbol, describeTicker(ctx, ticker))
+}
+
+// checkMaxPrice reads the ticker of the strategy's symbol and skips the
+// buy while its last price is above strategy.maxPrice. Without a price the
+// run fails rather than buy above the ceiling.
+func checkMaxPrice(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, res *result.ExecutionResult) (*guard.Skip, error) {
+	ticker, err := readTicker(ctx, exc, payload.Strategy.Symbol, res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the price for maxPrice: %w", err)
+	}
+	return guard.MaxPrice(payload.Strategy, ticker.Last)
+}
+
+// readTicker returns the ticker of symbol before a buy: the one res
+// recorded when the run read it already, otherwise a fresh one, logged and
+// recorded in res
+func readTicker(ctx context.Context, exc exchange.Exchange, symbol string, res *result.ExecutionResult) (exchange.Ticker, error) {
+	if res.Ticker != nil {
+		return *res.Ticker, nil
+	}
+	if _, ok := exc.(*route.Exchange); ok {
+		return exchange.Ticker{}, fmt.Errorf("a routed buy of %s has no price of its own", symbol)
+	}
+	spanCtx, end := run.StartSpan(ctx, "exchange.ticker")
+	ticker, err := exc.GetTicker(spanCtx, symbol)
+	end()
+	if err != nil {
+		return exchange.Ticker{}, err
+	}
+	res.Ticker = &ticker
+	log.Printf("📊 Market %s: %s", symbol, describeTicker(ctx, ticker))
+	return ticker, nil
+}
+
+// tickerDetails returns the "Market" detail of notifications, when the
+// run read a ticker
+func tickerDetails(ctx context.Context, res *result.ExecutionResult) []notify.Detail {
+	if res.Ticker == nil {
+		return nil
+	}
+	return []notify.Detail{{Label: "Market", Value: describeTicker(ctx, *res.Ticker)}}
+}
+
+// describeTicker renders a ticker, e.g. "last 62,500.01 · bid 62,500.00 ·
+// ask 62,500.02 (spread 0.00%)"
+func describeTicker(ctx context.Context, t exchange.Ticker) string {
+	s := fmt.Sprintf("last %s · bid %s · ask %s", describePrice(ctx, t.Symbol, t.Last), describePrice(ctx, t.Symbol, t.Bid), describePrice(ctx, t.Symbol, t.Ask))
+	if spread := t.Spread(); spread.IsPositive() {
+		s += fmt.Sprintf(" (spread %s%%)", spread.Mul(decimal.NewFromInt(100)).StringFixed(3))
+	}
+	return s
+}
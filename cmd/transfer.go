@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// autoTransfer runs strategy.autoTransfer before a buy of amount: when the
+// free quote balance is short, the shortfall plus config.AutoTransferBuffer
+// of it is moved in from the funding or earn account, and the run fails
+// when that account cannot cover it either. Where the funding balance
+// cannot be read the buy goes ahead as without the option. Dry runs only
+// describe it.
+func autoTransfer(ctx context.Context, payload *config.DCAPayload, exc exchange.Exchange, amount decimal.Decimal, res *result.ExecutionResult) error {
+	quote, err := extractQuoteCurrency(payload.Strategy.Symbol)
+	if err != nil {
+		return err
+	}
+	if payload.Flags.DryRun {
+		log.Printf("🧪 DRY RUN: a %s balance short of %s would be topped up from the %s funding account", quote, amount.String(), payload.Exchange.Name)
+		return nil
+	}
+	ctx, end := run.StartSpan(ctx, "autoTransfer")
+	defer end()
+
+	free, err := exc.GetBalance(ctx, quote)
+	if err != nil {
+		run.Warn(ctx, "autoTransfer", "balance", err)
+		return nil
+	}
+	if free.GreaterThanOrEqual(amount) {
+		return nil
+	}
+
+	source, err := newFundingTransferer(ctx, payload.Exchange, exc)
+	if err != nil {
+		run.Warn(ctx, "autoTransfer", "funding", fmt.Errorf("failed to create funding client: %w", err))
+		return nil
+	}
+	if source == nil {
+		log.Printf("⚠️ %s has no funding account to top up from; placing the order with %s %s free", payload.Exchange.Name, free.String(), quote)
+		return nil
+	}
+	funding, err := source.GetFundingBalance(ctx, quote)
+	if err != nil {
+		run.Warn(ctx, "autoTransfer", "funding", err)
+		return nil
+	}
+	topUp, err := guard.TopUp(quote, free, funding, amount, config.AutoTransferBuffer)
+	if err != nil {
+		return failure.Mark(failure.CodeOrderRejected, err)
+	}
+
+	transfer, err := source.TransferToTrading(ctx, quote, topUp)
+	if err != nil {
+		return fmt.Errorf("failed to top up %s: %w", quote, err)
+	}
+	res.Transfer = transfer
+	log.Printf("💸 Moved %s %s from %s to the trading account (%s free, %s needed, transfer %s)", transfer.Amount.String(), quote, transfer.From, free.String(), amount.String(), transfer.ID)
+	return nil
+}
+
+// newFundingTransferer returns what moves the venue's funds into its
+// trading account: the exchange itself when it can, otherwise a standalone
+// client for the exchanges in config.AutoTransferExchanges. It is nil for
+// the others.
+func newFundingTransferer(ctx context.Context, venue config.ExchangeConfig, exc exchange.Exchange) (exchange.FundingTransferer, error) {
+	if source, ok := exc.(exchange.FundingTransferer); ok {
+		return source, nil
+	}
+	name := strings.ToLower(venue.Name)
+	if !slices.Contains(config.AutoTransferExchanges, name) {
+		return nil, nil
+	}
+	creds, err := exchangeCredentials(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	if name == "binance" {
+		return exchange.NewBinanceEarn(creds.APIKey, creds.APISecret), nil
+	}
+	return exchange.NewOKXFunding(creds.APIKey, creds.APISecret, creds.Passphrase), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/conformance"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// verifyExchangeCommand runs the conformance scenario against an exchange,
+// its sandbox unless --i-know-this-is-live is given, prints a table of the
+// steps and fails when any step did
+func verifyExchangeCommand(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify-exchange", flag.ContinueOnError)
+	name := fs.String("exchange", "", "exchange to verify: binance or okx")
+	credentialsPath := fs.String("credentials", "", "JSON file holding the exchange's credential source")
+	symbol := fs.String("symbol", "BTC-USDT", "symbol to trade")
+	amount := fs.String("amount", "10", "quote amount of the market buy")
+	sandbox := fs.Bool("sandbox", false, "use the exchange's sandbox")
+	live := fs.Bool("i-know-this-is-live", false, "allow running against the live exchange with real funds")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *credentialsPath == "" {
+		return errors.New("--exchange and --credentials are required")
+	}
+	if !*sandbox && !*live {
+		return errors.New("refusing to trade on the live exchange: pass --sandbox, or --i-know-this-is-live to spend real funds")
+	}
+	quote, err := decimal.NewFromString(*amount)
+	if err != nil || !quote.IsPositive() {
+		return fmt.Errorf("--amount: must be a positive number, got %q", *amount)
+	}
+
+	raw, err := os.ReadFile(*credentialsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read credentials: %w", err)
+	}
+	var credentials config.CredentialSource
+	if err := json.Unmarshal(raw, &credentials); err != nil {
+		return fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	if err := config.ValidateCredentialType(credentials.Type); err != nil {
+		return fmt.Errorf("credentials: %w", err)
+	}
+
+	payload := &config.DCAPayload{Exchange: config.ExchangeConfig{
+		Name:        *name,
+		Credentials: credentials,
+		Options:     &config.ExchangeOptions{Sandbox: *sandbox},
+	}}
+	exc, err := newExchange(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	ctx = run.WithID(ctx, run.NewID())
+	report := conformance.Scenario{Symbol: *symbol, Amount: quote}.Run(ctx, *name, exc)
+	if err := report.WriteTable(os.Stdout); err != nil {
+		return err
+	}
+	if report.Failed() {
+		return fmt.Errorf("%s failed %d conformance steps", *name, report.Count(conformance.Fail))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/tradingview"
+)
+
+// webhookPayloadEnv holds the payload TradingView alerts run against when the
+// function is invoked through its Function URL. The event itself only
+// carries the alert.
+const webhookPayloadEnv = "DCA_WEBHOOK_PAYLOAD"
+
+// newTradingViewTrigger builds the alert trigger from the webhook payload
+func newTradingViewTrigger(raw string) (*tradingview.Trigger, error) {
+	base, err := config.ParseDCAPayload([]byte(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", webhookPayloadEnv, err)
+	}
+	if base.Integrations.TradingView == nil {
+		return nil, fmt.Errorf("%s has no integrations.tradingview block", webhookPayloadEnv)
+	}
+	return &tradingview.Trigger{
+		Base:    base,
+		Resolve: resolvePassphrase,
+		// Warm instances share the limiter; cold starts begin with a fresh window
+		Limiter: tradingview.NewMemoryLimiter(),
+	}, nil
+}
+
+// resolvePassphrase reads the alert passphrase from its configured source
+func resolvePassphrase(ctx context.Context, source config.CredentialSource) (string, error) {
+	return resolveSecret(ctx, source, "passphrase")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/withdrawal"
+)
+
+// verifyWithdrawal checks strategy.withdrawal again at run time: the
+// address must still be valid and, when DCA_WITHDRAWAL_ALLOWLIST names an
+// SSM parameter, appear in the allow-list stored there. Call it right
+// before withdrawing; an allow-list that cannot be read fails the check.
+func verifyWithdrawal(ctx context.Context, payload *config.DCAPayload) error {
+	w := payload.Strategy.Withdrawal
+	if w == nil {
+		return nil
+	}
+	ctx, end := run.StartSpan(ctx, "withdrawal.verify")
+	defer end()
+
+	var list *withdrawal.AllowList
+	if param := os.Getenv(withdrawal.AllowListEnv); param != "" {
+		text, err := readParameter(ctx, param)
+		if err != nil {
+			return fmt.Errorf("failed to read withdrawal allow-list: %w", err)
+		}
+		list, err = withdrawal.ParseAllowList(text)
+		if err != nil {
+			return fmt.Errorf("invalid withdrawal allow-list %s: %w", param, err)
+		}
+	} else {
+		log.Printf("⚠️ %s is not set; the withdrawal address is only checked for its format", withdrawal.AllowListEnv)
+	}
+
+	if err := withdrawal.Verify(w.Network, w.Address, list); err != nil {
+		return err
+	}
+	log.Printf("🔐 Withdrawal address verified for %s", w.Network)
+	return nil
+}
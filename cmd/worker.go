@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sudowanderer/dca-bot-go/env"
+	"github.com/sudowanderer/dca-bot-go/internal/entrypoint"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/worker"
+)
+
+// runWorker long-polls the SQS queue in DCA_QUEUE_URL and runs the handler
+// on each message, serving a health endpoint for the orchestrator. SIGTERM
+// stops the polling; the run in flight finishes first, so the task's stop
+// timeout should exceed serverTimeout.
+func runWorker() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	queueURL := os.Getenv(env.QueueURLEnv)
+	if queueURL == "" {
+		log.Fatalf("%s=%s needs %s", env.RuntimeEnv, env.RuntimeSQSWorker, env.QueueURLEnv)
+	}
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		log.Fatalf("failed to load AWS config: %v", err)
+	}
+	w := &worker.Worker{
+		Queue:    worker.NewClient(cfg),
+		QueueURL: queueURL,
+		Handler:  newHandler(handler.Timeout(serverTimeout)),
+		Out:      os.Stdout,
+	}
+
+	// A health endpoint that cannot serve stops the worker, after the run
+	// in flight, so the orchestrator replaces it
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		if err := entrypoint.Serve(ctx, env.ListenAddr(env.RuntimeSQSWorker), w.Health()); err != nil {
+			cancel(fmt.Errorf("health endpoint failed: %w", err))
+		}
+	}()
+	w.Run(ctx)
+	if err := context.Cause(ctx); !errors.Is(err, context.Canceled) {
+		log.Fatal(err)
+	}
+}
@@ -1,6 +1,32 @@
 package env
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Runtime 标识二进制所运行的平台
+type Runtime string
+
+const (
+	RuntimeLambda Runtime = "lambda" // AWS Lambda
+	RuntimeGCF    Runtime = "gcf"    // Google Cloud Functions（HTTP 触发）
+	RuntimeAzure  Runtime = "azure"  // Azure Functions 自定义处理程序
+	RuntimeServer Runtime = "server" // 普通容器，如 Fly.io
+	RuntimeLocal  Runtime = "local"  // 本地读取 local_event.json
+
+	RuntimeSQSWorker Runtime = "sqs-worker" // 容器长轮询 SQS 队列，如 ECS/Fargate
+)
+
+// RuntimeEnv 强制指定运行平台，跳过自动检测；"server" 让容器以 HTTP 服务方式运行
+const RuntimeEnv = "DCA_RUNTIME"
+
+// QueueURLEnv 是 sqs-worker 轮询的 SQS 队列 URL
+const QueueURLEnv = "DCA_QUEUE_URL"
+
+// defaultPort 是平台未指定端口时 HTTP 服务监听的端口
+const defaultPort = "8080"
 
 // IsLambdaEnvironment 检测当前环境是否在 AWS Lambda 中
 func IsLambdaEnvironment() bool {
@@ -11,3 +37,60 @@ func IsLambdaEnvironment() bool {
 	}
 	return false
 }
+
+// DetectRuntime 根据环境变量检测运行平台。DCA_RUNTIME 优先；
+// 其值不是已知平台时返回错误，而不是悄悄回退到自动检测。
+func DetectRuntime() (Runtime, error) {
+	return detectRuntime(os.LookupEnv, IsLambdaEnvironment())
+}
+
+// detectRuntime 是 DetectRuntime 的可测试版本，lookup 读取环境变量
+func detectRuntime(lookup func(string) (string, bool), lambda bool) (Runtime, error) {
+	if v, ok := lookup(RuntimeEnv); ok && v != "" {
+		switch rt := Runtime(strings.ToLower(v)); rt {
+		case RuntimeLambda, RuntimeGCF, RuntimeAzure, RuntimeServer, RuntimeLocal, RuntimeSQSWorker:
+			return rt, nil
+		}
+		return "", fmt.Errorf("unknown %s %q (want lambda, gcf, azure, server, sqs-worker or local)", RuntimeEnv, v)
+	}
+	set := func(name string) bool {
+		v, ok := lookup(name)
+		return ok && v != ""
+	}
+	switch {
+	case lambda:
+		return RuntimeLambda, nil
+	case set("FUNCTION_TARGET"), set("K_SERVICE"):
+		// FUNCTION_TARGET 由 Functions Framework 设置，K_SERVICE 由第二代（Cloud Run）设置
+		return RuntimeGCF, nil
+	case set("FUNCTIONS_WORKER_RUNTIME"):
+		return RuntimeAzure, nil
+	}
+	return RuntimeLocal, nil
+}
+
+// Serves 报告该平台是否以 HTTP 服务接收调用
+func (r Runtime) Serves() bool {
+	return r == RuntimeGCF || r == RuntimeAzure || r == RuntimeServer
+}
+
+// ListenAddr 返回 HTTP 服务的监听地址（sqs-worker 在此提供健康检查）。
+// Azure 自定义处理程序使用 FUNCTIONS_CUSTOMHANDLER_PORT，GCF、Cloud Run、
+// Fly.io 和 sqs-worker 使用 PORT。
+func ListenAddr(r Runtime) string {
+	return listenAddr(r, os.LookupEnv)
+}
+
+// listenAddr 是 ListenAddr 的可测试版本
+func listenAddr(r Runtime, lookup func(string) (string, bool)) string {
+	names := []string{"PORT"}
+	if r == RuntimeAzure {
+		names = []string{"FUNCTIONS_CUSTOMHANDLER_PORT", "PORT"}
+	}
+	for _, name := range names {
+		if port, ok := lookup(name); ok && port != "" {
+			return ":" + port
+		}
+	}
+	return ":" + defaultPort
+}
@@ -44,3 +44,88 @@ func splitOnce(s string, sep byte) []string {
 	}
 	return []string{s}
 }
+
+// fakeEnv 返回读取给定变量的 lookup 函数
+func fakeEnv(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func TestDetectRuntime(t *testing.T) {
+	tests := []struct {
+		name   string
+		vars   map[string]string
+		lambda bool
+		want   Runtime
+	}{
+		{"local", nil, false, RuntimeLocal},
+		{"lambda", nil, true, RuntimeLambda},
+		{"gcf functions framework", map[string]string{"FUNCTION_TARGET": "DCA"}, false, RuntimeGCF},
+		{"gcf gen2", map[string]string{"K_SERVICE": "dca-bot"}, false, RuntimeGCF},
+		{"azure", map[string]string{"FUNCTIONS_WORKER_RUNTIME": "custom"}, false, RuntimeAzure},
+		{"server", map[string]string{"DCA_RUNTIME": "server"}, false, RuntimeServer},
+		{"sqs worker", map[string]string{"DCA_RUNTIME": "sqs-worker"}, false, RuntimeSQSWorker},
+		{"override wins over detection", map[string]string{"DCA_RUNTIME": "local", "K_SERVICE": "dca-bot"}, true, RuntimeLocal},
+		{"override is case-insensitive", map[string]string{"DCA_RUNTIME": "Server"}, false, RuntimeServer},
+		{"empty override is ignored", map[string]string{"DCA_RUNTIME": "", "FUNCTIONS_WORKER_RUNTIME": "custom"}, false, RuntimeAzure},
+		{"empty signal is ignored", map[string]string{"K_SERVICE": ""}, false, RuntimeLocal},
+		{"lambda wins over gcf", map[string]string{"K_SERVICE": "dca-bot"}, true, RuntimeLambda},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectRuntime(fakeEnv(tt.vars), tt.lambda)
+			if err != nil {
+				t.Fatalf("detectRuntime() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("detectRuntime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectRuntime_UnknownOverride(t *testing.T) {
+	if _, err := detectRuntime(fakeEnv(map[string]string{"DCA_RUNTIME": "k8s"}), false); err == nil {
+		t.Error("Expected an error for an unknown DCA_RUNTIME")
+	}
+}
+
+func TestRuntimeServes(t *testing.T) {
+	for rt, want := range map[Runtime]bool{
+		RuntimeLambda: false,
+		RuntimeGCF:    true,
+		RuntimeAzure:  true,
+		RuntimeServer: true,
+		RuntimeLocal:  false,
+
+		RuntimeSQSWorker: false,
+	} {
+		if got := rt.Serves(); got != want {
+			t.Errorf("%s.Serves() = %v, want %v", rt, got, want)
+		}
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   Runtime
+		vars map[string]string
+		want string
+	}{
+		{"default", RuntimeServer, nil, ":8080"},
+		{"port", RuntimeGCF, map[string]string{"PORT": "9000"}, ":9000"},
+		{"azure custom handler port", RuntimeAzure, map[string]string{"FUNCTIONS_CUSTOMHANDLER_PORT": "7071", "PORT": "9000"}, ":7071"},
+		{"azure falls back to port", RuntimeAzure, map[string]string{"PORT": "9000"}, ":9000"},
+		{"custom handler port is azure only", RuntimeServer, map[string]string{"FUNCTIONS_CUSTOMHANDLER_PORT": "7071"}, ":8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listenAddr(tt.rt, fakeEnv(tt.vars)); got != tt.want {
+				t.Errorf("listenAddr() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
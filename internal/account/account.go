@@ -0,0 +1,62 @@
+// Package account runs one strategy for each of several accounts on the
+// same exchange (exchange.accounts). Each account runs on its own copy of
+// the payload (see config.DCAPayload.ForAccount) with its own result,
+// client order IDs and notification route; one account failing does not
+// stop the others.
+package account
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// RouteKey names the notification route of a payload's events: its symbol,
+// followed by "@label" for the run of one account
+func RouteKey(payload *config.DCAPayload) string {
+	if payload.Account == "" {
+		return payload.Strategy.Symbol
+	}
+	return payload.Strategy.Symbol + "@" + payload.Account
+}
+
+// Run calls fn once per account of payload, in order, recording each
+// account's result in res.Accounts. fn runs with the account's payload and
+// a context carrying its label and notification route. The error lists the
+// accounts that failed; once any account placed an order it is marked
+// exchange.ErrOrderPlaced so the invocation is not retried.
+func Run(ctx context.Context, payload *config.DCAPayload, res *result.ExecutionResult,
+	fn func(context.Context, *config.DCAPayload, *result.ExecutionResult) error) error {
+	var errs []error
+	placed := false
+	for _, a := range payload.Exchange.Accounts {
+		accPayload := payload.ForAccount(a)
+		accCtx := notify.WithStrategy(run.WithAccount(ctx, a.Label), RouteKey(accPayload))
+		accRes := result.New(accCtx, accPayload)
+
+		log.Printf("👤 Running account %s", a.Label)
+		err := fn(accCtx, accPayload, accRes)
+		accRes.Finish(err)
+		res.Accounts = append(res.Accounts, accRes)
+		placed = placed || accRes.Order != nil
+		if err != nil {
+			log.Printf("❌ Account %s failed: %v", a.Label, err)
+			errs = append(errs, fmt.Errorf("account %s: %w", a.Label, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%d of %d accounts failed: %w", len(errs), len(payload.Exchange.Accounts), errors.Join(errs...))
+	if placed {
+		err = fmt.Errorf("%w: %w", exchange.ErrOrderPlaced, err)
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+const payloadJSON = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "accounts": [
+		{"label": "alice", "credentials": {"type": "env", "config": {"apiKeyEnv": "ALICE_KEY", "apiSecretEnv": "ALICE_SECRET"}}},
+		{"label": "bob", "credentials": {"type": "env", "config": {"apiKeyEnv": "BOB_KEY", "apiSecretEnv": "BOB_SECRET"}},
+			"notifications": {"telegram": {"type": "env", "config": {"chatId": "2"}}}},
+		{"label": "carol", "credentials": {"type": "env", "config": {"apiKeyEnv": "CAROL_KEY", "apiSecretEnv": "CAROL_SECRET"}},
+			"notifications": {"telegram": {"type": "env", "config": {"chatId": "3"}}}}
+	]},
+	"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+	"flags": {"dryRun": true}
+}`
+
+type recorder struct {
+	events []notify.Event
+}
+
+func (r *recorder) Notify(ctx context.Context, event notify.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func parse(t *testing.T) *config.DCAPayload {
+	t.Helper()
+	payload, err := config.ParseDCAPayload([]byte(payloadJSON))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	return payload
+}
+
+func TestRun_PartialFailure(t *testing.T) {
+	payload := parse(t)
+	res := result.New(context.Background(), payload)
+
+	var keys []string
+	err := Run(context.Background(), payload, res, func(ctx context.Context, p *config.DCAPayload, r *result.ExecutionResult) error {
+		keys = append(keys, p.Exchange.Credentials.Config["apiKeyEnv"].(string))
+		if run.Account(ctx) != p.Account {
+			t.Errorf("run.Account() = %q, want %q", run.Account(ctx), p.Account)
+		}
+		switch p.Account {
+		case "alice":
+			r.Order = &exchange.Order{ID: "1"}
+		case "bob":
+			return errors.New("insufficient balance")
+		}
+		return nil
+	})
+
+	if strings.Join(keys, ",") != "ALICE_KEY,BOB_KEY,CAROL_KEY" {
+		t.Errorf("credentials = %v, want each account's own, in order", keys)
+	}
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 accounts failed") || !strings.Contains(err.Error(), "account bob: insufficient balance") {
+		t.Errorf("Run() error = %v, want bob's failure", err)
+	}
+	if !errors.Is(err, exchange.ErrOrderPlaced) || exchange.IsRetriable(err) {
+		t.Errorf("Run() error = %v, want it not retriable once alice's order was placed", err)
+	}
+
+	want := []struct {
+		account string
+		status  result.Status
+	}{
+		{"alice", result.StatusExecuted},
+		{"bob", result.StatusFailed},
+		{"carol", result.StatusExecuted},
+	}
+	if len(res.Accounts) != len(want) {
+		t.Fatalf("Accounts = %d sections, want %d", len(res.Accounts), len(want))
+	}
+	for i, w := range want {
+		if got := res.Accounts[i]; got.Account != w.account || got.Status != w.status {
+			t.Errorf("Accounts[%d] = %s %s, want %s %s", i, got.Account, got.Status, w.account, w.status)
+		}
+	}
+}
+
+func TestRun_Succeeded(t *testing.T) {
+	payload := parse(t)
+	res := result.New(context.Background(), payload)
+	err := Run(context.Background(), payload, res, func(ctx context.Context, p *config.DCAPayload, r *result.ExecutionResult) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if len(res.Accounts) != 3 {
+		t.Errorf("Accounts = %d sections, want 3", len(res.Accounts))
+	}
+}
+
+func TestRun_NotificationIsolation(t *testing.T) {
+	payload := parse(t)
+	global := &recorder{}
+	own := map[string]*recorder{}
+	d := notify.NewDispatcher(config.NotificationConfig{}, global)
+	for _, a := range payload.Exchange.Accounts {
+		accPayload := payload.ForAccount(a)
+		if o := accPayload.Strategy.Notifications; o != nil {
+			own[a.Label] = &recorder{}
+			d.Route(RouteKey(accPayload), *o, own[a.Label])
+		}
+	}
+
+	if len(own) != 2 {
+		t.Fatalf("%d accounts with their own channel, want 2", len(own))
+	}
+
+	res := result.New(context.Background(), payload)
+	Run(context.Background(), payload, res, func(ctx context.Context, p *config.DCAPayload, r *result.ExecutionResult) error {
+		d.Dispatch(ctx, notify.Event{Type: notify.EventError, Summary: p.Account})
+		return errors.New("failed")
+	})
+
+	// alice has no override and keeps the global channel
+	if len(global.events) != 1 || global.events[0].Summary != "alice" {
+		t.Errorf("global channel got %v, want alice's event only", global.events)
+	}
+	for label, r := range own {
+		if len(r.events) != 1 || r.events[0].Summary != label {
+			t.Errorf("%s's channel got %v, want its own event only", label, r.events)
+		}
+	}
+}
+
+func TestRouteKey(t *testing.T) {
+	payload := parse(t)
+	if got := RouteKey(payload); got != "BTC-USDT" {
+		t.Errorf("RouteKey() = %q, want the symbol", got)
+	}
+	if got := RouteKey(payload.ForAccount(payload.Exchange.Accounts[1])); got != "BTC-USDT@bob" {
+		t.Errorf("RouteKey() = %q, want the symbol and label", got)
+	}
+}
@@ -0,0 +1,47 @@
+// Package alert keeps balance alerts from repeating on every run: an alert
+// is sent when its condition starts to hold, then stays quiet for a
+// cooldown while it keeps holding, and is rearmed once the condition
+// clears. Each alert is tracked on its own, so several firing in one run
+// do not hold each other back.
+package alert
+
+import (
+	"strings"
+	"time"
+)
+
+// Kinds of alert, the first part of a Key
+const (
+	KindLowBalance = "lowBalance"
+	KindHoldings   = "holdings"
+)
+
+// Key identifies one alert, e.g. the low balance of BTC-USDT on binance
+func Key(kind, exchange, subject string) string {
+	return kind + ":" + strings.ToLower(exchange) + ":" + subject
+}
+
+// State is when each alert whose condition still holds was last sent,
+// kept in the state store between runs
+type State struct {
+	Sent map[string]time.Time `json:"sent,omitempty"`
+}
+
+// Due records whether the condition of the alert key holds now and
+// reports whether to send it: when it starts to hold, and again once
+// cooldown has passed since it was last sent. A condition that cleared
+// forgets the alert, so the next time it holds it is sent at once.
+func (s *State) Due(key string, holds bool, now time.Time, cooldown time.Duration) bool {
+	if !holds {
+		delete(s.Sent, key)
+		return false
+	}
+	if sent, ok := s.Sent[key]; ok && now.Sub(sent) < cooldown {
+		return false
+	}
+	if s.Sent == nil {
+		s.Sent = map[string]time.Time{}
+	}
+	s.Sent[key] = now.UTC()
+	return true
+}
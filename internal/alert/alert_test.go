@@ -0,0 +1,55 @@
+package alert
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestState_Due(t *testing.T) {
+	const cooldown = 7 * 24 * time.Hour
+	low := Key(KindLowBalance, "Binance", "BTC-USDT")
+	holdings := Key(KindHoldings, "Binance", "BTC")
+	start := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return start.Add(time.Duration(n) * 24 * time.Hour) }
+
+	// Each run is one day; both alerts fire in the first run
+	runs := []struct {
+		low, holdings         bool // whether each condition holds
+		wantLow, wantHoldings bool // whether each alert is sent
+	}{
+		{true, true, true, true},    // day 0: both start to hold
+		{true, true, false, false},  // day 1: both quiet
+		{false, true, false, false}, // day 2: the balance was topped up
+		{true, true, true, false},   // day 3: low again is sent at once; holdings stays quiet
+		{true, true, false, true},   // day 7: holdings' cooldown has passed, low's has not
+	}
+	days := []int{0, 1, 2, 3, 7}
+
+	var s State
+	for i, run := range runs {
+		// Round-trip the state as the state store does between runs
+		data, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s = State{}
+		if err := json.Unmarshal(data, &s); err != nil {
+			t.Fatal(err)
+		}
+
+		now := day(days[i])
+		if got := s.Due(low, run.low, now, cooldown); got != run.wantLow {
+			t.Errorf("day %d: low balance due = %v, want %v", days[i], got, run.wantLow)
+		}
+		if got := s.Due(holdings, run.holdings, now, cooldown); got != run.wantHoldings {
+			t.Errorf("day %d: holdings due = %v, want %v", days[i], got, run.wantHoldings)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	if got := Key(KindHoldings, "OKX", "BTC"); got != "holdings:okx:BTC" {
+		t.Errorf("Key() = %s", got)
+	}
+}
@@ -0,0 +1,210 @@
+// Package approval enforces the two-person rule of flags.requireApproval.
+// A live run trades only a strategy whose hash is the approved hash in an
+// SSM parameter. A changed strategy is held, and its hash and what changed
+// are sent out, until a second person approves it by writing the hash to
+// the parameter or with /approve in the Telegram bot. The bot cannot tell
+// who edited the payload, so keeping the approver a different person is
+// up to the IAM and chat permissions of each.
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/plan"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// ErrNotFound is returned by Parameters.Get for a parameter that does not
+// exist, as before the first approval
+var ErrNotFound = errors.New("approval parameter not found")
+
+// Parameters reads and writes the approval parameter
+type Parameters interface {
+	Get(ctx context.Context, name string) (string, error)
+	Put(ctx context.Context, name, value string) error
+}
+
+// State is what state.status keeps of approvals
+type State struct {
+	// Hash and Strategy are the last strategy a run found approved, to
+	// summarize the next change against
+	Hash     string          `json:"hash,omitempty"`
+	Strategy config.Snapshot `json:"strategy,omitempty"`
+
+	// Pending is the hash of the strategy held for approval; /approve
+	// approves no other
+	Pending      string    `json:"pending,omitempty"`
+	PendingSince time.Time `json:"pendingSince,omitzero"`
+}
+
+// StateStore keeps the State between runs
+type StateStore interface {
+	Approval(ctx context.Context) (*State, error)
+	SaveApproval(ctx context.Context, state State) error
+}
+
+// Gate checks strategies against the approved hash
+type Gate struct {
+	Parameters Parameters
+	Parameter  string
+
+	// State, when set, records the approved strategy and the pending hash.
+	// Without it a change is summarized as the whole new strategy and
+	// /approve is unavailable.
+	State StateStore
+
+	Now func() time.Time // defaults to time.Now
+}
+
+// Decision is the outcome of checking a strategy
+type Decision struct {
+	Hash     string `json:"hash"`
+	Approved bool   `json:"approved"`
+
+	// Previous is the approved hash, empty before the first approval
+	Previous string `json:"previous,omitempty"`
+
+	// Changes are the strategy fields that differ from the last strategy
+	// found approved; every field is new before there is one
+	Changes []plan.Change `json:"changes,omitempty"`
+}
+
+// Check reads the approved hash and compares it with the hash of
+// payload's strategy. A parameter that cannot be read fails the check, as
+// trading past it would defeat the rule; state.status failures are only
+// warnings.
+func (g *Gate) Check(ctx context.Context, payload *config.DCAPayload) (*Decision, error) {
+	fields, err := payload.StrategyFields()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := payload.StrategyHash()
+	if err != nil {
+		return nil, err
+	}
+	approved, err := g.Parameters.Get(ctx, g.Parameter)
+	if errors.Is(err, ErrNotFound) {
+		approved, err = "", nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approved hash from %s: %w", g.Parameter, err)
+	}
+	d := &Decision{Hash: hash, Previous: normalize(approved)}
+	d.Approved = d.Previous == hash
+
+	state := g.load(ctx)
+	if d.Approved {
+		if state != nil && (state.Hash != hash || state.Pending != "") {
+			g.save(ctx, State{Hash: hash, Strategy: fields})
+		}
+		return d, nil
+	}
+
+	var last config.Snapshot
+	if state != nil {
+		last = state.Strategy
+	}
+	d.Changes = plan.Diff(last, fields)
+	if state != nil && state.Pending != hash {
+		state.Pending, state.PendingSince = hash, g.now().UTC()
+		g.save(ctx, *state)
+	}
+	return d, nil
+}
+
+// Approve writes hash to the approval parameter on behalf of by, e.g.
+// "telegram chat 42". Only the hash of the change awaiting approval is
+// accepted, so a typo cannot approve anything else.
+func (g *Gate) Approve(ctx context.Context, hash, by string) error {
+	if g.State == nil {
+		return errors.New("approving from the bot requires state.status")
+	}
+	state, err := g.State.Approval(ctx)
+	if err != nil {
+		return err
+	}
+	hash = normalize(hash)
+	switch {
+	case state.Pending == "":
+		return errors.New("no strategy change awaits approval")
+	case state.Pending != hash:
+		return fmt.Errorf("%s is not the change awaiting approval", hash)
+	}
+	if err := g.Parameters.Put(ctx, g.Parameter, hash); err != nil {
+		return fmt.Errorf("failed to write approved hash to %s: %w", g.Parameter, err)
+	}
+	log.Printf("✅ Strategy %s approved by %s", hash, by)
+	return nil
+}
+
+// load reads the state; nil without a StateStore or when it cannot be read
+func (g *Gate) load(ctx context.Context) *State {
+	if g.State == nil {
+		return nil
+	}
+	state, err := g.State.Approval(ctx)
+	if err != nil {
+		run.Warn(ctx, "approval", "state", err)
+		return nil
+	}
+	return state
+}
+
+func (g *Gate) save(ctx context.Context, state State) {
+	if err := g.State.SaveApproval(ctx, state); err != nil {
+		run.Warn(ctx, "approval", "state", err)
+	}
+}
+
+func (g *Gate) now() time.Time {
+	if g.Now != nil {
+		return g.Now()
+	}
+	return time.Now()
+}
+
+// normalize trims a hash as pasted or typed
+func normalize(hash string) string {
+	return strings.ToLower(strings.TrimSpace(hash))
+}
+
+// maxChanges bounds the changes listed in a notification
+const maxChanges = 10
+
+// Event is the notification asking for approval of the strategy of symbol
+// held by d
+func Event(d *Decision, symbol, parameter string) notify.Event {
+	previous := d.Previous
+	if previous == "" {
+		previous = "none yet"
+	}
+	changes := make([]string, 0, maxChanges+1)
+	for i, c := range d.Changes {
+		if i == maxChanges {
+			changes = append(changes, fmt.Sprintf("… and %d more", len(d.Changes)-maxChanges))
+			break
+		}
+		changes = append(changes, c.String())
+	}
+	if len(changes) == 0 {
+		changes = append(changes, "none since the last approved strategy")
+	}
+	return notify.Event{
+		Type:    notify.EventSkip,
+		Symbol:  symbol,
+		Summary: fmt.Sprintf("✋ %s strategy change awaits approval; no order until a second person approves it", symbol),
+		Details: []notify.Detail{
+			{Label: "Hash", Value: d.Hash},
+			{Label: "Approved Hash", Value: previous},
+			{Label: "Changes", Value: strings.Join(changes, "; ")},
+			{Label: "Approve", Value: fmt.Sprintf("write %s to SSM parameter %s, or send /approve %s to the bot", d.Hash, parameter, d.Hash)},
+		},
+	}
+}
@@ -0,0 +1,226 @@
+package approval_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/approval"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/plan"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/status"
+)
+
+const parameter = "/dca/family/approved-strategy"
+
+// fakeSSM is Parameter Store in memory
+type fakeSSM struct {
+	values map[string]string
+	err    error
+}
+
+func (s *fakeSSM) Get(ctx context.Context, name string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	value, ok := s.values[name]
+	if !ok {
+		return "", approval.ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *fakeSSM) Put(ctx context.Context, name, value string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.values[name] = value
+	return nil
+}
+
+func payload(t *testing.T, quoteAmount string) *config.DCAPayload {
+	t.Helper()
+	input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "` + quoteAmount + `"},
+		"flags": {"requireApproval": {"parameter": "` + parameter + `"}}}`
+	p, err := config.ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func hash(t *testing.T, p *config.DCAPayload) string {
+	t.Helper()
+	h, err := p.StrategyHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func newGate(ssm *fakeSSM, st *status.Status) *approval.Gate {
+	return &approval.Gate{
+		Parameters: ssm,
+		Parameter:  parameter,
+		State:      st,
+		Now:        func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) },
+	}
+}
+
+func check(t *testing.T, gate *approval.Gate, p *config.DCAPayload) *approval.Decision {
+	t.Helper()
+	d, err := gate.Check(context.Background(), p)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	return d
+}
+
+// TestGate_Flow follows a strategy from its first run through approval,
+// a change to a huge quoteAmount and that change's approval in SSM
+func TestGate_Flow(t *testing.T) {
+	ssm := &fakeSSM{values: map[string]string{}}
+	st := status.New(status.NewFileStore(t.TempDir()), "")
+	gate := newGate(ssm, st)
+	ctx := context.Background()
+
+	// Nothing approved yet: the run is held and the request lists the strategy
+	first := payload(t, "10")
+	d := check(t, gate, first)
+	if d.Approved || d.Hash != hash(t, first) || d.Previous != "" {
+		t.Fatalf("first Check() = %+v, want the new strategy held", d)
+	}
+	if len(d.Changes) == 0 || d.Changes[0].Kind != plan.Added {
+		t.Errorf("Changes = %v, want the whole strategy as added", d.Changes)
+	}
+	event := approval.Event(d, "BTC-USDT", parameter)
+	if event.Type != notify.EventSkip || !strings.Contains(event.Summary, "awaits approval") {
+		t.Errorf("Event() = %+v", event)
+	}
+	if got := detail(event, "Approve"); !strings.Contains(got, d.Hash) || !strings.Contains(got, parameter) || !strings.Contains(got, "/approve "+d.Hash) {
+		t.Errorf("Approve detail = %q, want the hash, the parameter and the command", got)
+	}
+
+	// Only the pending hash can be approved from the bot
+	if err := gate.Approve(ctx, "0123456789abcdef", "telegram chat 7"); err == nil {
+		t.Error("Approve() of another hash succeeded")
+	}
+	if err := gate.Approve(ctx, " "+strings.ToUpper(d.Hash)+" ", "telegram chat 7"); err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if ssm.values[parameter] != d.Hash {
+		t.Errorf("parameter = %q, want the approved hash", ssm.values[parameter])
+	}
+
+	// The next run trades and records the approved strategy
+	if d := check(t, gate, first); !d.Approved {
+		t.Fatalf("Check() after approval = %+v, want approved", d)
+	}
+	state, err := st.Approval(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Hash != hash(t, first) || state.Pending != "" || len(state.Strategy) == 0 {
+		t.Errorf("state = %+v, want the approved strategy and nothing pending", state)
+	}
+
+	// Someone edits the payload to a huge quoteAmount
+	huge := payload(t, "10000")
+	d = check(t, gate, huge)
+	if d.Approved || d.Previous != hash(t, first) {
+		t.Fatalf("Check() of the edit = %+v, want it held", d)
+	}
+	if len(d.Changes) != 1 || d.Changes[0].String() != "~ strategy.quoteAmount: 10 → 10000" {
+		t.Errorf("Changes = %v, want only the quoteAmount", d.Changes)
+	}
+	if state, _ := st.Approval(ctx); state.Pending != d.Hash || state.PendingSince.IsZero() {
+		t.Errorf("state = %+v, want the edit pending", state)
+	}
+	// Held runs keep asking for the same change
+	if again := check(t, gate, huge); again.Approved || len(again.Changes) != 1 {
+		t.Errorf("second Check() of the edit = %+v", again)
+	}
+
+	// A second person approves it in SSM directly
+	ssm.values[parameter] = d.Hash + "\n"
+	if d := check(t, gate, huge); !d.Approved {
+		t.Errorf("Check() after the SSM approval = %+v, want approved", d)
+	}
+	if err := gate.Approve(ctx, d.Hash, "telegram chat 7"); err == nil || !strings.Contains(err.Error(), "no strategy change awaits approval") {
+		t.Errorf("Approve() with nothing pending = %v", err)
+	}
+}
+
+func TestGate_WithoutState(t *testing.T) {
+	ssm := &fakeSSM{values: map[string]string{}}
+	gate := &approval.Gate{Parameters: ssm, Parameter: parameter}
+	p := payload(t, "10")
+
+	d := check(t, gate, p)
+	if d.Approved || len(d.Changes) == 0 {
+		t.Errorf("Check() = %+v, want the strategy held and listed", d)
+	}
+	if err := gate.Approve(context.Background(), d.Hash, "telegram chat 7"); err == nil {
+		t.Error("Approve() without state.status succeeded")
+	}
+
+	ssm.values[parameter] = d.Hash
+	if d := check(t, gate, p); !d.Approved {
+		t.Errorf("Check() = %+v, want approved", d)
+	}
+}
+
+func TestGate_Failures(t *testing.T) {
+	// An unreadable parameter fails the check rather than trading past it
+	gate := newGate(&fakeSSM{err: errors.New("AccessDeniedException")}, nil)
+	if _, err := gate.Check(context.Background(), payload(t, "10")); err == nil || !strings.Contains(err.Error(), parameter) {
+		t.Errorf("Check() error = %v, want the parameter named", err)
+	}
+
+	// An unreadable state.status only drops the summary of the change
+	ssm := &fakeSSM{values: map[string]string{}}
+	broken := status.New(brokenStore{}, "")
+	warnings := &run.Warnings{}
+	ctx := run.WithWarnings(context.Background(), warnings)
+	d, err := newGate(ssm, broken).Check(ctx, payload(t, "10"))
+	if err != nil || d.Approved || len(warnings.List()) == 0 {
+		t.Errorf("Check() = %+v, %v with warnings %v; want the run held with a warning", d, err, warnings.List())
+	}
+}
+
+func TestEvent_ManyChanges(t *testing.T) {
+	d := &approval.Decision{Hash: "abc"}
+	for i := 0; i < 12; i++ {
+		d.Changes = append(d.Changes, plan.Change{Path: "strategy.x", Kind: plan.Added, New: i})
+	}
+	if got := detail(approval.Event(d, "BTC-USDT", parameter), "Changes"); !strings.HasSuffix(got, "… and 2 more") {
+		t.Errorf("Changes = %q, want the list cut", got)
+	}
+	if got := detail(approval.Event(d, "BTC-USDT", parameter), "Approved Hash"); got != "none yet" {
+		t.Errorf("Approved Hash = %q", got)
+	}
+}
+
+// brokenStore fails every read and write
+type brokenStore struct{}
+
+func (brokenStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, errors.New("AccessDenied")
+}
+
+func (brokenStore) Put(ctx context.Context, key string, data []byte) error {
+	return errors.New("AccessDenied")
+}
+
+func detail(event notify.Event, label string) string {
+	for _, d := range event.Details {
+		if d.Label == label {
+			return d.Value
+		}
+	}
+	return ""
+}
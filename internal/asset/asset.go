@@ -0,0 +1,113 @@
+// Package asset translates venue-specific asset codes (Kraken's XXBT and
+// ZUSD, HTX's lowercase codes, retired fork tickers) to the canonical codes
+// used everywhere else, so thresholds, ledgers and notifications all say "BTC".
+package asset
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// aliases maps codes that mean the same asset on every venue
+var aliases = map[string]string{
+	"XBT":    "BTC",
+	"BCC":    "BCH", // Binance's ticker before the 2017 rename
+	"BCHABC": "BCH", // Bitcoin ABC after the November 2018 fork
+	"BCHSV":  "BSV",
+}
+
+// venueAliases holds per-exchange codes. The first code listed for a
+// canonical asset is the one VenueCode returns.
+var venueAliases = map[string][]alias{
+	"kraken": {
+		{"XXBT", "BTC"}, {"XBT", "BTC"},
+		{"XETH", "ETH"},
+		{"XXDG", "DOGE"}, {"XDG", "DOGE"},
+		{"XLTC", "LTC"},
+		{"XXRP", "XRP"},
+		{"XXLM", "XLM"},
+		{"XXMR", "XMR"},
+		{"XZEC", "ZEC"},
+		{"XETC", "ETC"},
+		{"XREP", "REP"},
+		{"ZUSD", "USD"},
+		{"ZEUR", "EUR"},
+		{"ZGBP", "GBP"},
+		{"ZCAD", "CAD"},
+		{"ZJPY", "JPY"},
+		{"ZAUD", "AUD"},
+	},
+}
+
+type alias struct {
+	venue     string
+	canonical string
+}
+
+// known lists canonical codes that need no translation, so only genuinely
+// unfamiliar codes are logged
+var known = map[string]bool{
+	"BTC": true, "ETH": true, "USDT": true, "USDC": true, "FDUSD": true, "BUSD": true,
+	"TUSD": true, "DAI": true, "BNB": true, "OKB": true, "HT": true, "SOL": true,
+	"XRP": true, "ADA": true, "DOGE": true, "DOT": true, "LTC": true, "BCH": true,
+	"TRX": true, "AVAX": true, "LINK": true, "MATIC": true, "USD": true, "EUR": true,
+	"GBP": true, "JPY": true, "TRY": true,
+}
+
+var (
+	logged   sync.Map // unknown codes already logged
+	canonSet = buildCanonicalSet()
+)
+
+func buildCanonicalSet() map[string]bool {
+	set := map[string]bool{}
+	for code := range known {
+		set[code] = true
+	}
+	for _, canonical := range aliases {
+		set[canonical] = true
+	}
+	for _, list := range venueAliases {
+		for _, a := range list {
+			set[a.canonical] = true
+		}
+	}
+	return set
+}
+
+// Canonical returns the canonical code for an asset code reported by
+// exchange (e.g. "kraken", "htx"; may be empty). Codes are upper-cased;
+// unknown codes pass through unchanged and are logged once.
+func Canonical(exchange, code string) string {
+	upper := strings.ToUpper(strings.TrimSpace(code))
+
+	for _, a := range venueAliases[strings.ToLower(exchange)] {
+		if a.venue == upper {
+			return a.canonical
+		}
+	}
+	if canonical, ok := aliases[upper]; ok {
+		return canonical
+	}
+
+	if !canonSet[upper] {
+		if _, seen := logged.LoadOrStore(upper, true); !seen {
+			log.Printf("❔ Unknown asset code %q, using it unchanged", code)
+		}
+	}
+	return upper
+}
+
+// VenueCode returns the code exchange uses for a canonical asset, for
+// building requests. Assets without a venue-specific code are returned
+// upper-cased; callers lower-case them for venues such as HTX.
+func VenueCode(exchange, canonical string) string {
+	upper := strings.ToUpper(strings.TrimSpace(canonical))
+	for _, a := range venueAliases[strings.ToLower(exchange)] {
+		if a.canonical == upper {
+			return a.venue
+		}
+	}
+	return upper
+}
@@ -0,0 +1,97 @@
+package asset
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		exchange string
+		code     string
+		expected string
+	}{
+		// Kraken X/Z prefixed codes
+		{"kraken", "XXBT", "BTC"},
+		{"kraken", "XBT", "BTC"},
+		{"kraken", "XETH", "ETH"},
+		{"kraken", "XXDG", "DOGE"},
+		{"kraken", "ZUSD", "USD"},
+		{"kraken", "ZEUR", "EUR"},
+		{"Kraken", "xxbt", "BTC"},
+		// Kraken codes only mean something on Kraken
+		{"binance", "XETH", "XETH"},
+		// HTX reports lower-case codes
+		{"htx", "usdt", "USDT"},
+		{"htx", "btc", "BTC"},
+		// Retired fork tickers on any venue
+		{"", "BCHABC", "BCH"},
+		{"htx", "bchabc", "BCH"},
+		{"binance", "BCC", "BCH"},
+		{"", "BCHSV", "BSV"},
+		{"", "XBT", "BTC"},
+		// Canonical and unknown codes pass through
+		{"binance", "USDT", "USDT"},
+		{"okx", " fdusd ", "FDUSD"},
+		{"binance", "PEPE", "PEPE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exchange+"/"+tt.code, func(t *testing.T) {
+			if got := Canonical(tt.exchange, tt.code); got != tt.expected {
+				t.Errorf("Canonical(%q, %q) = %q, want %q", tt.exchange, tt.code, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVenueCode(t *testing.T) {
+	tests := []struct {
+		exchange  string
+		canonical string
+		expected  string
+	}{
+		{"kraken", "BTC", "XXBT"},
+		{"kraken", "USD", "ZUSD"},
+		{"kraken", "DOGE", "XXDG"},
+		{"kraken", "USDT", "USDT"},
+		{"binance", "BTC", "BTC"},
+		{"htx", "usdt", "USDT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.exchange+"/"+tt.canonical, func(t *testing.T) {
+			if got := VenueCode(tt.exchange, tt.canonical); got != tt.expected {
+				t.Errorf("VenueCode(%q, %q) = %q, want %q", tt.exchange, tt.canonical, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVenueCode_RoundTrip(t *testing.T) {
+	for exchange, list := range venueAliases {
+		for _, a := range list {
+			venue := VenueCode(exchange, a.canonical)
+			if got := Canonical(exchange, venue); got != a.canonical {
+				t.Errorf("%s: Canonical(VenueCode(%s)) = %s", exchange, a.canonical, got)
+			}
+		}
+	}
+}
+
+func TestCanonical_LogsUnknownOnce(t *testing.T) {
+	var buf bytes.Buffer
+	original := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(original)
+
+	Canonical("binance", "WOBBLE")
+	Canonical("binance", "wobble")
+	Canonical("binance", "BTC")
+
+	if got := strings.Count(buf.String(), "WOBBLE") + strings.Count(buf.String(), "wobble"); got != 1 {
+		t.Errorf("unknown code logged %d times, want once:\n%s", got, buf.String())
+	}
+}
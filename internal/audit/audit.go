@@ -0,0 +1,354 @@
+// Package audit keeps a tamper-evident trail of every live request that
+// moves funds on an exchange. Each request and its response is written as
+// its own JSON object, with credentials redacted, and carries the SHA-256 of
+// the object written before it, so an edited or deleted object breaks the
+// chain.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// Actions that are audited
+const (
+	ActionPlaceOrder  = "order.place"
+	ActionCancelOrder = "order.cancel"
+	ActionWithdraw    = "withdraw"
+	ActionConvertDust = "dust.convert"
+)
+
+// Redacted replaces credentials in audited requests
+const Redacted = "[REDACTED]"
+
+// MaxBodySize caps each body kept in an object, so objects stay small
+const MaxBodySize = 64 * 1024
+
+// writeTimeout bounds each queued write, which runs detached from the
+// invocation
+const writeTimeout = 30 * time.Second
+
+// redactedParams are query and form parameters that carry signatures
+var redactedParams = map[string]bool{"signature": true, "sign": true}
+
+// redactedHeaders carry API keys, signatures and passphrases
+var redactedHeaders = map[string]bool{
+	"Authorization":        true,
+	"X-Mbx-Apikey":         true,
+	"Ok-Access-Key":        true,
+	"Ok-Access-Sign":       true,
+	"Ok-Access-Passphrase": true,
+}
+
+// Record is one audited request and its outcome
+type Record struct {
+	Sequence    int64     `json:"sequence"` // position in the chain, from 1
+	ExecutionID string    `json:"executionId"`
+	Action      string    `json:"action"`
+	Request     Request   `json:"request"`
+	Response    *Response `json:"response,omitempty"`
+	Error       string    `json:"error,omitempty"` // set when no response arrived
+	RequestedAt time.Time `json:"requestedAt"`
+	RespondedAt time.Time `json:"respondedAt"`
+
+	// PreviousHash is the SHA-256 of the previous object, hex encoded;
+	// empty for the first object of the chain
+	PreviousHash string `json:"previousHash"`
+}
+
+// Request is a sanitized HTTP request
+type Request struct {
+	Method        string              `json:"method"`
+	URL           string              `json:"url"`
+	Header        map[string][]string `json:"header,omitempty"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// Response is an HTTP response as received
+type Response struct {
+	Status        int                 `json:"status"`
+	Header        map[string][]string `json:"header,omitempty"`
+	Body          string              `json:"body,omitempty"`
+	BodyTruncated bool                `json:"bodyTruncated,omitempty"`
+}
+
+// Redact copies req with its API key, signature and extra headers from
+// exchange.options.extraHeaders replaced by Redacted. Everything else,
+// including the body, is kept as sent.
+func Redact(req *http.Request, body []byte) Request {
+	u := *req.URL
+	u.RawQuery = redactParams(u.RawQuery)
+
+	extra := useragent.Extra(req.Context())
+	header := make(map[string][]string, len(req.Header))
+	for name, values := range req.Header {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] || extra.Get(name) != "" {
+			values = []string{Redacted}
+		}
+		header[name] = values
+	}
+
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		body = []byte(redactParams(string(body)))
+	}
+	r := Request{Method: req.Method, URL: u.String(), Header: header}
+	r.Body, r.BodyTruncated = truncate(body)
+	return r
+}
+
+// redactParams replaces signature values in a query string, keeping the
+// order and encoding of everything else
+func redactParams(query string) string {
+	if query == "" {
+		return query
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		name, _, _ := strings.Cut(pair, "=")
+		if redactedParams[strings.ToLower(name)] {
+			pairs[i] = name + "=" + Redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func truncate(body []byte) (string, bool) {
+	if len(body) > MaxBodySize {
+		return string(body[:MaxBodySize]), true
+	}
+	return string(body), false
+}
+
+// Hash returns the hex SHA-256 of an object, as stored in the next object's
+// PreviousHash
+func Hash(object []byte) string {
+	sum := sha256.Sum256(object)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks that objects, oldest first, form an unbroken chain starting
+// from previousHash ("" for the start of the log)
+func Verify(previousHash string, objects [][]byte) error {
+	for i, object := range objects {
+		var rec Record
+		if err := json.Unmarshal(object, &rec); err != nil {
+			return fmt.Errorf("object %d: %w", i, err)
+		}
+		if rec.PreviousHash != previousHash {
+			return fmt.Errorf("object %d (sequence %d): chain broken, previousHash %q, want %q", i, rec.Sequence, rec.PreviousHash, previousHash)
+		}
+		previousHash = Hash(object)
+	}
+	return nil
+}
+
+// ErrNotFound is returned by Store.Get for keys that do not exist
+var ErrNotFound = errors.New("not found")
+
+// Store keeps audit objects
+type Store interface {
+	// Create writes a new object; it must not replace an existing one
+	Create(ctx context.Context, key string, data []byte) error
+	// Put writes or replaces an object
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Head points at the last object of the chain. It is rewritten after each
+// object so the next run can continue the chain.
+type Head struct {
+	Key      string `json:"key"`
+	Hash     string `json:"hash"`
+	Sequence int64  `json:"sequence"`
+}
+
+// HeadKey is the name of the head object under the log's prefix
+const HeadKey = "head.json"
+
+// Logger writes records to a Store, chaining each to the one before. It is
+// safe for concurrent use. Concurrent runs sharing a prefix may fork the
+// chain; Verify then reports the fork.
+type Logger struct {
+	store  Store
+	prefix string
+	fatal  bool
+
+	mu     sync.Mutex // orders records in the chain
+	head   *Head      // loaded on first use
+	writes chan write
+	done   chan struct{}
+
+	errMu sync.Mutex
+	err   error // first failed write
+}
+
+type write struct {
+	key    string
+	object []byte
+	head   Head
+}
+
+// NewLogger creates a logger writing under prefix. Async loggers write in
+// the background until Close; a fatal logger refuses further records once a
+// write has failed.
+func NewLogger(store Store, prefix string, async, fatal bool) *Logger {
+	l := &Logger{store: store, prefix: prefix, fatal: fatal}
+	if async {
+		l.writes = make(chan write, 16)
+		l.done = make(chan struct{})
+		go l.drain()
+	}
+	return l
+}
+
+// Log chains rec to the previous record and writes it, or queues the write
+// for an async logger. Errors are also kept for Close.
+func (l *Logger) Log(ctx context.Context, rec Record) error {
+	if err := l.Check(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.head == nil {
+		head, err := l.loadHead(ctx)
+		if err != nil {
+			return l.fail(err)
+		}
+		l.head = head
+	}
+
+	rec.Sequence = l.head.Sequence + 1
+	rec.PreviousHash = l.head.Hash
+	object, err := json.Marshal(rec)
+	if err != nil {
+		return l.fail(fmt.Errorf("failed to encode audit record: %w", err))
+	}
+	w := write{key: l.objectKey(rec), object: object}
+	w.head = Head{Key: w.key, Hash: Hash(object), Sequence: rec.Sequence}
+
+	if l.done != nil {
+		l.head = &w.head
+		l.writes <- w
+		return nil
+	}
+	created, err := l.write(ctx, w)
+	if created {
+		// Chain on from the object even when only the head failed to update
+		l.head = &w.head
+	}
+	return l.fail(err)
+}
+
+// Check returns the first failed write of a fatal logger, so callers can
+// refuse to send a request that could not be audited
+func (l *Logger) Check() error {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	if l.fatal && l.err != nil {
+		return fmt.Errorf("audit log unavailable: %w", l.err)
+	}
+	return nil
+}
+
+// Close waits for queued writes and returns the first failure. The logger
+// must not be used afterwards.
+func (l *Logger) Close() error {
+	if l.done != nil {
+		l.mu.Lock()
+		close(l.writes)
+		l.mu.Unlock()
+		<-l.done
+	}
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	return l.err
+}
+
+func (l *Logger) drain() {
+	defer close(l.done)
+	for w := range l.writes {
+		// The invocation's context may end before the queue does, so each
+		// write gets its own bound instead
+		ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+		_, err := l.write(ctx, w)
+		cancel()
+		l.fail(err)
+	}
+}
+
+// write stores the object, then moves the head to it; created reports
+// whether the object was stored
+func (l *Logger) write(ctx context.Context, w write) (created bool, err error) {
+	if err := l.store.Create(ctx, w.key, w.object); err != nil {
+		return false, fmt.Errorf("failed to write audit object %s: %w", w.key, err)
+	}
+	head, err := json.Marshal(w.head)
+	if err != nil {
+		return true, err
+	}
+	if err := l.store.Put(ctx, l.prefix+HeadKey, head); err != nil {
+		return true, fmt.Errorf("failed to write audit head: %w", err)
+	}
+	return true, nil
+}
+
+// fail keeps the first error
+func (l *Logger) fail(err error) error {
+	if err == nil {
+		return nil
+	}
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	if l.err == nil {
+		l.err = err
+	}
+	log.Printf("⚠️ Audit log: %v", err)
+	return err
+}
+
+func (l *Logger) loadHead(ctx context.Context) (*Head, error) {
+	data, err := l.store.Get(ctx, l.prefix+HeadKey)
+	if errors.Is(err, ErrNotFound) {
+		return &Head{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit head: %w", err)
+	}
+	var head Head
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid audit head: %w", err)
+	}
+	return &head, nil
+}
+
+// objectKey files objects by day; the zero-padded sequence keeps a day's
+// objects listed in chain order
+func (l *Logger) objectKey(rec Record) string {
+	return fmt.Sprintf("%s%s/%012d-%s-%s.json", l.prefix, rec.RequestedAt.UTC().Format("2006/01/02"), rec.Sequence, rec.ExecutionID, rec.Action)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx whose audited requests are written to l
+func WithLogger(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or nil when auditing is off
+func FromContext(ctx context.Context) *Logger {
+	l, _ := ctx.Value(loggerKey{}).(*Logger)
+	return l
+}
@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// memStore is an in-memory Store; fail makes writes of matching keys fail
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	order   []string // created keys, in write order
+	fail    func(key string) bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: map[string][]byte{}}
+}
+
+func (s *memStore) Create(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[key]; ok {
+		return errors.New("precondition failed")
+	}
+	if s.fail != nil && s.fail(key) {
+		return errors.New("access denied")
+	}
+	s.objects[key] = data
+	s.order = append(s.order, key)
+	return nil
+}
+
+func (s *memStore) Put(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail != nil && s.fail(key) {
+		return errors.New("access denied")
+	}
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// chain returns the created objects in write order
+func (s *memStore) chain() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var objects [][]byte
+	for _, key := range s.order {
+		objects = append(objects, s.objects[key])
+	}
+	return objects
+}
+
+var testTime = time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func testRecord(action string) Record {
+	return Record{ExecutionID: "01ARYZ6S41", Action: action, RequestedAt: testTime, RespondedAt: testTime.Add(80 * time.Millisecond)}
+}
+
+func TestRedact(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.binance.com/api/v3/order?symbol=BTCUSDT&side=BUY&quoteOrderQty=25&timestamp=1&signature=abc123", nil)
+	req.Header.Set("X-MBX-APIKEY", "key-123")
+	req.Header.Set("OK-ACCESS-SIGN", "sig-456")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "dca-bot")
+	req.Header.Set("CF-Access-Client-Secret", "cf-789")
+	req = req.WithContext(useragent.WithExtra(req.Context(), http.Header{"Cf-Access-Client-Secret": {"cf-789"}}))
+
+	got := Redact(req, []byte("newClientOrderId=dca-1&signature=def456"))
+
+	if want := "https://api.binance.com/api/v3/order?symbol=BTCUSDT&side=BUY&quoteOrderQty=25&timestamp=1&signature=[REDACTED]"; got.URL != want {
+		t.Errorf("URL = %s, want %s", got.URL, want)
+	}
+	if got.Body != "newClientOrderId=dca-1&signature=[REDACTED]" {
+		t.Errorf("Body = %s, want the signature redacted and the rest kept", got.Body)
+	}
+	for _, name := range []string{"X-Mbx-Apikey", "Ok-Access-Sign", "Cf-Access-Client-Secret"} {
+		if v := got.Header[name]; len(v) != 1 || v[0] != Redacted {
+			t.Errorf("Header %s = %v, want redacted", name, v)
+		}
+	}
+	if v := got.Header["User-Agent"]; len(v) != 1 || v[0] != "dca-bot" {
+		t.Errorf("User-Agent = %v, want kept", v)
+	}
+	if req.Header.Get("X-MBX-APIKEY") != "key-123" || !strings.Contains(req.URL.RawQuery, "abc123") {
+		t.Error("Redact() modified the request it was given")
+	}
+	encoded, _ := json.Marshal(got)
+	for _, secret := range []string{"key-123", "sig-456", "abc123", "def456", "cf-789"} {
+		if strings.Contains(string(encoded), secret) {
+			t.Errorf("redacted request contains %q: %s", secret, encoded)
+		}
+	}
+
+	// JSON bodies are kept exactly as sent
+	req, _ = http.NewRequest(http.MethodPost, "https://www.okx.com/api/v5/trade/order", nil)
+	req.Header.Set("Content-Type", "application/json")
+	body := `{"instId":"BTC-USDT","side":"buy","sz":"25","sign":"kept"}`
+	if got := Redact(req, []byte(body)); got.Body != body {
+		t.Errorf("Body = %s, want %s", got.Body, body)
+	}
+
+	large := strings.Repeat("x", MaxBodySize+1)
+	if got := Redact(req, []byte(large)); len(got.Body) != MaxBodySize || !got.BodyTruncated {
+		t.Errorf("Body of %d bytes, truncated %v; want %d, true", len(got.Body), got.BodyTruncated, MaxBodySize)
+	}
+}
+
+func TestLogger_Chain(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+
+	l := NewLogger(store, "audit/", false, true)
+	for _, action := range []string{ActionPlaceOrder, ActionCancelOrder} {
+		if err := l.Log(ctx, testRecord(action)); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// A later run continues the chain from the head
+	l = NewLogger(store, "audit/", false, true)
+	if err := l.Log(ctx, testRecord(ActionPlaceOrder)); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+
+	objects := store.chain()
+	if len(objects) != 3 {
+		t.Fatalf("wrote %d objects, want 3", len(objects))
+	}
+	if err := Verify("", objects); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if want := "audit/2025/03/01/000000000002-01ARYZ6S41-order.cancel.json"; store.order[1] != want {
+		t.Errorf("key = %s, want %s", store.order[1], want)
+	}
+
+	var head Head
+	json.Unmarshal(store.objects["audit/head.json"], &head)
+	if head.Sequence != 3 || head.Key != store.order[2] || head.Hash != Hash(objects[2]) {
+		t.Errorf("head = %+v, want the third object", head)
+	}
+
+	var first Record
+	json.Unmarshal(objects[0], &first)
+	if first.Sequence != 1 || first.PreviousHash != "" {
+		t.Errorf("first record = sequence %d, previous %q; want 1 and no previous hash", first.Sequence, first.PreviousHash)
+	}
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	ctx := context.Background()
+	store := newMemStore()
+	l := NewLogger(store, "audit/", false, true)
+	for range 3 {
+		l.Log(ctx, testRecord(ActionPlaceOrder))
+	}
+	objects := store.chain()
+
+	edited := append([][]byte(nil), objects...)
+	edited[1] = []byte(strings.Replace(string(edited[1]), `"order.place"`, `"order.cancel"`, 1))
+	if err := Verify("", edited); err == nil || !strings.Contains(err.Error(), "object 2") {
+		t.Errorf("Verify(edited) error = %v, want a broken chain at object 2", err)
+	}
+
+	deleted := [][]byte{objects[0], objects[2]}
+	if err := Verify("", deleted); err == nil || !strings.Contains(err.Error(), "object 1") {
+		t.Errorf("Verify(deleted) error = %v, want a broken chain at object 1", err)
+	}
+}
+
+func TestLogger_Async(t *testing.T) {
+	store := newMemStore()
+	l := NewLogger(store, "audit/", true, true)
+	for range 40 {
+		if err := l.Log(context.Background(), testRecord(ActionPlaceOrder)); err != nil {
+			t.Fatalf("Log() error = %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if objects := store.chain(); len(objects) != 40 {
+		t.Errorf("wrote %d objects, want 40", len(objects))
+	} else if err := Verify("", objects); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	keys := append([]string(nil), store.order...)
+	if !sort.StringsAreSorted(keys) {
+		t.Error("async objects were written out of order")
+	}
+}
+
+func TestLogger_Failure(t *testing.T) {
+	ctx := context.Background()
+	failing := func(key string) bool { return strings.HasSuffix(key, "order.place.json") }
+
+	tests := []struct {
+		name       string
+		fatal      bool
+		wantRefuse bool
+	}{
+		{"fatal", true, true},
+		{"warn", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			store.fail = failing
+			l := NewLogger(store, "audit/", false, tt.fatal)
+
+			if err := l.Log(ctx, testRecord(ActionPlaceOrder)); err == nil {
+				t.Error("Log() error = nil, want the write failure")
+			}
+			err := l.Log(ctx, testRecord(ActionCancelOrder))
+			if refused := err != nil; refused != tt.wantRefuse {
+				t.Errorf("Log() after failure error = %v, want refused %v", err, tt.wantRefuse)
+			}
+			if (l.Check() != nil) != tt.wantRefuse {
+				t.Errorf("Check() = %v, want refused %v", l.Check(), tt.wantRefuse)
+			}
+			if err := l.Close(); err == nil || !strings.Contains(err.Error(), "access denied") {
+				t.Errorf("Close() error = %v, want the first failure", err)
+			}
+
+			if !tt.fatal {
+				// The failed object is not part of the chain
+				if err := Verify("", store.chain()); err != nil {
+					t.Errorf("Verify() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestLogger_HeadUnreadable(t *testing.T) {
+	store := newMemStore()
+	store.objects["audit/head.json"] = []byte("not json")
+	l := NewLogger(store, "audit/", false, true)
+	if err := l.Log(context.Background(), testRecord(ActionPlaceOrder)); err == nil || !strings.Contains(err.Error(), "invalid audit head") {
+		t.Errorf("Log() error = %v, want invalid audit head", err)
+	}
+	if len(store.order) != 0 {
+		t.Errorf("wrote %v, want nothing without a head to chain from", store.order)
+	}
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3API is the subset of the S3 client used here
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3Store keeps audit objects in a bucket
+type S3Store struct {
+	client S3API
+	bucket string
+}
+
+// NewS3Store creates a store writing to bucket
+func NewS3Store(client S3API, bucket string) *S3Store {
+	return &S3Store{client: client, bucket: bucket}
+}
+
+// Create writes key only if it does not exist yet, so an object is never
+// overwritten
+func (s *S3Store) Create(ctx context.Context, key string, data []byte) error {
+	return s.put(ctx, key, data, aws.String("*"))
+}
+
+// Put writes or replaces key
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	return s.put(ctx, key, data, nil)
+}
+
+func (s *S3Store) put(ctx context.Context, key string, data []byte, ifNoneMatch *string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+		IfNoneMatch: ifNoneMatch,
+	})
+	return err
+}
+
+// Get reads key, returning ErrNotFound when it does not exist
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(key)})
+	var noKey *types.NoSuchKey
+	if errors.As(err, &noKey) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// actions maps the exchange endpoints that move funds to their action, by
+// "METHOD path"
+var actions = map[string]string{
+	// Binance
+	"POST /api/v3/order":                   ActionPlaceOrder,
+	"POST /api/v3/orderList/oco":           ActionPlaceOrder,
+	"DELETE /api/v3/order":                 ActionCancelOrder,
+	"DELETE /api/v3/openOrders":            ActionCancelOrder,
+	"POST /sapi/v1/capital/withdraw/apply": ActionWithdraw,
+	"POST /sapi/v1/asset/dust":             ActionConvertDust,
+
+	// OKX
+	"POST /api/v5/trade/order":               ActionPlaceOrder,
+	"POST /api/v5/trade/order-algo":          ActionPlaceOrder,
+	"POST /api/v5/trade/cancel-order":        ActionCancelOrder,
+	"POST /api/v5/trade/cancel-algos":        ActionCancelOrder,
+	"POST /api/v5/asset/withdrawal":          ActionWithdraw,
+	"POST /api/v5/asset/convert-dust-assets": ActionConvertDust,
+}
+
+// Classify returns the audited action of a request; ok is false for
+// requests that move no funds, such as balance and price queries
+func Classify(req *http.Request) (action string, ok bool) {
+	action, ok = actions[req.Method+" "+req.URL.Path]
+	return action, ok
+}
+
+// Transport audits the requests Classify selects to the Logger in their
+// context; everything else passes straight through to Base
+type Transport struct {
+	Base http.RoundTripper // defaults to http.DefaultTransport
+	Now  func() time.Time  // defaults to time.Now
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip sends req and, for an audited request, writes the record
+// before returning the response. A fatal logger that has already failed
+// refuses to send, so no order goes out unaudited. When the record itself
+// cannot be written the response is still returned: the request has already
+// reached the exchange, and the failure is reported by Logger.Close.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	logger := FromContext(req.Context())
+	action, audited := Classify(req)
+	if logger == nil || !audited {
+		return base.RoundTrip(req)
+	}
+	if err := logger.Check(); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	rec := Record{
+		ExecutionID: run.ID(req.Context()),
+		Action:      action,
+		Request:     Redact(req, body),
+		RequestedAt: now().UTC(),
+	}
+
+	resp, err := base.RoundTrip(req)
+	rec.RespondedAt = now().UTC()
+	if err != nil {
+		rec.Error = err.Error()
+		logger.Log(req.Context(), rec)
+		return nil, err
+	}
+
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	rec.Response = &Response{Status: resp.StatusCode, Header: resp.Header}
+	rec.Response.Body, rec.Response.BodyTruncated = truncate(respBody)
+	if readErr != nil {
+		rec.Error = readErr.Error()
+	}
+	logger.Log(req.Context(), rec)
+	return resp, readErr
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodPost, "/api/v3/order", ActionPlaceOrder},
+		{http.MethodDelete, "/api/v3/order", ActionCancelOrder},
+		{http.MethodGet, "/api/v3/order", ""},
+		{http.MethodPost, "/sapi/v1/asset/dust", ActionConvertDust},
+		{http.MethodPost, "/sapi/v1/asset/dust-btc", ""},
+		{http.MethodPost, "/sapi/v1/capital/withdraw/apply", ActionWithdraw},
+		{http.MethodPost, "/api/v5/trade/cancel-order", ActionCancelOrder},
+		{http.MethodGet, "/api/v3/account", ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, "https://api.example.com"+tt.path, nil)
+		got, ok := Classify(req)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Classify(%s %s) = %q, %v; want %q", tt.method, tt.path, got, ok, tt.want)
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/api/v3/order" && string(body) != "quoteOrderQty=25&signature=s3cret" {
+			t.Errorf("exchange received body %q, want it unchanged", body)
+		}
+		w.Write([]byte(`{"orderId":42,"status":"FILLED"}`))
+	}))
+	defer server.Close()
+
+	store := newMemStore()
+	logger := NewLogger(store, "audit/", false, true)
+	ctx := WithLogger(run.WithID(context.Background(), "01ARYZ6S41"), logger)
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	post := func(path, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-MBX-APIKEY", "key-123")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		return resp
+	}
+
+	resp := post("/api/v3/order", "quoteOrderQty=25&signature=s3cret")
+	got, _ := io.ReadAll(resp.Body)
+	if string(got) != `{"orderId":42,"status":"FILLED"}` {
+		t.Errorf("response body = %s, want it passed through", got)
+	}
+	post("/sapi/v1/asset/dust-btc", "") // a query, not audited
+
+	if len(store.order) != 1 {
+		t.Fatalf("wrote %v, want one object for the order", store.order)
+	}
+	var rec Record
+	if err := json.Unmarshal(store.objects[store.order[0]], &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Action != ActionPlaceOrder || rec.ExecutionID != "01ARYZ6S41" || rec.Sequence != 1 {
+		t.Errorf("record = %+v, want order.place of the run", rec)
+	}
+	if rec.Request.Body != "quoteOrderQty=25&signature=[REDACTED]" || rec.Request.Header["X-Mbx-Apikey"][0] != Redacted {
+		t.Errorf("request = %+v, want the key and signature redacted", rec.Request)
+	}
+	if rec.Response == nil || rec.Response.Status != 200 || rec.Response.Body != `{"orderId":42,"status":"FILLED"}` {
+		t.Errorf("response = %+v, want the full response", rec.Response)
+	}
+	if rec.RequestedAt.IsZero() || rec.RespondedAt.Before(rec.RequestedAt) {
+		t.Errorf("timestamps = %v, %v", rec.RequestedAt, rec.RespondedAt)
+	}
+
+	// Once a fatal logger has failed, audited requests are not sent
+	store.fail = func(string) bool { return true }
+	post("/api/v3/order", "quoteOrderQty=25&signature=s3cret")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/api/v3/order", strings.NewReader("quoteOrderQty=25&signature=s3cret"))
+	if _, err := client.Do(req); err == nil || !strings.Contains(err.Error(), "audit log unavailable") {
+		t.Errorf("Do() error = %v, want the request refused", err)
+	}
+	if calls != 3 {
+		t.Errorf("exchange received %d requests, want 3", calls)
+	}
+}
+
+func TestTransport_WithoutLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Post(server.URL+"/api/v3/order", "application/json", strings.NewReader(`{}`))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Post() = %v, %v; want the request sent unaudited", resp, err)
+	}
+}
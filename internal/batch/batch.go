@@ -0,0 +1,244 @@
+// Package batch runs a directory of payload files locally, each through
+// the normal pipeline, and summarizes the outcomes. It backs the run-all
+// command.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+// Extensions are the payload files Discover picks up
+var Extensions = []string{".json", ".yaml", ".yml"}
+
+// ErrYAML is returned by Load for YAML payloads: the binary has no YAML
+// parser, so those files fail on their own rather than being skipped
+var ErrYAML = errors.New("YAML payloads are not supported yet; convert the file to JSON")
+
+// Discover lists the payload files directly in dir, sorted by name so the
+// runs go in the same order every time. Hidden files are left out.
+func Discover(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload directory: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !slices.Contains(Extensions, strings.ToLower(filepath.Ext(name))) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// Load reads the payload in path
+func Load(path string) ([]byte, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		return nil, ErrYAML
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %w", err)
+	}
+	return data, nil
+}
+
+// Invoker runs the payload in a file through the pipeline and returns the
+// run's result, nil when it failed before it had one
+type Invoker func(ctx context.Context, path string) (*result.ExecutionResult, error)
+
+// StatusNotRun marks a file left out after an earlier one failed, or once
+// the batch was interrupted
+const StatusNotRun = "not run"
+
+// Entry is the outcome of one payload file
+type Entry struct {
+	File       string          `json:"file"`
+	Status     string          `json:"status"` // a result.Status, or StatusNotRun
+	DryRun     bool            `json:"dryRun,omitempty"`
+	Spent      decimal.Decimal `json:"spent,omitzero"` // quote asset paid for the run's buys
+	Asset      string          `json:"asset,omitempty"`
+	DurationMs float64         `json:"durationMs"`
+	Error      string          `json:"error,omitempty"`
+
+	Result *result.ExecutionResult `json:"result,omitempty"`
+}
+
+// Report is the outcome of a batch, in file order
+type Report struct {
+	Dir     string  `json:"dir"`
+	Entries []Entry `json:"entries"`
+}
+
+// Failed counts the files whose run failed or was canceled
+func (r *Report) Failed() int {
+	n := 0
+	for _, e := range r.Entries {
+		if failed(e.Status) {
+			n++
+		}
+	}
+	return n
+}
+
+// Options tune a batch
+type Options struct {
+	Parallel int // files run at once; at least 1
+
+	// ContinueOnError runs every file; otherwise no file is started once
+	// one failed, and the files left are StatusNotRun
+	ContinueOnError bool
+}
+
+// failed reports whether an entry's status counts as a failure
+func failed(status string) bool {
+	return status == string(result.StatusFailed) || status == string(result.StatusCanceled)
+}
+
+// Run runs files with invoke, up to opts.Parallel at a time. A panic in one
+// run fails that file only. Once ctx is done no file is started; the runs
+// in flight see the cancellation and wrap up.
+func Run(ctx context.Context, files []string, invoke Invoker, opts Options) *Report {
+	r := &Report{Entries: make([]Entry, len(files))}
+	for i, f := range files {
+		r.Entries[i] = Entry{File: f, Status: StatusNotRun}
+	}
+
+	var (
+		next    atomic.Int64
+		stopped atomic.Bool
+		wg      sync.WaitGroup
+	)
+	for range max(opts.Parallel, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= len(files) || stopped.Load() || ctx.Err() != nil {
+					return
+				}
+				r.Entries[i] = runOne(ctx, files[i], invoke)
+				if failed(r.Entries[i].Status) && !opts.ContinueOnError {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return r
+}
+
+// runOne runs the payload in path, turning a panic into a failure
+func runOne(ctx context.Context, path string, invoke Invoker) (e Entry) {
+	e.File = path
+	start := time.Now()
+	defer func() {
+		if v := recover(); v != nil {
+			log.Printf("💥 %s panicked: %v\n%s", path, v, debug.Stack())
+			e.Status, e.Error = string(result.StatusFailed), fmt.Sprintf("panic: %v", v)
+		}
+		e.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	}()
+
+	res, err := invoke(ctx, path)
+	e.Result = res
+	switch {
+	case err != nil && res != nil && res.Status == result.StatusCanceled:
+		e.Status, e.Error = string(res.Status), err.Error()
+	case err != nil:
+		e.Status, e.Error = string(result.StatusFailed), err.Error()
+	case res == nil:
+		e.Status, e.Error = string(result.StatusFailed), "the run recorded no result"
+	default:
+		e.Status = string(res.Status)
+	}
+	if res != nil {
+		e.DryRun = res.DryRun
+		e.Spent, e.Asset = spent(res)
+		if e.Error == "" && res.Skip != nil {
+			e.Error = res.Skip.String()
+		}
+	}
+	return e
+}
+
+// spent adds up the quote asset paid for the buys of res, its accounts'
+// included
+func spent(res *result.ExecutionResult) (decimal.Decimal, string) {
+	total := decimal.Zero
+	for _, o := range res.Orders() {
+		if o.Side != "sell" {
+			total = total.Add(o.Quantity.Mul(o.Price))
+		}
+	}
+	_, quote, err := exchange.SplitSymbol(res.Symbol)
+	if err != nil || total.IsZero() {
+		return total, ""
+	}
+	return total, asset.Canonical(res.Exchange, quote)
+}
+
+// WriteSummary writes the entries as a table, then a count of their
+// statuses
+func (r *Report) WriteSummary(w io.Writer) error {
+	f := money.New("en", nil)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSTATUS\tSPENT\tDURATION\tDETAIL")
+	counts := map[string]int{}
+	var statuses []string
+	for _, e := range r.Entries {
+		status := e.Status
+		if e.DryRun {
+			status += " (dry run)"
+		}
+		amount := "-"
+		if e.Spent.IsPositive() {
+			amount = f.Amount(e.Spent, e.Asset)
+		}
+		took := "-"
+		if e.Status != StatusNotRun {
+			took = time.Duration(e.DurationMs * float64(time.Millisecond)).Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.File, status, amount, took, e.Error)
+
+		if counts[e.Status] == 0 {
+			statuses = append(statuses, e.Status)
+		}
+		counts[e.Status]++
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	parts := make([]string, len(statuses))
+	for i, s := range statuses {
+		parts[i] = fmt.Sprintf("%d %s", counts[s], s)
+	}
+	files := "files"
+	if len(r.Entries) == 1 {
+		files = "file"
+	}
+	_, err := fmt.Fprintf(w, "\n%d %s: %s\n", len(r.Entries), files, strings.Join(parts, ", "))
+	return err
+}
@@ -0,0 +1,230 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"eth.json", "btc.JSON", "sol.yaml", "ada.yml", "notes.txt", ".draft.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "old.json"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	if want := []string{"ada.yml", "btc.JSON", "eth.json", "sol.yaml"}; !slices.Equal(names, want) {
+		t.Errorf("Discover() = %v, want %v", names, want)
+	}
+
+	if _, err := Discover(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Discover() of a missing directory succeeded")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "btc.json")
+	if err := os.WriteFile(path, []byte(`{"version":"v2"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := Load(path); err != nil || string(data) != `{"version":"v2"}` {
+		t.Errorf("Load() = %s, %v", data, err)
+	}
+	if _, err := Load(filepath.Join(dir, "sol.yaml")); !errors.Is(err, ErrYAML) {
+		t.Errorf("Load() of YAML error = %v, want ErrYAML", err)
+	}
+}
+
+// executed is the result of a live buy of 25 USDT of BTC
+func executed() *result.ExecutionResult {
+	return &result.ExecutionResult{
+		Status:   result.StatusExecuted,
+		Exchange: "binance",
+		Symbol:   "BTC-USDT",
+		Order:    &exchange.Order{Side: "buy", Quantity: d("0.0004"), Price: d("62500")},
+	}
+}
+
+// outcomes is an Invoker answering by file name
+func outcomes(ran *[]string, mu *sync.Mutex) Invoker {
+	return func(ctx context.Context, path string) (*result.ExecutionResult, error) {
+		mu.Lock()
+		*ran = append(*ran, path)
+		mu.Unlock()
+		switch path {
+		case "fail.json":
+			return nil, errors.New("failed to parse payload: version must be \"v2\"")
+		case "panic.json":
+			panic("nil map")
+		case "skip.json":
+			return &result.ExecutionResult{Status: result.StatusSkipped, Skip: &guard.Skip{Guard: "paused", Reason: "paused from Telegram"}}, nil
+		}
+		return executed(), nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	files := []string{"a.json", "fail.json", "b.json", "panic.json", "skip.json"}
+	tests := []struct {
+		name     string
+		opts     Options
+		statuses []string
+		failed   int
+	}{
+		{"stop at the first failure", Options{Parallel: 1},
+			[]string{"executed", "failed", StatusNotRun, StatusNotRun, StatusNotRun}, 1},
+		{"continue on error", Options{Parallel: 1, ContinueOnError: true},
+			[]string{"executed", "failed", "executed", "failed", "skipped"}, 2},
+		{"continue on error in parallel", Options{Parallel: 3, ContinueOnError: true},
+			[]string{"executed", "failed", "executed", "failed", "skipped"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran []string
+			var mu sync.Mutex
+			r := Run(context.Background(), files, outcomes(&ran, &mu), tt.opts)
+			var statuses []string
+			for i, e := range r.Entries {
+				if e.File != files[i] {
+					t.Errorf("entry %d is %s, want %s: entries keep the file order", i, e.File, files[i])
+				}
+				statuses = append(statuses, e.Status)
+			}
+			if !slices.Equal(statuses, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", statuses, tt.statuses)
+			}
+			if got := r.Failed(); got != tt.failed {
+				t.Errorf("Failed() = %d, want %d", got, tt.failed)
+			}
+			if !tt.opts.ContinueOnError && len(ran) != 2 {
+				t.Errorf("ran %v, want nothing started after the failure", ran)
+			}
+		})
+	}
+}
+
+func TestRun_Entries(t *testing.T) {
+	var ran []string
+	var mu sync.Mutex
+	r := Run(context.Background(), []string{"a.json", "panic.json", "skip.json"}, outcomes(&ran, &mu), Options{ContinueOnError: true})
+
+	buy := r.Entries[0]
+	if !buy.Spent.Equal(d("25")) || buy.Asset != "USDT" || buy.Result == nil || buy.Error != "" {
+		t.Errorf("buy entry = %+v, want 25 USDT spent", buy)
+	}
+	if e := r.Entries[1]; e.Error != "panic: nil map" || e.Result != nil {
+		t.Errorf("panicking entry = %+v", e)
+	}
+	if e := r.Entries[2]; e.Error != "skipped (paused): paused from Telegram" || !e.Spent.IsZero() {
+		t.Errorf("skipped entry = %+v, want the skip reason", e)
+	}
+}
+
+func TestRun_Parallel(t *testing.T) {
+	var running, peak atomic.Int32
+	invoke := func(ctx context.Context, path string) (*result.ExecutionResult, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return executed(), nil
+	}
+	files := []string{"1.json", "2.json", "3.json", "4.json", "5.json", "6.json", "7.json"}
+	r := Run(context.Background(), files, invoke, Options{Parallel: 3})
+	if got := peak.Load(); got > 3 || got < 2 {
+		t.Errorf("%d runs at once, want up to 3", got)
+	}
+	for _, e := range r.Entries {
+		if e.Status != "executed" {
+			t.Errorf("%s = %s, want every file run", e.File, e.Status)
+		}
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	invoke := func(ctx context.Context, path string) (*result.ExecutionResult, error) {
+		cancel() // Ctrl-C during the first run
+		res := &result.ExecutionResult{}
+		res.Finish(ctx.Err())
+		return res, ctx.Err()
+	}
+	r := Run(ctx, []string{"a.json", "b.json"}, invoke, Options{Parallel: 1, ContinueOnError: true})
+	if got := []string{r.Entries[0].Status, r.Entries[1].Status}; !slices.Equal(got, []string{"canceled", StatusNotRun}) {
+		t.Errorf("statuses = %v, want the run canceled and nothing started after it", got)
+	}
+	if r.Failed() != 1 {
+		t.Errorf("Failed() = %d, want the canceled run counted", r.Failed())
+	}
+}
+
+func TestWriteSummary_Golden(t *testing.T) {
+	buy := executed()
+	dry := executed()
+	dry.DryRun = true
+	dry.Order.Quantity = d("0.0016")
+	r := &Report{Entries: []Entry{
+		{File: "payloads/btc.json", Status: "executed", Spent: d("25"), Asset: "USDT", DurationMs: 1832.4, Result: buy},
+		{File: "payloads/eth.json", Status: "skipped", DurationMs: 412, Error: "skipped (circuitBreaker): ETH moved 12% in 60m, more than the 10% limit"},
+		{File: "payloads/family-btc.json", Status: "executed", DryRun: true, Spent: d("100"), Asset: "USDT", DurationMs: 95.7, Result: dry},
+		{File: "payloads/sol.yaml", Status: "failed", DurationMs: 0.1, Error: ErrYAML.Error()},
+		{File: "payloads/xrp.json", Status: StatusNotRun},
+	}}
+
+	var buf bytes.Buffer
+	if err := r.WriteSummary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", "summary.golden")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("missing golden file (run with -update): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("output differs from %s:\n%s", golden, buf.String())
+	}
+	if !strings.HasSuffix(buf.String(), "\n5 files: 2 executed, 1 skipped, 1 failed, 1 not run\n") {
+		t.Errorf("summary line missing:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,155 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/sudowanderer/dca-bot-go/internal/dynamo"
+)
+
+// tablePollInterval spaces out the checks while a new table is created
+const tablePollInterval = 2 * time.Second
+
+// DynamoTables implements Tables with the DynamoDB JSON API
+type DynamoTables struct {
+	Client *dynamo.Client
+}
+
+type tableDescription struct {
+	Table struct {
+		TableStatus string `json:"TableStatus"`
+		KeySchema   []struct {
+			AttributeName string `json:"AttributeName"`
+			KeyType       string `json:"KeyType"` // HASH or RANGE
+		} `json:"KeySchema"`
+		AttributeDefinitions []struct {
+			AttributeName string `json:"AttributeName"`
+			AttributeType string `json:"AttributeType"`
+		} `json:"AttributeDefinitions"`
+	} `json:"Table"`
+}
+
+func (d DynamoTables) describe(ctx context.Context, name string) (*tableDescription, error) {
+	var desc tableDescription
+	err := d.Client.Call(ctx, "DescribeTable", map[string]any{"TableName": name}, &desc)
+	if dynamo.IsType(err, "ResourceNotFoundException") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// DescribeTable reads the key schema and time to live of name
+func (d DynamoTables) DescribeTable(ctx context.Context, name string) (*Table, error) {
+	desc, err := d.describe(ctx, name)
+	if err != nil || desc == nil {
+		return nil, err
+	}
+	t := &Table{}
+	for _, k := range desc.Table.KeySchema {
+		switch k.KeyType {
+		case "HASH":
+			t.Key = k.AttributeName
+		case "RANGE":
+			t.SortKey = k.AttributeName
+		}
+	}
+	for _, a := range desc.Table.AttributeDefinitions {
+		if a.AttributeName == t.Key {
+			t.KeyType = a.AttributeType
+		}
+	}
+
+	var ttl struct {
+		TimeToLiveDescription struct {
+			TimeToLiveStatus string `json:"TimeToLiveStatus"`
+			AttributeName    string `json:"AttributeName"`
+		} `json:"TimeToLiveDescription"`
+	}
+	if err := d.Client.Call(ctx, "DescribeTimeToLive", map[string]any{"TableName": name}, &ttl); err != nil {
+		return nil, err
+	}
+	switch ttl.TimeToLiveDescription.TimeToLiveStatus {
+	case "ENABLED", "ENABLING":
+		t.TTLAttribute = ttl.TimeToLiveDescription.AttributeName
+	}
+	return t, nil
+}
+
+// CreateTable creates an on-demand table keyed by the string attribute key
+// and waits until it is active
+func (d DynamoTables) CreateTable(ctx context.Context, name, key string) error {
+	err := d.Client.Call(ctx, "CreateTable", map[string]any{
+		"TableName":            name,
+		"AttributeDefinitions": []map[string]string{{"AttributeName": key, "AttributeType": "S"}},
+		"KeySchema":            []map[string]string{{"AttributeName": key, "KeyType": "HASH"}},
+		"BillingMode":          "PAY_PER_REQUEST",
+	}, nil)
+	if err != nil {
+		return err
+	}
+	for {
+		desc, err := d.describe(ctx, name)
+		if err != nil {
+			return err
+		}
+		if desc != nil && desc.Table.TableStatus == "ACTIVE" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("table %s is not active yet: %w", name, ctx.Err())
+		case <-time.After(tablePollInterval):
+		}
+	}
+}
+
+// EnableTTL turns on time to live on attribute
+func (d DynamoTables) EnableTTL(ctx context.Context, name, attribute string) error {
+	return d.Client.Call(ctx, "UpdateTimeToLive", map[string]any{
+		"TableName":               name,
+		"TimeToLiveSpecification": map[string]any{"Enabled": true, "AttributeName": attribute},
+	}, nil)
+}
+
+// S3API is the subset of the S3 client used here
+type S3API interface {
+	HeadBucket(ctx context.Context, params *s3.HeadBucketInput, optFns ...func(*s3.Options)) (*s3.HeadBucketOutput, error)
+	CreateBucket(ctx context.Context, params *s3.CreateBucketInput, optFns ...func(*s3.Options)) (*s3.CreateBucketOutput, error)
+}
+
+// S3Buckets implements Buckets with the S3 client. New buckets are created
+// in Region.
+type S3Buckets struct {
+	Client S3API
+	Region string
+}
+
+// BucketExists reports whether name exists. A bucket owned by another
+// account, or one the caller may not see, is an error.
+func (b S3Buckets) BucketExists(ctx context.Context, name string) (bool, error) {
+	_, err := b.Client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(name)})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateBucket creates name in the configured region
+func (b S3Buckets) CreateBucket(ctx context.Context, name string) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(name)}
+	// us-east-1 is the default location and must not be named
+	if b.Region != "" && b.Region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{LocationConstraint: types.BucketLocationConstraint(b.Region)}
+	}
+	_, err := b.Client.CreateBucket(ctx, input)
+	return err
+}
@@ -0,0 +1,352 @@
+// Package bootstrap checks that the AWS resources a payload refers to exist
+// with the shape the bot expects, creates the missing ones on request and
+// lists the IAM permissions the function's role needs. The bot never
+// creates resources while running; only the local bootstrap command does.
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Kinds of Resource
+const (
+	KindTable  = "DynamoDB table"
+	KindBucket = "S3 bucket"
+)
+
+// Resource is a DynamoDB table or S3 bucket the payload refers to
+type Resource struct {
+	Kind string
+	Name string
+	Path string // config field naming it, e.g. "state.status.bucket"
+
+	// Key is the string partition key and TTLAttribute the time-to-live
+	// attribute a table must have; TTLAttribute is empty for tables whose
+	// items do not expire
+	Key          string
+	TTLAttribute string
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s (%s)", r.Kind, r.Name, r.Path)
+}
+
+// Required lists the tables and buckets payload refers to, each once, in
+// the order of the config
+func Required(payload *config.DCAPayload) []Resource {
+	var resources []Resource
+	add := func(r Resource) {
+		if !slices.ContainsFunc(resources, func(o Resource) bool { return o.Kind == r.Kind && o.Name == r.Name }) {
+			resources = append(resources, r)
+		}
+	}
+	addLocation := func(path, location string) {
+		if bucket, _, ok := s3Location(location); ok {
+			add(Resource{Kind: KindBucket, Name: bucket, Path: path})
+		}
+	}
+
+	if state := payload.State; state != nil {
+		if rl := state.SharedRateLimit; rl != nil {
+			add(Resource{Kind: KindTable, Name: rl.Table, Path: "state.sharedRateLimit.table", Key: rateLimitKey})
+		}
+		if st := state.Status; st != nil && st.Bucket != "" {
+			add(Resource{Kind: KindBucket, Name: st.Bucket, Path: "state.status.bucket"})
+		}
+	}
+	if idem := payload.Idempotency; idem != nil {
+		add(Resource{Kind: KindTable, Name: idem.Table, Path: "idempotency.table", Key: lockKey, TTLAttribute: lockTTLAttribute})
+	}
+	if al := payload.Integrations.AuditLog; al != nil {
+		add(Resource{Kind: KindBucket, Name: al.Bucket, Path: "integrations.auditLog.bucket"})
+	}
+	addLocation("strategy.budgetHistory", payload.Strategy.BudgetHistory)
+	if r := payload.Report; r != nil {
+		addLocation("report.history", r.History)
+	}
+	if r := payload.Reconcile; r != nil {
+		addLocation("reconcile.history", r.History)
+	}
+	return resources
+}
+
+// rateLimitKey is the partition key of the shared rate limit table; see
+// ratelimit.DynamoKeyAttribute
+const rateLimitKey = "pk"
+
+// lockKey and lockTTLAttribute shape the idempotency lock table; see
+// execlock.DynamoKeyAttribute and execlock.DynamoTTLAttribute
+const (
+	lockKey          = "pk"
+	lockTTLAttribute = "expiresAt"
+)
+
+// s3Location splits s3://bucket/key; ok is false for local paths
+func s3Location(location string) (bucket, key string, ok bool) {
+	rest, found := strings.CutPrefix(location, "s3://")
+	if !found {
+		return "", "", false
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	return bucket, key, bucket != "" && key != ""
+}
+
+// Table is the shape of an existing table
+type Table struct {
+	Key          string // partition key attribute
+	KeyType      string // its type: "S", "N" or "B"
+	SortKey      string // empty without one
+	TTLAttribute string // empty while time to live is off
+}
+
+// Tables looks up and creates DynamoDB tables
+type Tables interface {
+	// DescribeTable returns nil, nil when the table does not exist
+	DescribeTable(ctx context.Context, name string) (*Table, error)
+	// CreateTable creates an on-demand table with a string partition key
+	// and waits until it can be used
+	CreateTable(ctx context.Context, name, key string) error
+	EnableTTL(ctx context.Context, name, attribute string) error
+}
+
+// Buckets looks up and creates S3 buckets
+type Buckets interface {
+	BucketExists(ctx context.Context, name string) (bool, error)
+	CreateBucket(ctx context.Context, name string) error
+}
+
+// States of a Status
+const (
+	StateOK       = "ok"
+	StateMissing  = "missing"
+	StateMismatch = "mismatch" // exists with the wrong shape
+	StateCreated  = "created"
+)
+
+// Status is what Check found for one resource
+type Status struct {
+	Resource
+	State   string
+	Problem string // what is wrong with a mismatched resource
+
+	// Fixable marks a mismatch Create can repair, such as a table whose
+	// time to live is off
+	Fixable bool
+}
+
+func (s Status) String() string {
+	switch s.State {
+	case StateMismatch:
+		return fmt.Sprintf("%s: %s", s.Resource, s.Problem)
+	default:
+		return fmt.Sprintf("%s: %s", s.Resource, s.State)
+	}
+}
+
+// Check looks up every resource. Lookup failures, such as missing
+// permissions, are returned rather than reported as missing resources.
+func Check(ctx context.Context, resources []Resource, tables Tables, buckets Buckets) ([]Status, error) {
+	statuses := make([]Status, 0, len(resources))
+	for _, r := range resources {
+		s := Status{Resource: r, State: StateOK}
+		switch r.Kind {
+		case KindTable:
+			t, err := tables.DescribeTable(ctx, r.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", r, err)
+			}
+			if t == nil {
+				s.State = StateMissing
+			} else {
+				s.Problem, s.Fixable = tableProblem(r, t)
+			}
+		case KindBucket:
+			ok, err := buckets.BucketExists(ctx, r.Name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", r, err)
+			}
+			if !ok {
+				s.State = StateMissing
+			}
+		}
+		if s.Problem != "" {
+			s.State = StateMismatch
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// tableProblem describes how t differs from what r needs; fixable is true
+// when only the time to live is off
+func tableProblem(r Resource, t *Table) (problem string, fixable bool) {
+	switch {
+	case t.Key != r.Key || t.KeyType != "S":
+		return fmt.Sprintf("partition key is %s (%s), want %s (S)", t.Key, t.KeyType, r.Key), false
+	case t.SortKey != "":
+		return fmt.Sprintf("has sort key %s, want none", t.SortKey), false
+	case r.TTLAttribute != "" && t.TTLAttribute == "":
+		return fmt.Sprintf("time to live is off, want it on attribute %s", r.TTLAttribute), true
+	case r.TTLAttribute != "" && t.TTLAttribute != r.TTLAttribute:
+		return fmt.Sprintf("time to live is on attribute %s, want %s", t.TTLAttribute, r.TTLAttribute), false
+	}
+	return "", false
+}
+
+// Create creates the missing resources and repairs fixable mismatches,
+// returning the statuses with the ones it changed marked created or ok.
+// Mismatches it cannot repair are left for the caller to report.
+func Create(ctx context.Context, statuses []Status, tables Tables, buckets Buckets) ([]Status, error) {
+	out := slices.Clone(statuses)
+	for i, s := range out {
+		switch {
+		case s.State == StateMissing && s.Kind == KindTable:
+			if err := tables.CreateTable(ctx, s.Name, s.Key); err != nil {
+				return out, fmt.Errorf("failed to create %s: %w", s.Resource, err)
+			}
+			if s.TTLAttribute != "" {
+				if err := tables.EnableTTL(ctx, s.Name, s.TTLAttribute); err != nil {
+					return out, fmt.Errorf("failed to enable time to live on %s: %w", s.Resource, err)
+				}
+			}
+			out[i].State = StateCreated
+		case s.State == StateMissing && s.Kind == KindBucket:
+			if err := buckets.CreateBucket(ctx, s.Name); err != nil {
+				return out, fmt.Errorf("failed to create %s: %w", s.Resource, err)
+			}
+			out[i].State = StateCreated
+		case s.State == StateMismatch && s.Fixable:
+			if err := tables.EnableTTL(ctx, s.Name, s.TTLAttribute); err != nil {
+				return out, fmt.Errorf("failed to enable time to live on %s: %w", s.Resource, err)
+			}
+			out[i].State, out[i].Problem, out[i].Fixable = StateOK, "", false
+		}
+	}
+	return out, nil
+}
+
+// Permission is one IAM policy statement the function's role needs
+type Permission struct {
+	Actions  []string
+	Resource string // ARN, possibly with wildcards
+}
+
+func (p Permission) String() string {
+	return strings.Join(p.Actions, ", ") + " on " + p.Resource
+}
+
+// Permissions lists the IAM permissions a run of payload needs for the AWS
+// services its config refers to, sorted by resource
+func Permissions(payload *config.DCAPayload) []Permission {
+	var perms []Permission
+	add := func(resource string, actions ...string) {
+		for i, p := range perms {
+			if p.Resource == resource {
+				for _, a := range actions {
+					if !slices.Contains(p.Actions, a) {
+						perms[i].Actions = append(perms[i].Actions, a)
+					}
+				}
+				return
+			}
+		}
+		perms = append(perms, Permission{Actions: actions, Resource: resource})
+	}
+	object := func(location string, actions ...string) {
+		if bucket, key, ok := s3Location(location); ok {
+			add("arn:aws:s3:::"+bucket+"/"+key, actions...)
+		}
+	}
+
+	if state := payload.State; state != nil {
+		if rl := state.SharedRateLimit; rl != nil {
+			add("arn:aws:dynamodb:*:*:table/"+rl.Table, "dynamodb:GetItem", "dynamodb:PutItem")
+		}
+		if st := state.Status; st != nil && st.Bucket != "" {
+			add("arn:aws:s3:::"+st.Bucket+"/"+st.Prefix+"*", "s3:GetObject", "s3:PutObject")
+		}
+	}
+	if idem := payload.Idempotency; idem != nil {
+		add("arn:aws:dynamodb:*:*:table/"+idem.Table, "dynamodb:PutItem", "dynamodb:GetItem", "dynamodb:UpdateItem", "dynamodb:DeleteItem")
+	}
+	if al := payload.Integrations.AuditLog; al != nil {
+		prefix := al.Prefix
+		if prefix == "" {
+			prefix = config.AuditLogDefaultPrefix
+		}
+		add("arn:aws:s3:::"+al.Bucket+"/"+prefix+"*", "s3:GetObject", "s3:PutObject")
+	}
+	object(payload.Strategy.BudgetHistory, "s3:GetObject")
+	if r := payload.Report; r != nil {
+		object(r.History, "s3:GetObject")
+	}
+	if r := payload.Reconcile; r != nil {
+		object(r.History, "s3:GetObject", "s3:PutObject")
+	}
+	if eb := payload.Integrations.EventBridge; eb != nil {
+		add("arn:aws:events:*:*:event-bus/"+eb.BusName, "events:PutEvents")
+	}
+	if rs := payload.Integrations.RetryScheduler; rs != nil {
+		add("arn:aws:scheduler:*:*:schedule/"+rs.GroupName+"/*", "scheduler:CreateSchedule")
+		add(rs.RoleARN, "iam:PassRole")
+	}
+	if ap := payload.Flags.RequireApproval; ap != nil {
+		resource := "arn:aws:ssm:*:*:parameter/" + strings.TrimPrefix(ap.Parameter, "/")
+		add(resource, "ssm:GetParameter")
+		// The Telegram bot writes the hash approved with /approve
+		if tg := payload.Notifications.Telegram; tg != nil && tg.Commands != nil {
+			add(resource, "ssm:PutParameter")
+		}
+	}
+	for _, name := range ssmParameters(payload) {
+		add("arn:aws:ssm:*:*:parameter/"+strings.TrimPrefix(name, "/"), "ssm:GetParameter")
+	}
+
+	sort.SliceStable(perms, func(i, j int) bool { return perms[i].Resource < perms[j].Resource })
+	return perms
+}
+
+// ssmParameters lists the SSM parameters the payload's ssm credential
+// sources read: the values of their "...Path" config keys
+func ssmParameters(payload *config.DCAPayload) []string {
+	var sources []config.CredentialSource
+	notifications := []*config.NotificationConfig{&payload.Notifications, payload.Strategy.Notifications}
+	for _, venue := range payload.Venues() {
+		sources = append(sources, venue.Credentials)
+		for _, a := range venue.Accounts {
+			sources = append(sources, a.Credentials)
+			notifications = append(notifications, a.Notifications)
+		}
+	}
+	if hb := payload.Integrations.Heartbeat; hb != nil {
+		sources = append(sources, hb.URL)
+	}
+
+	var names []string
+	addConfig := func(typ string, cfg map[string]interface{}) {
+		if typ != config.CredentialTypeSSM {
+			return
+		}
+		for key, v := range cfg {
+			if name, ok := v.(string); ok && strings.HasSuffix(key, "Path") && name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, s := range sources {
+		addConfig(s.Type, s.Config)
+	}
+	for _, n := range notifications {
+		if n != nil && n.Telegram != nil {
+			addConfig(n.Telegram.Type, n.Telegram.Config)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
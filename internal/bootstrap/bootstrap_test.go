@@ -0,0 +1,206 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// stubAWS is an account holding tables and buckets in memory
+type stubAWS struct {
+	tables  map[string]*Table
+	buckets map[string]bool
+	err     error // returned by every lookup when set
+	created []string
+}
+
+func (s *stubAWS) DescribeTable(ctx context.Context, name string) (*Table, error) {
+	return s.tables[name], s.err
+}
+
+func (s *stubAWS) CreateTable(ctx context.Context, name, key string) error {
+	s.tables[name] = &Table{Key: key, KeyType: "S"}
+	s.created = append(s.created, "table "+name)
+	return nil
+}
+
+func (s *stubAWS) EnableTTL(ctx context.Context, name, attribute string) error {
+	s.tables[name].TTLAttribute = attribute
+	s.created = append(s.created, "ttl "+name+"."+attribute)
+	return nil
+}
+
+func (s *stubAWS) BucketExists(ctx context.Context, name string) (bool, error) {
+	return s.buckets[name], s.err
+}
+
+func (s *stubAWS) CreateBucket(ctx context.Context, name string) error {
+	s.buckets[name] = true
+	s.created = append(s.created, "bucket "+name)
+	return nil
+}
+
+const payloadJSON = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "credentials": {"type": "ssm", "config": {"apiKeyPath": "/dca/key", "apiSecretPath": "/dca/secret"}}},
+	"strategy": {"symbol": "BTC-USDT", "monthlyBudget": "300", "schedule": "0 9 * * 1", "budgetHistory": "s3://dca-history/history.jsonl"},
+	"state": {"sharedRateLimit": {"table": "dca-rate-limits", "requestsPerMinute": 600}, "status": {"bucket": "dca-state"}},
+	"integrations": {"auditLog": {"bucket": "dca-state"}, "eventBridge": {}},
+	"flags": {"dryRun": true}
+}`
+
+func parse(t *testing.T) *config.DCAPayload {
+	t.Helper()
+	payload, err := config.ParseDCAPayload([]byte(payloadJSON))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	return payload
+}
+
+func TestRequired(t *testing.T) {
+	var got []string
+	for _, r := range Required(parse(t)) {
+		got = append(got, r.String())
+	}
+	want := []string{
+		"DynamoDB table dca-rate-limits (state.sharedRateLimit.table)",
+		"S3 bucket dca-state (state.status.bucket)",
+		"S3 bucket dca-history (strategy.budgetHistory)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Required() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	resources := []Resource{
+		{Kind: KindTable, Name: "good", Key: "pk"},
+		{Kind: KindTable, Name: "absent", Key: "pk"},
+		{Kind: KindTable, Name: "numeric", Key: "pk"},
+		{Kind: KindTable, Name: "sorted", Key: "pk"},
+		{Kind: KindTable, Name: "no-ttl", Key: "pk", TTLAttribute: "expiresAt"},
+		{Kind: KindTable, Name: "other-ttl", Key: "pk", TTLAttribute: "expiresAt"},
+		{Kind: KindBucket, Name: "present"},
+		{Kind: KindBucket, Name: "gone"},
+	}
+	aws := &stubAWS{
+		tables: map[string]*Table{
+			"good":      {Key: "pk", KeyType: "S"},
+			"numeric":   {Key: "pk", KeyType: "N"},
+			"sorted":    {Key: "pk", KeyType: "S", SortKey: "sk"},
+			"no-ttl":    {Key: "pk", KeyType: "S"},
+			"other-ttl": {Key: "pk", KeyType: "S", TTLAttribute: "ttl"},
+		},
+		buckets: map[string]bool{"present": true},
+	}
+
+	statuses, err := Check(context.Background(), resources, aws, aws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		state   string
+		fixable bool
+	}{
+		{StateOK, false},
+		{StateMissing, false},
+		{StateMismatch, false},
+		{StateMismatch, false},
+		{StateMismatch, true},
+		{StateMismatch, false},
+		{StateOK, false},
+		{StateMissing, false},
+	}
+	for i, w := range want {
+		if s := statuses[i]; s.State != w.state || s.Fixable != w.fixable {
+			t.Errorf("%s = %s (fixable %v), want %s (fixable %v)", s.Name, s.State, s.Fixable, w.state, w.fixable)
+		}
+	}
+	if p := statuses[2].Problem; !strings.Contains(p, "partition key is pk (N)") {
+		t.Errorf("problem = %q, want the key type", p)
+	}
+
+	statuses, err = Create(context.Background(), statuses, aws, aws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(aws.created, ", "); got != "table absent, ttl no-ttl.expiresAt, bucket gone" {
+		t.Errorf("created %s, want the missing table and bucket and the TTL", got)
+	}
+	for i, want := range []string{StateOK, StateCreated, StateMismatch, StateMismatch, StateOK, StateMismatch, StateOK, StateCreated} {
+		if statuses[i].State != want {
+			t.Errorf("after Create %s = %s, want %s", statuses[i].Name, statuses[i].State, want)
+		}
+	}
+}
+
+func TestCheck_LookupError(t *testing.T) {
+	aws := &stubAWS{err: errors.New("AccessDenied")}
+	_, err := Check(context.Background(), []Resource{{Kind: KindBucket, Name: "dca-state", Path: "state.status.bucket"}}, aws, aws)
+	if err == nil || !strings.Contains(err.Error(), "S3 bucket dca-state (state.status.bucket): AccessDenied") {
+		t.Errorf("Check() error = %v, want the lookup failure", err)
+	}
+}
+
+func TestCreate_TableWithTTL(t *testing.T) {
+	aws := &stubAWS{tables: map[string]*Table{}, buckets: map[string]bool{}}
+	statuses := []Status{{Resource: Resource{Kind: KindTable, Name: "locks", Key: "pk", TTLAttribute: "expiresAt"}, State: StateMissing}}
+	if _, err := Create(context.Background(), statuses, aws, aws); err != nil {
+		t.Fatal(err)
+	}
+	if tbl := aws.tables["locks"]; tbl == nil || tbl.TTLAttribute != "expiresAt" {
+		t.Errorf("table = %+v, want time to live on expiresAt", tbl)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	var got []string
+	for _, p := range Permissions(parse(t)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		"dynamodb:GetItem, dynamodb:PutItem on arn:aws:dynamodb:*:*:table/dca-rate-limits",
+		"events:PutEvents on arn:aws:events:*:*:event-bus/default",
+		"s3:GetObject on arn:aws:s3:::dca-history/history.jsonl",
+		"s3:GetObject, s3:PutObject on arn:aws:s3:::dca-state/audit/*",
+		"s3:GetObject, s3:PutObject on arn:aws:s3:::dca-state/status/*",
+		"ssm:GetParameter on arn:aws:ssm:*:*:parameter/dca/key",
+		"ssm:GetParameter on arn:aws:ssm:*:*:parameter/dca/secret",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Permissions() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIdempotencyTable(t *testing.T) {
+	payload := parse(t)
+	payload.Idempotency = &config.IdempotencyConfig{Table: "dca-locks"}
+	want := Resource{Kind: KindTable, Name: "dca-locks", Path: "idempotency.table", Key: "pk", TTLAttribute: "expiresAt"}
+	if !slices.Contains(Required(payload), want) {
+		t.Errorf("Required() lacks %+v", want)
+	}
+	perm := "dynamodb:PutItem, dynamodb:GetItem, dynamodb:UpdateItem, dynamodb:DeleteItem on arn:aws:dynamodb:*:*:table/dca-locks"
+	if !slices.ContainsFunc(Permissions(payload), func(p Permission) bool { return p.String() == perm }) {
+		t.Errorf("Permissions() lacks %q", perm)
+	}
+}
+
+func TestPermissions_Approval(t *testing.T) {
+	payload := parse(t)
+	payload.Flags.RequireApproval = &config.ApprovalConfig{Parameter: "/dca/approved-strategy"}
+	want := "ssm:GetParameter on arn:aws:ssm:*:*:parameter/dca/approved-strategy"
+	if !slices.ContainsFunc(Permissions(payload), func(p Permission) bool { return p.String() == want }) {
+		t.Errorf("Permissions() lacks %q", want)
+	}
+
+	payload.Notifications.Telegram = &config.TelegramConfig{Commands: &config.TelegramCommandsConfig{}}
+	want = "ssm:GetParameter, ssm:PutParameter on arn:aws:ssm:*:*:parameter/dca/approved-strategy"
+	if !slices.ContainsFunc(Permissions(payload), func(p Permission) bool { return p.String() == want }) {
+		t.Errorf("Permissions() lacks %q for /approve", want)
+	}
+}
@@ -0,0 +1,52 @@
+// Package budget turns a monthly budget into the quote amount of one run,
+// spreading what is left of the month's budget over its remaining runs so
+// that missed or skipped runs are made up later in the month.
+package budget
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/cron"
+)
+
+// Plan records how a run's amount was derived from the monthly budget
+type Plan struct {
+	Monthly  decimal.Decimal `json:"monthly"`
+	Spent    decimal.Decimal `json:"spent"`            // month-to-date spend before this run
+	RunsLeft int             `json:"runsLeft"`         // scheduled runs left in the month, this run included
+	Amount   decimal.Decimal `json:"amount"`           // quote amount of this run; zero once the budget is spent
+	Capped   bool            `json:"capped,omitempty"` // Amount was limited by maxQuoteAmount
+}
+
+// MonthStart returns the first instant of now's month, in now's location
+func MonthStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// PerRun divides the unspent monthly budget evenly across this run and the
+// runs the schedule has left in now's month, in now's location. The amount
+// is rounded down to places and capped by max unless max is zero. It
+// depends only on its arguments, so a rerun for the same time and history
+// gets the same amount.
+func PerRun(monthly, spent, max decimal.Decimal, schedule *cron.Schedule, now time.Time, places int32) Plan {
+	// Fire times after this run's minute; the run itself counts once even
+	// when it started late or was triggered by hand
+	next := now.Truncate(time.Minute).Add(time.Minute)
+	plan := Plan{
+		Monthly:  monthly,
+		Spent:    spent,
+		RunsLeft: 1 + schedule.Count(next, MonthStart(now).AddDate(0, 1, 0)),
+		Amount:   decimal.Zero,
+	}
+
+	remaining := monthly.Sub(spent)
+	if !remaining.IsPositive() {
+		return plan
+	}
+	plan.Amount = remaining.Div(decimal.NewFromInt(int64(plan.RunsLeft))).RoundDown(places)
+	if max.IsPositive() && plan.Amount.GreaterThan(max) {
+		plan.Amount, plan.Capped = max, true
+	}
+	return plan
+}
@@ -0,0 +1,80 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/cron"
+)
+
+func TestPerRun(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	dec := decimal.RequireFromString
+
+	tests := []struct {
+		name         string
+		schedule     string
+		now          time.Time
+		spent        string
+		max          string
+		wantRunsLeft int
+		wantAmount   string
+		wantCapped   bool
+	}{
+		// First run of months of every length
+		{"feb_28_days", "0 8 * * *", at(2026, time.February, 1, 8, 0), "0", "0", 28, "10.71", false},
+		{"feb_29_days", "0 8 * * *", at(2028, time.February, 1, 8, 0), "0", "0", 29, "10.34", false},
+		{"30_days", "0 8 * * *", at(2026, time.April, 1, 8, 0), "0", "0", 30, "10", false},
+		{"31_days", "0 8 * * *", at(2026, time.January, 1, 8, 0), "0", "0", 31, "9.67", false},
+		{"last_run_of_month", "0 8 * * *", at(2026, time.January, 31, 8, 0), "290", "0", 1, "10", false},
+
+		// Runs missed on the 1st and 2nd are made up over the rest of the month
+		{"missed_runs", "0 8 * * *", at(2026, time.April, 3, 8, 0), "0", "0", 28, "10.71", false},
+		// A run started late still counts itself once
+		{"late_start", "0 8 * * *", at(2026, time.April, 3, 8, 2), "0", "0", 28, "10.71", false},
+		// Daily until mid-month, then weekly on Mondays: the unspent half goes to the remaining Mondays
+		{"cadence_change", "0 8 * * 1", at(2026, time.March, 16, 8, 0), "150", "0", 3, "50", false},
+		// A manual run between scheduled runs takes a share too
+		{"manual_run", "0 8 * * 1", at(2026, time.March, 18, 13, 37), "150", "0", 3, "50", false},
+
+		{"capped", "0 8 * * 1", at(2026, time.March, 30, 8, 0), "100", "50", 1, "50", true},
+		{"below_cap", "0 8 * * *", at(2026, time.April, 1, 8, 0), "0", "50", 30, "10", false},
+		{"spent", "0 8 * * *", at(2026, time.April, 20, 8, 0), "300", "0", 11, "0", false},
+		{"overspent", "0 8 * * *", at(2026, time.April, 20, 8, 0), "320", "0", 11, "0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := cron.Parse(tt.schedule)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			plan := PerRun(dec("300"), dec(tt.spent), dec(tt.max), schedule, tt.now, 2)
+			if plan.RunsLeft != tt.wantRunsLeft {
+				t.Errorf("RunsLeft = %d, want %d", plan.RunsLeft, tt.wantRunsLeft)
+			}
+			if !plan.Amount.Equal(dec(tt.wantAmount)) || plan.Capped != tt.wantCapped {
+				t.Errorf("Amount = %s (capped %v), want %s (capped %v)", plan.Amount, plan.Capped, tt.wantAmount, tt.wantCapped)
+			}
+			if again := PerRun(dec("300"), dec(tt.spent), dec(tt.max), schedule, tt.now, 2); !again.Amount.Equal(plan.Amount) || again.RunsLeft != plan.RunsLeft {
+				t.Errorf("second PerRun() = %+v, want %+v", again, plan)
+			}
+		})
+	}
+}
+
+func TestPerRun_Timezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	schedule, _ := cron.Parse("0 8 * * *")
+	// 23:00 UTC on 31 March is already 1 April in Tokyo: a new month with all 30 runs ahead
+	now := time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC).In(tokyo)
+	plan := PerRun(decimal.NewFromInt(300), decimal.Zero, decimal.Zero, schedule, now, 2)
+	if plan.RunsLeft != 30 || !MonthStart(now).Equal(time.Date(2026, time.April, 1, 0, 0, 0, 0, tokyo)) {
+		t.Errorf("RunsLeft = %d, month start %s; want the Tokyo month", plan.RunsLeft, MonthStart(now))
+	}
+}
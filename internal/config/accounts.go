@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// AccountConfig is one of several accounts on the same exchange that run
+// the same strategy, each with its own API keys and notifications
+type AccountConfig struct {
+	// Label names the account in results, logs, client order IDs and state
+	// keys: letters, digits and underscores, up to 16 characters
+	Label       string           `json:"label"`
+	Credentials CredentialSource `json:"credentials"`
+
+	// Notifications takes the place of strategy.notifications for the
+	// account's events; see NotificationConfig.Merge
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+}
+
+// accountLabel is the form of AccountConfig.Label
+var accountLabel = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// validateAccounts checks exchange.accounts: unique labels, valid
+// credentials and overrides, and no exchange-wide credentials or failover
+// exchanges alongside them
+func (p *DCAPayload) validateAccounts() error {
+	if p.Exchange.Credentials.Type != "" {
+		return fmt.Errorf("exchange.credentials: set per account with exchange.accounts")
+	}
+	if len(p.Failover) > 0 {
+		return fmt.Errorf("exchange.accounts: not supported with failover exchanges")
+	}
+	seen := map[string]bool{}
+	for i, account := range p.Exchange.Accounts {
+		if !accountLabel.MatchString(account.Label) {
+			return fmt.Errorf("exchange.accounts[%d].label: %q must be 1-16 letters, digits or underscores", i, account.Label)
+		}
+		key := strings.ToLower(account.Label)
+		if seen[key] {
+			return fmt.Errorf("exchange.accounts[%d].label: %q is used by another account", i, account.Label)
+		}
+		seen[key] = true
+		if err := ValidateCredentialType(account.Credentials.Type); err != nil {
+			return fmt.Errorf("exchange.accounts[%d].credentials: %w", i, err)
+		}
+		if _, err := DecodeExchangeCredentials(p.Exchange.Name, account.Credentials); err != nil {
+			return fmt.Errorf("exchange.accounts[%d]: %w", i, err)
+		}
+		if override := account.Notifications; override != nil {
+			if err := override.validateOverride(); err != nil {
+				return fmt.Errorf("exchange.accounts[%d].notifications.%w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ForAccount returns a copy of the payload that runs for account only: with
+// its credentials and notifications, and with state.status kept apart from
+// the other accounts' below a directory named after its label
+func (p *DCAPayload) ForAccount(account AccountConfig) *DCAPayload {
+	c := *p
+	c.Account = account.Label
+	c.Exchange.Credentials = account.Credentials
+	c.Exchange.Accounts = nil
+	if account.Notifications != nil {
+		c.Strategy.Notifications = account.Notifications
+	}
+	if p.State != nil && p.State.Status != nil {
+		state := *p.State
+		st := *p.State.Status
+		if st.Dir != "" {
+			st.Dir = filepath.Join(st.Dir, account.Label)
+		} else {
+			st.Prefix += account.Label + "/"
+		}
+		state.Status = &st
+		c.State = &state
+	}
+	return &c
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApprovalConfig is the two-person rule of flags.requireApproval: a live
+// run trades only a strategy whose StrategyHash is the approved hash in
+// Parameter. A second person approves a change by writing its hash there,
+// or with /approve in the Telegram bot.
+type ApprovalConfig struct {
+	// Parameter is the SSM parameter holding the approved hash, e.g.
+	// "/dca-bot/family/approved-strategy"
+	Parameter string `json:"parameter"`
+}
+
+func (c *ApprovalConfig) validate() error {
+	if c.Parameter == "" {
+		return fmt.Errorf("parameter is required")
+	}
+	if strings.ContainsAny(c.Parameter, " \t\n") {
+		return fmt.Errorf("parameter: invalid SSM parameter name %q", c.Parameter)
+	}
+	return nil
+}
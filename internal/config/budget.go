@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/cron"
+)
+
+// Budgeted reports whether the strategy derives quoteAmount from
+// monthlyBudget. Each run then divides what is left of the month's budget
+// evenly across the runs schedule has left in the month, so missed or
+// skipped runs are made up later. The month-to-date spend is read from
+// budgetHistory.
+func (s DCAStrategy) Budgeted() bool {
+	return s.MonthlyBudget != ""
+}
+
+// ParsedSchedule returns the parsed schedule
+func (s DCAStrategy) ParsedSchedule() (*cron.Schedule, error) {
+	return cron.Parse(s.Schedule)
+}
+
+func (s DCAStrategy) validateBudget() error {
+	if !s.Budgeted() {
+		if s.BudgetHistory != "" || (s.MaxQuoteAmount != "" && !s.PercentSized()) {
+			return fmt.Errorf("monthlyBudget: required with maxQuoteAmount and budgetHistory")
+		}
+		if s.Schedule != "" {
+			if _, err := s.ParsedSchedule(); err != nil {
+				return fmt.Errorf("schedule: %w", err)
+			}
+		}
+		return nil
+	}
+	if s.QuoteAmount != "" {
+		return fmt.Errorf("monthlyBudget: set either quoteAmount or monthlyBudget")
+	}
+	if s.DipMultipliers != nil {
+		return fmt.Errorf("dipMultipliers: not supported with monthlyBudget; a multiplied run could spend past the month's budget")
+	}
+	if budget, err := decimal.NewFromString(s.MonthlyBudget); err != nil || !budget.IsPositive() {
+		return fmt.Errorf("monthlyBudget: invalid amount %q", s.MonthlyBudget)
+	}
+	if s.Schedule == "" {
+		return fmt.Errorf("schedule: required with monthlyBudget")
+	}
+	if s.BudgetHistory == "" {
+		return fmt.Errorf("budgetHistory: required with monthlyBudget")
+	}
+	if _, err := s.ParsedSchedule(); err != nil {
+		return fmt.Errorf("schedule: %w", err)
+	}
+	if s.MaxQuoteAmount != "" {
+		if max, err := decimal.NewFromString(s.MaxQuoteAmount); err != nil || !max.IsPositive() {
+			return fmt.Errorf("maxQuoteAmount: invalid amount %q", s.MaxQuoteAmount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// MaxCircuitBreakerWindow bounds circuitBreaker.windowMinutes: the window is
+// read as one-minute candles in a single request
+const MaxCircuitBreakerWindow = 720
+
+// CircuitBreakerConfig skips buys while the price has moved more than
+// MaxMovePercent, up or down, within the last WindowMinutes, so that the
+// bot stands down during a flash crash or a glitch in the exchange's
+// prices. With monthlyBudget, the amount of a skipped run is spread over
+// the month's remaining runs.
+type CircuitBreakerConfig struct {
+	MaxMovePercent string `json:"maxMovePercent"` // e.g. "10" for 10%
+	WindowMinutes  int    `json:"windowMinutes"`  // e.g. 60
+}
+
+// MaxMove returns MaxMovePercent, valid once the payload was parsed
+func (c *CircuitBreakerConfig) MaxMove() money.Percent {
+	pct, _ := money.PercentFromString(c.MaxMovePercent)
+	return pct
+}
+
+// Window returns WindowMinutes as a duration
+func (c *CircuitBreakerConfig) Window() time.Duration {
+	return time.Duration(c.WindowMinutes) * time.Minute
+}
+
+func (c *CircuitBreakerConfig) validate() error {
+	pct, err := money.PercentFromString(c.MaxMovePercent)
+	if err != nil || !pct.IsPositive() || pct.Cmp(money.HundredPercent) >= 0 {
+		return fmt.Errorf("maxMovePercent: invalid value %q (want a percentage between 0 and 100)", c.MaxMovePercent)
+	}
+	if c.WindowMinutes < 1 || c.WindowMinutes > MaxCircuitBreakerWindow {
+		return fmt.Errorf("windowMinutes: must be between 1 and %d, got %d", MaxCircuitBreakerWindow, c.WindowMinutes)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// validateCostPrices checks flags.costPrices: USD prices keyed as in the
+// embedded price table, "lambda.gbSecond", "lambda.request", or the SigV4
+// name of an AWS service such as "ssm" or "dynamodb" for one request
+func validateCostPrices(prices map[string]string) error {
+	for key, price := range prices {
+		if key == "" {
+			return fmt.Errorf("%q: empty key", key)
+		}
+		if d, err := decimal.NewFromString(price); err != nil || d.IsNegative() {
+			return fmt.Errorf("%s: invalid price %q", key, price)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// SSMCredentialConfig is the config of an "ssm" exchange credential
+// source: the SSM parameters holding the secrets
+type SSMCredentialConfig struct {
+	APIKeyPath     string `json:"apiKeyPath"`
+	APISecretPath  string `json:"apiSecretPath"`
+	PassphrasePath string `json:"passphrasePath,omitempty"` // OKX only
+}
+
+// InlineCredentialConfig is the config of an "inline" exchange credential
+// source: the secrets themselves
+type InlineCredentialConfig struct {
+	APIKey     string `json:"apiKey"`
+	APISecret  string `json:"apiSecret"`
+	Passphrase string `json:"passphrase,omitempty"` // OKX only
+}
+
+// EnvCredentialConfig is the config of an "env" exchange credential
+// source: the environment variables holding the secrets, read when the
+// exchange client is created
+type EnvCredentialConfig struct {
+	APIKeyEnv     string `json:"apiKeyEnv"`
+	APISecretEnv  string `json:"apiSecretEnv"`
+	PassphraseEnv string `json:"passphraseEnv,omitempty"` // OKX only
+}
+
+// exchangeCredentialKeys are the config keys of each exchange credential
+// source type
+var exchangeCredentialKeys = map[string][]string{
+	CredentialTypeSSM:    {"apiKeyPath", "apiSecretPath", "passphrasePath"},
+	CredentialTypeInline: {"apiKey", "apiSecret", "passphrase"},
+	CredentialTypeEnv:    {"apiKeyEnv", "apiSecretEnv", "passphraseEnv"},
+}
+
+// ExchangeCredentials is an exchange credential source decoded by its
+// type; exactly one field is set
+type ExchangeCredentials struct {
+	SSM    *SSMCredentialConfig
+	Inline *InlineCredentialConfig
+	Env    *EnvCredentialConfig
+}
+
+// DecodeExchangeCredentials decodes the config of source, an exchange's
+// credential source. Unknown keys, such as a misspelt "apikeyPath", and
+// missing ones are errors naming the exchange and type, e.g. "binance ssm
+// credentials: apiSecretPath is required". OKX also needs the passphrase.
+// Errors never include the values.
+func DecodeExchangeCredentials(exchange string, source CredentialSource) (ExchangeCredentials, error) {
+	if err := ValidateCredentialType(source.Type); err != nil {
+		return ExchangeCredentials{}, err
+	}
+	exchange = strings.ToLower(exchange)
+	prefix := exchange + " " + source.Type + " credentials"
+
+	// encoding/json matches keys ignoring case, the credential lookups do not
+	known := exchangeCredentialKeys[source.Type]
+	for _, key := range slices.Sorted(maps.Keys(source.Config)) {
+		if slices.Contains(known, key) {
+			continue
+		}
+		for _, k := range known {
+			if strings.EqualFold(k, key) {
+				return ExchangeCredentials{}, fmt.Errorf("%s: unknown key %q (did you mean %s?)", prefix, key, k)
+			}
+		}
+		return ExchangeCredentials{}, fmt.Errorf("%s: unknown key %q (want %s)", prefix, key, strings.Join(known, ", "))
+	}
+
+	var creds ExchangeCredentials
+	var target any
+	switch source.Type {
+	case CredentialTypeSSM:
+		creds.SSM = &SSMCredentialConfig{}
+		target = creds.SSM
+	case CredentialTypeInline:
+		creds.Inline = &InlineCredentialConfig{}
+		target = creds.Inline
+	default:
+		creds.Env = &EnvCredentialConfig{}
+		target = creds.Env
+	}
+	raw, err := json.Marshal(source.Config)
+	if err != nil {
+		return ExchangeCredentials{}, fmt.Errorf("%s: invalid config", prefix)
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ExchangeCredentials{}, fmt.Errorf("%s: %s must be a string", prefix, typeErr.Field)
+		}
+		return ExchangeCredentials{}, fmt.Errorf("%s: invalid config", prefix)
+	}
+
+	for _, field := range creds.fields(exchange == "okx") {
+		if strings.TrimSpace(field.value) == "" {
+			return ExchangeCredentials{}, fmt.Errorf("%s: %s is required", prefix, field.key)
+		}
+	}
+	return creds, nil
+}
+
+// credentialField is a config key and its value
+type credentialField struct{ key, value string }
+
+// fields returns the config keys the decoded source must set, with their
+// values; the passphrase only when okx
+func (c ExchangeCredentials) fields(okx bool) []credentialField {
+	var fields []credentialField
+	add := func(key, value string) {
+		fields = append(fields, credentialField{key, value})
+	}
+	switch {
+	case c.SSM != nil:
+		add("apiKeyPath", c.SSM.APIKeyPath)
+		add("apiSecretPath", c.SSM.APISecretPath)
+		if okx {
+			add("passphrasePath", c.SSM.PassphrasePath)
+		}
+	case c.Inline != nil:
+		add("apiKey", c.Inline.APIKey)
+		add("apiSecret", c.Inline.APISecret)
+		if okx {
+			add("passphrase", c.Inline.Passphrase)
+		}
+	case c.Env != nil:
+		add("apiKeyEnv", c.Env.APIKeyEnv)
+		add("apiSecretEnv", c.Env.APISecretEnv)
+		if okx {
+			add("passphraseEnv", c.Env.PassphraseEnv)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeExchangeCredentials(t *testing.T) {
+	full := map[string]map[string]interface{}{
+		CredentialTypeSSM:    {"apiKeyPath": "/dca/key", "apiSecretPath": "/dca/secret", "passphrasePath": "/dca/passphrase"},
+		CredentialTypeInline: {"apiKey": "key-value", "apiSecret": "secret-value", "passphrase": "passphrase-value"},
+		CredentialTypeEnv:    {"apiKeyEnv": "DCA_KEY", "apiSecretEnv": "DCA_SECRET", "passphraseEnv": "DCA_PASSPHRASE"},
+	}
+	// without returns the full config of sourceType less key
+	without := func(sourceType, key string) map[string]interface{} {
+		config := map[string]interface{}{}
+		for k, v := range full[sourceType] {
+			if k != key {
+				config[k] = v
+			}
+		}
+		return config
+	}
+
+	tests := []struct {
+		name       string
+		exchange   string
+		sourceType string
+		config     map[string]interface{}
+		wantErr    string
+	}{
+		{name: "binance ssm", exchange: "binance", sourceType: CredentialTypeSSM, config: without(CredentialTypeSSM, "passphrasePath")},
+		{name: "binance inline", exchange: "binance", sourceType: CredentialTypeInline, config: without(CredentialTypeInline, "passphrase")},
+		{name: "binance env", exchange: "binance", sourceType: CredentialTypeEnv, config: without(CredentialTypeEnv, "passphraseEnv")},
+		{name: "binance with a passphrase", exchange: "binance", sourceType: CredentialTypeSSM, config: full[CredentialTypeSSM]},
+		{name: "okx ssm", exchange: "okx", sourceType: CredentialTypeSSM, config: full[CredentialTypeSSM]},
+		{name: "okx inline", exchange: "OKX", sourceType: CredentialTypeInline, config: full[CredentialTypeInline]},
+		{name: "okx env", exchange: "okx", sourceType: CredentialTypeEnv, config: full[CredentialTypeEnv]},
+
+		{name: "binance ssm without key", exchange: "binance", sourceType: CredentialTypeSSM, config: without(CredentialTypeSSM, "apiKeyPath"), wantErr: "binance ssm credentials: apiKeyPath is required"},
+		{name: "binance ssm without secret", exchange: "binance", sourceType: CredentialTypeSSM, config: without(CredentialTypeSSM, "apiSecretPath"), wantErr: "binance ssm credentials: apiSecretPath is required"},
+		{name: "binance inline without key", exchange: "binance", sourceType: CredentialTypeInline, config: without(CredentialTypeInline, "apiKey"), wantErr: "binance inline credentials: apiKey is required"},
+		{name: "binance inline without secret", exchange: "binance", sourceType: CredentialTypeInline, config: without(CredentialTypeInline, "apiSecret"), wantErr: "binance inline credentials: apiSecret is required"},
+		{name: "binance env without key", exchange: "binance", sourceType: CredentialTypeEnv, config: without(CredentialTypeEnv, "apiKeyEnv"), wantErr: "binance env credentials: apiKeyEnv is required"},
+		{name: "binance env without secret", exchange: "binance", sourceType: CredentialTypeEnv, config: without(CredentialTypeEnv, "apiSecretEnv"), wantErr: "binance env credentials: apiSecretEnv is required"},
+		{name: "okx ssm without passphrase", exchange: "okx", sourceType: CredentialTypeSSM, config: without(CredentialTypeSSM, "passphrasePath"), wantErr: "okx ssm credentials: passphrasePath is required"},
+		{name: "okx inline without passphrase", exchange: "okx", sourceType: CredentialTypeInline, config: without(CredentialTypeInline, "passphrase"), wantErr: "okx inline credentials: passphrase is required"},
+		{name: "okx env without passphrase", exchange: "okx", sourceType: CredentialTypeEnv, config: without(CredentialTypeEnv, "passphraseEnv"), wantErr: "okx env credentials: passphraseEnv is required"},
+		{name: "okx ssm without secret", exchange: "okx", sourceType: CredentialTypeSSM, config: without(CredentialTypeSSM, "apiSecretPath"), wantErr: "okx ssm credentials: apiSecretPath is required"},
+		{name: "no config", exchange: "binance", sourceType: CredentialTypeSSM, wantErr: "binance ssm credentials: apiKeyPath is required"},
+		{name: "blank value", exchange: "binance", sourceType: CredentialTypeEnv, config: map[string]interface{}{"apiKeyEnv": " ", "apiSecretEnv": "DCA_SECRET"}, wantErr: "binance env credentials: apiKeyEnv is required"},
+
+		{name: "misspelt key", exchange: "binance", sourceType: CredentialTypeSSM, config: map[string]interface{}{"apikeyPath": "/dca/key", "apiSecretPath": "/dca/secret"}, wantErr: `binance ssm credentials: unknown key "apikeyPath" (did you mean apiKeyPath?)`},
+		{name: "key of another type", exchange: "okx", sourceType: CredentialTypeInline, config: map[string]interface{}{"apiKeyPath": "/dca/key"}, wantErr: `okx inline credentials: unknown key "apiKeyPath" (want apiKey, apiSecret, passphrase)`},
+		{name: "not a string", exchange: "binance", sourceType: CredentialTypeInline, config: map[string]interface{}{"apiKey": 12345, "apiSecret": "secret-value"}, wantErr: "binance inline credentials: apiKey must be a string"},
+		{name: "unknown type", exchange: "binance", sourceType: "vault", wantErr: ValidateCredentialType("vault").Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := DecodeExchangeCredentials(tt.exchange, CredentialSource{Type: tt.sourceType, Config: tt.config})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("DecodeExchangeCredentials() error = %v, want %q", err, tt.wantErr)
+				}
+				for _, secret := range []string{"key-value", "secret-value", "12345"} {
+					if strings.Contains(err.Error(), secret) {
+						t.Errorf("DecodeExchangeCredentials() error %q includes a value", err)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeExchangeCredentials() error = %v", err)
+			}
+
+			var got map[string]string
+			switch tt.sourceType {
+			case CredentialTypeSSM:
+				got = map[string]string{"apiKeyPath": creds.SSM.APIKeyPath, "apiSecretPath": creds.SSM.APISecretPath, "passphrasePath": creds.SSM.PassphrasePath}
+			case CredentialTypeInline:
+				got = map[string]string{"apiKey": creds.Inline.APIKey, "apiSecret": creds.Inline.APISecret, "passphrase": creds.Inline.Passphrase}
+			case CredentialTypeEnv:
+				got = map[string]string{"apiKeyEnv": creds.Env.APIKeyEnv, "apiSecretEnv": creds.Env.APISecretEnv, "passphraseEnv": creds.Env.PassphraseEnv}
+			}
+			for key, value := range got {
+				if want, _ := tt.config[key].(string); value != want {
+					t.Errorf("%s = %q, want %q", key, value, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// Bounds of strategy.dipMultipliers
+const (
+	DipDefaultLookbackDays = 30
+	DipMaxLookbackDays     = 365 // read as daily candles in a single request
+	DipMaxMultiplier       = 10
+)
+
+// DipMultipliersConfig buys more while the market is down: a run's
+// quoteAmount is multiplied by the tier matching how far the last price is
+// below the reference, the highest price of the last LookbackDays daily
+// candles or ReferencePrice when set. Where the reference or the price
+// cannot be read the run buys the base amount.
+type DipMultipliersConfig struct {
+	Tiers          []DipTier `json:"tiers"`
+	LookbackDays   int       `json:"lookbackDays,omitempty"`   // days of candles the reference high is taken from; default 30
+	ReferencePrice string    `json:"referencePrice,omitempty"` // fixed reference instead of the candles' high
+	MaxQuoteAmount string    `json:"maxQuoteAmount,omitempty"` // cap on the multiplied amount; never below the base amount
+}
+
+// DipTier multiplies the amount of runs at least DrawdownPercent below the
+// reference, unless a deeper tier matches too
+type DipTier struct {
+	DrawdownPercent string `json:"drawdownPercent"` // e.g. "20" for 20% below the reference
+	Multiplier      string `json:"multiplier"`      // e.g. "2" to buy twice the amount
+}
+
+func (c *DipMultipliersConfig) validate() error {
+	if len(c.Tiers) == 0 {
+		return fmt.Errorf("tiers: at least one tier is required")
+	}
+	seen := map[string]bool{}
+	for i, tier := range c.Tiers {
+		drawdown, err := money.PercentFromString(tier.DrawdownPercent)
+		if err != nil || !drawdown.IsPositive() || drawdown.Cmp(money.HundredPercent) >= 0 {
+			return fmt.Errorf("tiers.%d.drawdownPercent: invalid percentage %q (want more than 0 and less than 100)", i, tier.DrawdownPercent)
+		}
+		key := drawdown.String()
+		if seen[key] {
+			return fmt.Errorf("tiers.%d.drawdownPercent: %s%% is listed twice", i, drawdown)
+		}
+		seen[key] = true
+		multiplier, err := decimal.NewFromString(tier.Multiplier)
+		if err != nil || !multiplier.IsPositive() || multiplier.GreaterThan(decimal.NewFromInt(DipMaxMultiplier)) {
+			return fmt.Errorf("tiers.%d.multiplier: invalid value %q (want more than 0 and at most %d)", i, tier.Multiplier, DipMaxMultiplier)
+		}
+	}
+	if c.LookbackDays < 1 || c.LookbackDays > DipMaxLookbackDays {
+		return fmt.Errorf("lookbackDays: must be between 1 and %d, got %d", DipMaxLookbackDays, c.LookbackDays)
+	}
+	if c.ReferencePrice != "" {
+		if price, err := decimal.NewFromString(c.ReferencePrice); err != nil || !price.IsPositive() {
+			return fmt.Errorf("referencePrice: invalid value %q", c.ReferencePrice)
+		}
+	}
+	if c.MaxQuoteAmount != "" {
+		if max, err := decimal.NewFromString(c.MaxQuoteAmount); err != nil || !max.IsPositive() {
+			return fmt.Errorf("maxQuoteAmount: invalid amount %q", c.MaxQuoteAmount)
+		}
+	}
+	return nil
+}
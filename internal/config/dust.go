@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Payload modes selecting what a run does
+const (
+	ModeDCA  = "dca"  // buy strategy.quoteAmount of strategy.symbol (default)
+	ModeDust = "dust" // convert small leftover balances to dust.target
+)
+
+// DustDefaultTarget applies when DustConfig.Target is unset
+const DustDefaultTarget = "BNB"
+
+// DustConfig selects which leftover balances a dust run converts. The base
+// and quote assets of strategy.symbol are never converted.
+type DustConfig struct {
+	Target   string   `json:"target,omitempty"`   // asset dust is converted to; default "BNB"
+	MaxValue string   `json:"maxValue,omitempty"` // only balances worth less than this in Target, e.g. "0.001"
+	Include  []string `json:"include,omitempty"`  // when set, only these assets are converted
+	Exclude  []string `json:"exclude,omitempty"`  // assets never converted
+}
+
+// Protected returns the assets a dust run must not touch: the strategy's
+// base and quote assets plus Exclude
+func (c *DustConfig) Protected(symbol string) []string {
+	protected := append([]string(nil), c.Exclude...)
+	if base, quote, ok := strings.Cut(symbol, "-"); ok {
+		protected = append(protected, base, quote)
+	}
+	return protected
+}
+
+func (c *DustConfig) validate() error {
+	if c.MaxValue != "" {
+		if v, err := decimal.NewFromString(c.MaxValue); err != nil || !v.IsPositive() {
+			return fmt.Errorf("maxValue: invalid value %q", c.MaxValue)
+		}
+	}
+	for i, code := range c.Include {
+		if code == "" {
+			return fmt.Errorf("include[%d]: asset code is required", i)
+		}
+		c.Include[i] = strings.ToUpper(code)
+	}
+	for i, code := range c.Exclude {
+		if code == "" {
+			return fmt.Errorf("exclude[%d]: asset code is required", i)
+		}
+		c.Exclude[i] = strings.ToUpper(code)
+	}
+	return nil
+}
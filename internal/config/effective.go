@@ -0,0 +1,175 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Origin tells where a configuration value came from
+type Origin string
+
+const (
+	OriginPayload Origin = "payload" // set in the payload
+	OriginDefault Origin = "default" // filled in by ParseDCAPayload
+	OriginDerived Origin = "derived" // computed during the run, e.g. quoteAmount from monthlyBudget
+)
+
+// redacted replaces inline credential values in a Snapshot
+const redacted = "[REDACTED]"
+
+// SetOrigin records where the value at path, a dotted JSON path such as
+// "strategy.orderType", came from. The origin applies to everything below
+// path too. Values without a recorded origin came from the payload.
+func (p *DCAPayload) SetOrigin(path string, origin Origin) {
+	if p.origins == nil {
+		p.origins = map[string]Origin{}
+	}
+	p.origins[path] = origin
+}
+
+// Origin returns where the value at path came from
+func (p *DCAPayload) Origin(path string) Origin {
+	for {
+		if origin, ok := p.origins[path]; ok {
+			return origin
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return OriginPayload
+		}
+		path = path[:i]
+	}
+}
+
+// defaultString sets an empty *field to value, recording path as a default
+func (p *DCAPayload) defaultString(field *string, value, path string) {
+	if *field == "" {
+		*field = value
+		p.SetOrigin(path, OriginDefault)
+	}
+}
+
+// defaultInt sets a zero *field to value, recording path as a default
+func (p *DCAPayload) defaultInt(field *int, value int, path string) {
+	if *field == 0 {
+		*field = value
+		p.SetOrigin(path, OriginDefault)
+	}
+}
+
+// EffectiveField is one value of the configuration that governed a run
+type EffectiveField struct {
+	Path   string `json:"path"`
+	Value  any    `json:"value"`
+	Origin Origin `json:"origin"`
+}
+
+// Snapshot is the effective configuration of a run, one field per leaf
+// value, sorted by path
+type Snapshot []EffectiveField
+
+// Effective returns the configuration as it stands, with the origin of
+// every value. Values of inline credentials are redacted; env variable
+// names and SSM paths are kept.
+func (p *DCAPayload) Effective() (Snapshot, error) {
+	return snapshotOf(p, p.Origin)
+}
+
+// SnapshotOf flattens the JSON encoding of v, such as a run's plan, into a
+// Snapshot whose fields all have origin
+func SnapshotOf(v any, origin Origin) (Snapshot, error) {
+	return snapshotOf(v, func(string) Origin { return origin })
+}
+
+func snapshotOf(v any, origin func(path string) Origin) (Snapshot, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to decode configuration: %w", err)
+	}
+
+	var snapshot Snapshot
+	flatten(tree, "", false, func(path string, value any, secret bool) {
+		if secret {
+			value = redacted
+		}
+		snapshot = append(snapshot, EffectiveField{Path: path, Value: value, Origin: origin(path)})
+	})
+	sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Path < snapshot[j].Path })
+	return snapshot, nil
+}
+
+// flatten calls leaf for every scalar below v, telling whether it is the
+// value of an inline credential
+func flatten(v any, path string, secret bool, leaf func(path string, value any, secret bool)) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		inline := v["type"] == CredentialTypeInline
+		for key, child := range v {
+			flatten(child, join(key), secret || (inline && key == "config"), leaf)
+		}
+	case []any:
+		for i, child := range v {
+			flatten(child, join(strconv.Itoa(i)), secret, leaf)
+		}
+	default:
+		leaf(path, v, secret)
+	}
+}
+
+// minSecretLength is the shortest inline credential InlineSecrets returns;
+// scrubbing shorter values, such as a chat ID of "42", would mangle
+// unrelated text
+const minSecretLength = 4
+
+// InlineSecrets returns the inline credential values of a raw payload, the
+// ones Effective redacts, so that text derived from the payload can be
+// scrubbed of them too. The payload only has to be valid JSON, not a valid
+// configuration.
+func InlineSecrets(raw []byte) ([]string, error) {
+	var tree any
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	var secrets []string
+	flatten(tree, "", false, func(_ string, value any, secret bool) {
+		if s, ok := value.(string); ok && secret && len(s) >= minSecretLength && !slices.Contains(secrets, s) {
+			secrets = append(secrets, s)
+		}
+	})
+	return secrets, nil
+}
+
+// Lines renders the snapshot one field per line, e.g.
+// "strategy.orderType = market (default)"
+func (s Snapshot) Lines() []string {
+	lines := make([]string, len(s))
+	for i, f := range s {
+		value := fmt.Sprint(f.Value)
+		switch f.Value {
+		case nil:
+			value = "null"
+		case "":
+			value = `""`
+		}
+		lines[i] = fmt.Sprintf("%s = %s (%s)", f.Path, value, f.Origin)
+	}
+	return lines
+}
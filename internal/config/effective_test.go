@@ -0,0 +1,110 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestEffective_Golden(t *testing.T) {
+	for _, name := range []string{"effective_dca", "effective_dust"} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			payload, err := ParseDCAPayload(raw)
+			if err != nil {
+				t.Fatalf("ParseDCAPayload() error = %v", err)
+			}
+			snapshot, err := payload.Effective()
+			if err != nil {
+				t.Fatalf("Effective() error = %v", err)
+			}
+			encoded, err := json.MarshalIndent(snapshot, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for golden, got := range map[string]string{
+				name + ".golden":      strings.Join(snapshot.Lines(), "\n") + "\n",
+				name + ".json.golden": string(encoded) + "\n",
+			} {
+				path := filepath.Join("testdata", golden)
+				if *update {
+					if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				expected, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("missing golden file (run with -update): %v", err)
+				}
+				if got != string(expected) {
+					t.Errorf("output differs from %s:\n%s", path, got)
+				}
+			}
+			for _, secret := range []string{"key-123", "secret-456", "123:abc", "secret-uuid"} {
+				if strings.Contains(string(encoded), secret) {
+					t.Errorf("snapshot contains the inline credential %q", secret)
+				}
+			}
+		})
+	}
+}
+
+func TestEffective_Origins(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "effective_dca.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := ParseDCAPayload(raw)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+
+	// Every recorded default names a field that is in the snapshot
+	snapshot, _ := payload.Effective()
+	for path := range payload.origins {
+		found := false
+		for _, f := range snapshot {
+			if f.Path == path || strings.HasPrefix(f.Path, path+".") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("origin recorded for %q, which is not a configuration path", path)
+		}
+	}
+
+	tests := []struct {
+		path string
+		want Origin
+	}{
+		{"strategy.quoteAmount", OriginPayload},
+		{"strategy.orderType", OriginDefault},
+		{"strategy.routeBridges.1", OriginDefault},
+		{"integrations.eventBridge.busName", OriginPayload},
+		{"integrations.eventBridge.source", OriginDefault},
+	}
+	for _, tt := range tests {
+		if got := payload.Origin(tt.path); got != tt.want {
+			t.Errorf("Origin(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	// A value computed during the run overrides what was parsed
+	payload.Strategy.QuoteAmount = "11.76"
+	payload.SetOrigin("strategy.quoteAmount", OriginDerived)
+	snapshot, _ = payload.Effective()
+	for _, f := range snapshot {
+		if f.Path == "strategy.quoteAmount" && (f.Value != "11.76" || f.Origin != OriginDerived) {
+			t.Errorf("quoteAmount field = %+v, want the derived amount", f)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// DefaultEgressEchoURL answers with the caller's public IP as plain text
+const DefaultEgressEchoURL = "https://checkip.amazonaws.com"
+
+// ExpectedEgress is where the bot must run from before it touches an
+// exchange's authenticated endpoints, for API keys restricted to a NAT
+// gateway's IP (flags.expectedEgress)
+type ExpectedEgress struct {
+	// IPs are the allowed egress IPs, as addresses or CIDR ranges
+	IPs []string `json:"ips,omitempty"`
+
+	// Region is the AWS region the function must run in, e.g. "eu-central-1"
+	Region string `json:"region,omitempty"`
+
+	// EchoURL returns the caller's IP as plain text; default
+	// DefaultEgressEchoURL
+	EchoURL string `json:"echoURL,omitempty"`
+}
+
+// Echo returns EchoURL, or the default
+func (e *ExpectedEgress) Echo() string {
+	if e.EchoURL != "" {
+		return e.EchoURL
+	}
+	return DefaultEgressEchoURL
+}
+
+// Prefixes returns IPs as prefixes, a single address as a /32 or /128;
+// entries that do not parse are skipped, validate having rejected them
+func (e *ExpectedEgress) Prefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, ip := range e.IPs {
+		if p, err := parseEgress(ip); err == nil {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+func parseEgress(ip string) (netip.Prefix, error) {
+	ip = strings.TrimSpace(ip)
+	if strings.Contains(ip, "/") {
+		p, err := netip.ParsePrefix(ip)
+		return p.Masked(), err
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func (e *ExpectedEgress) validate() error {
+	if len(e.IPs) == 0 && e.Region == "" {
+		return fmt.Errorf("ips or region is required")
+	}
+	for i, ip := range e.IPs {
+		if _, err := parseEgress(ip); err != nil {
+			return fmt.Errorf("ips[%d]: %q is not an IP address or CIDR range", i, ip)
+		}
+	}
+	if e.EchoURL != "" {
+		u, err := url.Parse(e.EchoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("echoURL: %q is not an http(s) URL", e.EchoURL)
+		}
+	}
+	return nil
+}
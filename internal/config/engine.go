@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Execution engines for strategy.engine
+const (
+	EngineSpot   = "spot"   // place a spot order each run (default)
+	EngineNative = "native" // keep the exchange's own recurring-buy plan in line with the strategy
+)
+
+// NativeEngineExchanges lists the exchanges with a native engine
+var NativeEngineExchanges = []string{"binance"}
+
+// Native reports whether the strategy delegates its buys to the exchange's
+// recurring-buy plan instead of placing orders
+func (s DCAStrategy) Native() bool {
+	return s.Engine == EngineNative
+}
+
+// Cycles of a Cadence
+const (
+	CycleDaily   = "daily"
+	CycleWeekly  = "weekly"
+	CycleMonthly = "monthly"
+)
+
+// CadenceMaxDay bounds a monthly cadence's day, so it falls in every month
+const CadenceMaxDay = 28
+
+// Cadence is when a native recurring-buy plan executes, in UTC
+type Cadence struct {
+	Cycle   string       `json:"cycle"`             // CycleDaily, CycleWeekly or CycleMonthly
+	Weekday time.Weekday `json:"weekday,omitempty"` // CycleWeekly only
+	Day     int          `json:"day,omitempty"`     // CycleMonthly only: 1 to CadenceMaxDay
+	Hour    int          `json:"hour"`              // 0-23
+}
+
+func (c Cadence) String() string {
+	switch c.Cycle {
+	case CycleWeekly:
+		return fmt.Sprintf("weekly on %s at %02d:00 UTC", c.Weekday, c.Hour)
+	case CycleMonthly:
+		return fmt.Sprintf("monthly on day %d at %02d:00 UTC", c.Day, c.Hour)
+	default:
+		return fmt.Sprintf("daily at %02d:00 UTC", c.Hour)
+	}
+}
+
+// NativeCadence translates the strategy's schedule into a native plan's
+// cadence: a run on the hour every day ("0 9 * * *"), on one weekday
+// ("0 9 * * 1") or on one day of the month ("0 9 1 * *"). The hour is
+// moved to UTC with the timezone's offset at the given time, so a plan in
+// a zone with daylight saving keeps the UTC hour of when it was set up.
+func (s DCAStrategy) NativeCadence(at time.Time) (Cadence, error) {
+	fields := strings.Fields(s.Schedule)
+	if len(fields) != 5 {
+		return Cadence{}, fmt.Errorf("schedule: required for the native engine")
+	}
+	unsupported := fmt.Errorf("schedule: %q has no native equivalent (want a daily, weekly or monthly run on the hour, e.g. \"0 9 * * 1\")", s.Schedule)
+	minute, dom, month, dow := fields[0], fields[2], fields[3], fields[4]
+	hour, err := strconv.Atoi(fields[1])
+	if err != nil || minute != "0" || month != "*" || hour < 0 || hour > 23 {
+		return Cadence{}, unsupported
+	}
+
+	loc, err := s.Location()
+	if err != nil {
+		return Cadence{}, err
+	}
+	local := at.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, loc)
+	utc := start.UTC()
+	// Days the run moves by in UTC: -1, 0 or +1
+	shift := int(time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC).Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	c := Cadence{Cycle: CycleDaily, Hour: utc.Hour()}
+
+	switch {
+	case dom == "*" && dow == "*":
+	case dom == "*":
+		day, err := strconv.Atoi(dow)
+		if err != nil || day < 0 || day > 7 {
+			return Cadence{}, unsupported
+		}
+		c.Cycle = CycleWeekly
+		c.Weekday = time.Weekday((day + shift + 7) % 7)
+	case dow == "*":
+		day, err := strconv.Atoi(dom)
+		if err != nil || day < 1 || day > CadenceMaxDay {
+			return Cadence{}, fmt.Errorf("schedule: %q has no native equivalent (a monthly plan runs on day 1 to %d)", s.Schedule, CadenceMaxDay)
+		}
+		c.Cycle = CycleMonthly
+		c.Day = day + shift
+		if c.Day < 1 || c.Day > CadenceMaxDay {
+			return Cadence{}, fmt.Errorf("schedule: %q falls on another day of the month in UTC, which a native plan cannot follow", s.Schedule)
+		}
+	default:
+		return Cadence{}, unsupported
+	}
+	return c, nil
+}
+
+func (p *DCAPayload) validateEngine() error {
+	s := p.Strategy
+	switch s.Engine {
+	case "", EngineSpot:
+		return nil
+	case EngineNative:
+	default:
+		return fmt.Errorf("engine: unknown value %q (want %s or %s)", s.Engine, EngineSpot, EngineNative)
+	}
+
+	name := strings.ToLower(p.Exchange.Name)
+	switch {
+	case !slices.Contains(NativeEngineExchanges, name):
+		return fmt.Errorf("engine: %s has no native recurring-buy engine (supported on %s)", p.Exchange.Name, strings.Join(NativeEngineExchanges, ", "))
+	case len(p.Failover) > 0:
+		return fmt.Errorf("engine: the native engine cannot fail over to another exchange")
+	case s.Selling():
+		return fmt.Errorf("engine: the native engine only buys")
+	case s.Budgeted() || s.PercentSized() || s.DipMultipliers != nil:
+		return fmt.Errorf("engine: the native engine needs a fixed quoteAmount")
+	case s.StopLoss != nil || s.AllowRouting || s.CircuitBreaker != nil || s.MaxPrice != "" || s.AutoTransfer || s.HoldingsAlert != nil:
+		return fmt.Errorf("engine: stopLoss, allowRouting, circuitBreaker, maxPrice, autoTransfer and holdingsAlert need the spot engine")
+	}
+	_, err := s.NativeCadence(time.Now())
+	return err
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AlertCooldown is how long a balance alert that keeps firing stays quiet
+// after it was sent. It needs state.status to remember when that was;
+// without it every run that finds the balance out of bounds alerts.
+const AlertCooldown = 7 * 24 * time.Hour
+
+// HoldingsAlertConfig sends a holdings notification once the bot has
+// accumulated more than Threshold of Asset, e.g. to move it to cold storage
+type HoldingsAlertConfig struct {
+	Asset     string `json:"asset,omitempty"` // e.g. "BTC"; default the symbol's base asset
+	Threshold string `json:"threshold"`       // in Asset, e.g. "0.5"
+}
+
+// Amount returns Threshold, valid once the payload was parsed
+func (c *HoldingsAlertConfig) Amount() decimal.Decimal {
+	amount, _ := decimal.NewFromString(c.Threshold)
+	return amount
+}
+
+func (c *HoldingsAlertConfig) validate() error {
+	if c.Asset != "" && (strings.TrimSpace(c.Asset) != c.Asset || strings.Contains(c.Asset, "-")) {
+		return fmt.Errorf("asset: invalid value %q (want an asset code such as \"BTC\")", c.Asset)
+	}
+	if amount, err := decimal.NewFromString(c.Threshold); err != nil || !amount.IsPositive() {
+		return fmt.Errorf("threshold: invalid value %q (want a positive amount)", c.Threshold)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeyMaxLength bounds flags.idempotencyKey; the key is hashed
+// into client order IDs, so longer keys add nothing
+const IdempotencyKeyMaxLength = 128
+
+// OccurrenceKeyFields are the placeholders of flags.idempotencyKey, the
+// time of the run in strategy.timezone
+var OccurrenceKeyFields = []string{"date", "hour", "month"}
+
+// validateIdempotencyKey checks flags.idempotencyKey, which names the
+// scheduled occurrence a run belongs to, e.g. the schedule's
+// <aws.scheduler.scheduled-time>. Runs sharing it place their orders under
+// the same client order IDs, so a redelivered event reuses the order of
+// the first delivery instead of buying again. A key without placeholders
+// is scoped to the day of the triggering event, see OccurrenceKey.
+func (f *RuntimeFlags) validateIdempotencyKey() error {
+	if f.IdempotencyKey == "" {
+		return nil
+	}
+	if strings.TrimSpace(f.IdempotencyKey) == "" {
+		return fmt.Errorf("idempotencyKey: must not be blank")
+	}
+	if len(f.IdempotencyKey) > IdempotencyKeyMaxLength {
+		return fmt.Errorf("idempotencyKey: must be at most %d characters, got %d", IdempotencyKeyMaxLength, len(f.IdempotencyKey))
+	}
+	for _, placeholder := range placeholderPattern.FindAllString(f.IdempotencyKey, -1) {
+		if !slices.Contains(OccurrenceKeyFields, strings.Trim(placeholder, "{}")) {
+			return fmt.Errorf("idempotencyKey: unknown placeholder %s (want one of %v)", placeholder, OccurrenceKeyFields)
+		}
+	}
+	return nil
+}
+
+// OccurrenceKey expands flags.idempotencyKey for a run of payload whose
+// event occurred at, empty without one. at must be the event's time, which
+// retries and redeliveries keep (run.Source.EventTime), not when the run
+// executes: a retry crossing midnight would otherwise get another key and
+// buy again. A key without placeholders gets the date appended, e.g.
+// "nightly#2026-10-16": a static key would otherwise name every run alike,
+// and each would reuse the first run's order instead of buying. Schedules
+// running more than once a day need {hour} or a key that changes per run,
+// such as the scheduled time.
+func (p *DCAPayload) OccurrenceKey(at time.Time) string {
+	key := p.Flags.IdempotencyKey
+	if key == "" {
+		return ""
+	}
+	if !placeholderPattern.MatchString(key) {
+		key += "#{date}"
+	}
+	return p.expandTimes(key, at)
+}
+
+// expandTimes replaces the {date}, {hour} and {month} placeholders of s with
+// at in strategy.timezone
+func (p *DCAPayload) expandTimes(s string, at time.Time) string {
+	if loc, err := p.Strategy.Location(); err == nil {
+		at = at.In(loc)
+	}
+	return strings.NewReplacer(
+		"{date}", at.Format(time.DateOnly),
+		"{hour}", at.Format("2006-01-02T15"),
+		"{month}", at.Format("2006-01"),
+	).Replace(s)
+}
+
+// IdempotencyConfig limits a strategy to one run per window, e.g. one buy
+// a day, with a lock per window in a DynamoDB table that live runs take
+// before trading. A run that finds the lock taken is skipped and returns
+// the order of the run that took it. Every runtime shares the locks through
+// the table, Lambda, the SQS worker and the HTTP servers alike; only local
+// runs keep them in process. Cloud Functions and Azure Functions reach the
+// table with AWS_REGION and AWS credentials such as AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY; without them their runs fail, naming what is
+// missing, or go ahead unlocked under failOpen.
+type IdempotencyConfig struct {
+	Table string `json:"table"` // DynamoDB table with string partition key "pk" and TTL attribute "expiresAt"
+
+	// Key names the window, with the placeholders of IdempotencyKeyFields
+	// expanded; default IdempotencyDefaultKey. Runs for an exchange account
+	// are prefixed by its label unless Key places {account} itself.
+	Key string `json:"key,omitempty"`
+
+	// TTLHours keeps a lock this long after it was taken; default 48
+	TTLHours int `json:"ttlHours,omitempty"`
+
+	// FailOpen runs without the lock when the table cannot be reached;
+	// by default such a run fails rather than risk ordering twice
+	FailOpen bool `json:"failOpen,omitempty"`
+}
+
+// Defaults for IdempotencyConfig
+const (
+	IdempotencyDefaultKey      = "{exchange}#{symbol}#{date}"
+	IdempotencyDefaultTTLHours = 48
+)
+
+// IdempotencyKeyFields are the placeholders of idempotency.key: {date},
+// {hour} and {month} are the time of the run in strategy.timezone, {key}
+// is flags.idempotencyKey with its own placeholders expanded
+var IdempotencyKeyFields = []string{"exchange", "symbol", "side", "account", "date", "hour", "month", "key"}
+
+// placeholderPattern matches the placeholders of idempotency.key
+var placeholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+func (c *IdempotencyConfig) validate() error {
+	if c.Table == "" {
+		return fmt.Errorf("table is required")
+	}
+	if c.Key != "" && strings.TrimSpace(c.Key) == "" {
+		return fmt.Errorf("key: must not be blank")
+	}
+	for _, placeholder := range placeholderPattern.FindAllString(c.Key, -1) {
+		if !slices.Contains(IdempotencyKeyFields, strings.Trim(placeholder, "{}")) {
+			return fmt.Errorf("key: unknown placeholder %s (want one of %v)", placeholder, IdempotencyKeyFields)
+		}
+	}
+	if c.TTLHours < 0 {
+		return fmt.Errorf("ttlHours: must be positive, got %d", c.TTLHours)
+	}
+	return nil
+}
+
+// TTL is how long a lock is kept
+func (c *IdempotencyConfig) TTL() time.Duration {
+	return time.Duration(c.TTLHours) * time.Hour
+}
+
+// LockKey expands idempotency.key for a run of payload at, e.g.
+// "binance#BTC-USDT#2026-10-16"
+func (p *DCAPayload) LockKey(at time.Time) string {
+	if p.Idempotency == nil {
+		return ""
+	}
+	key := p.Idempotency.Key
+	if p.Account != "" && !strings.Contains(key, "{account}") {
+		key = p.Account + "#" + key
+	}
+	return p.expandTimes(strings.NewReplacer(
+		"{exchange}", p.Exchange.Name,
+		"{symbol}", p.Strategy.Symbol,
+		"{side}", p.Strategy.Side,
+		"{account}", p.Account,
+		"{key}", p.Flags.IdempotencyKey,
+	).Replace(key), at)
+}
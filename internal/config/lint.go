@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Finding is a part of a payload that parses but does nothing, contradicts
+// another setting or is a leftover of the legacy format
+type Finding struct {
+	Path   string `json:"path"` // dotted JSON path, e.g. "flags.mock"
+	Reason string `json:"reason"`
+}
+
+func (f Finding) String() string {
+	return f.Path + ": " + f.Reason
+}
+
+// lintRule reports Path with Reason when Applies holds for a parsed payload
+type lintRule struct {
+	Path    string
+	Reason  string
+	Applies func(p *DCAPayload) bool
+}
+
+// lintRules are checked in order by Lint; add new rules here
+var lintRules = []lintRule{
+	{
+		Path:    "dca",
+		Reason:  "legacy payload field, ignored; convert the payload with migrate-payload",
+		Applies: func(p *DCAPayload) bool { return p.hasLegacy("dca") },
+	},
+	{
+		Path:    "credentials",
+		Reason:  "legacy payload field, ignored; use exchange.credentials",
+		Applies: func(p *DCAPayload) bool { return p.hasLegacy("credentials") },
+	},
+	{
+		Path:    "strategy.orderType",
+		Reason:  `"limit" is not supported; every order is a market order`,
+		Applies: func(p *DCAPayload) bool { return strings.EqualFold(p.Strategy.OrderType, "limit") },
+	},
+	{
+		Path:    "strategy.routeBridges",
+		Reason:  "only used with strategy.allowRouting",
+		Applies: func(p *DCAPayload) bool { return len(p.Strategy.RouteBridges) > 0 && !p.Strategy.AllowRouting },
+	},
+	{
+		Path:   "strategy.checkPendingDeposits",
+		Reason: "the exchange does not report pending deposits; only the free balance is checked",
+		Applies: func(p *DCAPayload) bool {
+			return p.Strategy.CheckPendingDeposits && !slices.Contains(PendingDepositExchanges, strings.ToLower(p.Exchange.Name))
+		},
+	},
+	{
+		Path:   "strategy.circuitBreaker",
+		Reason: "the exchange does not report candles; buys are not paused however far the price moves",
+		Applies: func(p *DCAPayload) bool {
+			return p.Strategy.CircuitBreaker != nil && !slices.Contains(CandleExchanges, strings.ToLower(p.Exchange.Name))
+		},
+	},
+	{
+		Path:   "strategy.dipMultipliers",
+		Reason: "the exchange does not report candles; without referencePrice every run buys the base amount",
+		Applies: func(p *DCAPayload) bool {
+			dm := p.Strategy.DipMultipliers
+			return dm != nil && dm.ReferencePrice == "" && !slices.Contains(CandleExchanges, strings.ToLower(p.Exchange.Name))
+		},
+	},
+	{
+		Path:   "strategy.autoTransfer",
+		Reason: "the exchange has no funding or earn account to move funds from; a short balance fails the buy",
+		Applies: func(p *DCAPayload) bool {
+			return p.Strategy.AutoTransfer && !slices.Contains(AutoTransferExchanges, strings.ToLower(p.Exchange.Name))
+		},
+	},
+	{
+		Path:   "state.enrichWithContext",
+		Reason: "no guard reads candles without strategy.circuitBreaker; results get no market context",
+		Applies: func(p *DCAPayload) bool {
+			return p.State != nil && p.State.EnrichWithContext && p.Strategy.CircuitBreaker == nil
+		},
+	},
+	{
+		Path:    "strategy.stopLoss",
+		Reason:  "no stop-loss is placed without flags.allowProtectiveOrders",
+		Applies: func(p *DCAPayload) bool { return p.Strategy.StopLoss != nil && !p.Flags.AllowProtectiveOrders },
+	},
+	{
+		Path:    "flags.allowProtectiveOrders",
+		Reason:  "set without strategy.stopLoss",
+		Applies: func(p *DCAPayload) bool { return p.Flags.AllowProtectiveOrders && p.Strategy.StopLoss == nil },
+	},
+	{
+		Path:    "flags.mock",
+		Reason:  "only the dry-run mock exchange uses it; flags.dryRun is not set",
+		Applies: func(p *DCAPayload) bool { return p.Flags.Mock != nil && !p.Flags.DryRun },
+	},
+	{
+		Path:    "flags.importForeignTrades",
+		Reason:  fmt.Sprintf("only used in %s mode", ModeReconcile),
+		Applies: func(p *DCAPayload) bool { return p.Flags.ImportForeignTrades && p.Mode != ModeReconcile },
+	},
+	{
+		Path:    "dust",
+		Reason:  fmt.Sprintf("only used in %s mode", ModeDust),
+		Applies: func(p *DCAPayload) bool { return p.Dust != nil && p.Mode != ModeDust },
+	},
+	{
+		Path:    "reconcile",
+		Reason:  fmt.Sprintf("only used in %s mode", ModeReconcile),
+		Applies: func(p *DCAPayload) bool { return p.Reconcile != nil && p.Mode != ModeReconcile },
+	},
+	{
+		Path:    "report",
+		Reason:  fmt.Sprintf("only used in %s mode", ModeReport),
+		Applies: func(p *DCAPayload) bool { return p.Report != nil && p.Mode != ModeReport },
+	},
+	{
+		Path:    "notifications.telegram.config.chatId",
+		Reason:  "missing; telegram has no chat to send to",
+		Applies: func(p *DCAPayload) bool { return missingChatID(p.Notifications.Telegram) },
+	},
+	{
+		Path:   "strategy.notifications.telegram.config.chatId",
+		Reason: "missing; telegram has no chat to send to",
+		Applies: func(p *DCAPayload) bool {
+			return p.Strategy.Notifications != nil && missingChatID(p.Strategy.Notifications.Telegram)
+		},
+	},
+}
+
+// Lint is the payload's self-audit: it lists the settings of a parsed
+// payload that have no effect, contradict each other or are deprecated.
+// The run goes ahead regardless unless flags.strictConfig is set, in which
+// case ParseDCAPayload rejects the payload.
+func (p *DCAPayload) Lint() []Finding {
+	var findings []Finding
+	for _, rule := range lintRules {
+		if rule.Applies(p) {
+			findings = append(findings, Finding{Path: rule.Path, Reason: rule.Reason})
+		}
+	}
+	return findings
+}
+
+func (p *DCAPayload) hasLegacy(key string) bool {
+	return slices.Contains(p.legacy, key)
+}
+
+func missingChatID(t *TelegramConfig) bool {
+	if t == nil {
+		return false
+	}
+	switch chatID := t.Config["chatId"].(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(chatID) == ""
+	default:
+		return false // a numeric ID
+	}
+}
+
+// lintError joins findings into the error of a strictConfig payload
+func lintError(findings []Finding) error {
+	parts := make([]string, len(findings))
+	for i, f := range findings {
+		parts[i] = f.String()
+	}
+	return fmt.Errorf("flags.strictConfig: %s", strings.Join(parts, "; "))
+}
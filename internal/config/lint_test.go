@@ -0,0 +1,114 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const lintBase = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "credentials": {"type": "env", "config": {"apiKeyEnv": "BINANCE_KEY", "apiSecretEnv": "BINANCE_SECRET"}}},
+	"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+	"notifications": {"telegram": {"type": "env", "config": {"botTokenEnv": "TG_TOKEN", "chatId": "123"}}},
+	"flags": {"dryRun": true}
+}`
+
+func lintPayload(t *testing.T) *DCAPayload {
+	t.Helper()
+	p, err := ParseDCAPayload([]byte(lintBase))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	return p
+}
+
+func TestLint_Clean(t *testing.T) {
+	if findings := lintPayload(t).Lint(); len(findings) != 0 {
+		t.Errorf("Lint() = %v, want no findings", findings)
+	}
+}
+
+func TestLint_Rules(t *testing.T) {
+	tests := []struct {
+		path   string
+		modify func(p *DCAPayload)
+	}{
+		{"dca", func(p *DCAPayload) { p.legacy = []string{"dca"} }},
+		{"credentials", func(p *DCAPayload) { p.legacy = []string{"credentials"} }},
+		{"strategy.orderType", func(p *DCAPayload) { p.Strategy.OrderType = "limit" }},
+		{"strategy.routeBridges", func(p *DCAPayload) { p.Strategy.RouteBridges = []string{"ETH"} }},
+		{"strategy.checkPendingDeposits", func(p *DCAPayload) { p.Exchange.Name, p.Strategy.CheckPendingDeposits = "okx", true }},
+		{"strategy.circuitBreaker", func(p *DCAPayload) {
+			p.Exchange.Name, p.Strategy.CircuitBreaker = "okx", &CircuitBreakerConfig{MaxMovePercent: "10", WindowMinutes: 60}
+		}},
+		{"strategy.dipMultipliers", func(p *DCAPayload) {
+			p.Exchange.Name, p.Strategy.DipMultipliers = "okx", &DipMultipliersConfig{Tiers: []DipTier{{DrawdownPercent: "10", Multiplier: "2"}}}
+		}},
+		{"strategy.autoTransfer", func(p *DCAPayload) { p.Exchange.Name, p.Strategy.AutoTransfer = "kraken", true }},
+		{"state.enrichWithContext", func(p *DCAPayload) { p.State = &StateConfig{EnrichWithContext: true} }},
+		{"strategy.stopLoss", func(p *DCAPayload) { p.Strategy.StopLoss = &StopLossConfig{PercentBelowFill: "5"} }},
+		{"flags.allowProtectiveOrders", func(p *DCAPayload) { p.Flags.AllowProtectiveOrders = true }},
+		{"flags.mock", func(p *DCAPayload) { p.Flags.DryRun, p.Flags.Mock = false, &MockFlags{} }},
+		{"flags.importForeignTrades", func(p *DCAPayload) { p.Flags.ImportForeignTrades = true }},
+		{"dust", func(p *DCAPayload) { p.Dust = &DustConfig{} }},
+		{"reconcile", func(p *DCAPayload) { p.Reconcile = &ReconcileConfig{} }},
+		{"report", func(p *DCAPayload) { p.Report = &ReportConfig{} }},
+		{"notifications.telegram.config.chatId", func(p *DCAPayload) { delete(p.Notifications.Telegram.Config, "chatId") }},
+		{"strategy.notifications.telegram.config.chatId", func(p *DCAPayload) {
+			p.Strategy.Notifications = &NotificationConfig{Telegram: &TelegramConfig{Type: "env", Config: map[string]interface{}{"chatId": " "}}}
+		}},
+	}
+	if len(tests) != len(lintRules) {
+		t.Fatalf("%d cases for %d rules; test every rule", len(tests), len(lintRules))
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			p := lintPayload(t)
+			tt.modify(p)
+			findings := p.Lint()
+			if len(findings) != 1 || findings[0].Path != tt.path || findings[0].Reason == "" {
+				t.Errorf("Lint() = %v, want one finding at %s", findings, tt.path)
+			}
+		})
+	}
+}
+
+func TestLint_PendingDeposits(t *testing.T) {
+	p := lintPayload(t)
+	p.Exchange.Name, p.Strategy.CheckPendingDeposits = "Kraken", true
+	if findings := p.Lint(); len(findings) != 0 {
+		t.Errorf("Lint() = %v, want checkPendingDeposits accepted on kraken", findings)
+	}
+}
+
+func TestLint_NumericChatID(t *testing.T) {
+	p := lintPayload(t)
+	p.Notifications.Telegram.Config["chatId"] = float64(123456789)
+	if findings := p.Lint(); len(findings) != 0 {
+		t.Errorf("Lint() = %v, want a numeric chat ID accepted", findings)
+	}
+}
+
+func TestParseDCAPayload_LegacyFields(t *testing.T) {
+	raw := strings.Replace(lintBase, `"version": "v2",`, `"version": "v2", "dca": {"symbol": "BTC-USDT"},`, 1)
+	p, err := ParseDCAPayload([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if findings := p.Lint(); len(findings) != 1 || findings[0].Path != "dca" {
+		t.Errorf("Lint() = %v, want the legacy dca field", findings)
+	}
+}
+
+func TestParseDCAPayload_StrictConfig(t *testing.T) {
+	raw := strings.Replace(lintBase, `"flags": {"dryRun": true}`, `"flags": {"strictConfig": true, "mock": {}}`, 1)
+	_, err := ParseDCAPayload([]byte(raw))
+	if err == nil || !strings.Contains(err.Error(), "flags.strictConfig: flags.mock: only the dry-run mock exchange uses it") {
+		t.Errorf("ParseDCAPayload() error = %v, want the finding", err)
+	}
+
+	clean := strings.Replace(lintBase, `"flags": {"dryRun": true}`, `"flags": {"dryRun": true, "strictConfig": true}`, 1)
+	if _, err := ParseDCAPayload([]byte(clean)); err != nil {
+		t.Errorf("ParseDCAPayload() error = %v for a clean payload", err)
+	}
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TelegramSinkStdout is the legacy telegram sink that only logged messages
+const TelegramSinkStdout = "stdout"
+
+// MigrateV2 converts a legacy PayloadV2 to a DCAPayload. Notes lists the
+// legacy fields that could not be carried over and why. The payload is
+// checked with ParseDCAPayload but returned without its defaults filled in.
+func MigrateV2(raw []byte) (payload *DCAPayload, notes []string, err error) {
+	u, err := ParseUnifiedV2(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	var v2 PayloadV2
+	if err := json.Unmarshal(raw, &v2); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	payload = &DCAPayload{
+		Version:  "v2",
+		Exchange: ExchangeConfig{Name: u.Exchange},
+		Strategy: DCAStrategy{
+			Symbol:           u.Symbol,
+			QuoteAmount:      strings.TrimSpace(v2.DCA.QuoteAmount),
+			BalanceThreshold: strings.TrimSpace(v2.DCA.BalanceThreshold),
+			OrderType:        "market", // the only order type of the legacy bot
+		},
+		Flags: RuntimeFlags{DryRun: v2.Flags.DryRun},
+	}
+	if v2.DCA.Symbol != "" && (v2.DCA.TargetAsset != "" || v2.DCA.OrderCurrency != "") {
+		notes = append(notes, "dca.targetAsset/orderCurrency: dca.symbol takes precedence; dropped")
+	}
+
+	creds := &v2.Credentials
+	switch u.Exchange {
+	case "okx":
+		if okx := creds.OKX; okx != nil {
+			sources := []*CredentialSource{}
+			if okx.Inline != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeInline, Config: map[string]any{
+					"apiKey": okx.Inline.APIKey, "apiSecret": okx.Inline.APISecret, "passphrase": okx.Inline.Passphrase,
+				}})
+			}
+			if okx.Env != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeEnv, Config: map[string]any{
+					"apiKeyEnv": okx.Env.APIKeyEnv, "apiSecretEnv": okx.Env.APISecretEnv, "passphraseEnv": okx.Env.PassphraseEnv,
+				}})
+			}
+			if okx.APIKeyPath != "" || okx.APISecretPath != "" || okx.PassphrasePath != "" {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeSSM, Config: map[string]any{
+					"apiKeyPath": okx.APIKeyPath, "apiSecretPath": okx.APISecretPath, "passphrasePath": okx.PassphrasePath,
+				}})
+			}
+			payload.Exchange.Credentials, notes = pickSource("credentials.okx", sources, notes)
+		}
+		if creds.Binance != nil {
+			notes = append(notes, "credentials.binance: not used by exchange okx; dropped")
+		}
+	case "binance":
+		if binance := creds.Binance; binance != nil {
+			sources := []*CredentialSource{}
+			if binance.Inline != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeInline, Config: map[string]any{
+					"apiKey": binance.Inline.APIKey, "apiSecret": binance.Inline.APISecret,
+				}})
+			}
+			if binance.Env != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeEnv, Config: map[string]any{
+					"apiKeyEnv": binance.Env.APIKeyEnv, "apiSecretEnv": binance.Env.APISecretEnv,
+				}})
+			}
+			if binance.APIKeyPath != "" || binance.APISecretPath != "" {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeSSM, Config: map[string]any{
+					"apiKeyPath": binance.APIKeyPath, "apiSecretPath": binance.APISecretPath,
+				}})
+			}
+			payload.Exchange.Credentials, notes = pickSource("credentials.binance", sources, notes)
+		}
+		if creds.OKX != nil {
+			notes = append(notes, "credentials.okx: not used by exchange binance; dropped")
+		}
+	}
+	if payload.Exchange.Credentials.Type == "" {
+		notes = append(notes, fmt.Sprintf("credentials.%s: missing; add exchange.credentials before a live run", u.Exchange))
+	}
+
+	if tg := v2.Notifications.Telegram; tg != nil {
+		switch strings.ToLower(strings.TrimSpace(tg.Sink)) {
+		case TelegramSinkStdout:
+			// Notifications are always logged; without telegram that is all the sink did
+			notes = append(notes, `notifications.telegram.sink: "stdout" is the default without telegram; telegram dropped`)
+		case "", "telegram":
+			sources := []*CredentialSource{}
+			if tg.Inline != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeInline, Config: map[string]any{"botToken": tg.Inline.BotToken}})
+			}
+			if tg.Env != nil {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeEnv, Config: map[string]any{"botTokenEnv": tg.Env.BotTokenEnv}})
+			}
+			if tg.BotTokenPath != "" {
+				sources = append(sources, &CredentialSource{Type: CredentialTypeSSM, Config: map[string]any{"botTokenPath": tg.BotTokenPath}})
+			}
+			var source CredentialSource
+			source, notes = pickSource("notifications.telegram", sources, notes)
+			if source.Type == "" {
+				notes = append(notes, "notifications.telegram: no bot token; dropped")
+				break
+			}
+			source.Config["chatId"] = tg.ChatID
+			payload.Notifications.Telegram = &TelegramConfig{Type: source.Type, Config: source.Config}
+		default:
+			notes = append(notes, fmt.Sprintf("notifications.telegram.sink: unknown sink %q; telegram dropped", tg.Sink))
+		}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := ParseDCAPayload(data); err != nil {
+		return nil, notes, fmt.Errorf("migrated payload is invalid: %w", err)
+	}
+	return payload, notes, nil
+}
+
+// pickSource returns the first of the legacy credential variants, in the
+// order inline, env, ssm, noting the ones dropped
+func pickSource(field string, sources []*CredentialSource, notes []string) (CredentialSource, []string) {
+	if len(sources) == 0 {
+		return CredentialSource{}, notes
+	}
+	for _, dropped := range sources[1:] {
+		notes = append(notes, fmt.Sprintf("%s: %s credentials dropped; %s takes precedence", field, dropped.Type, sources[0].Type))
+	}
+	return *sources[0], notes
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateV2_Golden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "migrate", "*.json"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no migration fixtures: %v", err)
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			payload, notes, err := MigrateV2(raw)
+			if err != nil {
+				t.Fatalf("MigrateV2() error = %v", err)
+			}
+			encoded, err := json.MarshalIndent(payload, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := string(encoded) + "\n"
+			for _, note := range notes {
+				got += "# " + note + "\n"
+			}
+
+			path := strings.TrimSuffix(input, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", path, got)
+			}
+
+			assertRoundTrip(t, raw, encoded)
+		})
+	}
+}
+
+// assertRoundTrip checks that the migrated payload reads back as the same
+// Unified configuration as the legacy one, for the fields both can express
+func assertRoundTrip(t *testing.T, legacy, migrated []byte) {
+	t.Helper()
+	want, err := ParseUnifiedV2(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := ParseDCAPayload(migrated)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	got, err := payload.ToUnified()
+	if err != nil {
+		t.Fatalf("ToUnified() error = %v", err)
+	}
+
+	if got.Exchange != want.Exchange || got.Symbol != want.Symbol || got.DryRun != want.DryRun ||
+		!got.QuoteAmount.Equal(want.QuoteAmount) || !got.BalanceThreshold.Equal(want.BalanceThreshold) {
+		t.Errorf("ToUnified() = %+v, want %+v", got, want)
+	}
+
+	creds := payload.Exchange.Credentials
+	switch {
+	case creds.Type == CredentialTypeSSM && want.OKX != nil && *got.OKX != *want.OKX:
+		t.Errorf("okx ssm paths = %+v, want %+v", *got.OKX, *want.OKX)
+	case creds.Type == CredentialTypeSSM && want.Binance != nil && *got.Binance != *want.Binance:
+		t.Errorf("binance ssm paths = %+v, want %+v", *got.Binance, *want.Binance)
+	case creds.Type == CredentialTypeInline && want.OKXInline != nil && (got.OKXInline == nil || *got.OKXInline != *want.OKXInline):
+		t.Errorf("okx inline credentials = %+v, want %+v", got.OKXInline, *want.OKXInline)
+	case creds.Type == CredentialTypeEnv && want.OKXEnv != nil && (got.OKXEnv == nil || *got.OKXEnv != *want.OKXEnv):
+		t.Errorf("okx env credentials = %+v, want %+v", got.OKXEnv, *want.OKXEnv)
+	case creds.Type == CredentialTypeEnv && want.BinanceEnv != nil && (got.BinanceEnv == nil || *got.BinanceEnv != *want.BinanceEnv):
+		t.Errorf("binance env credentials = %+v, want %+v", got.BinanceEnv, *want.BinanceEnv)
+	}
+
+	tg := payload.Notifications.Telegram
+	if want.Telegram != nil && (want.Telegram.Sink == "" || want.Telegram.Sink == "telegram") && tg == nil {
+		t.Error("telegram notifications were dropped")
+	}
+	if tg != nil {
+		if got.Telegram.ChatID != want.Telegram.ChatID {
+			t.Errorf("telegram chatId = %q, want %q", got.Telegram.ChatID, want.Telegram.ChatID)
+		}
+		if tg.Type == CredentialTypeSSM && got.Telegram.BotTokenPath != want.Telegram.BotTokenPath {
+			t.Errorf("telegram botTokenPath = %q, want %q", got.Telegram.BotTokenPath, want.Telegram.BotTokenPath)
+		}
+	}
+}
+
+func TestMigrateV2_Errors(t *testing.T) {
+	for _, tt := range []struct {
+		name, input, want string
+	}{
+		{"not_v2", `{"version": "v1", "exchange": "okx", "dca": {"symbol": "BTC-USDT", "quoteAmount": "10"}}`, "version"},
+		{"no_symbol", `{"version": "v2", "exchange": "okx", "dca": {"quoteAmount": "10"}}`, "dca.symbol"},
+		{"bad_amount", `{"version": "v2", "exchange": "okx", "dca": {"symbol": "BTC-USDT", "quoteAmount": "-1"}}`, "dca.quoteAmount"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := MigrateV2([]byte(tt.input)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("MigrateV2() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
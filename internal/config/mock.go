@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MockDefaultMethod keys the latency of mock exchange methods without
+// their own entry in MockFlags.Latency
+const MockDefaultMethod = "default"
+
+// MockFlags makes the dry-run mock exchange slow, flaky or halted, to
+// rehearse timeouts and suspensions locally
+type MockFlags struct {
+	// Latency delays mock exchange calls, keyed by method name (e.g.
+	// "PlaceMarketBuyOrder", "GetBalance") or MockDefaultMethod
+	Latency map[string]MockLatency `json:"latency,omitempty"`
+
+	// FailureRate is the probability, from 0 to 1, that a call fails as if
+	// the exchange were unavailable
+	FailureRate float64 `json:"failureRate,omitempty"`
+
+	// Seed makes latencies and failures repeatable; 0 picks a random seed
+	Seed uint64 `json:"seed,omitempty"`
+
+	// Halted are symbols the mock reports as listed but not trading
+	Halted []string `json:"halted,omitempty"`
+
+	// BaseOnly makes the mock accept market buys only in the base asset, so
+	// quote amounts are converted at the ticker price
+	BaseOnly bool `json:"baseOnly,omitempty"`
+
+	// Settlement is the sequence of statuses the mock reports for a market
+	// buy, e.g. ["open", "partial", "filled"]: the first when placing it,
+	// the next on each later lookup, the last repeating
+	Settlement []string `json:"settlement,omitempty"`
+}
+
+// MockOrderStatuses are the statuses flags.mock.settlement may list
+var MockOrderStatuses = []string{"open", "partial", "filled", "rejected", "canceled"}
+
+// MockLatency is a fixed delay or one drawn uniformly from [min, max], as
+// Go durations such as "8s" or "250ms"
+type MockLatency struct {
+	Fixed string `json:"fixed,omitempty"`
+	Min   string `json:"min,omitempty"`
+	Max   string `json:"max,omitempty"`
+}
+
+// Range returns the bounds of the delay; they are equal for a fixed delay
+func (l MockLatency) Range() (min, max time.Duration, err error) {
+	if l.Fixed != "" {
+		if l.Min != "" || l.Max != "" {
+			return 0, 0, fmt.Errorf("set either fixed or min and max")
+		}
+		d, err := parseMockDuration("fixed", l.Fixed)
+		return d, d, err
+	}
+	if min, err = parseMockDuration("min", l.Min); err != nil {
+		return 0, 0, err
+	}
+	if max, err = parseMockDuration("max", l.Max); err != nil {
+		return 0, 0, err
+	}
+	if max < min {
+		return 0, 0, fmt.Errorf("max %s is below min %s", l.Max, l.Min)
+	}
+	return min, max, nil
+}
+
+func parseMockDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, fmt.Errorf("%s is required", field)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", field, value)
+	}
+	return d, nil
+}
+
+func (f *MockFlags) validate() error {
+	for method, latency := range f.Latency {
+		if _, _, err := latency.Range(); err != nil {
+			return fmt.Errorf("latency.%s: %w", method, err)
+		}
+	}
+	if f.FailureRate < 0 || f.FailureRate > 1 {
+		return fmt.Errorf("failureRate must be between 0 and 1")
+	}
+	for i, status := range f.Settlement {
+		if !slices.Contains(MockOrderStatuses, status) {
+			return fmt.Errorf("settlement[%d]: unknown order status %q (want one of %s)", i, status, strings.Join(MockOrderStatuses, ", "))
+		}
+	}
+	return nil
+}
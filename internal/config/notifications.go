@@ -0,0 +1,320 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Notification event names used as keys of NotificationConfig.Events
+const (
+	NotifyPreTrade   = "preTrade"   // guards passed, order about to be placed
+	NotifyPostTrade  = "postTrade"  // order placed
+	NotifySkip       = "skip"       // a guard skipped the run
+	NotifyError      = "error"      // the run failed
+	NotifyLowBalance = "lowBalance" // free quote balance below balanceThreshold
+	NotifyHoldings   = "holdings"   // holdings above strategy.holdingsAlert
+)
+
+// NotificationEvents lists the valid event names
+var NotificationEvents = []string{NotifyPreTrade, NotifyPostTrade, NotifySkip, NotifyError, NotifyLowBalance, NotifyHoldings}
+
+// How a strategy's notifications override treats the global channels
+const (
+	ChannelsReplace = "replace" // channels set in the override replace the global ones
+	ChannelsAppend  = "append"  // channels set in the override receive events alongside the global ones
+)
+
+// NotificationLanguages lists the valid notifications.language values
+var NotificationLanguages = []string{"en", "de", "es", "fr", "ja", "ru", "zh"}
+
+// maxDisplayPrecision bounds notifications.displayPrecision
+const maxDisplayPrecision = 18
+
+// Condition names usable in EventRule.Conditions
+const ConditionMinNotional = "minNotional" // only notify for orders of at least this quote amount
+
+// eventConditions lists the conditions each event supports
+var eventConditions = map[string][]string{
+	NotifyPreTrade:  {ConditionMinNotional},
+	NotifyPostTrade: {ConditionMinNotional},
+}
+
+// EventRule enables or disables one notification event. Every event except
+// preTrade is enabled by default.
+type EventRule struct {
+	Enabled    *bool             `json:"enabled,omitempty"`
+	Conditions map[string]string `json:"conditions,omitempty"` // e.g. {"minNotional": "100"}
+	Delay      string            `json:"delay,omitempty"`      // preTrade only: wait before ordering, e.g. "10s"; default 0
+}
+
+// EventEnabledByDefault reports whether an event without a rule is sent
+func EventEnabledByDefault(event string) bool {
+	return event != NotifyPreTrade
+}
+
+// MinNotional returns the minNotional condition, if set
+func (r EventRule) MinNotional() (decimal.Decimal, bool) {
+	value, ok := r.Conditions[ConditionMinNotional]
+	if !ok {
+		return decimal.Zero, false
+	}
+	min, err := decimal.NewFromString(value)
+	return min, err == nil
+}
+
+// PreTradeDelay returns how long to wait after the preTrade notification
+func (c NotificationConfig) PreTradeDelay() time.Duration {
+	rule, ok := c.Events[NotifyPreTrade]
+	if !ok || rule.Delay == "" {
+		return 0
+	}
+	d, _ := time.ParseDuration(rule.Delay)
+	return d
+}
+
+func (c NotificationConfig) validate() error {
+	if c.Webhook != nil {
+		if err := c.Webhook.validate(); err != nil {
+			return fmt.Errorf("webhook.%w", err)
+		}
+	}
+	for event, rule := range c.Events {
+		if !isNotificationEvent(event) {
+			return fmt.Errorf("events: unknown event %q (want one of %s)", event, strings.Join(NotificationEvents, ", "))
+		}
+
+		names := make([]string, 0, len(rule.Conditions))
+		for name := range rule.Conditions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !supportsCondition(event, name) {
+				return fmt.Errorf("events.%s: unsupported condition %q", event, name)
+			}
+		}
+		if value, ok := rule.Conditions[ConditionMinNotional]; ok {
+			if _, err := decimal.NewFromString(value); err != nil {
+				return fmt.Errorf("events.%s: invalid minNotional %q", event, value)
+			}
+		}
+
+		if rule.Delay != "" {
+			if event != NotifyPreTrade {
+				return fmt.Errorf("events.%s: delay is only supported for %s", event, NotifyPreTrade)
+			}
+			if d, err := time.ParseDuration(rule.Delay); err != nil || d < 0 {
+				return fmt.Errorf("events.%s: invalid delay %q", event, rule.Delay)
+			}
+		}
+	}
+	for _, event := range c.DigestExcludes {
+		if !isNotificationEvent(event) {
+			return fmt.Errorf("digestExcludes: unknown event %q (want one of %s)", event, strings.Join(NotificationEvents, ", "))
+		}
+	}
+	if len(c.DigestExcludes) > 0 && !c.Digest {
+		return fmt.Errorf("digestExcludes: requires digest")
+	}
+	if c.Language != "" && !slices.Contains(NotificationLanguages, c.Language) {
+		return fmt.Errorf("language: unsupported language %q (want one of %s)", c.Language, strings.Join(NotificationLanguages, ", "))
+	}
+	for _, code := range slices.Sorted(maps.Keys(c.DisplayPrecision)) {
+		if strings.TrimSpace(code) == "" {
+			return fmt.Errorf("displayPrecision: asset code is required")
+		}
+		if p := c.DisplayPrecision[code]; p < 0 || p > maxDisplayPrecision {
+			return fmt.Errorf("displayPrecision.%s: must be between 0 and %d, got %d", code, maxDisplayPrecision, p)
+		}
+	}
+	return nil
+}
+
+// Merge returns c with a strategy's override applied, for that strategy's
+// events. Event rules merge per event: the override's rule for an event
+// replaces the global rule as a whole, and events it does not name keep
+// the global rule. A channel set in the override replaces the global one,
+// or with ChannelsAppend is used as well; see notify.Dispatcher.Route.
+// Digest settings are run-wide and always come from c.
+func (c NotificationConfig) Merge(override NotificationConfig) NotificationConfig {
+	merged := c
+	merged.Channels = ""
+	if override.Telegram != nil {
+		merged.Telegram = override.Telegram
+	}
+	if len(override.Events) > 0 {
+		merged.Events = make(map[string]EventRule, len(c.Events)+len(override.Events))
+		for event, rule := range c.Events {
+			merged.Events[event] = rule
+		}
+		for event, rule := range override.Events {
+			merged.Events[event] = rule
+		}
+	}
+	return merged
+}
+
+// AppendsChannels reports whether an override's channels are used alongside
+// the global ones
+func (c NotificationConfig) AppendsChannels() bool {
+	return c.Channels == ChannelsAppend
+}
+
+// validateOverride checks a strategy's notifications override
+func (c NotificationConfig) validateOverride() error {
+	if c.Telegram != nil {
+		if err := ValidateCredentialType(c.Telegram.Type); err != nil {
+			return fmt.Errorf("telegram: %w", err)
+		}
+		if c.Telegram.Commands != nil {
+			return fmt.Errorf("telegram.commands: only valid in the top-level notifications")
+		}
+	}
+	if c.Webhook != nil {
+		return fmt.Errorf("webhook: only valid in the top-level notifications")
+	}
+	if c.Digest || len(c.DigestExcludes) > 0 {
+		return fmt.Errorf("digest: only valid in the top-level notifications")
+	}
+	if c.Language != "" || len(c.DisplayPrecision) > 0 {
+		return fmt.Errorf("language and displayPrecision: only valid in the top-level notifications")
+	}
+	if c.ProjectRunway {
+		return fmt.Errorf("projectRunway: only valid in the top-level notifications")
+	}
+	switch c.Channels {
+	case "", ChannelsReplace, ChannelsAppend:
+	default:
+		return fmt.Errorf("channels: unsupported value %q (want %s or %s)", c.Channels, ChannelsReplace, ChannelsAppend)
+	}
+	if c.Channels != "" && c.Telegram == nil {
+		return fmt.Errorf("channels: requires a channel such as telegram")
+	}
+	return c.validate()
+}
+
+// Digested reports whether an event is held for the invocation's digest.
+// preTrade is never held: it announces an order that is about to happen.
+func (c NotificationConfig) Digested(event string) bool {
+	return c.Digest && event != NotifyPreTrade && !slices.Contains(c.DigestExcludes, event)
+}
+
+// WebhookConfig posts every notification as JSON to URL, signed with
+// HMAC-SHA256 so the receiver can tell it came from the bot. Secrets lists
+// the current secret first; keeping the previous one after it while
+// receivers switch over rotates it without rejected events. See
+// notify.Webhook for the headers.
+type WebhookConfig struct {
+	URL     string          `json:"url"`
+	Secrets []WebhookSecret `json:"secrets"`
+}
+
+// WebhookSecret is one signing secret, identified to receivers by KeyID.
+// Its config key is "hmacSecret", "hmacSecretEnv" or "hmacSecretPath"
+// depending on Type.
+type WebhookSecret struct {
+	KeyID string `json:"keyId"`
+	CredentialSource
+}
+
+// WebhookTimeout bounds a webhook delivery
+const WebhookTimeout = 5 * time.Second
+
+// TelegramTimeout bounds sending one Telegram message
+const TelegramTimeout = 5 * time.Second
+
+// ChatID is the chat notifications go to, config key "chatId": a numeric
+// ID, given as a number or a string, or "@channelusername"
+func (t *TelegramConfig) ChatID() string {
+	switch id := t.Config["chatId"].(type) {
+	case string:
+		return strings.TrimSpace(id)
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
+
+// NotificationDedupRetention is how long state.status remembers a
+// delivered notification, well past the six hours Lambda retries an
+// asynchronous invocation for
+const NotificationDedupRetention = 24 * time.Hour
+
+func (c *WebhookConfig) validate() error {
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url: invalid URL %q", c.URL)
+	}
+	if len(c.Secrets) == 0 {
+		return fmt.Errorf("secrets: at least one secret is required")
+	}
+	seen := make(map[string]bool, len(c.Secrets))
+	for i, secret := range c.Secrets {
+		id := strings.TrimSpace(secret.KeyID)
+		switch {
+		case id == "":
+			return fmt.Errorf("secrets[%d].keyId: required", i)
+		case id != secret.KeyID || strings.ContainsAny(id, ",= "):
+			return fmt.Errorf("secrets[%d].keyId: %q must not contain spaces, commas or '='", i, secret.KeyID)
+		case seen[id]:
+			return fmt.Errorf("secrets[%d].keyId: duplicate key ID %q", i, id)
+		}
+		seen[id] = true
+		if err := ValidateCredentialType(secret.Type); err != nil {
+			return fmt.Errorf("secrets[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// TelegramCommandsConfig lets the chats in AllowedChatIDs send the bot
+// /status, /balance, /pause and /resume. Updates arrive by long polling
+// from the local bot command, or through the Function URL when the bot's
+// webhook points there; webhook requests must carry the secret token
+// configured with the bot token, config key "webhookSecret",
+// "webhookSecretEnv" or "webhookSecretPath" depending on the type.
+type TelegramCommandsConfig struct {
+	AllowedChatIDs []string `json:"allowedChatIds"`
+}
+
+// Allowed reports whether chatID may send commands
+func (c *TelegramCommandsConfig) Allowed(chatID int64) bool {
+	return slices.Contains(c.AllowedChatIDs, strconv.FormatInt(chatID, 10))
+}
+
+func (c *TelegramCommandsConfig) validate() error {
+	if len(c.AllowedChatIDs) == 0 {
+		return fmt.Errorf("allowedChatIds: at least one chat ID is required")
+	}
+	for i, id := range c.AllowedChatIDs {
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return fmt.Errorf("allowedChatIds[%d]: invalid chat ID %q", i, id)
+		}
+	}
+	return nil
+}
+
+func isNotificationEvent(name string) bool {
+	for _, event := range NotificationEvents {
+		if name == event {
+			return true
+		}
+	}
+	return false
+}
+
+func supportsCondition(event, condition string) bool {
+	for _, c := range eventConditions[event] {
+		if c == condition {
+			return true
+		}
+	}
+	return false
+}
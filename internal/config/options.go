@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExchangeOptions tunes how the bot talks to an exchange
+type ExchangeOptions struct {
+	// ExtraHeaders are sent with every request to the exchange, e.g. the
+	// CF-Access tokens of a reverse proxy, keyed by header name. Each value
+	// comes from config key "value", "valueEnv" or "valuePath" depending on
+	// its Type. A "User-Agent" entry replaces the default one.
+	ExtraHeaders map[string]CredentialSource `json:"extraHeaders,omitempty"`
+
+	// SettlementTimeout is how long to wait for a market buy the exchange
+	// acknowledged before it filled, as a Go duration such as "30s";
+	// default DefaultSettlementTimeout
+	SettlementTimeout string `json:"settlementTimeout,omitempty"`
+
+	// SettlementPollInterval is the wait before the first lookup of such
+	// a buy, as a Go duration such as "500ms"; later lookups back off by
+	// doubling. Default DefaultSettlementPollInterval.
+	SettlementPollInterval string `json:"settlementPollInterval,omitempty"`
+
+	// Sandbox talks to the exchange's test venue, Binance's spot testnet or
+	// OKX demo trading, instead of the live one
+	Sandbox bool `json:"sandbox,omitempty"`
+
+	// UseWebsocketFills waits for a market buy to settle on the exchange's
+	// user-data websocket instead of polling the order, falling back to
+	// polling if the socket fails (Binance and OKX)
+	UseWebsocketFills bool `json:"useWebsocketFills,omitempty"`
+
+	// MaxAttempts is how many times a request that fails transiently, with
+	// a timeout, a 5xx or a 429, is sent in all before the run fails;
+	// default DefaultMaxAttempts, 1 disables retries (Binance)
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+}
+
+// Defaults of settlementTimeout, settlementPollInterval and maxAttempts
+const (
+	DefaultSettlementTimeout      = 10 * time.Second
+	DefaultSettlementPollInterval = 500 * time.Millisecond
+	DefaultMaxAttempts            = 3
+)
+
+// Settlement returns SettlementTimeout, or the default; nil-safe
+func (c *ExchangeOptions) Settlement() time.Duration {
+	if c != nil && c.SettlementTimeout != "" {
+		if d, err := time.ParseDuration(c.SettlementTimeout); err == nil {
+			return d
+		}
+	}
+	return DefaultSettlementTimeout
+}
+
+// SettlementPoll returns SettlementPollInterval, or the default; nil-safe
+func (c *ExchangeOptions) SettlementPoll() time.Duration {
+	if c != nil && c.SettlementPollInterval != "" {
+		if d, err := time.ParseDuration(c.SettlementPollInterval); err == nil {
+			return d
+		}
+	}
+	return DefaultSettlementPollInterval
+}
+
+// Attempts returns MaxAttempts, or the default; nil-safe
+func (c *ExchangeOptions) Attempts() int {
+	if c != nil && c.MaxAttempts > 0 {
+		return c.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// reservedHeaders are set by the HTTP client or carry an exchange's
+// credentials and signature, so extraHeaders cannot set them
+var reservedHeaders = []string{
+	"Host", "Content-Length", "Content-Type", "Authorization",
+	"X-Mbx-Apikey",
+	"Ok-Access-Key", "Ok-Access-Sign", "Ok-Access-Timestamp", "Ok-Access-Passphrase",
+	"Api-Key", "Api-Sign",
+	"Cb-Access-Key", "Cb-Access-Sign", "Cb-Access-Timestamp", "Cb-Version",
+}
+
+func (c *ExchangeOptions) validate() error {
+	if c.SettlementTimeout != "" {
+		if d, err := time.ParseDuration(c.SettlementTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("settlementTimeout: must be a positive duration such as \"30s\", got %q", c.SettlementTimeout)
+		}
+	}
+	if c.SettlementPollInterval != "" {
+		if d, err := time.ParseDuration(c.SettlementPollInterval); err != nil || d <= 0 {
+			return fmt.Errorf("settlementPollInterval: must be a positive duration such as \"500ms\", got %q", c.SettlementPollInterval)
+		}
+	}
+	if c.MaxAttempts < 0 {
+		return fmt.Errorf("maxAttempts: must be at least 1, got %d", c.MaxAttempts)
+	}
+	for name, source := range c.ExtraHeaders {
+		if name == "" || strings.ContainsFunc(name, func(r rune) bool { return !isTokenRune(r) }) {
+			return fmt.Errorf("extraHeaders: invalid header name %q", name)
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name)) {
+			return fmt.Errorf("extraHeaders.%s: set by the bot itself", name)
+		}
+		if err := ValidateCredentialType(source.Type); err != nil {
+			return fmt.Errorf("extraHeaders.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// isTokenRune reports whether r may appear in an HTTP header name
+func isTokenRune(r rune) bool {
+	return r < 0x7f && (r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || strings.ContainsRune("!#$%&'*+-.^_`|~", r))
+}
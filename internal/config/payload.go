@@ -3,31 +3,368 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+	"github.com/sudowanderer/dca-bot-go/internal/withdrawal"
 )
 
 // New unified payload structure
 type DCAPayload struct {
 	Version       string              `json:"version"`
+	Mode          string             `json:"mode,omitempty"` // ModeDCA (default), ModeDust, ModeReconcile or ModeReport
 	Exchange      ExchangeConfig      `json:"exchange"`
 	Strategy      DCAStrategy         `json:"strategy"`
 	Notifications NotificationConfig  `json:"notifications"`
 	Flags         RuntimeFlags        `json:"flags"`
+	Integrations  IntegrationsConfig `json:"integrations,omitzero"`
+	State         *StateConfig       `json:"state,omitempty"`
+	Dust          *DustConfig        `json:"dust,omitempty"`      // used in ModeDust
+	Reconcile     *ReconcileConfig   `json:"reconcile,omitempty"` // used in ModeReconcile
+	Report        *ReportConfig      `json:"report,omitempty"`    // used in ModeReport
+
+	// Idempotency allows one run per window; see IdempotencyConfig
+	Idempotency *IdempotencyConfig `json:"idempotency,omitempty"`
+
+	// The payload is only valid from NotBefore until ExpiresAt, both
+	// optional RFC3339 timestamps: earlier runs are skipped, later ones fail
+	NotBefore string `json:"notBefore,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+
+	// Failover holds further exchanges, in priority order, tried when
+	// Exchange is unavailable. In JSON, "exchange" is then an array whose
+	// first entry is Exchange.
+	Failover []ExchangeConfig `json:"-"`
+
+	// Account is the label of the exchange account a payload returned by
+	// ForAccount runs for
+	Account string `json:"-"`
+
+	origins map[string]Origin // see SetOrigin
+	legacy  []string          // top-level keys of the legacy format still present; see Lint
+}
+
+// payloadJSON is DCAPayload with exchange kept raw, so it can be an object or an array
+type payloadJSON struct {
+	dcaPayloadFields
+	Exchange json.RawMessage `json:"exchange"`
+
+	// Keys of the legacy PayloadV2, kept only to be reported
+	LegacyDCA         json.RawMessage `json:"dca,omitempty"`
+	LegacyCredentials json.RawMessage `json:"credentials,omitempty"`
+}
+
+type dcaPayloadFields DCAPayload
+
+// UnmarshalJSON accepts "exchange" as a single object or a priority-ordered array
+func (p *DCAPayload) UnmarshalJSON(data []byte) error {
+	var raw payloadJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = DCAPayload(raw.dcaPayloadFields)
+	p.Exchange, p.Failover = ExchangeConfig{}, nil
+	if raw.LegacyDCA != nil {
+		p.legacy = append(p.legacy, "dca")
+	}
+	if raw.LegacyCredentials != nil {
+		p.legacy = append(p.legacy, "credentials")
+	}
+
+	trimmed := strings.TrimSpace(string(raw.Exchange))
+	switch {
+	case trimmed == "" || trimmed == "null":
+	case strings.HasPrefix(trimmed, "["):
+		var venues []ExchangeConfig
+		if err := json.Unmarshal(raw.Exchange, &venues); err != nil {
+			return err
+		}
+		if len(venues) == 0 {
+			return fmt.Errorf("exchange array must not be empty")
+		}
+		p.Exchange, p.Failover = venues[0], venues[1:]
+	default:
+		if err := json.Unmarshal(raw.Exchange, &p.Exchange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes "exchange" as an array when failover exchanges are set
+func (p DCAPayload) MarshalJSON() ([]byte, error) {
+	var exchange interface{} = p.Exchange
+	if len(p.Failover) > 0 {
+		exchange = p.Venues()
+	}
+	encoded, err := json.Marshal(exchange)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(payloadJSON{dcaPayloadFields: dcaPayloadFields(p), Exchange: encoded})
+}
+
+// Venues returns Exchange followed by the failover exchanges
+func (p *DCAPayload) Venues() []ExchangeConfig {
+	return append([]ExchangeConfig{p.Exchange}, p.Failover...)
+}
+
+// WithVenue returns a copy of the payload that runs against venue only
+func (p *DCAPayload) WithVenue(venue ExchangeConfig) *DCAPayload {
+	c := *p
+	c.Exchange = venue
+	c.Failover = nil
+	return &c
 }
 
 type ExchangeConfig struct {
 	Name        string          `json:"name"`        // "binance", "okx"
 	Credentials CredentialSource `json:"credentials"` // unified credential source
 	Region      string          `json:"region,omitempty"` // optional, for different regions
+
+	// Accounts runs the strategy once per account, in order, instead of
+	// once with Credentials; see DCAPayload.ForAccount
+	Accounts []AccountConfig `json:"accounts,omitempty"`
+
+	APIUsage *APIUsageConfig `json:"apiUsage,omitempty"` // when to warn about request weight
+
+	Options *ExchangeOptions `json:"options,omitempty"` // extra request headers, settlement wait
 }
 
 type DCAStrategy struct {
 	Symbol           string `json:"symbol"`           // "BTC-USDT"
 	QuoteAmount      string `json:"quoteAmount"`      // "10.00"
-	BalanceThreshold string `json:"balanceThreshold"` // "5000.00"
+	BalanceThreshold string `json:"balanceThreshold"` // "5000.00", or {"amount": "200", "currency": "USD"}
 	OrderType        string `json:"orderType"`        // "market", "limit"
+
+	// ThresholdCurrency and ThresholdPegTolerance come from the object form
+	// of balanceThreshold; see Threshold
+	ThresholdCurrency     string `json:"-"` // empty means the quote asset
+	ThresholdPegTolerance string `json:"-"`
+
+	Timezone string          `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"; default UTC
+	Calendar *CalendarConfig `json:"calendar,omitempty"` // optional days on which no order is placed
+
+	ExecutionWindow *ExecutionWindowConfig `json:"executionWindow,omitempty"` // when a late invocation still places an order
+
+	FeeHandling string `json:"feeHandling,omitempty"` // "include" (default) or "deduct"
+	FeeRateBps  string `json:"feeRateBps,omitempty"`  // taker fee fallback when the exchange cannot report it, e.g. "10"
+
+	AllowRouting bool     `json:"allowRouting,omitempty"` // buy through a bridge asset when the symbol is not listed
+	RouteBridges []string `json:"routeBridges,omitempty"` // bridge assets tried in order; default ["USDT", "BTC"]
+
+	StopLoss *StopLossConfig `json:"stopLoss,omitempty"` // protective sell after each buy; needs flags.allowProtectiveOrders
+
+	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"` // skip buys while the price moves too fast
+
+	DipMultipliers *DipMultipliersConfig `json:"dipMultipliers,omitempty"` // buy more the further the price is below a reference
+
+	Withdrawal *WithdrawalConfig `json:"withdrawal,omitempty"` // where bought coins are withdrawn to
+
+	HoldingsAlert *HoldingsAlertConfig `json:"holdingsAlert,omitempty"` // notify once the bought asset piles up
+
+	Side     string `json:"side,omitempty"`     // "buy" (default) or "sell"; selling makes quoteAmount the target proceeds
+	MinPrice string `json:"minPrice,omitempty"` // sell only: skip the run while the price is below this floor
+	MaxPrice string `json:"maxPrice,omitempty"` // buy only: skip the run while the last price is above this ceiling
+
+	// MonthlyBudget replaces quoteAmount with an amount derived each run
+	// from the month's budget; see Budgeted
+	MonthlyBudget  string `json:"monthlyBudget,omitempty"`  // e.g. "300"
+	Schedule       string `json:"schedule,omitempty"`       // cron expression the runs are triggered on, in the strategy timezone
+	MaxQuoteAmount string `json:"maxQuoteAmount,omitempty"` // cap on a budgeted or percent-sized run's amount
+	BudgetHistory  string `json:"budgetHistory,omitempty"`  // execution history JSONL holding the month-to-date spend; a local path or s3://bucket/key
+
+	// QuoteAmountPercent replaces quoteAmount with a percentage of the free
+	// quote balance read each run; see PercentSized
+	QuoteAmountPercent string `json:"quoteAmountPercent,omitempty"` // e.g. "5" for 5%
+
+	Engine string `json:"engine,omitempty"` // EngineSpot (default) or EngineNative
+
+	// CheckPendingDeposits tells a short balance caused by deposits still
+	// settling from one with no money coming, skipping the run for the
+	// former; see PendingDepositExchanges
+	CheckPendingDeposits bool `json:"checkPendingDeposits,omitempty"`
+
+	// AutoTransfer tops up a short spot balance from the funding or earn
+	// account before a buy; see AutoTransferExchanges
+	AutoTransfer bool `json:"autoTransfer,omitempty"`
+
+	// CancelUnfilledAfterSeconds cancels a buy still open or partly filled
+	// this long after it was placed, keeping what filled; see
+	// CancelUnfilledAfter
+	CancelUnfilledAfterSeconds int `json:"cancelUnfilledAfterSeconds,omitempty"`
+
+	// Notifications overrides the top-level notifications for this
+	// strategy's events; see NotificationConfig.Merge
+	Notifications *NotificationConfig `json:"notifications,omitempty"`
+}
+
+// CancelUnfilledAfter is how long a buy may stay unsettled before it is
+// canceled, in place of exchange.options.settlementTimeout; zero when
+// unsettled buys are left pending
+func (s DCAStrategy) CancelUnfilledAfter() time.Duration {
+	return time.Duration(s.CancelUnfilledAfterSeconds) * time.Second
+}
+
+// PendingDepositExchanges report the deposits strategy.checkPendingDeposits
+// waits for; elsewhere only the free balance is checked
+var PendingDepositExchanges = []string{"kraken", "coinbase", "binance"}
+
+// CandleExchanges report candles (exchange.KlineSource), which
+// strategy.circuitBreaker measures the price move with and
+// strategy.dipMultipliers its reference high; elsewhere neither reads
+// candles
+var CandleExchanges = []string{"binance"}
+
+// AutoTransferExchanges move funds for strategy.autoTransfer: OKX from the
+// funding account, Binance by redeeming Simple Earn flexible products
+var AutoTransferExchanges = []string{"binance", "okx"}
+
+// AutoTransferBuffer is added to the shortfall strategy.autoTransfer moves,
+// so that fees and price moves before the buy do not leave it short
+var AutoTransferBuffer = money.NewPercent(decimal.NewFromInt(1))
+
+// StopLossConfig places a stop-limit sell below each fill. Percentages are
+// of the fill price, e.g. "5" for 5%.
+type StopLossConfig struct {
+	PercentBelowFill   string `json:"percentBelowFill"`             // stop (trigger) price distance
+	LimitOffsetPercent string `json:"limitOffsetPercent,omitempty"` // limit price distance below the stop; default "0.5"
+}
+
+// WithdrawalConfig is the address bought coins are withdrawn to. The
+// address must be valid for Network, e.g. a checksummed 0x address for
+// "ETH", and is checked again, against the allow-list in the SSM parameter
+// named by DCA_WITHDRAWAL_ALLOWLIST, before it is used.
+type WithdrawalConfig struct {
+	Address string `json:"address"`
+	Network string `json:"network"` // e.g. "BTC", "ETH", "ARBITRUM"; see withdrawal.Networks
+}
+
+func (c *WithdrawalConfig) validate() error {
+	if c.Network == "" {
+		return fmt.Errorf("network: required to validate the address")
+	}
+	if _, err := withdrawal.Network(c.Network); err != nil {
+		return fmt.Errorf("network: %w", err)
+	}
+	if err := withdrawal.ValidateAddress(c.Network, c.Address); err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+	return nil
+}
+
+// StopLossDefaultLimitOffsetPercent applies when LimitOffsetPercent is unset
+const StopLossDefaultLimitOffsetPercent = "0.5"
+
+// Below returns PercentBelowFill, valid once the payload was parsed
+func (c StopLossConfig) Below() money.Percent {
+	below, _ := money.PercentFromString(c.PercentBelowFill)
+	return below
+}
+
+// LimitOffset returns LimitOffsetPercent, zero when unset and valid once
+// the payload was parsed
+func (c StopLossConfig) LimitOffset() money.Percent {
+	offset, _ := money.PercentFromString(c.LimitOffsetPercent)
+	return offset
+}
+
+func (c *StopLossConfig) validate() error {
+	pct, err := money.PercentFromString(c.PercentBelowFill)
+	if err != nil || !pct.IsPositive() || pct.Cmp(money.HundredPercent) >= 0 {
+		return fmt.Errorf("percentBelowFill: invalid value %q (want a percentage between 0 and 100)", c.PercentBelowFill)
+	}
+	if c.LimitOffsetPercent != "" {
+		offset, err := money.PercentFromString(c.LimitOffsetPercent)
+		if err != nil || offset.IsNegative() || pct.Add(offset).Cmp(money.HundredPercent) >= 0 {
+			return fmt.Errorf("limitOffsetPercent: invalid value %q", c.LimitOffsetPercent)
+		}
+	}
+	return nil
+}
+
+// DefaultRouteBridges applies when routing is allowed and RouteBridges is unset
+var DefaultRouteBridges = []string{"USDT", "BTC"}
+
+// CalendarConfig lists days, in the strategy timezone, on which runs are skipped
+type CalendarConfig struct {
+	SkipWeekdays []string `json:"skipWeekdays,omitempty"` // e.g. ["SAT", "SUN"]
+	SkipDates    []string `json:"skipDates,omitempty"`    // ISO dates, e.g. ["2025-12-31"]
+}
+
+// DateLayout is the format of calendar dates in the payload
+const DateLayout = "2006-01-02"
+
+var weekdays = map[string]time.Weekday{
+	"SUN": time.Sunday,
+	"MON": time.Monday,
+	"TUE": time.Tuesday,
+	"WED": time.Wednesday,
+	"THU": time.Thursday,
+	"FRI": time.Friday,
+	"SAT": time.Saturday,
+}
+
+// ParseWeekday parses a three-letter weekday name such as "SAT" (case-insensitive)
+func ParseWeekday(name string) (time.Weekday, error) {
+	day, ok := weekdays[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("unknown weekday %q (want one of MON, TUE, WED, THU, FRI, SAT, SUN)", name)
+	}
+	return day, nil
+}
+
+// Location returns the strategy timezone, defaulting to UTC
+func (s DCAStrategy) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", s.Timezone, err)
+	}
+	return loc, nil
+}
+
+// FeeRate returns FeeRateBps, zero when unset and valid once the payload
+// was parsed
+func (s DCAStrategy) FeeRate() money.BasisPoints {
+	rate, _ := money.BasisPointsFromString(s.FeeRateBps)
+	return rate
+}
+
+func (s DCAStrategy) validateFees() error {
+	switch s.FeeHandling {
+	case "", sizing.FeeInclude, sizing.FeeDeduct:
+	default:
+		return fmt.Errorf("feeHandling: unknown value %q (want include or deduct)", s.FeeHandling)
+	}
+	if s.FeeRateBps != "" {
+		rate, err := money.BasisPointsFromString(s.FeeRateBps)
+		if err != nil || rate.IsNegative() || rate.Percent().Cmp(money.HundredPercent) >= 0 {
+			return fmt.Errorf("feeRateBps: invalid value %q", s.FeeRateBps)
+		}
+	}
+	return nil
+}
+
+func (c *CalendarConfig) validate() error {
+	for _, day := range c.SkipWeekdays {
+		if _, err := ParseWeekday(day); err != nil {
+			return fmt.Errorf("calendar.skipWeekdays: %w", err)
+		}
+	}
+	for _, date := range c.SkipDates {
+		if _, err := time.Parse(DateLayout, date); err != nil {
+			return fmt.Errorf("calendar.skipDates: invalid date %q (want YYYY-MM-DD)", date)
+		}
+	}
+	return nil
 }
 
 type CredentialSource struct {
@@ -36,16 +373,268 @@ type CredentialSource struct {
 }
 
 type NotificationConfig struct {
-	Telegram *TelegramConfig `json:"telegram,omitempty"`
+	Telegram *TelegramConfig      `json:"telegram,omitempty"`
+	Webhook  *WebhookConfig       `json:"webhook,omitempty"` // top-level only
+	Events   map[string]EventRule `json:"events,omitempty"`  // per-event toggles, keyed by event name
+
+	// Digest buffers the events of one invocation and sends them as a single
+	// message per channel when it ends. Events named in DigestExcludes, e.g.
+	// "error", are still sent immediately.
+	Digest         bool     `json:"digest,omitempty"`
+	DigestExcludes []string `json:"digestExcludes,omitempty"`
+
+	// Channels is only valid in a strategy's override: ChannelsReplace
+	// (default) or ChannelsAppend
+	Channels string `json:"channels,omitempty"`
+
+	// Language selects how numbers are written for people, e.g. "de" for
+	// 1.234,56; default "en". DisplayPrecision overrides the decimals shown
+	// per asset, e.g. {"ETH": 4}. Both are top-level only.
+	Language         string         `json:"language,omitempty"`
+	DisplayPrecision map[string]int `json:"displayPrecision,omitempty"`
+
+	// ProjectRunway adds to each successful order's notification the date
+	// the quote balance is projected to run short of the threshold or of a
+	// run, following the strategy's schedule. Top-level only.
+	ProjectRunway bool `json:"projectRunway,omitempty"`
 }
 
 type TelegramConfig struct {
 	Type   string                 `json:"type"`   // "inline", "env", "ssm"
 	Config map[string]interface{} `json:"config"` // flexible configuration
+
+	// Commands lets allowed chats query the bot; top-level notifications only
+	Commands *TelegramCommandsConfig `json:"commands,omitempty"`
+}
+
+// IntegrationsConfig configures where execution results are published for
+// downstream automation
+type IntegrationsConfig struct {
+	EventBridge *EventBridgeConfig `json:"eventBridge,omitempty"`
+	TradingView *TradingViewConfig `json:"tradingview,omitempty"`
+
+	RetryScheduler *RetrySchedulerConfig `json:"retryScheduler,omitempty"`
+
+	AuditLog *AuditLogConfig `json:"auditLog,omitempty"`
+
+	Heartbeat *HeartbeatConfig `json:"heartbeat,omitempty"`
+}
+
+// HeartbeatConfig pings a dead man's switch, such as healthchecks.io, at the
+// end of every run so that runs that stop or start failing raise an alert
+type HeartbeatConfig struct {
+	// URL is the ping URL; config key "url", "urlEnv" or "urlPath"
+	// depending on Type, so the URL can be kept out of the payload
+	URL CredentialSource `json:"url"`
+}
+
+// HeartbeatTimeout bounds a heartbeat ping
+const HeartbeatTimeout = 2 * time.Second
+
+func (c *HeartbeatConfig) validate() error {
+	if err := ValidateCredentialType(c.URL.Type); err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	if c.URL.Type != CredentialTypeInline {
+		return nil
+	}
+	raw, _ := c.URL.Config["url"].(string)
+	if u, err := url.Parse(raw); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url: invalid URL %q", raw)
+	}
+	return nil
+}
+
+// AuditLogConfig writes every live order, cancellation, withdrawal and dust
+// conversion request and its response to S3 as a hash-chained JSON object
+type AuditLogConfig struct {
+	Bucket string `json:"bucket"`
+	Prefix string `json:"prefix,omitempty"` // key prefix; default "audit/"
+
+	// Async writes objects in the background, flushed before the run
+	// returns, instead of before the exchange response is handed back
+	Async bool `json:"async,omitempty"`
+
+	// OnFailure is AuditLogFatal (default) or AuditLogWarn
+	OnFailure string `json:"onFailure,omitempty"`
+}
+
+// Audit log failure policies and defaults
+const (
+	// AuditLogFatal fails the run and refuses further audited requests once
+	// a write fails
+	AuditLogFatal = "fatal"
+	// AuditLogWarn logs failed writes and carries on
+	AuditLogWarn = "warn"
+
+	AuditLogDefaultPrefix = "audit/"
+)
+
+// Fatal reports whether a failed write fails the run
+func (c *AuditLogConfig) Fatal() bool {
+	return c.OnFailure != AuditLogWarn
+}
+
+func (c *AuditLogConfig) validate() error {
+	if c.Bucket == "" {
+		return fmt.Errorf("bucket is required")
+	}
+	switch c.OnFailure {
+	case "", AuditLogFatal, AuditLogWarn:
+	default:
+		return fmt.Errorf("onFailure must be %s or %s", AuditLogFatal, AuditLogWarn)
+	}
+	return nil
+}
+
+// EventBridgeConfig selects the bus and event fields used to publish results.
+// Empty fields fall back to the EventBridgeDefault* values.
+type EventBridgeConfig struct {
+	BusName    string `json:"busName,omitempty"`
+	Source     string `json:"source,omitempty"`
+	DetailType string `json:"detailType,omitempty"` // suffixed with the run status, e.g. "DCA Execution Skipped"
+}
+
+// Defaults for EventBridgeConfig
+const (
+	EventBridgeDefaultBusName    = "default"
+	EventBridgeDefaultSource     = "dca-bot"
+	EventBridgeDefaultDetailType = "DCA Execution"
+)
+
+// TradingViewConfig maps TradingView webhook alerts to immediate buys.
+// The amount always comes from here, never from the webhook body.
+type TradingViewConfig struct {
+	// Passphrase is the shared secret alerts must carry; config key
+	// "passphrase", "passphraseEnv" or "passphrasePath" depending on Type
+	Passphrase CredentialSource `json:"passphrase"`
+
+	Symbols            map[string]TradingViewSymbol `json:"symbols"`                      // keyed by alert ticker, e.g. "BTCUSDT"
+	MaxTriggersPerHour int                          `json:"maxTriggersPerHour,omitempty"` // per symbol; default 1
+}
+
+// TradingViewSymbol is what an alert for one ticker buys
+type TradingViewSymbol struct {
+	Symbol      string `json:"symbol"`      // "BTC-USDT"
+	QuoteAmount string `json:"quoteAmount"` // "25"
+}
+
+// TradingViewDefaultMaxTriggersPerHour applies when MaxTriggersPerHour is unset
+const TradingViewDefaultMaxTriggersPerHour = 1
+
+func (c *TradingViewConfig) validate() error {
+	if err := ValidateCredentialType(c.Passphrase.Type); err != nil {
+		return fmt.Errorf("passphrase: %w", err)
+	}
+	if len(c.Symbols) == 0 {
+		return fmt.Errorf("at least one symbol mapping is required")
+	}
+	for ticker, mapping := range c.Symbols {
+		if err := ValidateSymbol(mapping.Symbol); err != nil {
+			return fmt.Errorf("symbols.%s: %w", ticker, err)
+		}
+		if err := ValidateQuoteAmount(mapping.QuoteAmount); err != nil {
+			return fmt.Errorf("symbols.%s: %w", ticker, err)
+		}
+		mapping.Symbol = strings.ToUpper(mapping.Symbol)
+		c.Symbols[ticker] = mapping
+	}
+	if c.MaxTriggersPerHour < 0 {
+		return fmt.Errorf("maxTriggersPerHour must not be negative")
+	}
+	return nil
+}
+
+// RetrySchedulerConfig re-schedules an EventBridge-triggered run as a
+// one-shot EventBridge Scheduler schedule when the exchange is unavailable
+type RetrySchedulerConfig struct {
+	RoleARN      string `json:"roleArn"`                // role the scheduler assumes to invoke the function
+	TargetARN    string `json:"targetArn,omitempty"`    // defaults to the invoked function
+	GroupName    string `json:"groupName,omitempty"`    // default "default"
+	DelayMinutes int    `json:"delayMinutes,omitempty"` // default 10
+}
+
+// Defaults and limits for RetrySchedulerConfig
+const (
+	RetrySchedulerDefaultGroupName    = "default"
+	RetrySchedulerDefaultDelayMinutes = 10
+	RetrySchedulerMaxDelayMinutes     = 24 * 60
+)
+
+// Delay returns how long after a failure the retry runs
+func (c *RetrySchedulerConfig) Delay() time.Duration {
+	return time.Duration(c.DelayMinutes) * time.Minute
+}
+
+func (c *RetrySchedulerConfig) validate() error {
+	if c.RoleARN == "" {
+		return fmt.Errorf("roleArn is required")
+	}
+	if c.DelayMinutes < 0 || c.DelayMinutes > RetrySchedulerMaxDelayMinutes {
+		return fmt.Errorf("delayMinutes must be between 1 and %d", RetrySchedulerMaxDelayMinutes)
+	}
+	return nil
 }
 
 type RuntimeFlags struct {
 	DryRun bool `json:"dryRun"`
+
+	// AllowProtectiveOrders must be set for strategy.stopLoss to place orders
+	AllowProtectiveOrders bool `json:"allowProtectiveOrders,omitempty"`
+
+	// LogTiming logs the run's timing breakdown as a table
+	LogTiming bool `json:"logTiming,omitempty"`
+
+	// LogConfig logs the effective configuration with the origin of each value
+	LogConfig bool `json:"logConfig,omitempty"`
+
+	// StrictMode fails a run that would succeed with warnings, such as a
+	// failed notification or result publish. The order has still been
+	// placed; sources that retry failed invocations will run it again.
+	StrictMode bool `json:"strictMode,omitempty"`
+
+	// Mock simulates latency and outages in the dry-run mock exchange
+	Mock *MockFlags `json:"mock,omitempty"`
+
+	// ImportForeignTrades makes a reconcile run import trades the bot did not
+	// place; otherwise they are only reported
+	ImportForeignTrades bool `json:"importForeignTrades,omitempty"`
+
+	// SimulateFailure fails the run with a SIMULATED error at the named
+	// stage (see SimulateOrder), exercising the real failure path. Live runs
+	// also need AllowSimulatedFailuresEnv.
+	SimulateFailure string `json:"simulateFailure,omitempty"`
+
+	// StrictConfig rejects a payload with Lint findings instead of running
+	// it with warnings
+	StrictConfig bool `json:"strictConfig,omitempty"`
+
+	// IdempotencyKey identifies the scheduled occurrence of the run, so
+	// redeliveries of its event do not order twice; see
+	// validateIdempotencyKey. It may use {date}, {hour} and {month}; a key
+	// without them is scoped to the day of the triggering event
+	// (OccurrenceKey). Without it the EventBridge event id, or the
+	// invocation ID retries keep, is used.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
+	// AllowUnknownFields accepts keys no field reads, such as a misspelt
+	// "quoteAmout", which ParseDCAPayload otherwise rejects
+	AllowUnknownFields bool `json:"allowUnknownFields,omitempty"`
+
+	// RampUp scales the first orders after a strategy change
+	RampUp *RampUpConfig `json:"rampUp,omitempty"`
+
+	// ExpectedEgress fails a live run that does not leave from the expected
+	// IPs or run in the expected region, before any authenticated request
+	ExpectedEgress *ExpectedEgress `json:"expectedEgress,omitempty"`
+
+	// CostPrices override entries of the price table the run's cost
+	// estimate uses; see validateCostPrices
+	CostPrices map[string]string `json:"costPrices,omitempty"`
+
+	// RequireApproval holds a live run whose strategy changed until a
+	// second person approves its hash
+	RequireApproval *ApprovalConfig `json:"requireApproval,omitempty"`
 }
 
 // Legacy PayloadV2 struct (keep for backward compatibility)
@@ -58,7 +647,7 @@ type PayloadV2 struct {
 		OrderCurrency    string `json:"orderCurrency"`
 		QuoteAmount      string `json:"quoteAmount"`
 		BalanceThreshold string `json:"balanceThreshold"`
-	} `json:"dca"`
+	} `json:"dca,omitempty"`
 	Credentials struct {
 		OKX *struct {
 			APIKeyPath     string `json:"apiKeyPath"`
@@ -87,7 +676,7 @@ type PayloadV2 struct {
 				APISecretEnv string `json:"apiSecretEnv"`
 			} `json:"env"`
 		} `json:"binance"`
-	} `json:"credentials"`
+	} `json:"credentials,omitempty"`
 	Notifications struct {
 		Telegram *struct {
 			BotTokenPath string `json:"botTokenPath"`
@@ -119,9 +708,15 @@ type Unified struct {
 	OKXInline *struct {
 		APIKey, APISecret, Passphrase string
 	}
+	OKXEnv *struct {
+		APIKeyEnv, APISecretEnv, PassphraseEnv string
+	}
 	Binance *struct {
 		APIKeyPath, APISecretPath string
 	}
+	BinanceEnv *struct {
+		APIKeyEnv, APISecretEnv string
+	}
 	Telegram *struct {
 		BotTokenPath, ChatID, Sink string
 	}
@@ -137,46 +732,378 @@ func ParseDCAPayload(raw []byte) (*DCAPayload, error) {
 	if strings.ToLower(payload.Version) != "v2" {
 		return nil, fmt.Errorf(`version must be "v2"`)
 	}
+	if !payload.Flags.AllowUnknownFields {
+		if err := checkUnknownFields(raw); err != nil {
+			return nil, err
+		}
+	}
+	if err := payload.validateValidity(); err != nil {
+		return nil, err
+	}
 	
 	// Validate exchange name
-	if payload.Exchange.Name == "" {
-		return nil, fmt.Errorf("exchange name is required")
+	if err := ValidateExchangeName(payload.Exchange.Name); err != nil {
+		return nil, err
+	}
+
+	if payload.Exchange.Credentials.Type != "" {
+		if err := ValidateCredentialType(payload.Exchange.Credentials.Type); err != nil {
+			return nil, fmt.Errorf("exchange credentials: %w", err)
+		}
+		if _, err := DecodeExchangeCredentials(payload.Exchange.Name, payload.Exchange.Credentials); err != nil {
+			return nil, err
+		}
 	}
 	
+	if len(payload.Exchange.Accounts) > 0 {
+		if err := payload.validateAccounts(); err != nil {
+			return nil, err
+		}
+	}
+	if usage := payload.Exchange.APIUsage; usage != nil {
+		if err := usage.validate(); err != nil {
+			return nil, fmt.Errorf("exchange.apiUsage.%w", err)
+		}
+	}
+	if options := payload.Exchange.Options; options != nil {
+		if err := options.validate(); err != nil {
+			return nil, fmt.Errorf("exchange.options.%w", err)
+		}
+	}
+
+	for i, venue := range payload.Failover {
+		if err := ValidateExchangeName(venue.Name); err != nil {
+			return nil, fmt.Errorf("exchange[%d]: %w", i+1, err)
+		}
+		if err := ValidateCredentialType(venue.Credentials.Type); err != nil {
+			return nil, fmt.Errorf("exchange[%d] credentials: %w", i+1, err)
+		}
+		if _, err := DecodeExchangeCredentials(venue.Name, venue.Credentials); err != nil {
+			return nil, fmt.Errorf("exchange[%d]: %w", i+1, err)
+		}
+		if len(venue.Accounts) > 0 {
+			return nil, fmt.Errorf("exchange[%d].accounts: not supported with failover exchanges", i+1)
+		}
+		if usage := venue.APIUsage; usage != nil {
+			if err := usage.validate(); err != nil {
+				return nil, fmt.Errorf("exchange[%d].apiUsage.%w", i+1, err)
+			}
+		}
+		if options := venue.Options; options != nil {
+			if err := options.validate(); err != nil {
+				return nil, fmt.Errorf("exchange[%d].options.%w", i+1, err)
+			}
+		}
+	}
+
 	// Validate strategy
-	if payload.Strategy.Symbol == "" {
-		return nil, fmt.Errorf("strategy symbol is required")
+	if err := ValidateSymbol(payload.Strategy.Symbol); err != nil {
+		return nil, err
 	}
+	payload.Strategy.Symbol = strings.ToUpper(payload.Strategy.Symbol)
 	
-	if payload.Strategy.QuoteAmount == "" {
-		return nil, fmt.Errorf("strategy quoteAmount is required")
+	switch payload.Mode {
+	case "", ModeDCA:
+		payload.defaultString(&payload.Mode, ModeDCA, "mode")
+		if err := payload.Strategy.validateBudget(); err != nil {
+			return nil, fmt.Errorf("strategy %w", err)
+		}
+		if err := payload.Strategy.validatePercent(); err != nil {
+			return nil, fmt.Errorf("strategy %w", err)
+		}
+		if !payload.Strategy.Budgeted() && !payload.Strategy.PercentSized() {
+			if err := ValidateQuoteAmount(payload.Strategy.QuoteAmount); err != nil {
+				return nil, err
+			}
+		}
+	case ModeDust:
+		// A dust run buys nothing; the symbol only names the holdings to protect
+		payload.defaultString(&payload.Strategy.QuoteAmount, "0", "strategy.quoteAmount")
+		if payload.Dust == nil {
+			payload.Dust = &DustConfig{}
+			payload.SetOrigin("dust", OriginDefault)
+		}
+		if err := payload.Dust.validate(); err != nil {
+			return nil, fmt.Errorf("dust.%w", err)
+		}
+		payload.defaultString(&payload.Dust.Target, DustDefaultTarget, "dust.target")
+		payload.Dust.Target = strings.ToUpper(payload.Dust.Target)
+	case ModeReconcile:
+		// A reconcile run trades nothing; the symbol names the trades to check
+		payload.defaultString(&payload.Strategy.QuoteAmount, "0", "strategy.quoteAmount")
+		if payload.Reconcile == nil {
+			return nil, fmt.Errorf("reconcile is required in %s mode", ModeReconcile)
+		}
+		if err := payload.Reconcile.validate(); err != nil {
+			return nil, fmt.Errorf("reconcile.%w", err)
+		}
+	case ModeReport:
+		// A report run trades nothing; the symbol names the holdings to report
+		payload.defaultString(&payload.Strategy.QuoteAmount, "0", "strategy.quoteAmount")
+		if payload.Strategy.Schedule != "" {
+			if _, err := payload.Strategy.ParsedSchedule(); err != nil {
+				return nil, fmt.Errorf("strategy schedule: %w", err)
+			}
+		}
+		if payload.Report == nil {
+			payload.Report = &ReportConfig{}
+			payload.SetOrigin("report", OriginDefault)
+		}
+		if payload.Strategy.BudgetHistory != "" {
+			payload.defaultString(&payload.Report.History, payload.Strategy.BudgetHistory, "report.history")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode %q (want %s, %s, %s or %s)", payload.Mode, ModeDCA, ModeDust, ModeReconcile, ModeReport)
 	}
 	
-	// Validate quote amount is a valid decimal
-	if _, err := decimal.NewFromString(payload.Strategy.QuoteAmount); err != nil {
-		return nil, fmt.Errorf("invalid quoteAmount: %w", err)
+	if err := ValidateBalanceThreshold(payload.Strategy.BalanceThreshold); err != nil {
+		return nil, err
+	}
+	if err := payload.Strategy.validateThreshold(); err != nil {
+		return nil, fmt.Errorf("strategy balanceThreshold.%w", err)
 	}
 	
-	// Validate balance threshold if provided
-	if payload.Strategy.BalanceThreshold != "" {
-		if _, err := decimal.NewFromString(payload.Strategy.BalanceThreshold); err != nil {
-			return nil, fmt.Errorf("invalid balanceThreshold: %w", err)
+	if telegram := payload.Notifications.Telegram; telegram != nil {
+		if err := ValidateCredentialType(telegram.Type); err != nil {
+			return nil, fmt.Errorf("notifications.telegram: %w", err)
+		}
+		if commands := telegram.Commands; commands != nil {
+			if err := commands.validate(); err != nil {
+				return nil, fmt.Errorf("notifications.telegram.commands.%w", err)
+			}
+			if payload.State == nil || payload.State.Status == nil {
+				return nil, fmt.Errorf("notifications.telegram.commands: requires state.status")
+			}
+		}
+	}
+
+	if err := payload.Notifications.validate(); err != nil {
+		return nil, fmt.Errorf("notifications.%w", err)
+	}
+	if payload.Notifications.Channels != "" {
+		return nil, fmt.Errorf("notifications.channels: only valid in strategy notifications")
+	}
+	if payload.Notifications.ProjectRunway && payload.Strategy.Schedule == "" {
+		return nil, fmt.Errorf("notifications.projectRunway: requires strategy.schedule")
+	}
+	if override := payload.Strategy.Notifications; override != nil {
+		if err := override.validateOverride(); err != nil {
+			return nil, fmt.Errorf("strategy notifications.%w", err)
 		}
 	}
+
+	if err := payload.Strategy.validateFees(); err != nil {
+		return nil, fmt.Errorf("strategy %w", err)
+	}
+
+	// Validate timezone and calendar
+	if _, err := payload.Strategy.Location(); err != nil {
+		return nil, fmt.Errorf("strategy %w", err)
+	}
+
+	if payload.Strategy.Calendar != nil {
+		if err := payload.Strategy.Calendar.validate(); err != nil {
+			return nil, fmt.Errorf("invalid strategy %w", err)
+		}
+	}
+	if err := payload.Strategy.validateWindow(); err != nil {
+		return nil, fmt.Errorf("strategy %w", err)
+	}
 	
 	// Set default order type
-	if payload.Strategy.OrderType == "" {
-		payload.Strategy.OrderType = "market"
+	payload.defaultString(&payload.Strategy.OrderType, "market", "strategy.orderType")
+	if err := ValidateOrderType(payload.Strategy.OrderType); err != nil {
+		return nil, err
+	}
+	payload.Strategy.OrderType = strings.ToLower(payload.Strategy.OrderType)
+	payload.defaultString(&payload.Strategy.FeeHandling, sizing.FeeInclude, "strategy.feeHandling")
+	if payload.Strategy.CancelUnfilledAfterSeconds < 0 {
+		return nil, fmt.Errorf("strategy cancelUnfilledAfterSeconds: must not be negative, got %d", payload.Strategy.CancelUnfilledAfterSeconds)
+	}
+
+	if err := payload.Strategy.validateSide(); err != nil {
+		return nil, fmt.Errorf("strategy %w", err)
+	}
+	payload.defaultString(&payload.Strategy.Side, SideBuy, "strategy.side")
+	if err := payload.validateEngine(); err != nil {
+		return nil, fmt.Errorf("strategy %w", err)
+	}
+
+	if sl := payload.Strategy.StopLoss; sl != nil {
+		if err := sl.validate(); err != nil {
+			return nil, fmt.Errorf("strategy stopLoss.%w", err)
+		}
+		payload.defaultString(&sl.LimitOffsetPercent, StopLossDefaultLimitOffsetPercent, "strategy.stopLoss.limitOffsetPercent")
+	}
+
+	if cb := payload.Strategy.CircuitBreaker; cb != nil {
+		if err := cb.validate(); err != nil {
+			return nil, fmt.Errorf("strategy circuitBreaker.%w", err)
+		}
+	}
+
+	if dm := payload.Strategy.DipMultipliers; dm != nil {
+		payload.defaultInt(&dm.LookbackDays, DipDefaultLookbackDays, "strategy.dipMultipliers.lookbackDays")
+		if err := dm.validate(); err != nil {
+			return nil, fmt.Errorf("strategy dipMultipliers.%w", err)
+		}
+		// Either would be undone by the multiplier applied after it
+		if payload.Strategy.PercentSized() {
+			return nil, fmt.Errorf("strategy dipMultipliers: not supported with quoteAmountPercent; a multiplied run could order more than the percentage of the free balance")
+		}
+		if payload.Flags.RampUp != nil {
+			return nil, fmt.Errorf("strategy dipMultipliers: not supported with flags.rampUp; a tier would override the ramp-up step")
+		}
+	}
+
+	if h := payload.Strategy.HoldingsAlert; h != nil {
+		if err := h.validate(); err != nil {
+			return nil, fmt.Errorf("strategy holdingsAlert.%w", err)
+		}
+	}
+
+	if w := payload.Strategy.Withdrawal; w != nil {
+		if err := w.validate(); err != nil {
+			return nil, fmt.Errorf("strategy withdrawal.%w", err)
+		}
+	}
+
+	if payload.Strategy.AllowRouting && len(payload.Strategy.RouteBridges) == 0 {
+		payload.Strategy.RouteBridges = slices.Clone(DefaultRouteBridges)
+		payload.SetOrigin("strategy.routeBridges", OriginDefault)
+	}
+	for i, bridge := range payload.Strategy.RouteBridges {
+		if strings.TrimSpace(bridge) == "" {
+			return nil, fmt.Errorf("strategy routeBridges[%d]: asset code is required", i)
+		}
+		payload.Strategy.RouteBridges[i] = strings.ToUpper(strings.TrimSpace(bridge))
+	}
+
+	if tv := payload.Integrations.TradingView; tv != nil {
+		if err := tv.validate(); err != nil {
+			return nil, fmt.Errorf("integrations.tradingview: %w", err)
+		}
+		payload.defaultInt(&tv.MaxTriggersPerHour, TradingViewDefaultMaxTriggersPerHour, "integrations.tradingview.maxTriggersPerHour")
+	}
+
+	if rs := payload.Integrations.RetryScheduler; rs != nil {
+		if err := rs.validate(); err != nil {
+			return nil, fmt.Errorf("integrations.retryScheduler: %w", err)
+		}
+		payload.defaultString(&rs.GroupName, RetrySchedulerDefaultGroupName, "integrations.retryScheduler.groupName")
+		payload.defaultInt(&rs.DelayMinutes, RetrySchedulerDefaultDelayMinutes, "integrations.retryScheduler.delayMinutes")
+	}
+
+	if err := payload.Flags.validateSimulateFailure(); err != nil {
+		return nil, fmt.Errorf("flags.%w", err)
+	}
+
+	if err := payload.Flags.validateIdempotencyKey(); err != nil {
+		return nil, fmt.Errorf("flags.%w", err)
+	}
+
+	if mock := payload.Flags.Mock; mock != nil {
+		if err := mock.validate(); err != nil {
+			return nil, fmt.Errorf("flags.mock.%w", err)
+		}
+	}
+
+	if egress := payload.Flags.ExpectedEgress; egress != nil {
+		if err := egress.validate(); err != nil {
+			return nil, fmt.Errorf("flags.expectedEgress.%w", err)
+		}
+	}
+
+	if approval := payload.Flags.RequireApproval; approval != nil {
+		if err := approval.validate(); err != nil {
+			return nil, fmt.Errorf("flags.requireApproval.%w", err)
+		}
+	}
+
+	if err := validateCostPrices(payload.Flags.CostPrices); err != nil {
+		return nil, fmt.Errorf("flags.costPrices.%w", err)
+	}
+
+	if ramp := payload.Flags.RampUp; ramp != nil {
+		if err := ramp.validate(); err != nil {
+			return nil, fmt.Errorf("flags.rampUp.%w", err)
+		}
+		if payload.State == nil || payload.State.Status == nil {
+			return nil, fmt.Errorf("flags.rampUp: requires state.status to remember the strategy between runs")
+		}
+	}
+
+	if idem := payload.Idempotency; idem != nil {
+		if err := idem.validate(); err != nil {
+			return nil, fmt.Errorf("idempotency.%w", err)
+		}
+		payload.defaultString(&idem.Key, IdempotencyDefaultKey, "idempotency.key")
+		payload.defaultInt(&idem.TTLHours, IdempotencyDefaultTTLHours, "idempotency.ttlHours")
+	}
+
+	if state := payload.State; state != nil && state.SharedRateLimit != nil {
+		rl := state.SharedRateLimit
+		if err := rl.validate(); err != nil {
+			return nil, fmt.Errorf("state.sharedRateLimit.%w", err)
+		}
+		payload.defaultString(&rl.IPGroup, SharedRateLimitDefaultIPGroup, "state.sharedRateLimit.ipGroup")
+		payload.defaultInt(&rl.Burst, rl.RequestsPerMinute, "state.sharedRateLimit.burst")
+	}
+
+	if state := payload.State; state != nil && state.Status != nil {
+		st := state.Status
+		if err := st.validate(); err != nil {
+			return nil, fmt.Errorf("state.status: %w", err)
+		}
+		if st.Bucket != "" {
+			payload.defaultString(&st.Prefix, StatusDefaultPrefix, "state.status.prefix")
+			if !strings.HasSuffix(st.Prefix, "/") {
+				st.Prefix += "/"
+			}
+		}
+	}
+
+	if hb := payload.Integrations.Heartbeat; hb != nil {
+		if err := hb.validate(); err != nil {
+			return nil, fmt.Errorf("integrations.heartbeat.%w", err)
+		}
+	}
+
+	if al := payload.Integrations.AuditLog; al != nil {
+		if err := al.validate(); err != nil {
+			return nil, fmt.Errorf("integrations.auditLog: %w", err)
+		}
+		payload.defaultString(&al.Prefix, AuditLogDefaultPrefix, "integrations.auditLog.prefix")
+		if !strings.HasSuffix(al.Prefix, "/") {
+			al.Prefix += "/"
+		}
+		payload.defaultString(&al.OnFailure, AuditLogFatal, "integrations.auditLog.onFailure")
+	}
+
+	if eb := payload.Integrations.EventBridge; eb != nil {
+		payload.defaultString(&eb.BusName, EventBridgeDefaultBusName, "integrations.eventBridge.busName")
+		payload.defaultString(&eb.Source, EventBridgeDefaultSource, "integrations.eventBridge.source")
+		payload.defaultString(&eb.DetailType, EventBridgeDefaultDetailType, "integrations.eventBridge.detailType")
 	}
 	
+	if payload.Flags.StrictConfig {
+		if findings := payload.Lint(); len(findings) > 0 {
+			return nil, lintError(findings)
+		}
+	}
+
 	return &payload, nil
 }
 
 // Convert DCAPayload to Unified for backward compatibility
 func (p *DCAPayload) ToUnified() (Unified, error) {
-	qa, err := decimal.NewFromString(p.Strategy.QuoteAmount)
-	if err != nil {
-		return Unified{}, fmt.Errorf("invalid quoteAmount: %w", err)
+	// A percent-sized amount is only known once the balance has been read
+	qa := decimal.Zero
+	var err error
+	if p.Strategy.QuoteAmount != "" || !p.Strategy.PercentSized() {
+		if qa, err = decimal.NewFromString(p.Strategy.QuoteAmount); err != nil {
+			return Unified{}, fmt.Errorf("invalid quoteAmount: %w", err)
+		}
 	}
 	
 	bt := decimal.Zero
@@ -189,7 +1116,7 @@ func (p *DCAPayload) ToUnified() (Unified, error) {
 	
 	unified := Unified{
 		Exchange:         strings.ToLower(p.Exchange.Name),
-		Symbol:           strings.ToUpper(p.Strategy.Symbol),
+		Symbol:           p.Strategy.Symbol,
 		QuoteAmount:      qa,
 		BalanceThreshold: bt,
 		DryRun:           p.Flags.DryRun,
@@ -225,55 +1152,48 @@ func (p *DCAPayload) ToUnified() (Unified, error) {
 	return unified, nil
 }
 
+// populateUnifiedCredentials copies the decoded exchange credentials into
+// the per-exchange fields of unified
 func (p *DCAPayload) populateUnifiedCredentials(unified *Unified) error {
-	switch strings.ToLower(p.Exchange.Name) {
+	name := strings.ToLower(p.Exchange.Name)
+	var creds ExchangeCredentials
+	if p.Exchange.Credentials.Type != "" {
+		var err error
+		if creds, err = DecodeExchangeCredentials(name, p.Exchange.Credentials); err != nil {
+			return err
+		}
+	}
+
+	switch name {
 	case "binance":
 		unified.Binance = &struct {
 			APIKeyPath, APISecretPath string
 		}{}
-		
-		switch p.Exchange.Credentials.Type {
-		case "ssm":
-			if keyPath, ok := p.Exchange.Credentials.Config["apiKeyPath"].(string); ok {
-				unified.Binance.APIKeyPath = keyPath
-			}
-			if secretPath, ok := p.Exchange.Credentials.Config["apiSecretPath"].(string); ok {
-				unified.Binance.APISecretPath = secretPath
-			}
+		if ssm := creds.SSM; ssm != nil {
+			unified.Binance.APIKeyPath, unified.Binance.APISecretPath = ssm.APIKeyPath, ssm.APISecretPath
+		}
+		if env := creds.Env; env != nil {
+			unified.BinanceEnv = &struct {
+				APIKeyEnv, APISecretEnv string
+			}{env.APIKeyEnv, env.APISecretEnv}
 		}
 		
 	case "okx":
 		unified.OKX = &struct {
 			APIKeyPath, APISecretPath, PassphrasePath string
 		}{}
-		
-		switch p.Exchange.Credentials.Type {
-		case "ssm":
-			if keyPath, ok := p.Exchange.Credentials.Config["apiKeyPath"].(string); ok {
-				unified.OKX.APIKeyPath = keyPath
-			}
-			if secretPath, ok := p.Exchange.Credentials.Config["apiSecretPath"].(string); ok {
-				unified.OKX.APISecretPath = secretPath
-			}
-			if passphrasePath, ok := p.Exchange.Credentials.Config["passphrasePath"].(string); ok {
-				unified.OKX.PassphrasePath = passphrasePath
-			}
+		if ssm := creds.SSM; ssm != nil {
+			unified.OKX.APIKeyPath, unified.OKX.APISecretPath, unified.OKX.PassphrasePath = ssm.APIKeyPath, ssm.APISecretPath, ssm.PassphrasePath
 		}
-		
-		if p.Exchange.Credentials.Type == "inline" {
+		if env := creds.Env; env != nil {
+			unified.OKXEnv = &struct {
+				APIKeyEnv, APISecretEnv, PassphraseEnv string
+			}{env.APIKeyEnv, env.APISecretEnv, env.PassphraseEnv}
+		}
+		if inline := creds.Inline; inline != nil {
 			unified.OKXInline = &struct {
 				APIKey, APISecret, Passphrase string
-			}{}
-			
-			if key, ok := p.Exchange.Credentials.Config["apiKey"].(string); ok {
-				unified.OKXInline.APIKey = key
-			}
-			if secret, ok := p.Exchange.Credentials.Config["apiSecret"].(string); ok {
-				unified.OKXInline.APISecret = secret
-			}
-			if passphrase, ok := p.Exchange.Credentials.Config["passphrase"].(string); ok {
-				unified.OKXInline.Passphrase = passphrase
-			}
+			}{inline.APIKey, inline.APISecret, inline.Passphrase}
 		}
 	}
 	
@@ -337,6 +1257,11 @@ func ParseUnifiedV2(raw []byte) (Unified, error) {
 				v2.Credentials.OKX.Inline.Passphrase,
 			}
 		}
+		if env := v2.Credentials.OKX.Env; env != nil {
+			u.OKXEnv = &struct {
+				APIKeyEnv, APISecretEnv, PassphraseEnv string
+			}{env.APIKeyEnv, env.APISecretEnv, env.PassphraseEnv}
+		}
 	}
 	if v2.Credentials.Binance != nil {
 		u.Binance = &struct {
@@ -345,6 +1270,11 @@ func ParseUnifiedV2(raw []byte) (Unified, error) {
 			v2.Credentials.Binance.APIKeyPath,
 			v2.Credentials.Binance.APISecretPath,
 		}
+		if env := v2.Credentials.Binance.Env; env != nil {
+			u.BinanceEnv = &struct {
+				APIKeyEnv, APISecretEnv string
+			}{env.APIKeyEnv, env.APISecretEnv}
+		}
 	}
 	if v2.Notifications.Telegram != nil {
 		u.Telegram = &struct {
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/shopspring/decimal"
 )
@@ -228,6 +232,161 @@ func TestParseDCAPayload_Errors(t *testing.T) {
 			}`,
 			expectedErr: "invalid balanceThreshold",
 		},
+		{
+			name: "zero_quote_amount",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "0"}
+			}`,
+			expectedErr: `invalid quoteAmount "0": must be greater than zero`,
+		},
+		{
+			name: "negative_quote_amount",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "-5"}
+			}`,
+			expectedErr: `invalid quoteAmount "-5": must be greater than zero`,
+		},
+		{
+			name: "negative_balance_threshold",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "balanceThreshold": "-1"}
+			}`,
+			expectedErr: `invalid balanceThreshold "-1": must not be negative`,
+		},
+		{
+			name: "unknown_order_type",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "orderType": "stop"}
+			}`,
+			expectedErr: `strategy orderType: unknown value "stop" (want market or limit)`,
+		},
+		{
+			name: "tradingview_without_symbols",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"integrations": {"tradingview": {"passphrase": {"type": "env"}}}
+			}`,
+			expectedErr: "at least one symbol mapping",
+		},
+		{
+			name: "tradingview_invalid_amount",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"integrations": {"tradingview": {
+					"passphrase": {"type": "env"},
+					"symbols": {"BTCUSDT": {"symbol": "BTC-USDT", "quoteAmount": "lots"}}
+				}}
+			}`,
+			expectedErr: "symbols.BTCUSDT: invalid quoteAmount",
+		},
+		{
+			name: "unknown_notification_event",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"orderFilled": {"enabled": true}}}
+			}`,
+			expectedErr: `notifications.events: unknown event "orderFilled"`,
+		},
+		{
+			name: "unknown_notification_condition",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"preTrade": {"conditions": {"maxNotional": "5"}}}}
+			}`,
+			expectedErr: `notifications.events.preTrade: unsupported condition "maxNotional"`,
+		},
+		{
+			name: "condition_on_wrong_event",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"skip": {"conditions": {"minNotional": "5"}}}}
+			}`,
+			expectedErr: `notifications.events.skip: unsupported condition "minNotional"`,
+		},
+		{
+			name: "invalid_min_notional",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"preTrade": {"conditions": {"minNotional": "lots"}}}}
+			}`,
+			expectedErr: "invalid minNotional",
+		},
+		{
+			name: "delay_on_wrong_event",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"error": {"delay": "5s"}}}
+			}`,
+			expectedErr: "delay is only supported for preTrade",
+		},
+		{
+			name: "invalid_delay",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+				"notifications": {"events": {"preTrade": {"delay": "soon"}}}
+			}`,
+			expectedErr: `invalid delay "soon"`,
+		},
+		{
+			name: "unknown_fee_handling",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "feeHandling": "absorb"}
+			}`,
+			expectedErr: `feeHandling: unknown value "absorb"`,
+		},
+		{
+			name: "invalid_fee_rate_bps",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "feeHandling": "deduct", "feeRateBps": "-1"}
+			}`,
+			expectedErr: `feeRateBps: invalid value "-1"`,
+		},
+		{
+			name: "env_credentials_without_secret_variable",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "binance", "credentials": {"type": "env", "config": {"apiKeyEnv": "BINANCE_KEY"}}},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}
+			}`,
+			expectedErr: "binance env credentials: apiSecretEnv is required",
+		},
+		{
+			name: "env_credentials_without_okx_passphrase",
+			input: `{
+				"version": "v2",
+				"exchange": {"name": "okx", "credentials": {"type": "env", "config": {"apiKeyEnv": "OKX_KEY", "apiSecretEnv": "OKX_SECRET"}}},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}
+			}`,
+			expectedErr: "okx env credentials: passphraseEnv is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -243,6 +402,88 @@ func TestParseDCAPayload_Errors(t *testing.T) {
 	}
 }
 
+func TestParseDCAPayload_UnknownFields(t *testing.T) {
+	const exchange = `{"name": "binance", "credentials": {"type": "ssm", "config": {"apiKeyPath": "/b/key", "apiSecretPath": "/b/secret"}}}`
+	const strategy = `{"symbol": "BTC-USDT", "quoteAmount": "10"}`
+	payload := func(fields string) string {
+		return `{"version": "v2", ` + fields + `}`
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "top level",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": ` + strategy + `, "notification": {}`),
+			wantErr: "notification: unknown field (did you mean notifications?)",
+		},
+		{
+			name:    "strategy",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": {"symbol": "BTC-USDT", "quoteAmout": "10"}`),
+			wantErr: "strategy.quoteAmout: unknown field (did you mean quoteAmount?)",
+		},
+		{
+			name:    "different case",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": {"symbol": "BTC-USDT", "QuoteAmount": "10"}`),
+			wantErr: "strategy.QuoteAmount: unknown field (did you mean quoteAmount?)",
+		},
+		{
+			name:    "no close field",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": ` + strategy + `, "flags": {"turbo": true}`),
+			wantErr: "flags.turbo: unknown field",
+		},
+		{
+			name:    "threshold object",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "balanceThreshold": {"amount": "100", "currncy": "USD"}}`),
+			wantErr: "strategy.balanceThreshold.currncy: unknown field (did you mean currency?)",
+		},
+		{
+			name:    "exchange credentials",
+			input:   payload(`"exchange": {"name": "binance", "credentials": {"type": "ssm", "config": {"apikeyPath": "/b/key", "apiSecretPath": "/b/secret"}}}, "strategy": ` + strategy),
+			wantErr: "exchange.credentials.config.apikeyPath: unknown key of ssm credentials (did you mean apiKeyPath?)",
+		},
+		{
+			name:    "failover exchange",
+			input:   payload(`"exchange": [` + exchange + `, {"name": "okx", "credentals": {}}], "strategy": ` + strategy),
+			wantErr: "exchange.1.credentals: unknown field (did you mean credentials?)",
+		},
+		{
+			name:    "account credentials",
+			input:   payload(`"exchange": {"name": "binance", "accounts": [{"label": "main", "credentials": {"type": "env", "config": {"apiKeyEnv": "K", "apiSecretPath": "/b/secret"}}}]}, "strategy": ` + strategy),
+			wantErr: "exchange.accounts.0.credentials.config.apiSecretPath: unknown key of env credentials (want apiKeyEnv, apiSecretEnv, passphraseEnv)",
+		},
+		{
+			name:    "telegram config",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": ` + strategy + `, "notifications": {"telegram": {"type": "ssm", "config": {"botTokenPath": "/t/token", "chatID": "42"}}}`),
+			wantErr: "notifications.telegram.config.chatID: unknown key of ssm credentials (did you mean chatId?)",
+		},
+		{
+			name:    "telegram token of another type",
+			input:   payload(`"exchange": ` + exchange + `, "strategy": ` + strategy + `, "notifications": {"telegram": {"type": "env", "config": {"botTokenPath": "/t/token", "chatId": "42"}}}`),
+			wantErr: "notifications.telegram.config.botTokenPath: unknown key of env credentials (want botTokenEnv, chatId)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDCAPayload([]byte(tt.input))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseDCAPayload() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The legacy keys are left to Lint, and the flag accepts unknown fields
+	if _, err := ParseDCAPayload([]byte(payload(`"exchange": ` + exchange + `, "strategy": ` + strategy + `, "dca": {}`))); err != nil {
+		t.Errorf("ParseDCAPayload() with a legacy key error = %v", err)
+	}
+	lenient := payload(`"exchange": ` + exchange + `, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "note": "weekly"}, "flags": {"allowUnknownFields": true}`)
+	if _, err := ParseDCAPayload([]byte(lenient)); err != nil {
+		t.Errorf("ParseDCAPayload() with allowUnknownFields error = %v", err)
+	}
+}
+
 func TestDCAPayload_ToUnified(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -329,6 +570,42 @@ func TestDCAPayload_ToUnified(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "binance_env_to_unified",
+			payload: DCAPayload{
+				Version: "v2",
+				Exchange: ExchangeConfig{
+					Name: "binance",
+					Credentials: CredentialSource{
+						Type: "env",
+						Config: map[string]interface{}{
+							"apiKeyEnv":    "BINANCE_API_KEY",
+							"apiSecretEnv": "BINANCE_API_SECRET",
+						},
+					},
+				},
+				Strategy: DCAStrategy{
+					Symbol:      "BTC-USDT",
+					QuoteAmount: "10.00",
+					OrderType:   "market",
+				},
+			},
+			expected: Unified{
+				Exchange:         "binance",
+				Symbol:           "BTC-USDT",
+				QuoteAmount:      decimal.RequireFromString("10.00"),
+				BalanceThreshold: decimal.Zero,
+				Binance: &struct {
+					APIKeyPath, APISecretPath string
+				}{},
+				BinanceEnv: &struct {
+					APIKeyEnv, APISecretEnv string
+				}{
+					APIKeyEnv:    "BINANCE_API_KEY",
+					APISecretEnv: "BINANCE_API_SECRET",
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -365,6 +642,14 @@ func TestDCAPayload_ToUnified(t *testing.T) {
 				}
 			}
 
+			if tt.expected.BinanceEnv != nil {
+				if unified.BinanceEnv == nil {
+					t.Error("Expected BinanceEnv config, got nil")
+				} else if *unified.BinanceEnv != *tt.expected.BinanceEnv {
+					t.Errorf("BinanceEnv = %+v, want %+v", *unified.BinanceEnv, *tt.expected.BinanceEnv)
+				}
+			}
+
 			if tt.expected.OKXInline != nil {
 				if unified.OKXInline == nil {
 					t.Error("Expected OKXInline config, got nil")
@@ -423,7 +708,7 @@ func TestDefaultOrderType(t *testing.T) {
 		"version": "v2",
 		"exchange": {
 			"name": "binance",
-			"credentials": {"type": "ssm", "config": {}}
+			"credentials": {"type": "ssm", "config": {"apiKeyPath": "/b/key", "apiSecretPath": "/b/secret"}}
 		},
 		"strategy": {
 			"symbol": "BTC-USDT",
@@ -440,4 +725,1622 @@ func TestDefaultOrderType(t *testing.T) {
 	if payload.Strategy.OrderType != "market" {
 		t.Errorf("OrderType = %v, want market", payload.Strategy.OrderType)
 	}
-}
\ No newline at end of file
+}
+
+func TestParseDCAPayload_Normalizes(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "btc-usdt", "quoteAmount": "10", "balanceThreshold": "0", "orderType": "MARKET"}
+	}`
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Strategy.Symbol != "BTC-USDT" || payload.Strategy.OrderType != "market" {
+		t.Errorf("Symbol, OrderType = %q, %q, want BTC-USDT, market", payload.Strategy.Symbol, payload.Strategy.OrderType)
+	}
+}
+
+func TestEventBridgeDefaults(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+		"integrations": {"eventBridge": {"busName": "dca-events"}}
+	}`
+
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+
+	expected := EventBridgeConfig{BusName: "dca-events", Source: "dca-bot", DetailType: "DCA Execution"}
+	if eb := payload.Integrations.EventBridge; eb == nil || *eb != expected {
+		t.Errorf("EventBridge = %+v, want %+v", eb, expected)
+	}
+}
+
+func TestParseDCAPayload_Calendar(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		expectedErr string
+	}{
+		{
+			name:     "valid_calendar",
+			strategy: `"timezone": "Europe/Berlin", "calendar": {"skipWeekdays": ["SAT", "sun"], "skipDates": ["2025-12-31"]}`,
+		},
+		{
+			name:        "unknown_weekday",
+			strategy:    `"calendar": {"skipWeekdays": ["SATURDAY"]}`,
+			expectedErr: `unknown weekday "SATURDAY"`,
+		},
+		{
+			name:        "malformed_date",
+			strategy:    `"calendar": {"skipDates": ["31/12/2025"]}`,
+			expectedErr: `invalid date "31/12/2025"`,
+		},
+		{
+			name:        "impossible_date",
+			strategy:    `"calendar": {"skipDates": ["2025-02-30"]}`,
+			expectedErr: `invalid date "2025-02-30"`,
+		},
+		{
+			name:        "unknown_timezone",
+			strategy:    `"timezone": "Mars/Olympus_Mons"`,
+			expectedErr: `invalid timezone "Mars/Olympus_Mons"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{
+				"version": "v2",
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", ` + tt.strategy + `}
+			}`
+			_, err := ParseDCAPayload([]byte(input))
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("ParseDCAPayload() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ParseDCAPayload() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestParseDCAPayload_Validity(t *testing.T) {
+	tests := []struct {
+		name        string
+		fields      string
+		expectedErr string
+	}{
+		{name: "unset"},
+		{name: "both", fields: `"notBefore": "2025-05-01T00:00:00Z", "expiresAt": "2025-05-08T00:00:00+02:00",`},
+		{name: "expiry_only", fields: `"expiresAt": "2025-05-08T00:00:00Z",`},
+		{name: "date_only", fields: `"expiresAt": "2025-05-08",`, expectedErr: `expiresAt: invalid timestamp "2025-05-08"`},
+		{name: "not_a_time", fields: `"notBefore": "next week",`, expectedErr: `notBefore: invalid timestamp "next week"`},
+		{name: "empty_window", fields: `"notBefore": "2025-05-08T00:00:00Z", "expiresAt": "2025-05-08T00:00:00Z",`, expectedErr: "notBefore: must be before expiresAt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{
+				"version": "v2",` + tt.fields + `
+				"exchange": {"name": "binance"},
+				"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}
+			}`
+			payload, err := ParseDCAPayload([]byte(input))
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDCAPayload() error = %v", err)
+			}
+			if tt.fields == "" && (!payload.Expires().IsZero() || !payload.ValidFrom().IsZero()) {
+				t.Errorf("Expires() = %v, ValidFrom() = %v, want zero when unset", payload.Expires(), payload.ValidFrom())
+			}
+		})
+	}
+}
+
+func TestNotificationEvents(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+		"notifications": {"events": {
+			"preTrade": {"enabled": true, "conditions": {"minNotional": "100"}, "delay": "10s"},
+			"skip": {"enabled": false}
+		}}
+	}`
+
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+
+	if got := payload.Notifications.PreTradeDelay(); got != 10*time.Second {
+		t.Errorf("PreTradeDelay() = %v, want 10s", got)
+	}
+	min, ok := payload.Notifications.Events[NotifyPreTrade].MinNotional()
+	if !ok || min.String() != "100" {
+		t.Errorf("MinNotional() = %v, %v; want 100, true", min, ok)
+	}
+	if got := (NotificationConfig{}).PreTradeDelay(); got != 0 {
+		t.Errorf("default PreTradeDelay() = %v, want 0", got)
+	}
+}
+
+func TestParseDCAPayload_ExchangeArray(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"exchange": [
+			{"name": "binance", "credentials": {"type": "ssm", "config": {"apiKeyPath": "/b/key", "apiSecretPath": "/b/secret"}}},
+			{"name": "okx", "credentials": {"type": "env", "config": {"apiKeyEnv": "OKX_KEY", "apiSecretEnv": "OKX_SECRET", "passphraseEnv": "OKX_PASSPHRASE"}}}
+		],
+		"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}
+	}`
+
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Exchange.Name != "binance" || len(payload.Failover) != 1 || payload.Failover[0].Name != "okx" {
+		t.Fatalf("Exchange = %+v, Failover = %+v", payload.Exchange, payload.Failover)
+	}
+
+	venues := payload.Venues()
+	if len(venues) != 2 || venues[1].Credentials.Type != "env" {
+		t.Errorf("Venues() = %+v", venues)
+	}
+	single := payload.WithVenue(venues[1])
+	if single.Exchange.Name != "okx" || len(single.Failover) != 0 || payload.Exchange.Name != "binance" {
+		t.Errorf("WithVenue() = %+v, original %+v", single.Exchange, payload.Exchange)
+	}
+
+	// Marshalling keeps the array form and round-trips
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"exchange":[`) {
+		t.Errorf("expected exchange array in %s", encoded)
+	}
+	again, err := ParseDCAPayload(encoded)
+	if err != nil || again.Failover[0].Name != "okx" {
+		t.Errorf("round trip = %+v, %v", again, err)
+	}
+
+	// A single exchange still marshals as an object
+	encoded, _ = json.Marshal(single)
+	if !strings.Contains(string(encoded), `"exchange":{"name":"okx"`) {
+		t.Errorf("expected exchange object in %s", encoded)
+	}
+}
+
+func TestParseDCAPayload_ExchangeArrayErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		exchange    string
+		expectedErr string
+	}{
+		{"empty", `[]`, "exchange array must not be empty"},
+		{"fallback_without_name", `[{"name": "binance"}, {"credentials": {"type": "env"}}]`, "exchange[1]: exchange name is required"},
+		{"fallback_bad_credentials", `[{"name": "binance"}, {"name": "okx", "credentials": {"type": "vault"}}]`, "exchange[1] credentials"},
+		{"fallback_env_without_variables", `[{"name": "binance"}, {"name": "okx", "credentials": {"type": "env", "config": {}}}]`, "exchange[1]: okx env credentials: apiKeyEnv is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"version": "v2", "exchange": ` + tt.exchange + `, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}}`
+			_, err := ParseDCAPayload([]byte(input))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRetrySchedulerConfig(t *testing.T) {
+	parse := func(retryScheduler string) (*DCAPayload, error) {
+		input := `{
+			"version": "v2",
+			"exchange": {"name": "binance"},
+			"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+			"integrations": {"retryScheduler": ` + retryScheduler + `}
+		}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"roleArn": "arn:aws:iam::123456789012:role/dca-scheduler"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	rs := payload.Integrations.RetryScheduler
+	if rs.GroupName != "default" || rs.DelayMinutes != 10 || rs.Delay() != 10*time.Minute {
+		t.Errorf("RetryScheduler = %+v, want default group and 10 minute delay", rs)
+	}
+
+	for _, invalid := range []string{`{}`, `{"roleArn": "arn:role", "delayMinutes": -1}`, `{"roleArn": "arn:role", "delayMinutes": 1441}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "integrations.retryScheduler") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want integrations.retryScheduler error", invalid, err)
+		}
+	}
+}
+
+func TestAuditLogConfig(t *testing.T) {
+	parse := func(auditLog string) (*DCAPayload, error) {
+		input := `{
+			"version": "v2",
+			"exchange": {"name": "binance"},
+			"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+			"integrations": {"auditLog": ` + auditLog + `}
+		}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"bucket": "dca-audit"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	al := payload.Integrations.AuditLog
+	if al.Prefix != "audit/" || al.OnFailure != AuditLogFatal || !al.Fatal() || al.Async {
+		t.Errorf("AuditLog = %+v, want audit/ prefix, synchronous and fatal", al)
+	}
+
+	payload, err = parse(`{"bucket": "dca-audit", "prefix": "bot/orders", "async": true, "onFailure": "warn"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	al = payload.Integrations.AuditLog
+	if al.Prefix != "bot/orders/" || al.Fatal() || !al.Async {
+		t.Errorf("AuditLog = %+v, want bot/orders/ prefix, async and warn", al)
+	}
+
+	for _, invalid := range []string{`{}`, `{"bucket": "dca-audit", "onFailure": "ignore"}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "integrations.auditLog") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want integrations.auditLog error", invalid, err)
+		}
+	}
+}
+
+func TestMockFlags(t *testing.T) {
+	parse := func(mock string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": {"dryRun": true, "mock": ` + mock + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"latency": {"PlaceMarketBuyOrder": {"fixed": "8s"}, "default": {"min": "50ms", "max": "2s"}}, "failureRate": 0.1, "seed": 42}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	min, max, err := payload.Flags.Mock.Latency[MockDefaultMethod].Range()
+	if err != nil || min != 50*time.Millisecond || max != 2*time.Second {
+		t.Errorf("default Range() = %s, %s, %v; want 50ms, 2s", min, max, err)
+	}
+	if min, max, _ := payload.Flags.Mock.Latency["PlaceMarketBuyOrder"].Range(); min != 8*time.Second || max != min {
+		t.Errorf("fixed Range() = %s, %s; want 8s, 8s", min, max)
+	}
+
+	tests := []struct {
+		name        string
+		mock        string
+		expectedErr string
+	}{
+		{"bad_duration", `{"latency": {"GetBalance": {"fixed": "8"}}}`, "flags.mock.latency.GetBalance: fixed: invalid duration"},
+		{"fixed_and_range", `{"latency": {"GetBalance": {"fixed": "1s", "max": "2s"}}}`, "set either fixed or min and max"},
+		{"missing_max", `{"latency": {"GetBalance": {"min": "1s"}}}`, "max is required"},
+		{"reversed", `{"latency": {"GetBalance": {"min": "2s", "max": "1s"}}}`, "max 1s is below min 2s"},
+		{"failure_rate", `{"failureRate": 1.5}`, "flags.mock.failureRate"},
+		{"settlement", `{"settlement": ["open", "settled"]}`, `flags.mock.settlement[1]: unknown order status "settled"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.mock)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRouteBridges(t *testing.T) {
+	parse := func(strategy string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDC", "quoteAmount": "10"` + strategy + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`, "allowRouting": true`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if got := strings.Join(payload.Strategy.RouteBridges, ","); got != "USDT,BTC" {
+		t.Errorf("RouteBridges = %s, want default USDT,BTC", got)
+	}
+
+	payload, err = parse(`, "allowRouting": true, "routeBridges": [" fdusd", "ETH"]`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if got := strings.Join(payload.Strategy.RouteBridges, ","); got != "FDUSD,ETH" {
+		t.Errorf("RouteBridges = %s, want FDUSD,ETH", got)
+	}
+
+	if payload, _ := parse(``); payload.Strategy.RouteBridges != nil {
+		t.Errorf("RouteBridges = %v without allowRouting, want none", payload.Strategy.RouteBridges)
+	}
+	if _, err := parse(`, "allowRouting": true, "routeBridges": ["USDT", ""]`); err == nil || !strings.Contains(err.Error(), "routeBridges[1]") {
+		t.Errorf("ParseDCAPayload() error = %v, want routeBridges[1] error", err)
+	}
+}
+
+func TestParseDCAPayload_DustMode(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"mode": "dust",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "BTC-USDT"},
+		"dust": {"maxValue": "0.001", "exclude": ["ada"]}
+	}`
+
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Dust.Target != "BNB" || payload.Dust.Exclude[0] != "ADA" {
+		t.Errorf("Dust = %+v, want BNB target and upper-cased exclude", payload.Dust)
+	}
+	if got := strings.Join(payload.Dust.Protected(payload.Strategy.Symbol), ","); got != "ADA,BTC,USDT" {
+		t.Errorf("Protected() = %s, want ADA,BTC,USDT", got)
+	}
+
+	// Without a mode the payload is a regular DCA run
+	payload, err = ParseDCAPayload([]byte(`{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}}`))
+	if err != nil || payload.Mode != ModeDCA {
+		t.Errorf("Mode = %q, %v; want %q", payload.Mode, err, ModeDCA)
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{"unknown_mode", `{"version": "v2", "mode": "sweep", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}}`, `unknown mode "sweep"`},
+		{"bad_max_value", `{"version": "v2", "mode": "dust", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}, "dust": {"maxValue": "-1"}}`, "dust.maxValue"},
+		{"empty_include", `{"version": "v2", "mode": "dust", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}, "dust": {"include": [""]}}`, "dust.include[0]"},
+		{"dca_needs_amount", `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}}`, "quoteAmount is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDCAPayload([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestParseDCAPayload_ReportMode(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"mode": "report",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "BTC-USDT", "schedule": "0 8 * * 1", "budgetHistory": "s3://bucket/history.jsonl"}
+	}`
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Report == nil || payload.Report.History != "s3://bucket/history.jsonl" {
+		t.Errorf("Report = %+v, want the history to default to strategy.budgetHistory", payload.Report)
+	}
+	if payload.Origin("report.history") != OriginDefault {
+		t.Errorf("report.history origin = %s, want default", payload.Origin("report.history"))
+	}
+
+	// Neither a history nor a schedule is required
+	payload, err = ParseDCAPayload([]byte(`{"version": "v2", "mode": "report", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}}`))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Report == nil || payload.Report.History != "" {
+		t.Errorf("Report = %+v, want no history", payload.Report)
+	}
+
+	_, err = ParseDCAPayload([]byte(`{"version": "v2", "mode": "report", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "schedule": "every monday"}}`))
+	if err == nil || !strings.Contains(err.Error(), "strategy schedule") {
+		t.Errorf("ParseDCAPayload() error = %v, want a schedule error", err)
+	}
+}
+
+func TestParseDCAPayload_ReconcileMode(t *testing.T) {
+	input := `{
+		"version": "v2",
+		"mode": "reconcile",
+		"exchange": {"name": "binance"},
+		"strategy": {"symbol": "BTC-USDT"},
+		"reconcile": {"history": "s3://bucket/history.jsonl", "from": "2025-03-01", "to": "2025-03-31"},
+		"flags": {"importForeignTrades": true}
+	}`
+
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if !payload.Flags.ImportForeignTrades {
+		t.Error("ImportForeignTrades = false, want true")
+	}
+	from, to, err := payload.Reconcile.Range(time.Now())
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if want := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Errorf("from = %v, want %v", from, want)
+	}
+	if want := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC); !to.Equal(want) {
+		t.Errorf("to = %v, want %v (the day after the last day)", to, want)
+	}
+
+	// Without dates the last week up to today is reconciled
+	now := time.Date(2025, 3, 10, 15, 4, 5, 0, time.UTC)
+	from, to, err = (&ReconcileConfig{History: "history.jsonl"}).Range(now)
+	if err != nil {
+		t.Fatalf("Range() error = %v", err)
+	}
+	if want := time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC); !to.Equal(want) {
+		t.Errorf("default to = %v, want %v", to, want)
+	}
+	if want := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Errorf("default from = %v, want %v", from, want)
+	}
+
+	tests := []struct {
+		name        string
+		reconcile   string
+		expectedErr string
+	}{
+		{"missing", ``, "reconcile is required"},
+		{"no_history", `, "reconcile": {}`, "reconcile.history is required"},
+		{"bad_from", `, "reconcile": {"history": "h.jsonl", "from": "03/01/2025"}`, "reconcile.from: invalid date"},
+		{"bad_to", `, "reconcile": {"history": "h.jsonl", "to": "2025-3-1"}`, "reconcile.to: invalid date"},
+		{"reversed", `, "reconcile": {"history": "h.jsonl", "from": "2025-03-02", "to": "2025-03-01"}`, "from 2025-03-02 is after to 2025-03-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `{"version": "v2", "mode": "reconcile", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT"}` + tt.reconcile + `}`
+			_, err := ParseDCAPayload([]byte(input))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("ParseDCAPayload() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestStopLossConfig(t *testing.T) {
+	parse := func(stopLoss string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "stopLoss": ` + stopLoss + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"percentBelowFill": "5"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Strategy.StopLoss.LimitOffsetPercent != "0.5" || payload.Flags.AllowProtectiveOrders {
+		t.Errorf("StopLoss = %+v, AllowProtectiveOrders = %v", payload.Strategy.StopLoss, payload.Flags.AllowProtectiveOrders)
+	}
+
+	for _, invalid := range []string{`{}`, `{"percentBelowFill": "0"}`, `{"percentBelowFill": "100"}`, `{"percentBelowFill": "5", "limitOffsetPercent": "-1"}`, `{"percentBelowFill": "60", "limitOffsetPercent": "40"}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "strategy stopLoss.") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want strategy stopLoss error", invalid, err)
+		}
+	}
+}
+
+func TestCancelUnfilledAfter(t *testing.T) {
+	parse := func(seconds string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "cancelUnfilledAfterSeconds": ` + seconds + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse("30")
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if got := payload.Strategy.CancelUnfilledAfter(); got != 30*time.Second {
+		t.Errorf("CancelUnfilledAfter() = %s, want 30s", got)
+	}
+	if _, err := parse("-1"); err == nil || !strings.Contains(err.Error(), "cancelUnfilledAfterSeconds: must not be negative") {
+		t.Errorf("ParseDCAPayload(-1) error = %v, want a negative value error", err)
+	}
+}
+
+func TestCircuitBreakerConfig(t *testing.T) {
+	parse := func(circuitBreaker string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "circuitBreaker": ` + circuitBreaker + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"maxMovePercent": "7.5", "windowMinutes": 60}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	cb := payload.Strategy.CircuitBreaker
+	if !cb.MaxMove().Decimal().Equal(decimal.RequireFromString("7.5")) || cb.Window() != time.Hour {
+		t.Errorf("CircuitBreaker = %+v", cb)
+	}
+
+	for _, tt := range []struct{ circuitBreaker, want string }{
+		{`{"windowMinutes": 60}`, "strategy circuitBreaker.maxMovePercent: invalid value"},
+		{`{"maxMovePercent": "0", "windowMinutes": 60}`, "strategy circuitBreaker.maxMovePercent"},
+		{`{"maxMovePercent": "10"}`, "strategy circuitBreaker.windowMinutes: must be between 1 and 720, got 0"},
+		{`{"maxMovePercent": "10", "windowMinutes": 1440}`, "strategy circuitBreaker.windowMinutes"},
+	} {
+		if _, err := parse(tt.circuitBreaker); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.circuitBreaker, err, tt.want)
+		}
+	}
+}
+
+func TestDipMultipliersConfig(t *testing.T) {
+	parse := func(dipMultipliers string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "dipMultipliers": ` + dipMultipliers + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"tiers": [{"drawdownPercent": "10", "multiplier": "1.5"}, {"drawdownPercent": "20", "multiplier": "2"}], "maxQuoteAmount": "25"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if dm := payload.Strategy.DipMultipliers; dm.LookbackDays != DipDefaultLookbackDays || payload.Origin("strategy.dipMultipliers.lookbackDays") != OriginDefault {
+		t.Errorf("DipMultipliers = %+v, want the default lookback", dm)
+	}
+
+	for _, tt := range []struct{ dipMultipliers, want string }{
+		{`{}`, "strategy dipMultipliers.tiers: at least one tier is required"},
+		{`{"tiers": [{"drawdownPercent": "0", "multiplier": "2"}]}`, "strategy dipMultipliers.tiers.0.drawdownPercent: invalid percentage"},
+		{`{"tiers": [{"drawdownPercent": "100", "multiplier": "2"}]}`, "strategy dipMultipliers.tiers.0.drawdownPercent"},
+		{`{"tiers": [{"drawdownPercent": "10", "multiplier": "2"}, {"drawdownPercent": "10.0", "multiplier": "3"}]}`, "strategy dipMultipliers.tiers.1.drawdownPercent: 10% is listed twice"},
+		{`{"tiers": [{"drawdownPercent": "10"}]}`, "strategy dipMultipliers.tiers.0.multiplier: invalid value"},
+		{`{"tiers": [{"drawdownPercent": "10", "multiplier": "11"}]}`, "strategy dipMultipliers.tiers.0.multiplier"},
+		{`{"tiers": [{"drawdownPercent": "10", "multiplier": "2"}], "lookbackDays": 400}`, "strategy dipMultipliers.lookbackDays: must be between 1 and 365"},
+		{`{"tiers": [{"drawdownPercent": "10", "multiplier": "2"}], "referencePrice": "-1"}`, "strategy dipMultipliers.referencePrice: invalid value"},
+		{`{"tiers": [{"drawdownPercent": "10", "multiplier": "2"}], "maxQuoteAmount": "0"}`, "strategy dipMultipliers.maxQuoteAmount: invalid amount"},
+	} {
+		if _, err := parse(tt.dipMultipliers); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.dipMultipliers, err, tt.want)
+		}
+	}
+
+	// The multiplier would undo the percentage's cap and the ramp-up step
+	const tiers = `"dipMultipliers": {"tiers": [{"drawdownPercent": "10", "multiplier": "3"}]}`
+	for _, tt := range []struct{ input, want string }{
+		{`"strategy": {"symbol": "BTC-USDT", "quoteAmountPercent": "50", ` + tiers + `}`, "strategy dipMultipliers: not supported with quoteAmountPercent"},
+		{`"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", ` + tiers + `}, "state": {"status": {"dir": ".dca-state"}}, "flags": {"rampUp": {"runs": 3, "startPercent": "40"}}`, "strategy dipMultipliers: not supported with flags.rampUp"},
+	} {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, ` + tt.input + `}`
+		if _, err := ParseDCAPayload([]byte(input)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+func TestHoldingsAlertConfig(t *testing.T) {
+	parse := func(holdingsAlert string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "holdingsAlert": ` + holdingsAlert + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"threshold": "0.5"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if h := payload.Strategy.HoldingsAlert; h.Asset != "" || !h.Amount().Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("HoldingsAlert = %+v", h)
+	}
+
+	for _, tt := range []struct{ holdingsAlert, want string }{
+		{`{}`, "strategy holdingsAlert.threshold: invalid value"},
+		{`{"threshold": "-1"}`, "strategy holdingsAlert.threshold"},
+		{`{"asset": "BTC-USDT", "threshold": "1"}`, "strategy holdingsAlert.asset: invalid value"},
+	} {
+		if _, err := parse(tt.holdingsAlert); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.holdingsAlert, err, tt.want)
+		}
+	}
+}
+
+func TestWithdrawalConfig(t *testing.T) {
+	parse := func(withdrawal string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "withdrawal": ` + withdrawal + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	for _, valid := range []string{
+		`{"network": "BTC", "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}`,
+		`{"network": "erc20", "address": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"}`,
+	} {
+		if _, err := parse(valid); err != nil {
+			t.Errorf("ParseDCAPayload(%s) error = %v", valid, err)
+		}
+	}
+
+	tests := []struct {
+		withdrawal string
+		want       string
+	}{
+		{`{"address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}`, "strategy withdrawal.network: required"},
+		{`{"network": "SOL", "address": "x"}`, "strategy withdrawal.network: unsupported network"},
+		{`{"network": "BTC", "address": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"}`, "strategy withdrawal.address: invalid BTC address"},
+		{`{"network": "ETH", "address": "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`, "no EIP-55 checksum"},
+		{`{"network": "ETH"}`, "strategy withdrawal.address: address is required"},
+	}
+	for _, tt := range tests {
+		if _, err := parse(tt.withdrawal); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.withdrawal, err, tt.want)
+		}
+	}
+}
+
+func TestBalanceThresholdObject(t *testing.T) {
+	parse := func(threshold string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-EUR", "quoteAmount": "10", "balanceThreshold": ` + threshold + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"amount": "200", "currency": "usd"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	want := Threshold{Amount: "200", Currency: "USD", PegTolerance: ThresholdDefaultPegTolerance}
+	if got := payload.Strategy.Threshold(); got != want {
+		t.Errorf("Threshold() = %+v, want %+v", got, want)
+	}
+
+	// The object form survives a round trip; a plain amount stays a string
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"balanceThreshold":{"amount":"200","currency":"USD"}`) {
+		t.Errorf("Marshal() = %s, want object balanceThreshold", data)
+	}
+	payload, err = parse(`"5000"`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if got := payload.Strategy.Threshold(); got != (Threshold{Amount: "5000"}) {
+		t.Errorf("Threshold() = %+v, want plain amount", got)
+	}
+	if data, _ := json.Marshal(payload); !strings.Contains(string(data), `"balanceThreshold":"5000"`) {
+		t.Errorf("Marshal() = %s, want string balanceThreshold", data)
+	}
+
+	for _, invalid := range []string{`{"currency": "USD"}`, `{"amount": "200", "currency": "EUR"}`, `{"amount": "200", "pegTolerance": "0.01"}`, `{"amount": "200", "currency": "USD", "pegTolerance": "1"}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "balanceThreshold") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want balanceThreshold error", invalid, err)
+		}
+	}
+}
+
+func TestStrategySide(t *testing.T) {
+	parse := func(strategy string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "50"` + strategy + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(``)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Strategy.Side != SideBuy || payload.Strategy.Selling() {
+		t.Errorf("Side = %q, want default buy", payload.Strategy.Side)
+	}
+
+	payload, err = parse(`, "side": "sell", "minPrice": "60000"`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if !payload.Strategy.Selling() || payload.Strategy.MinPrice != "60000" {
+		t.Errorf("Strategy = %+v, want selling with a 60000 floor", payload.Strategy)
+	}
+
+	payload, err = parse(`, "maxPrice": "65000.50"`)
+	if err != nil || payload.Strategy.MaxPrice != "65000.50" {
+		t.Errorf("ParseDCAPayload() = %+v, %v, want a buy with a 65000.50 ceiling", payload, err)
+	}
+
+	for _, invalid := range []string{
+		`, "side": "short"`,
+		`, "minPrice": "60000"`,
+		`, "maxPrice": "0"`,
+		`, "maxPrice": "65k"`,
+		`, "maxPrice": "65000", "allowRouting": true`,
+		`, "side": "sell", "maxPrice": "65000"`,
+		`, "side": "sell", "minPrice": "-1"`,
+		`, "side": "sell", "feeHandling": "deduct"`,
+		`, "side": "sell", "stopLoss": {"percentBelowFill": "5"}`,
+		`, "side": "sell", "allowRouting": true`,
+		`, "side": "sell", "checkPendingDeposits": true`,
+		`, "side": "sell", "circuitBreaker": {"maxMovePercent": "10", "windowMinutes": 60}`,
+		`, "side": "sell", "dipMultipliers": {"tiers": [{"drawdownPercent": "10", "multiplier": "2"}]}`,
+	} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "strategy ") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want strategy error", invalid, err)
+		}
+	}
+}
+
+func TestNotificationDigest(t *testing.T) {
+	parse := func(notifications string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "notifications": ` + notifications + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"digest": true, "digestExcludes": ["error"]}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	n := payload.Notifications
+	if !n.Digested(NotifyPostTrade) || n.Digested(NotifyError) || n.Digested(NotifyPreTrade) {
+		t.Errorf("Digested() wrong for %+v", n)
+	}
+
+	for _, invalid := range []string{`{"digest": true, "digestExcludes": ["fills"]}`, `{"digestExcludes": ["error"]}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "notifications.digestExcludes") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want digestExcludes error", invalid, err)
+		}
+	}
+}
+
+func TestNotificationDisplay(t *testing.T) {
+	parse := func(notifications string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "notifications": ` + notifications + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"language": "de", "displayPrecision": {"ETH": 4, "JPY": 0}}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	n := payload.Notifications
+	if n.Language != "de" || n.DisplayPrecision["ETH"] != 4 || n.DisplayPrecision["JPY"] != 0 {
+		t.Errorf("notifications = %+v", n)
+	}
+
+	for _, tt := range []struct {
+		notifications string
+		want          string
+	}{
+		{`{"language": "klingon"}`, "notifications.language: unsupported language"},
+		{`{"displayPrecision": {"BTC": 19}}`, "notifications.displayPrecision.BTC: must be between 0 and 18"},
+		{`{"displayPrecision": {"ETH": -1}}`, "notifications.displayPrecision.ETH"},
+		{`{"displayPrecision": {" ": 2}}`, "notifications.displayPrecision: asset code is required"},
+	} {
+		if _, err := parse(tt.notifications); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.notifications, err, tt.want)
+		}
+	}
+}
+
+func TestNotificationWebhook(t *testing.T) {
+	parse := func(webhook string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "notifications": {"webhook": ` + webhook + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"url": "https://hooks.example.com/dca", "secrets": [
+		{"keyId": "2026-10", "type": "ssm", "config": {"hmacSecretPath": "/dca/webhook/2026-10"}},
+		{"keyId": "2026-04", "type": "env", "config": {"hmacSecretEnv": "WEBHOOK_SECRET_OLD"}}]}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	secrets := payload.Notifications.Webhook.Secrets
+	if len(secrets) != 2 || secrets[0].KeyID != "2026-10" || secrets[0].Type != "ssm" || secrets[1].Config["hmacSecretEnv"] != "WEBHOOK_SECRET_OLD" {
+		t.Errorf("secrets = %+v", secrets)
+	}
+
+	for _, tt := range []struct{ webhook, want string }{
+		{`{"url": "hooks.example.com", "secrets": [{"keyId": "k1", "type": "env"}]}`, "notifications.webhook.url: invalid URL"},
+		{`{"url": "https://hooks.example.com", "secrets": []}`, "notifications.webhook.secrets: at least one secret is required"},
+		{`{"url": "https://hooks.example.com", "secrets": [{"type": "env"}]}`, "notifications.webhook.secrets[0].keyId: required"},
+		{`{"url": "https://hooks.example.com", "secrets": [{"keyId": "a,b", "type": "env"}]}`, "must not contain spaces, commas or '='"},
+		{`{"url": "https://hooks.example.com", "secrets": [{"keyId": "k1", "type": "env"}, {"keyId": "k1", "type": "ssm"}]}`, "notifications.webhook.secrets[1].keyId: duplicate key ID \"k1\""},
+		{`{"url": "https://hooks.example.com", "secrets": [{"keyId": "k1", "type": "vault"}]}`, "notifications.webhook.secrets[0]: "},
+	} {
+		if _, err := parse(tt.webhook); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.webhook, err, tt.want)
+		}
+	}
+}
+
+func TestStrategyNotifications(t *testing.T) {
+	parse := func(override string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "notifications": {"telegram": {"type": "env"}, "events": {"skip": {"enabled": false}, "postTrade": {"conditions": {"minNotional": "100"}}}},
+			"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "notifications": ` + override + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"telegram": {"type": "inline", "config": {"chatId": "42"}}, "channels": "append", "events": {"skip": {"enabled": true}}}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	override := *payload.Strategy.Notifications
+	if !override.AppendsChannels() {
+		t.Error("AppendsChannels() = false for channels append")
+	}
+	merged := payload.Notifications.Merge(override)
+	if merged.Telegram.Type != "inline" || payload.Notifications.Telegram.Type != "env" {
+		t.Errorf("merged telegram = %+v, want the override's without changing the global one", merged.Telegram)
+	}
+	if rule := merged.Events[NotifySkip]; rule.Enabled == nil || !*rule.Enabled {
+		t.Errorf("merged skip rule = %+v, want the override's", rule)
+	}
+	if _, ok := merged.Events[NotifyPostTrade].MinNotional(); !ok {
+		t.Error("merged config lost the global postTrade rule")
+	}
+	if _, ok := payload.Notifications.Events[NotifySkip]; !ok || *payload.Notifications.Events[NotifySkip].Enabled {
+		t.Error("Merge() modified the global events")
+	}
+
+	for _, tt := range []struct{ override, wantErr string }{
+		{`{"digest": true}`, "strategy notifications.digest"},
+		{`{"language": "de"}`, "strategy notifications.language and displayPrecision"},
+		{`{"projectRunway": true}`, "strategy notifications.projectRunway"},
+		{`{"webhook": {"url": "https://hooks.example.com", "secrets": [{"keyId": "k1", "type": "env"}]}}`, "strategy notifications.webhook: only valid in the top-level notifications"},
+		{`{"telegram": {"type": "inline"}, "channels": "both"}`, "strategy notifications.channels: unsupported value"},
+		{`{"channels": "append"}`, "strategy notifications.channels: requires a channel"},
+		{`{"events": {"fills": {}}}`, "strategy notifications.events: unknown event"},
+		{`{"telegram": {"type": "vault"}}`, "strategy notifications.telegram"},
+	} {
+		if _, err := parse(tt.override); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("override %s: error = %v, want %q", tt.override, err, tt.wantErr)
+		}
+	}
+
+	global := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "notifications": {"channels": "append"}}`
+	if _, err := ParseDCAPayload([]byte(global)); err == nil || !strings.Contains(err.Error(), "notifications.channels") {
+		t.Errorf("top-level channels: error = %v, want it rejected", err)
+	}
+
+	unscheduled := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "notifications": {"projectRunway": true}}`
+	if _, err := ParseDCAPayload([]byte(unscheduled)); err == nil || !strings.Contains(err.Error(), "notifications.projectRunway: requires strategy.schedule") {
+		t.Errorf("projectRunway without a schedule: error = %v, want it rejected", err)
+	}
+}
+
+func TestMonthlyBudget(t *testing.T) {
+	parse := func(strategy string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", ` + strategy + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`"monthlyBudget": "300", "schedule": "0 8 * * 1", "maxQuoteAmount": "100", "budgetHistory": "s3://bucket/history.jsonl"`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if !payload.Strategy.Budgeted() || payload.Strategy.QuoteAmount != "" {
+		t.Errorf("strategy = %+v, want a budgeted strategy without quoteAmount", payload.Strategy)
+	}
+
+	for _, tt := range []struct{ strategy, wantErr string }{
+		{`"quoteAmount": "10", "monthlyBudget": "300", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl"`, "strategy monthlyBudget: set either"},
+		{`"monthlyBudget": "-5", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl"`, "strategy monthlyBudget: invalid amount"},
+		{`"monthlyBudget": "300", "budgetHistory": "h.jsonl"`, "strategy schedule: required"},
+		{`"monthlyBudget": "300", "schedule": "daily", "budgetHistory": "h.jsonl"`, "strategy schedule: invalid cron expression"},
+		{`"monthlyBudget": "300", "schedule": "0 8 * * *"`, "strategy budgetHistory: required"},
+		{`"monthlyBudget": "300", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl", "maxQuoteAmount": "0"`, "strategy maxQuoteAmount: invalid amount"},
+		{`"quoteAmount": "10", "maxQuoteAmount": "50"`, "strategy monthlyBudget: required"},
+		{`"monthlyBudget": "300", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl", "side": "sell"`, "strategy monthlyBudget: not supported when selling"},
+		{`"monthlyBudget": "300", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl", "dipMultipliers": {"tiers": [{"drawdownPercent": "10", "multiplier": "3"}]}`, "strategy dipMultipliers: not supported with monthlyBudget"},
+	} {
+		if _, err := parse(tt.strategy); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("strategy {%s}: error = %v, want %q", tt.strategy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestQuoteAmountPercent(t *testing.T) {
+	parse := func(strategy string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", ` + strategy + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`"quoteAmountPercent": "5", "maxQuoteAmount": "100"`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if !payload.Strategy.PercentSized() || payload.Strategy.QuoteAmount != "" {
+		t.Errorf("strategy = %+v, want a percent-sized strategy without quoteAmount", payload.Strategy)
+	}
+	unified, err := payload.ToUnified()
+	if err != nil || !unified.QuoteAmount.IsZero() {
+		t.Errorf("ToUnified() = %s, %v, want a zero amount until the balance is read", unified.QuoteAmount, err)
+	}
+
+	for _, tt := range []struct{ strategy, wantErr string }{
+		{`"quoteAmount": "10", "quoteAmountPercent": "5"`, "strategy quoteAmountPercent: set only one"},
+		{`"monthlyBudget": "300", "schedule": "0 8 * * *", "budgetHistory": "h.jsonl", "quoteAmountPercent": "5"`, "strategy quoteAmountPercent: set only one"},
+		{`"quoteAmountPercent": "0"`, "strategy quoteAmountPercent: invalid percentage"},
+		{`"quoteAmountPercent": "100.5"`, "strategy quoteAmountPercent: invalid percentage"},
+		{`"quoteAmountPercent": "half"`, "strategy quoteAmountPercent: invalid percentage"},
+		{`"quoteAmountPercent": "5", "maxQuoteAmount": "-1"`, "strategy maxQuoteAmount: invalid amount"},
+		{`"quoteAmountPercent": "5", "budgetHistory": "h.jsonl"`, "strategy monthlyBudget: required"},
+		{`"quoteAmountPercent": "5", "side": "sell"`, "strategy quoteAmountPercent: not supported when selling"},
+	} {
+		if _, err := parse(tt.strategy); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("strategy {%s}: error = %v, want %q", tt.strategy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestExecutionWindow(t *testing.T) {
+	parse := func(strategy string) error {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", ` + strategy + `}}`
+		_, err := ParseDCAPayload([]byte(input))
+		return err
+	}
+
+	for _, valid := range []string{
+		`"executionWindow": {"after": "08:30", "before": "10:00"}`,
+		`"executionWindow": {"after": "22:00", "before": "02:00"}`,
+		`"executionWindow": {"before": "06:00"}`,
+		`"schedule": "0 9 * * 1", "executionWindow": {"maxDelayMinutes": 30}`,
+	} {
+		if err := parse(valid); err != nil {
+			t.Errorf("strategy {%s}: error = %v", valid, err)
+		}
+	}
+
+	for _, tt := range []struct{ strategy, wantErr string }{
+		{`"executionWindow": {}`, "strategy executionWindow: set after, before or maxDelayMinutes"},
+		{`"schedule": "0 9 * * 1", "executionWindow": {"after": "08:00", "maxDelayMinutes": 30}`, "strategy executionWindow: set either"},
+		{`"executionWindow": {"maxDelayMinutes": 30}`, "strategy executionWindow.maxDelayMinutes: requires schedule"},
+		{`"executionWindow": {"maxDelayMinutes": -5}`, "must not be negative"},
+		{`"executionWindow": {"after": "9am"}`, "strategy executionWindow.after: invalid time of day"},
+		{`"executionWindow": {"before": "24:00"}`, "strategy executionWindow.before: invalid time of day"},
+		{`"executionWindow": {"after": "09:00", "before": "09:00"}`, "leaving no time to run"},
+	} {
+		if err := parse(tt.strategy); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("strategy {%s}: error = %v, want %q", tt.strategy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHeartbeatConfig(t *testing.T) {
+	parse := func(heartbeat string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "integrations": {"heartbeat": ` + heartbeat + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	for _, valid := range []string{
+		`{"url": {"type": "inline", "config": {"url": "https://hc-ping.com/0b1e2c3d"}}}`,
+		`{"url": {"type": "env", "config": {"urlEnv": "HEARTBEAT_URL"}}}`,
+		`{"url": {"type": "ssm", "config": {"urlPath": "/dca/heartbeat"}}}`,
+	} {
+		if _, err := parse(valid); err != nil {
+			t.Errorf("ParseDCAPayload(%s) error = %v", valid, err)
+		}
+	}
+
+	for _, invalid := range []string{
+		`{"url": {"type": "vault"}}`,
+		`{"url": {"type": "inline", "config": {"url": "hc-ping.com/0b1e2c3d"}}}`,
+		`{"url": {"type": "inline", "config": {}}}`,
+	} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "integrations.heartbeat.url") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want a heartbeat url error", invalid, err)
+		}
+	}
+}
+
+func TestRampUpConfig(t *testing.T) {
+	parse := func(extra string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}` + extra + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	if _, err := parse(`, "state": {"status": {"dir": ".dca-state"}}, "flags": {"rampUp": {"runs": 3, "startPercent": "40"}}`); err != nil {
+		t.Errorf("ParseDCAPayload() error = %v", err)
+	}
+	for _, tt := range []struct{ extra, wantErr string }{
+		{`, "flags": {"rampUp": {"runs": 3, "startPercent": "40"}}`, "flags.rampUp: requires state.status"},
+		{`, "state": {"status": {"dir": "s"}}, "flags": {"rampUp": {"runs": 0, "startPercent": "40"}}`, "flags.rampUp.runs: must be between"},
+		{`, "state": {"status": {"dir": "s"}}, "flags": {"rampUp": {"runs": 3, "startPercent": "100"}}`, "flags.rampUp.startPercent: invalid percentage"},
+		{`, "state": {"status": {"dir": "s"}}, "flags": {"rampUp": {"runs": 3}}`, "flags.rampUp.startPercent: invalid percentage"},
+	} {
+		if _, err := parse(tt.extra); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("payload {%s}: error = %v, want %q", tt.extra, err, tt.wantErr)
+		}
+	}
+}
+
+func TestStrategyHash(t *testing.T) {
+	hash := func(strategy string) string {
+		t.Helper()
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", ` + strategy + `}}`
+		payload, err := ParseDCAPayload([]byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := payload.StrategyHash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	base := hash(`"quoteAmount": "10"`)
+	if got := hash(`"quoteAmount": "10", "orderType": "market", "side": "buy"`); got != base {
+		t.Errorf("spelling out defaults changed the hash: %s != %s", got, base)
+	}
+	if got := hash(`"quoteAmount": "10", "notifications": {"events": {"skip": {"enabled": false}}}`); got != base {
+		t.Errorf("a notifications override changed the hash: %s != %s", got, base)
+	}
+	if got := hash(`"quoteAmount": "20"`); got == base {
+		t.Error("a new quoteAmount kept the hash")
+	}
+	if len(base) != 16 {
+		t.Errorf("hash %q, want 16 hex digits", base)
+	}
+
+	input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "monthlyBudget": "300", "schedule": "0 9 * * 1", "budgetHistory": "h.jsonl"}}`
+	payload, err := ParseDCAPayload([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := payload.StrategyHash()
+	payload.Strategy.QuoteAmount = "69.23"
+	payload.SetOrigin("strategy.quoteAmount", OriginDerived)
+	if after, _ := payload.StrategyHash(); after != before {
+		t.Errorf("a derived quoteAmount changed the hash: %s != %s", after, before)
+	}
+}
+
+func TestEngine(t *testing.T) {
+	parse := func(exchange, strategy string) error {
+		input := `{"version": "v2", "exchange": {"name": "` + exchange + `"}, "strategy": {"symbol": "BTC-USDT", ` + strategy + `}}`
+		_, err := ParseDCAPayload([]byte(input))
+		return err
+	}
+
+	for _, strategy := range []string{
+		`"quoteAmount": "10"`,
+		`"quoteAmount": "10", "engine": "spot"`,
+		`"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1"`,
+	} {
+		if err := parse("binance", strategy); err != nil {
+			t.Errorf("strategy {%s}: error = %v", strategy, err)
+		}
+	}
+	for _, tt := range []struct{ exchange, strategy, wantErr string }{
+		{"binance", `"quoteAmount": "10", "engine": "grid"`, "strategy engine: unknown value"},
+		{"okx", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1"`, "strategy engine: okx has no native recurring-buy engine (supported on binance)"},
+		{"binance", `"quoteAmount": "10", "engine": "native"`, "strategy schedule: required for the native engine"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "30 9 * * 1"`, "has no native equivalent"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1-5"`, "has no native equivalent"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 31 * *"`, "a monthly plan runs on day 1 to 28"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1", "side": "sell"`, "the native engine only buys"},
+		{"binance", `"quoteAmountPercent": "5", "engine": "native", "schedule": "0 9 * * 1"`, "needs a fixed quoteAmount"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1", "dipMultipliers": {"tiers": [{"drawdownPercent": "10", "multiplier": "2"}]}`, "needs a fixed quoteAmount"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1", "allowRouting": true`, "need the spot engine"},
+		{"binance", `"quoteAmount": "10", "engine": "native", "schedule": "0 9 * * 1", "maxPrice": "65000"`, "need the spot engine"},
+	} {
+		if err := parse(tt.exchange, tt.strategy); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s strategy {%s}: error = %v, want %q", tt.exchange, tt.strategy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNativeCadence(t *testing.T) {
+	winter := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+	summer := time.Date(2026, time.July, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		schedule, timezone string
+		at                 time.Time
+		want               Cadence
+		wantErr            string
+	}{
+		{schedule: "0 9 * * *", at: winter, want: Cadence{Cycle: CycleDaily, Hour: 9}},
+		{schedule: "0 9 * * 1", at: winter, want: Cadence{Cycle: CycleWeekly, Weekday: time.Monday, Hour: 9}},
+		{schedule: "0 9 * * 7", at: winter, want: Cadence{Cycle: CycleWeekly, Weekday: time.Sunday, Hour: 9}},
+		{schedule: "0 9 15 * *", at: winter, want: Cadence{Cycle: CycleMonthly, Day: 15, Hour: 9}},
+		{schedule: "0 9 * * *", timezone: "Europe/Berlin", at: winter, want: Cadence{Cycle: CycleDaily, Hour: 8}},
+		{schedule: "0 9 * * *", timezone: "Europe/Berlin", at: summer, want: Cadence{Cycle: CycleDaily, Hour: 7}},
+		{schedule: "0 0 * * 1", timezone: "Europe/Berlin", at: winter, want: Cadence{Cycle: CycleWeekly, Weekday: time.Sunday, Hour: 23}},
+		{schedule: "0 20 * * 6", timezone: "America/New_York", at: winter, want: Cadence{Cycle: CycleWeekly, Weekday: time.Sunday, Hour: 1}},
+		{schedule: "0 5 10 * *", timezone: "Asia/Tokyo", at: winter, want: Cadence{Cycle: CycleMonthly, Day: 9, Hour: 20}},
+		{schedule: "0 0 1 * *", timezone: "Europe/Berlin", at: winter, wantErr: "falls on another day of the month in UTC"},
+		{schedule: "0 22 28 * *", timezone: "America/New_York", at: winter, wantErr: "falls on another day of the month in UTC"},
+		{schedule: "0 9 1 * 1", at: winter, wantErr: "has no native equivalent"},
+		{schedule: "0 9 * 1 *", at: winter, wantErr: "has no native equivalent"},
+		{schedule: "0 */4 * * *", at: winter, wantErr: "has no native equivalent"},
+	}
+	for _, tt := range tests {
+		s := DCAStrategy{Schedule: tt.schedule, Timezone: tt.timezone}
+		got, err := s.NativeCadence(tt.at)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NativeCadence(%q, %s) error = %v, want %q", tt.schedule, tt.timezone, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NativeCadence(%q, %s) = %+v, %v, want %+v", tt.schedule, tt.timezone, got, err, tt.want)
+		}
+	}
+
+	if got := (Cadence{Cycle: CycleWeekly, Weekday: time.Sunday, Hour: 23}).String(); got != "weekly on Sunday at 23:00 UTC" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestSimulateFailure(t *testing.T) {
+	parse := func(flags string) error {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": ` + flags + `}`
+		_, err := ParseDCAPayload([]byte(input))
+		return err
+	}
+
+	t.Setenv(AllowSimulatedFailuresEnv, "")
+	for _, stage := range []string{SimulateCredentials, SimulateOrder, SimulateNotification} {
+		if err := parse(`{"dryRun": true, "simulateFailure": "` + stage + `"}`); err != nil {
+			t.Errorf("dry run simulating %s: error = %v", stage, err)
+		}
+	}
+	if err := parse(`{"dryRun": true, "simulateFailure": "balance"}`); err == nil || !strings.Contains(err.Error(), "flags.simulateFailure") {
+		t.Errorf("unknown stage: error = %v, want a flags.simulateFailure error", err)
+	}
+	if err := parse(`{"dryRun": false, "simulateFailure": "order"}`); err == nil || !strings.Contains(err.Error(), AllowSimulatedFailuresEnv) {
+		t.Errorf("live run: error = %v, want a refusal naming %s", err, AllowSimulatedFailuresEnv)
+	}
+
+	t.Setenv(AllowSimulatedFailuresEnv, "true")
+	if err := parse(`{"dryRun": false, "simulateFailure": "order"}`); err != nil {
+		t.Errorf("live run with %s=true: error = %v", AllowSimulatedFailuresEnv, err)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	parse := func(flags string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": ` + flags + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"idempotencyKey": "2026-10-16T08:00:00Z"}`)
+	if err != nil || payload.Flags.IdempotencyKey != "2026-10-16T08:00:00Z" {
+		t.Fatalf("ParseDCAPayload() = %+v, %v, want the key", payload, err)
+	}
+	for _, key := range []string{`"  "`, `"` + strings.Repeat("k", IdempotencyKeyMaxLength+1) + `"`, `"nightly-{symbol}"`} {
+		if _, err := parse(`{"idempotencyKey": ` + key + `}`); err == nil || !strings.Contains(err.Error(), "flags.idempotencyKey") {
+			t.Errorf("key %.10s…: error = %v, want a flags.idempotencyKey error", key, err)
+		}
+	}
+
+	at := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	for _, tt := range []struct{ key, want string }{
+		{"", ""},
+		{"nightly", "nightly#2026-10-16"},
+		{"2026-10-16T08:00:00Z", "2026-10-16T08:00:00Z#2026-10-16"},
+		{"dca-{hour}", "dca-2026-10-16T08"},
+		{"dca-{month}", "dca-2026-10"},
+	} {
+		payload, err := parse(`{"idempotencyKey": "` + tt.key + `"}`)
+		if err != nil {
+			t.Fatalf("%q: error = %v", tt.key, err)
+		}
+		if got := payload.OccurrenceKey(at); got != tt.want {
+			t.Errorf("OccurrenceKey() of %q = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+	// A static key names another occurrence the next day
+	payload, _ = parse(`{"idempotencyKey": "nightly"}`)
+	if payload.OccurrenceKey(at) == payload.OccurrenceKey(at.AddDate(0, 0, 1)) {
+		t.Error("OccurrenceKey() of a static key is the same on consecutive days")
+	}
+}
+
+func TestIdempotencyConfig(t *testing.T) {
+	parse := func(idem string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "timezone": "Asia/Tokyo"}, "flags": {"idempotencyKey": "nightly"}, "idempotency": ` + idem + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"table": "dca-locks"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if idem := payload.Idempotency; idem.Key != IdempotencyDefaultKey || idem.TTL() != 48*time.Hour || idem.FailOpen {
+		t.Errorf("Idempotency = %+v, want the defaults", idem)
+	}
+	// 20:00 UTC is the next day in Tokyo
+	at := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	if got := payload.LockKey(at); got != "binance#BTC-USDT#2026-10-17" {
+		t.Errorf("LockKey() = %q, want binance#BTC-USDT#2026-10-17", got)
+	}
+	if got := payload.ForAccount(AccountConfig{Label: "alice"}).LockKey(at); got != "alice#binance#BTC-USDT#2026-10-17" {
+		t.Errorf("LockKey() of an account = %q, want it prefixed by the label", got)
+	}
+
+	payload, err = parse(`{"table": "dca-locks", "key": "{side}/{symbol}/{month}/{key}", "ttlHours": 24, "failOpen": true}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if got := payload.LockKey(at); got != "buy/BTC-USDT/2026-10/nightly" || payload.Idempotency.TTL() != 24*time.Hour {
+		t.Errorf("LockKey() = %q, TTL %s, want buy/BTC-USDT/2026-10/nightly and 24h", got, payload.Idempotency.TTL())
+	}
+
+	for _, tt := range []struct{ idem, want string }{
+		{`{}`, "idempotency.table is required"},
+		{`{"table": "dca-locks", "key": "{exchange}#{day}"}`, "idempotency.key: unknown placeholder {day}"},
+		{`{"table": "dca-locks", "ttlHours": -1}`, "idempotency.ttlHours"},
+	} {
+		if _, err := parse(tt.idem); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.idem, err, tt.want)
+		}
+	}
+}
+
+func TestSharedRateLimitConfig(t *testing.T) {
+	parse := func(rl string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "state": {"sharedRateLimit": ` + rl + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"table": "dca-rate-limits", "requestsPerMinute": 600}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	rl := payload.State.SharedRateLimit
+	if rl.Key("binance") != "binance:default" || rl.Burst != 600 || rl.MaxWaitDuration() != 0 {
+		t.Errorf("defaults = %+v, want ipGroup default, burst 600 and no max wait", rl)
+	}
+	if payload.Origin("state.sharedRateLimit.burst") != OriginDefault {
+		t.Error("burst origin must be recorded as a default")
+	}
+
+	payload, err = parse(`{"table": "dca-rate-limits", "ipGroup": "nat-a", "requestsPerMinute": 600, "burst": 50, "maxWait": "5s"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if rl := payload.State.SharedRateLimit; rl.Key("okx") != "okx:nat-a" || rl.Burst != 50 || rl.MaxWaitDuration() != 5*time.Second {
+		t.Errorf("config = %+v", rl)
+	}
+
+	for _, invalid := range []string{
+		`{"requestsPerMinute": 600}`,
+		`{"table": "dca-rate-limits"}`,
+		`{"table": "dca-rate-limits", "requestsPerMinute": 600, "burst": -1}`,
+		`{"table": "dca-rate-limits", "requestsPerMinute": 600, "maxWait": "soon"}`,
+	} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "state.sharedRateLimit.") {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want a state.sharedRateLimit error", invalid, err)
+		}
+	}
+}
+
+func TestStatusConfig(t *testing.T) {
+	parse := func(status string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "state": {"status": ` + status + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"bucket": "dca-state"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if st := payload.State.Status; st.Prefix != StatusDefaultPrefix {
+		t.Errorf("prefix = %q, want %q", st.Prefix, StatusDefaultPrefix)
+	}
+	if payload.Origin("state.status.prefix") != OriginDefault {
+		t.Error("prefix origin must be recorded as a default")
+	}
+
+	payload, err = parse(`{"bucket": "dca-state", "prefix": "bot"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if st := payload.State.Status; st.Prefix != "bot/" {
+		t.Errorf("prefix = %q, want bot/", st.Prefix)
+	}
+
+	payload, err = parse(`{"dir": ".dca-state"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if st := payload.State.Status; st.Dir != ".dca-state" || st.Prefix != "" {
+		t.Errorf("config = %+v, want the dir without a prefix", st)
+	}
+
+	for _, invalid := range []string{
+		`{}`,
+		`{"bucket": "dca-state", "dir": ".dca-state"}`,
+		`{"dir": ".dca-state", "prefix": "bot/"}`,
+	} {
+		if _, err := parse(invalid); err == nil {
+			t.Errorf("expected error for state.status %s", invalid)
+		}
+	}
+}
+
+func TestTelegramCommandsConfig(t *testing.T) {
+	parse := func(commands, state string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+			"notifications": {"telegram": {"type": "env", "config": {"botTokenEnv": "TG_TOKEN", "chatId": "42"}, "commands": ` + commands + `}}` + state + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+	withStatus := `, "state": {"status": {"dir": ".dca-state"}}`
+
+	payload, err := parse(`{"allowedChatIds": ["42", "-1001234567890"]}`, withStatus)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	commands := payload.Notifications.Telegram.Commands
+	if !commands.Allowed(42) || !commands.Allowed(-1001234567890) {
+		t.Error("listed chats must be allowed")
+	}
+	if commands.Allowed(7) {
+		t.Error("unlisted chat must not be allowed")
+	}
+
+	if _, err := parse(`{"allowedChatIds": ["42"]}`, ""); err == nil || !strings.Contains(err.Error(), "requires state.status") {
+		t.Errorf("error = %v, want commands to require state.status", err)
+	}
+	for _, invalid := range []string{
+		`{}`,
+		`{"allowedChatIds": []}`,
+		`{"allowedChatIds": ["@mychannel"]}`,
+	} {
+		if _, err := parse(invalid, withStatus); err == nil {
+			t.Errorf("expected error for commands %s", invalid)
+		}
+	}
+
+	override := `{"version": "v2", "exchange": {"name": "binance"}, "state": {"status": {"dir": ".dca-state"}},
+		"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10", "notifications": {"telegram": {"type": "inline", "config": {"chatId": "7"}, "commands": {"allowedChatIds": ["7"]}}}}}`
+	if _, err := ParseDCAPayload([]byte(override)); err == nil {
+		t.Error("expected error for commands in a strategy override")
+	}
+}
+
+func TestExchangeAccounts(t *testing.T) {
+	parse := func(exchange, extra string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": ` + exchange + `, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}` + extra + `}`
+		return ParseDCAPayload([]byte(input))
+	}
+	accounts := `{"name": "binance", "accounts": [
+		{"label": "alice", "credentials": {"type": "env", "config": {"apiKeyEnv": "ALICE_KEY", "apiSecretEnv": "ALICE_SECRET"}}},
+		{"label": "bob", "credentials": {"type": "env", "config": {"apiKeyEnv": "BOB_KEY", "apiSecretEnv": "BOB_SECRET"}},
+			"notifications": {"telegram": {"type": "env", "config": {"chatId": "2"}}}}
+	]}`
+
+	payload, err := parse(accounts, `, "state": {"status": {"bucket": "dca-state"}}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	alice := payload.ForAccount(payload.Exchange.Accounts[0])
+	if alice.Account != "alice" || alice.Exchange.Credentials.Config["apiKeyEnv"] != "ALICE_KEY" || alice.Exchange.Accounts != nil {
+		t.Errorf("ForAccount() = %+v, want alice's credentials and no accounts", alice.Exchange)
+	}
+	if alice.Strategy.Notifications != nil {
+		t.Error("an account without an override must keep strategy.notifications")
+	}
+	if alice.State.Status.Prefix != "status/alice/" || payload.State.Status.Prefix != "status/" {
+		t.Errorf("prefix = %q, want status/alice/ without changing the payload", alice.State.Status.Prefix)
+	}
+	bob := payload.ForAccount(payload.Exchange.Accounts[1])
+	if bob.Strategy.Notifications == nil || bob.Strategy.Notifications.Telegram == nil {
+		t.Error("bob's notifications override must replace strategy.notifications")
+	}
+
+	payload, err = parse(accounts, `, "state": {"status": {"dir": ".dca-state"}}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if dir := payload.ForAccount(payload.Exchange.Accounts[1]).State.Status.Dir; dir != filepath.Join(".dca-state", "bob") {
+		t.Errorf("dir = %q, want bob's own directory", dir)
+	}
+
+	account := func(label string) string {
+		return `{"label": "` + label + `", "credentials": {"type": "env", "config": {"apiKeyEnv": "` + label + `_KEY", "apiSecretEnv": "` + label + `_SECRET"}}}`
+	}
+	tests := []struct {
+		name     string
+		exchange string
+		extra    string
+		want     string
+	}{
+		{"duplicate label", `{"name": "binance", "accounts": [` + account("alice") + `, ` + account("Alice") + `]}`, "", `"Alice" is used by another account`},
+		{"invalid label", `{"name": "binance", "accounts": [` + account("alice smith") + `]}`, "", "exchange.accounts[0].label"},
+		{"empty label", `{"name": "binance", "accounts": [` + account("") + `]}`, "", "exchange.accounts[0].label"},
+		{"credential type", `{"name": "binance", "accounts": [{"label": "a", "credentials": {"type": "vault"}}]}`, "", "exchange.accounts[0].credentials"},
+		{"shared credentials", `{"name": "binance", "credentials": {"type": "env", "config": {"apiKeyEnv": "KEY", "apiSecretEnv": "SECRET"}}, "accounts": [` + account("a") + `]}`, "", "set per account"},
+		{"failover", `[{"name": "binance", "accounts": [` + account("a") + `]}, {"name": "okx"}]`, "", "failover"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parse(tt.exchange, tt.extra); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseDCAPayload() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIUsageConfig(t *testing.T) {
+	parse := func(usage string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance", "apiUsage": ` + usage + `}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	usage := payload.Exchange.APIUsage
+	if !usage.Fraction().Equal(decimal.RequireFromString("0.8")) || usage.Limit("binance") != BinanceWeightLimit || usage.Limit("okx") != 0 {
+		t.Errorf("defaults = %s of %d, want 0.8 of Binance's limit and none for OKX", usage.Fraction(), usage.Limit("binance"))
+	}
+	var unset *APIUsageConfig
+	if unset.Limit("binance") != BinanceWeightLimit {
+		t.Error("an unset apiUsage must use the documented limit")
+	}
+
+	payload, err = parse(`{"warnFraction": "0.5", "weightLimit": 1200}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if usage := payload.Exchange.APIUsage; !usage.Fraction().Equal(decimal.RequireFromString("0.5")) || usage.Limit("okx") != 1200 {
+		t.Errorf("config = %+v, want the configured fraction and limit", usage)
+	}
+
+	for _, invalid := range []string{`{"warnFraction": "0"}`, `{"warnFraction": "1.5"}`, `{"warnFraction": "most"}`, `{"weightLimit": -1}`} {
+		if _, err := parse(invalid); err == nil || !strings.Contains(err.Error(), "exchange.apiUsage.") {
+			t.Errorf("error = %v, want exchange.apiUsage %s rejected", err, invalid)
+		}
+	}
+}
+
+func TestExchangeOptions(t *testing.T) {
+	parse := func(options string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "okx", "options": ` + options + `}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"extraHeaders": {"CF-Access-Client-Id": {"type": "inline", "config": {"value": "id.access"}}, "User-Agent": {"type": "env", "config": {"valueEnv": "UA"}}}}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if len(payload.Exchange.Options.ExtraHeaders) != 2 {
+		t.Errorf("extraHeaders = %v, want both headers", payload.Exchange.Options.ExtraHeaders)
+	}
+	if got := payload.Exchange.Options.Settlement(); got != DefaultSettlementTimeout {
+		t.Errorf("Settlement() = %s, want default %s", got, DefaultSettlementTimeout)
+	}
+	if got := payload.Exchange.Options.SettlementPoll(); got != DefaultSettlementPollInterval {
+		t.Errorf("SettlementPoll() = %s, want default %s", got, DefaultSettlementPollInterval)
+	}
+	if payload, err = parse(`{"settlementTimeout": "45s"}`); err != nil || payload.Exchange.Options.Settlement() != 45*time.Second {
+		t.Errorf("settlementTimeout 45s: error = %v", err)
+	}
+	if payload, err = parse(`{"settlementPollInterval": "500ms"}`); err != nil || payload.Exchange.Options.SettlementPoll() != 500*time.Millisecond {
+		t.Errorf("settlementPollInterval 500ms: error = %v", err)
+	}
+	if got := payload.Exchange.Options.Attempts(); got != DefaultMaxAttempts {
+		t.Errorf("Attempts() = %d, want default %d", got, DefaultMaxAttempts)
+	}
+	if payload, err = parse(`{"maxAttempts": 1}`); err != nil || payload.Exchange.Options.Attempts() != 1 {
+		t.Errorf("maxAttempts 1: error = %v", err)
+	}
+
+	tests := []struct{ options, want string }{
+		{`{"extraHeaders": {"OK-ACCESS-SIGN": {"type": "inline", "config": {"value": "x"}}}}`, "extraHeaders.OK-ACCESS-SIGN: set by the bot"},
+		{`{"extraHeaders": {"content-type": {"type": "inline", "config": {"value": "x"}}}}`, "extraHeaders.content-type: set by the bot"},
+		{`{"extraHeaders": {"X Proxy": {"type": "inline", "config": {"value": "x"}}}}`, "extraHeaders: invalid header name"},
+		{`{"extraHeaders": {"X-Proxy": {"type": "vault"}}}`, "extraHeaders.X-Proxy: unknown credential type"},
+		{`{"settlementTimeout": "-5s"}`, "settlementTimeout: must be a positive duration"},
+		{`{"settlementPollInterval": "soon"}`, "settlementPollInterval: must be a positive duration"},
+		{`{"maxAttempts": -1}`, "maxAttempts: must be at least 1"},
+	}
+	for _, tt := range tests {
+		if _, err := parse(tt.options); err == nil || !strings.Contains(err.Error(), "exchange.options."+tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.options, err, tt.want)
+		}
+	}
+}
+
+func TestExpectedEgress(t *testing.T) {
+	parse := func(egress string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": {"expectedEgress": ` + egress + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"ips": ["3.121.4.5", "52.28.0.0/16"], "region": "eu-central-1"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	e := payload.Flags.ExpectedEgress
+	if len(e.Prefixes()) != 2 || e.Echo() != DefaultEgressEchoURL {
+		t.Errorf("Prefixes() = %v, Echo() = %s", e.Prefixes(), e.Echo())
+	}
+
+	tests := []struct{ egress, want string }{
+		{`{}`, "flags.expectedEgress.ips or region is required"},
+		{`{"ips": ["3.121.x.x"]}`, "flags.expectedEgress.ips[0]"},
+		{`{"region": "eu-central-1", "echoURL": "checkip"}`, "flags.expectedEgress.echoURL"},
+	}
+	for _, tt := range tests {
+		if _, err := parse(tt.egress); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.egress, err, tt.want)
+		}
+	}
+}
+
+func TestCostPrices(t *testing.T) {
+	parse := func(prices string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": {"costPrices": ` + prices + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+
+	payload, err := parse(`{"lambda.gbSecond": "0.0000133334", "ssm": "0"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Flags.CostPrices["ssm"] != "0" {
+		t.Errorf("CostPrices = %v", payload.Flags.CostPrices)
+	}
+
+	tests := []struct{ prices, want string }{
+		{`{"ssm": "free"}`, `flags.costPrices.ssm: invalid price "free"`},
+		{`{"s3": "-0.000005"}`, `flags.costPrices.s3: invalid price`},
+		{`{"": "0.1"}`, `flags.costPrices."": empty key`},
+	}
+	for _, tt := range tests {
+		if _, err := parse(tt.prices); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", tt.prices, err, tt.want)
+		}
+	}
+}
+
+func TestRequireApproval(t *testing.T) {
+	parse := func(approval string) (*DCAPayload, error) {
+		input := `{"version": "v2", "exchange": {"name": "binance"}, "strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"}, "flags": {"requireApproval": ` + approval + `}}`
+		return ParseDCAPayload([]byte(input))
+	}
+	payload, err := parse(`{"parameter": "/dca/approved-strategy"}`)
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	if payload.Flags.RequireApproval.Parameter != "/dca/approved-strategy" {
+		t.Errorf("RequireApproval = %+v", payload.Flags.RequireApproval)
+	}
+
+	fields, err := payload.StrategyFields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fields {
+		if !strings.HasPrefix(f.Path, "strategy.") {
+			t.Errorf("StrategyFields() has %s, want strategy fields only", f.Path)
+		}
+	}
+
+	for approval, want := range map[string]string{
+		`{}`:                        "flags.requireApproval.parameter is required",
+		`{"parameter": "/dca/a b"}`: "flags.requireApproval.parameter: invalid SSM parameter name",
+	} {
+		if _, err := parse(approval); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseDCAPayload(%s) error = %v, want %q", approval, err, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// PercentSized reports whether the strategy derives quoteAmount from
+// quoteAmountPercent. Each run then spends that percentage of the free
+// quote balance, capped by maxQuoteAmount, and skips when the result is
+// below the exchange's minimum order value.
+func (s DCAStrategy) PercentSized() bool {
+	return s.QuoteAmountPercent != ""
+}
+
+// AmountPercent returns QuoteAmountPercent, valid once the payload was
+// parsed
+func (s DCAStrategy) AmountPercent() money.Percent {
+	percent, _ := money.PercentFromString(s.QuoteAmountPercent)
+	return percent
+}
+
+func (s DCAStrategy) validatePercent() error {
+	if !s.PercentSized() {
+		return nil
+	}
+	if s.QuoteAmount != "" || s.Budgeted() {
+		return fmt.Errorf("quoteAmountPercent: set only one of quoteAmount, monthlyBudget and quoteAmountPercent")
+	}
+	percent, err := money.PercentFromString(s.QuoteAmountPercent)
+	if err != nil || !percent.IsPositive() || percent.Cmp(money.HundredPercent) > 0 {
+		return fmt.Errorf("quoteAmountPercent: invalid percentage %q (want more than 0 and at most 100)", s.QuoteAmountPercent)
+	}
+	if s.MaxQuoteAmount != "" {
+		if max, err := decimal.NewFromString(s.MaxQuoteAmount); err != nil || !max.IsPositive() {
+			return fmt.Errorf("maxQuoteAmount: invalid amount %q", s.MaxQuoteAmount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// RampUpConfig phases in a changed strategy: the first Runs orders after
+// its hash changes are scaled linearly from StartPercent up to the full
+// amount. The hash and progress are kept in state.status.
+type RampUpConfig struct {
+	Runs         int    `json:"runs"`         // ramped orders, the last at full size, e.g. 3
+	StartPercent string `json:"startPercent"` // size of the first, e.g. "40" for 40%
+}
+
+// RampUpMaxRuns bounds flags.rampUp.runs
+const RampUpMaxRuns = 100
+
+// Start returns StartPercent, valid once the payload was parsed
+func (c RampUpConfig) Start() money.Percent {
+	start, _ := money.PercentFromString(c.StartPercent)
+	return start
+}
+
+func (c *RampUpConfig) validate() error {
+	if c.Runs < 1 || c.Runs > RampUpMaxRuns {
+		return fmt.Errorf("runs: must be between 1 and %d", RampUpMaxRuns)
+	}
+	if start, err := money.PercentFromString(c.StartPercent); err != nil || !start.IsPositive() || start.Cmp(money.HundredPercent) >= 0 {
+		return fmt.Errorf("startPercent: invalid percentage %q (want more than 0 and less than 100)", c.StartPercent)
+	}
+	return nil
+}
+
+// StrategyFields returns the strategy as configured, the fields
+// StrategyHash covers. Values derived during the run, such as a budgeted
+// quoteAmount, and the strategy's notifications do not count.
+func (p *DCAPayload) StrategyFields() (Snapshot, error) {
+	snapshot, err := p.Effective()
+	if err != nil {
+		return nil, err
+	}
+	var fields Snapshot
+	for _, f := range snapshot {
+		if !strings.HasPrefix(f.Path, "strategy.") || strings.HasPrefix(f.Path, "strategy.notifications.") || f.Origin == OriginDerived {
+			continue
+		}
+		// An unset field is the same as one derived later in the run
+		if f.Value == "" {
+			continue
+		}
+		fields = append(fields, EffectiveField{Path: f.Path, Value: f.Value})
+	}
+	return fields, nil
+}
+
+// StrategyHash identifies the strategy as configured, so a run can tell
+// whether it changed since the last one
+func (p *DCAPayload) StrategyHash() (string, error) {
+	fields, err := p.StrategyFields()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode strategy: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ModeReconcile compares the exchange's trade history of strategy.symbol
+// with the execution history and imports the fills it is missing
+const ModeReconcile = "reconcile"
+
+// ReconcileDefaultLookbackDays is the range reconciled when From is unset
+const ReconcileDefaultLookbackDays = 7
+
+// ReconcileConfig selects the history a reconcile run checks
+type ReconcileConfig struct {
+	History string `json:"history"`        // execution history JSONL; a local path or s3://bucket/key
+	From    string `json:"from,omitempty"` // first day, YYYY-MM-DD (UTC); default 7 days before To
+	To      string `json:"to,omitempty"`   // last day, YYYY-MM-DD (UTC), inclusive; default today
+}
+
+func (c *ReconcileConfig) validate() error {
+	if c.History == "" {
+		return fmt.Errorf("history is required")
+	}
+	from, to, err := c.Range(time.Now())
+	if err != nil {
+		return err
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("from %s is after to %s", from.Format(DateLayout), to.AddDate(0, 0, -1).Format(DateLayout))
+	}
+	return nil
+}
+
+// Range returns the UTC range [from, to) to reconcile; unset dates default
+// relative to now
+func (c *ReconcileConfig) Range(now time.Time) (from, to time.Time, err error) {
+	to = now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if c.To != "" {
+		day, err := time.Parse(DateLayout, c.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to: invalid date %q (want YYYY-MM-DD)", c.To)
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	from = to.AddDate(0, 0, -ReconcileDefaultLookbackDays)
+	if c.From != "" {
+		if from, err = time.Parse(DateLayout, c.From); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from: invalid date %q (want YYYY-MM-DD)", c.From)
+		}
+	}
+	return from, to, nil
+}
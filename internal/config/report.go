@@ -0,0 +1,18 @@
+package config
+
+// ModeReport reports the holdings of strategy.symbol without trading: the
+// balances, their market value, the cost basis from the execution history
+// and the upcoming scheduled runs. It only reads from the exchange, so
+// read-only API keys are enough.
+const ModeReport = "report"
+
+// ReportUpcomingRuns is how many scheduled runs a report lists
+const ReportUpcomingRuns = 3
+
+// ReportConfig tunes a report run
+type ReportConfig struct {
+	// History is the execution history JSONL the cost basis is computed
+	// from; a local path or s3://bucket/key, defaulting to
+	// strategy.budgetHistory. Without one the report has no cost basis.
+	History string `json:"history,omitempty"`
+}
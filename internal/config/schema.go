@@ -0,0 +1,111 @@
+package config
+
+import (
+	"regexp"
+
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// Modes lists the valid payload modes
+var Modes = []string{ModeDCA, ModeDust, ModeReconcile, ModeReport}
+
+// OrderTypes lists the valid strategy.orderType values
+var OrderTypes = []string{"market", "limit"}
+
+// SymbolPattern is the form of a strategy symbol: "BTC-USDT", or "BTCUSDT"
+// with a quote asset exchange.SplitSymbol recognizes
+const SymbolPattern = `^[A-Za-z0-9]+(-[A-Za-z0-9]+)?$`
+
+var symbolPattern = regexp.MustCompile(SymbolPattern)
+
+// DecimalPattern is the form of the amounts, prices and percentages the
+// payload writes as strings, as decimal.NewFromString reads them
+const DecimalPattern = `^[-+]?([0-9]+\.?[0-9]*|\.[0-9]+)([eE][-+]?[0-9]+)?$`
+
+// TimestampPattern is the form of the RFC3339 timestamps time.Parse reads
+const TimestampPattern = `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?(Z|[-+][0-9]{2}:[0-9]{2})$`
+
+// FieldRule is what ParseDCAPayload accepts for one field beyond its JSON
+// type. The payload schema is generated from the struct tags and these
+// rules, so each rule names the list or pattern its validator checks.
+type FieldRule struct {
+	Required bool     // rejected when missing from its object, whatever the mode
+	Enum     []string // allowed values of a string, or of the items of a string array
+	Keys     []string // allowed keys of a map
+	Pattern  string   // regular expression a string, or each string item, matches
+	Decimal  bool     // a decimal written as a string; of a map, its values
+
+	// Forms the field's UnmarshalJSON accepts besides its own type
+	ListForm   bool // a non-empty array of the field's type
+	ObjectForm any  // an object of this type
+}
+
+// FieldRules are keyed by Go type and JSON name, e.g. "DCAStrategy.side"
+var FieldRules = map[string]FieldRule{
+	"DCAPayload.version":   {Required: true, Enum: []string{"v2", "V2"}},
+	"DCAPayload.mode":      {Enum: Modes},
+	"DCAPayload.exchange":  {Required: true, ListForm: true},
+	"DCAPayload.strategy":  {Required: true},
+	"DCAPayload.notBefore": {Pattern: TimestampPattern},
+	"DCAPayload.expiresAt": {Pattern: TimestampPattern},
+
+	"ExchangeConfig.name":         {Required: true},
+	"CredentialSource.type":       {Enum: CredentialTypes},
+	"AccountConfig.label":         {Required: true, Pattern: accountLabel.String()},
+	"APIUsageConfig.warnFraction": {Decimal: true},
+
+	"DCAStrategy.symbol":                  {Required: true, Pattern: SymbolPattern},
+	"DCAStrategy.quoteAmount":             {Decimal: true},
+	"DCAStrategy.balanceThreshold":        {Decimal: true, ObjectForm: Threshold{}},
+	"DCAStrategy.orderType":               {Enum: OrderTypes},
+	"DCAStrategy.feeHandling":             {Enum: []string{sizing.FeeInclude, sizing.FeeDeduct}},
+	"DCAStrategy.feeRateBps":              {Decimal: true},
+	"DCAStrategy.side":                    {Enum: []string{SideBuy, SideSell}},
+	"DCAStrategy.minPrice":                {Decimal: true},
+	"DCAStrategy.maxPrice":                {Decimal: true},
+	"DCAStrategy.monthlyBudget":           {Decimal: true},
+	"DCAStrategy.maxQuoteAmount":          {Decimal: true},
+	"DCAStrategy.quoteAmountPercent":      {Decimal: true},
+	"DCAStrategy.engine":                  {Enum: []string{EngineSpot, EngineNative}},
+	"Threshold.amount":                    {Decimal: true},
+	"Threshold.pegTolerance":              {Decimal: true},
+	"CalendarConfig.skipDates":            {Pattern: `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`},
+	"StopLossConfig.percentBelowFill":     {Required: true, Decimal: true},
+	"StopLossConfig.limitOffsetPercent":   {Decimal: true},
+	"CircuitBreakerConfig.maxMovePercent": {Required: true, Decimal: true},
+	"CircuitBreakerConfig.windowMinutes":  {Required: true},
+	"DipMultipliersConfig.tiers":          {Required: true},
+	"DipMultipliersConfig.referencePrice": {Decimal: true},
+	"DipMultipliersConfig.maxQuoteAmount": {Decimal: true},
+	"DipTier.drawdownPercent":             {Required: true, Decimal: true},
+	"DipTier.multiplier":                  {Required: true, Decimal: true},
+	"WithdrawalConfig.address":            {Required: true},
+	"WithdrawalConfig.network":            {Required: true},
+	"HoldingsAlertConfig.threshold":       {Required: true, Decimal: true},
+	"DustConfig.maxValue":                 {Decimal: true},
+	"ReconcileConfig.history":             {Required: true},
+
+	"NotificationConfig.events":             {Keys: NotificationEvents},
+	"NotificationConfig.digestExcludes":     {Enum: NotificationEvents},
+	"NotificationConfig.channels":           {Enum: []string{ChannelsReplace, ChannelsAppend}},
+	"NotificationConfig.language":           {Enum: NotificationLanguages},
+	"TelegramConfig.type":                   {Required: true, Enum: CredentialTypes},
+	"TelegramCommandsConfig.allowedChatIds": {Required: true},
+	"WebhookConfig.url":                     {Required: true},
+	"WebhookConfig.secrets":                 {Required: true},
+	"WebhookSecret.keyId":                   {Required: true},
+
+	"AuditLogConfig.bucket":                   {Required: true},
+	"AuditLogConfig.onFailure":                {Enum: []string{AuditLogFatal, AuditLogWarn}},
+	"RetrySchedulerConfig.roleArn":            {Required: true},
+	"TradingViewConfig.symbols":               {Required: true},
+	"TradingViewSymbol.symbol":                {Required: true, Pattern: SymbolPattern},
+	"TradingViewSymbol.quoteAmount":           {Required: true, Decimal: true},
+	"SharedRateLimitConfig.table":             {Required: true},
+	"SharedRateLimitConfig.requestsPerMinute": {Required: true},
+
+	"RuntimeFlags.costPrices":   {Decimal: true},
+	"RampUpConfig.runs":         {Required: true},
+	"RampUpConfig.startPercent": {Required: true, Decimal: true},
+	"ApprovalConfig.parameter":  {Required: true},
+}
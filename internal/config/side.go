@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// Strategy sides for strategy.side
+const (
+	SideBuy  = "buy"  // DCA-in: spend quoteAmount on the base asset
+	SideSell = "sell" // DCA-out: sell enough base asset for quoteAmount of proceeds
+)
+
+// Selling reports whether the strategy is a DCA-out strategy
+func (s DCAStrategy) Selling() bool {
+	return s.Side == SideSell
+}
+
+func (s DCAStrategy) validateSide() error {
+	switch s.Side {
+	case "", SideBuy:
+		if s.MinPrice != "" {
+			return fmt.Errorf("minPrice: only applies to side %q", SideSell)
+		}
+		if s.MaxPrice != "" {
+			if price, err := decimal.NewFromString(s.MaxPrice); err != nil || !price.IsPositive() {
+				return fmt.Errorf("maxPrice: invalid value %q", s.MaxPrice)
+			}
+			// A routed symbol is not listed, so it has no last price to compare
+			if s.AllowRouting {
+				return fmt.Errorf("maxPrice: not supported with allowRouting; a routed symbol has no ticker of its own")
+			}
+		}
+		return nil
+	case SideSell:
+	default:
+		return fmt.Errorf("side: unknown value %q (want %s or %s)", s.Side, SideBuy, SideSell)
+	}
+
+	if s.MinPrice != "" {
+		if price, err := decimal.NewFromString(s.MinPrice); err != nil || !price.IsPositive() {
+			return fmt.Errorf("minPrice: invalid value %q", s.MinPrice)
+		}
+	}
+	switch {
+	case s.MaxPrice != "":
+		return fmt.Errorf("maxPrice: only applies to side %q", SideBuy)
+	case s.FeeHandling == sizing.FeeDeduct:
+		return fmt.Errorf("feeHandling: %q is not supported when selling", sizing.FeeDeduct)
+	case s.StopLoss != nil:
+		return fmt.Errorf("stopLoss: not supported when selling")
+	case s.AllowRouting:
+		return fmt.Errorf("allowRouting: not supported when selling")
+	case s.Budgeted():
+		return fmt.Errorf("monthlyBudget: not supported when selling")
+	case s.PercentSized():
+		return fmt.Errorf("quoteAmountPercent: not supported when selling")
+	case s.CheckPendingDeposits:
+		return fmt.Errorf("checkPendingDeposits: not supported when selling")
+	case s.CircuitBreaker != nil:
+		return fmt.Errorf("circuitBreaker: not supported when selling")
+	case s.DipMultipliers != nil:
+		return fmt.Errorf("dipMultipliers: not supported when selling")
+	case s.AutoTransfer:
+		return fmt.Errorf("autoTransfer: not supported when selling")
+	case s.HoldingsAlert != nil:
+		return fmt.Errorf("holdingsAlert: not supported when selling")
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Stages at which flags.simulateFailure injects a failure
+const (
+	SimulateCredentials  = "credentials"  // while creating the exchange client
+	SimulateOrder        = "order"        // instead of placing the order
+	SimulateNotification = "notification" // when delivering a notification
+)
+
+var simulateStages = []string{SimulateCredentials, SimulateOrder, SimulateNotification}
+
+// AllowSimulatedFailuresEnv must be "true" for flags.simulateFailure to be
+// accepted on a live run, so a copied payload cannot make production runs
+// fail by accident
+const AllowSimulatedFailuresEnv = "DCA_ALLOW_SIMULATED_FAILURES"
+
+// validateSimulateFailure checks flags.simulateFailure
+func (f *RuntimeFlags) validateSimulateFailure() error {
+	if f.SimulateFailure == "" {
+		return nil
+	}
+	if !slices.Contains(simulateStages, f.SimulateFailure) {
+		return fmt.Errorf("simulateFailure: unknown stage %q (want one of %v)", f.SimulateFailure, simulateStages)
+	}
+	if !f.DryRun && os.Getenv(AllowSimulatedFailuresEnv) != "true" {
+		return fmt.Errorf("simulateFailure: refusing to simulate a failure of a live run unless %s=true", AllowSimulatedFailuresEnv)
+	}
+	return nil
+}
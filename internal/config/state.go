@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StateConfig is state shared by the invocations of the bot
+type StateConfig struct {
+	SharedRateLimit *SharedRateLimitConfig `json:"sharedRateLimit,omitempty"`
+	Status          *StatusConfig          `json:"status,omitempty"`
+
+	// EnrichWithContext records the market around each run with its result:
+	// the 24h change, the 7- and 30-day averages and the day's high and low,
+	// as far as the candles strategy.circuitBreaker read cover them
+	EnrichWithContext bool `json:"enrichWithContext,omitempty"`
+}
+
+// StatusConfig keeps the result of the last run and the pause switch, which
+// the Telegram commands read and flip. Runs save their result there and are
+// skipped while the switch is on. Exactly one of Bucket and Dir is set.
+type StatusConfig struct {
+	Bucket string `json:"bucket,omitempty"` // S3 bucket
+	Prefix string `json:"prefix,omitempty"` // key prefix in Bucket; default "status/"
+	Dir    string `json:"dir,omitempty"`    // local directory, for local runs and the local bot
+}
+
+// Default for StatusConfig
+const StatusDefaultPrefix = "status/"
+
+func (c *StatusConfig) validate() error {
+	if (c.Bucket == "") == (c.Dir == "") {
+		return fmt.Errorf("exactly one of bucket and dir is required")
+	}
+	if c.Dir != "" && c.Prefix != "" {
+		return fmt.Errorf("prefix: only valid with bucket")
+	}
+	return nil
+}
+
+// SharedRateLimitConfig spaces out exchange requests with a token bucket per
+// exchange and IP group, shared through a DynamoDB table so that parallel
+// invocations behind one NAT gateway stay under the exchange's per-IP limit.
+// Every runtime shares the bucket through the table; only local runs keep
+// it in process.
+type SharedRateLimitConfig struct {
+	Table   string `json:"table"`             // DynamoDB table with string partition key "pk"
+	IPGroup string `json:"ipGroup,omitempty"` // invocations sharing an egress IP; default "default"
+
+	RequestsPerMinute int `json:"requestsPerMinute"`
+	Burst             int `json:"burst,omitempty"` // default requestsPerMinute
+
+	// MaxWait fails a request that would wait longer, as a Go duration such
+	// as "5s"; by default a request waits as long as the run's deadline allows
+	MaxWait string `json:"maxWait,omitempty"`
+}
+
+// Default for SharedRateLimitConfig
+const SharedRateLimitDefaultIPGroup = "default"
+
+// Key is the bucket of requests to exchange
+func (c *SharedRateLimitConfig) Key(exchange string) string {
+	return exchange + ":" + c.IPGroup
+}
+
+// MaxWaitDuration returns MaxWait, 0 when unset
+func (c *SharedRateLimitConfig) MaxWaitDuration() time.Duration {
+	d, _ := time.ParseDuration(c.MaxWait)
+	return d
+}
+
+func (c *SharedRateLimitConfig) validate() error {
+	if c.Table == "" {
+		return fmt.Errorf("table is required")
+	}
+	if c.RequestsPerMinute <= 0 {
+		return fmt.Errorf("requestsPerMinute must be positive")
+	}
+	if c.Burst < 0 {
+		return fmt.Errorf("burst must be positive")
+	}
+	if c.MaxWait != "" {
+		if d, err := time.ParseDuration(c.MaxWait); err != nil || d <= 0 {
+			return fmt.Errorf("maxWait: invalid duration %q", c.MaxWait)
+		}
+	}
+	return nil
+}
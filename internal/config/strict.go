@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// legacyKeys are the top-level keys of the legacy format, accepted so Lint
+// can report them
+var legacyKeys = []string{"dca", "credentials"}
+
+// telegramConfigKeys are the config keys of notifications.telegram by
+// source type
+var telegramConfigKeys = map[string][]string{
+	CredentialTypeSSM:    {"botTokenPath", "chatId"},
+	CredentialTypeEnv:    {"botTokenEnv", "chatId"},
+	CredentialTypeInline: {"botToken", "chatId"},
+}
+
+// configPackage is the import path of the types walkFields descends into
+var configPackage = reflect.TypeOf(DCAPayload{}).PkgPath()
+
+// checkUnknownFields returns an error naming, by its JSON path, the first
+// key of the payload in raw that no field reads, e.g. "strategy.quoteAmout:
+// unknown field (did you mean quoteAmount?)". encoding/json would drop it,
+// and match keys that differ only in case. The config of exchange and
+// telegram credential sources is checked against the keys of its type.
+func checkUnknownFields(raw []byte) error {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return walkFields(v, reflect.TypeOf(DCAPayload{}), "")
+}
+
+// structField is a field of a config struct as encoding/json reads it
+type structField struct {
+	typ  reflect.Type
+	rule FieldRule
+}
+
+// jsonFields returns the fields of struct t by JSON name, its embedded
+// structs' fields included as encoding/json flattens them
+func jsonFields(t reflect.Type, fields map[string]structField) map[string]structField {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			jsonFields(f.Type, fields)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = structField{typ: f.Type, rule: FieldRules[t.Name()+"."+name]}
+	}
+	return fields
+}
+
+// walkFields checks the keys of v, a decoded JSON value read into a t
+func walkFields(v any, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		items, _ := v.([]any)
+		for i, item := range items {
+			if err := walkFields(item, t.Elem(), joinPath(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, _ := v.(map[string]any)
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			if err := walkFields(obj[key], t.Elem(), joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok || t.PkgPath() != configPackage {
+			return nil
+		}
+		fields := jsonFields(t, map[string]structField{})
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			field, ok := fields[key]
+			if !ok {
+				if path == "" && slices.Contains(legacyKeys, key) {
+					continue
+				}
+				return fmt.Errorf("%s: unknown field%s", joinPath(path, key), suggest(key, slices.Collect(maps.Keys(fields))))
+			}
+			if err := walkField(obj[key], field, joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+		return checkSourceKeys(obj, t, path)
+	}
+	return nil
+}
+
+// walkField checks a field's value in any of the forms its rule accepts
+func walkField(v any, field structField, path string) error {
+	if items, ok := v.([]any); ok && field.rule.ListForm {
+		for i, item := range items {
+			if err := walkFields(item, field.typ, joinPath(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if _, ok := v.(map[string]any); ok && field.rule.ObjectForm != nil {
+		return walkFields(v, reflect.TypeOf(field.rule.ObjectForm), path)
+	}
+	return walkFields(v, field.typ, path)
+}
+
+// checkSourceKeys checks the config keys of the credential sources of
+// exchanges, accounts and telegram, obj being one of those of type t
+func checkSourceKeys(obj map[string]any, t reflect.Type, path string) error {
+	switch t {
+	case reflect.TypeOf(ExchangeConfig{}), reflect.TypeOf(AccountConfig{}):
+		source, _ := obj["credentials"].(map[string]any)
+		sourceType, _ := source["type"].(string)
+		return checkConfigKeys(source, exchangeCredentialKeys[sourceType], sourceType, joinPath(path, "credentials"))
+	case reflect.TypeOf(TelegramConfig{}):
+		sourceType, _ := obj["type"].(string)
+		return checkConfigKeys(obj, telegramConfigKeys[sourceType], sourceType, path)
+	}
+	return nil
+}
+
+// checkConfigKeys checks the keys of source's config are in known, the
+// keys of sourceType, or lists them; unknown source types are left to
+// their validation
+func checkConfigKeys(source map[string]any, known []string, sourceType, path string) error {
+	config, _ := source["config"].(map[string]any)
+	if known == nil {
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		if slices.Contains(known, key) {
+			continue
+		}
+		hint := suggest(key, known)
+		if hint == "" {
+			hint = fmt.Sprintf(" (want %s)", strings.Join(known, ", "))
+		}
+		return fmt.Errorf("%s: unknown key of %s credentials%s", joinPath(path, "config."+key), sourceType, hint)
+	}
+	return nil
+}
+
+// suggest returns " (did you mean x?)" for the candidate x closest to key,
+// when one is within two edits
+func suggest(key string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, c := range slices.Sorted(slices.Values(candidates)) {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", best)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// joinPath appends key to a dotted JSON path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
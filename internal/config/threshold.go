@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ThresholdCurrencyUSD is the only currency a threshold can be converted to
+const ThresholdCurrencyUSD = "USD"
+
+// ThresholdDefaultPegTolerance applies when ThresholdPegTolerance is unset
+const ThresholdDefaultPegTolerance = "0.005"
+
+// Threshold is the object form of strategy.balanceThreshold, for amounts in
+// a currency other than the quote asset
+type Threshold struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"` // "USD"
+
+	// PegTolerance is how far a USD stablecoin may trade from 1 and still be
+	// counted 1:1, e.g. "0.005" for half a percent
+	PegTolerance string `json:"pegTolerance,omitempty"`
+}
+
+// strategyJSON is DCAStrategy with balanceThreshold kept raw, so it can be a
+// string or an object
+type strategyJSON struct {
+	dcaStrategyFields
+	BalanceThreshold json.RawMessage `json:"balanceThreshold,omitempty"`
+}
+
+type dcaStrategyFields DCAStrategy
+
+// UnmarshalJSON accepts balanceThreshold as a plain amount or a Threshold
+func (s *DCAStrategy) UnmarshalJSON(data []byte) error {
+	var raw strategyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = DCAStrategy(raw.dcaStrategyFields)
+	s.BalanceThreshold, s.ThresholdCurrency, s.ThresholdPegTolerance = "", "", ""
+
+	trimmed := strings.TrimSpace(string(raw.BalanceThreshold))
+	switch {
+	case trimmed == "" || trimmed == "null":
+	case strings.HasPrefix(trimmed, "{"):
+		var t Threshold
+		if err := json.Unmarshal(raw.BalanceThreshold, &t); err != nil {
+			return fmt.Errorf("balanceThreshold: %w", err)
+		}
+		s.BalanceThreshold = t.Amount
+		s.ThresholdCurrency = strings.ToUpper(t.Currency)
+		s.ThresholdPegTolerance = t.PegTolerance
+	default:
+		if err := json.Unmarshal(raw.BalanceThreshold, &s.BalanceThreshold); err != nil {
+			return fmt.Errorf("balanceThreshold: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes balanceThreshold as an object when it has a currency
+func (s DCAStrategy) MarshalJSON() ([]byte, error) {
+	var threshold interface{} = s.BalanceThreshold
+	if s.ThresholdCurrency != "" {
+		threshold = Threshold{Amount: s.BalanceThreshold, Currency: s.ThresholdCurrency, PegTolerance: s.ThresholdPegTolerance}
+	}
+	encoded, err := json.Marshal(threshold)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(strategyJSON{dcaStrategyFields: dcaStrategyFields(s), BalanceThreshold: encoded})
+}
+
+// Threshold returns the balance threshold in its object form. Currency is
+// empty for a plain amount, which is in the quote asset.
+func (s DCAStrategy) Threshold() Threshold {
+	t := Threshold{Amount: s.BalanceThreshold, Currency: s.ThresholdCurrency, PegTolerance: s.ThresholdPegTolerance}
+	if t.Currency != "" && t.PegTolerance == "" {
+		t.PegTolerance = ThresholdDefaultPegTolerance
+	}
+	return t
+}
+
+func (s DCAStrategy) validateThreshold() error {
+	if s.ThresholdCurrency == "" {
+		if s.ThresholdPegTolerance != "" {
+			return fmt.Errorf("pegTolerance: requires currency")
+		}
+		return nil
+	}
+	if s.BalanceThreshold == "" {
+		return fmt.Errorf("amount: required with currency")
+	}
+	if s.ThresholdCurrency != ThresholdCurrencyUSD {
+		return fmt.Errorf("currency: unsupported value %q (want USD)", s.ThresholdCurrency)
+	}
+	if s.ThresholdPegTolerance != "" {
+		if tol, err := decimal.NewFromString(s.ThresholdPegTolerance); err != nil || tol.IsNegative() || tol.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return fmt.Errorf("pegTolerance: invalid value %q", s.ThresholdPegTolerance)
+		}
+	}
+	return nil
+}
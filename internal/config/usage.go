@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// APIUsageConfig sets when the run's exchange API usage is high enough to
+// warn and to skip calls the run can do without
+type APIUsageConfig struct {
+	// WarnFraction is the share of WeightLimit at which the run warns,
+	// e.g. "0.8"; default APIUsageDefaultWarnFraction
+	WarnFraction string `json:"warnFraction,omitempty"`
+
+	// WeightLimit is the request weight the exchange allows per minute;
+	// default the exchange's documented limit, where it reports weight
+	WeightLimit int `json:"weightLimit,omitempty"`
+}
+
+// Defaults for APIUsageConfig
+const (
+	APIUsageDefaultWarnFraction = "0.8"
+	BinanceWeightLimit          = 6000 // REQUEST_WEIGHT per minute and IP
+)
+
+// Fraction returns WarnFraction, or the default; nil-safe
+func (c *APIUsageConfig) Fraction() decimal.Decimal {
+	if c != nil && c.WarnFraction != "" {
+		if f, err := decimal.NewFromString(c.WarnFraction); err == nil {
+			return f
+		}
+	}
+	return decimal.RequireFromString(APIUsageDefaultWarnFraction)
+}
+
+// Limit returns WeightLimit, or the documented limit of exchange; 0 when
+// the exchange reports no request weight. nil-safe.
+func (c *APIUsageConfig) Limit(exchange string) int {
+	if c != nil && c.WeightLimit > 0 {
+		return c.WeightLimit
+	}
+	if exchange == "binance" {
+		return BinanceWeightLimit
+	}
+	return 0
+}
+
+func (c *APIUsageConfig) validate() error {
+	if c.WarnFraction != "" {
+		f, err := decimal.NewFromString(c.WarnFraction)
+		if err != nil || !f.IsPositive() || f.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("warnFraction: must be a number in (0, 1], got %q", c.WarnFraction)
+		}
+	}
+	if c.WeightLimit < 0 {
+		return fmt.Errorf("weightLimit: must be positive")
+	}
+	return nil
+}
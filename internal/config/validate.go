@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Credential source types supported for exchange and notification secrets
+const (
+	CredentialTypeInline = "inline"
+	CredentialTypeEnv    = "env"
+	CredentialTypeSSM    = "ssm"
+)
+
+// CredentialTypes lists the valid CredentialSource.Type values
+var CredentialTypes = []string{CredentialTypeSSM, CredentialTypeEnv, CredentialTypeInline}
+
+// The validators below are shared by ParseDCAPayload and the payload
+// generator so both accept exactly the same values.
+
+// ValidateExchangeName checks the exchange name is present
+func ValidateExchangeName(name string) error {
+	if name == "" {
+		return fmt.Errorf("exchange name is required")
+	}
+	return nil
+}
+
+// ValidateSymbol checks the strategy symbol is present and matches
+// SymbolPattern
+func ValidateSymbol(symbol string) error {
+	if symbol == "" {
+		return fmt.Errorf("strategy symbol is required")
+	}
+	if !symbolPattern.MatchString(symbol) {
+		return fmt.Errorf("invalid strategy symbol %q (want a pair such as BTC-USDT)", symbol)
+	}
+	return nil
+}
+
+// ValidateOrderType checks an order type is one of OrderTypes, in any case
+func ValidateOrderType(orderType string) error {
+	if !slices.Contains(OrderTypes, strings.ToLower(orderType)) {
+		return fmt.Errorf("strategy orderType: unknown value %q (want %s)", orderType, strings.Join(OrderTypes, " or "))
+	}
+	return nil
+}
+
+// ValidateQuoteAmount checks the quote amount is present and a positive
+// decimal
+func ValidateQuoteAmount(amount string) error {
+	if amount == "" {
+		return fmt.Errorf("strategy quoteAmount is required")
+	}
+	qa, err := decimal.NewFromString(amount)
+	if err != nil {
+		return fmt.Errorf("invalid quoteAmount: %w", err)
+	}
+	if !qa.IsPositive() {
+		return fmt.Errorf("invalid quoteAmount %q: must be greater than zero", amount)
+	}
+	return nil
+}
+
+// ValidateBalanceThreshold checks an optional balance threshold is a
+// non-negative decimal
+func ValidateBalanceThreshold(threshold string) error {
+	if threshold == "" {
+		return nil
+	}
+	bt, err := decimal.NewFromString(threshold)
+	if err != nil {
+		return fmt.Errorf("invalid balanceThreshold: %w", err)
+	}
+	if bt.IsNegative() {
+		return fmt.Errorf("invalid balanceThreshold %q: must not be negative", threshold)
+	}
+	return nil
+}
+
+// ValidateCredentialType checks t is one of CredentialTypes
+func ValidateCredentialType(t string) error {
+	for _, valid := range CredentialTypes {
+		if t == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown credential type %q (want one of ssm, env, inline)", t)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expires returns ExpiresAt, zero when unset and valid once the payload
+// was parsed
+func (p *DCAPayload) Expires() time.Time {
+	t, _ := time.Parse(time.RFC3339, p.ExpiresAt)
+	return t
+}
+
+// ValidFrom returns NotBefore, zero when unset and valid once the payload
+// was parsed
+func (p *DCAPayload) ValidFrom() time.Time {
+	t, _ := time.Parse(time.RFC3339, p.NotBefore)
+	return t
+}
+
+// validateValidity checks that notBefore and expiresAt are RFC3339
+// timestamps, in that order when both are set
+func (p *DCAPayload) validateValidity() error {
+	for _, field := range []struct{ name, value string }{{"notBefore", p.NotBefore}, {"expiresAt", p.ExpiresAt}} {
+		if field.value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, field.value); err != nil {
+			return fmt.Errorf("%s: invalid timestamp %q (want RFC3339, e.g. 2025-05-01T00:00:00Z)", field.name, field.value)
+		}
+	}
+	if p.NotBefore != "" && p.ExpiresAt != "" && !p.ValidFrom().Before(p.Expires()) {
+		return fmt.Errorf("notBefore: must be before expiresAt")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExecutionWindowConfig limits when an invocation may still place an order,
+// so that a late delivery or a retried invocation does not buy long after
+// the intended slot. Either after and before bound the time of day in the
+// strategy timezone, or maxDelayMinutes bounds how long after the
+// schedule's latest fire time the invocation may arrive.
+type ExecutionWindowConfig struct {
+	After           string `json:"after,omitempty"`           // "08:30"; from this time on
+	Before          string `json:"before,omitempty"`          // "10:00"; up to this time, wrapping past midnight when earlier than after
+	MaxDelayMinutes int    `json:"maxDelayMinutes,omitempty"` // needs strategy.schedule
+}
+
+// TimeOfDayLayout is the format of execution window times
+const TimeOfDayLayout = "15:04"
+
+// ParseTimeOfDay parses a time of day in TimeOfDayLayout into the time
+// since midnight
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse(TimeOfDayLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q (want HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (s DCAStrategy) validateWindow() error {
+	w := s.ExecutionWindow
+	if w == nil {
+		return nil
+	}
+	bounded := w.After != "" || w.Before != ""
+	switch {
+	case w.MaxDelayMinutes < 0:
+		return fmt.Errorf("executionWindow.maxDelayMinutes: must not be negative")
+	case w.MaxDelayMinutes > 0 && bounded:
+		return fmt.Errorf("executionWindow: set either after and before or maxDelayMinutes")
+	case w.MaxDelayMinutes == 0 && !bounded:
+		return fmt.Errorf("executionWindow: set after, before or maxDelayMinutes")
+	}
+
+	if w.MaxDelayMinutes > 0 {
+		if s.Schedule == "" {
+			return fmt.Errorf("executionWindow.maxDelayMinutes: requires schedule")
+		}
+		if _, err := s.ParsedSchedule(); err != nil {
+			return fmt.Errorf("schedule: %w", err)
+		}
+		return nil
+	}
+	for _, bound := range []struct{ field, value string }{{"after", w.After}, {"before", w.Before}} {
+		if bound.value == "" {
+			continue
+		}
+		if _, err := ParseTimeOfDay(bound.value); err != nil {
+			return fmt.Errorf("executionWindow.%s: %w", bound.field, err)
+		}
+	}
+	if w.After == w.Before {
+		return fmt.Errorf("executionWindow: after and before are both %s, leaving no time to run", w.After)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// Tolerances of the checks
+var (
+	// PriceTolerance is how far a fill may be from the ticker price
+	PriceTolerance = decimal.RequireFromString("0.05")
+
+	// SpendTolerance is how far a quote-sized buy may overspend its amount,
+	// and how far balance changes may be from the fill
+	SpendTolerance = decimal.RequireFromString("0.01")
+)
+
+// CheckSymbolInfo checks the symbol info reported for symbol
+func CheckSymbolInfo(info exchange.SymbolInfo, symbol string) error {
+	base, quote, err := exchange.SplitSymbol(symbol)
+	if err != nil {
+		return err
+	}
+	if info.Symbol != symbol {
+		return fmt.Errorf("symbol %q, want %q", info.Symbol, symbol)
+	}
+	if asset.Canonical("", info.Base) != asset.Canonical("", base) || asset.Canonical("", info.Quote) != asset.Canonical("", quote) {
+		return fmt.Errorf("assets %s/%s, want %s/%s", info.Base, info.Quote, base, quote)
+	}
+	if info.Status != exchange.SymbolTrading {
+		return fmt.Errorf("status %s (%s), want %s", info.Status, info.RawStatus, exchange.SymbolTrading)
+	}
+	return nil
+}
+
+// CheckPrice checks a ticker price
+func CheckPrice(price decimal.Decimal) error {
+	if !price.IsPositive() {
+		return fmt.Errorf("price %s is not positive", price)
+	}
+	return nil
+}
+
+// CheckBalance checks a balance of asset adds up
+func CheckBalance(b exchange.Balance, code string) error {
+	if b.Free.IsNegative() || b.Locked.IsNegative() {
+		return fmt.Errorf("%s balance free %s, locked %s: negative", code, b.Free, b.Locked)
+	}
+	if !b.Total.Equal(b.Free.Add(b.Locked)) {
+		return fmt.Errorf("%s balance total %s is not free %s + locked %s", code, b.Total, b.Free, b.Locked)
+	}
+	return nil
+}
+
+// CheckMarketBuy checks the order a quote-sized market buy of amount
+// returned; price is the ticker price before it, zero when unknown
+func CheckMarketBuy(order *exchange.Order, symbol string, amount, price decimal.Decimal) error {
+	if order == nil {
+		return fmt.Errorf("no order returned")
+	}
+	if order.ID == "" {
+		return fmt.Errorf("order has no ID")
+	}
+	if order.Symbol != symbol || order.Side != "buy" {
+		return fmt.Errorf("order is a %s of %s, want a buy of %s", order.Side, order.Symbol, symbol)
+	}
+	if !order.Settled() {
+		// Quantity and price are provisional; the order status step checks them
+		return nil
+	}
+	if order.Status != exchange.OrderStatusFilled {
+		return fmt.Errorf("order %s", order.Status)
+	}
+	if !order.Quantity.IsPositive() || !order.Price.IsPositive() {
+		return fmt.Errorf("filled %s at %s", order.Quantity, order.Price)
+	}
+	if spent := order.Quantity.Mul(order.Price); spent.GreaterThan(amount.Mul(decimal.NewFromInt(1).Add(SpendTolerance))) {
+		return fmt.Errorf("spent %s of %s", spent, amount)
+	}
+	if price.IsPositive() && order.Price.Sub(price).Abs().GreaterThan(price.Mul(PriceTolerance)) {
+		return fmt.Errorf("filled at %s, ticker %s", order.Price, price)
+	}
+	return nil
+}
+
+// CheckOrderLookup checks an order looked up by the ID placed returned
+func CheckOrderLookup(got, placed *exchange.Order) error {
+	if got.ID != placed.ID || got.Symbol != placed.Symbol {
+		return fmt.Errorf("got order %s of %s, want %s of %s", got.ID, got.Symbol, placed.ID, placed.Symbol)
+	}
+	if got.Status != exchange.OrderStatusFilled {
+		return fmt.Errorf("order %s, want %s", got.Status, exchange.OrderStatusFilled)
+	}
+	if placed.Settled() && !got.Quantity.Equal(placed.Quantity) {
+		return fmt.Errorf("quantity %s, placing reported %s", got.Quantity, placed.Quantity)
+	}
+	return nil
+}
+
+// CheckBalanceChange checks the free balances of base and quote moved by
+// the fill of order, within SpendTolerance
+func CheckBalanceChange(order *exchange.Order, base, quote string, baseBefore, baseAfter, quoteBefore, quoteAfter decimal.Decimal) error {
+	received := order.Quantity
+	spent := order.Quantity.Mul(order.Price)
+	switch asset.Canonical("", order.FeeAsset) {
+	case asset.Canonical("", base):
+		received = received.Sub(order.FeeAmount)
+	case asset.Canonical("", quote):
+		spent = spent.Add(order.FeeAmount)
+	}
+	if !within(baseAfter.Sub(baseBefore), received) {
+		return fmt.Errorf("%s grew by %s, want %s", base, baseAfter.Sub(baseBefore), received)
+	}
+	if !within(quoteBefore.Sub(quoteAfter), spent) {
+		return fmt.Errorf("%s fell by %s, want %s", quote, quoteBefore.Sub(quoteAfter), spent)
+	}
+	return nil
+}
+
+// within reports whether got is want within SpendTolerance
+func within(got, want decimal.Decimal) bool {
+	return got.Sub(want).Abs().LessThanOrEqual(want.Abs().Mul(SpendTolerance))
+}
+
+// CheckOpenOrder checks that open lists the order placed, open
+func CheckOpenOrder(open []exchange.Order, placed *exchange.Order) error {
+	for _, o := range open {
+		if o.ID == placed.ID {
+			if o.Settled() {
+				return fmt.Errorf("order %s listed as %s", o.ID, o.Status)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("order %s not among %d open orders", placed.ID, len(open))
+}
+
+// CheckCanceled checks that open no longer lists the order canceled
+func CheckCanceled(open []exchange.Order, canceled *exchange.Order) error {
+	for _, o := range open {
+		if o.ID == canceled.ID {
+			return fmt.Errorf("order %s still open after canceling", o.ID)
+		}
+	}
+	return nil
+}
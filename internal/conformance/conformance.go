@@ -0,0 +1,302 @@
+// Package conformance is the scenario an exchange client must pass against
+// the real venue: preflight, symbol info, ticker, a tiny market buy, its
+// status, the balances before and after, and a limit order placed and
+// canceled. The verify-exchange command runs it against a sandbox before a
+// release, to catch API drift recorded fixtures cannot; the tests run it
+// against the mock with the same checks, so the two stay in sync.
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Results of a step
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip" // unsupported by the exchange, or an earlier step failed
+)
+
+// Steps of the scenario, in order
+const (
+	StepPreflight     = "preflight"
+	StepSymbolInfo    = "symbol info"
+	StepTicker        = "ticker"
+	StepBalanceBefore = "balance before"
+	StepMarketBuy     = "market buy"
+	StepOrderStatus   = "order status"
+	StepBalanceAfter  = "balance after"
+	StepLimitOrder    = "limit order"
+)
+
+// Step is the outcome of one step
+type Step struct {
+	Name   string
+	Result string
+	Detail string
+	Took   time.Duration
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Exchange string
+	Symbol   string
+	Steps    []Step
+}
+
+// Failed reports whether any step failed
+func (r *Report) Failed() bool {
+	return r.Count(Fail) > 0
+}
+
+// Count returns the number of steps with result
+func (r *Report) Count(result string) int {
+	n := 0
+	for _, s := range r.Steps {
+		if s.Result == result {
+			n++
+		}
+	}
+	return n
+}
+
+// Step returns the named step, or nil before it ran
+func (r *Report) Step(name string) *Step {
+	for i := range r.Steps {
+		if r.Steps[i].Name == name {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// WriteTable writes the steps as a table, then a count of their results
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tRESULT\tTOOK\tDETAIL")
+	for _, s := range r.Steps {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, strings.ToUpper(s.Result), s.Took.Round(time.Millisecond), s.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s %s: %d passed, %d failed, %d skipped\n", r.Exchange, r.Symbol, r.Count(Pass), r.Count(Fail), r.Count(Skip))
+	return err
+}
+
+// errSkip marks a step the exchange does not support
+type errSkip struct{ reason string }
+
+func (e errSkip) Error() string { return e.reason }
+
+// skip returns the error of a step skipped for reason
+func skip(format string, args ...any) error {
+	return errSkip{fmt.Sprintf(format, args...)}
+}
+
+// Scenario buys Amount of Symbol's quote asset; keep it just above the
+// exchange's minimum order value
+type Scenario struct {
+	Symbol string
+	Amount decimal.Decimal
+
+	// LimitDiscount places the limit order this far below the market, so it
+	// never fills before it is canceled; default 0.2
+	LimitDiscount decimal.Decimal
+}
+
+// state is what the steps learn for the later ones
+type state struct {
+	base, quote   string
+	price         decimal.Decimal
+	before, after struct{ base, quote exchange.Balance }
+	order         *exchange.Order
+}
+
+// Run runs the scenario against exc, named name in the report. A step that
+// needs an earlier one that failed is skipped; Run never stops early, so
+// the report lists every step.
+func (s Scenario) Run(ctx context.Context, name string, exc exchange.Exchange) *Report {
+	report := &Report{Exchange: name, Symbol: s.Symbol}
+	var st state
+	step := func(stepName string, needs []string, fn func() (string, error)) {
+		for _, need := range needs {
+			if prev := report.Step(need); prev == nil || prev.Result != Pass {
+				report.Steps = append(report.Steps, Step{Name: stepName, Result: Skip, Detail: "needs " + need})
+				return
+			}
+		}
+		start := time.Now()
+		detail, err := fn()
+		result := Pass
+		var skipped errSkip
+		switch {
+		case errors.As(err, &skipped):
+			result, detail = Skip, skipped.reason
+		case err != nil:
+			result, detail = Fail, err.Error()
+		}
+		report.Steps = append(report.Steps, Step{Name: stepName, Result: result, Detail: detail, Took: time.Since(start)})
+	}
+
+	step(StepPreflight, nil, func() (string, error) {
+		base, quote, err := exchange.SplitSymbol(s.Symbol)
+		if err != nil {
+			return "", err
+		}
+		st.base, st.quote = base, quote
+		if err := exchange.CheckTradable(ctx, exc, s.Symbol); err != nil {
+			return "", err
+		}
+		return s.Symbol + " is tradable", nil
+	})
+	step(StepSymbolInfo, []string{StepPreflight}, func() (string, error) {
+		infoer, ok := exc.(exchange.SymbolInfoer)
+		if !ok {
+			return "", skip("the exchange reports no symbol info")
+		}
+		info, err := infoer.GetSymbolInfo(ctx, s.Symbol)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/%s %s", info.Base, info.Quote, info.RawStatus), CheckSymbolInfo(info, s.Symbol)
+	})
+	step(StepTicker, []string{StepPreflight}, func() (string, error) {
+		ticker, ok := exc.(exchange.PriceTicker)
+		if !ok {
+			return "", skip("the exchange reports no prices")
+		}
+		price, err := ticker.LastPrice(ctx, s.Symbol)
+		if err != nil {
+			return "", err
+		}
+		st.price = price
+		return price.String(), CheckPrice(price)
+	})
+	step(StepBalanceBefore, []string{StepPreflight}, func() (string, error) {
+		return s.balances(ctx, exc, st.base, st.quote, &st.before.base, &st.before.quote)
+	})
+	step(StepMarketBuy, []string{StepBalanceBefore}, func() (string, error) {
+		if st.before.quote.Free.LessThan(s.Amount) {
+			return "", fmt.Errorf("%s %s free, %s needed", st.before.quote.Free, st.quote, s.Amount)
+		}
+		order, err := exchange.MarketBuy(run.WithID(ctx, "verify"), exc, s.Symbol, exchange.QuoteSize(s.Amount))
+		if err != nil {
+			return "", err
+		}
+		st.order = order
+		return fmt.Sprintf("order %s %s %s @ %s", order.ID, order.Status, order.Quantity, order.Price), CheckMarketBuy(order, s.Symbol, s.Amount, st.price)
+	})
+	step(StepOrderStatus, []string{StepMarketBuy}, func() (string, error) {
+		getter, ok := exc.(exchange.OrderGetter)
+		if !ok {
+			return "", skip("the exchange cannot look orders up")
+		}
+		got, err := getter.GetOrder(ctx, s.Symbol, st.order.ID)
+		if err != nil {
+			return "", err
+		}
+		if !st.order.Settled() {
+			st.order = got
+		}
+		return fmt.Sprintf("%s %s @ %s", got.Status, got.Quantity, got.Price), CheckOrderLookup(got, st.order)
+	})
+	step(StepBalanceAfter, []string{StepMarketBuy}, func() (string, error) {
+		detail, err := s.balances(ctx, exc, st.base, st.quote, &st.after.base, &st.after.quote)
+		if err != nil {
+			return detail, err
+		}
+		if !st.order.Settled() {
+			return detail, fmt.Errorf("order %s still %s", st.order.ID, st.order.Status)
+		}
+		return detail, CheckBalanceChange(st.order, st.base, st.quote, st.before.base.Free, st.after.base.Free, st.before.quote.Free, st.after.quote.Free)
+	})
+	step(StepLimitOrder, []string{StepTicker, StepBalanceAfter}, func() (string, error) {
+		return s.limitOrder(ctx, exc, &st)
+	})
+	return report
+}
+
+// balances reads the balances of base and quote into b and q
+func (s Scenario) balances(ctx context.Context, exc exchange.Exchange, base, quote string, b, q *exchange.Balance) (string, error) {
+	var err error
+	if *b, err = exc.GetBalanceDetail(ctx, base); err != nil {
+		return "", err
+	}
+	if *q, err = exc.GetBalanceDetail(ctx, quote); err != nil {
+		return "", err
+	}
+	detail := fmt.Sprintf("%s %s, %s %s free", b.Free, base, q.Free, quote)
+	return detail, errors.Join(CheckBalance(*b, base), CheckBalance(*q, quote))
+}
+
+// limitOrder places a stop-limit sell of the bought quantity, the only
+// limit order the exchange interface has, well below the market, checks it
+// is open, cancels it and checks it is gone
+func (s Scenario) limitOrder(ctx context.Context, exc exchange.Exchange, st *state) (string, error) {
+	step := exchange.DefaultLotStep
+	if sizer, ok := exc.(exchange.LotSizer); ok {
+		lot, err := sizer.LotStep(ctx, s.Symbol)
+		if err != nil {
+			return "", err
+		}
+		step = lot
+	}
+	quantity := st.after.base.Free.Sub(st.before.base.Free)
+	quantity = quantity.Div(step).Floor().Mul(step)
+	if !quantity.IsPositive() {
+		return "", skip("bought less than one lot")
+	}
+	discount := s.LimitDiscount
+	if discount.IsZero() {
+		discount = decimal.RequireFromString("0.2")
+	}
+	one := decimal.NewFromInt(1)
+	stop := significant(st.price.Mul(one.Sub(discount)))
+	limit := significant(st.price.Mul(one.Sub(discount).Sub(decimal.RequireFromString("0.01"))))
+
+	placed, err := exc.PlaceStopLossOrder(ctx, s.Symbol, quantity, stop, limit, run.ClientOrderID(run.WithID(ctx, "verify"), "verify"))
+	if err != nil {
+		return "", fmt.Errorf("place: %w", err)
+	}
+	detail := fmt.Sprintf("order %s: %s below %s", placed.ID, quantity, stop)
+	open, err := exc.OpenOrders(ctx, s.Symbol)
+	if err == nil {
+		err = CheckOpenOrder(open, placed)
+	}
+	// Cancel whatever the listing said, so no order is left behind
+	if cancelErr := exc.CancelOrder(ctx, s.Symbol, placed.ID); cancelErr != nil {
+		return detail, errors.Join(err, fmt.Errorf("cancel: %w", cancelErr))
+	}
+	if err != nil {
+		return detail, err
+	}
+	if open, err = exc.OpenOrders(ctx, s.Symbol); err != nil {
+		return detail, err
+	}
+	return detail + ", canceled", CheckCanceled(open, placed)
+}
+
+// significant rounds a price down to five significant digits, within the
+// tick size of the usual pairs
+func significant(price decimal.Decimal) decimal.Decimal {
+	places := int32(5)
+	if whole := price.Truncate(0); whole.IsPositive() {
+		places -= int32(len(whole.String()))
+	} else {
+		// Count the zeros right after the point as well
+		fraction := strings.TrimPrefix(price.String(), "0.")
+		places += int32(len(fraction) - len(strings.TrimLeft(fraction, "0")))
+	}
+	return price.RoundDown(places)
+}
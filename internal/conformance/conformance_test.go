@@ -0,0 +1,191 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// ledger is the mock with balances that market buys move, as on a sandbox
+type ledger struct {
+	*exchange.MockExchange
+}
+
+func newLedger() ledger {
+	return ledger{&exchange.MockExchange{Balances: map[string]exchange.Balance{
+		"BTC":  exchange.NewBalance("BTC", d("0.5"), decimal.Zero),
+		"USDT": exchange.NewBalance("USDT", d("1000"), decimal.Zero),
+	}}}
+}
+
+func (l ledger) PlaceMarketBuyOrder(ctx context.Context, symbol string, size exchange.OrderSize) (*exchange.Order, error) {
+	order, err := l.MockExchange.PlaceMarketBuyOrder(ctx, symbol, size)
+	if err != nil || !order.Settled() {
+		return order, err
+	}
+	base, quote := l.Balances["BTC"], l.Balances["USDT"]
+	l.Balances["BTC"] = exchange.NewBalance("BTC", base.Free.Add(order.Quantity), base.Locked)
+	l.Balances["USDT"] = exchange.NewBalance("USDT", quote.Free.Sub(order.Quantity.Mul(order.Price)), quote.Locked)
+	return order, nil
+}
+
+// basic hides every optional capability of the mock
+type basic struct {
+	exchange.Exchange
+}
+
+var scenario = Scenario{Symbol: "BTC-USDT", Amount: d("10")}
+
+func results(r *Report) map[string]string {
+	got := map[string]string{}
+	for _, s := range r.Steps {
+		got[s.Name] = s.Result
+	}
+	return got
+}
+
+func TestScenario_Mock(t *testing.T) {
+	report := scenario.Run(context.Background(), "mock", newLedger())
+	if len(report.Steps) != 8 {
+		t.Fatalf("ran %d steps, want 8", len(report.Steps))
+	}
+	for _, s := range report.Steps {
+		if s.Result != Pass {
+			t.Errorf("%s: %s (%s), want pass", s.Name, s.Result, s.Detail)
+		}
+	}
+	if report.Failed() {
+		t.Error("Failed() = true")
+	}
+}
+
+func TestScenario_Settlement(t *testing.T) {
+	exc := newLedger()
+	exc.Settlement = []string{"open", "filled"}
+	report := scenario.Run(context.Background(), "mock", exc)
+	got := results(report)
+	if got[StepMarketBuy] != Pass || got[StepOrderStatus] != Pass {
+		t.Errorf("results = %v, want the open buy followed up", got)
+	}
+	// The ledger only moves balances for fills it sees when placing
+	if got[StepBalanceAfter] != Fail {
+		t.Errorf("balance after = %s, want a failure for balances that did not move", got[StepBalanceAfter])
+	}
+	if got[StepLimitOrder] != Skip {
+		t.Errorf("limit order = %s, want it skipped after the balance failure", got[StepLimitOrder])
+	}
+}
+
+func TestScenario_Failures(t *testing.T) {
+	halted := newLedger()
+	halted.Halted = []string{"BTC-USDT"}
+	poor := newLedger()
+	poor.Balances["USDT"] = exchange.NewBalance("USDT", d("3"), decimal.Zero)
+
+	tests := []struct {
+		name string
+		exc  exchange.Exchange
+		want map[string]string
+	}{
+		{
+			name: "halted", exc: halted,
+			want: map[string]string{StepPreflight: Fail, StepSymbolInfo: Skip, StepMarketBuy: Skip, StepLimitOrder: Skip},
+		},
+		{
+			name: "insufficient balance", exc: poor,
+			want: map[string]string{StepBalanceBefore: Pass, StepMarketBuy: Fail, StepOrderStatus: Skip, StepBalanceAfter: Skip},
+		},
+		{
+			name: "no optional capabilities", exc: basic{newLedger()},
+			want: map[string]string{StepSymbolInfo: Skip, StepTicker: Skip, StepMarketBuy: Pass, StepOrderStatus: Skip, StepBalanceAfter: Pass, StepLimitOrder: Skip},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := scenario.Run(context.Background(), "mock", tt.exc)
+			got := results(report)
+			for step, want := range tt.want {
+				if got[step] != want {
+					t.Errorf("%s = %s (%s), want %s", step, got[step], report.Step(step).Detail, want)
+				}
+			}
+			if report.Failed() != (report.Count(Fail) > 0) {
+				t.Error("Failed() disagrees with the results")
+			}
+		})
+	}
+}
+
+func TestReport_WriteTable(t *testing.T) {
+	report := &Report{Exchange: "binance", Symbol: "BTC-USDT", Steps: []Step{
+		{Name: StepPreflight, Result: Pass, Detail: "BTC-USDT is tradable"},
+		{Name: StepMarketBuy, Result: Fail, Detail: "spent 10.5 of 10"},
+		{Name: StepOrderStatus, Result: Skip, Detail: "needs market buy"},
+	}}
+	var buf bytes.Buffer
+	if err := report.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"STEP", "market buy    FAIL", "spent 10.5 of 10", "binance BTC-USDT: 1 passed, 1 failed, 1 skipped"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCheckMarketBuy(t *testing.T) {
+	filled := exchange.Order{ID: "1", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.0002"), Price: d("50000"), Status: "filled"}
+	tests := []struct {
+		name  string
+		edit  func(o *exchange.Order)
+		price string
+		want  string
+	}{
+		{name: "filled", edit: func(o *exchange.Order) {}, price: "50100"},
+		{name: "provisional", edit: func(o *exchange.Order) { o.Status, o.Quantity = "open", decimal.Zero }, price: "50000"},
+		{name: "no ID", edit: func(o *exchange.Order) { o.ID = "" }, want: "no ID"},
+		{name: "sell", edit: func(o *exchange.Order) { o.Side = "sell" }, want: "a sell of BTC-USDT"},
+		{name: "overspent", edit: func(o *exchange.Order) { o.Quantity = d("0.00021") }, want: "spent 10.5 of 10"},
+		{name: "far from ticker", edit: func(o *exchange.Order) {}, price: "60000", want: "ticker 60000"},
+		{name: "rejected", edit: func(o *exchange.Order) { o.Status = "rejected" }, want: "order rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := filled
+			tt.edit(&order)
+			price := decimal.Zero
+			if tt.price != "" {
+				price = d(tt.price)
+			}
+			err := CheckMarketBuy(&order, "BTC-USDT", d("10"), price)
+			if (err == nil) != (tt.want == "") || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("CheckMarketBuy() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBalanceChange(t *testing.T) {
+	order := &exchange.Order{Quantity: d("0.0002"), Price: d("50000"), FeeAmount: d("0.0000002"), FeeAsset: "BTC"}
+	if err := CheckBalanceChange(order, "BTC", "USDT", d("0.5"), d("0.5001998"), d("1000"), d("990")); err != nil {
+		t.Errorf("CheckBalanceChange() = %v, want the base fee allowed for", err)
+	}
+	if err := CheckBalanceChange(order, "BTC", "USDT", d("0.5"), d("0.5"), d("1000"), d("990")); err == nil || !strings.Contains(err.Error(), "BTC grew by 0") {
+		t.Errorf("CheckBalanceChange() = %v, want the unmoved base caught", err)
+	}
+}
+
+func TestSignificant(t *testing.T) {
+	for in, want := range map[string]string{"49600.987": "49600", "2549.944": "2549.9", "0.000123456": "0.00012345", "0.8": "0.8"} {
+		if got := significant(d(in)); !got.Equal(d(want)) {
+			t.Errorf("significant(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+// Package cost estimates what a run costs: the AWS requests it made, the
+// Lambda duration and memory it was billed for, and the exchange fees of
+// its orders. AWS prices come from an embedded table of on-demand
+// us-east-1 prices, which flags.costPrices overrides. The figures are for
+// a rough per-run and per-month overhead, not a bill: free tiers, storage
+// and data transfer are left out.
+package cost
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// Keys of the Lambda prices; every other key is the SigV4 name of an AWS
+// service, priced per request
+const (
+	KeyLambdaGBSecond = "lambda.gbSecond"
+	KeyLambdaRequest  = "lambda.request"
+)
+
+// MemoryEnv is set by Lambda to the function's memory in MB
+const MemoryEnv = "AWS_LAMBDA_FUNCTION_MEMORY_SIZE"
+
+//go:embed prices.json
+var pricesJSON []byte
+
+// Table is a versioned table of USD prices per unit
+type Table struct {
+	Version string                     `json:"version"`
+	Prices  map[string]decimal.Decimal `json:"prices"`
+}
+
+var embedded = sync.OnceValue(func() Table {
+	var t Table
+	if err := json.Unmarshal(pricesJSON, &t); err != nil {
+		panic(fmt.Sprintf("cost: invalid embedded price table: %v", err))
+	}
+	return t
+})
+
+// Default returns the embedded price table
+func Default() Table {
+	t := embedded()
+	t.Prices = maps.Clone(t.Prices)
+	return t
+}
+
+// With returns a copy of t with the prices of overrides, as validated by
+// flags.costPrices. The copy's version gets a "+custom" suffix, so an
+// estimate tells which table it was made with.
+func (t Table) With(overrides map[string]string) (Table, error) {
+	if len(overrides) == 0 {
+		return t, nil
+	}
+	c := Table{Version: t.Version + "+custom", Prices: maps.Clone(t.Prices)}
+	if c.Prices == nil {
+		c.Prices = map[string]decimal.Decimal{}
+	}
+	for key, price := range overrides {
+		d, err := decimal.NewFromString(price)
+		if err != nil {
+			return Table{}, fmt.Errorf("%s: invalid price %q", key, price)
+		}
+		c.Prices[key] = d
+	}
+	return c, nil
+}
+
+// Price returns the price of one unit of key, zero for keys t lacks
+func (t Table) Price(key string) decimal.Decimal {
+	return t.Prices[key]
+}
+
+// Usage is what a run consumed
+type Usage struct {
+	Calls    map[string]int // AWS requests by service
+	MemoryMB int            // zero outside Lambda
+	Duration time.Duration  // billed Lambda duration
+	Orders   []*exchange.Order
+}
+
+// LambdaMemory returns the function's memory in MB, zero outside Lambda
+func LambdaMemory() int {
+	mb, _ := strconv.Atoi(os.Getenv(MemoryEnv))
+	return mb
+}
+
+// Fee is an amount of trading fees in one asset
+type Fee struct {
+	Asset  string          `json:"asset"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+// Estimate is the estimated cost of one or more runs
+type Estimate struct {
+	PriceVersion string          `json:"priceVersion"` // Table.Version the AWS cost was priced with
+	AWS          decimal.Decimal `json:"aws"`          // in USD
+
+	Calls           map[string]int  `json:"calls,omitempty"`          // AWS requests by service
+	LambdaGBSeconds decimal.Decimal `json:"lambdaGbSeconds,omitzero"` // billed memory times duration
+
+	Fees []Fee `json:"fees,omitempty"` // exchange trading fees by asset
+}
+
+// Estimate prices u. Lambda is billed per started millisecond, plus the
+// request; services missing from t cost nothing but are still counted.
+func (t Table) Estimate(u Usage) *Estimate {
+	e := &Estimate{PriceVersion: t.Version, Calls: maps.Clone(u.Calls)}
+	for service, n := range u.Calls {
+		e.AWS = e.AWS.Add(t.Price(service).Mul(decimal.NewFromInt(int64(n))))
+	}
+	if u.MemoryMB > 0 {
+		ms := (u.Duration + time.Millisecond - 1).Milliseconds()
+		e.LambdaGBSeconds = decimal.NewFromInt(int64(u.MemoryMB) * ms).Div(decimal.NewFromInt(1024 * 1000))
+		e.AWS = e.AWS.Add(e.LambdaGBSeconds.Mul(t.Price(KeyLambdaGBSecond))).Add(t.Price(KeyLambdaRequest))
+	}
+	for _, o := range u.Orders {
+		e.addOrder(o)
+	}
+	return e
+}
+
+// addOrder adds the fees of o, or of its legs for a routed buy, whose own
+// fee is folded into its price
+func (e *Estimate) addOrder(o *exchange.Order) {
+	if o == nil {
+		return
+	}
+	if len(o.Legs) > 0 {
+		for i := range o.Legs {
+			e.addOrder(&o.Legs[i])
+		}
+		return
+	}
+	e.addFee(Fee{Asset: o.FeeAsset, Amount: o.FeeAmount})
+}
+
+func (e *Estimate) addFee(f Fee) {
+	if !f.Amount.IsPositive() {
+		return
+	}
+	code := asset.Canonical("", f.Asset)
+	for i := range e.Fees {
+		if e.Fees[i].Asset == code {
+			e.Fees[i].Amount = e.Fees[i].Amount.Add(f.Amount)
+			return
+		}
+	}
+	e.Fees = append(e.Fees, Fee{Asset: code, Amount: f.Amount})
+}
+
+// Add adds o to e. Estimates priced with different tables keep the
+// versions of both, e.g. "2026-01,2026-10".
+func (e *Estimate) Add(o *Estimate) {
+	if o == nil {
+		return
+	}
+	e.AWS = e.AWS.Add(o.AWS)
+	e.LambdaGBSeconds = e.LambdaGBSeconds.Add(o.LambdaGBSeconds)
+	for service, n := range o.Calls {
+		if e.Calls == nil {
+			e.Calls = map[string]int{}
+		}
+		e.Calls[service] += n
+	}
+	for _, f := range o.Fees {
+		e.addFee(f)
+	}
+	versions := strings.Split(e.PriceVersion, ",")
+	if e.PriceVersion == "" {
+		versions = nil
+	}
+	if o.PriceVersion != "" {
+		for _, v := range strings.Split(o.PriceVersion, ",") {
+			if !slices.Contains(versions, v) {
+				versions = append(versions, v)
+			}
+		}
+	}
+	slices.Sort(versions)
+	e.PriceVersion = strings.Join(versions, ",")
+}
+
+// String renders the estimate, e.g. "$0.0021 AWS + 0.01 USDT fees"
+func (e *Estimate) String() string {
+	s := "$" + usd(e.AWS) + " AWS"
+	if len(e.Fees) == 0 {
+		return s
+	}
+	fees := make([]string, len(e.Fees))
+	for i, f := range e.Fees {
+		fees[i] = f.Amount.String() + " " + f.Asset
+	}
+	return s + " + " + strings.Join(fees, " + ") + " fees"
+}
+
+// usd writes a small USD amount with at least four decimals and two
+// significant digits, so a fraction of a cent still shows
+func usd(d decimal.Decimal) string {
+	places := int32(4)
+	for !d.IsZero() && places < 10 && d.Abs().LessThan(decimal.New(1, -places+1)) {
+		places++
+	}
+	return d.Round(places).StringFixed(places)
+}
+
+// Summary is the cost of the runs of a period, in total and on average
+type Summary struct {
+	Period string    `json:"period,omitempty"` // e.g. "October 2026"
+	Runs   int       `json:"runs"`
+	Total  *Estimate `json:"total"`
+	PerRun *Estimate `json:"perRun"` // Total over Runs; AWS and fees only
+}
+
+// Summarize adds up the estimates of the runs of period, skipping runs
+// that recorded none; nil when none did
+func Summarize(period string, estimates []*Estimate) *Summary {
+	s := &Summary{Period: period, Total: &Estimate{}}
+	for _, e := range estimates {
+		if e == nil {
+			continue
+		}
+		s.Runs++
+		s.Total.Add(e)
+	}
+	if s.Runs == 0 {
+		return nil
+	}
+	n := decimal.NewFromInt(int64(s.Runs))
+	s.PerRun = &Estimate{PriceVersion: s.Total.PriceVersion, AWS: s.Total.AWS.Div(n).Round(10)}
+	for _, f := range s.Total.Fees {
+		s.PerRun.Fees = append(s.PerRun.Fees, Fee{Asset: f.Asset, Amount: f.Amount.Div(n).Round(8)})
+	}
+	return s
+}
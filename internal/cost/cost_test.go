@@ -0,0 +1,154 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestDefault(t *testing.T) {
+	table := Default()
+	if table.Version == "" {
+		t.Error("embedded table has no version")
+	}
+	for _, key := range []string{KeyLambdaGBSecond, KeyLambdaRequest, "ssm", "dynamodb", "s3", "kms", "events"} {
+		if p, ok := table.Prices[key]; !ok || p.IsNegative() {
+			t.Errorf("price of %s = %s, %v; want a price", key, p, ok)
+		}
+	}
+	// Callers get their own copy
+	table.Prices["ssm"] = d("1")
+	if Default().Price("ssm").Equal(d("1")) {
+		t.Error("Default() shares its prices between callers")
+	}
+}
+
+func TestTable_With(t *testing.T) {
+	base := Table{Version: "2026-10", Prices: map[string]decimal.Decimal{"ssm": d("0.000005"), "s3": d("0.000005")}}
+
+	same, err := base.With(nil)
+	if err != nil || same.Version != "2026-10" {
+		t.Errorf("With(nil) = %+v, %v; want the table unchanged", same, err)
+	}
+
+	custom, err := base.With(map[string]string{"ssm": "0", "lambda.gbSecond": "0.0000133334"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if custom.Version != "2026-10+custom" {
+		t.Errorf("Version = %q, want the override marked", custom.Version)
+	}
+	if !custom.Price("ssm").IsZero() || !custom.Price(KeyLambdaGBSecond).Equal(d("0.0000133334")) || !custom.Price("s3").Equal(d("0.000005")) {
+		t.Errorf("Prices = %v, want the overrides over the table", custom.Prices)
+	}
+	if !base.Price("ssm").Equal(d("0.000005")) {
+		t.Error("With() changed the table it was called on")
+	}
+
+	if _, err := base.With(map[string]string{"ssm": "cheap"}); err == nil {
+		t.Error("With() accepted an invalid price")
+	}
+}
+
+func TestTable_Estimate(t *testing.T) {
+	table := Table{Version: "v1", Prices: map[string]decimal.Decimal{
+		KeyLambdaGBSecond: d("0.0000166667"),
+		KeyLambdaRequest:  d("0.0000002"),
+		"ssm":             d("0.000005"),
+		"dynamodb":        d("0.000000625"),
+	}}
+	routed := &exchange.Order{Legs: []exchange.Order{
+		{FeeAmount: d("0.005"), FeeAsset: "USDT"},
+		{FeeAmount: d("0.00000002"), FeeAsset: "btc"},
+	}}
+	e := table.Estimate(Usage{
+		Calls:    map[string]int{"ssm": 3, "dynamodb": 4, "sts": 1},
+		MemoryMB: 256,
+		Duration: 1999500 * time.Microsecond, // billed as 2000ms
+		Orders:   []*exchange.Order{{FeeAmount: d("0.01"), FeeAsset: "USDT"}, routed, nil},
+	})
+
+	if !e.LambdaGBSeconds.Equal(d("0.5")) {
+		t.Errorf("LambdaGBSeconds = %s, want 0.5", e.LambdaGBSeconds)
+	}
+	// 3 SSM, 4 DynamoDB, 0.5 GB-s and the request; STS is unpriced
+	want := d("0.000015").Add(d("0.0000025")).Add(d("0.00000833335")).Add(d("0.0000002"))
+	if !e.AWS.Equal(want) {
+		t.Errorf("AWS = %s, want %s", e.AWS, want)
+	}
+	if e.Calls["sts"] != 1 || e.PriceVersion != "v1" {
+		t.Errorf("Calls = %v, PriceVersion = %q; want every call counted with the version", e.Calls, e.PriceVersion)
+	}
+	if len(e.Fees) != 2 || !e.Fees[0].Amount.Equal(d("0.015")) || e.Fees[1].Asset != "BTC" {
+		t.Errorf("Fees = %+v, want USDT fees summed and the routed legs' BTC fee", e.Fees)
+	}
+
+	// Outside Lambda only the requests cost
+	local := table.Estimate(Usage{Calls: map[string]int{"ssm": 1}, Duration: time.Second})
+	if !local.AWS.Equal(d("0.000005")) || !local.LambdaGBSeconds.IsZero() {
+		t.Errorf("local estimate = %+v, want no Lambda cost", local)
+	}
+}
+
+func TestEstimate_String(t *testing.T) {
+	tests := []struct {
+		e    Estimate
+		want string
+	}{
+		{Estimate{AWS: d("0.00213")}, "$0.0021 AWS"},
+		{Estimate{AWS: d("0.0021"), Fees: []Fee{{Asset: "USDT", Amount: d("0.01")}}}, "$0.0021 AWS + 0.01 USDT fees"},
+		{Estimate{AWS: d("0.0000084"), Fees: []Fee{{Asset: "BNB", Amount: d("0.00002")}, {Asset: "USDT", Amount: d("0.01")}}}, "$0.0000084 AWS + 0.00002 BNB + 0.01 USDT fees"},
+		{Estimate{}, "$0.0000 AWS"},
+		{Estimate{AWS: d("1.5")}, "$1.5000 AWS"},
+	}
+	for _, tt := range tests {
+		if got := tt.e.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestEstimate_Add(t *testing.T) {
+	e := &Estimate{PriceVersion: "2026-10", AWS: d("0.002"), Calls: map[string]int{"ssm": 2}}
+	e.Add(&Estimate{PriceVersion: "2026-01", AWS: d("0.001"), Calls: map[string]int{"ssm": 1, "s3": 1}, Fees: []Fee{{Asset: "USDT", Amount: d("0.01")}}})
+	e.Add(&Estimate{PriceVersion: "2026-10", Fees: []Fee{{Asset: "USDT", Amount: d("0.02")}}})
+	e.Add(nil)
+
+	if !e.AWS.Equal(d("0.003")) || e.Calls["ssm"] != 3 || e.Calls["s3"] != 1 {
+		t.Errorf("sum = %+v, want AWS and calls added", e)
+	}
+	if len(e.Fees) != 1 || !e.Fees[0].Amount.Equal(d("0.03")) {
+		t.Errorf("Fees = %+v, want 0.03 USDT", e.Fees)
+	}
+	if e.PriceVersion != "2026-01,2026-10" {
+		t.Errorf("PriceVersion = %q, want both tables named", e.PriceVersion)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	if s := Summarize("October 2026", []*Estimate{nil, nil}); s != nil {
+		t.Errorf("Summarize() of runs without estimates = %+v, want nil", s)
+	}
+
+	runs := []*Estimate{
+		{PriceVersion: "2026-10", AWS: d("0.002"), Fees: []Fee{{Asset: "USDT", Amount: d("0.01")}}},
+		nil, // recorded before costs were estimated
+		{PriceVersion: "2026-10", AWS: d("0.001"), Fees: []Fee{{Asset: "USDT", Amount: d("0.01")}}},
+		{PriceVersion: "2026-10", AWS: d("0.001")},
+	}
+	s := Summarize("October 2026", runs)
+	if s.Runs != 3 || !s.Total.AWS.Equal(d("0.004")) || !s.Total.Fees[0].Amount.Equal(d("0.02")) {
+		t.Errorf("Total = %d runs, %+v; want the three estimates added", s.Runs, s.Total)
+	}
+	if got := s.PerRun.String(); got != "$0.0013 AWS + 0.00666667 USDT fees" {
+		t.Errorf("PerRun = %q, want the average", got)
+	}
+	if !strings.Contains(s.Period, "October") {
+		t.Errorf("Period = %q", s.Period)
+	}
+}
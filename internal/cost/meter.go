@@ -0,0 +1,71 @@
+package cost
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Meter counts the AWS requests of an invocation by service
+type Meter struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewMeter returns a meter with nothing counted
+func NewMeter() *Meter {
+	return &Meter{calls: map[string]int{}}
+}
+
+type meterKey struct{}
+
+// WithMeter returns a copy of ctx whose AWS requests are counted by m
+func WithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// MeterFrom returns the meter stored in ctx, or nil when requests are not
+// counted
+func MeterFrom(ctx context.Context) *Meter {
+	m, _ := ctx.Value(meterKey{}).(*Meter)
+	return m
+}
+
+// Add counts a request to service; nil-safe
+func (m *Meter) Add(service string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.calls[service]++
+	m.mu.Unlock()
+}
+
+// Calls returns the requests counted by service; nil-safe
+func (m *Meter) Calls() map[string]int {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.calls)
+}
+
+// Service returns the AWS service a signed request is for, from the
+// credential scope of its SigV4 signature, e.g. "ssm" for
+// "Credential=AKID/20261016/eu-central-1/ssm/aws4_request". Unsigned
+// requests fall back to the first label of the host.
+func Service(req *http.Request) string {
+	credential := req.URL.Query().Get("X-Amz-Credential")
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		_, credential, _ = strings.Cut(auth, "Credential=")
+		credential, _, _ = strings.Cut(credential, ",")
+	}
+	if scope := strings.Split(credential, "/"); len(scope) == 5 {
+		return scope[3]
+	}
+	host, _, _ := strings.Cut(req.URL.Hostname(), ".")
+	return host
+}
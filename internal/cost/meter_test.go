@@ -0,0 +1,58 @@
+package cost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestService(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		auth string
+		want string
+	}{
+		{
+			name: "signed",
+			url:  "https://ssm.eu-central-1.amazonaws.com/",
+			auth: "AWS4-HMAC-SHA256 Credential=AKID/20261016/eu-central-1/ssm/aws4_request, SignedHeaders=host;x-amz-date, Signature=abc",
+			want: "ssm",
+		},
+		{
+			name: "virtual-hosted S3",
+			url:  "https://my-bucket.s3.eu-central-1.amazonaws.com/history.json",
+			auth: "AWS4-HMAC-SHA256 Credential=AKID/20261016/eu-central-1/s3/aws4_request, SignedHeaders=host, Signature=abc",
+			want: "s3",
+		},
+		{
+			name: "presigned",
+			url:  "https://my-bucket.s3.amazonaws.com/key?X-Amz-Credential=AKID%2F20261016%2Fus-east-1%2Fs3%2Faws4_request",
+			want: "s3",
+		},
+		{name: "unsigned", url: "https://events.us-east-1.amazonaws.com/", want: "events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if got := Service(req); got != tt.want {
+				t.Errorf("Service() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeter_NilSafe(t *testing.T) {
+	var m *Meter
+	m.Add("ssm")
+	if m.Calls() != nil {
+		t.Error("nil meter counted a call")
+	}
+	if MeterFrom(context.Background()) != nil {
+		t.Error("MeterFrom() of a plain context is not nil")
+	}
+}
@@ -0,0 +1,34 @@
+package cost
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// HTTPClient counts every request it sends, retries included, with the
+// meter of the request's context, then sends it with Base
+type HTTPClient struct {
+	Base aws.HTTPClient
+}
+
+// Do counts and sends req
+func (c HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	MeterFrom(req.Context()).Add(Service(req))
+	return c.Base.Do(req)
+}
+
+// Instrument makes the clients built from cfg count their requests. The
+// SDK clients and the bot's own DynamoDB and Scheduler clients all send
+// through cfg.HTTPClient, so one call covers a shared config.
+func Instrument(cfg *aws.Config) {
+	if _, ok := cfg.HTTPClient.(HTTPClient); ok {
+		return
+	}
+	base := cfg.HTTPClient
+	if base == nil {
+		base = awshttp.NewBuildableClient()
+	}
+	cfg.HTTPClient = HTTPClient{Base: base}
+}
@@ -0,0 +1,52 @@
+package cost
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sudowanderer/dca-bot-go/internal/dynamo"
+)
+
+// TestInstrument counts the requests of the bot's own DynamoDB client,
+// which sends through the instrumented config
+func TestInstrument(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cfg := aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	Instrument(&cfg)
+	Instrument(&cfg) // a second call does not count twice
+	client := dynamo.New(cfg)
+
+	meter := NewMeter()
+	ctx := WithMeter(context.Background(), meter)
+	for range 2 {
+		if err := client.Call(ctx, "GetItem", map[string]any{"TableName": "t"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Requests without a meter are sent uncounted
+	if err := client.Call(context.Background(), "GetItem", map[string]any{"TableName": "t"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := meter.Calls(); len(got) != 1 || got["dynamodb"] != 2 {
+		t.Errorf("Calls() = %v, want 2 dynamodb requests", got)
+	}
+	if calls != 3 {
+		t.Errorf("server saw %d requests, want 3", calls)
+	}
+}
@@ -0,0 +1,155 @@
+// Package credentials resolves the secrets named by a payload's credential
+// sources. An ssm source reads all of its parameters in one GetParameters
+// call, decrypting SecureStrings; error messages name the parameter paths
+// and never include the values.
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// SSMAPI is the subset of the SSM client used by this package
+type SSMAPI interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// NewClientFunc creates the SSM client; it is only called for ssm sources
+type NewClientFunc func(ctx context.Context) (SSMAPI, error)
+
+// Credentials are the resolved API credentials of an exchange account
+type Credentials struct {
+	APIKey     string
+	APISecret  string
+	Passphrase string // OKX only; empty when the source names none
+}
+
+// Resolve reads the API key and secret of source, and its passphrase when
+// the source names one
+func Resolve(ctx context.Context, newClient NewClientFunc, source config.CredentialSource) (Credentials, error) {
+	names := []string{"apiKey", "apiSecret"}
+	if _, ok := source.Config[configKey(source.Type, "passphrase")]; ok {
+		names = append(names, "passphrase")
+	}
+	secrets, err := lookup(ctx, newClient, source, names)
+	if err != nil {
+		return Credentials{}, err
+	}
+	creds := Credentials{APIKey: secrets[0], APISecret: secrets[1]}
+	if len(secrets) > 2 {
+		creds.Passphrase = secrets[2]
+	}
+	return creds, nil
+}
+
+// Secret reads the secret called name from source. The config key depends
+// on the source type: name for inline, name+"Env" for env and name+"Path"
+// for ssm, e.g. "apiKey", "apiKeyEnv", "apiKeyPath".
+func Secret(ctx context.Context, newClient NewClientFunc, source config.CredentialSource, name string) (string, error) {
+	secrets, err := lookup(ctx, newClient, source, []string{name})
+	if err != nil {
+		return "", err
+	}
+	return secrets[0], nil
+}
+
+// lookup resolves the secrets called names from source, in order. Errors
+// name the config key, variable or parameter they concern.
+func lookup(ctx context.Context, newClient NewClientFunc, source config.CredentialSource, names []string) ([]string, error) {
+	if err := config.ValidateCredentialType(source.Type); err != nil {
+		return nil, err
+	}
+	refs := make([]string, len(names))
+	for i, name := range names {
+		ref, err := configString(source.Config, configKey(source.Type, name))
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ref
+	}
+
+	switch source.Type {
+	case config.CredentialTypeInline:
+		return refs, nil
+	case config.CredentialTypeEnv:
+		secrets := make([]string, len(refs))
+		for i, env := range refs {
+			if secrets[i] = os.Getenv(env); secrets[i] == "" {
+				return nil, fmt.Errorf("%s: environment variable %s is not set or empty", names[i]+"Env", env)
+			}
+		}
+		return secrets, nil
+	default:
+		client, err := newClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values, err := readParameters(ctx, client, refs)
+		if err != nil {
+			return nil, err
+		}
+		secrets := make([]string, len(refs))
+		for i, path := range refs {
+			secrets[i] = values[path]
+		}
+		return secrets, nil
+	}
+}
+
+// readParameters reads the SSM parameters at paths in a single call,
+// keyed by path. A parameter that does not exist is an error.
+func readParameters(ctx context.Context, client SSMAPI, paths []string) (map[string]string, error) {
+	names := slices.Compact(slices.Sorted(slices.Values(paths)))
+	out, err := client.GetParameters(ctx, &ssm.GetParametersInput{
+		Names:          names,
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSM parameters %s: %w", strings.Join(names, ", "), err)
+	}
+	if len(out.InvalidParameters) > 0 {
+		return nil, fmt.Errorf("SSM parameters not found: %s", strings.Join(out.InvalidParameters, ", "))
+	}
+
+	values := make(map[string]string, len(out.Parameters))
+	for _, p := range out.Parameters {
+		// A versioned path such as "/dca/key:3" comes back as the name
+		// and its selector
+		values[aws.ToString(p.Name)+aws.ToString(p.Selector)] = aws.ToString(p.Value)
+	}
+	for _, path := range names {
+		if _, ok := values[path]; !ok {
+			return nil, fmt.Errorf("SSM parameter %s was not returned", path)
+		}
+	}
+	return values, nil
+}
+
+// configKey is the config key naming the secret called name in a source
+// of type sourceType
+func configKey(sourceType, name string) string {
+	switch sourceType {
+	case config.CredentialTypeEnv:
+		return name + "Env"
+	case config.CredentialTypeSSM:
+		return name + "Path"
+	default:
+		return name
+	}
+}
+
+// configString reads a required string value from a credential config map
+func configString(cfg map[string]interface{}, key string) (string, error) {
+	value, _ := cfg[key].(string)
+	if value == "" {
+		return "", fmt.Errorf("%s is required", key)
+	}
+	return value, nil
+}
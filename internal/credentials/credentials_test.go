@@ -0,0 +1,205 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// fakeSSM serves parameters from a map and records its calls
+type fakeSSM struct {
+	params map[string]string
+	err    error
+	calls  []*ssm.GetParametersInput
+}
+
+func (f *fakeSSM) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	f.calls = append(f.calls, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := &ssm.GetParametersOutput{}
+	for _, name := range params.Names {
+		value, ok := f.params[name]
+		if !ok {
+			out.InvalidParameters = append(out.InvalidParameters, name)
+			continue
+		}
+		out.Parameters = append(out.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value)})
+	}
+	return out, nil
+}
+
+// client returns a NewClientFunc serving f
+func (f *fakeSSM) client(ctx context.Context) (SSMAPI, error) {
+	return f, nil
+}
+
+// noClient fails the test when an SSM client is created
+func noClient(t *testing.T) NewClientFunc {
+	return func(ctx context.Context) (SSMAPI, error) {
+		t.Error("SSM client created for a source without ssm parameters")
+		return nil, errors.New("no SSM")
+	}
+}
+
+func ssmSource(paths map[string]interface{}) config.CredentialSource {
+	return config.CredentialSource{Type: config.CredentialTypeSSM, Config: paths}
+}
+
+func TestResolve_SSM(t *testing.T) {
+	fake := &fakeSSM{params: map[string]string{
+		"/dca/okx/key":        "key-1",
+		"/dca/okx/secret":     "secret-1",
+		"/dca/okx/passphrase": "pass-1",
+	}}
+	source := ssmSource(map[string]interface{}{
+		"apiKeyPath":     "/dca/okx/key",
+		"apiSecretPath":  "/dca/okx/secret",
+		"passphrasePath": "/dca/okx/passphrase",
+	})
+
+	creds, err := Resolve(context.Background(), fake.client, source)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := (Credentials{APIKey: "key-1", APISecret: "secret-1", Passphrase: "pass-1"}); creds != want {
+		t.Errorf("Resolve() = %+v, want %+v", creds, want)
+	}
+	if len(fake.calls) != 1 {
+		t.Fatalf("GetParameters called %d times, want once", len(fake.calls))
+	}
+	call := fake.calls[0]
+	if !aws.ToBool(call.WithDecryption) {
+		t.Error("GetParameters called without decryption")
+	}
+	if want := []string{"/dca/okx/key", "/dca/okx/passphrase", "/dca/okx/secret"}; !slices.Equal(call.Names, want) {
+		t.Errorf("GetParameters names = %v, want %v", call.Names, want)
+	}
+}
+
+func TestResolve_SSMWithoutPassphrase(t *testing.T) {
+	// One parameter may hold both; it is read once
+	fake := &fakeSSM{params: map[string]string{"/dca/shared": "same"}}
+	source := ssmSource(map[string]interface{}{"apiKeyPath": "/dca/shared", "apiSecretPath": "/dca/shared"})
+
+	creds, err := Resolve(context.Background(), fake.client, source)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := (Credentials{APIKey: "same", APISecret: "same"}); creds != want {
+		t.Errorf("Resolve() = %+v, want %+v", creds, want)
+	}
+	if names := fake.calls[0].Names; !slices.Equal(names, []string{"/dca/shared"}) {
+		t.Errorf("GetParameters names = %v, want the path once", names)
+	}
+}
+
+func TestResolve_SSMErrors(t *testing.T) {
+	source := ssmSource(map[string]interface{}{"apiKeyPath": "/dca/key", "apiSecretPath": "/dca/secret"})
+	denied := errors.New("AccessDeniedException: not authorized to perform ssm:GetParameters")
+
+	tests := []struct {
+		name    string
+		fake    *fakeSSM
+		source  config.CredentialSource
+		wantErr string
+	}{
+		{
+			name:    "missing parameter",
+			fake:    &fakeSSM{params: map[string]string{"/dca/key": "key-value"}},
+			source:  source,
+			wantErr: "SSM parameters not found: /dca/secret",
+		},
+		{
+			name:    "access denied",
+			fake:    &fakeSSM{err: denied},
+			source:  source,
+			wantErr: "failed to read SSM parameters /dca/key, /dca/secret: " + denied.Error(),
+		},
+		{
+			name:    "missing path",
+			fake:    &fakeSSM{},
+			source:  ssmSource(map[string]interface{}{"apiKeyPath": "/dca/key"}),
+			wantErr: "apiSecretPath is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Resolve(context.Background(), tt.fake.client, tt.source)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Contains(err.Error(), "key-value") {
+				t.Errorf("Resolve() error %q includes a parameter value", err)
+			}
+			if tt.fake.err != nil && !errors.Is(err, tt.fake.err) {
+				t.Errorf("Resolve() error does not wrap the SSM error")
+			}
+		})
+	}
+}
+
+func TestResolve_InlineAndEnv(t *testing.T) {
+	t.Setenv("DCA_TEST_KEY", "env-key")
+	t.Setenv("DCA_TEST_SECRET", "env-secret")
+
+	tests := []struct {
+		name    string
+		source  config.CredentialSource
+		want    Credentials
+		wantErr string
+	}{
+		{
+			name:   "inline",
+			source: config.CredentialSource{Type: config.CredentialTypeInline, Config: map[string]interface{}{"apiKey": "k", "apiSecret": "s"}},
+			want:   Credentials{APIKey: "k", APISecret: "s"},
+		},
+		{
+			name:   "env",
+			source: config.CredentialSource{Type: config.CredentialTypeEnv, Config: map[string]interface{}{"apiKeyEnv": "DCA_TEST_KEY", "apiSecretEnv": "DCA_TEST_SECRET"}},
+			want:   Credentials{APIKey: "env-key", APISecret: "env-secret"},
+		},
+		{
+			name:    "env not set",
+			source:  config.CredentialSource{Type: config.CredentialTypeEnv, Config: map[string]interface{}{"apiKeyEnv": "DCA_TEST_KEY", "apiSecretEnv": "DCA_TEST_UNSET"}},
+			wantErr: "apiSecretEnv: environment variable DCA_TEST_UNSET is not set or empty",
+		},
+		{
+			name:    "unknown type",
+			source:  config.CredentialSource{Type: "vault"},
+			wantErr: config.ValidateCredentialType("vault").Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := Resolve(context.Background(), noClient(t), tt.source)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || creds != tt.want {
+				t.Errorf("Resolve() = %+v, %v, want %+v", creds, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecret(t *testing.T) {
+	fake := &fakeSSM{params: map[string]string{"/dca/telegram/token": "123:abc"}}
+	source := ssmSource(map[string]interface{}{"botTokenPath": "/dca/telegram/token"})
+
+	token, err := Secret(context.Background(), fake.client, source, "botToken")
+	if err != nil || token != "123:abc" {
+		t.Errorf("Secret() = %q, %v, want the parameter's value", token, err)
+	}
+}
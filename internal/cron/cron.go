@@ -0,0 +1,225 @@
+// Package cron parses five-field cron expressions ("minute hour
+// day-of-month month day-of-week") and counts when they fire. Fields take
+// "*", numbers, ranges "a-b", steps "*/n" or "a-b/n" and comma lists;
+// day-of-week is 0-7 with both 0 and 7 meaning Sunday. As in standard cron,
+// a day matches either day field when both are restricted.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	minute, hour, dom, month, dow uint64 // bit i set: value i matches
+	domAny, dowAny                bool   // the field was "*"
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day-of-month", 1, 31},
+	{"month", 1, 12},
+	{"day-of-week", 0, 7},
+}
+
+// Parse parses a five-field cron expression, e.g. "0 8 * * 1" for Mondays
+// at 08:00
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(parts))
+	}
+
+	sets := make([]uint64, len(fields))
+	for i, f := range fields {
+		set, err := parseField(parts[i], f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %s: %w", expr, f.name, err)
+		}
+		sets[i] = set
+	}
+	// 7 is Sunday too
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &Schedule{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domAny: parts[2] == "*", dowAny: parts[4] == "*",
+	}, nil
+}
+
+func parseField(expr string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		span, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			span, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case span == "*":
+		case strings.Contains(span, "-"):
+			a, b, _ := strings.Cut(span, "-")
+			var err error
+			if lo, err = parseValue(a, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(b, f); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("range %q is backwards", span)
+			}
+		default:
+			v, err := parseValue(span, f)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Matches reports whether the schedule fires at t's minute, in t's location
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minute&(1<<t.Minute()) != 0 && s.hour&(1<<t.Hour()) != 0 && s.matchesDay(t)
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if s.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// Count returns how many times the schedule fires in [from, to), in
+// from's location
+func (s *Schedule) Count(from, to time.Time) int {
+	n := 0
+	s.each(from, to, func(time.Time) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// nextHorizon bounds the search for the next firing; a schedule such as
+// "0 0 29 2 *" fires only in leap years
+const nextHorizon = 8 * 366 * 24 * time.Hour
+
+// Next returns up to n times the schedule fires after from, in from's
+// location. It returns fewer when the schedule does not fire again within
+// eight years.
+func (s *Schedule) Next(from time.Time, n int) []time.Time {
+	var next []time.Time
+	if n <= 0 {
+		return next
+	}
+	s.each(from, from.Add(nextHorizon), func(t time.Time) bool {
+		if t.After(from) {
+			next = append(next, t)
+		}
+		return len(next) < n
+	})
+	return next
+}
+
+// Prev returns the latest time the schedule fires at or before at, in at's
+// location. ok is false when it did not fire within the previous eight
+// years.
+func (s *Schedule) Prev(at time.Time) (t time.Time, ok bool) {
+	loc := at.Location()
+	limit := at.Add(-nextHorizon)
+	for day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc); !day.Before(limit); day = day.AddDate(0, 0, -1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		for hour := 23; hour >= 0; hour-- {
+			if s.hour&(1<<hour) == 0 {
+				continue
+			}
+			for minute := 59; minute >= 0; minute-- {
+				if s.minute&(1<<minute) == 0 {
+					continue
+				}
+				t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+				if t.Hour() != hour || t.Minute() != minute {
+					continue // skipped by a daylight saving change
+				}
+				if !t.After(at) {
+					return t, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// each calls fn with the times the schedule fires in [from, to), in order
+// and in from's location, until fn returns false
+func (s *Schedule) each(from, to time.Time, fn func(time.Time) bool) {
+	loc := from.Location()
+	to = to.In(loc)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !s.matchesDay(day) {
+			continue
+		}
+		for hour := 0; hour < 24; hour++ {
+			if s.hour&(1<<hour) == 0 {
+				continue
+			}
+			for minute := 0; minute < 60; minute++ {
+				if s.minute&(1<<minute) == 0 {
+					continue
+				}
+				t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+				if t.Hour() != hour || t.Minute() != minute {
+					continue // skipped by a daylight saving change
+				}
+				if !t.Before(from) && t.Before(to) && !fn(t) {
+					return
+				}
+			}
+		}
+	}
+}
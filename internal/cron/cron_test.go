@@ -0,0 +1,192 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{"0 8 * *", "want 5 fields"},
+		{"60 8 * * *", "minute: value \"60\" out of range 0-59"},
+		{"0 8 0 * *", "day-of-month: value \"0\" out of range 1-31"},
+		{"0 8 * * 8", "day-of-week"},
+		{"0 8 * * MON", "day-of-week"},
+		{"0 10-8 * * *", "hour: range \"10-8\" is backwards"},
+		{"*/0 8 * * *", "minute: invalid step"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchedule_Matches(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"0 8 * * *", monday, true},
+		{"0 8 * * *", monday.Add(time.Minute), false},
+		{"0 8 * * 1", monday, true},
+		{"0 8 * * 1-5", monday.AddDate(0, 0, 5), false},
+		{"0 8 * * 7", monday.AddDate(0, 0, 6), true}, // 7 is Sunday
+		{"*/15 8 * * *", monday.Add(45 * time.Minute), true},
+		{"0 8 1,15 * *", monday, false},
+		{"0 8 15 * 1", monday, true}, // either day field matches
+		{"0 8 * 1-6 *", monday, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Matches(tt.at); got != tt.want {
+				t.Errorf("Matches(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Count(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	month := func(year int, m time.Month, loc *time.Location) time.Time {
+		return time.Date(year, m, 1, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		from, to time.Time // a zero to is the end of from's month
+		want     int
+	}{
+		{"daily_feb_28", "0 8 * * *", month(2026, time.February, time.UTC), time.Time{}, 28},
+		{"daily_feb_29", "0 8 * * *", month(2028, time.February, time.UTC), time.Time{}, 29},
+		{"daily_30", "0 8 * * *", month(2026, time.April, time.UTC), time.Time{}, 30},
+		{"daily_31", "0 8 * * *", month(2026, time.January, time.UTC), time.Time{}, 31},
+		{"twice_daily", "0 8,20 * * *", month(2026, time.April, time.UTC), time.Time{}, 60},
+		{"mondays", "0 8 * * 1", month(2026, time.March, time.UTC), time.Time{}, 5},
+		{"first_and_fifteenth", "30 9 1,15 * *", month(2026, time.March, time.UTC), time.Time{}, 2},
+		{"half_open", "0 8 * * *", time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 12, 8, 0, 0, 0, time.UTC), 2},
+		// 02:30 does not exist on the last Sunday of March in Berlin
+		{"dst_gap", "30 2 * * *", month(2026, time.March, berlin), time.Time{}, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			to := tt.to
+			if to.IsZero() {
+				to = tt.from.AddDate(0, 1, 0)
+			}
+			if got := s.Count(tt.from, to); got != tt.want {
+				t.Errorf("Count() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		n    int
+		want []time.Time
+	}{
+		{"mondays", "0 8 * * 1", at(10, 12, 0), 3, []time.Time{at(16, 8, 0), at(23, 8, 0), at(30, 8, 0)}},
+		{"strictly_after", "0 8 * * *", at(10, 8, 0), 2, []time.Time{at(11, 8, 0), at(12, 8, 0)}},
+		{"same_day", "0 8,20 * * *", at(10, 9, 0), 2, []time.Time{at(10, 20, 0), at(11, 8, 0)}},
+		{"leap_day", "0 0 29 2 *", at(1, 0, 0), 1, []time.Time{time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)}},
+		{"none_asked", "0 8 * * *", at(10, 0, 0), 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := s.Next(tt.from, tt.n)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Next() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("Next()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSchedule_NextNever(t *testing.T) {
+	// April 31 does not exist
+	s, err := Parse("0 0 31 4 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 1); len(got) != 0 {
+		t.Errorf("Next() = %v, want none", got)
+	}
+}
+
+func TestSchedule_Prev(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		at   time.Time
+		want time.Time
+	}{
+		{"same_minute", "0 9 * * *", at(10, 9, 0).Add(40 * time.Second), at(10, 9, 0)},
+		{"later_that_day", "0 9 * * *", at(10, 13, 42), at(10, 9, 0)},
+		{"before_today", "0 9 * * *", at(10, 8, 59), at(9, 9, 0)},
+		{"across_midnight", "55 23 * * *", at(10, 0, 5), at(9, 23, 55)},
+		{"mondays", "0 8 * * 1", at(19, 12, 0), at(16, 8, 0)},
+		{"leap_day", "0 0 29 2 *", at(1, 0, 0), time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// 02:30 does not exist on 29 March 2026 in Berlin, so the latest is the day before
+		{"dst_gap", "30 2 * * *", time.Date(2026, 3, 29, 3, 5, 0, 0, berlin), time.Date(2026, 3, 28, 2, 30, 0, 0, berlin)},
+		{"dst_day", "0 9 * * *", time.Date(2026, 3, 29, 9, 10, 0, 0, berlin), time.Date(2026, 3, 29, 9, 0, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, ok := s.Prev(tt.at)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("Prev() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+
+	s, _ := Parse("0 0 31 4 *")
+	if got, ok := s.Prev(at(1, 0, 0)); ok {
+		t.Errorf("Prev() = %s, want none", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Package dip buys more while the market is down: strategy.dipMultipliers
+// multiplies a run's amount by the tier matching how far the price is
+// below a reference price
+package dip
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// Sources of the reference price recorded in Dip
+const (
+	SourceCandles = "candles" // highest price of the lookback's daily candles
+	SourcePayload = "payload" // strategy.dipMultipliers.referencePrice
+)
+
+// Tier is a parsed config.DipTier
+type Tier struct {
+	Drawdown   money.Percent
+	Multiplier decimal.Decimal
+}
+
+// Dip records how a run's amount was multiplied
+type Dip struct {
+	Reference       decimal.Decimal `json:"reference"`
+	ReferenceSource string          `json:"referenceSource"`        // "candles" or "payload"
+	LookbackDays    int             `json:"lookbackDays,omitempty"` // days of candles the reference high was taken from
+	Price           decimal.Decimal `json:"price"`                  // last price compared with the reference
+	Drawdown        money.Percent   `json:"drawdown"`               // how far Price is below Reference; negative above it
+	Tier            money.Percent   `json:"tier,omitzero"`          // drawdownPercent of the tier applied, zero when none matched
+	Multiplier      decimal.Decimal `json:"multiplier"`             // of the tier matched, 1 when none; see Multiplied
+	BaseAmount      decimal.Decimal `json:"baseAmount"`             // quoteAmount before the multiplier
+	Amount          decimal.Decimal `json:"amount"`                 // quoteAmount ordered
+	Capped          bool            `json:"capped,omitempty"`       // maxQuoteAmount lowered the amount
+}
+
+// Multiplied reports whether the amount was raised: a tier matched and
+// maxQuoteAmount left room above the base amount
+func (d Dip) Multiplied() bool {
+	return d.Amount.GreaterThan(d.BaseAmount)
+}
+
+// Tiers parses the tiers of cfg, sorted by drawdown
+func Tiers(cfg config.DipMultipliersConfig) ([]Tier, error) {
+	tiers := make([]Tier, 0, len(cfg.Tiers))
+	for _, t := range cfg.Tiers {
+		drawdown, err := money.PercentFromString(t.DrawdownPercent)
+		if err != nil {
+			return nil, err
+		}
+		multiplier, err := decimal.NewFromString(t.Multiplier)
+		if err != nil {
+			return nil, fmt.Errorf("invalid multiplier %q", t.Multiplier)
+		}
+		tiers = append(tiers, Tier{Drawdown: drawdown, Multiplier: multiplier})
+	}
+	slices.SortFunc(tiers, func(a, b Tier) int { return a.Drawdown.Cmp(b.Drawdown) })
+	return tiers, nil
+}
+
+// Drawdown returns how far price is below reference, which must be
+// positive; negative when price is above it
+func Drawdown(reference, price decimal.Decimal) money.Percent {
+	return money.PercentOf(reference.Sub(price), reference)
+}
+
+// Select returns the deepest of tiers, sorted by drawdown, that drawdown
+// reaches; a drawdown equal to a tier's matches it. It is false when none
+// does.
+func Select(tiers []Tier, drawdown money.Percent) (Tier, bool) {
+	for i := len(tiers) - 1; i >= 0; i-- {
+		if drawdown.Cmp(tiers[i].Drawdown) >= 0 {
+			return tiers[i], true
+		}
+	}
+	return Tier{}, false
+}
+
+// Apply multiplies amount by the tier of cfg matching price's drawdown from
+// reference, truncated to places decimals. The multiplied amount is capped
+// at cfg.MaxQuoteAmount, but never below amount itself.
+func Apply(cfg config.DipMultipliersConfig, amount, reference, price decimal.Decimal, places int32) (Dip, error) {
+	if !reference.IsPositive() {
+		return Dip{}, fmt.Errorf("reference price %s is not positive", reference)
+	}
+	tiers, err := Tiers(cfg)
+	if err != nil {
+		return Dip{}, err
+	}
+	d := Dip{Reference: reference, Price: price, Drawdown: Drawdown(reference, price), Multiplier: decimal.NewFromInt(1), BaseAmount: amount, Amount: amount}
+	tier, ok := Select(tiers, d.Drawdown)
+	if !ok {
+		return d, nil
+	}
+	d.Tier, d.Multiplier = tier.Drawdown, tier.Multiplier
+	d.Amount = amount.Mul(tier.Multiplier).Truncate(places)
+	if cfg.MaxQuoteAmount != "" {
+		max, err := decimal.NewFromString(cfg.MaxQuoteAmount)
+		if err != nil {
+			return Dip{}, fmt.Errorf("invalid maxQuoteAmount %q", cfg.MaxQuoteAmount)
+		}
+		if d.Amount.GreaterThan(max) {
+			d.Amount, d.Capped = decimal.Max(max, amount), true
+		}
+	}
+	return d, nil
+}
+
+// High returns the highest price of klines, zero when there are none
+func High(klines []exchange.Kline) decimal.Decimal {
+	high := decimal.Zero
+	for _, k := range klines {
+		if k.High.GreaterThan(high) {
+			high = k.High
+		}
+	}
+	return high
+}
@@ -0,0 +1,110 @@
+package dip
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+var tiers = config.DipMultipliersConfig{Tiers: []config.DipTier{
+	{DrawdownPercent: "30", Multiplier: "3"},
+	{DrawdownPercent: "10", Multiplier: "1.5"},
+	{DrawdownPercent: "20", Multiplier: "2"},
+}}
+
+func TestApply_Tiers(t *testing.T) {
+	tests := []struct {
+		name           string
+		price          string
+		wantTier       string // empty when no tier matches
+		wantMultiplier string
+		wantAmount     string
+	}{
+		{"above the reference", "110", "", "1", "100"},
+		{"at the reference", "100", "", "1", "100"},
+		{"just short of the first tier", "90.01", "", "1", "100"},
+		{"at the first tier", "90", "10", "1.5", "150"},
+		{"between tiers", "85", "10", "1.5", "150"},
+		{"just short of the second tier", "80.0001", "10", "1.5", "150"},
+		{"at the second tier", "80", "20", "2", "200"},
+		{"at the deepest tier", "70", "30", "3", "300"},
+		{"beyond the deepest tier", "5", "30", "3", "300"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tiers, d("100"), d("100"), d(tt.price), 2)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if tt.wantTier == "" && !got.Tier.IsZero() || tt.wantTier != "" && !got.Tier.Decimal().Equal(d(tt.wantTier)) {
+				t.Errorf("Tier = %s, want %q", got.Tier, tt.wantTier)
+			}
+			if !got.Multiplier.Equal(d(tt.wantMultiplier)) || !got.Amount.Equal(d(tt.wantAmount)) || got.Capped {
+				t.Errorf("Apply() = ×%s %s (capped %v), want ×%s %s", got.Multiplier, got.Amount, got.Capped, tt.wantMultiplier, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestApply_Cap(t *testing.T) {
+	tests := []struct {
+		name       string
+		max        string
+		price      string
+		wantAmount string
+		wantCapped bool
+		multiplied bool
+	}{
+		{"below the cap", "250", "80", "200", false, true},
+		{"at the cap", "200", "80", "200", false, true},
+		{"above the cap", "250", "70", "250", true, true},
+		{"cap at the base amount", "100", "70", "100", true, false},
+		{"cap below the base amount", "50", "70", "100", true, false},
+		{"no tier matched", "50", "100", "100", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tiers
+			cfg.MaxQuoteAmount = tt.max
+			got, err := Apply(cfg, d("100"), d("100"), d(tt.price), 2)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if !got.Amount.Equal(d(tt.wantAmount)) || got.Capped != tt.wantCapped {
+				t.Errorf("Apply() = %s (capped %v), want %s (capped %v)", got.Amount, got.Capped, tt.wantAmount, tt.wantCapped)
+			}
+			if got.Multiplied() != tt.multiplied {
+				t.Errorf("Multiplied() = %v, want %v", got.Multiplied(), tt.multiplied)
+			}
+		})
+	}
+}
+
+func TestApply_Truncates(t *testing.T) {
+	cfg := config.DipMultipliersConfig{Tiers: []config.DipTier{{DrawdownPercent: "5", Multiplier: "1.333"}}}
+	got, err := Apply(cfg, d("10"), d("68000"), d("52000"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Amount.Equal(d("13.33")) || !got.Drawdown.Round(2).Decimal().Equal(d("23.53")) {
+		t.Errorf("Apply() = %s at %s%% below, want 13.33 at 23.53%%", got.Amount, got.Drawdown)
+	}
+
+	if _, err := Apply(cfg, d("10"), decimal.Zero, d("52000"), 2); err == nil {
+		t.Error("Apply() accepted a zero reference")
+	}
+}
+
+func TestHigh(t *testing.T) {
+	klines := []exchange.Kline{{High: d("61000")}, {High: d("68000.5")}, {High: d("52000")}}
+	if got := High(klines); !got.Equal(d("68000.5")) {
+		t.Errorf("High() = %s, want 68000.5", got)
+	}
+	if got := High(nil); !got.IsZero() {
+		t.Errorf("High(nil) = %s, want 0", got)
+	}
+}
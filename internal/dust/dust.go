@@ -0,0 +1,116 @@
+// Package dust sweeps small leftover balances into a single asset without
+// ever touching the assets a DCA strategy holds.
+package dust
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// Reasons a convertible balance was left alone
+const (
+	SkipProtected   = "protected"    // strategy asset or in dust.exclude
+	SkipNotIncluded = "not included" // dust.include is set and does not list it
+	SkipAboveMax    = "above maxValue"
+	SkipTargetAsset = "target asset"
+)
+
+// Report records what a dust run found and converted
+type Report struct {
+	Target      string                    `json:"target"`
+	DryRun      bool                      `json:"dryRun"`
+	Selected    []exchange.DustAsset      `json:"selected"`              // converted, or would be in a dry run
+	Skipped     []Skipped                 `json:"skipped,omitempty"`     // convertible but left alone
+	Conversions []exchange.DustConversion `json:"conversions,omitempty"` // as reported by the exchange
+	Received    decimal.Decimal           `json:"received"`              // target asset received, net of fees
+}
+
+// Skipped is a convertible balance that was not selected
+type Skipped struct {
+	Asset  string `json:"asset"`
+	Reason string `json:"reason"`
+}
+
+// Select splits the exchange's convertible balances into those cfg allows
+// converting and those it does not. protected assets are never selected.
+func Select(assets []exchange.DustAsset, cfg config.DustConfig, protected []string) ([]exchange.DustAsset, []Skipped) {
+	maxValue, hasMax := decimal.Zero, cfg.MaxValue != ""
+	if hasMax {
+		maxValue = decimal.RequireFromString(cfg.MaxValue)
+	}
+
+	var selected []exchange.DustAsset
+	var skipped []Skipped
+	for _, a := range assets {
+		var reason string
+		switch {
+		case contains(protected, a.Asset):
+			reason = SkipProtected
+		case sameAsset(a.Asset, cfg.Target):
+			reason = SkipTargetAsset
+		case len(cfg.Include) > 0 && !contains(cfg.Include, a.Asset):
+			reason = SkipNotIncluded
+		case hasMax && !a.Value.LessThan(maxValue):
+			reason = SkipAboveMax
+		}
+		if reason != "" {
+			skipped = append(skipped, Skipped{Asset: a.Asset, Reason: reason})
+			continue
+		}
+		selected = append(selected, a)
+	}
+	return selected, skipped
+}
+
+// Sweep lists conv's convertible balances, selects them per cfg while
+// protecting the assets of symbol, and converts the selection unless dryRun
+// is set. Finding nothing to convert is not an error.
+func Sweep(ctx context.Context, conv exchange.DustConverter, cfg config.DustConfig, symbol string, dryRun bool) (*Report, error) {
+	if !sameAsset(cfg.Target, conv.DustTarget()) {
+		return nil, fmt.Errorf("this exchange converts dust to %s only, not %s", conv.DustTarget(), cfg.Target)
+	}
+
+	assets, err := conv.ListDust(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Target: conv.DustTarget(), DryRun: dryRun}
+	report.Selected, report.Skipped = Select(assets, cfg, cfg.Protected(symbol))
+	for _, s := range report.Skipped {
+		log.Printf("   Leaving %s: %s", s.Asset, s.Reason)
+	}
+
+	if len(report.Selected) == 0 || dryRun {
+		return report, nil
+	}
+
+	codes := make([]string, len(report.Selected))
+	for i, a := range report.Selected {
+		codes[i] = a.Asset
+	}
+	report.Conversions, err = conv.ConvertDust(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range report.Conversions {
+		report.Received = report.Received.Add(c.Converted)
+	}
+	return report, nil
+}
+
+// contains reports whether list holds code, comparing canonical codes
+func contains(list []string, code string) bool {
+	return slices.ContainsFunc(list, func(item string) bool { return sameAsset(item, code) })
+}
+
+// sameAsset compares asset codes after canonicalization
+func sameAsset(a, b string) bool {
+	return asset.Canonical("", a) == asset.Canonical("", b)
+}
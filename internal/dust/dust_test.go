@@ -0,0 +1,146 @@
+package dust
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+type stubConverter struct {
+	assets    []exchange.DustAsset
+	converted [][]string
+	err       error
+}
+
+func (s *stubConverter) DustTarget() string { return "BNB" }
+
+func (s *stubConverter) ListDust(ctx context.Context) ([]exchange.DustAsset, error) {
+	return s.assets, nil
+}
+
+func (s *stubConverter) ConvertDust(ctx context.Context, assets []string) ([]exchange.DustConversion, error) {
+	s.converted = append(s.converted, assets)
+	if s.err != nil {
+		return nil, s.err
+	}
+	var out []exchange.DustConversion
+	for _, code := range assets {
+		out = append(out, exchange.DustConversion{Asset: code, Converted: decimal.RequireFromString("0.0005")})
+	}
+	return out, nil
+}
+
+func dustAsset(code, value string) exchange.DustAsset {
+	return exchange.DustAsset{Asset: code, Free: decimal.RequireFromString("1"), Value: decimal.RequireFromString(value)}
+}
+
+var listed = []exchange.DustAsset{
+	dustAsset("USDT", "0.0005"),
+	dustAsset("ADA", "0.0177"),
+	dustAsset("BTC", "0.0008"),
+	dustAsset("XBT", "0.0001"),
+	dustAsset("BCC", "0.0008"),
+	dustAsset("BNB", "0.0001"),
+}
+
+func codes(assets []exchange.DustAsset) string {
+	var out []string
+	for _, a := range assets {
+		out = append(out, a.Asset)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.DustConfig
+		symbol       string
+		wantSelected string
+	}{
+		{"protects_strategy_assets", config.DustConfig{Target: "BNB"}, "BTC-FDUSD", "USDT,ADA,BCC"},
+		{"protects_quote", config.DustConfig{Target: "BNB"}, "ETH-USDT", "ADA,BTC,XBT,BCC"},
+		{"exclude", config.DustConfig{Target: "BNB", Exclude: []string{"ADA"}}, "BTC-FDUSD", "USDT,BCC"},
+		{"include", config.DustConfig{Target: "BNB", Include: []string{"USDT", "BTC"}}, "BTC-FDUSD", "USDT"},
+		{"max_value", config.DustConfig{Target: "BNB", MaxValue: "0.001"}, "BTC-FDUSD", "USDT,BCC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, skipped := Select(listed, tt.cfg, tt.cfg.Protected(tt.symbol))
+			if got := codes(selected); got != tt.wantSelected {
+				t.Errorf("selected = %s, want %s", got, tt.wantSelected)
+			}
+			if len(selected)+len(skipped) != len(listed) {
+				t.Errorf("%d selected + %d skipped, want %d in total", len(selected), len(skipped), len(listed))
+			}
+		})
+	}
+
+	_, skipped := Select(listed, config.DustConfig{Target: "BNB"}, []string{"BTC"})
+	reasons := map[string]string{}
+	for _, s := range skipped {
+		reasons[s.Asset] = s.Reason
+	}
+	if reasons["XBT"] != SkipProtected || reasons["BNB"] != SkipTargetAsset {
+		t.Errorf("skip reasons = %v", reasons)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	cfg := config.DustConfig{Target: "BNB", Exclude: []string{"ADA"}}
+
+	conv := &stubConverter{assets: listed}
+	report, err := Sweep(context.Background(), conv, cfg, "BTC-FDUSD", false)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(conv.converted) != 1 || strings.Join(conv.converted[0], ",") != "USDT,BCC" {
+		t.Errorf("converted = %v, want one call for USDT,BCC", conv.converted)
+	}
+	if !report.Received.Equal(decimal.RequireFromString("0.001")) || len(report.Conversions) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestSweep_DryRunOnlyLists(t *testing.T) {
+	conv := &stubConverter{assets: listed}
+	report, err := Sweep(context.Background(), conv, config.DustConfig{Target: "BNB"}, "BTC-FDUSD", true)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(conv.converted) != 0 {
+		t.Errorf("dry run converted %v", conv.converted)
+	}
+	if !report.DryRun || codes(report.Selected) != "USDT,ADA,BCC" {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestSweep_NothingToConvert(t *testing.T) {
+	conv := &stubConverter{assets: []exchange.DustAsset{dustAsset("BTC", "0.0008")}}
+	report, err := Sweep(context.Background(), conv, config.DustConfig{Target: "BNB"}, "BTC-USDT", false)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(conv.converted) != 0 || len(report.Selected) != 0 {
+		t.Errorf("converted = %v, selected = %v; want nothing", conv.converted, report.Selected)
+	}
+}
+
+func TestSweep_Errors(t *testing.T) {
+	if _, err := Sweep(context.Background(), &stubConverter{}, config.DustConfig{Target: "USDT"}, "BTC-USDT", false); err == nil || !strings.Contains(err.Error(), "BNB only") {
+		t.Errorf("Sweep() error = %v, want unsupported target", err)
+	}
+
+	boom := errors.New("convert failed")
+	conv := &stubConverter{assets: listed, err: boom}
+	if _, err := Sweep(context.Background(), conv, config.DustConfig{Target: "BNB"}, "BTC-USDT", false); !errors.Is(err, boom) {
+		t.Errorf("Sweep() error = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,125 @@
+// Package dynamo calls the DynamoDB JSON API with SigV4-signed requests,
+// for the few operations the bot needs without the SDK's DynamoDB client
+package dynamo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// signingName is the SigV4 service name of DynamoDB
+const signingName = "dynamodb"
+
+// targetPrefix selects the DynamoDB JSON API version
+const targetPrefix = "DynamoDB_20120810."
+
+// Client sends DynamoDB JSON API requests
+type Client struct {
+	cfg    aws.Config
+	signer *v4.Signer
+}
+
+// New creates a client. Requests go to cfg.BaseEndpoint when set, the
+// regional endpoint otherwise.
+func New(cfg aws.Config) *Client {
+	return &Client{cfg: cfg, signer: v4.NewSigner()}
+}
+
+// Region is the region requests are signed for
+func (c *Client) Region() string {
+	return c.cfg.Region
+}
+
+// APIError is an error response of the DynamoDB API
+type APIError struct {
+	Operation string
+	Status    int
+	Type      string // e.g. "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException"
+	Body      []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: HTTP %d: %s", e.Operation, e.Status, e.Body)
+}
+
+// IsType reports whether err is an APIError of the named exception, such
+// as "ConditionalCheckFailedException"
+func IsType(err error, name string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && strings.HasSuffix(apiErr.Type, "#"+name)
+}
+
+// Exception is the name of the exception, e.g.
+// "ConditionalCheckFailedException"
+func (e *APIError) Exception() string {
+	return e.Type[strings.LastIndex(e.Type, "#")+1:]
+}
+
+// Call sends a DynamoDB JSON API request and decodes the response into out
+func (c *Client) Call(ctx context.Context, operation string, params any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://dynamodb.%s.amazonaws.com", c.cfg.Region)
+	if c.cfg.BaseEndpoint != nil {
+		endpoint = *c.cfg.BaseEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", targetPrefix+operation)
+
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), signingName, c.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	httpClient := c.cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", operation, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Type string `json:"__type"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return &APIError{Operation: operation, Status: resp.StatusCode, Type: apiErr.Type, Body: bytes.TrimSpace(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
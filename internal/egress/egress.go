@@ -0,0 +1,125 @@
+// Package egress checks that the bot runs where its exchange API keys
+// expect it to: leaving from an allowlisted IP, such as a NAT gateway's,
+// and in the expected AWS region. A redeploy into another subnet otherwise
+// shows up only as a confusing authentication error from the exchange.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// Timeout bounds the echo request, which only informs the check
+const Timeout = 5 * time.Second
+
+// Region returns the AWS region the function runs in, from the environment
+// Lambda sets, or "" outside AWS
+func Region() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// lookup is the answer of an echo endpoint
+type lookup struct {
+	ip  netip.Addr
+	err error
+}
+
+type cacheKey struct{}
+
+// cache holds the lookups of one invocation by echo URL
+type cache struct {
+	mu      sync.Mutex
+	lookups map[string]lookup
+}
+
+// WithCache returns a copy of ctx in which each echo endpoint is asked
+// once, failures included, however many strategies or accounts check
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheKey{}, &cache{lookups: map[string]lookup{}})
+}
+
+// Lookup returns the public IP that requests to echoURL leave from, as the
+// endpoint reports it, asking once per WithCache context
+func Lookup(ctx context.Context, client *http.Client, echoURL string) (netip.Addr, error) {
+	c, _ := ctx.Value(cacheKey{}).(*cache)
+	if c == nil {
+		return ask(ctx, client, echoURL)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l, ok := c.lookups[echoURL]; ok {
+		return l.ip, l.err
+	}
+	ip, err := ask(ctx, client, echoURL)
+	c.lookups[echoURL] = lookup{ip, err}
+	return ip, err
+}
+
+// ask requests echoURL
+func ask(ctx context.Context, client *http.Client, echoURL string) (netip.Addr, error) {
+	if client == nil {
+		client = &http.Client{Timeout: Timeout, Transport: useragent.NewTransport(nil)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, echoURL, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to look up egress IP: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to look up egress IP: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("failed to look up egress IP: %s returned %s", echoURL, resp.Status)
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("failed to look up egress IP: %s returned %q", echoURL, body)
+	}
+	return ip.Unmap(), nil
+}
+
+// Check fails when the function runs outside expected.Region or its
+// egress IP is not one of expected.IPs. An echo endpoint that cannot be
+// asked is only a warning: it says nothing about the deployment.
+func Check(ctx context.Context, client *http.Client, expected config.ExpectedEgress, region string) error {
+	if expected.Region != "" && region != expected.Region {
+		if region == "" {
+			region = "unknown (AWS_REGION is not set)"
+		}
+		return fmt.Errorf("region %s is not the expected %s", region, expected.Region)
+	}
+	prefixes := expected.Prefixes()
+	if len(prefixes) == 0 {
+		return nil
+	}
+	ip, err := Lookup(ctx, client, expected.Echo())
+	if err != nil {
+		run.Warn(ctx, "egress", "lookup", fmt.Errorf("%w; egress IP not checked", err))
+		return nil
+	}
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("egress IP %s not in expected list %s", ip, strings.Join(expected.IPs, ", "))
+}
@@ -0,0 +1,95 @@
+package egress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// echoServer answers every request with body and counts them
+func echoServer(t *testing.T, status int, body string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestCheck(t *testing.T) {
+	server, _ := echoServer(t, http.StatusOK, "3.121.4.5\n")
+	tests := []struct {
+		name     string
+		expected config.ExpectedEgress
+		region   string
+		want     string
+	}{
+		{name: "listed", expected: config.ExpectedEgress{IPs: []string{"52.28.0.1", "3.121.4.5"}}},
+		{name: "in range", expected: config.ExpectedEgress{IPs: []string{"3.121.0.0/16"}, Region: "eu-central-1"}, region: "eu-central-1"},
+		{name: "not listed", expected: config.ExpectedEgress{IPs: []string{"52.28.0.1"}}, want: "egress IP 3.121.4.5 not in expected list 52.28.0.1"},
+		{name: "other region", expected: config.ExpectedEgress{Region: "eu-central-1"}, region: "us-east-1", want: "region us-east-1 is not the expected eu-central-1"},
+		{name: "outside AWS", expected: config.ExpectedEgress{Region: "eu-central-1"}, want: "AWS_REGION is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expected.EchoURL = server.URL
+			err := Check(context.Background(), server.Client(), tt.expected, tt.region)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Check() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck_EchoDown(t *testing.T) {
+	answers := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusServiceUnavailable, ""},
+		{http.StatusOK, "<html>"},
+	}
+	for _, answer := range answers {
+		server, _ := echoServer(t, answer.status, answer.body)
+		warnings := &run.Warnings{}
+		ctx := run.WithWarnings(context.Background(), warnings)
+		err := Check(ctx, server.Client(), config.ExpectedEgress{IPs: []string{"3.121.4.5"}, EchoURL: server.URL}, "")
+		if err != nil {
+			t.Errorf("Check() error = %v, want the run to continue", err)
+		}
+		if list := warnings.List(); len(list) != 1 || !strings.Contains(list[0].Error, "egress IP not checked") {
+			t.Errorf("warnings = %+v, want one about the lookup", list)
+		}
+	}
+}
+
+func TestLookup_Cached(t *testing.T) {
+	server, calls := echoServer(t, http.StatusOK, "2a05:d014::1")
+	ctx := WithCache(context.Background())
+	for range 3 {
+		ip, err := Lookup(ctx, server.Client(), server.URL)
+		if err != nil || ip.String() != "2a05:d014::1" {
+			t.Fatalf("Lookup() = %s, %v", ip, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("echo endpoint asked %d times, want once per invocation", calls.Load())
+	}
+	if _, err := Lookup(context.Background(), server.Client(), server.URL); err != nil || calls.Load() != 2 {
+		t.Errorf("Lookup() without a cache asked %d times, error %v", calls.Load(), err)
+	}
+}
@@ -0,0 +1,213 @@
+// Package entrypoint adapts the invocation Handler to the platforms the
+// binary runs on: the Lambda runtime, an HTTP server for Cloud Functions,
+// Azure Functions and plain containers, and the local event file. Every
+// adapter runs the same handler and renders the same ExecutionResult.
+package entrypoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// MaxBodySize bounds the payload an HTTP request can carry
+const MaxBodySize = 1 << 20
+
+// shutdownGrace is how long Serve waits for a run in flight when the
+// platform stops the server
+const shutdownGrace = 30 * time.Second
+
+// Invoke runs h on event and returns the result the handler recorded. The
+// result is nil when the run failed before it started one, e.g. because
+// the payload did not parse.
+func Invoke(ctx context.Context, h handler.Handler, event json.RawMessage) (*result.ExecutionResult, error) {
+	res, _, err := invoke(ctx, h, event)
+	return res, err
+}
+
+// invoke is Invoke, also returning the step the run started last. The run
+// gets its timing recorder here so the step is known even when the run
+// failed before it started a result.
+func invoke(ctx context.Context, h handler.Handler, event json.RawMessage) (*result.ExecutionResult, string, error) {
+	ctx = result.WithScope(ctx)
+	rec := run.RecorderFrom(ctx)
+	if rec == nil {
+		rec = run.NewRecorder()
+		ctx = run.WithRecorder(ctx, rec)
+	}
+	err := h(ctx, event)
+	return result.FromContext(ctx), rec.LastStep(), err
+}
+
+// Render writes res as indented JSON
+func Render(w io.Writer, res *result.ExecutionResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// Lambda adapts h for lambda.Start; the run's result is the invocation's
+// response. A failed run returns a *RunError, as the runtime drops the
+// response of a failed invocation.
+func Lambda(h handler.Handler) func(ctx context.Context, event json.RawMessage) (*result.ExecutionResult, error) {
+	return func(ctx context.Context, event json.RawMessage) (*result.ExecutionResult, error) {
+		res, step, err := invoke(ctx, h, event)
+		if err != nil {
+			return res, &RunError{Err: err, body: newErrorBody(res, step, err)}
+		}
+		return res, nil
+	}
+}
+
+// RunError is the error of a failed Lambda invocation. Its message is the
+// error body of the run as JSON, e.g. {"error":"...","step":"exchange.order",
+// ...}, so callers such as Step Functions can read the failing step from
+// the errorMessage of the response.
+type RunError struct {
+	Err  error
+	body errorBody
+}
+
+func (e *RunError) Error() string {
+	data, err := json.Marshal(e.body)
+	if err != nil {
+		return e.Err.Error()
+	}
+	return string(data)
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Local runs h once on the event in path and renders the result to w; a
+// run that failed before it started a result renders its error body
+func Local(ctx context.Context, h handler.Handler, path string, w io.Writer) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read event file: %w", err)
+	}
+	res, step, err := invoke(ctx, h, data)
+	var renderErr error
+	switch {
+	case res != nil:
+		renderErr = Render(w, res)
+	case err != nil:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		renderErr = enc.Encode(newErrorBody(nil, step, err))
+	}
+	if renderErr != nil {
+		log.Printf("⚠️ Failed to render result: %v", renderErr)
+	}
+	return err
+}
+
+// errorBody summarizes a failed run: the error, the step it failed in and,
+// when the run started a result, which run it was
+type errorBody struct {
+	Error       string        `json:"error"`
+	Step        string        `json:"step,omitempty"`
+	ExecutionID string        `json:"executionId,omitempty"`
+	Status      result.Status `json:"status,omitempty"`
+}
+
+// newErrorBody returns the error body of a run that failed with err, res
+// being its result, if any, and step the step it started last
+func newErrorBody(res *result.ExecutionResult, step string, err error) errorBody {
+	body := errorBody{Error: err.Error(), Step: step}
+	if res != nil {
+		body.ExecutionID, body.Status = res.ExecutionID, res.Status
+		if res.Step != "" {
+			body.Step = res.Step
+		}
+	}
+	return body
+}
+
+// HTTP adapts h to an HTTP server: the body of a POST is the invocation
+// event and the response is the run's result as JSON, with status 500 when
+// the run failed. Runs are detached from the request, so a client that
+// hangs up does not cancel an order in flight, and they run one at a time,
+// like invocations of a single Lambda instance.
+//
+// Azure Functions custom handlers must set enableForwardingHttpRequest so
+// the request arrives unwrapped.
+func HTTP(h handler.Handler) http.Handler {
+	var mu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		event, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to read payload", http.StatusBadRequest)
+			return
+		}
+		if len(event) == 0 {
+			http.Error(w, "empty payload", http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		res, step, err := invoke(context.WithoutCancel(r.Context()), h, event)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		status := http.StatusOK
+		if err != nil {
+			status = http.StatusInternalServerError
+		}
+		w.WriteHeader(status)
+		if res == nil && err != nil {
+			json.NewEncoder(w).Encode(newErrorBody(nil, step, err))
+			return
+		}
+		if err := Render(w, res); err != nil {
+			log.Printf("⚠️ Failed to write result: %v", err)
+		}
+	})
+}
+
+// Serve runs an HTTP server for h on addr until ctx is done, then waits for
+// the run in flight before returning
+func Serve(ctx context.Context, addr string, h http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.ListenAndServe()
+	}()
+	log.Printf("🌐 Listening on %s", addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	log.Printf("🛑 Shutting down, waiting for the run in flight")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,477 @@
+package entrypoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/settle"
+	"github.com/sudowanderer/dca-bot-go/internal/threshold"
+)
+
+// fakeHandler records a result for the symbol in the event, like
+// handleRequest does, and returns err. Events that are not JSON objects
+// fail before a result is started.
+func fakeHandler(err error) handler.Handler {
+	return func(ctx context.Context, event json.RawMessage) error {
+		var payload struct {
+			Symbol string `json:"symbol"`
+		}
+		if jsonErr := json.Unmarshal(event, &payload); jsonErr != nil {
+			return jsonErr
+		}
+		res := &result.ExecutionResult{Symbol: payload.Symbol}
+		res.Finish(err)
+		result.Record(ctx, res)
+		return err
+	}
+}
+
+func decodeResult(t *testing.T, data []byte) *result.ExecutionResult {
+	t.Helper()
+	var res result.ExecutionResult
+	if err := json.Unmarshal(data, &res); err != nil {
+		t.Fatalf("response is not a result: %v\n%s", err, data)
+	}
+	return &res
+}
+
+func TestInvoke_WithoutRecordedResult(t *testing.T) {
+	res, err := Invoke(context.Background(), fakeHandler(nil), json.RawMessage(`[]`))
+	if err == nil {
+		t.Fatal("Invoke() error = nil, want the parse error")
+	}
+	if res != nil {
+		t.Errorf("Invoke() result = %+v, want nil", res)
+	}
+}
+
+func TestLambda(t *testing.T) {
+	res, err := Lambda(fakeHandler(nil))(context.Background(), json.RawMessage(`{"symbol":"BTC-USDT"}`))
+	if err != nil {
+		t.Fatalf("Lambda() error = %v", err)
+	}
+	if res == nil || res.Symbol != "BTC-USDT" || res.Status != result.StatusExecuted {
+		t.Errorf("Lambda() result = %+v, want an executed BTC-USDT run", res)
+	}
+}
+
+func TestLambda_Failure(t *testing.T) {
+	sentinel := errors.New("exchange down")
+	res, err := Lambda(fakeHandler(sentinel))(context.Background(), json.RawMessage(`{"symbol":"BTC-USDT"}`))
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("Lambda() error = %v, want %v", err, sentinel)
+	}
+	if res == nil || res.Status != result.StatusFailed {
+		t.Errorf("Lambda() result = %+v, want a failed run", res)
+	}
+}
+
+// dryRunHandler runs a dry-run buy against the mock exchange the way
+// handleRequest does, recording the order, the balance left and the
+// timing of each step
+func dryRunHandler(ctx context.Context, event json.RawMessage) error {
+	_, end := run.StartSpan(ctx, "payload.parse")
+	payload, err := config.ParseDCAPayload(event)
+	end()
+	if err != nil {
+		return fmt.Errorf("failed to parse payload: %w", err)
+	}
+	res := result.New(ctx, payload)
+	mock := &exchange.MockExchange{}
+	orderCtx, end := run.StartSpan(ctx, "exchange.order")
+	res.Order, err = mock.PlaceMarketBuyOrder(orderCtx, payload.Strategy.Symbol, exchange.QuoteSize(decimal.RequireFromString(payload.Strategy.QuoteAmount)))
+	end()
+	if err != nil {
+		return err
+	}
+	balanceCtx, end := run.StartSpan(ctx, "balance.check")
+	balance, err := mock.GetBalanceDetail(balanceCtx, "USDT")
+	end()
+	if err != nil {
+		return err
+	}
+	check, err := threshold.Evaluate(ctx, nil, payload.Strategy.Symbol, balance, payload.Strategy.Threshold())
+	if err != nil {
+		return err
+	}
+	res.Balance = &check
+	res.Finish(nil)
+	res.Timing = run.RecorderFrom(ctx).Timing()
+	result.Record(ctx, res)
+	return nil
+}
+
+const dryRunEvent = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "credentials": {"type": "env", "config": {"apiKeyEnv": "DCA_KEY", "apiSecretEnv": "DCA_SECRET"}}},
+	"strategy": {"symbol": "btc-usdt", "quoteAmount": "10", "balanceThreshold": "50"},
+	"flags": {"dryRun": true}
+}`
+
+// The response of a dry run says what the run did in fields callers such as
+// Step Functions can select by path
+func TestLambda_DryRunResultShape(t *testing.T) {
+	res, err := Lambda(dryRunHandler)(context.Background(), json.RawMessage(dryRunEvent))
+	if err != nil {
+		t.Fatalf("Lambda() error = %v", err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Exchange string        `json:"exchange"`
+		Symbol   string        `json:"symbol"`
+		DryRun   *bool         `json:"dryRun"`
+		Status   result.Status `json:"status"`
+		Order    struct {
+			ID        string `json:"id"`
+			Quantity  string `json:"quantity"`
+			Price     string `json:"price"`
+			FeeAmount string `json:"feeAmount"`
+		} `json:"order"`
+		Balance struct {
+			Balance struct {
+				Asset string `json:"asset"`
+				Free  string `json:"free"`
+			} `json:"balance"`
+			Threshold string `json:"threshold"`
+			Low       *bool  `json:"low"`
+		} `json:"balance"`
+		Timing struct {
+			Phases []struct {
+				Name    string   `json:"name"`
+				TotalMs *float64 `json:"totalMs"`
+			} `json:"phases"`
+		} `json:"timing"`
+		Step  *string `json:"step"`
+		Error *string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("response does not have the documented shape: %v\n%s", err, data)
+	}
+
+	if got.Exchange != "binance" || got.Symbol != "BTC-USDT" || got.DryRun == nil || !*got.DryRun || got.Status != result.StatusExecuted {
+		t.Errorf("run = %s %s dryRun %v %s, want an executed dry run of BTC-USDT on binance", got.Exchange, got.Symbol, got.DryRun, got.Status)
+	}
+	if got.Order.ID == "" || got.Order.Quantity == "" || got.Order.Price == "" || got.Order.FeeAmount == "" {
+		t.Errorf("order = %+v, want its ID, filled quantity, average price and fees", got.Order)
+	}
+	if got.Balance.Balance.Asset != "USDT" || got.Balance.Balance.Free == "" || got.Balance.Threshold != "50" || got.Balance.Low == nil {
+		t.Errorf("balance = %+v, want the USDT left and whether it is below 50", got.Balance)
+	}
+	var phases []string
+	for _, p := range got.Timing.Phases {
+		if p.TotalMs == nil {
+			t.Errorf("phase %s has no totalMs", p.Name)
+		}
+		phases = append(phases, p.Name)
+	}
+	if want := []string{"payload.parse", "exchange.order", "balance.check"}; fmt.Sprint(phases) != fmt.Sprint(want) {
+		t.Errorf("timing phases = %v, want %v", phases, want)
+	}
+	if got.Step != nil || got.Error != nil {
+		t.Errorf("successful run has step %v and error %v, want neither", got.Step, got.Error)
+	}
+}
+
+func TestLambda_FailureBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		wantStep string
+		started  bool
+	}{
+		{name: "before a result", event: `{"version": "v2"}`, wantStep: "payload.parse"},
+		{name: "with a result", event: dryRunEvent, wantStep: "balance.check", started: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := func(ctx context.Context, event json.RawMessage) error {
+				if err := dryRunHandler(ctx, event); err != nil {
+					return err
+				}
+				// Fail after the balance check, like a run whose balance
+				// was unreadable
+				err := errors.New("balance unavailable")
+				result.FromContext(ctx).Finish(err)
+				return err
+			}
+			_, err := Lambda(h)(context.Background(), json.RawMessage(tt.event))
+			var runErr *RunError
+			if !errors.As(err, &runErr) {
+				t.Fatalf("Lambda() error = %v, want a *RunError", err)
+			}
+			var body errorBody
+			if jsonErr := json.Unmarshal([]byte(err.Error()), &body); jsonErr != nil {
+				t.Fatalf("error message is not an error body: %v\n%s", jsonErr, err)
+			}
+			if body.Error == "" || body.Step != tt.wantStep {
+				t.Errorf("body = %+v, want an error at %s", body, tt.wantStep)
+			}
+			if started := body.Status == result.StatusFailed; started != tt.started {
+				t.Errorf("body status = %q, want a result %v", body.Status, tt.started)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local_event.json")
+	if err := os.WriteFile(path, []byte(`{"symbol":"ETH-USDT"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Local(context.Background(), fakeHandler(nil), path, &out); err != nil {
+		t.Fatalf("Local() error = %v", err)
+	}
+	if res := decodeResult(t, out.Bytes()); res.Symbol != "ETH-USDT" {
+		t.Errorf("rendered symbol = %q, want ETH-USDT", res.Symbol)
+	}
+}
+
+func TestLocal_FailureStillRendersResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local_event.json")
+	if err := os.WriteFile(path, []byte(`{"symbol":"ETH-USDT"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sentinel := errors.New("exchange down")
+	var out bytes.Buffer
+	if err := Local(context.Background(), fakeHandler(sentinel), path, &out); !errors.Is(err, sentinel) {
+		t.Fatalf("Local() error = %v, want %v", err, sentinel)
+	}
+	if res := decodeResult(t, out.Bytes()); res.Error != sentinel.Error() {
+		t.Errorf("rendered error = %q, want %q", res.Error, sentinel.Error())
+	}
+}
+
+func TestLocal_MissingFile(t *testing.T) {
+	called := false
+	h := func(ctx context.Context, event json.RawMessage) error {
+		called = true
+		return nil
+	}
+	var out bytes.Buffer
+	if err := Local(context.Background(), h, filepath.Join(t.TempDir(), "missing.json"), &out); err == nil {
+		t.Fatal("Local() error = nil, want a read error")
+	}
+	if called || out.Len() > 0 {
+		t.Error("Local() ran the handler without an event")
+	}
+}
+
+func TestLocal_FailureBeforeResultRendersErrorBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "local_event.json")
+	if err := os.WriteFile(path, []byte(`{"version": "v2"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := Local(context.Background(), dryRunHandler, path, &out); err == nil {
+		t.Fatal("Local() error = nil, want the parse error")
+	}
+	var body errorBody
+	if err := json.Unmarshal(out.Bytes(), &body); err != nil || body.Error == "" || body.Step != "payload.parse" {
+		t.Errorf("rendered %s, want an error body at payload.parse", out.Bytes())
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"symbol":"BTC-USDT"}`))
+	HTTP(fakeHandler(nil)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if res := decodeResult(t, rec.Body.Bytes()); res.Symbol != "BTC-USDT" || res.Status != result.StatusExecuted {
+		t.Errorf("result = %+v, want an executed BTC-USDT run", res)
+	}
+}
+
+func TestHTTP_FailedRun(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"symbol":"BTC-USDT"}`))
+	HTTP(fakeHandler(errors.New("exchange down"))).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if res := decodeResult(t, rec.Body.Bytes()); res.Status != result.StatusFailed {
+		t.Errorf("status = %q, want failed", res.Status)
+	}
+}
+
+func TestHTTP_FailedBeforeResult(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`"not a payload"`))
+	HTTP(fakeHandler(nil)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	var body errorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" {
+		t.Errorf("body = %s, want an error object", rec.Body)
+	}
+}
+
+func TestHTTP_RejectedRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		want   int
+	}{
+		{"get", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"empty body", http.MethodPost, "", http.StatusBadRequest},
+		{"too large", http.MethodPost, strings.Repeat(" ", MaxBodySize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			h := func(ctx context.Context, event json.RawMessage) error {
+				called = true
+				return nil
+			}
+			rec := httptest.NewRecorder()
+			HTTP(h).ServeHTTP(rec, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if called {
+				t.Error("handler ran for a rejected request")
+			}
+		})
+	}
+}
+
+func TestHTTP_ClientHangUpDoesNotCancelRun(t *testing.T) {
+	reqCtx, cancel := context.WithCancel(context.Background())
+	var runErr error
+	h := func(ctx context.Context, event json.RawMessage) error {
+		cancel() // the client goes away mid-run
+		runErr = ctx.Err()
+		return nil
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)).WithContext(reqCtx)
+	HTTP(h).ServeHTTP(httptest.NewRecorder(), req)
+
+	if runErr != nil {
+		t.Errorf("run context error = %v, want the run to continue", runErr)
+	}
+}
+
+// slowNotifier hangs on trade notifications until the context ends, like
+// a webhook that stopped answering mid-run, and records the others
+type slowNotifier struct {
+	live []bool // whether each other delivery had a live context
+}
+
+func (n *slowNotifier) Notify(ctx context.Context, event notify.Event) error {
+	if event.Type == notify.EventPostTrade {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	n.live = append(n.live, ctx.Err() == nil)
+	return nil
+}
+
+// Interrupting a run at each of its long steps ends it promptly, with a
+// result that says what happened
+func TestInvoke_CanceledMidRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		step   func(ctx context.Context, res *result.ExecutionResult) error
+		status result.Status
+	}{
+		{
+			name: "credential fetch",
+			step: func(ctx context.Context, res *result.ExecutionResult) error {
+				<-ctx.Done() // a secret lookup honouring ctx
+				return fmt.Errorf("failed to resolve api key: %w", failure.Mark(failure.CodeCredentialsFailed, ctx.Err()))
+			},
+			status: result.StatusCanceled,
+		},
+		{
+			// The order went out, so the run is recorded with the order
+			// pending rather than as canceled
+			name: "order polling",
+			step: func(ctx context.Context, res *result.ExecutionResult) error {
+				mock := &exchange.MockExchange{Settlement: []string{"open"}}
+				order, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+				if err != nil {
+					return err
+				}
+				waited := settle.Waiter{Timeout: time.Hour}.Wait(ctx, mock, order)
+				res.Order, res.PendingSettlement = waited.Order, waited.Provisional
+				return nil
+			},
+			status: result.StatusExecuted,
+		},
+		{
+			name: "notification fan-out",
+			step: func(ctx context.Context, res *result.ExecutionResult) error {
+				return notify.FromContext(ctx).Dispatch(ctx, notify.Event{Type: notify.EventPostTrade, Symbol: "BTC-USDT"})
+			},
+			status: result.StatusCanceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := &slowNotifier{}
+			dispatcher := notify.NewDispatcher(config.NotificationConfig{}, channel)
+			h := handler.Chain(func(ctx context.Context, event json.RawMessage) error {
+				notify.SetDispatcher(ctx, dispatcher)
+				res := &result.ExecutionResult{Symbol: "BTC-USDT"}
+				err := tt.step(ctx, res)
+				res.Finish(err)
+				result.Record(ctx, res)
+				return err
+			}, handler.NotificationScope(), handler.FlushNotifications(), handler.NotifyOnError(func(ctx context.Context, err error) {
+				notify.FromContext(ctx).Dispatch(ctx, notify.Event{Type: notify.EventError, Summary: err.Error()})
+			}), handler.Classify())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			start := time.Now()
+			res, err := Invoke(ctx, h, json.RawMessage(`{}`))
+			if took := time.Since(start); took > time.Second {
+				t.Errorf("run took %s after the cancellation, want it prompt", took)
+			}
+			if res == nil || res.Status != tt.status {
+				t.Fatalf("result = %+v, want %s", res, tt.status)
+			}
+			if tt.status == result.StatusCanceled {
+				if failure.Classify(err) != failure.CodeCanceled {
+					t.Errorf("error = %v (%s), want CANCELED", err, failure.Classify(err))
+				}
+				if len(channel.live) != 1 || !channel.live[0] {
+					t.Errorf("deliveries after the cancellation had live contexts %v, want the error report on one", channel.live)
+				}
+			} else if err != nil || !res.PendingSettlement {
+				t.Errorf("error = %v, pending = %v, want the order recorded as pending", err, res.PendingSettlement)
+			}
+		})
+	}
+}
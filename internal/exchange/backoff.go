@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Waits of NewBackoff: the first retry waits about backoffBase, each later
+// one twice the previous, up to backoffMax
+const (
+	backoffBase = 500 * time.Millisecond
+	backoffMax  = 8 * time.Second
+)
+
+// Backoff retries exchange requests that fail transiently, waiting
+// exponentially longer between attempts, with jitter. The zero value sends
+// each request once.
+type Backoff struct {
+	MaxAttempts int           // attempts in all, the first included
+	Base        time.Duration // wait after the first attempt, doubled after each later one
+	Max         time.Duration // longest wait, unless Retry-After asks for more
+
+	// Sleep waits d or until ctx is done; defaults to a timer
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	// Jitter spreads a wait d; defaults to a random duration in [d/2, d]
+	Jitter func(d time.Duration) time.Duration
+}
+
+// NewBackoff returns the backoff of an exchange client configured by
+// options, exchange.options.maxAttempts
+func NewBackoff(options *config.ExchangeOptions) Backoff {
+	return Backoff{MaxAttempts: options.Attempts(), Base: backoffBase, Max: backoffMax}
+}
+
+// Do calls fn until it succeeds, fails with an error that is not
+// transient, or has failed MaxAttempts times, and returns its last error.
+// A wait that would outlast ctx's deadline, as a long Retry-After can, is
+// not made: Do fails at once with ErrExchangeUnavailable instead.
+// op names the request in the log, which records the attempts and the
+// time spent retrying.
+func (b Backoff) Do(ctx context.Context, op string, fn func() error) error {
+	var start time.Time
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !IsTransient(err) || attempt >= b.MaxAttempts || ctx.Err() != nil {
+			if attempt > 1 {
+				outcome := "succeeded"
+				if err != nil {
+					outcome = "failed"
+				}
+				log.Printf("🔁 %s %s after attempts=%d retryTime=%s", op, outcome, attempt, time.Since(start).Round(time.Millisecond))
+			}
+			return err
+		}
+		if attempt == 1 {
+			start = time.Now()
+		}
+		wait := b.wait(attempt, err)
+		if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+			log.Printf("🔁 %s failed transiently (attempt=%d of %d), not retrying: the %s wait outlasts the deadline", op, attempt, b.MaxAttempts, wait.Round(time.Millisecond))
+			return fmt.Errorf("%w: %s cannot wait %s before the deadline: %w", ErrExchangeUnavailable, op, wait.Round(time.Millisecond), err)
+		}
+		log.Printf("🔁 %s failed transiently (attempt=%d of %d), retrying in %s: %v", op, attempt, b.MaxAttempts, wait.Round(time.Millisecond), err)
+		if sleepErr := b.sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// wait returns the wait after the failed attempt: what a Retry-After
+// header asked for, or the exponential backoff with jitter
+func (b Backoff) wait(attempt int, err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter
+	}
+	d := b.Base << (attempt - 1)
+	if d > b.Max || d <= 0 {
+		d = b.Max
+	}
+	if b.Jitter != nil {
+		return b.Jitter(d)
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func (b Backoff) sleep(ctx context.Context, d time.Duration) error {
+	if b.Sleep != nil {
+		return b.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsTransient reports whether a request that failed with err may succeed
+// when sent again soon: timeouts, 5xx and 429 responses, and connections
+// reset or closed mid-response. Maintenance, authentication and validation
+// failures are not.
+func IsTransient(err error) bool {
+	if err == nil || IsMaintenance(err) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	}
+	return IsTimeout(err) || isConnectionLost(err) || isConnectionRefused(err)
+}
+
+// mayHaveExecuted reports whether a request that failed with err may have
+// been carried out by the exchange: after a timeout, a 5xx or a lost
+// connection its outcome is unknown, while a 429 or a refused connection
+// was never processed
+func mayHaveExecuted(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return !isConnectionRefused(err)
+}
+
+// isConnectionLost reports whether err is a connection reset or closed
+// before the response was read
+func isConnectionLost(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// isConnectionRefused reports whether err is a failure to connect, before
+// the request was sent
+func isConnectionRefused(err error) bool {
+	var opErr *net.OpError
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &opErr) && opErr.Op == "dial" && !opErr.Timeout()
+}
+
+// withRetryAfter records the Retry-After header of a response in the
+// *HTTPError err carries, if any
+func withRetryAfter(err error, header http.Header) error {
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		return err
+	}
+	value := header.Get("Retry-After")
+	if seconds, parseErr := strconv.Atoi(value); parseErr == nil && seconds > 0 {
+		httpErr.RetryAfter = time.Duration(seconds) * time.Second
+	} else if at, parseErr := http.ParseTime(value); parseErr == nil {
+		httpErr.RetryAfter = max(time.Until(at), 0)
+	}
+	return err
+}
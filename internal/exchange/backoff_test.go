@@ -0,0 +1,312 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// reply is one scripted answer of scriptedTransport: a response, or err
+type reply struct {
+	status int
+	body   string
+	header http.Header
+	err    error
+}
+
+// scriptedTransport answers the requests to each "METHOD /path" with its
+// replies in turn, repeating the last, and records the requests sent
+type scriptedTransport struct {
+	replies map[string][]reply
+	sent    []string
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Method + " " + req.URL.Path
+	s.sent = append(s.sent, key)
+	replies := s.replies[key]
+	if len(replies) == 0 {
+		return nil, fmt.Errorf("unexpected request %s", key)
+	}
+	r := replies[0]
+	if len(replies) > 1 {
+		s.replies[key] = replies[1:]
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	header := r.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{StatusCode: r.status, Header: header, Body: io.NopCloser(strings.NewReader(r.body)), Request: req}, nil
+}
+
+// retryingBinance returns a Binance client sending through transport that
+// retries up to attempts times, recording its waits instead of sleeping
+func retryingBinance(transport *scriptedTransport, attempts int, waits *[]time.Duration) *BinanceExchange {
+	return &BinanceExchange{
+		BaseURL:    "https://binance.test",
+		APIKey:     "key",
+		APISecret:  "secret",
+		Now:        func() time.Time { return binanceNow },
+		HTTPClient: &http.Client{Transport: transport},
+		Retry: Backoff{
+			MaxAttempts: attempts,
+			Base:        100 * time.Millisecond,
+			Max:         time.Second,
+			Sleep: func(ctx context.Context, d time.Duration) error {
+				*waits = append(*waits, d)
+				return nil
+			},
+			Jitter: func(d time.Duration) time.Duration { return d },
+		},
+	}
+}
+
+const binanceAccountBody = `{"balances":[{"asset":"USDT","free":"120.5","locked":"0"}]}`
+
+func TestBinanceExchange_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempts  int
+		replies   []reply
+		wantWaits []time.Duration
+		wantSent  int
+		wantErr   bool
+	}{
+		{
+			name:      "5xx then success",
+			attempts:  4,
+			replies:   []reply{{status: 502, body: "Bad Gateway"}, {status: 503, body: "Service Unavailable"}, {status: 200, body: binanceAccountBody}},
+			wantWaits: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			wantSent:  3,
+		},
+		{
+			name:      "gives up after max attempts",
+			attempts:  3,
+			replies:   []reply{{status: 500, body: "Internal Server Error"}},
+			wantWaits: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			wantSent:  3,
+			wantErr:   true,
+		},
+		{
+			name:      "backoff is capped",
+			attempts:  6,
+			replies:   []reply{{status: 504}, {status: 504}, {status: 504}, {status: 504}, {status: 504}, {status: 200, body: binanceAccountBody}},
+			wantWaits: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second},
+			wantSent:  6,
+		},
+		{
+			name:      "429 waits as Retry-After says",
+			attempts:  3,
+			replies:   []reply{{status: 429, body: `{"code":-1003,"msg":"Too many requests"}`, header: http.Header{"Retry-After": {"7"}}}, {status: 200, body: binanceAccountBody}},
+			wantWaits: []time.Duration{7 * time.Second},
+			wantSent:  2,
+		},
+		{
+			name:      "timeout",
+			attempts:  3,
+			replies:   []reply{{err: timeoutError{}}, {status: 200, body: binanceAccountBody}},
+			wantWaits: []time.Duration{100 * time.Millisecond},
+			wantSent:  2,
+		},
+		{
+			name:      "connection reset",
+			attempts:  3,
+			replies:   []reply{{err: &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, {status: 200, body: binanceAccountBody}},
+			wantWaits: []time.Duration{100 * time.Millisecond},
+			wantSent:  2,
+		},
+		{
+			name:     "auth failure fails fast",
+			attempts: 3,
+			replies:  []reply{{status: 401, body: `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`}},
+			wantSent: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "validation failure fails fast",
+			attempts: 3,
+			replies:  []reply{{status: 400, body: `{"code":-1102,"msg":"Mandatory parameter 'symbol' was not sent."}`}},
+			wantSent: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "maintenance fails fast",
+			attempts: 3,
+			replies:  []reply{{status: 503, body: `{"code":-1001,"msg":"Internal error; unable to process your request. Please try again."}`}},
+			wantSent: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "retries disabled",
+			attempts: 1,
+			replies:  []reply{{status: 502}},
+			wantSent: 1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &scriptedTransport{replies: map[string][]reply{"GET /api/v3/account": tt.replies}}
+			var waits []time.Duration
+			balance, err := retryingBinance(transport, tt.attempts, &waits).GetBalance(context.Background(), "USDT")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetBalance() = %s, %v, want error %v", balance, err, tt.wantErr)
+			}
+			if !tt.wantErr && !balance.Equal(decimal.RequireFromString("120.5")) {
+				t.Errorf("GetBalance() = %s, want 120.5", balance)
+			}
+			if len(transport.sent) != tt.wantSent {
+				t.Errorf("sent %d requests, want %d", len(transport.sent), tt.wantSent)
+			}
+			if !reflect.DeepEqual(waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestBackoff_RetryAfterPastDeadline(t *testing.T) {
+	transport := &scriptedTransport{replies: map[string][]reply{
+		"GET /api/v3/account": {{status: 429, body: `{"code":-1003,"msg":"Too many requests"}`, header: http.Header{"Retry-After": {"60"}}}, {status: 200, body: binanceAccountBody}},
+	}}
+	var waits []time.Duration
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := retryingBinance(transport, 3, &waits).GetBalance(ctx, "USDT")
+	if !IsUnavailable(err) {
+		t.Fatalf("GetBalance() error = %v, want unavailable", err)
+	}
+	if len(transport.sent) != 1 || len(waits) != 0 {
+		t.Errorf("sent %v with waits %v, want no wait past the deadline", transport.sent, waits)
+	}
+}
+
+func TestBinanceExchange_RetriesMarketData(t *testing.T) {
+	transport := &scriptedTransport{replies: map[string][]reply{
+		"GET /api/v3/ticker/price": {{status: 502}, {status: 200, body: `{"symbol":"BTCUSDT","price":"62500.10"}`}},
+	}}
+	var waits []time.Duration
+	price, err := retryingBinance(transport, 3, &waits).LastPrice(context.Background(), "BTC-USDT")
+	if err != nil || !price.Equal(decimal.RequireFromString("62500.10")) {
+		t.Fatalf("LastPrice() = %s, %v, want 62500.10", price, err)
+	}
+	if len(waits) != 1 {
+		t.Errorf("waits = %v, want one retry", waits)
+	}
+}
+
+func TestBinanceExchange_RetriesOrderPlacement(t *testing.T) {
+	full, err := os.ReadFile(filepath.Join("testdata", "binance_order_full.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const notFound = `{"code":-2013,"msg":"Order does not exist."}`
+	tests := []struct {
+		name        string
+		replies     map[string][]reply
+		wantSent    []string
+		wantUnknown bool
+	}{
+		{
+			// The request was refused before Binance processed it, so it
+			// is sent again without a lookup
+			name:     "rate limited",
+			replies:  map[string][]reply{"POST /api/v3/order": {{status: 429, body: `{"code":-1003,"msg":"Too many requests"}`}, {status: 200, body: string(full)}}},
+			wantSent: []string{"POST /api/v3/order", "POST /api/v3/order"},
+		},
+		{
+			name: "timed out before it was placed",
+			replies: map[string][]reply{
+				"POST /api/v3/order": {{err: timeoutError{}}, {status: 200, body: string(full)}},
+				"GET /api/v3/order":  {{status: 400, body: notFound}},
+			},
+			wantSent: []string{"POST /api/v3/order", "GET /api/v3/order", "POST /api/v3/order"},
+		},
+		{
+			name: "timed out after it was placed",
+			replies: map[string][]reply{
+				"POST /api/v3/order": {{status: 502, body: "Bad Gateway"}},
+				"GET /api/v3/order":  {{status: 200, body: string(full)}},
+			},
+			wantSent: []string{"POST /api/v3/order", "GET /api/v3/order"},
+		},
+		{
+			name: "outcome still unknown",
+			replies: map[string][]reply{
+				"POST /api/v3/order": {{err: timeoutError{}}},
+				"GET /api/v3/order":  {{status: 502, body: "Bad Gateway"}},
+			},
+			wantSent:    []string{"POST /api/v3/order", "GET /api/v3/order", "GET /api/v3/order"},
+			wantUnknown: true,
+		},
+		{
+			name:     "rejected",
+			replies:  map[string][]reply{"POST /api/v3/order": {{status: 400, body: `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`}}},
+			wantSent: []string{"POST /api/v3/order"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &scriptedTransport{replies: tt.replies}
+			var waits []time.Duration
+			ctx := run.WithID(context.Background(), "01ARYZ6S41TSV4RRFFQ69G5FAV")
+			order, err := retryingBinance(transport, 3, &waits).PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.RequireFromString("50")))
+			if !reflect.DeepEqual(transport.sent, tt.wantSent) {
+				t.Errorf("sent %v, want %v", transport.sent, tt.wantSent)
+			}
+			if got := errors.Is(err, ErrOrderOutcomeUnknown); got != tt.wantUnknown {
+				t.Errorf("error = %v, outcome unknown %v, want %v", err, got, tt.wantUnknown)
+			}
+			if err == nil && order.ID != "28457112" {
+				t.Errorf("order ID = %s, want 28457112", order.ID)
+			}
+		})
+	}
+}
+
+func TestBackoff_StopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	b := Backoff{MaxAttempts: 5, Base: time.Hour, Max: time.Hour}
+	err := b.Do(ctx, "test", func() error {
+		calls++
+		cancel()
+		return &HTTPError{StatusCode: 503}
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("Do() = %v after %d calls, want the first error", err, calls)
+	}
+}
+
+func TestNewBackoff(t *testing.T) {
+	if b := NewBackoff(nil); b.MaxAttempts != config.DefaultMaxAttempts || b.Base != backoffBase || b.Max != backoffMax {
+		t.Errorf("NewBackoff(nil) = %+v, want the defaults", b)
+	}
+	if b := NewBackoff(&config.ExchangeOptions{MaxAttempts: 5}); b.MaxAttempts != 5 {
+		t.Errorf("NewBackoff() attempts = %d, want 5", b.MaxAttempts)
+	}
+	// Jitter keeps waits between half and all of the backoff
+	for range 100 {
+		if d := (Backoff{Base: time.Second, Max: time.Second}).wait(1, errors.New("x")); d < 500*time.Millisecond || d > time.Second {
+			t.Fatalf("wait = %s, want within [500ms, 1s]", d)
+		}
+	}
+}
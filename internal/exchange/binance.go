@@ -0,0 +1,551 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// BinanceTestnetURL is Binance's spot testnet, which options.sandbox selects
+const BinanceTestnetURL = "https://testnet.binance.vision"
+
+// binanceTradePageLimit is the most trades GET /api/v3/myTrades returns
+const binanceTradePageLimit = 1000
+
+// binanceInvalidSymbol is the error code of a symbol Binance does not list
+const binanceInvalidSymbol = "-1121"
+
+// binanceOrderNotFound is the error code of a lookup of an unknown order
+const binanceOrderNotFound = "-2013"
+
+// BinanceExchange trades Binance spot through its signed REST API
+type BinanceExchange struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+	Retry      Backoff          // retries of transient failures; the zero value sends each request once
+
+	symbols symbolCache // exchangeInfo read by GetSymbolInfo
+}
+
+// NewBinanceExchange creates a Binance client for the account of creds;
+// options.sandbox selects the spot testnet
+func NewBinanceExchange(cfg *config.DCAPayload, creds Credentials) (Exchange, error) {
+	if creds.APIKey == "" || creds.APISecret == "" {
+		return nil, fmt.Errorf("binance needs an apiKey and apiSecret")
+	}
+	baseURL := BinanceBaseURL
+	if options := cfg.Exchange.Options; options != nil && options.Sandbox {
+		baseURL = BinanceTestnetURL
+	}
+	return &BinanceExchange{
+		BaseURL:    baseURL,
+		APIKey:     creds.APIKey,
+		APISecret:  creds.APISecret,
+		HTTPClient: newHTTPClient(),
+		Retry:      NewBackoff(cfg.Exchange.Options),
+	}, nil
+}
+
+// binanceSymbol converts a payload symbol such as "BTC-USDT" to Binance's
+// "BTCUSDT"
+func binanceSymbol(symbol string) string {
+	return strings.ReplaceAll(symbol, "-", "")
+}
+
+// Capabilities returns clientCapabilities["binance"]
+func (b *BinanceExchange) Capabilities() Capabilities {
+	return clientCapabilities["binance"]
+}
+
+// GetBalance returns the free balance of asset
+func (b *BinanceExchange) GetBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	balance, err := b.GetBalanceDetail(ctx, asset)
+	return balance.Free, err
+}
+
+// GetBalanceDetail returns the free and locked balance of code from the
+// account; an asset the account never held has a zero balance
+func (b *BinanceExchange) GetBalanceDetail(ctx context.Context, code string) (Balance, error) {
+	var account struct {
+		Balances []struct {
+			Asset  string          `json:"asset"`
+			Free   decimal.Decimal `json:"free"`
+			Locked decimal.Decimal `json:"locked"`
+		} `json:"balances"`
+	}
+	params := url.Values{"omitZeroBalances": {"true"}}
+	if err := b.signed(ctx, http.MethodGet, "/api/v3/account", params, &account); err != nil {
+		return Balance{}, err
+	}
+	for _, balance := range account.Balances {
+		if strings.EqualFold(balance.Asset, code) {
+			return NewBalance(balance.Asset, balance.Free, balance.Locked), nil
+		}
+	}
+	return NewBalance(strings.ToUpper(code), decimal.Zero, decimal.Zero), nil
+}
+
+// PlaceMarketBuyOrder places a market buy of size, with quoteOrderQty for a
+// quote amount, and returns it as filled by the FULL response
+func (b *BinanceExchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, size OrderSize) (*Order, error) {
+	if err := size.validate(); err != nil {
+		return nil, err
+	}
+	params := BinanceMarketBuyParams(symbol, size)
+	params.Set("newClientOrderId", run.ClientOrderID(ctx, "dca"))
+	params.Set("newOrderRespType", "FULL")
+	return b.placeOrder(ctx, symbol, params)
+}
+
+// PlaceMarketSellOrder sells quantity of the symbol's base asset at market
+func (b *BinanceExchange) PlaceMarketSellOrder(ctx context.Context, symbol string, quantity decimal.Decimal) (*Order, error) {
+	params := url.Values{
+		"symbol":           {binanceSymbol(symbol)},
+		"side":             {"SELL"},
+		"type":             {"MARKET"},
+		"quantity":         {quantity.String()},
+		"newClientOrderId": {run.ClientOrderID(ctx, "dca")},
+		"newOrderRespType": {"FULL"},
+	}
+	return b.placeOrder(ctx, symbol, params)
+}
+
+// PlaceStopLossOrder places a STOP_LOSS_LIMIT sell, good till canceled
+func (b *BinanceExchange) PlaceStopLossOrder(ctx context.Context, symbol string, quantity, stopPrice, limitPrice decimal.Decimal, clientOrderID string) (*Order, error) {
+	params := url.Values{
+		"symbol":           {binanceSymbol(symbol)},
+		"side":             {"SELL"},
+		"type":             {"STOP_LOSS_LIMIT"},
+		"timeInForce":      {"GTC"},
+		"quantity":         {quantity.String()},
+		"price":            {limitPrice.String()},
+		"stopPrice":        {stopPrice.String()},
+		"newClientOrderId": {clientOrderID},
+		"newOrderRespType": {"RESULT"},
+	}
+	return b.placeOrder(ctx, symbol, params)
+}
+
+// placeOrder places the order of params, retrying transient failures. An
+// attempt whose outcome is unknown is followed by a lookup of its client
+// order ID, which the retries share, so an order that went through is
+// returned rather than placed twice. When the retries run out with the
+// outcome still unknown, the error is marked ErrOrderOutcomeUnknown.
+func (b *BinanceExchange) placeOrder(ctx context.Context, symbol string, params url.Values) (*Order, error) {
+	var raw json.RawMessage
+	clientOrderID := params.Get("newClientOrderId")
+	unknown := false
+	err := b.Retry.Do(ctx, "binance POST /api/v3/order", func() error {
+		if unknown && clientOrderID != "" {
+			found, err := b.orderByClientID(ctx, symbol, clientOrderID)
+			if err != nil || found != nil {
+				raw = found
+				return err
+			}
+			unknown = false
+		}
+		err := b.send(ctx, http.MethodPost, "/api/v3/order", params, &raw)
+		unknown = err != nil && IsTransient(err) && mayHaveExecuted(err)
+		return err
+	})
+	if err != nil {
+		if unknown && !errors.Is(err, ErrOrderOutcomeUnknown) {
+			return nil, fmt.Errorf("%w: %w", ErrOrderOutcomeUnknown, err)
+		}
+		if isBinanceDuplicate(err) {
+			return nil, fmt.Errorf("%w %s: %w", ErrDuplicateClientOrderID, clientOrderID, err)
+		}
+		return nil, err
+	}
+	return parseBinanceOrder(symbol, raw)
+}
+
+// isBinanceDuplicate reports whether err is Binance rejecting an order
+// whose client order ID an open order already has. Binance only checks open
+// orders, so a filled market order's ID is not caught; see PlaceOnce.
+func isBinanceDuplicate(err error) bool {
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && errorCode([]byte(httpErr.Body)) == "-2010" && strings.Contains(httpErr.Body, "Duplicate order sent")
+}
+
+// GetOrderByClientID looks up the order of symbol placed with
+// clientOrderID, with the fees of what it traded; nil when Binance has none
+func (b *BinanceExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error) {
+	var raw json.RawMessage
+	err := b.Retry.Do(ctx, "binance GET /api/v3/order", func() (err error) {
+		raw, err = b.orderByClientID(ctx, symbol, clientOrderID)
+		return err
+	})
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	order, err := parseBinanceOrder(symbol, raw)
+	if err != nil {
+		return nil, err
+	}
+	return b.GetOrder(ctx, symbol, order.ID)
+}
+
+// orderByClientID looks up the order of symbol placed with clientOrderID;
+// nil when Binance has none
+func (b *BinanceExchange) orderByClientID(ctx context.Context, symbol, clientOrderID string) (json.RawMessage, error) {
+	params := url.Values{"symbol": {binanceSymbol(symbol)}, "origClientOrderId": {clientOrderID}}
+	var raw json.RawMessage
+	err := b.send(ctx, http.MethodGet, "/api/v3/order", params, &raw)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && errorCode([]byte(httpErr.Body)) == binanceOrderNotFound {
+		return nil, nil
+	}
+	return raw, err
+}
+
+// GetOrder looks an order up. The lookup carries no fills, so the fees of
+// an order that traded are read from its trades.
+func (b *BinanceExchange) GetOrder(ctx context.Context, symbol, orderID string) (*Order, error) {
+	params := url.Values{"symbol": {binanceSymbol(symbol)}, "orderId": {orderID}}
+	var raw json.RawMessage
+	if err := b.signed(ctx, http.MethodGet, "/api/v3/order", params, &raw); err != nil {
+		return nil, err
+	}
+	order, err := parseBinanceOrder(symbol, raw)
+	if err != nil || !order.Settled() || !order.Quantity.IsPositive() {
+		return order, err
+	}
+
+	var trades []binanceTrade
+	params = url.Values{"symbol": {binanceSymbol(symbol)}, "orderId": {orderID}}
+	if err := b.signed(ctx, http.MethodGet, "/api/v3/myTrades", params, &trades); err != nil {
+		return nil, fmt.Errorf("failed to get the fees of order %s: %w", orderID, err)
+	}
+	order.Fills = make([]Fill, len(trades))
+	for i, t := range trades {
+		order.Fills[i] = Fill{TradeID: strconv.FormatInt(t.ID, 10), Price: t.Price, Quantity: t.Quantity, FeeAmount: t.Commission, FeeAsset: t.CommissionAsset}
+	}
+	order.FeeAmount, order.FeeAsset = SumFees(order.Fills)
+	return order, nil
+}
+
+// OpenOrders returns the open orders of symbol, stop orders included
+func (b *BinanceExchange) OpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	var raws []json.RawMessage
+	params := url.Values{"symbol": {binanceSymbol(symbol)}}
+	if err := b.signed(ctx, http.MethodGet, "/api/v3/openOrders", params, &raws); err != nil {
+		return nil, err
+	}
+	orders := make([]Order, 0, len(raws))
+	for _, raw := range raws {
+		order, err := parseBinanceOrder(symbol, raw)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
+
+// CancelOrder cancels an open order by its order ID
+func (b *BinanceExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	var canceled json.RawMessage
+	params := url.Values{"symbol": {binanceSymbol(symbol)}, "orderId": {orderID}}
+	return b.signed(ctx, http.MethodDelete, "/api/v3/order", params, &canceled)
+}
+
+// binanceTrade is a trade of GET /api/v3/myTrades
+type binanceTrade struct {
+	ID              int64           `json:"id"`
+	OrderID         int64           `json:"orderId"`
+	Price           decimal.Decimal `json:"price"`
+	Quantity        decimal.Decimal `json:"qty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+	Time            int64           `json:"time"`
+	IsBuyer         bool            `json:"isBuyer"`
+}
+
+// GetMyTrades pages through the trades of symbol in [from, to), a day and
+// 1000 trades at a time. Binance takes either a time range or a trade ID
+// to start from, so later pages of a day are cut at its end here.
+func (b *BinanceExchange) GetMyTrades(ctx context.Context, symbol string, from, to time.Time) ([]Trade, error) {
+	return CollectTrades(ctx, from, to, 24*time.Hour, binanceTradePageLimit, func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error) {
+		params := url.Values{"symbol": {binanceSymbol(symbol)}, "limit": {strconv.Itoa(limit)}}
+		if after == "" {
+			params.Set("startTime", strconv.FormatInt(start.UnixMilli(), 10))
+			params.Set("endTime", strconv.FormatInt(end.UnixMilli()-1, 10))
+		} else {
+			id, err := strconv.ParseInt(after, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trade ID %q", after)
+			}
+			params.Set("fromId", strconv.FormatInt(id+1, 10))
+		}
+		var rows []binanceTrade
+		if err := b.signed(ctx, http.MethodGet, "/api/v3/myTrades", params, &rows); err != nil {
+			return nil, err
+		}
+		trades := make([]Trade, 0, len(rows))
+		for _, row := range rows {
+			t := Trade{
+				ID:        strconv.FormatInt(row.ID, 10),
+				OrderID:   strconv.FormatInt(row.OrderID, 10),
+				Symbol:    symbol,
+				Side:      "sell",
+				Quantity:  row.Quantity,
+				Price:     row.Price,
+				FeeAmount: row.Commission,
+				FeeAsset:  row.CommissionAsset,
+				Time:      time.UnixMilli(row.Time).UTC(),
+			}
+			if row.IsBuyer {
+				t.Side = "buy"
+			}
+			if !t.Time.Before(end) {
+				break
+			}
+			trades = append(trades, t)
+		}
+		return trades, nil
+	})
+}
+
+// LastPrice returns the last traded price of symbol
+func (b *BinanceExchange) LastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	var ticker struct {
+		Price decimal.Decimal `json:"price"`
+	}
+	params := url.Values{"symbol": {binanceSymbol(symbol)}}
+	if err := b.public(ctx, "/api/v3/ticker/price", params, &ticker); err != nil {
+		return decimal.Zero, binanceSymbolErr(symbol, err)
+	}
+	return ticker.Price, nil
+}
+
+// GetTicker returns the best bid and ask of symbol from its book ticker
+// and its last price from the price ticker
+func (b *BinanceExchange) GetTicker(ctx context.Context, symbol string) (Ticker, error) {
+	var book struct {
+		BidPrice decimal.Decimal `json:"bidPrice"`
+		AskPrice decimal.Decimal `json:"askPrice"`
+	}
+	params := url.Values{"symbol": {binanceSymbol(symbol)}}
+	if err := b.public(ctx, "/api/v3/ticker/bookTicker", params, &book); err != nil {
+		return Ticker{}, binanceSymbolErr(symbol, err)
+	}
+	last, err := b.LastPrice(ctx, symbol)
+	if err != nil {
+		return Ticker{}, err
+	}
+	return Ticker{Symbol: symbol, Last: last, Bid: book.BidPrice, Ask: book.AskPrice}, nil
+}
+
+// GetSymbolInfo returns the trading status and rules of symbol. The
+// exchangeInfo of each symbol is read once per client.
+func (b *BinanceExchange) GetSymbolInfo(ctx context.Context, symbol string) (SymbolInfo, error) {
+	return b.symbols.get(binanceSymbol(symbol), func() (SymbolInfo, error) {
+		var raw json.RawMessage
+		params := url.Values{"symbol": {binanceSymbol(symbol)}}
+		if err := b.public(ctx, "/api/v3/exchangeInfo", params, &raw); err != nil {
+			return SymbolInfo{}, binanceSymbolErr(symbol, err)
+		}
+		infos, err := ParseBinanceExchangeInfo(raw)
+		if err != nil {
+			return SymbolInfo{}, err
+		}
+		if len(infos) == 0 {
+			return SymbolInfo{}, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+		}
+		return infos[0], nil
+	})
+}
+
+// LotStep returns the LOT_SIZE step of symbol
+func (b *BinanceExchange) LotStep(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	info, err := b.GetSymbolInfo(ctx, symbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !info.Rules.LotStep.IsPositive() {
+		return DefaultLotStep, nil
+	}
+	return info.Rules.LotStep, nil
+}
+
+// MinNotional returns the NOTIONAL minimum of symbol
+func (b *BinanceExchange) MinNotional(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	info, err := b.GetSymbolInfo(ctx, symbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return info.Rules.MinNotional, nil
+}
+
+// ListSymbols returns every spot symbol Binance lists
+func (b *BinanceExchange) ListSymbols(ctx context.Context) ([]SymbolInfo, error) {
+	var raw json.RawMessage
+	if err := b.public(ctx, "/api/v3/exchangeInfo", url.Values{}, &raw); err != nil {
+		return nil, err
+	}
+	return ParseBinanceExchangeInfo(raw)
+}
+
+// binanceSymbolErr wraps ErrSymbolNotFound into the invalid-symbol error
+func binanceSymbolErr(symbol string, err error) error {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && errorCode([]byte(httpErr.Body)) == binanceInvalidSymbol {
+		return fmt.Errorf("%s: %w: %w", symbol, ErrSymbolNotFound, err)
+	}
+	return err
+}
+
+// binanceOrder is an order as POST and GET /api/v3/order and
+// /api/v3/openOrders return it
+type binanceOrder struct {
+	OrderID             int64           `json:"orderId"`
+	ClientOrderID       string          `json:"clientOrderId"`
+	Price               decimal.Decimal `json:"price"`
+	OrigQty             decimal.Decimal `json:"origQty"`
+	ExecutedQty         decimal.Decimal `json:"executedQty"`
+	CummulativeQuoteQty decimal.Decimal `json:"cummulativeQuoteQty"`
+	Status              string          `json:"status"`
+	Type                string          `json:"type"`
+	Side                string          `json:"side"`
+	StopPrice           decimal.Decimal `json:"stopPrice"`
+	Fills               []binanceFill   `json:"fills"` // FULL responses only
+}
+
+// binanceFill is a trade of an order's FULL response
+type binanceFill struct {
+	TradeID         int64           `json:"tradeId"`
+	Price           decimal.Decimal `json:"price"`
+	Quantity        decimal.Decimal `json:"qty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+}
+
+// parseBinanceOrder converts an order response. Symbol is kept as the
+// caller wrote it. The price of a filled order is the average of its
+// fills, or of the quote spent when the response has none; stop orders
+// keep their limit and quantity instead.
+func parseBinanceOrder(symbol string, raw json.RawMessage) (*Order, error) {
+	var o binanceOrder
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	status, ok := binanceOrderStatuses[o.Status]
+	if !ok {
+		status = strings.ToLower(o.Status)
+	}
+	order := &Order{
+		ID:            strconv.FormatInt(o.OrderID, 10),
+		ClientOrderID: o.ClientOrderID,
+		Symbol:        symbol,
+		Side:          strings.ToLower(o.Side),
+		Type:          strings.ToLower(o.Type),
+		Quantity:      o.ExecutedQty,
+		Status:        status,
+		Raw:           raw,
+	}
+	if order.Type == OrderTypeStopLossLimit {
+		order.Quantity, order.Price, order.StopPrice = o.OrigQty, o.Price, o.StopPrice
+		return order, nil
+	}
+
+	filled, paid := decimal.Zero, decimal.Zero
+	for _, f := range o.Fills {
+		filled = filled.Add(f.Quantity)
+		paid = paid.Add(f.Price.Mul(f.Quantity))
+		order.Fills = append(order.Fills, Fill{
+			TradeID:   strconv.FormatInt(f.TradeID, 10),
+			Price:     f.Price,
+			Quantity:  f.Quantity,
+			FeeAmount: f.Commission,
+			FeeAsset:  f.CommissionAsset,
+		})
+	}
+	switch {
+	case filled.IsPositive():
+		order.Price = paid.Div(filled)
+	case o.ExecutedQty.IsPositive():
+		order.Price = o.CummulativeQuoteQty.Div(o.ExecutedQty)
+	}
+	order.FeeAmount, order.FeeAsset = SumFees(order.Fills)
+	return order, nil
+}
+
+// signed sends a signed request and decodes the JSON response into out.
+// GETs that fail transiently are retried, signed anew each time; requests
+// that change the account are not.
+func (b *BinanceExchange) signed(ctx context.Context, method, path string, params url.Values, out interface{}) error {
+	if method != http.MethodGet {
+		return b.send(ctx, method, path, params, out)
+	}
+	return b.Retry.Do(ctx, "binance GET "+path, func() error {
+		return b.send(ctx, method, path, params, out)
+	})
+}
+
+// send sends a signed request once
+func (b *BinanceExchange) send(ctx context.Context, method, path string, params url.Values, out interface{}) error {
+	return binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, method, path, params, out)
+}
+
+// public sends an unsigned GET for market data, retrying transient
+// failures, and decodes the JSON response into out
+func (b *BinanceExchange) public(ctx context.Context, path string, params url.Values, out interface{}) error {
+	return b.Retry.Do(ctx, "binance GET "+path, func() error {
+		return b.publicOnce(ctx, path, params, out)
+	})
+}
+
+// publicOnce sends an unsigned GET once
+func (b *BinanceExchange) publicOnce(ctx context.Context, path string, params url.Values, out interface{}) error {
+	_, end := run.StartSpan(ctx, "exchange.binance "+path)
+	defer end()
+
+	target := b.BaseURL + path
+	if len(params) > 0 {
+		target += "?" + sign.CanonicalQuery(params)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	useragent.Apply(req)
+
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse("binance", resp.StatusCode, body); err != nil {
+		return withRetryAfter(err, resp.Header)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// BinanceAutoInvest manages single-asset Binance Auto-Invest plans funded
+// from the spot wallet
+type BinanceAutoInvest struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+}
+
+// NewBinanceAutoInvest creates an Auto-Invest client for the production API
+func NewBinanceAutoInvest(apiKey, apiSecret string) *BinanceAutoInvest {
+	return &BinanceAutoInvest{
+		BaseURL:    BinanceBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// binanceWeekdays are Auto-Invest's subscriptionStartWeekday values, by
+// time.Weekday
+var binanceWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+// binanceCycles maps cadence cycles to Auto-Invest's subscriptionCycle
+var binanceCycles = map[string]string{
+	config.CycleDaily:   "DAILY",
+	config.CycleWeekly:  "WEEKLY",
+	config.CycleMonthly: "MONTHLY",
+}
+
+type binancePlan struct {
+	PlanID                   int64           `json:"planId"`
+	Status                   string          `json:"status"`
+	SourceAsset              string          `json:"sourceAsset"`
+	SubscriptionAmount       decimal.Decimal `json:"subscriptionAmount"`
+	SubscriptionCycle        string          `json:"subscriptionCycle"`
+	SubscriptionStartDay     string          `json:"subscriptionStartDay"`
+	SubscriptionStartWeekday string          `json:"subscriptionStartWeekday"`
+	SubscriptionStartTime    string          `json:"subscriptionStartTime"`
+	NextExecutionDateTime    int64           `json:"nextExecutionDateTime"`
+	Details                  []struct {
+		TargetAsset string `json:"targetAsset"`
+	} `json:"details"`
+}
+
+// plan converts an Auto-Invest plan; hourly cycles, which strategies
+// cannot configure, keep an empty cadence cycle
+func (p binancePlan) plan() RecurringPlan {
+	plan := RecurringPlan{
+		ID:          strconv.FormatInt(p.PlanID, 10),
+		SourceAsset: p.SourceAsset,
+		Amount:      p.SubscriptionAmount,
+		Status:      p.Status,
+	}
+	if len(p.Details) == 1 {
+		plan.TargetAsset = p.Details[0].TargetAsset
+	}
+	if p.NextExecutionDateTime > 0 {
+		plan.NextExecution = time.UnixMilli(p.NextExecutionDateTime).UTC()
+	}
+	for cycle, name := range binanceCycles {
+		if name == p.SubscriptionCycle {
+			plan.Cadence.Cycle = cycle
+		}
+	}
+	plan.Cadence.Hour, _ = strconv.Atoi(p.SubscriptionStartTime)
+	switch plan.Cadence.Cycle {
+	case config.CycleWeekly:
+		for i, day := range binanceWeekdays {
+			if day == p.SubscriptionStartWeekday {
+				plan.Cadence.Weekday = time.Weekday(i)
+			}
+		}
+	case config.CycleMonthly:
+		plan.Cadence.Day, _ = strconv.Atoi(p.SubscriptionStartDay)
+	}
+	return plan
+}
+
+// RecurringPlans lists the account's single-asset plans
+func (b *BinanceAutoInvest) RecurringPlans(ctx context.Context) ([]RecurringPlan, error) {
+	var resp struct {
+		Plans []binancePlan `json:"plans"`
+	}
+	params := url.Values{"planType": {"SINGLE"}}
+	if err := binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, http.MethodGet, "/sapi/v1/lending/auto-invest/plan/list", params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list auto-invest plans: %w", err)
+	}
+	plans := make([]RecurringPlan, 0, len(resp.Plans))
+	for _, p := range resp.Plans {
+		plans = append(plans, p.plan())
+	}
+	return plans, nil
+}
+
+// CreateRecurringPlan creates a single-asset plan funded from the spot
+// wallet only
+func (b *BinanceAutoInvest) CreateRecurringPlan(ctx context.Context, plan RecurringPlan) (*RecurringPlan, error) {
+	params, err := planParams(plan)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("sourceType", "MAIN_SITE")
+	params.Set("planType", "SINGLE")
+	params.Set("flexibleAllowedToUse", "false")
+	return b.save(ctx, "/sapi/v1/lending/auto-invest/plan/add", params, plan)
+}
+
+// UpdateRecurringPlan changes the plan with plan.ID
+func (b *BinanceAutoInvest) UpdateRecurringPlan(ctx context.Context, plan RecurringPlan) (*RecurringPlan, error) {
+	params, err := planParams(plan)
+	if err != nil {
+		return nil, err
+	}
+	params.Set("planId", plan.ID)
+	return b.save(ctx, "/sapi/v1/lending/auto-invest/plan/edit", params, plan)
+}
+
+func (b *BinanceAutoInvest) save(ctx context.Context, path string, params url.Values, plan RecurringPlan) (*RecurringPlan, error) {
+	var resp struct {
+		PlanID                int64 `json:"planId"`
+		NextExecutionDateTime int64 `json:"nextExecutionDateTime"`
+	}
+	if err := binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, http.MethodPost, path, params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to save auto-invest plan: %w", err)
+	}
+	plan.ID = strconv.FormatInt(resp.PlanID, 10)
+	plan.NextExecution = time.UnixMilli(resp.NextExecutionDateTime).UTC()
+	return &plan, nil
+}
+
+// planParams are the amount, cadence and assets shared by creating and
+// editing a plan
+func planParams(plan RecurringPlan) (url.Values, error) {
+	cycle, ok := binanceCycles[plan.Cadence.Cycle]
+	if !ok {
+		return nil, fmt.Errorf("unsupported auto-invest cycle %q", plan.Cadence.Cycle)
+	}
+	params := url.Values{
+		"subscriptionAmount":     {plan.Amount.String()},
+		"subscriptionCycle":      {cycle},
+		"subscriptionStartTime":  {strconv.Itoa(plan.Cadence.Hour)},
+		"sourceAsset":            {strings.ToUpper(plan.SourceAsset)},
+		"details[0].targetAsset": {strings.ToUpper(plan.TargetAsset)},
+		"details[0].percentage":  {"100"},
+	}
+	switch plan.Cadence.Cycle {
+	case config.CycleWeekly:
+		params.Set("subscriptionStartWeekday", binanceWeekdays[plan.Cadence.Weekday])
+	case config.CycleMonthly:
+		params.Set("subscriptionStartDay", strconv.Itoa(plan.Cadence.Day))
+	}
+	return params, nil
+}
@@ -0,0 +1,166 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+)
+
+// autoInvestServer serves the fixture for each plan endpoint and records
+// the requests
+func autoInvestServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	t.Helper()
+	fixtures := map[string]string{
+		"GET /sapi/v1/lending/auto-invest/plan/list":  "binance_autoinvest_list.json",
+		"POST /sapi/v1/lending/auto-invest/plan/add":  "binance_autoinvest_add.json",
+		"POST /sapi/v1/lending/auto-invest/plan/edit": "binance_autoinvest_edit.json",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		name, ok := fixtures[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(body)
+	}))
+}
+
+func testBinanceAutoInvest(baseURL string) *BinanceAutoInvest {
+	return &BinanceAutoInvest{
+		BaseURL:   baseURL,
+		APIKey:    "key",
+		APISecret: "secret",
+		Now:       func() time.Time { return time.UnixMilli(1760600000000) },
+	}
+}
+
+func TestBinanceAutoInvest_RecurringPlans(t *testing.T) {
+	var requests []*http.Request
+	server := autoInvestServer(t, &requests)
+	defer server.Close()
+
+	plans, err := testBinanceAutoInvest(server.URL).RecurringPlans(context.Background())
+	if err != nil {
+		t.Fatalf("RecurringPlans() error = %v", err)
+	}
+	if len(plans) != 2 {
+		t.Fatalf("got %d plans, want 2", len(plans))
+	}
+	weekly := RecurringPlan{
+		ID: "3812", SourceAsset: "USDT", TargetAsset: "BTC", Amount: decimal.RequireFromString("50"),
+		Cadence: config.Cadence{Cycle: config.CycleWeekly, Weekday: time.Monday, Hour: 9},
+		Status:  "ONGOING", NextExecution: time.UnixMilli(1760950800000).UTC(),
+	}
+	if p := plans[0]; !p.Same(weekly) || p.ID != weekly.ID || p.Status != weekly.Status || !p.NextExecution.Equal(weekly.NextExecution) {
+		t.Errorf("plans[0] = %+v, want %+v", p, weekly)
+	}
+	monthly := config.Cadence{Cycle: config.CycleMonthly, Day: 1, Hour: 18}
+	if p := plans[1]; !p.Buys("FDUSD", "ETH") || p.Cadence != monthly || !p.Amount.Equal(decimal.RequireFromString("25.5")) || p.Status != "PAUSED" {
+		t.Errorf("plans[1] = %+v, want the paused monthly ETH plan", p)
+	}
+
+	req := requests[0]
+	if req.Header.Get("X-MBX-APIKEY") != "key" {
+		t.Errorf("X-MBX-APIKEY = %q", req.Header.Get("X-MBX-APIKEY"))
+	}
+	query, signature, _ := strings.Cut(req.URL.RawQuery, "&signature=")
+	if query != "planType=SINGLE&recvWindow=5000&timestamp=1760600000000" {
+		t.Errorf("query = %s", query)
+	}
+	if signature != sign.SignQueryHMACHex("secret", query) {
+		t.Errorf("signature = %s does not match the signed query", signature)
+	}
+}
+
+func TestBinanceAutoInvest_CreateRecurringPlan(t *testing.T) {
+	var requests []*http.Request
+	server := autoInvestServer(t, &requests)
+	defer server.Close()
+
+	plan := RecurringPlan{
+		SourceAsset: "USDT", TargetAsset: "BTC", Amount: decimal.RequireFromString("25"),
+		Cadence: config.Cadence{Cycle: config.CycleWeekly, Weekday: time.Sunday, Hour: 7},
+	}
+	created, err := testBinanceAutoInvest(server.URL).CreateRecurringPlan(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("CreateRecurringPlan() error = %v", err)
+	}
+	if created.ID != "5230" || !created.NextExecution.Equal(time.UnixMilli(1760950800000)) || !created.Same(plan) {
+		t.Errorf("created = %+v, want plan 5230 with its next execution", created)
+	}
+
+	q := requests[0].URL.Query()
+	want := map[string]string{
+		"sourceType": "MAIN_SITE", "planType": "SINGLE", "flexibleAllowedToUse": "false",
+		"subscriptionAmount": "25", "subscriptionCycle": "WEEKLY", "subscriptionStartWeekday": "SUN", "subscriptionStartTime": "7",
+		"sourceAsset": "USDT", "details[0].targetAsset": "BTC", "details[0].percentage": "100",
+	}
+	for key, value := range want {
+		if got := q.Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+	if q.Has("subscriptionStartDay") || q.Has("planId") {
+		t.Errorf("query = %s, want neither a start day nor a plan ID", requests[0].URL.RawQuery)
+	}
+}
+
+func TestBinanceAutoInvest_UpdateRecurringPlan(t *testing.T) {
+	var requests []*http.Request
+	server := autoInvestServer(t, &requests)
+	defer server.Close()
+
+	plan := RecurringPlan{
+		ID: "3812", SourceAsset: "USDT", TargetAsset: "BTC", Amount: decimal.RequireFromString("75"),
+		Cadence: config.Cadence{Cycle: config.CycleMonthly, Day: 15, Hour: 9},
+	}
+	updated, err := testBinanceAutoInvest(server.URL).UpdateRecurringPlan(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("UpdateRecurringPlan() error = %v", err)
+	}
+	if updated.ID != "3812" || !updated.NextExecution.Equal(time.UnixMilli(1761037200000)) {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	q := requests[0].URL.Query()
+	if q.Get("planId") != "3812" || q.Get("subscriptionCycle") != "MONTHLY" || q.Get("subscriptionStartDay") != "15" || q.Get("subscriptionAmount") != "75" {
+		t.Errorf("query = %s", requests[0].URL.RawQuery)
+	}
+	if q.Has("sourceType") || q.Has("subscriptionStartWeekday") {
+		t.Errorf("query = %s, want only the editable fields", requests[0].URL.RawQuery)
+	}
+}
+
+func TestBinanceAutoInvest_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":-13003,"msg":"You need to deposit first."}`))
+	}))
+	defer server.Close()
+
+	_, err := testBinanceAutoInvest(server.URL).CreateRecurringPlan(context.Background(), RecurringPlan{
+		SourceAsset: "USDT", TargetAsset: "BTC", Amount: decimal.RequireFromString("25"),
+		Cadence: config.Cadence{Cycle: config.CycleDaily, Hour: 7},
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to save auto-invest plan") || IsRetriable(err) {
+		t.Errorf("CreateRecurringPlan() error = %v, want a permanent save failure", err)
+	}
+
+	if _, err := testBinanceAutoInvest(server.URL).CreateRecurringPlan(context.Background(), RecurringPlan{Cadence: config.Cadence{Cycle: "hourly"}}); err == nil {
+		t.Error("CreateRecurringPlan() accepted an unsupported cycle")
+	}
+}
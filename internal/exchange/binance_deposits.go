@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+)
+
+// binancePendingFiat are the fiat order statuses of deposits not credited
+// yet
+var binancePendingFiat = []string{"Processing"}
+
+// GetPendingDeposits returns the fiat deposits of code not credited yet.
+// Binance does not report when they settle, so ExpectedAt is estimated
+// from the deposit method.
+func (b *BinanceExchange) GetPendingDeposits(ctx context.Context, code string) ([]Deposit, error) {
+	var resp struct {
+		Data []struct {
+			FiatCurrency    string          `json:"fiatCurrency"`
+			IndicatedAmount decimal.Decimal `json:"indicatedAmount"`
+			Amount          decimal.Decimal `json:"amount"` // credited after fees
+			Method          string          `json:"method"`
+			Status          string          `json:"status"`
+			CreateTime      int64           `json:"createTime"`
+		} `json:"data"`
+	}
+	params := url.Values{"transactionType": {"0"}, "rows": {"100"}}
+	if err := b.signed(ctx, http.MethodGet, "/sapi/v1/fiat/orders", params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get fiat deposits: %w", err)
+	}
+
+	var deposits []Deposit
+	for _, o := range resp.Data {
+		if !slices.Contains(binancePendingFiat, o.Status) || asset.Canonical("binance", o.FiatCurrency) != asset.Canonical("", code) {
+			continue
+		}
+		d := Deposit{
+			Asset:     asset.Canonical("binance", o.FiatCurrency),
+			Amount:    o.Amount,
+			Method:    o.Method,
+			CreatedAt: time.UnixMilli(o.CreateTime).UTC(),
+		}
+		if d.Amount.IsZero() {
+			d.Amount = o.IndicatedAmount
+		}
+		d.ExpectedAt, _ = EstimateSettlement(o.Method, d.CreatedAt)
+		deposits = append(deposits, d)
+	}
+	slices.SortStableFunc(deposits, func(a, b Deposit) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return deposits, nil
+}
@@ -0,0 +1,165 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// BinanceBaseURL is the production Binance API
+const BinanceBaseURL = "https://api.binance.com"
+
+// binanceRecvWindow bounds how late a signed request may arrive, in milliseconds
+const binanceRecvWindow = "5000"
+
+// binanceErrors explains the Binance API error codes users most often hit
+var binanceErrors = map[string]Explanation{
+	"-1003": {"too many requests", "spread the schedules out; Binance may ban the IP for a few minutes"},
+	"-1013": {"the order fails a symbol filter such as the minimum notional", "raise strategy.quoteAmount above the symbol's minimum order size"},
+	"-1021": {"the request timestamp is outside the receive window", "retry; if it persists, check the clock of the machine running the bot"},
+	"-1022": {"the request signature is invalid", "check that apiSecret belongs to apiKey and was copied whole"},
+	"-1121": {"invalid symbol", "check that strategy.symbol is listed on Binance spot"},
+	"-2010": {"the order was rejected, usually for insufficient balance", "check that the free quote balance covers quoteAmount plus fees"},
+	"-2014": {"the API key format is invalid", "check that apiKey was copied whole, without spaces"},
+	"-2015": {"invalid API key, IP not whitelisted, or missing permission", "check the key's IP restriction matches your Lambda's egress IP and spot trading is enabled for it"},
+}
+
+// BinanceDust converts small balances to BNB through Binance's dust
+// transfer endpoints
+type BinanceDust struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+}
+
+// NewBinanceDust creates a dust converter for the production API
+func NewBinanceDust(apiKey, apiSecret string) *BinanceDust {
+	return &BinanceDust{
+		BaseURL:    BinanceBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// DustTarget is always BNB on Binance
+func (b *BinanceDust) DustTarget() string {
+	return "BNB"
+}
+
+// ListDust returns the spot balances Binance will convert to BNB
+func (b *BinanceDust) ListDust(ctx context.Context) ([]DustAsset, error) {
+	var resp struct {
+		Details []struct {
+			Asset      string          `json:"asset"`
+			AmountFree decimal.Decimal `json:"amountFree"`
+			ToBNB      decimal.Decimal `json:"toBNB"`
+		} `json:"details"`
+	}
+	if err := b.post(ctx, "/sapi/v1/asset/dust-btc", url.Values{}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list dust: %w", err)
+	}
+
+	assets := make([]DustAsset, 0, len(resp.Details))
+	for _, d := range resp.Details {
+		assets = append(assets, DustAsset{
+			Asset: d.Asset,
+			Free:  d.AmountFree,
+			Value: d.ToBNB,
+		})
+	}
+	return assets, nil
+}
+
+// ConvertDust converts the given assets to BNB in one transfer
+func (b *BinanceDust) ConvertDust(ctx context.Context, assets []string) ([]DustConversion, error) {
+	params := url.Values{}
+	for _, code := range assets {
+		params.Add("asset", code)
+	}
+
+	var resp struct {
+		TransferResult []struct {
+			FromAsset           string          `json:"fromAsset"`
+			Amount              decimal.Decimal `json:"amount"`
+			TransferedAmount    decimal.Decimal `json:"transferedAmount"`
+			ServiceChargeAmount decimal.Decimal `json:"serviceChargeAmount"`
+			TranID              int64           `json:"tranId"`
+		} `json:"transferResult"`
+	}
+	if err := b.post(ctx, "/sapi/v1/asset/dust", params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to convert dust: %w", err)
+	}
+
+	conversions := make([]DustConversion, 0, len(resp.TransferResult))
+	for _, r := range resp.TransferResult {
+		conversions = append(conversions, DustConversion{
+			Asset:      r.FromAsset,
+			Amount:     r.Amount,
+			Converted:  r.TransferedAmount,
+			Fee:        r.ServiceChargeAmount,
+			TransferID: strconv.FormatInt(r.TranID, 10),
+		})
+	}
+	return conversions, nil
+}
+
+// post sends a signed request and decodes the JSON response into out
+func (b *BinanceDust) post(ctx context.Context, path string, params url.Values, out interface{}) error {
+	return binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, http.MethodPost, path, params, out)
+}
+
+// binanceSigned sends a signed request to the Binance API at baseURL and
+// decodes the JSON response into out. now defaults to time.Now.
+func binanceSigned(ctx context.Context, client *http.Client, baseURL, apiKey, apiSecret string, now func() time.Time, method, path string, params url.Values, out interface{}) error {
+	_, end := run.StartSpan(ctx, "exchange.binance "+path)
+	defer end()
+
+	if now == nil {
+		now = time.Now
+	}
+	params.Set("recvWindow", binanceRecvWindow)
+	params.Set("timestamp", strconv.FormatInt(now().UnixMilli(), 10))
+	query := sign.CanonicalQuery(params)
+	query += "&signature=" + sign.SignQueryHMACHex(apiSecret, query)
+
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path+"?"+query, nil)
+	if err != nil {
+		return err
+	}
+	useragent.Apply(req)
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse("binance", resp.StatusCode, body); err != nil {
+		return withRetryAfter(err, resp.Header)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
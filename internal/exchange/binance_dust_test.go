@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+)
+
+// dustServer serves the fixture for each dust endpoint and records the requests
+func dustServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	t.Helper()
+	fixtures := map[string]string{
+		"/sapi/v1/asset/dust-btc": "binance_dust_btc.json",
+		"/sapi/v1/asset/dust":     "binance_dust.json",
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		name, ok := fixtures[r.URL.Path]
+		if !ok || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(body)
+	}))
+}
+
+func testBinanceDust(baseURL string) *BinanceDust {
+	return &BinanceDust{
+		BaseURL:   baseURL,
+		APIKey:    "key",
+		APISecret: "secret",
+		Now:       func() time.Time { return time.UnixMilli(1760600000000) },
+	}
+}
+
+func TestBinanceDust_ListDust(t *testing.T) {
+	var requests []*http.Request
+	server := dustServer(t, &requests)
+	defer server.Close()
+
+	assets, err := testBinanceDust(server.URL).ListDust(context.Background())
+	if err != nil {
+		t.Fatalf("ListDust() error = %v", err)
+	}
+	if len(assets) != 4 {
+		t.Fatalf("got %d assets, want 4", len(assets))
+	}
+	if assets[0].Asset != "USDT" || !assets[0].Free.Equal(decimal.RequireFromString("0.31")) || !assets[0].Value.Equal(decimal.RequireFromString("0.00051666")) {
+		t.Errorf("assets[0] = %+v", assets[0])
+	}
+	// Delisted tickers keep the exchange's code so they can be converted
+	if assets[3].Asset != "BCC" {
+		t.Errorf("assets[3].Asset = %s, want BCC", assets[3].Asset)
+	}
+
+	req := requests[0]
+	if req.Header.Get("X-MBX-APIKEY") != "key" {
+		t.Errorf("X-MBX-APIKEY = %q", req.Header.Get("X-MBX-APIKEY"))
+	}
+	query, signature, _ := strings.Cut(req.URL.RawQuery, "&signature=")
+	if query != "recvWindow=5000&timestamp=1760600000000" {
+		t.Errorf("query = %s", query)
+	}
+	if signature != sign.SignQueryHMACHex("secret", query) {
+		t.Errorf("signature = %s does not match the signed query", signature)
+	}
+}
+
+func TestBinanceDust_ConvertDust(t *testing.T) {
+	var requests []*http.Request
+	server := dustServer(t, &requests)
+	defer server.Close()
+
+	conversions, err := testBinanceDust(server.URL).ConvertDust(context.Background(), []string{"USDT", "BCC"})
+	if err != nil {
+		t.Fatalf("ConvertDust() error = %v", err)
+	}
+	if got := requests[0].URL.Query()["asset"]; strings.Join(got, ",") != "USDT,BCC" {
+		t.Errorf("asset params = %v, want USDT,BCC", got)
+	}
+
+	if len(conversions) != 2 {
+		t.Fatalf("got %d conversions, want 2", len(conversions))
+	}
+	c := conversions[1]
+	if c.Asset != "BCC" || !c.Amount.Equal(decimal.RequireFromString("0.0012")) ||
+		!c.Converted.Equal(decimal.RequireFromString("0.00085344")) || !c.Fee.Equal(decimal.RequireFromString("0.00001742")) ||
+		c.TransferID != "2970932919" {
+		t.Errorf("conversions[1] = %+v", c)
+	}
+}
+
+func TestBinanceDust_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantRetriable bool
+	}{
+		{"maintenance", 503, `{"code":-1001,"msg":"Internal error"}`, true},
+		{"too_frequent", 400, `{"code":-5003,"msg":"You can only convert dust once every 6 hours."}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := testBinanceDust(server.URL).ConvertDust(context.Background(), []string{"ADA"})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if IsRetriable(err) != tt.wantRetriable {
+				t.Errorf("IsRetriable(%v) = %v, want %v", err, IsRetriable(err), tt.wantRetriable)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BinanceEarn tops up the spot wallet by redeeming Binance Simple Earn
+// flexible products
+type BinanceEarn struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+}
+
+// NewBinanceEarn creates a Simple Earn client for the production API
+func NewBinanceEarn(apiKey, apiSecret string) *BinanceEarn {
+	return &BinanceEarn{
+		BaseURL:    BinanceBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+type binanceEarnPosition struct {
+	ProductID   string          `json:"productId"`
+	Asset       string          `json:"asset"`
+	TotalAmount decimal.Decimal `json:"totalAmount"`
+	CanRedeem   bool            `json:"canRedeem"`
+}
+
+// positions returns the redeemable flexible positions in code
+func (b *BinanceEarn) positions(ctx context.Context, code string) ([]binanceEarnPosition, error) {
+	var resp struct {
+		Rows []binanceEarnPosition `json:"rows"`
+	}
+	params := url.Values{"asset": {code}, "size": {"100"}}
+	if err := binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, http.MethodGet, "/sapi/v1/simple-earn/flexible/position", params, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get flexible positions: %w", err)
+	}
+	var positions []binanceEarnPosition
+	for _, p := range resp.Rows {
+		if p.CanRedeem && p.Asset == code && p.TotalAmount.IsPositive() {
+			positions = append(positions, p)
+		}
+	}
+	return positions, nil
+}
+
+// GetFundingBalance returns the redeemable flexible Simple Earn balance of code
+func (b *BinanceEarn) GetFundingBalance(ctx context.Context, code string) (decimal.Decimal, error) {
+	positions, err := b.positions(ctx, code)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return redeemable(positions), nil
+}
+
+// redeemable is the total of positions
+func redeemable(positions []binanceEarnPosition) decimal.Decimal {
+	total := decimal.Zero
+	for _, p := range positions {
+		total = total.Add(p.TotalAmount)
+	}
+	return total
+}
+
+// TransferToTrading redeems amount of code to the spot wallet, taking the
+// flexible products in the order Binance lists them
+func (b *BinanceEarn) TransferToTrading(ctx context.Context, code string, amount decimal.Decimal) (*Transfer, error) {
+	positions, err := b.positions(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	// Redeem nothing unless the whole amount can be
+	if available := redeemable(positions); available.LessThan(amount) {
+		return nil, fmt.Errorf("only %s %s is redeemable, %s needed", available.String(), code, amount.String())
+	}
+
+	remaining := amount
+	var ids []string
+	for _, p := range positions {
+		if !remaining.IsPositive() {
+			break
+		}
+		redeem := decimal.Min(remaining, p.TotalAmount)
+		var resp struct {
+			RedeemID int64 `json:"redeemId"`
+			Success  bool  `json:"success"`
+		}
+		params := url.Values{"productId": {p.ProductID}, "amount": {redeem.String()}, "destAccount": {"SPOT"}}
+		if err := binanceSigned(ctx, b.HTTPClient, b.BaseURL, b.APIKey, b.APISecret, b.Now, http.MethodPost, "/sapi/v1/simple-earn/flexible/redeem", params, &resp); err != nil {
+			return nil, fmt.Errorf("failed to redeem %s %s from %s: %w", redeem.String(), code, p.ProductID, err)
+		}
+		if !resp.Success {
+			return nil, fmt.Errorf("redemption of %s %s from %s was not accepted", redeem.String(), code, p.ProductID)
+		}
+		ids = append(ids, strconv.FormatInt(resp.RedeemID, 10))
+		remaining = remaining.Sub(redeem)
+	}
+	return &Transfer{Asset: code, Amount: amount, From: "simple earn", ID: strings.Join(ids, ",")}, nil
+}
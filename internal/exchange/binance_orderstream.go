@@ -0,0 +1,196 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// BinanceStreamURL is the production Binance market and user-data stream
+// endpoint
+const BinanceStreamURL = "wss://stream.binance.com:9443"
+
+// BinanceOrderStream follows orders on Binance's user-data stream: a
+// listen key from the REST API names the stream, which pushes an
+// executionReport for every order change
+type BinanceOrderStream struct {
+	BaseURL    string // REST API, for the listen key
+	StreamURL  string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewBinanceOrderStream creates an order streamer for the production API
+func NewBinanceOrderStream(apiKey string) *BinanceOrderStream {
+	return &BinanceOrderStream{
+		BaseURL:    BinanceBaseURL,
+		StreamURL:  BinanceStreamURL,
+		APIKey:     apiKey,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// binanceOrderStatuses maps executionReport statuses to Order statuses
+var binanceOrderStatuses = map[string]string{
+	"NEW":              OrderStatusOpen,
+	"PARTIALLY_FILLED": OrderStatusPartial,
+	"FILLED":           OrderStatusFilled,
+	"CANCELED":         OrderStatusCanceled,
+	"EXPIRED":          OrderStatusCanceled,
+	"REJECTED":         OrderStatusRejected,
+}
+
+// OpenOrderStream creates a listen key and connects to its stream. The key
+// is not kept alive: it lasts an hour, longer than any settlement wait.
+func (b *BinanceOrderStream) OpenOrderStream(ctx context.Context) (OrderStream, error) {
+	ctx, end := run.StartSpan(ctx, "exchange.binance userDataStream")
+	defer end()
+
+	listenKey, err := b.listenKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listen key: %w", err)
+	}
+	conn, err := dialWebsocket(ctx, b.StreamURL+"/ws/"+listenKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the user-data stream: %w", err)
+	}
+	return &binanceStream{conn: conn, seen: map[string]binanceTrades{}}, nil
+}
+
+// listenKey creates a user-data stream, which needs the API key but no
+// signature
+func (b *BinanceOrderStream) listenKey(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.BaseURL+"/api/v3/userDataStream", nil)
+	if err != nil {
+		return "", err
+	}
+	useragent.Apply(req)
+	req.Header.Set("X-MBX-APIKEY", b.APIKey)
+
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := CheckResponse("binance", resp.StatusCode, body); err != nil {
+		return "", err
+	}
+	var out struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil || out.ListenKey == "" {
+		return "", fmt.Errorf("invalid response: no listen key")
+	}
+	return out.ListenKey, nil
+}
+
+// binanceStream is an open user-data stream
+type binanceStream struct {
+	conn *wsConn
+	seen map[string]binanceTrades // by order ID
+}
+
+// binanceTrades sums the trades of an order seen on the stream. Reports
+// carry the fee of their own trade only, so the fees are complete when
+// the quantity traded is the order's filled quantity.
+type binanceTrades struct {
+	quantity decimal.Decimal
+	fees     decimal.Decimal
+}
+
+// binanceExecutionReport is the user-data stream event of an order change
+type binanceExecutionReport struct {
+	Event           string          `json:"e"`
+	Symbol          string          `json:"s"`
+	ClientOrderID   string          `json:"c"`
+	Side            string          `json:"S"`
+	Type            string          `json:"o"`
+	Status          string          `json:"X"`
+	OrderID         int64           `json:"i"`
+	LastQuantity    decimal.Decimal `json:"l"` // of this trade
+	FilledQuantity  decimal.Decimal `json:"z"` // cumulative
+	QuoteFilled     decimal.Decimal `json:"Z"` // cumulative
+	Commission      decimal.Decimal `json:"n"` // of this trade
+	CommissionAsset *string         `json:"N"` // asset, null before a trade
+
+	// Keys that differ from those above only in case, decoded so that
+	// encoding/json's case-insensitive matching cannot mix them up
+	EventTime         json.RawMessage `json:"E"`
+	OrigClientOrderID json.RawMessage `json:"C"`
+	ExecutionType     json.RawMessage `json:"x"`
+	Ignore            json.RawMessage `json:"I"`
+	LastPrice         json.RawMessage `json:"L"`
+	CreatedAt         json.RawMessage `json:"O"`
+}
+
+// Next returns the next executionReport; other events are skipped
+func (s *binanceStream) Next(ctx context.Context) (*OrderUpdate, error) {
+	for {
+		data, err := s.conn.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var report binanceExecutionReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return nil, fmt.Errorf("invalid user-data stream event: %w", err)
+		}
+		if report.Event != "executionReport" {
+			continue
+		}
+		return s.update(report, data), nil
+	}
+}
+
+// update converts report, adding its trade to those seen of the order
+func (s *binanceStream) update(report binanceExecutionReport, raw []byte) *OrderUpdate {
+	id := strconv.FormatInt(report.OrderID, 10)
+	trades := s.seen[id]
+	trades.quantity = trades.quantity.Add(report.LastQuantity)
+	trades.fees = trades.fees.Add(report.Commission)
+	s.seen[id] = trades
+
+	status, ok := binanceOrderStatuses[report.Status]
+	if !ok {
+		status = strings.ToLower(report.Status)
+	}
+	order := Order{
+		ID:            id,
+		ClientOrderID: report.ClientOrderID,
+		Symbol:        report.Symbol,
+		Side:          strings.ToLower(report.Side),
+		Type:          strings.ToLower(report.Type),
+		Quantity:      report.FilledQuantity,
+		Status:        status,
+		FeeAmount:     trades.fees,
+		Raw:           raw,
+	}
+	if report.FilledQuantity.IsPositive() {
+		order.Price = report.QuoteFilled.Div(report.FilledQuantity)
+	}
+	if report.CommissionAsset != nil {
+		order.FeeAsset = *report.CommissionAsset
+	}
+	return &OrderUpdate{Order: order, FeesIncomplete: !trades.quantity.Equal(report.FilledQuantity)}
+}
+
+// Close closes the stream's websocket
+func (s *binanceStream) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,412 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// binanceNow is the clock of the test clients, in the signed timestamps
+var binanceNow = time.UnixMilli(1760600000000)
+
+// binanceResponse answers a request of the test server
+type binanceResponse func(query url.Values) (status int, body string)
+
+// fixture answers with a testdata file
+func fixture(t *testing.T, name string) binanceResponse {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(url.Values) (int, string) { return http.StatusOK, string(body) }
+}
+
+// binancePublicPaths are the market data endpoints, which are not signed
+var binancePublicPaths = map[string]bool{
+	"/api/v3/ticker/price":      true,
+	"/api/v3/ticker/bookTicker": true,
+	"/api/v3/exchangeInfo":      true,
+}
+
+// binanceServer answers requests by "METHOD /path". Requests to /api/v3/
+// paths other than market data must carry the API key and a signature of
+// their query, which routes receive without the signing parameters.
+func binanceServer(t *testing.T, routes map[string]binanceResponse) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond, ok := routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		query := r.URL.Query()
+		if !binancePublicPaths[r.URL.Path] {
+			signed, signature, _ := strings.Cut(r.URL.RawQuery, "&signature=")
+			if signature != sign.SignQueryHMACHex("secret", signed) {
+				t.Errorf("%s: signature %q does not match the signed query %s", r.URL.Path, signature, signed)
+			}
+			if r.Header.Get("X-MBX-APIKEY") != "key" {
+				t.Errorf("%s: X-MBX-APIKEY = %q", r.URL.Path, r.Header.Get("X-MBX-APIKEY"))
+			}
+			if query.Get("timestamp") != "1760600000000" || query.Get("recvWindow") != "5000" {
+				t.Errorf("%s: timestamp %s, recvWindow %s", r.URL.Path, query.Get("timestamp"), query.Get("recvWindow"))
+			}
+			for _, key := range []string{"signature", "timestamp", "recvWindow"} {
+				query.Del(key)
+			}
+		}
+		status, body := respond(query)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func testBinance(server *httptest.Server) *BinanceExchange {
+	return &BinanceExchange{
+		BaseURL:   server.URL,
+		APIKey:    "key",
+		APISecret: "secret",
+		Now:       func() time.Time { return binanceNow },
+	}
+}
+
+// wantQuery checks the parameters of a request, less its signing ones
+func wantQuery(t *testing.T, query url.Values, want string) {
+	t.Helper()
+	if got := query.Encode(); got != want {
+		t.Errorf("query = %s, want %s", got, want)
+	}
+}
+
+func TestNewBinanceExchange(t *testing.T) {
+	payload := &config.DCAPayload{Exchange: config.ExchangeConfig{Name: "binance"}}
+	if _, err := NewBinanceExchange(payload, Credentials{APIKey: "key"}); err == nil {
+		t.Error("NewBinanceExchange() without a secret succeeded")
+	}
+
+	exc, err := NewBinanceExchange(payload, Credentials{APIKey: "key", APISecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := exc.(*BinanceExchange).BaseURL; got != BinanceBaseURL {
+		t.Errorf("BaseURL = %s, want production", got)
+	}
+	if exc.Capabilities() != clientCapabilities["binance"] {
+		t.Errorf("Capabilities() = %s, want the binance entry", exc.Capabilities())
+	}
+
+	payload.Exchange.Options = &config.ExchangeOptions{Sandbox: true}
+	exc, _ = NewBinanceExchange(payload, Credentials{APIKey: "key", APISecret: "secret"})
+	if got := exc.(*BinanceExchange).BaseURL; got != BinanceTestnetURL {
+		t.Errorf("BaseURL with options.sandbox = %s, want the testnet", got)
+	}
+}
+
+func TestBinanceExchange_GetBalance(t *testing.T) {
+	account := fixture(t, "binance_account.json")
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/account": func(query url.Values) (int, string) {
+			wantQuery(t, query, "omitZeroBalances=true")
+			return account(query)
+		},
+	})
+	defer server.Close()
+	b := testBinance(server)
+	ctx := context.Background()
+
+	free, err := b.GetBalance(ctx, "USDT")
+	if err != nil || !free.Equal(decimal.RequireFromString("1234.56")) {
+		t.Errorf("GetBalance(USDT) = %s, %v, want the free 1234.56", free, err)
+	}
+	detail, err := b.GetBalanceDetail(ctx, "usdt")
+	if err != nil || !detail.Locked.Equal(decimal.RequireFromString("50")) || !detail.Total.Equal(decimal.RequireFromString("1284.56")) || detail.Asset != "USDT" {
+		t.Errorf("GetBalanceDetail(usdt) = %+v, %v", detail, err)
+	}
+	// Zero balances are omitted from the response
+	if free, err := b.GetBalance(ctx, "ETH"); err != nil || !free.IsZero() {
+		t.Errorf("GetBalance(ETH) = %s, %v, want zero", free, err)
+	}
+}
+
+func TestBinanceExchange_PlaceMarketBuyOrder(t *testing.T) {
+	full := fixture(t, "binance_order_full.json")
+	server := binanceServer(t, map[string]binanceResponse{
+		"POST /api/v3/order": func(query url.Values) (int, string) {
+			wantQuery(t, query, "newClientOrderId=dca-Q69G5FAV&newOrderRespType=FULL&quoteOrderQty=50&side=BUY&symbol=BTCUSDT&type=MARKET")
+			return full(query)
+		},
+	})
+	defer server.Close()
+
+	ctx := run.WithID(context.Background(), "01ARYZ6S41TSV4RRFFQ69G5FAV")
+	order, err := testBinance(server).PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.RequireFromString("50")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The price is the average of the fills weighted by their quantity
+	if order.ID != "28457112" || order.Symbol != "BTC-USDT" || order.Status != OrderStatusFilled || order.Side != "buy" || order.Type != OrderTypeMarket ||
+		!order.Quantity.Equal(decimal.RequireFromString("0.0008")) || !order.Price.Equal(decimal.RequireFromString("62499.75")) {
+		t.Errorf("order = %s %s %s %s %s of %s at %s", order.ID, order.Symbol, order.Status, order.Side, order.Type, order.Quantity, order.Price)
+	}
+	if !order.FeeAmount.Equal(decimal.RequireFromString("0.0000008")) || order.FeeAsset != "BTC" || order.ClientOrderID != "dca-7f3a9c2e" || len(order.Raw) == 0 {
+		t.Errorf("order fee = %s %s, client ID %s", order.FeeAmount, order.FeeAsset, order.ClientOrderID)
+	}
+	// Fees of both fills add up, and were taken from the BTC received
+	if len(order.Fills) != 2 || order.Fills[1].TradeID != "4012332" || !order.Fills[1].FeeAmount.Equal(decimal.RequireFromString("0.0000005")) {
+		t.Errorf("fills = %+v", order.Fills)
+	}
+	if net := order.NetQuantity(); !net.Equal(decimal.RequireFromString("0.0007992")) {
+		t.Errorf("NetQuantity() = %s, want 0.0007992", net)
+	}
+}
+
+func TestBinanceExchange_PlaceMarketBuyOrder_Rejected(t *testing.T) {
+	server := binanceServer(t, map[string]binanceResponse{
+		"POST /api/v3/order": func(url.Values) (int, string) {
+			return http.StatusBadRequest, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`
+		},
+	})
+	defer server.Close()
+
+	_, err := testBinance(server).PlaceMarketBuyOrder(context.Background(), "BTC-USDT", QuoteSize(decimal.RequireFromString("50")))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("PlaceMarketBuyOrder() error = %v, want the HTTP 400", err)
+	}
+	if _, ok := Explain(err); !ok {
+		t.Errorf("error %v is not explained", err)
+	}
+}
+
+func TestBinanceExchange_GetOrder(t *testing.T) {
+	query := fixture(t, "binance_order_query.json")
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/order": func(q url.Values) (int, string) {
+			wantQuery(t, q, "orderId=28457113&symbol=BTCUSDT")
+			return query(q)
+		},
+		"GET /api/v3/myTrades": func(q url.Values) (int, string) {
+			wantQuery(t, q, "orderId=28457113&symbol=BTCUSDT")
+			return http.StatusOK, `[{"symbol":"BTCUSDT","id":4012333,"orderId":28457113,"price":"62500.00000000","qty":"0.00080000","commission":"0.00003750","commissionAsset":"BNB","time":1760600000530,"isBuyer":true}]`
+		},
+	})
+	defer server.Close()
+
+	order, err := testBinance(server).GetOrder(context.Background(), "BTC-USDT", "28457113")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without fills the price comes from the quote spent, the fees from the trades
+	if order.Status != OrderStatusFilled || !order.Quantity.Equal(decimal.RequireFromString("0.0008")) || !order.Price.Equal(decimal.RequireFromString("62500")) ||
+		!order.FeeAmount.Equal(decimal.RequireFromString("0.0000375")) || order.FeeAsset != "BNB" {
+		t.Errorf("order = %s %s at %s, fee %s %s", order.Status, order.Quantity, order.Price, order.FeeAmount, order.FeeAsset)
+	}
+}
+
+func TestBinanceExchange_StopOrders(t *testing.T) {
+	open := fixture(t, "binance_open_orders.json")
+	var canceled url.Values
+	server := binanceServer(t, map[string]binanceResponse{
+		"POST /api/v3/order": func(q url.Values) (int, string) {
+			wantQuery(t, q, "newClientOrderId=dcasl-Q69G5FAV&newOrderRespType=RESULT&price=55000&quantity=0.0007992&side=SELL&stopPrice=56250&symbol=BTCUSDT&timeInForce=GTC&type=STOP_LOSS_LIMIT")
+			return http.StatusOK, `{"symbol":"BTCUSDT","orderId":28457140,"clientOrderId":"dcasl-Q69G5FAV","price":"55000.00000000","origQty":"0.00079920","executedQty":"0.00000000","cummulativeQuoteQty":"0.00000000","status":"NEW","timeInForce":"GTC","type":"STOP_LOSS_LIMIT","side":"SELL","stopPrice":"56250.00000000"}`
+		},
+		"GET /api/v3/openOrders": func(q url.Values) (int, string) {
+			wantQuery(t, q, "symbol=BTCUSDT")
+			return open(q)
+		},
+		"DELETE /api/v3/order": func(q url.Values) (int, string) {
+			canceled = q
+			return http.StatusOK, `{"symbol":"BTCUSDT","orderId":28457140,"status":"CANCELED"}`
+		},
+	})
+	defer server.Close()
+	b := testBinance(server)
+	ctx := context.Background()
+
+	stop, err := b.PlaceStopLossOrder(ctx, "BTC-USDT", decimal.RequireFromString("0.0007992"), decimal.RequireFromString("56250"), decimal.RequireFromString("55000"), "dcasl-Q69G5FAV")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := b.OpenOrders(ctx, "BTC-USDT")
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("OpenOrders() = %v, %v", orders, err)
+	}
+	for _, o := range []Order{*stop, orders[0]} {
+		if o.ID != "28457140" || o.Type != OrderTypeStopLossLimit || o.Status != OrderStatusOpen || o.ClientOrderID != "dcasl-Q69G5FAV" ||
+			!o.Quantity.Equal(decimal.RequireFromString("0.0007992")) || !o.Price.Equal(decimal.RequireFromString("55000")) || !o.StopPrice.Equal(decimal.RequireFromString("56250")) {
+			t.Errorf("stop = %s %s %s %s of %s, limit %s, stop %s", o.ID, o.Type, o.Status, o.ClientOrderID, o.Quantity, o.Price, o.StopPrice)
+		}
+	}
+
+	if err := b.CancelOrder(ctx, "BTC-USDT", "28457140"); err != nil {
+		t.Fatal(err)
+	}
+	wantQuery(t, canceled, "orderId=28457140&symbol=BTCUSDT")
+}
+
+func TestBinanceExchange_GetMyTrades(t *testing.T) {
+	trades := fixture(t, "binance_my_trades.json")
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/myTrades": func(q url.Values) (int, string) {
+			wantQuery(t, q, "endTime=1760659199999&limit=1000&startTime=1760572800000&symbol=BTCUSDT")
+			return trades(q)
+		},
+	})
+	defer server.Close()
+
+	from := time.Date(2025, time.October, 16, 0, 0, 0, 0, time.UTC)
+	got, err := testBinance(server).GetMyTrades(context.Background(), "BTC-USDT", from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d trades, want 2", len(got))
+	}
+	buy, sell := got[0], got[1]
+	if buy.ID != "4012333" || buy.OrderID != "28457113" || buy.Side != "buy" || buy.Symbol != "BTC-USDT" || !buy.Quantity.Equal(decimal.RequireFromString("0.0008")) ||
+		!buy.Price.Equal(decimal.RequireFromString("62500")) || !buy.FeeAmount.Equal(decimal.RequireFromString("0.0000375")) || buy.FeeAsset != "BNB" || !buy.Time.Equal(time.UnixMilli(1760600000530)) {
+		t.Errorf("buy = %+v", buy)
+	}
+	if sell.Side != "sell" || sell.FeeAsset != "USDT" {
+		t.Errorf("sell = %+v", sell)
+	}
+}
+
+// A full page is followed by one from the trade after its last, which
+// cannot be bounded by time on Binance
+func TestBinanceExchange_GetMyTrades_Paging(t *testing.T) {
+	from := time.Date(2025, time.October, 16, 0, 0, 0, 0, time.UTC)
+	row := func(id int, at time.Time) string {
+		return fmt.Sprintf(`{"id":%d,"orderId":%d,"price":"62500","qty":"0.0001","commission":"0","commissionAsset":"BNB","time":%d,"isBuyer":true}`, id, id, at.UnixMilli())
+	}
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/myTrades": func(q url.Values) (int, string) {
+			if q.Get("fromId") == "" {
+				rows := make([]string, binanceTradePageLimit)
+				for i := range rows {
+					rows[i] = row(i+1, from.Add(time.Duration(i)*time.Second))
+				}
+				return http.StatusOK, "[" + strings.Join(rows, ",") + "]"
+			}
+			wantQuery(t, q, "fromId=1001&limit=1000&symbol=BTCUSDT")
+			return http.StatusOK, "[" + row(1001, from.Add(time.Hour)) + "," + row(1002, from.AddDate(0, 0, 1)) + "]"
+		},
+	})
+	defer server.Close()
+
+	got, err := testBinance(server).GetMyTrades(context.Background(), "BTC-USDT", from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != binanceTradePageLimit+1 || got[len(got)-1].ID != "1001" {
+		t.Errorf("got %d trades ending with %s, want 1001 ending with the last of the day", len(got), got[len(got)-1].ID)
+	}
+}
+
+func TestBinanceExchange_UnknownSymbol(t *testing.T) {
+	invalid := func(url.Values) (int, string) {
+		return http.StatusBadRequest, `{"code":-1121,"msg":"Invalid symbol."}`
+	}
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/ticker/price":      invalid,
+		"GET /api/v3/ticker/bookTicker": invalid,
+		"GET /api/v3/exchangeInfo":      invalid,
+	})
+	defer server.Close()
+	b := testBinance(server)
+
+	if _, err := b.LastPrice(context.Background(), "FOO-USDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("LastPrice() error = %v, want ErrSymbolNotFound", err)
+	}
+	if _, err := b.GetTicker(context.Background(), "FOO-USDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("GetTicker() error = %v, want ErrSymbolNotFound", err)
+	}
+	if _, err := b.GetSymbolInfo(context.Background(), "FOO-USDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("GetSymbolInfo() error = %v, want ErrSymbolNotFound", err)
+	}
+}
+
+func TestBinanceExchange_LastPrice(t *testing.T) {
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/ticker/price": func(q url.Values) (int, string) {
+			wantQuery(t, q, "symbol=BTCUSDT")
+			return http.StatusOK, `{"symbol":"BTCUSDT","price":"62500.01000000"}`
+		},
+	})
+	defer server.Close()
+
+	price, err := testBinance(server).LastPrice(context.Background(), "BTC-USDT")
+	if err != nil || !price.Equal(decimal.RequireFromString("62500.01")) {
+		t.Errorf("LastPrice() = %s, %v", price, err)
+	}
+}
+
+func TestBinanceExchange_TradingRules(t *testing.T) {
+	requests := 0
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/exchangeInfo": func(q url.Values) (int, string) {
+			requests++
+			wantQuery(t, q, "symbol=BTCUSDT")
+			return fixture(t, "binance_exchange_info.json")(q)
+		},
+	})
+	defer server.Close()
+	b := testBinance(server)
+	ctx := context.Background()
+
+	info, err := b.GetSymbolInfo(ctx, "BTC-USDT")
+	if err != nil || !info.Rules.TickSize.Equal(decimal.RequireFromString("0.01")) {
+		t.Fatalf("GetSymbolInfo() = %+v, %v", info, err)
+	}
+	if step, err := b.LotStep(ctx, "BTC-USDT"); err != nil || !step.Equal(decimal.RequireFromString("0.00001")) {
+		t.Errorf("LotStep() = %s, %v, want 0.00001", step, err)
+	}
+	if min, err := b.MinNotional(ctx, "BTC-USDT"); err != nil || !min.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("MinNotional() = %s, %v, want 5", min, err)
+	}
+	if requests != 1 {
+		t.Errorf("exchangeInfo read %d times, want once per client", requests)
+	}
+}
+
+func TestBinanceExchange_GetTicker(t *testing.T) {
+	server := binanceServer(t, map[string]binanceResponse{
+		"GET /api/v3/ticker/bookTicker": func(q url.Values) (int, string) {
+			wantQuery(t, q, "symbol=BTCUSDT")
+			return http.StatusOK, `{"symbol":"BTCUSDT","bidPrice":"62500.00000000","bidQty":"0.81000000","askPrice":"62500.02000000","askQty":"1.20000000"}`
+		},
+		"GET /api/v3/ticker/price": func(q url.Values) (int, string) {
+			wantQuery(t, q, "symbol=BTCUSDT")
+			return http.StatusOK, `{"symbol":"BTCUSDT","price":"62500.01000000"}`
+		},
+	})
+	defer server.Close()
+
+	ticker, err := testBinance(server).GetTicker(context.Background(), "BTC-USDT")
+	if err != nil {
+		t.Fatalf("GetTicker() error = %v", err)
+	}
+	if ticker.Symbol != "BTC-USDT" || !ticker.Last.Equal(decimal.RequireFromString("62500.01")) ||
+		!ticker.Bid.Equal(decimal.RequireFromString("62500")) || !ticker.Ask.Equal(decimal.RequireFromString("62500.02")) {
+		t.Errorf("GetTicker() = %+v", ticker)
+	}
+	if spread := ticker.Spread().Round(8); !spread.Equal(decimal.RequireFromString("0.00000032")) {
+		t.Errorf("Spread() = %s, want 0.00000032", spread)
+	}
+}
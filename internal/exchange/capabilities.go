@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Capabilities is a set of the features an exchange client supports
+type Capabilities uint
+
+// Capabilities of exchange clients
+const (
+	QuoteSizedMarketBuy Capabilities = 1 << iota // market buys sized in the quote asset
+	StopOrders                                   // PlaceStopLossOrder
+	Withdrawals                                  // withdrawing bought coins
+	FundingTransfers                             // FundingTransferer
+	NativeRecurring                              // RecurringBuyer
+	TradeHistory                                 // GetMyTrades
+
+	// AllCapabilities is every capability, as the mock advertises
+	AllCapabilities = QuoteSizedMarketBuy | StopOrders | Withdrawals | FundingTransfers | NativeRecurring | TradeHistory
+)
+
+var capabilityNames = []struct {
+	capability Capabilities
+	name       string
+}{
+	{QuoteSizedMarketBuy, "QuoteSizedMarketBuy"},
+	{StopOrders, "StopOrders"},
+	{Withdrawals, "Withdrawals"},
+	{FundingTransfers, "FundingTransfers"},
+	{NativeRecurring, "NativeRecurring"},
+	{TradeHistory, "TradeHistory"},
+}
+
+// Has reports whether c includes every capability of want
+func (c Capabilities) Has(want Capabilities) bool {
+	return c&want == want
+}
+
+// String lists the capabilities, e.g. "StopOrders,TradeHistory"
+func (c Capabilities) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c.Has(n.capability) {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// clientCapabilities are what the real clients of NewExchange support, so
+// a payload can be checked before a client is created. Each client's
+// Capabilities must return its entry.
+var clientCapabilities = map[string]Capabilities{
+	// quoteOrderQty, STOP_LOSS_LIMIT, capital withdrawals, funding wallet
+	// and Simple Earn, Auto-Invest plans, myTrades
+	"binance": AllCapabilities,
+
+	// tgtCcy, conditional algo orders, asset withdrawals, funding account,
+	// fills history; OKX recurring buys have no API
+	"okx": QuoteSizedMarketBuy | StopOrders | Withdrawals | FundingTransfers | TradeHistory,
+}
+
+// ClientCapabilities returns the capabilities of the real client for the
+// named exchange, and false for exchanges NewExchange has no client for
+func ClientCapabilities(name string) (Capabilities, bool) {
+	c, ok := clientCapabilities[strings.ToLower(name)]
+	return c, ok
+}
+
+// Requirement is a capability a payload setting needs
+type Requirement struct {
+	Path       string // dotted JSON path of the setting, e.g. "strategy.stopLoss"
+	Capability Capabilities
+}
+
+// Requirements lists the capabilities p's settings need of its exchanges
+func Requirements(p *config.DCAPayload) []Requirement {
+	var reqs []Requirement
+	if p.Strategy.StopLoss != nil && p.Flags.AllowProtectiveOrders {
+		reqs = append(reqs, Requirement{"strategy.stopLoss", StopOrders})
+	}
+	if p.Strategy.Withdrawal != nil {
+		reqs = append(reqs, Requirement{"strategy.withdrawal", Withdrawals})
+	}
+	if p.Strategy.AutoTransfer {
+		reqs = append(reqs, Requirement{"strategy.autoTransfer", FundingTransfers})
+	}
+	if p.Strategy.Native() {
+		reqs = append(reqs, Requirement{"strategy.engine", NativeRecurring})
+	}
+	if p.Mode == config.ModeReconcile {
+		reqs = append(reqs, Requirement{"mode", TradeHistory})
+	}
+	return reqs
+}
+
+// Check fails for the first of p's requirements c lacks, naming the client
+// of exchange name
+func (c Capabilities) Check(name string, p *config.DCAPayload) error {
+	for _, r := range Requirements(p) {
+		if !c.Has(r.Capability) {
+			return fmt.Errorf("%s client does not support feature %s required by %s", name, r.Capability, r.Path)
+		}
+	}
+	return nil
+}
+
+// CheckCapabilities checks p's settings against the clients of its
+// exchange and failover exchanges, so an unsupported combination fails
+// before the run rather than halfway through it. Dry runs pass: the mock
+// advertises every capability. Exchanges without a client are left to
+// NewExchange to reject.
+func CheckCapabilities(p *config.DCAPayload) error {
+	if p.Flags.DryRun {
+		return nil
+	}
+	for _, venue := range append([]config.ExchangeConfig{p.Exchange}, p.Failover...) {
+		c, ok := ClientCapabilities(venue.Name)
+		if !ok {
+			continue
+		}
+		if err := c.Check(venue.Name, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
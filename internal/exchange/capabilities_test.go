@@ -0,0 +1,121 @@
+package exchange
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestCapabilities_String(t *testing.T) {
+	if got := (StopOrders | TradeHistory).String(); got != "StopOrders,TradeHistory" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := Capabilities(0).String(); got != "none" {
+		t.Errorf("String() of no capabilities = %q", got)
+	}
+}
+
+func TestCapabilities_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		caps   Capabilities
+		modify func(p *config.DCAPayload)
+		want   string // error, empty for none
+	}{
+		{"plain buy", 0, func(p *config.DCAPayload) {}, ""},
+		{"stop-loss", QuoteSizedMarketBuy | TradeHistory, func(p *config.DCAPayload) {
+			p.Strategy.StopLoss = &config.StopLossConfig{PercentBelowFill: "5"}
+			p.Flags.AllowProtectiveOrders = true
+		}, "kraken client does not support feature StopOrders required by strategy.stopLoss"},
+		{"stop-loss never placed", 0, func(p *config.DCAPayload) {
+			p.Strategy.StopLoss = &config.StopLossConfig{PercentBelowFill: "5"}
+		}, ""},
+		{"withdrawal", AllCapabilities &^ Withdrawals, func(p *config.DCAPayload) {
+			p.Strategy.Withdrawal = &config.WithdrawalConfig{Network: "BTC"}
+		}, "kraken client does not support feature Withdrawals required by strategy.withdrawal"},
+		{"auto-transfer", StopOrders, func(p *config.DCAPayload) { p.Strategy.AutoTransfer = true },
+			"kraken client does not support feature FundingTransfers required by strategy.autoTransfer"},
+		{"native engine", AllCapabilities &^ NativeRecurring, func(p *config.DCAPayload) { p.Strategy.Engine = config.EngineNative },
+			"kraken client does not support feature NativeRecurring required by strategy.engine"},
+		{"reconcile", QuoteSizedMarketBuy, func(p *config.DCAPayload) { p.Mode = config.ModeReconcile },
+			"kraken client does not support feature TradeHistory required by mode"},
+		{"everything supported", AllCapabilities, func(p *config.DCAPayload) {
+			p.Strategy.AutoTransfer, p.Mode = true, config.ModeReconcile
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &config.DCAPayload{Exchange: config.ExchangeConfig{Name: "kraken"}}
+			tt.modify(p)
+			err := tt.caps.Check("kraken", p)
+			if got := errorString(err); got != tt.want {
+				t.Errorf("Check() error = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCapabilities(t *testing.T) {
+	native := func(p *config.DCAPayload) { p.Strategy.Engine = config.EngineNative }
+	tests := []struct {
+		name   string
+		venues []string
+		dryRun bool
+		modify func(p *config.DCAPayload)
+		want   string
+	}{
+		{"binance native", []string{"binance"}, false, native, ""},
+		{"okx native", []string{"okx"}, false, native, "okx client does not support feature NativeRecurring required by strategy.engine"},
+		{"failover to okx", []string{"binance", "okx"}, false, native, "okx client does not support feature NativeRecurring required by strategy.engine"},
+		{"dry run on the mock", []string{"okx"}, true, native, ""},
+		{"no client", []string{"kraken"}, false, native, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &config.DCAPayload{Exchange: config.ExchangeConfig{Name: tt.venues[0]}}
+			for _, name := range tt.venues[1:] {
+				p.Failover = append(p.Failover, config.ExchangeConfig{Name: name})
+			}
+			p.Flags.DryRun = tt.dryRun
+			tt.modify(p)
+			if got := errorString(CheckCapabilities(p)); got != tt.want {
+				t.Errorf("CheckCapabilities() error = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The client table agrees with the config lists of exchanges per feature
+func TestClientCapabilities_MatchConfig(t *testing.T) {
+	for _, list := range []struct {
+		names      []string
+		capability Capabilities
+	}{
+		{config.AutoTransferExchanges, FundingTransfers},
+		{config.NativeEngineExchanges, NativeRecurring},
+	} {
+		for name := range clientCapabilities {
+			c, _ := ClientCapabilities(name)
+			if c.Has(list.capability) != slices.Contains(list.names, name) {
+				t.Errorf("%s client has %s = %v, but the config list is %v", name, list.capability, c.Has(list.capability), list.names)
+			}
+		}
+	}
+}
+
+func TestMockExchange_Capabilities(t *testing.T) {
+	if got := NewMockExchange().Capabilities(); got != AllCapabilities {
+		t.Errorf("mock Capabilities() = %s, want every capability", got)
+	}
+	if (&MockExchange{BaseOnly: true}).Capabilities().Has(QuoteSizedMarketBuy) {
+		t.Error("BaseOnly mock advertises quote-sized market buys")
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
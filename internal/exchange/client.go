@@ -0,0 +1,20 @@
+package exchange
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/audit"
+	"github.com/sudowanderer/dca-bot-go/internal/ratelimit"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// newHTTPClient returns the HTTP client of the standalone exchange
+// clients: requests wait for the rate limiter, those that move funds are
+// audited, and all carry the identification headers of package useragent
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: ratelimit.NewTransport(audit.NewTransport(useragent.NewTransport(nil))),
+	}
+}
@@ -0,0 +1,116 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// TestIdentificationHeaders checks per signing scheme that requests carry
+// the User-Agent and extra headers, and that adding them leaves the signed
+// headers exactly as without them: an extra header named like an auth
+// header does not replace it
+func TestIdentificationHeaders(t *testing.T) {
+	tests := []struct {
+		scheme   string
+		fixtures map[string]string
+		signed   []string // headers the request is authenticated with
+		call     func(ctx context.Context, baseURL string, client *http.Client) error
+	}{
+		{
+			scheme:   "binance query HMAC",
+			fixtures: map[string]string{"GET /sapi/v1/simple-earn/flexible/position": "binance_earn_position.json"},
+			signed:   []string{"X-Mbx-Apikey"},
+			call: func(ctx context.Context, baseURL string, client *http.Client) error {
+				earn := &BinanceEarn{BaseURL: baseURL, APIKey: "key", APISecret: "secret", HTTPClient: client, Now: transferNow}
+				_, err := earn.GetFundingBalance(ctx, "USDT")
+				return err
+			},
+		},
+		{
+			scheme:   "okx prehash",
+			fixtures: map[string]string{"GET /api/v5/asset/balances": "okx_funding_balances.json"},
+			signed:   []string{"Ok-Access-Key", "Ok-Access-Sign", "Ok-Access-Timestamp", "Ok-Access-Passphrase"},
+			call: func(ctx context.Context, baseURL string, client *http.Client) error {
+				funding := &OKXFunding{BaseURL: baseURL, APIKey: "key", APISecret: "secret", Passphrase: "pass", HTTPClient: client, Now: transferNow}
+				_, err := funding.GetFundingBalance(ctx, "USDT")
+				return err
+			},
+		},
+		{
+			scheme:   "kraken",
+			fixtures: map[string]string{"POST /0/private/DepositStatus": "kraken_deposit_status.json"},
+			signed:   []string{"Api-Key", "Api-Sign"},
+			call: func(ctx context.Context, baseURL string, client *http.Client) error {
+				k := testKrakenDeposits(baseURL)
+				k.HTTPClient = client
+				_, err := k.GetPendingDeposits(ctx, "EUR")
+				return err
+			},
+		},
+		{
+			scheme:   "coinbase prehash",
+			fixtures: map[string]string{"GET /v2/accounts": "coinbase_accounts.json"},
+			signed:   []string{"Cb-Access-Key", "Cb-Access-Sign", "Cb-Access-Timestamp"},
+			call: func(ctx context.Context, baseURL string, client *http.Client) error {
+				c := testCoinbaseDeposits(baseURL)
+				c.HTTPClient = client
+				_, err := c.GetPendingDeposits(ctx, "USDC")
+				return err
+			},
+		},
+		{
+			scheme:   "unsigned",
+			fixtures: map[string]string{"GET /api/v3/klines": "binance_klines.json"},
+			call: func(ctx context.Context, baseURL string, client *http.Client) error {
+				from := time.UnixMilli(1760601600000)
+				_, err := (&BinanceKlines{BaseURL: baseURL, HTTPClient: client}).GetKlines(ctx, "BTC-USDT", time.Minute, from, from.Add(2*time.Minute))
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			var requests []*http.Request
+			var bodies []string
+			server := fixtureServer(t, tt.fixtures, &requests, &bodies)
+			defer server.Close()
+			client := &http.Client{Transport: useragent.NewTransport(nil)}
+
+			if err := tt.call(context.Background(), server.URL, client); err != nil {
+				t.Fatalf("plain request error = %v", err)
+			}
+			plain, plainBody := requests[0], bodies[0]
+
+			extra := http.Header{"Cf-Access-Client-Id": {"id.access"}}
+			for _, name := range tt.signed {
+				extra.Set(name, "spoofed")
+			}
+			ctx := useragent.WithExtra(run.WithID(context.Background(), "exec-1"), extra)
+			requests, bodies = nil, nil
+			if err := tt.call(ctx, server.URL, client); err != nil {
+				t.Fatalf("identified request error = %v", err)
+			}
+			req := requests[0]
+
+			if got, want := req.Header.Get("User-Agent"), useragent.Product+"/"+useragent.Version()+" (+exec-1)"; got != want {
+				t.Errorf("User-Agent = %q, want %q", got, want)
+			}
+			if got := req.Header.Get("CF-Access-Client-Id"); got != "id.access" {
+				t.Errorf("CF-Access-Client-Id = %q, want the extra header", got)
+			}
+			for _, name := range tt.signed {
+				if got, want := req.Header.Values(name), plain.Header.Values(name); len(got) != 1 || len(want) != 1 || got[0] != want[0] {
+					t.Errorf("%s = %v, want %v as signed without extra headers", name, got, want)
+				}
+			}
+			if req.URL.RawQuery != plain.URL.RawQuery || bodies[0] != plainBody {
+				t.Errorf("request = %s %q, want it signed as %s %q", req.URL.RawQuery, bodies[0], plain.URL.RawQuery, plainBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// CoinbaseBaseURL is the production Coinbase API
+const CoinbaseBaseURL = "https://api.coinbase.com"
+
+// coinbaseVersion pins the CB-VERSION of the v2 API responses
+const coinbaseVersion = "2025-01-01"
+
+// coinbaseErrors explains the Coinbase v2 API error ids users most often hit
+var coinbaseErrors = map[string]Explanation{
+	"authentication_error": {"the API key or signature was refused", "check that apiKey and apiSecret are a legacy HMAC key pair and the key is enabled"},
+	"invalid_token":        {"the API key is invalid", "check that apiKey was copied whole and the key was not deleted"},
+	"expired_token":        {"the API key has expired", "create a new key and update the credentials"},
+	"invalid_scope":        {"the API key lacks a permission the request needs", "grant the key wallet:accounts:read and wallet:deposits:read"},
+	"rate_limit_exceeded":  {"too many requests", "spread the schedules out or lower the request rate"},
+}
+
+// CoinbaseDeposits reads pending deposits from the Coinbase v2 API, signed
+// with a legacy HMAC API key
+type CoinbaseDeposits struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+}
+
+// NewCoinbaseDeposits creates a deposit client for the production API
+func NewCoinbaseDeposits(apiKey, apiSecret string) *CoinbaseDeposits {
+	return &CoinbaseDeposits{
+		BaseURL:    CoinbaseBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+type coinbaseAccount struct {
+	ID       string `json:"id"`
+	Currency struct {
+		Code string `json:"code"`
+	} `json:"currency"`
+}
+
+// GetPendingDeposits returns the deposits into the code account not
+// credited yet, with ExpectedAt as reported by Coinbase. Without an
+// account for code there are none.
+func (c *CoinbaseDeposits) GetPendingDeposits(ctx context.Context, code string) ([]Deposit, error) {
+	var accounts struct {
+		Data []coinbaseAccount `json:"data"`
+	}
+	if err := c.get(ctx, "/v2/accounts", url.Values{"limit": {"100"}}, &accounts); err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	i := slices.IndexFunc(accounts.Data, func(a coinbaseAccount) bool {
+		return asset.Canonical("coinbase", a.Currency.Code) == asset.Canonical("", code)
+	})
+	if i < 0 {
+		return nil, nil
+	}
+
+	var resp struct {
+		Data []struct {
+			Status string `json:"status"`
+			Amount struct {
+				Amount   decimal.Decimal `json:"amount"`
+				Currency string          `json:"currency"`
+			} `json:"amount"`
+			CreatedAt time.Time  `json:"created_at"`
+			PayoutAt  *time.Time `json:"payout_at"`
+		} `json:"data"`
+	}
+	if err := c.get(ctx, "/v2/accounts/"+accounts.Data[i].ID+"/deposits", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to list deposits: %w", err)
+	}
+
+	var deposits []Deposit
+	for _, d := range resp.Data {
+		if d.Status != "created" {
+			continue
+		}
+		deposit := Deposit{
+			Asset:     asset.Canonical("coinbase", d.Amount.Currency),
+			Amount:    d.Amount.Amount,
+			CreatedAt: d.CreatedAt.UTC(),
+		}
+		if d.PayoutAt != nil {
+			deposit.ExpectedAt = d.PayoutAt.UTC()
+		}
+		deposits = append(deposits, deposit)
+	}
+	slices.SortStableFunc(deposits, func(a, b Deposit) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return deposits, nil
+}
+
+// get sends a signed request and decodes its body into out
+func (c *CoinbaseDeposits) get(ctx context.Context, path string, params url.Values, out any) error {
+	_, end := run.StartSpan(ctx, "exchange.coinbase "+path)
+	defer end()
+
+	now := c.Now
+	if now == nil {
+		now = time.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	query := sign.CanonicalQuery(params)
+	target := c.BaseURL + path
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	useragent.Apply(req)
+	req.Header.Set("CB-ACCESS-KEY", c.APIKey)
+	req.Header.Set("CB-ACCESS-SIGN", sign.SignPrehashHex(c.APISecret, sign.Prehash(timestamp, http.MethodGet, path, query, "")))
+	req.Header.Set("CB-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("CB-VERSION", coinbaseVersion)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse("coinbase", resp.StatusCode, body); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package exchange
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Deposit is a deposit the exchange has not credited yet, such as a SEPA
+// or ACH transfer still settling
+type Deposit struct {
+	Asset     string          `json:"asset"` // canonical code, e.g. "EUR"
+	Amount    decimal.Decimal `json:"amount"`
+	Method    string          `json:"method,omitempty"` // as reported, e.g. "SEPA (Instant)"
+	CreatedAt time.Time       `json:"createdAt"`
+
+	// ExpectedAt is when the deposit should be credited, as reported by the
+	// exchange or estimated from its method; zero when unknown
+	ExpectedAt time.Time `json:"expectedAt,omitzero"`
+}
+
+// DepositWatcher is implemented by exchanges that report pending deposits
+type DepositWatcher interface {
+	// GetPendingDeposits returns the deposits of asset that are not
+	// credited yet, oldest first
+	GetPendingDeposits(ctx context.Context, asset string) ([]Deposit, error)
+}
+
+// settlementTimes are typical settlement times of fiat deposit methods,
+// matched in order against the lower-cased method name
+var settlementTimes = []struct {
+	method string
+	after  time.Duration
+}{
+	{"instant", time.Hour},
+	{"sepa", 2 * 24 * time.Hour},
+	{"ach", 5 * 24 * time.Hour},
+	{"wire", 24 * time.Hour},
+	{"swift", 3 * 24 * time.Hour},
+}
+
+// EstimateSettlement estimates when a deposit made with method at created
+// is credited, for exchanges that do not report it; ok is false for
+// methods without a typical settlement time
+func EstimateSettlement(method string, created time.Time) (time.Time, bool) {
+	method = strings.ToLower(method)
+	for _, s := range settlementTimes {
+		if strings.Contains(method, s.method) {
+			return created.Add(s.after), true
+		}
+	}
+	return time.Time{}, false
+}
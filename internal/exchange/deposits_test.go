@@ -0,0 +1,272 @@
+package exchange
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+)
+
+// fixtureServer serves the fixture for each "METHOD path" and records the
+// requests with their bodies
+func fixtureServer(t *testing.T, fixtures map[string]string, requests *[]*http.Request, bodies *[]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, r)
+		*bodies = append(*bodies, string(body))
+		name, ok := fixtures[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}))
+}
+
+// krakenSecret is the secret of Kraken's authentication example
+const krakenSecret = "kQH5HW/8p1uGOVjbgWA7FunAmGO8lsSUXNsu3eow76sz84Q18fWxnyRzBHCd3pd5nE9qa99HAZtuZuj6F1huXg=="
+
+func testKrakenDeposits(baseURL string) *KrakenDeposits {
+	return &KrakenDeposits{
+		BaseURL:   baseURL,
+		APIKey:    "key",
+		APISecret: krakenSecret,
+		Now:       func() time.Time { return time.UnixMilli(1760600000000) },
+	}
+}
+
+func TestKrakenDeposits_GetPendingDeposits(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{"POST /0/private/DepositStatus": "kraken_deposit_status.json"}, &requests, &bodies)
+	defer server.Close()
+
+	deposits, err := testKrakenDeposits(server.URL).GetPendingDeposits(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("GetPendingDeposits() error = %v", err)
+	}
+	if len(deposits) != 2 {
+		t.Fatalf("got %d deposits, want the pending and the settled EUR ones: %+v", len(deposits), deposits)
+	}
+	sepa := deposits[0]
+	if sepa.Asset != "EUR" || !sepa.Amount.Equal(decimal.RequireFromString("200")) || sepa.Method != "SEPA" ||
+		!sepa.CreatedAt.Equal(time.Unix(1760428800, 0)) || !sepa.ExpectedAt.Equal(time.Unix(1760428800, 0).Add(48*time.Hour)) {
+		t.Errorf("deposits[0] = %+v, want the 200 EUR SEPA deposit settling two days after it was made", sepa)
+	}
+	if swift := deposits[1]; swift.Method != "Bank Frick (SWIFT)" || !swift.ExpectedAt.Equal(time.Unix(1760515200, 0).Add(72*time.Hour)) {
+		t.Errorf("deposits[1] = %+v, want the SWIFT deposit", swift)
+	}
+
+	req := requests[0]
+	if bodies[0] != "asset=ZEUR&nonce=1760600000000" {
+		t.Errorf("body = %s", bodies[0])
+	}
+	want, _ := sign.SignKrakenBase64(krakenSecret, "/0/private/DepositStatus", "1760600000000", bodies[0])
+	if req.Header.Get("API-Key") != "key" || req.Header.Get("API-Sign") != want {
+		t.Errorf("API-Key = %q, API-Sign = %q, want the signed body", req.Header.Get("API-Key"), req.Header.Get("API-Sign"))
+	}
+}
+
+func TestKrakenDeposits_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		unavailable bool
+	}{
+		{"invalid_key", `{"error":["EAPI:Invalid key"]}`, false},
+		{"unavailable", `{"error":["EService:Unavailable"]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := testKrakenDeposits(server.URL).GetPendingDeposits(context.Background(), "EUR")
+			if err == nil || IsUnavailable(err) != tt.unavailable {
+				t.Errorf("GetPendingDeposits() error = %v, want unavailable %v", err, tt.unavailable)
+			}
+			if _, explained := Explain(err); explained == tt.unavailable {
+				t.Errorf("GetPendingDeposits() error = %v, want only the invalid key explained", err)
+			}
+		})
+	}
+
+	k := testKrakenDeposits("http://127.0.0.1:0")
+	k.APISecret = "not base64!"
+	if _, err := k.GetPendingDeposits(context.Background(), "EUR"); err == nil || !strings.Contains(err.Error(), "invalid kraken secret") {
+		t.Errorf("GetPendingDeposits() error = %v, want the invalid secret", err)
+	}
+}
+
+func testCoinbaseDeposits(baseURL string) *CoinbaseDeposits {
+	return &CoinbaseDeposits{
+		BaseURL:   baseURL,
+		APIKey:    "key",
+		APISecret: "secret",
+		Now:       func() time.Time { return time.Unix(1760600000, 0) },
+	}
+}
+
+func TestCoinbaseDeposits_GetPendingDeposits(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{
+		"GET /v2/accounts": "coinbase_accounts.json",
+		"GET /v2/accounts/2bbf394c-193b-5b2a-9155-3b4732659ede/deposits": "coinbase_deposits.json",
+	}, &requests, &bodies)
+	defer server.Close()
+
+	deposits, err := testCoinbaseDeposits(server.URL).GetPendingDeposits(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("GetPendingDeposits() error = %v", err)
+	}
+	if len(deposits) != 1 {
+		t.Fatalf("got %d deposits, want the one not completed", len(deposits))
+	}
+	d := deposits[0]
+	if d.Asset != "EUR" || !d.Amount.Equal(decimal.RequireFromString("200")) ||
+		!d.CreatedAt.Equal(time.Date(2026, time.October, 14, 8, 0, 0, 0, time.UTC)) || !d.ExpectedAt.Equal(time.Date(2026, time.October, 18, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("deposit = %+v, want 200 EUR paid out on October 18", d)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want the accounts and the EUR deposits", len(requests))
+	}
+	req := requests[1]
+	want := sign.SignPrehashHex("secret", "1760600000GET/v2/accounts/2bbf394c-193b-5b2a-9155-3b4732659ede/deposits")
+	if req.Header.Get("CB-ACCESS-KEY") != "key" || req.Header.Get("CB-ACCESS-TIMESTAMP") != "1760600000" || req.Header.Get("CB-ACCESS-SIGN") != want {
+		t.Errorf("headers = %v, want the signed request", req.Header)
+	}
+	if q := requests[0].URL.RawQuery; q != "limit=100" {
+		t.Errorf("accounts query = %s", q)
+	}
+}
+
+func TestCoinbaseDeposits_NoAccount(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{"GET /v2/accounts": "coinbase_accounts.json"}, &requests, &bodies)
+	defer server.Close()
+
+	deposits, err := testCoinbaseDeposits(server.URL).GetPendingDeposits(context.Background(), "USD")
+	if err != nil || len(deposits) != 0 || len(requests) != 1 {
+		t.Errorf("GetPendingDeposits() = %v, %v after %d requests, want none without a USD account", deposits, err, len(requests))
+	}
+}
+
+func TestBinanceExchange_GetPendingDeposits(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{"GET /sapi/v1/fiat/orders": "binance_fiat_deposits.json"}, &requests, &bodies)
+	defer server.Close()
+
+	b := &BinanceExchange{BaseURL: server.URL, APIKey: "key", APISecret: "secret", Now: transferNow}
+	deposits, err := b.GetPendingDeposits(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("GetPendingDeposits() error = %v", err)
+	}
+	if len(deposits) != 2 {
+		t.Fatalf("got %d deposits, want the two processing EUR ones: %+v", len(deposits), deposits)
+	}
+	sepa := deposits[0]
+	if sepa.Asset != "EUR" || !sepa.Amount.Equal(decimal.RequireFromString("199.5")) || sepa.Method != "SEPA" ||
+		!sepa.CreatedAt.Equal(time.Unix(1760428800, 0)) || !sepa.ExpectedAt.Equal(time.Unix(1760428800, 0).Add(48*time.Hour)) {
+		t.Errorf("deposits[0] = %+v, want the 199.50 EUR credited by the SEPA deposit two days after it was made", sepa)
+	}
+	if bank := deposits[1]; bank.Method != "BankAccount" || !bank.ExpectedAt.IsZero() {
+		t.Errorf("deposits[1] = %+v, want the bank deposit without an expected settlement", bank)
+	}
+
+	query, signature, _ := strings.Cut(requests[0].URL.RawQuery, "&signature=")
+	if query != "recvWindow=5000&rows=100&timestamp=1760600000000&transactionType=0" || signature != sign.SignQueryHMACHex("secret", query) {
+		t.Errorf("query = %s, signature %s", query, signature)
+	}
+	if requests[0].Header.Get("X-MBX-APIKEY") != "key" {
+		t.Errorf("X-MBX-APIKEY = %q, want the API key", requests[0].Header.Get("X-MBX-APIKEY"))
+	}
+}
+
+func TestBinanceExchange_GetPendingDepositsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		unavailable bool
+	}{
+		{"invalid_key", 401, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`, false},
+		{"unavailable", 503, `{"code":-1001,"msg":"Internal error; unable to process your request."}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			b := &BinanceExchange{BaseURL: server.URL, APIKey: "key", APISecret: "secret", Now: transferNow}
+			_, err := b.GetPendingDeposits(context.Background(), "EUR")
+			if err == nil || IsUnavailable(err) != tt.unavailable {
+				t.Errorf("GetPendingDeposits() error = %v, want unavailable %v", err, tt.unavailable)
+			}
+			if _, explained := Explain(err); explained == tt.unavailable {
+				t.Errorf("GetPendingDeposits() error = %v, want only the invalid key explained", err)
+			}
+		})
+	}
+}
+
+func TestBinanceExchange_GetPendingDepositsRetries(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "binance_fiat_deposits.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var waits []time.Duration
+	transport := &scriptedTransport{replies: map[string][]reply{
+		"GET /sapi/v1/fiat/orders": {{status: 502, body: "Bad Gateway"}, {status: 200, body: string(data)}},
+	}}
+
+	deposits, err := retryingBinance(transport, 3, &waits).GetPendingDeposits(context.Background(), "EUR")
+	if err != nil || len(deposits) != 2 {
+		t.Fatalf("GetPendingDeposits() = %+v, %v, want the two deposits after a retry", deposits, err)
+	}
+	if len(transport.sent) != 2 || len(waits) != 1 {
+		t.Errorf("sent %v with waits %v, want the 502 retried once", transport.sent, waits)
+	}
+}
+
+func TestEstimateSettlement(t *testing.T) {
+	created := time.Date(2026, time.October, 14, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		method string
+		after  time.Duration
+		ok     bool
+	}{
+		{"SEPA", 48 * time.Hour, true},
+		{"SEPA (Instant)", time.Hour, true},
+		{"ACH", 5 * 24 * time.Hour, true},
+		{"Domestic Wire", 24 * time.Hour, true},
+		{"Bitcoin", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := EstimateSettlement(tt.method, created)
+		if ok != tt.ok || (ok && !got.Equal(created.Add(tt.after))) {
+			t.Errorf("EstimateSettlement(%q) = %v, %v, want %s later", tt.method, got, ok, tt.after)
+		}
+	}
+}
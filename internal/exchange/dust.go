@@ -0,0 +1,36 @@
+package exchange
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// DustAsset is a balance small enough for the exchange to convert as dust
+type DustAsset struct {
+	Asset string          `json:"asset"` // the exchange's own code, which ConvertDust expects`
+	Free  decimal.Decimal `json:"free"`
+	Value decimal.Decimal `json:"value"` // estimated value in the dust target asset
+}
+
+// DustConversion is one asset converted by a dust sweep
+type DustConversion struct {
+	Asset      string          `json:"asset"`
+	Amount     decimal.Decimal `json:"amount"`    // amount of Asset converted
+	Converted  decimal.Decimal `json:"converted"` // target asset received, net of Fee
+	Fee        decimal.Decimal `json:"fee"`       // in the target asset
+	TransferID string          `json:"transferId"`
+}
+
+// DustConverter is implemented by exchanges that can convert small balances
+// to a single target asset
+type DustConverter interface {
+	// DustTarget is the asset dust is converted to, e.g. "BNB"
+	DustTarget() string
+
+	// ListDust returns the balances that can currently be converted
+	ListDust(ctx context.Context) ([]DustAsset, error)
+
+	// ConvertDust converts the given assets' whole free balances
+	ConvertDust(ctx context.Context, assets []string) ([]DustConversion, error)
+}
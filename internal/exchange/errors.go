@@ -0,0 +1,222 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrExchangeUnavailable marks failures caused by the venue being down,
+// overloaded or in maintenance rather than by the request itself.
+// Retrying later, or elsewhere, may succeed.
+var ErrExchangeUnavailable = errors.New("exchange unavailable")
+
+// ErrOrderOutcomeUnknown marks an order request whose result was never
+// received. The order may have been filled, so it must not be retried,
+// here or on another venue.
+var ErrOrderOutcomeUnknown = errors.New("order outcome unknown")
+
+// ErrOrderPlaced marks a failure after an order already executed, such as
+// the second leg of a routed buy. Retrying would buy twice.
+var ErrOrderPlaced = errors.New("an order was already placed")
+
+// ErrDuplicateClientOrderID marks an order rejected because its client
+// order ID was already used; the order of that ID is the one to look up
+var ErrDuplicateClientOrderID = errors.New("duplicate client order id")
+
+// ErrOrderLookup marks a failure to look up a run's order by its client
+// order ID before placing it (see PlaceOnce); no order was sent
+var ErrOrderLookup = errors.New("order lookup failed")
+
+// ErrSymbolNotFound is returned by SymbolChecker when a symbol is not listed
+var ErrSymbolNotFound = errors.New("symbol not found")
+
+// ReasonMaintenance is the UnavailableError reason for scheduled maintenance
+const ReasonMaintenance = "maintenance"
+
+// UnavailableError is an availability failure of a specific venue
+type UnavailableError struct {
+	Exchange string
+	Reason   string // ReasonMaintenance or e.g. "HTTP 503"
+	Err      error  // underlying error, may be nil
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s unavailable (%s): %v", e.Exchange, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("%s unavailable (%s)", e.Exchange, e.Reason)
+}
+
+// Unwrap makes errors.Is(err, ErrExchangeUnavailable) hold
+func (e *UnavailableError) Unwrap() []error {
+	if e.Err != nil {
+		return []error{ErrExchangeUnavailable, e.Err}
+	}
+	return []error{ErrExchangeUnavailable}
+}
+
+// HTTPError is a non-2xx response from an exchange API
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // wait the response asked for in Retry-After, if any
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// Explanation is what an exchange error code means and how to fix it
+type Explanation struct {
+	Meaning string // e.g. "invalid API key"
+	Fix     string // the suggested fix, e.g. "check that apiKey was copied whole"
+}
+
+// ExplainedError is an exchange API error whose code its client explains.
+// The message keeps the raw error, code included, and adds the explanation.
+type ExplainedError struct {
+	Exchange string
+	Code     string // as the exchange wrote it, e.g. "-2015" or "50111"
+	Explanation
+	Err error // the raw error, usually an *HTTPError
+}
+
+func (e *ExplainedError) Error() string {
+	return fmt.Sprintf("%v (%s %s: %s; %s)", e.Err, e.Exchange, e.Code, e.Meaning, e.Fix)
+}
+
+func (e *ExplainedError) Unwrap() error {
+	return e.Err
+}
+
+// Explain returns the explanation attached to err, if any
+func Explain(err error) (Explanation, bool) {
+	var explained *ExplainedError
+	if !errors.As(err, &explained) {
+		return Explanation{}, false
+	}
+	return explained.Explanation, true
+}
+
+// errorExplanations are the error codes each exchange client explains
+var errorExplanations = map[string]map[string]Explanation{
+	"binance":  binanceErrors,
+	"coinbase": coinbaseErrors,
+	"kraken":   krakenErrors,
+	"okx":      okxErrors,
+}
+
+// explain wraps err in an *ExplainedError when the exchange's client
+// explains code; other errors are returned unchanged
+func explain(exchange, code string, err error) error {
+	explanation, ok := errorExplanations[exchange][code]
+	if !ok {
+		return err
+	}
+	return &ExplainedError{Exchange: exchange, Code: code, Explanation: explanation, Err: err}
+}
+
+// IsUnavailable reports whether err is availability-class: an explicit
+// ErrExchangeUnavailable, a timeout, or a 5xx response. Business
+// rejections (insufficient funds, invalid symbol, bad credentials) are not.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrExchangeUnavailable) || IsTimeout(err) {
+		return true
+	}
+	var httpErr *HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode >= 500
+}
+
+// IsTimeout reports whether err is a deadline or network timeout. After a
+// timeout the outcome of the request is unknown.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRetriable reports whether a run that failed with err may be retried
+// later or on another venue: only availability-class errors qualify, and
+// never once an order may have been placed
+func IsRetriable(err error) bool {
+	return IsUnavailable(err) && !errors.Is(err, ErrOrderOutcomeUnknown) && !errors.Is(err, ErrOrderPlaced)
+}
+
+// IsMaintenance reports whether err is an exchange's scheduled maintenance
+func IsMaintenance(err error) bool {
+	var unavailable *UnavailableError
+	return errors.As(err, &unavailable) && unavailable.Reason == ReasonMaintenance
+}
+
+// maintenanceCodes are the API error codes each exchange returns while
+// under maintenance or too overloaded to serve requests
+var maintenanceCodes = map[string]map[string]bool{
+	"binance": {"-1001": true, "-1008": true}, // disconnected, server busy
+	"okx":     {"50001": true, "50013": true}, // service temporarily unavailable, systems busy
+}
+
+// CheckResponse maps an exchange API response to an error: nil for 2xx,
+// an *UnavailableError for maintenance responses, an *HTTPError otherwise.
+// Exchange clients call it for every response before decoding the body.
+func CheckResponse(exchange string, statusCode int, body []byte) error {
+	if statusCode >= 200 && statusCode < 300 && !isMaintenanceBody(exchange, body) {
+		return nil
+	}
+	httpErr := &HTTPError{StatusCode: statusCode, Body: string(body)}
+	if isMaintenanceBody(exchange, body) {
+		return &UnavailableError{Exchange: exchange, Reason: ReasonMaintenance, Err: httpErr}
+	}
+	return explain(exchange, errorCode(body), httpErr)
+}
+
+// errorCode returns the error code of an API error body: Binance's and
+// OKX's code, the id of Coinbase's first error, or Kraken's first error
+func errorCode(body []byte) string {
+	var apiErr struct {
+		Code   json.RawMessage `json:"code"`
+		Errors []struct {
+			ID string `json:"id"`
+		} `json:"errors"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return ""
+	}
+	var messages []string
+	switch {
+	case len(apiErr.Code) > 0:
+		return strings.Trim(string(apiErr.Code), `"`)
+	case len(apiErr.Errors) > 0:
+		return apiErr.Errors[0].ID
+	case json.Unmarshal(apiErr.Error, &messages) == nil && len(messages) > 0:
+		return messages[0]
+	}
+	return ""
+}
+
+// isMaintenanceBody reports whether an API error body announces maintenance,
+// by code or by message
+func isMaintenanceBody(exchange string, body []byte) bool {
+	var apiErr struct {
+		Code json.RawMessage `json:"code"` // a number on Binance, a string on OKX
+		Msg  string          `json:"msg"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return false
+	}
+	if strings.Contains(strings.ToLower(apiErr.Msg), "maintenance") {
+		return true
+	}
+	code := strings.Trim(string(apiErr.Code), `"`)
+	return maintenanceCodes[exchange][code]
+}
@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil", nil, false},
+		{"sentinel", ErrExchangeUnavailable, true},
+		{"maintenance", &UnavailableError{Exchange: "binance", Reason: "maintenance"}, true},
+		{"wrapped_maintenance", fmt.Errorf("failed to place order: %w", &UnavailableError{Exchange: "okx", Reason: "HTTP 503"}), true},
+		{"deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), true},
+		{"net_timeout", fmt.Errorf("dial: %w", timeoutError{}), true},
+		{"http_502", &HTTPError{StatusCode: 502, Body: "Bad Gateway"}, true},
+		{"http_500_wrapped", fmt.Errorf("get balance: %w", &HTTPError{StatusCode: 500}), true},
+		{"http_400_insufficient_funds", &HTTPError{StatusCode: 400, Body: `{"code":-2010,"msg":"Account has insufficient balance"}`}, false},
+		{"http_401", &HTTPError{StatusCode: 401}, false},
+		{"http_429", &HTTPError{StatusCode: 429}, false},
+		{"cancelled", context.Canceled, false},
+		{"plain", errors.New("invalid symbol"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsUnavailable(tt.err); got != tt.expected {
+				t.Errorf("IsUnavailable(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnavailableError_Is(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := fmt.Errorf("wrapped: %w", &UnavailableError{Exchange: "binance", Reason: "HTTP 503", Err: cause})
+	if !errors.Is(err, ErrExchangeUnavailable) || !errors.Is(err, cause) {
+		t.Errorf("errors.Is failed for %v", err)
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name            string
+		exchange        string
+		status          int
+		body            string
+		wantErr         bool
+		wantMaintenance bool
+		wantRetriable   bool
+	}{
+		{"ok", "binance", 200, `{"orderId":42}`, false, false, false},
+		{"okx_ok", "okx", 200, `{"code":"0","msg":"","data":[]}`, false, false, false},
+		{"binance_maintenance_503", "binance", 503, `{"code":-1001,"msg":"Internal error; unable to process your request. Please try again."}`, true, true, true},
+		{"binance_maintenance_message", "binance", 503, `{"msg":"System is under maintenance."}`, true, true, true},
+		{"okx_maintenance_on_200", "okx", 200, `{"code":"50001","msg":"Service temporarily unavailable. Please try again later"}`, true, true, true},
+		{"plain_503", "binance", 503, `Service Unavailable`, true, false, true},
+		{"binance_insufficient_funds", "binance", 400, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`, true, false, false},
+		{"okx_invalid_symbol", "okx", 200, `{"code":"51001","msg":"Instrument ID does not exist"}`, false, false, false},
+		{"okx_code_on_binance", "binance", 400, `{"code":"50001","msg":"bad request"}`, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckResponse(tt.exchange, tt.status, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := IsMaintenance(err); got != tt.wantMaintenance {
+				t.Errorf("IsMaintenance() = %v, want %v", got, tt.wantMaintenance)
+			}
+			if got := IsRetriable(err); got != tt.wantRetriable {
+				t.Errorf("IsRetriable() = %v, want %v", got, tt.wantRetriable)
+			}
+		})
+	}
+}
+
+func TestCheckResponse_Explains(t *testing.T) {
+	tests := []struct {
+		name     string
+		exchange string
+		status   int
+		body     string
+		wantCode string // empty when not explained
+	}{
+		{"binance_number_code", "binance", 401, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`, "-2015"},
+		{"okx_string_code", "okx", 401, `{"code":"50110","msg":"Your IP is not in the API key's IP whitelist"}`, "50110"},
+		{"coinbase_error_id", "coinbase", 401, `{"errors":[{"id":"expired_token","message":"The access token expired"}]}`, "expired_token"},
+		{"kraken_error_list", "kraken", 403, `{"error":["EGeneral:Permission denied"]}`, "EGeneral:Permission denied"},
+		{"unknown_code", "binance", 400, `{"code":-9999,"msg":"Something new."}`, ""},
+		{"other_exchange_code", "binance", 401, `{"code":"50111","msg":"Invalid OK-ACCESS-KEY"}`, ""},
+		{"plain_body", "okx", 404, `Not Found`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckResponse(tt.exchange, tt.status, []byte(tt.body))
+			raw := fmt.Sprintf("HTTP %d: %s", tt.status, tt.body)
+			var explained *ExplainedError
+			if !errors.As(err, &explained) {
+				if tt.wantCode != "" {
+					t.Fatalf("CheckResponse() = %v, want code %s explained", err, tt.wantCode)
+				}
+				if err.Error() != raw {
+					t.Errorf("CheckResponse() = %q, want the raw error %q", err, raw)
+				}
+				return
+			}
+			if explained.Code != tt.wantCode {
+				t.Fatalf("explained code = %q, want %q", explained.Code, tt.wantCode)
+			}
+			if msg := err.Error(); !strings.HasPrefix(msg, raw) || !strings.Contains(msg, explained.Meaning) || !strings.Contains(msg, explained.Fix) {
+				t.Errorf("Error() = %q, want the raw error followed by the explanation", msg)
+			}
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+				t.Error("the explanation hides the HTTP error")
+			}
+		})
+	}
+}
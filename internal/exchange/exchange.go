@@ -2,87 +2,819 @@ package exchange
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
 	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
 )
 
 // Order represents a trading order result
 type Order struct {
-	ID       string          `json:"id"`
-	Symbol   string          `json:"symbol"`
-	Side     string          `json:"side"`     // "buy" or "sell"
-	Type     string          `json:"type"`     // "market" or "limit"
-	Quantity decimal.Decimal `json:"quantity"` // filled quantity
-	Price    decimal.Decimal `json:"price"`    // average fill price
-	Status   string          `json:"status"`   // "filled", "partial", "rejected"
+	ID            string          `json:"id"`
+	ClientOrderID string          `json:"clientOrderId"` // carries the execution ID suffix, or the idempotency key hash
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`     // "buy" or "sell"
+	Type          string          `json:"type"`     // "market" or "limit"
+	Quantity      decimal.Decimal `json:"quantity"` // filled quantity
+	Price         decimal.Decimal `json:"price"`    // average fill price
+	Status        string          `json:"status"`   // "filled", "partial", "rejected", "canceled", "open"
+
+	StopPrice decimal.Decimal `json:"stopPrice,omitzero"` // trigger price of stop orders; Price is then the limit
+
+	FeeAmount decimal.Decimal `json:"feeAmount"`          // total trading fee
+	FeeAsset  string          `json:"feeAsset,omitempty"` // asset the fee was charged in, e.g. "BNB"
+
+	Raw json.RawMessage `json:"raw,omitempty"` // unmodified exchange response, if any
+
+	// Fills are the trades of the order, when the exchange reported them;
+	// FeeAmount is their total
+	Fills []Fill `json:"fills,omitempty"`
+
+	// Legs are the orders of a routed buy. Quantity is then the net base
+	// received and Price the effective price with every leg's fees folded in.
+	Legs []Order `json:"legs,omitempty"`
+}
+
+// Fill is one trade of an order
+type Fill struct {
+	TradeID   string          `json:"tradeId,omitempty"`
+	Price     decimal.Decimal `json:"price"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	FeeAmount decimal.Decimal `json:"feeAmount"`
+	FeeAsset  string          `json:"feeAsset,omitempty"`
+}
+
+// SumFees totals the fees of fills in the asset of the first; an order
+// pays its fees in one asset, such as BNB or the asset received
+func SumFees(fills []Fill) (decimal.Decimal, string) {
+	if len(fills) == 0 {
+		return decimal.Zero, ""
+	}
+	feeAsset, total := fills[0].FeeAsset, decimal.Zero
+	for _, f := range fills {
+		if f.FeeAsset == feeAsset {
+			total = total.Add(f.FeeAmount)
+		}
+	}
+	return total, feeAsset
+}
+
+// NetQuantity is the base quantity the order left in the account:
+// Quantity less the fee when it was charged in the base asset. Routed
+// orders are net of fees already.
+func (o Order) NetQuantity() decimal.Decimal {
+	if len(o.Legs) > 0 || o.FeeAsset == "" {
+		return o.Quantity
+	}
+	base, _, err := SplitSymbol(o.Symbol)
+	if err != nil || asset.Canonical("", base) != asset.Canonical("", o.FeeAsset) {
+		return o.Quantity
+	}
+	return o.Quantity.Sub(o.FeeAmount)
+}
+
+// Balance is the detailed balance of a single asset
+type Balance struct {
+	Asset  string          `json:"asset"`
+	Free   decimal.Decimal `json:"free"`   // available for new orders
+	Locked decimal.Decimal `json:"locked"` // reserved by open orders
+	Total  decimal.Decimal `json:"total"`  // free + locked
+}
+
+// NewBalance builds a Balance from its free and locked parts
+func NewBalance(asset string, free, locked decimal.Decimal) Balance {
+	return Balance{
+		Asset:  asset,
+		Free:   free,
+		Locked: locked,
+		Total:  free.Add(locked),
+	}
+}
+
+// HasSignificantLocked reports whether the locked part is worth mentioning,
+// i.e. at least 1% of the total balance
+func (b Balance) HasSignificantLocked() bool {
+	if !b.Locked.IsPositive() {
+		return false
+	}
+	return b.Locked.GreaterThanOrEqual(b.Total.Div(decimal.NewFromInt(100)))
+}
+
+// Ticker is the market of a symbol at one moment: its last traded price
+// and the best bid and ask of the order book
+type Ticker struct {
+	Symbol string          `json:"symbol"`
+	Last   decimal.Decimal `json:"last"`
+	Bid    decimal.Decimal `json:"bid"`
+	Ask    decimal.Decimal `json:"ask"`
+}
+
+// Spread returns the ask less the bid as a fraction of the midpoint
+// (e.g. 0.0001 for 1 bp), or zero when either side is missing
+func (t Ticker) Spread() decimal.Decimal {
+	if !t.Bid.IsPositive() || !t.Ask.IsPositive() {
+		return decimal.Zero
+	}
+	mid := t.Bid.Add(t.Ask).Div(decimal.NewFromInt(2))
+	return t.Ask.Sub(t.Bid).Div(mid)
 }
 
 // Exchange defines the interface for cryptocurrency exchange operations
 type Exchange interface {
-	// GetBalance returns the available balance for a specific asset
+	// GetBalance returns the free (available) balance for a specific asset.
+	// Funds locked in open orders are excluded because they cannot be spent.
 	GetBalance(ctx context.Context, asset string) (decimal.Decimal, error)
 
-	// PlaceMarketBuyOrder places a market buy order with the specified quote amount
+	// GetBalanceDetail returns the free, locked and total balance for a specific asset
+	GetBalanceDetail(ctx context.Context, asset string) (Balance, error)
+
+	// PlaceMarketBuyOrder places a market buy order of size, mapped to the
+	// exchange's own parameters (see BinanceMarketBuyParams). Exchanges that
+	// only accept base quantities lack QuoteSizedMarketBuy; use MarketBuy to
+	// have quote amounts converted for them.
 	// symbol: trading pair (e.g., "BTC-USDT")
-	// quoteAmount: amount in quote currency to spend
-	PlaceMarketBuyOrder(ctx context.Context, symbol string, quoteAmount decimal.Decimal) (*Order, error)
+	PlaceMarketBuyOrder(ctx context.Context, symbol string, size OrderSize) (*Order, error)
+
+	// PlaceStopLossOrder places a stop-limit sell of quantity base asset that
+	// triggers at stopPrice and sells no lower than limitPrice (Binance
+	// STOP_LOSS_LIMIT, OKX conditional order). clientOrderID lets later
+	// runs find the order again.
+	PlaceStopLossOrder(ctx context.Context, symbol string, quantity, stopPrice, limitPrice decimal.Decimal, clientOrderID string) (*Order, error)
+
+	// OpenOrders returns the open orders for symbol, stop orders included
+	OpenOrders(ctx context.Context, symbol string) ([]Order, error)
+
+	// CancelOrder cancels an open order by its exchange order ID
+	CancelOrder(ctx context.Context, symbol, orderID string) error
+
+	// GetMyTrades returns the account's trades of symbol executed in
+	// [from, to), oldest first, paging through the history as needed
+	GetMyTrades(ctx context.Context, symbol string, from, to time.Time) ([]Trade, error)
+
+	// GetTicker returns the last price and best bid and ask of symbol, or
+	// an error wrapping ErrSymbolNotFound for symbols that are not listed
+	GetTicker(ctx context.Context, symbol string) (Ticker, error)
+
+	// Capabilities reports the features the client supports, which
+	// CheckCapabilities validates payloads against
+	Capabilities() Capabilities
 }
 
-// NewExchange creates an Exchange instance based on the provided configuration
-func NewExchange(cfg *config.DCAPayload) (Exchange, error) {
+// Order types
+const (
+	OrderTypeMarket        = "market"
+	OrderTypeStopLossLimit = "stop_loss_limit"
+)
+
+// Order statuses
+const (
+	OrderStatusFilled   = "filled"
+	OrderStatusPartial  = "partial" // partly filled and still working
+	OrderStatusOpen     = "open"    // acknowledged, nothing filled yet
+	OrderStatusRejected = "rejected"
+	OrderStatusCanceled = "canceled"
+)
+
+// Settled reports whether the order's status is final: filled, rejected
+// or canceled. Quantity and Price of an unsettled order are provisional.
+func (o Order) Settled() bool {
+	switch o.Status {
+	case OrderStatusFilled, OrderStatusRejected, OrderStatusCanceled:
+		return true
+	}
+	return false
+}
+
+// OrderGetter is implemented by exchanges that can look an order up by its
+// exchange order ID, to follow a market order acknowledged before it filled.
+// settle.Waiter polls it for the order's status, fills and average price.
+type OrderGetter interface {
+	GetOrder(ctx context.Context, symbol, orderID string) (*Order, error)
+}
+
+// ClientOrderGetter is implemented by exchanges that can look an order up
+// by the client order ID it was placed with, which PlaceOnce relies on
+type ClientOrderGetter interface {
+	// GetOrderByClientID returns the order of symbol placed with
+	// clientOrderID, or nil when there is none
+	GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error)
+}
+
+// OrderStreamer is implemented by exchanges that push order updates over
+// an authenticated websocket, their user-data stream, so an order can be
+// followed without polling GetOrder
+type OrderStreamer interface {
+	OpenOrderStream(ctx context.Context) (OrderStream, error)
+}
+
+// OrderStream is an open user-data stream. It does not reconnect: once
+// Next fails, the stream is done.
+type OrderStream interface {
+	// Next returns the next order update, waiting at most until ctx ends
+	Next(ctx context.Context) (*OrderUpdate, error)
+	Close() error
+}
+
+// OrderUpdate is an order as a user-data stream last pushed it. Symbol
+// is as the exchange writes it, e.g. "BTCUSDT" on Binance.
+type OrderUpdate struct {
+	Order Order
+
+	// FeesIncomplete is set when the order traded before the stream
+	// opened, so FeeAmount lacks the fees of those trades
+	FeesIncomplete bool
+}
+
+// MarketSeller is implemented by exchanges that can place market sells
+type MarketSeller interface {
+	// PlaceMarketSellOrder sells quantity of the symbol's base asset
+	PlaceMarketSellOrder(ctx context.Context, symbol string, quantity decimal.Decimal) (*Order, error)
+}
+
+// LotSizer is implemented by exchanges that can report the quantity step of
+// a symbol, e.g. 0.00001 for BTC-USDT on Binance. Order quantities must be
+// a multiple of it.
+type LotSizer interface {
+	LotStep(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// DefaultLotStep is assumed for exchanges that cannot report a lot step
+var DefaultLotStep = decimal.New(1, -8)
+
+// FeeRater is implemented by exchanges that can report the account's taker
+// fee rate for a symbol (e.g. 0.001 for 0.1%)
+type FeeRater interface {
+	TakerFeeRate(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// PriceTicker is implemented by exchanges that can report the last traded
+// price of a symbol. LastPrice returns an error wrapping ErrSymbolNotFound
+// for symbols that are not listed.
+type PriceTicker interface {
+	LastPrice(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// NotionalLimiter is implemented by exchanges that can report the smallest
+// order value a symbol accepts, in its quote asset (e.g. 5 USDT)
+type NotionalLimiter interface {
+	MinNotional(ctx context.Context, symbol string) (decimal.Decimal, error)
+}
+
+// Credentials are the resolved API credentials of an exchange account
+type Credentials struct {
+	APIKey     string
+	APISecret  string
+	Passphrase string // OKX only
+}
+
+// NewExchange creates an Exchange instance based on the provided
+// configuration. Dry runs need no credentials.
+func NewExchange(cfg *config.DCAPayload, creds Credentials) (Exchange, error) {
 	// Use mock exchange for dry run mode
 	if cfg.Flags.DryRun {
-		return NewMockExchange(), nil
+		if cfg.Flags.Mock == nil {
+			return NewMockExchange(), nil
+		}
+		sim, err := NewSimulation(*cfg.Flags.Mock)
+		if err != nil {
+			return nil, fmt.Errorf("flags.mock: %w", err)
+		}
+		return &MockExchange{Sim: sim, Halted: cfg.Flags.Mock.Halted, BaseOnly: cfg.Flags.Mock.BaseOnly, Settlement: cfg.Flags.Mock.Settlement}, nil
 	}
 
 	switch cfg.Exchange.Name {
 	case "binance":
-		return NewBinanceExchange(cfg)
+		return NewBinanceExchange(cfg, creds)
 	case "okx":
-		return NewOKXExchange(cfg)
+		return NewOKXExchange(cfg, creds)
 	default:
 		return nil, fmt.Errorf("unsupported exchange: %s", cfg.Exchange.Name)
 	}
 }
 
-// NewBinanceExchange creates a Binance exchange instance (placeholder)
-func NewBinanceExchange(cfg *config.DCAPayload) (Exchange, error) {
-	// TODO: Implement Binance exchange
-	return nil, fmt.Errorf("Binance exchange not implemented yet")
-}
-
 // NewOKXExchange creates an OKX exchange instance (placeholder)
-func NewOKXExchange(cfg *config.DCAPayload) (Exchange, error) {
-	// TODO: Implement OKX exchange
+func NewOKXExchange(cfg *config.DCAPayload, creds Credentials) (Exchange, error) {
+	// TODO: Implement OKX exchange; options.sandbox selects demo trading
+	// and Capabilities returns clientCapabilities["okx"]. Until it exists,
+	// OKX has none of these, which are left for it:
+	//   - GetTicker, by embedding OKXMarket (GET /api/v5/market/ticker)
+	//   - order fees, reading GET /api/v5/trade/order and fills with
+	//     ParseOKXOrder and ParseOKXFills
+	//   - CancelOrder, POST /api/v5/trade/cancel-order
+	//   - clOrdId from run.ClientOrderID on placed orders, and
+	//     ClientOrderGetter through GET /api/v5/trade/order?clOrdId= so
+	//     PlaceOnce can find an order a redelivered run already placed
 	return nil, fmt.Errorf("OKX exchange not implemented yet")
 }
 
 // MockExchange is a mock implementation for testing and dry run
-type MockExchange struct{}
+type MockExchange struct {
+	// Balances overrides the default mock balance per asset
+	Balances map[string]Balance
+
+	// Symbols, when set, are the only listed symbols
+	Symbols []string
+
+	// Halted are listed symbols whose trading is suspended
+	Halted []string
+
+	// BaseOnly makes market buys accept only base quantities, like venues
+	// without quote sizing
+	BaseOnly bool
+
+	// Prices overrides the default mock fill price per symbol
+	Prices map[string]decimal.Decimal
+
+	// Rules overrides the default mock trading rules per symbol
+	Rules map[string]TradingRules
+
+	// Open holds simulated open orders, such as stop-losses
+	Open []Order
+
+	// Trades is the simulated trade history, oldest first
+	Trades []Trade
+
+	// Klines overrides the candles per symbol, oldest first. Symbols
+	// without an entry trade flat at their mock price.
+	Klines map[string][]Kline
+
+	// TradePageLimit caps trades per history page; default 1000
+	TradePageLimit int
+
+	// Settlement scripts the statuses of market buys: PlaceMarketBuyOrder
+	// reports the first, each GetOrder call the next, and the last one
+	// repeats. The fill shows in full once filled, half while partial and
+	// not at all before (flags.mock.settlement). CancelOrder stops a buy
+	// at a status that is not final.
+	Settlement []string
+
+	// Sim, when set, delays and fails calls (flags.mock)
+	Sim *Simulation
+
+	placed   *Order // the last market buy, filled
+	settled  int    // index of its current status in Settlement
+	canceled bool   // CancelOrder stopped it at that status
+}
+
+// mockClientOrders are the market orders the mock exchanges of the process
+// placed under an idempotency key, by client order ID, so a redelivered run
+// is rejected as a duplicate like on a real exchange. Other client order
+// IDs carry the execution ID and never repeat.
+var mockClientOrders = struct {
+	sync.Mutex
+	orders map[string]Order
+}{orders: map[string]Order{}}
+
+// rememberClientOrder records order under its client order ID when the run
+// in ctx has an idempotency key, failing with ErrDuplicateClientOrderID
+// when the ID was used before
+func rememberClientOrder(ctx context.Context, order *Order) error {
+	if run.IdempotencyKey(ctx) == "" {
+		return nil
+	}
+	mockClientOrders.Lock()
+	defer mockClientOrders.Unlock()
+	if _, ok := mockClientOrders.orders[order.ClientOrderID]; ok {
+		return fmt.Errorf("%w %s", ErrDuplicateClientOrderID, order.ClientOrderID)
+	}
+	mockClientOrders.orders[order.ClientOrderID] = *order
+	return nil
+}
+
+// mockPrice is the fill price for symbols without an entry in Prices
+var mockPrice = decimal.NewFromFloat(50000) // Assume BTC price ~50k
 
 // NewMockExchange creates a new mock exchange instance
 func NewMockExchange() Exchange {
 	return &MockExchange{}
 }
 
-// GetBalance returns a mock balance for testing
+// GetBalance returns the mock free balance for testing
 func (m *MockExchange) GetBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	if err := m.Sim.call(ctx, "GetBalance"); err != nil {
+		return decimal.Zero, err
+	}
+	return m.balance(asset).Free, nil
+}
+
+// GetBalanceDetail returns the mock free/locked balance for testing
+func (m *MockExchange) GetBalanceDetail(ctx context.Context, code string) (Balance, error) {
+	if err := m.Sim.call(ctx, "GetBalanceDetail"); err != nil {
+		return Balance{}, err
+	}
+	return m.balance(code), nil
+}
+
+func (m *MockExchange) balance(code string) Balance {
+	code = asset.Canonical("", code)
+	if balance, ok := m.Balances[code]; ok {
+		return NewBalance(code, balance.Free, balance.Locked)
+	}
 	// Return a mock balance that's above typical thresholds for testing
-	return decimal.NewFromFloat(10000), nil
+	return NewBalance(code, decimal.NewFromFloat(10000), decimal.Zero)
+}
+
+// mockTakerFeeRate is the fee rate the mock reports, Binance's default 0.1%
+var mockTakerFeeRate = decimal.RequireFromString("0.001")
+
+// TakerFeeRate returns the mock taker fee rate
+func (m *MockExchange) TakerFeeRate(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	if err := m.Sim.call(ctx, "TakerFeeRate"); err != nil {
+		return decimal.Zero, err
+	}
+	return mockTakerFeeRate, nil
+}
+
+// CheckSymbol accepts any well-formed symbol, or only Symbols when set
+func (m *MockExchange) CheckSymbol(ctx context.Context, symbol string) error {
+	if err := m.Sim.call(ctx, "CheckSymbol"); err != nil {
+		return err
+	}
+	return m.checkSymbol(symbol)
+}
+
+func (m *MockExchange) checkSymbol(symbol string) error {
+	if _, _, err := SplitSymbol(symbol); err != nil {
+		return err
+	}
+	if m.Symbols != nil && !slices.Contains(m.Symbols, symbol) {
+		return fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+	}
+	return nil
+}
+
+// GetSymbolInfo reports a listed symbol as trading unless it is in Halted
+func (m *MockExchange) GetSymbolInfo(ctx context.Context, symbol string) (SymbolInfo, error) {
+	if err := m.Sim.call(ctx, "GetSymbolInfo"); err != nil {
+		return SymbolInfo{}, err
+	}
+	if err := m.checkSymbol(symbol); err != nil {
+		return SymbolInfo{}, err
+	}
+	return m.symbolInfo(symbol), nil
+}
+
+// ListSymbols returns Symbols; without them the mock cannot list anything
+func (m *MockExchange) ListSymbols(ctx context.Context) ([]SymbolInfo, error) {
+	if err := m.Sim.call(ctx, "ListSymbols"); err != nil {
+		return nil, err
+	}
+	infos := make([]SymbolInfo, 0, len(m.Symbols))
+	for _, symbol := range m.Symbols {
+		infos = append(infos, m.symbolInfo(symbol))
+	}
+	return infos, nil
+}
+
+func (m *MockExchange) symbolInfo(symbol string) SymbolInfo {
+	base, quote, _ := SplitSymbol(symbol)
+	info := SymbolInfo{Symbol: symbol, Base: base, Quote: quote, Status: SymbolTrading, RawStatus: "TRADING", Rules: m.rules(symbol)}
+	if slices.Contains(m.Halted, symbol) {
+		info.Status, info.RawStatus = SymbolHalted, "BREAK"
+	}
+	return info
+}
+
+// rules returns the mock trading rules of symbol
+func (m *MockExchange) rules(symbol string) TradingRules {
+	if rules, ok := m.Rules[symbol]; ok {
+		return rules
+	}
+	return mockRules
+}
+
+// price returns the mock fill price for symbol
+func (m *MockExchange) price(symbol string) decimal.Decimal {
+	if price, ok := m.Prices[symbol]; ok {
+		return price
+	}
+	return mockPrice
+}
+
+// mockParAssets trade at 1:1 against each other in the mock
+var mockParAssets = map[string]bool{"USD": true, "USDT": true, "USDC": true, "FDUSD": true, "BUSD": true, "TUSD": true, "DAI": true, "USDP": true}
+
+// GetKlines returns the symbol's entry in Klines opened in [from, to), or
+// flat candles at its mock price
+func (m *MockExchange) GetKlines(ctx context.Context, symbol string, interval time.Duration, from, to time.Time) ([]Kline, error) {
+	if err := m.Sim.call(ctx, "GetKlines"); err != nil {
+		return nil, err
+	}
+	if err := m.checkSymbol(symbol); err != nil {
+		return nil, err
+	}
+	var klines []Kline
+	if candles, ok := m.Klines[symbol]; ok {
+		for _, k := range candles {
+			if !k.OpenTime.Before(from) && k.OpenTime.Before(to) {
+				klines = append(klines, k)
+			}
+		}
+		return klines, nil
+	}
+	price := m.price(symbol)
+	for t := from.Truncate(interval); t.Before(to); t = t.Add(interval) {
+		if !t.Before(from) {
+			klines = append(klines, Kline{OpenTime: t, Open: price, High: price, Low: price, Close: price})
+		}
+	}
+	return klines, nil
+}
+
+// LastPrice returns the mock price of a listed symbol. Pairs of USD and its
+// stablecoins without an entry in Prices trade at par.
+func (m *MockExchange) LastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	if err := m.Sim.call(ctx, "LastPrice"); err != nil {
+		return decimal.Zero, err
+	}
+	return m.lastPrice(symbol)
+}
+
+func (m *MockExchange) lastPrice(symbol string) (decimal.Decimal, error) {
+	if err := m.checkSymbol(symbol); err != nil {
+		return decimal.Zero, err
+	}
+	base, quote, _ := SplitSymbol(symbol)
+	if _, ok := m.Prices[symbol]; !ok && mockParAssets[asset.Canonical("", base)] && mockParAssets[asset.Canonical("", quote)] {
+		return decimal.NewFromInt(1), nil
+	}
+	return m.price(symbol), nil
+}
+
+// GetTicker returns the LastPrice of symbol as its last price, bid and
+// ask: the mock book has no spread
+func (m *MockExchange) GetTicker(ctx context.Context, symbol string) (Ticker, error) {
+	if err := m.Sim.call(ctx, "GetTicker"); err != nil {
+		return Ticker{}, err
+	}
+	price, err := m.lastPrice(symbol)
+	if err != nil {
+		return Ticker{}, err
+	}
+	return Ticker{Symbol: symbol, Last: price, Bid: price, Ask: price}, nil
+}
+
+// mockLotStep is the lot step the mock reports, Binance's BTC step
+var mockLotStep = decimal.RequireFromString("0.00001")
+
+// mockMinNotional is the minimum order value the mock reports, Binance's
+// for BTC-USDT
+var mockMinNotional = decimal.NewFromInt(5)
+
+// mockRules are the trading rules of symbols without an entry in Rules,
+// Binance's for BTC-USDT
+var mockRules = TradingRules{
+	MinNotional:    mockMinNotional,
+	LotStep:        mockLotStep,
+	TickSize:       decimal.RequireFromString("0.01"),
+	BasePrecision:  8,
+	QuotePrecision: 8,
+}
+
+// LotStep returns the lot step of the symbol's mock rules
+func (m *MockExchange) LotStep(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	if err := m.Sim.call(ctx, "LotStep"); err != nil {
+		return decimal.Zero, err
+	}
+	if step := m.rules(symbol).LotStep; step.IsPositive() {
+		return step, nil
+	}
+	return DefaultLotStep, nil
+}
+
+// MinNotional returns the minimum order value of the symbol's mock rules
+func (m *MockExchange) MinNotional(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	if err := m.Sim.call(ctx, "MinNotional"); err != nil {
+		return decimal.Zero, err
+	}
+	return m.rules(symbol).MinNotional, nil
+}
+
+// Capabilities advertises every capability, less quote-sized market buys
+// with BaseOnly
+func (m *MockExchange) Capabilities() Capabilities {
+	if m.BaseOnly {
+		return AllCapabilities &^ QuoteSizedMarketBuy
+	}
+	return AllCapabilities
 }
 
 // PlaceMarketBuyOrder simulates placing a market buy order
-func (m *MockExchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, quoteAmount decimal.Decimal) (*Order, error) {
+func (m *MockExchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, size OrderSize) (*Order, error) {
+	if err := m.Sim.call(ctx, "PlaceMarketBuyOrder"); err != nil {
+		return nil, err
+	}
+	if err := size.validate(); err != nil {
+		return nil, err
+	}
+	if size.IsQuote() && m.BaseOnly {
+		return nil, fmt.Errorf("market buys must be sized in the base asset")
+	}
 	// Simulate a successful order with mock data
-	return &Order{
-		ID:       "mock-order-12345",
-		Symbol:   symbol,
-		Side:     "buy",
-		Type:     "market",
-		Quantity: quoteAmount.Div(decimal.NewFromFloat(50000)), // Assume BTC price ~50k
-		Price:    decimal.NewFromFloat(50000),
-		Status:   "filled",
-	}, nil
-}
\ No newline at end of file
+	price := m.price(symbol)
+	quantity := size.Base
+	if size.IsQuote() {
+		quantity = size.Quote.Div(price)
+	}
+	order := &Order{
+		ID:            "mock-order-12345",
+		ClientOrderID: run.ClientOrderID(ctx, "dca"),
+		Symbol:        symbol,
+		Side:          "buy",
+		Type:          "market",
+		Quantity:      quantity,
+		Price:         price,
+		Status:        "filled",
+	}
+	chargeMockFee(order)
+	if err := rememberClientOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	placed := *order
+	m.placed, m.settled, m.canceled = &placed, 0, false
+	return m.scripted(), nil
+}
+
+// GetOrderByClientID returns the market order of symbol placed under
+// clientOrderID by any mock exchange of the process, or nil
+func (m *MockExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error) {
+	if err := m.Sim.call(ctx, "GetOrderByClientID"); err != nil {
+		return nil, err
+	}
+	mockClientOrders.Lock()
+	defer mockClientOrders.Unlock()
+	order, ok := mockClientOrders.orders[clientOrderID]
+	if !ok || order.Symbol != symbol {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+// GetOrder returns the last market buy, with the next status of Settlement
+// when set
+func (m *MockExchange) GetOrder(ctx context.Context, symbol, orderID string) (*Order, error) {
+	if err := m.Sim.call(ctx, "GetOrder"); err != nil {
+		return nil, err
+	}
+	if m.placed == nil || m.placed.ID != orderID || m.placed.Symbol != symbol {
+		return nil, fmt.Errorf("order %s of %s not found", orderID, symbol)
+	}
+	if !m.canceled && m.settled < len(m.Settlement)-1 {
+		m.settled++
+	}
+	return m.scripted(), nil
+}
+
+// scripted is the placed market buy as of its current Settlement status,
+// filled without one, and canceled with what filled once CancelOrder
+// stopped it
+func (m *MockExchange) scripted() *Order {
+	order := *m.placed
+	if len(m.Settlement) == 0 {
+		return &order
+	}
+	order.Status = m.Settlement[m.settled]
+	switch order.Status {
+	case OrderStatusFilled:
+	case OrderStatusPartial:
+		order.Quantity = order.Quantity.Div(decimal.NewFromInt(2))
+	default:
+		order.Quantity, order.Price = decimal.Zero, decimal.Zero
+	}
+	if m.canceled {
+		order.Status = OrderStatusCanceled
+	}
+	chargeMockFee(&order)
+	return &order
+}
+
+// chargeMockFee books the order's quantity as one fill charged
+// mockTakerFeeRate, in the asset received like Binance without BNB: the
+// base asset for buys, the quote asset for sells
+func chargeMockFee(order *Order) {
+	order.Fills, order.FeeAmount, order.FeeAsset = nil, decimal.Zero, ""
+	if !order.Quantity.IsPositive() {
+		return
+	}
+	base, quote, _ := SplitSymbol(order.Symbol)
+	fill := Fill{TradeID: order.ID + "-1", Price: order.Price, Quantity: order.Quantity, FeeAmount: order.Quantity.Mul(mockTakerFeeRate), FeeAsset: base}
+	if order.Side == "sell" {
+		fill.FeeAmount, fill.FeeAsset = order.Quantity.Mul(order.Price).Mul(mockTakerFeeRate), quote
+	}
+	order.Fills = []Fill{fill}
+	order.FeeAmount, order.FeeAsset = SumFees(order.Fills)
+}
+
+// PlaceMarketSellOrder simulates placing a market sell order
+func (m *MockExchange) PlaceMarketSellOrder(ctx context.Context, symbol string, quantity decimal.Decimal) (*Order, error) {
+	if err := m.Sim.call(ctx, "PlaceMarketSellOrder"); err != nil {
+		return nil, err
+	}
+	order := &Order{
+		ID:            "mock-order-12346",
+		ClientOrderID: run.ClientOrderID(ctx, "dca"),
+		Symbol:        symbol,
+		Side:          "sell",
+		Type:          "market",
+		Quantity:      quantity,
+		Price:         m.price(symbol),
+		Status:        "filled",
+	}
+	chargeMockFee(order)
+	if err := rememberClientOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// PlaceStopLossOrder simulates placing a stop-limit sell; the order stays open
+func (m *MockExchange) PlaceStopLossOrder(ctx context.Context, symbol string, quantity, stopPrice, limitPrice decimal.Decimal, clientOrderID string) (*Order, error) {
+	if err := m.Sim.call(ctx, "PlaceStopLossOrder"); err != nil {
+		return nil, err
+	}
+	order := Order{
+		ID:            "mock-stop-" + clientOrderID,
+		ClientOrderID: clientOrderID,
+		Symbol:        symbol,
+		Side:          "sell",
+		Type:          OrderTypeStopLossLimit,
+		Quantity:      quantity,
+		Price:         limitPrice,
+		StopPrice:     stopPrice,
+		Status:        "open",
+	}
+	m.Open = append(m.Open, order)
+	return &order, nil
+}
+
+// OpenOrders returns the simulated open orders for symbol
+func (m *MockExchange) OpenOrders(ctx context.Context, symbol string) ([]Order, error) {
+	if err := m.Sim.call(ctx, "OpenOrders"); err != nil {
+		return nil, err
+	}
+	var open []Order
+	for _, o := range m.Open {
+		if o.Symbol == symbol {
+			open = append(open, o)
+		}
+	}
+	return open, nil
+}
+
+// CancelOrder removes a simulated open order, or stops the last market
+// buy while its Settlement status is not final
+func (m *MockExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	if err := m.Sim.call(ctx, "CancelOrder"); err != nil {
+		return err
+	}
+	if m.placed != nil && m.placed.ID == orderID && m.placed.Symbol == symbol {
+		if order := m.scripted(); order.Settled() {
+			return fmt.Errorf("order %s is already %s", orderID, order.Status)
+		}
+		m.canceled = true
+		return nil
+	}
+	for i, o := range m.Open {
+		if o.Symbol == symbol && o.ID == orderID {
+			m.Open = slices.Delete(m.Open, i, i+1)
+			return nil
+		}
+	}
+	return fmt.Errorf("order %s not found", orderID)
+}
+
+// GetMyTrades pages through the simulated trade history like Binance does,
+// one day and TradePageLimit trades at a time
+func (m *MockExchange) GetMyTrades(ctx context.Context, symbol string, from, to time.Time) ([]Trade, error) {
+	if err := m.Sim.call(ctx, "GetMyTrades"); err != nil {
+		return nil, err
+	}
+	limit := m.TradePageLimit
+	if limit == 0 {
+		limit = 1000
+	}
+	return CollectTrades(ctx, from, to, 24*time.Hour, limit, func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error) {
+		var page []Trade
+		skipping := after != ""
+		for _, t := range m.Trades {
+			if t.Symbol != symbol || t.Time.Before(start) || !t.Time.Before(end) {
+				continue
+			}
+			if skipping {
+				skipping = t.ID != after
+				continue
+			}
+			page = append(page, t)
+			if len(page) == limit {
+				break
+			}
+		}
+		return page, nil
+	})
+}
@@ -0,0 +1,249 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestMockExchange_GetBalanceReturnsFree(t *testing.T) {
+	mock := &MockExchange{
+		Balances: map[string]Balance{
+			"USDT": {Free: decimal.RequireFromString("12"), Locked: decimal.RequireFromString("200")},
+		},
+	}
+
+	balance, err := mock.GetBalance(context.Background(), "USDT")
+	if err != nil {
+		t.Fatalf("GetBalance() error = %v", err)
+	}
+	if !balance.Equal(decimal.RequireFromString("12")) {
+		t.Errorf("GetBalance() = %v, want 12 (free only)", balance)
+	}
+
+	detail, err := mock.GetBalanceDetail(context.Background(), "USDT")
+	if err != nil {
+		t.Fatalf("GetBalanceDetail() error = %v", err)
+	}
+	if !detail.Locked.Equal(decimal.RequireFromString("200")) {
+		t.Errorf("Locked = %v, want 200", detail.Locked)
+	}
+	if !detail.Total.Equal(decimal.RequireFromString("212")) {
+		t.Errorf("Total = %v, want 212", detail.Total)
+	}
+}
+
+func TestMockExchange_DefaultBalance(t *testing.T) {
+	mock := &MockExchange{}
+
+	detail, err := mock.GetBalanceDetail(context.Background(), "BTC")
+	if err != nil {
+		t.Fatalf("GetBalanceDetail() error = %v", err)
+	}
+	if detail.Asset != "BTC" {
+		t.Errorf("Asset = %v, want BTC", detail.Asset)
+	}
+	if !detail.Free.Equal(detail.Total) || !detail.Locked.IsZero() {
+		t.Errorf("expected default balance to be fully free, got %+v", detail)
+	}
+}
+
+func TestBalance_HasSignificantLocked(t *testing.T) {
+	tests := []struct {
+		name     string
+		free     string
+		locked   string
+		expected bool
+	}{
+		{"nothing_locked", "100", "0", false},
+		{"mostly_locked", "12", "200", true},
+		{"negligible_locked", "1000", "0.5", false},
+		{"exactly_one_percent", "99", "1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBalance("USDT", decimal.RequireFromString(tt.free), decimal.RequireFromString(tt.locked))
+			if got := b.HasSignificantLocked(); got != tt.expected {
+				t.Errorf("HasSignificantLocked() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMockExchange_ClientOrderIDCarriesExecutionID(t *testing.T) {
+	executionID := run.NewID()
+	ctx := run.WithID(context.Background(), executionID)
+	mock := NewMockExchange()
+
+	order, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.RequireFromString("10")))
+	if err != nil {
+		t.Fatalf("PlaceMarketBuyOrder() error = %v", err)
+	}
+	if !strings.HasSuffix(order.ClientOrderID, run.Suffix(executionID)) {
+		t.Errorf("ClientOrderID = %q, want suffix %q", order.ClientOrderID, run.Suffix(executionID))
+	}
+	if run.ID(ctx) != executionID {
+		t.Errorf("execution ID changed during the run: %q != %q", run.ID(ctx), executionID)
+	}
+}
+
+func TestMockExchange_CanonicalAssetCodes(t *testing.T) {
+	mock := &MockExchange{
+		Balances: map[string]Balance{"BTC": {Free: decimal.RequireFromString("0.5")}},
+	}
+
+	balance, err := mock.GetBalanceDetail(context.Background(), "XBT")
+	if err != nil {
+		t.Fatalf("GetBalanceDetail() error = %v", err)
+	}
+	if balance.Asset != "BTC" || !balance.Free.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("GetBalanceDetail(XBT) = %+v, want 0.5 BTC", balance)
+	}
+}
+
+func TestListed(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockExchange{Symbols: []string{"BTC-USDT"}}
+
+	if ok, err := Listed(ctx, mock, "BTC-USDT"); !ok || err != nil {
+		t.Errorf("Listed(BTC-USDT) = %v, %v; want true", ok, err)
+	}
+	if ok, err := Listed(ctx, mock, "BTC-USDC"); ok || err != nil {
+		t.Errorf("Listed(BTC-USDC) = %v, %v; want false without error", ok, err)
+	}
+	if ok, err := Listed(ctx, mock, "BTCXYZ"); ok || err == nil {
+		t.Errorf("Listed(BTCXYZ) = %v, %v; want a format error", ok, err)
+	}
+	if ok, _ := Listed(ctx, &MockExchange{}, "PEPE-USDC"); !ok {
+		t.Error("a mock without Symbols should list every symbol")
+	}
+}
+
+func TestSumFees(t *testing.T) {
+	d := decimal.RequireFromString
+	tests := []struct {
+		name      string
+		fills     []Fill
+		wantFee   string
+		wantAsset string
+	}{
+		{"none", nil, "0", ""},
+		{"one", []Fill{{FeeAmount: d("0.0000003"), FeeAsset: "BTC"}}, "0.0000003", "BTC"},
+		{"several", []Fill{
+			{FeeAmount: d("0.0000003"), FeeAsset: "BTC"},
+			{FeeAmount: d("0.0000005"), FeeAsset: "BTC"},
+			{FeeAmount: d("0.0000001"), FeeAsset: "BTC"},
+		}, "0.0000009", "BTC"},
+		{"bnb", []Fill{{FeeAmount: d("0.00004"), FeeAsset: "BNB"}, {FeeAmount: d("0.00006"), FeeAsset: "BNB"}}, "0.0001", "BNB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, feeAsset := SumFees(tt.fills)
+			if !fee.Equal(d(tt.wantFee)) || feeAsset != tt.wantAsset {
+				t.Errorf("SumFees() = %s %s, want %s %s", fee, feeAsset, tt.wantFee, tt.wantAsset)
+			}
+		})
+	}
+}
+
+func TestOrder_NetQuantity(t *testing.T) {
+	d := decimal.RequireFromString
+	tests := []struct {
+		name  string
+		order Order
+		want  string
+	}{
+		{"fee in base", Order{Symbol: "BTC-USDT", Quantity: d("0.001"), FeeAmount: d("0.000001"), FeeAsset: "BTC"}, "0.000999"},
+		{"fee in bnb", Order{Symbol: "BTC-USDT", Quantity: d("0.001"), FeeAmount: d("0.00002"), FeeAsset: "BNB"}, "0.001"},
+		{"no fee", Order{Symbol: "BTC-USDT", Quantity: d("0.001")}, "0.001"},
+		{"routed", Order{Symbol: "BTC-EUR", Quantity: d("0.001"), FeeAmount: d("0.000001"), FeeAsset: "BTC", Legs: []Order{{}}}, "0.001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.NetQuantity(); !got.Equal(d(tt.want)) {
+				t.Errorf("NetQuantity() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockExchange_ChargesFees(t *testing.T) {
+	mock := &MockExchange{Prices: map[string]decimal.Decimal{"BTC-USDT": decimal.NewFromInt(50000)}}
+	ctx := context.Background()
+
+	buy, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.1% of the 0.002 BTC bought, taken from the BTC
+	if !buy.FeeAmount.Equal(decimal.RequireFromString("0.000002")) || buy.FeeAsset != "BTC" || len(buy.Fills) != 1 {
+		t.Errorf("buy fee = %s %s over %d fills", buy.FeeAmount, buy.FeeAsset, len(buy.Fills))
+	}
+
+	sell, err := mock.PlaceMarketSellOrder(ctx, "BTC-USDT", decimal.RequireFromString("0.002"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sell.FeeAmount.Equal(decimal.RequireFromString("0.1")) || sell.FeeAsset != "USDT" {
+		t.Errorf("sell fee = %s %s, want 0.1 USDT", sell.FeeAmount, sell.FeeAsset)
+	}
+
+	// A buy still open has paid nothing yet
+	mock.Settlement = []string{OrderStatusOpen, OrderStatusPartial}
+	open, _ := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(100)))
+	if !open.FeeAmount.IsZero() || len(open.Fills) != 0 {
+		t.Errorf("open order fee = %s over %d fills, want none", open.FeeAmount, len(open.Fills))
+	}
+	partial, _ := mock.GetOrder(ctx, "BTC-USDT", open.ID)
+	if !partial.FeeAmount.Equal(decimal.RequireFromString("0.000001")) {
+		t.Errorf("partial order fee = %s, want 0.000001", partial.FeeAmount)
+	}
+}
+
+func TestMockExchange_Rules(t *testing.T) {
+	rules := TradingRules{MinNotional: decimal.NewFromInt(10), LotStep: decimal.RequireFromString("0.001")}
+	mock := &MockExchange{Rules: map[string]TradingRules{"ETH-USDT": rules}}
+	ctx := context.Background()
+
+	got, err := Rules(ctx, mock, "ETH-USDT")
+	if err != nil || !got.MinNotional.Equal(rules.MinNotional) {
+		t.Errorf("Rules(ETH-USDT) = %+v, %v, want the configured rules", got, err)
+	}
+	if min, _ := mock.MinNotional(ctx, "ETH-USDT"); !min.Equal(rules.MinNotional) {
+		t.Errorf("MinNotional(ETH-USDT) = %s, want 10", min)
+	}
+	if step, _ := mock.LotStep(ctx, "BTC-USDT"); !step.Equal(mockLotStep) {
+		t.Errorf("LotStep(BTC-USDT) = %s, want the default %s", step, mockLotStep)
+	}
+	if got, err := Rules(ctx, struct{ Exchange }{mock}, "ETH-USDT"); err != nil || got != (TradingRules{}) {
+		t.Errorf("Rules() without SymbolInfoer = %+v, %v, want none", got, err)
+	}
+}
+
+func TestMockExchange_GetTicker(t *testing.T) {
+	price := decimal.RequireFromString("3120.5")
+	mock := &MockExchange{Prices: map[string]decimal.Decimal{"ETH-USDT": price}, Symbols: []string{"ETH-USDT"}}
+	ticker, err := mock.GetTicker(context.Background(), "ETH-USDT")
+	if err != nil || !ticker.Last.Equal(price) || !ticker.Bid.Equal(price) || !ticker.Ask.Equal(price) {
+		t.Errorf("GetTicker() = %+v, %v, want %s on both sides", ticker, err, price)
+	}
+	if _, err := mock.GetTicker(context.Background(), "BTC-USDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("GetTicker(BTC-USDT) error = %v, want ErrSymbolNotFound", err)
+	}
+}
+
+func TestMockExchange_LastPriceStablecoinsAtPar(t *testing.T) {
+	mock := &MockExchange{}
+	ctx := context.Background()
+	if price, err := mock.LastPrice(ctx, "USDT-USD"); err != nil || !price.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("LastPrice(USDT-USD) = %s, %v, want 1", price, err)
+	}
+	if price, err := mock.LastPrice(ctx, "BTC-USD"); err != nil || !price.Equal(mockPrice) {
+		t.Errorf("LastPrice(BTC-USD) = %s, %v, want %s", price, err, mockPrice)
+	}
+}
@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// SymbolChecker is implemented by exchanges that can tell whether a symbol
+// is listed and tradable on them. CheckSymbol returns an error wrapping
+// ErrSymbolNotFound for symbols that are not listed.
+type SymbolChecker interface {
+	CheckSymbol(ctx context.Context, symbol string) error
+}
+
+// Listed reports whether symbol is listed on exc. Exchanges that cannot
+// tell are assumed to list every symbol.
+func Listed(ctx context.Context, exc Exchange, symbol string) (bool, error) {
+	checker, ok := exc.(SymbolChecker)
+	if !ok {
+		return true, nil
+	}
+	err := checker.CheckSymbol(ctx, symbol)
+	if errors.Is(err, ErrSymbolNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Failover records a venue that was skipped because it was unavailable
+type Failover struct {
+	Exchange string `json:"exchange"`
+	Reason   string `json:"reason"`
+}
+
+// RunWithFailover calls fn for each venue in priority order until one
+// succeeds or fails for a reason other than availability. It returns the
+// venues that were failed over; the last venue tried is the one whose error,
+// if any, is returned.
+func RunWithFailover(ctx context.Context, venues []config.ExchangeConfig, fn func(venue config.ExchangeConfig) error) ([]Failover, error) {
+	var failovers []Failover
+	for i, venue := range venues {
+		err := fn(venue)
+		if err == nil || !IsRetriable(err) || i == len(venues)-1 {
+			return failovers, err
+		}
+		if ctx.Err() != nil {
+			// The run is out of time; another venue would fail the same way
+			return failovers, err
+		}
+		log.Printf("🔀 %s is unavailable, failing over to %s: %v", venue.Name, venues[i+1].Name, err)
+		failovers = append(failovers, Failover{Exchange: venue.Name, Reason: err.Error()})
+	}
+	return failovers, fmt.Errorf("no exchange configured")
+}
@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestIsRetriable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"construction_5xx", fmt.Errorf("failed to create exchange: %w", &HTTPError{StatusCode: 503}), true},
+		{"preflight_maintenance", fmt.Errorf("failed to size order: %w", &UnavailableError{Exchange: "binance", Reason: "maintenance"}), true},
+		{"order_5xx", fmt.Errorf("failed to place order: %w", &HTTPError{StatusCode: 502}), true},
+		{"balance_timeout", fmt.Errorf("failed to get balance: %w", context.DeadlineExceeded), true},
+		{"order_timeout", fmt.Errorf("failed to place order: %w: %w", ErrOrderOutcomeUnknown, context.DeadlineExceeded), false},
+		{"insufficient_funds", fmt.Errorf("failed to place order: %w", &HTTPError{StatusCode: 400, Body: "insufficient balance"}), false},
+		{"invalid_symbol", fmt.Errorf("symbol FOO-USDT not available on okx: %w", errors.New("instrument not found")), false},
+		{"rate_limited", &HTTPError{StatusCode: 429}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetriable(tt.err); got != tt.expected {
+				t.Errorf("IsRetriable(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRunWithFailover(t *testing.T) {
+	venues := []config.ExchangeConfig{{Name: "binance"}, {Name: "okx"}, {Name: "kraken"}}
+	unavailable := &UnavailableError{Exchange: "binance", Reason: "HTTP 503"}
+	rejected := errors.New("insufficient balance")
+
+	tests := []struct {
+		name          string
+		errs          map[string]error
+		wantTried     string
+		wantFailovers string
+		wantErr       error
+	}{
+		{"primary_succeeds", nil, "binance", "", nil},
+		{"fails_over_once", map[string]error{"binance": unavailable}, "binance,okx", "binance", nil},
+		{"fails_over_twice", map[string]error{"binance": unavailable, "okx": unavailable}, "binance,okx,kraken", "binance,okx", nil},
+		{"all_unavailable", map[string]error{"binance": unavailable, "okx": unavailable, "kraken": unavailable}, "binance,okx,kraken", "binance,okx", unavailable},
+		{"business_rejection_stops", map[string]error{"binance": rejected}, "binance", "", rejected},
+		{"rejection_after_failover", map[string]error{"binance": unavailable, "okx": rejected}, "binance,okx", "binance", rejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tried []string
+			failovers, err := RunWithFailover(context.Background(), venues, func(venue config.ExchangeConfig) error {
+				tried = append(tried, venue.Name)
+				return tt.errs[venue.Name]
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got := strings.Join(tried, ","); got != tt.wantTried {
+				t.Errorf("tried = %s, want %s", got, tt.wantTried)
+			}
+			var names []string
+			for _, f := range failovers {
+				names = append(names, f.Exchange)
+				if f.Reason == "" {
+					t.Errorf("failover from %s has no reason", f.Exchange)
+				}
+			}
+			if got := strings.Join(names, ","); got != tt.wantFailovers {
+				t.Errorf("failovers = %s, want %s", got, tt.wantFailovers)
+			}
+		})
+	}
+}
+
+func TestRunWithFailover_StopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	_, err := RunWithFailover(ctx, []config.ExchangeConfig{{Name: "binance"}, {Name: "okx"}}, func(venue config.ExchangeConfig) error {
+		calls++
+		return &HTTPError{StatusCode: 503}
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("calls = %d, err = %v; want one call and an error", calls, err)
+	}
+}
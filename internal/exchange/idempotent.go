@@ -0,0 +1,50 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// PlaceOnce places a market order of symbol with place, unless the run in
+// ctx has an idempotency key (run.WithIdempotencyKey) and its client order
+// ID already names an order, which is returned instead. An order rejected
+// with ErrDuplicateClientOrderID, placed meanwhile by a concurrent run of
+// the same key, is looked up too. Without a key, or on exchanges that are
+// not a ClientOrderGetter, the order is simply placed. A failed lookup
+// before placing is an ErrOrderLookup, and an ErrExchangeUnavailable when
+// transient.
+func PlaceOnce(ctx context.Context, exc Exchange, symbol string, place func(ctx context.Context) (*Order, error)) (*Order, error) {
+	getter, ok := exc.(ClientOrderGetter)
+	if !ok || run.IdempotencyKey(ctx) == "" {
+		return place(ctx)
+	}
+	clientOrderID := run.ClientOrderID(ctx, "dca")
+	existing, err := getter.GetOrderByClientID(ctx, symbol, clientOrderID)
+	if err != nil {
+		// Nothing was sent: a transient failure leaves the venue
+		// unavailable for now, not the order rejected
+		if IsTransient(err) {
+			return nil, fmt.Errorf("%w for %s: %w: %w", ErrOrderLookup, clientOrderID, ErrExchangeUnavailable, err)
+		}
+		return nil, fmt.Errorf("%w for %s: %w", ErrOrderLookup, clientOrderID, err)
+	}
+	if existing != nil {
+		log.Printf("♻️ Order already placed for this run's idempotency key, reusing it (orderId=%s clientOrderId=%s status=%s)", existing.ID, clientOrderID, existing.Status)
+		return existing, nil
+	}
+
+	order, err := place(ctx)
+	if !errors.Is(err, ErrDuplicateClientOrderID) {
+		return order, err
+	}
+	existing, lookupErr := getter.GetOrderByClientID(ctx, symbol, clientOrderID)
+	if lookupErr != nil || existing == nil {
+		return nil, errors.Join(err, lookupErr)
+	}
+	log.Printf("♻️ Order rejected as a duplicate, reusing the one placed (orderId=%s clientOrderId=%s status=%s)", existing.ID, clientOrderID, existing.Status)
+	return existing, nil
+}
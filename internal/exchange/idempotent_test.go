@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// keyedRun returns the context of a run with a fresh execution ID and the
+// idempotency key
+func keyedRun(key string) context.Context {
+	return run.WithIdempotencyKey(run.WithID(context.Background(), run.NewID()), key)
+}
+
+func TestPlaceOnce_RedeliveryReusesOrder(t *testing.T) {
+	key := "TestPlaceOnce_RedeliveryReusesOrder|" + run.NewID()
+	buy := func(ctx context.Context, exc Exchange) (*Order, int) {
+		placed := 0
+		order, err := PlaceOnce(ctx, exc, "BTC-USDT", func(ctx context.Context) (*Order, error) {
+			placed++
+			return exc.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(50)))
+		})
+		if err != nil {
+			t.Fatalf("PlaceOnce() error = %v", err)
+		}
+		return order, placed
+	}
+
+	first, placed := buy(keyedRun(key), &MockExchange{})
+	if placed != 1 {
+		t.Fatalf("first delivery placed %d orders, want 1", placed)
+	}
+	// A redelivery runs with a new execution ID, on a new client
+	again, placed := buy(keyedRun(key), &MockExchange{})
+	if placed != 0 || !reflect.DeepEqual(again, first) {
+		t.Errorf("redelivery placed %d orders and got %+v, want none and %+v", placed, again, first)
+	}
+
+	// Runs without a key place every time
+	ctx := run.WithID(context.Background(), "01ARYZ6S41TSV4RRFFQ69G5FAV")
+	for range 2 {
+		if _, placed := buy(ctx, &MockExchange{}); placed != 1 {
+			t.Errorf("unkeyed run placed %d orders, want 1", placed)
+		}
+	}
+}
+
+// racingMock misses the order on its first lookup, as a run does that
+// checks just before a concurrent delivery of its event places it
+type racingMock struct {
+	*MockExchange
+	lookups int
+}
+
+func (r *racingMock) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error) {
+	r.lookups++
+	if r.lookups == 1 {
+		return nil, nil
+	}
+	return r.MockExchange.GetOrderByClientID(ctx, symbol, clientOrderID)
+}
+
+func TestPlaceOnce_DuplicateRejection(t *testing.T) {
+	key := "TestPlaceOnce_DuplicateRejection|" + run.NewID()
+	concurrent, err := (&MockExchange{}).PlaceMarketSellOrder(keyedRun(key), "BTC-USDT", decimal.RequireFromString("0.001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exc := &racingMock{MockExchange: &MockExchange{}}
+	ctx := keyedRun(key)
+	if _, err := exc.PlaceMarketSellOrder(ctx, "BTC-USDT", decimal.RequireFromString("0.001")); !errors.Is(err, ErrDuplicateClientOrderID) {
+		t.Fatalf("PlaceMarketSellOrder() with a used client order ID: error = %v, want ErrDuplicateClientOrderID", err)
+	}
+	order, err := PlaceOnce(ctx, exc, "BTC-USDT", func(ctx context.Context) (*Order, error) {
+		return exc.PlaceMarketSellOrder(ctx, "BTC-USDT", decimal.RequireFromString("0.001"))
+	})
+	if err != nil || order.ClientOrderID != concurrent.ClientOrderID || exc.lookups != 2 {
+		t.Errorf("PlaceOnce() = %+v, %v after %d lookups, want the concurrent order after 2", order, err, exc.lookups)
+	}
+}
+
+// failingLookupMock fails every lookup with err
+type failingLookupMock struct {
+	*MockExchange
+	err error
+}
+
+func (f *failingLookupMock) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*Order, error) {
+	return nil, f.err
+}
+
+func TestPlaceOnce_LookupFailure(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+	}{
+		{"5xx", &HTTPError{StatusCode: 502, Body: "Bad Gateway"}, true},
+		{"timeout", timeoutError{}, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"auth failure", &HTTPError{StatusCode: 401, Body: `{"code":-2015}`}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			placed := 0
+			_, err := PlaceOnce(keyedRun("TestPlaceOnce_LookupFailure|"+run.NewID()), &failingLookupMock{MockExchange: &MockExchange{}, err: tt.err}, "BTC-USDT", func(ctx context.Context) (*Order, error) {
+				placed++
+				return nil, nil
+			})
+			if placed != 0 || !errors.Is(err, ErrOrderLookup) {
+				t.Fatalf("PlaceOnce() = %v after placing %d orders, want ErrOrderLookup and none placed", err, placed)
+			}
+			if got := errors.Is(err, ErrExchangeUnavailable); got != tt.wantUnavailable {
+				t.Errorf("PlaceOnce() error = %v, unavailable %v, want %v", err, got, tt.wantUnavailable)
+			}
+		})
+	}
+}
+
+func TestBinanceExchange_ClientOrderIDs(t *testing.T) {
+	query, err := os.ReadFile(filepath.Join("testdata", "binance_order_query.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trades, err := os.ReadFile(filepath.Join("testdata", "binance_my_trades.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := run.WithIdempotencyKey(context.Background(), "2026-10-16T08:00:00Z|BTC-USDT|buy")
+
+	t.Run("duplicate rejection", func(t *testing.T) {
+		transport := &scriptedTransport{replies: map[string][]reply{
+			"POST /api/v3/order": {{status: 400, body: `{"code":-2010,"msg":"Duplicate order sent."}`}},
+		}}
+		var waits []time.Duration
+		_, err := retryingBinance(transport, 3, &waits).PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(50)))
+		if !errors.Is(err, ErrDuplicateClientOrderID) {
+			t.Errorf("PlaceMarketBuyOrder() error = %v, want ErrDuplicateClientOrderID", err)
+		}
+	})
+
+	t.Run("insufficient balance is not a duplicate", func(t *testing.T) {
+		transport := &scriptedTransport{replies: map[string][]reply{
+			"POST /api/v3/order": {{status: 400, body: `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`}},
+		}}
+		var waits []time.Duration
+		_, err := retryingBinance(transport, 3, &waits).PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(50)))
+		if err == nil || errors.Is(err, ErrDuplicateClientOrderID) {
+			t.Errorf("PlaceMarketBuyOrder() error = %v, want a rejection that is not a duplicate", err)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		transport := &scriptedTransport{replies: map[string][]reply{
+			"GET /api/v3/order":    {{status: 200, body: string(query)}},
+			"GET /api/v3/myTrades": {{status: 200, body: string(trades)}},
+		}}
+		var waits []time.Duration
+		order, err := retryingBinance(transport, 3, &waits).GetOrderByClientID(ctx, "BTC-USDT", run.ClientOrderID(ctx, "dca"))
+		if err != nil || order == nil || order.ID != "28457113" || order.Status != OrderStatusFilled || len(order.Fills) == 0 {
+			t.Errorf("GetOrderByClientID() = %+v, %v, want filled order 28457113 with its fills", order, err)
+		}
+	})
+
+	t.Run("lookup finds none", func(t *testing.T) {
+		transport := &scriptedTransport{replies: map[string][]reply{
+			"GET /api/v3/order": {{status: 400, body: `{"code":-2013,"msg":"Order does not exist."}`}},
+		}}
+		var waits []time.Duration
+		order, err := retryingBinance(transport, 3, &waits).GetOrderByClientID(ctx, "BTC-USDT", run.ClientOrderID(ctx, "dca"))
+		if err != nil || order != nil {
+			t.Errorf("GetOrderByClientID() = %+v, %v, want nil", order, err)
+		}
+	})
+}
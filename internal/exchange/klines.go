@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Kline is one candle of a symbol's trades
+type Kline struct {
+	OpenTime time.Time       `json:"openTime"`
+	Open     decimal.Decimal `json:"open"`
+	High     decimal.Decimal `json:"high"`
+	Low      decimal.Decimal `json:"low"`
+	Close    decimal.Decimal `json:"close"`
+}
+
+// KlineSource is implemented by exchanges that can report a symbol's recent
+// candles
+type KlineSource interface {
+	// GetKlines returns the candles of interval opened in [from, to),
+	// oldest first. Candles may be missing where the exchange has no data.
+	GetKlines(ctx context.Context, symbol string, interval time.Duration, from, to time.Time) ([]Kline, error)
+}
+
+// binanceIntervals are the kline intervals Binance accepts, by duration
+var binanceIntervals = map[time.Duration]string{
+	time.Minute:      "1m",
+	3 * time.Minute:  "3m",
+	5 * time.Minute:  "5m",
+	15 * time.Minute: "15m",
+	30 * time.Minute: "30m",
+	time.Hour:        "1h",
+	24 * time.Hour:   "1d",
+}
+
+// binanceKlineLimit is the most candles Binance returns per request
+const binanceKlineLimit = 1000
+
+// BinanceKlines reads candles from Binance's public market data endpoint,
+// which needs no API key
+type BinanceKlines struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewBinanceKlines creates a kline client for the production API
+func NewBinanceKlines() *BinanceKlines {
+	return &BinanceKlines{
+		BaseURL:    BinanceBaseURL,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// GetKlines returns up to 1000 candles of symbol opened in [from, to)
+func (b *BinanceKlines) GetKlines(ctx context.Context, symbol string, interval time.Duration, from, to time.Time) ([]Kline, error) {
+	name, ok := binanceIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unsupported kline interval %s", interval)
+	}
+	_, end := run.StartSpan(ctx, "exchange.binance /api/v3/klines")
+	defer end()
+
+	params := url.Values{
+		"symbol":    {strings.ReplaceAll(symbol, "-", "")},
+		"interval":  {name},
+		"startTime": {strconv.FormatInt(from.UnixMilli(), 10)},
+		"endTime":   {strconv.FormatInt(to.UnixMilli()-1, 10)},
+		"limit":     {strconv.Itoa(binanceKlineLimit)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.BaseURL+"/api/v3/klines?"+sign.CanonicalQuery(params), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := b.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse("binance", resp.StatusCode, body); err != nil {
+		return nil, err
+	}
+	return parseBinanceKlines(body)
+}
+
+// parseBinanceKlines decodes Binance's kline rows: [openTime, open, high,
+// low, close, volume, closeTime, ...] with prices as strings
+func parseBinanceKlines(body []byte) ([]Kline, error) {
+	var rows [][]json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	klines := make([]Kline, 0, len(rows))
+	for i, row := range rows {
+		if len(row) < 5 {
+			return nil, fmt.Errorf("invalid response: kline %d has %d fields", i, len(row))
+		}
+		var openTime int64
+		if err := json.Unmarshal(row[0], &openTime); err != nil {
+			return nil, fmt.Errorf("invalid response: kline %d open time: %w", i, err)
+		}
+		k := Kline{OpenTime: time.UnixMilli(openTime).UTC()}
+		for j, price := range []*decimal.Decimal{&k.Open, &k.High, &k.Low, &k.Close} {
+			if err := json.Unmarshal(row[j+1], price); err != nil {
+				return nil, fmt.Errorf("invalid response: kline %d price: %w", i, err)
+			}
+		}
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestBinanceKlines_GetKlines(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{"GET /api/v3/klines": "binance_klines.json"}, &requests, &bodies)
+	defer server.Close()
+
+	from := time.UnixMilli(1760601600000)
+	b := &BinanceKlines{BaseURL: server.URL}
+	klines, err := b.GetKlines(context.Background(), "BTC-USDT", time.Minute, from, from.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("GetKlines() error = %v", err)
+	}
+	if len(klines) != 2 {
+		t.Fatalf("got %d klines, want 2", len(klines))
+	}
+	k := klines[1]
+	if !k.OpenTime.Equal(from.Add(time.Minute)) || !k.Open.Equal(decimal.RequireFromString("61012.5")) ||
+		!k.High.Equal(decimal.RequireFromString("61100")) || !k.Low.Equal(decimal.RequireFromString("52460")) || !k.Close.Equal(decimal.RequireFromString("52800")) {
+		t.Errorf("klines[1] = %+v", k)
+	}
+	if q := requests[0].URL.RawQuery; q != "endTime=1760601719999&interval=1m&limit=1000&startTime=1760601600000&symbol=BTCUSDT" {
+		t.Errorf("query = %s", q)
+	}
+
+	if _, err := b.GetKlines(context.Background(), "BTC-USDT", 2*time.Minute, from, from.Add(time.Hour)); err == nil {
+		t.Error("GetKlines() accepted an interval Binance does not offer")
+	}
+}
+
+func TestMockExchange_GetKlines(t *testing.T) {
+	from := time.Date(2026, time.October, 16, 8, 0, 30, 0, time.UTC)
+	m := &MockExchange{}
+	klines, err := m.GetKlines(context.Background(), "BTC-USDT", time.Minute, from, from.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("GetKlines() error = %v", err)
+	}
+	if len(klines) != 3 || !klines[0].OpenTime.Equal(from.Add(30*time.Second)) || !klines[2].Close.Equal(mockPrice) {
+		t.Errorf("GetKlines() = %+v, want three flat candles from 08:01", klines)
+	}
+
+	m.Klines = map[string][]Kline{"BTC-USDT": {{OpenTime: from.Add(-time.Hour)}, {OpenTime: from}}}
+	if klines, _ := m.GetKlines(context.Background(), "BTC-USDT", time.Minute, from, from.Add(time.Minute)); len(klines) != 1 {
+		t.Errorf("GetKlines() = %+v, want only the candle in range", klines)
+	}
+}
@@ -0,0 +1,156 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// KrakenBaseURL is the production Kraken API
+const KrakenBaseURL = "https://api.kraken.com"
+
+// KrakenDeposits reads pending deposits from Kraken's deposit status
+type KrakenDeposits struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string // base64, as issued by Kraken
+	HTTPClient *http.Client
+	Now        func() time.Time // request nonces; defaults to time.Now
+}
+
+// NewKrakenDeposits creates a deposit client for the production API
+func NewKrakenDeposits(apiKey, apiSecret string) *KrakenDeposits {
+	return &KrakenDeposits{
+		BaseURL:    KrakenBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// krakenPending are the deposit statuses of deposits not credited yet
+var krakenPending = []string{"Initial", "Pending", "Settled"}
+
+// krakenUnavailable are the API errors Kraken returns while it cannot
+// serve requests
+var krakenUnavailable = []string{"EService:Unavailable", "EService:Busy"}
+
+// krakenErrors explains the Kraken API errors users most often hit
+var krakenErrors = map[string]Explanation{
+	"EAPI:Invalid key":           {"invalid API key", "check that apiKey was copied whole and the key was not deleted"},
+	"EAPI:Invalid signature":     {"the request signature is invalid", "check that apiSecret is the base64 private key of apiKey"},
+	"EAPI:Invalid nonce":         {"the request nonce is not increasing", "give the bot its own API key; keys shared with other tools reuse nonces"},
+	"EAPI:Rate limit exceeded":   {"too many requests", "spread the schedules out or lower the request rate"},
+	"EGeneral:Permission denied": {"the API key lacks a permission the request needs", "enable the Query Funds and Deposit permissions on the key"},
+	"EOrder:Insufficient funds":  {"insufficient balance for the order", "check that the free quote balance covers quoteAmount plus fees"},
+}
+
+// GetPendingDeposits returns the deposits of code not credited yet. Kraken
+// does not report when they settle, so ExpectedAt is estimated from the
+// deposit method.
+func (k *KrakenDeposits) GetPendingDeposits(ctx context.Context, code string) ([]Deposit, error) {
+	var entries []struct {
+		Method string          `json:"method"`
+		Asset  string          `json:"asset"`
+		Amount decimal.Decimal `json:"amount"`
+		Time   int64           `json:"time"`
+		Status string          `json:"status"`
+	}
+	params := url.Values{"asset": {asset.VenueCode("kraken", code)}}
+	if err := k.post(ctx, "/0/private/DepositStatus", params, &entries); err != nil {
+		return nil, fmt.Errorf("failed to get deposit status: %w", err)
+	}
+
+	var deposits []Deposit
+	for _, e := range entries {
+		if !slices.Contains(krakenPending, e.Status) || asset.Canonical("kraken", e.Asset) != asset.Canonical("", code) {
+			continue
+		}
+		d := Deposit{
+			Asset:     asset.Canonical("kraken", e.Asset),
+			Amount:    e.Amount,
+			Method:    e.Method,
+			CreatedAt: time.Unix(e.Time, 0).UTC(),
+		}
+		d.ExpectedAt, _ = EstimateSettlement(e.Method, d.CreatedAt)
+		deposits = append(deposits, d)
+	}
+	slices.SortStableFunc(deposits, func(a, b Deposit) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return deposits, nil
+}
+
+// post sends a signed private request and decodes its result into out
+func (k *KrakenDeposits) post(ctx context.Context, path string, params url.Values, out any) error {
+	_, end := run.StartSpan(ctx, "exchange.kraken "+path)
+	defer end()
+
+	now := k.Now
+	if now == nil {
+		now = time.Now
+	}
+	nonce := strconv.FormatInt(now().UnixMilli(), 10)
+	params.Set("nonce", nonce)
+	body := params.Encode()
+	signature, err := sign.SignKrakenBase64(k.APISecret, path, nonce, body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.BaseURL+path, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	useragent.Apply(req)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("API-Key", k.APIKey)
+	req.Header.Set("API-Sign", signature)
+
+	client := k.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse("kraken", resp.StatusCode, data); err != nil {
+		return err
+	}
+	var envelope struct {
+		Error  []string        `json:"error"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if len(envelope.Error) > 0 {
+		apiErr := fmt.Errorf("kraken: %s", strings.Join(envelope.Error, ", "))
+		if slices.ContainsFunc(envelope.Error, func(e string) bool { return slices.Contains(krakenUnavailable, e) }) {
+			return &UnavailableError{Exchange: "kraken", Reason: envelope.Error[0], Err: apiErr}
+		}
+		return explain("kraken", envelope.Error[0], apiErr)
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package exchange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// OKXBaseURL is the production OKX API
+const OKXBaseURL = "https://www.okx.com"
+
+// OKX account types of the funds-transfer endpoint
+const (
+	okxFundingAccount = "6"
+	okxTradingAccount = "18"
+)
+
+// okxErrors explains the OKX API error codes users most often hit
+var okxErrors = map[string]Explanation{
+	"50011": {"too many requests", "spread the schedules out or lower the request rate"},
+	"50102": {"the request timestamp expired", "retry; if it persists, check the clock of the machine running the bot"},
+	"50105": {"the passphrase is incorrect", "check that passphrase is the one set when the API key was created"},
+	"50110": {"the IP is not in the API key's whitelist", "add your Lambda's egress IP to the key's IP whitelist"},
+	"50111": {"invalid API key", "check that apiKey is a live (not demo trading) OKX key and was copied whole"},
+	"50113": {"the request signature is invalid", "check that apiSecret belongs to apiKey"},
+	"51001": {"the instrument does not exist", "check that strategy.symbol is listed on OKX spot"},
+	"51008": {"insufficient balance for the order", "check that the trading account's free quote balance covers quoteAmount plus fees"},
+	"58350": {"insufficient balance for the transfer", "check the funding account balance, or lower the top-up"},
+}
+
+// OKXFunding tops up the OKX trading account from the funding account
+type OKXFunding struct {
+	BaseURL    string
+	APIKey     string
+	APISecret  string
+	Passphrase string
+	HTTPClient *http.Client
+	Now        func() time.Time // request timestamps; defaults to time.Now
+}
+
+// NewOKXFunding creates a funding client for the production API
+func NewOKXFunding(apiKey, apiSecret, passphrase string) *OKXFunding {
+	return &OKXFunding{
+		BaseURL:    OKXBaseURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		Passphrase: passphrase,
+		HTTPClient: newHTTPClient(),
+	}
+}
+
+// GetFundingBalance returns the available funding-account balance of code
+func (o *OKXFunding) GetFundingBalance(ctx context.Context, code string) (decimal.Decimal, error) {
+	var balances []struct {
+		Ccy      string          `json:"ccy"`
+		AvailBal decimal.Decimal `json:"availBal"`
+	}
+	if err := o.do(ctx, http.MethodGet, "/api/v5/asset/balances", url.Values{"ccy": {code}}, nil, &balances); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get funding balance: %w", err)
+	}
+	for _, b := range balances {
+		if b.Ccy == code {
+			return b.AvailBal, nil
+		}
+	}
+	return decimal.Zero, nil
+}
+
+// TransferToTrading moves amount of code from the funding to the trading account
+func (o *OKXFunding) TransferToTrading(ctx context.Context, code string, amount decimal.Decimal) (*Transfer, error) {
+	body := map[string]string{
+		"ccy":  code,
+		"amt":  amount.String(),
+		"from": okxFundingAccount,
+		"to":   okxTradingAccount,
+		"type": "0", // within the account
+	}
+	var transfers []struct {
+		TransID string `json:"transId"`
+	}
+	if err := o.do(ctx, http.MethodPost, "/api/v5/asset/transfer", nil, body, &transfers); err != nil {
+		return nil, fmt.Errorf("failed to transfer %s %s: %w", amount.String(), code, err)
+	}
+	if len(transfers) == 0 {
+		return nil, fmt.Errorf("failed to transfer %s %s: no transfer in the response", amount.String(), code)
+	}
+	return &Transfer{Asset: code, Amount: amount, From: "funding", ID: transfers[0].TransID}, nil
+}
+
+// do sends a signed request and decodes the response's data into out. OKX
+// reports errors with a non-zero code in a 200 response.
+func (o *OKXFunding) do(ctx context.Context, method, path string, query url.Values, body any, out any) error {
+	_, end := run.StartSpan(ctx, "exchange.okx "+path)
+	defer end()
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	rawQuery := sign.CanonicalQuery(query)
+	target := o.BaseURL + path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	now := o.Now
+	if now == nil {
+		now = time.Now
+	}
+	timestamp := now().UTC().Format("2006-01-02T15:04:05.000Z")
+	useragent.Apply(req)
+	req.Header.Set("OK-ACCESS-KEY", o.APIKey)
+	req.Header.Set("OK-ACCESS-SIGN", sign.SignRequestBase64(o.APISecret, timestamp, method, path, rawQuery, string(payload)))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", o.Passphrase)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse("okx", resp.StatusCode, respBody); err != nil {
+		return err
+	}
+	var envelope struct {
+		Code string          `json:"code"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if envelope.Code != "0" {
+		return explain("okx", envelope.Code, &HTTPError{StatusCode: resp.StatusCode, Body: string(respBody)})
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
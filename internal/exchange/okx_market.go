@@ -0,0 +1,174 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// okxInvalidInstrument is the OKX error code of an unknown instId
+const okxInvalidInstrument = "51001"
+
+// OKXMarket reads OKX public market data and instrument rules; its
+// requests are not signed
+type OKXMarket struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	symbols symbolCache // instruments read by GetSymbolInfo
+}
+
+// NewOKXMarket creates a market data client for the production API
+func NewOKXMarket() *OKXMarket {
+	return &OKXMarket{BaseURL: OKXBaseURL, HTTPClient: newHTTPClient()}
+}
+
+// okxInstID returns the OKX instrument ID of symbol, e.g. "BTC-USDT" for
+// both "BTC-USDT" and "btcusdt"
+func okxInstID(symbol string) (string, error) {
+	base, quote, err := SplitSymbol(strings.ToUpper(symbol))
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + quote, nil
+}
+
+// GetTicker returns the last price and best bid and ask of symbol from
+// GET /api/v5/market/ticker
+func (o *OKXMarket) GetTicker(ctx context.Context, symbol string) (Ticker, error) {
+	instID, err := okxInstID(symbol)
+	if err != nil {
+		return Ticker{}, err
+	}
+	body, err := o.get(ctx, "/api/v5/market/ticker", url.Values{"instId": {instID}})
+	if err != nil {
+		return Ticker{}, okxSymbolErr(symbol, body, err)
+	}
+	ticker, err := ParseOKXTicker(body)
+	if err != nil {
+		return Ticker{}, okxSymbolErr(symbol, body, err)
+	}
+	ticker.Symbol = symbol
+	return ticker, nil
+}
+
+// GetSymbolInfo returns the trading status and rules of symbol. The
+// instrument of each symbol is read once per client.
+func (o *OKXMarket) GetSymbolInfo(ctx context.Context, symbol string) (SymbolInfo, error) {
+	instID, err := okxInstID(symbol)
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	return o.symbols.get(instID, func() (SymbolInfo, error) {
+		body, err := o.get(ctx, "/api/v5/public/instruments", url.Values{"instType": {"SPOT"}, "instId": {instID}})
+		if err != nil {
+			return SymbolInfo{}, okxSymbolErr(symbol, body, err)
+		}
+		infos, err := ParseOKXInstruments(body)
+		if err != nil {
+			return SymbolInfo{}, okxSymbolErr(symbol, body, err)
+		}
+		return FindSymbol(infos, instID)
+	})
+}
+
+// ListSymbols returns every spot instrument OKX lists
+func (o *OKXMarket) ListSymbols(ctx context.Context) ([]SymbolInfo, error) {
+	body, err := o.get(ctx, "/api/v5/public/instruments", url.Values{"instType": {"SPOT"}})
+	if err != nil {
+		return nil, err
+	}
+	return ParseOKXInstruments(body)
+}
+
+// get sends an unsigned GET and returns the response body, also along
+// with an error response
+func (o *OKXMarket) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	_, end := run.StartSpan(ctx, "exchange.okx "+path)
+	defer end()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	useragent.Apply(req)
+
+	client := o.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return body, CheckResponse("okx", resp.StatusCode, body)
+}
+
+// okxSymbolErr marks err, from a response with body, as ErrSymbolNotFound
+// when OKX does not know the instrument
+func okxSymbolErr(symbol string, body []byte, err error) error {
+	if errorCode(body) == okxInvalidInstrument {
+		return fmt.Errorf("%s: %w: %w", symbol, ErrSymbolNotFound, err)
+	}
+	return err
+}
+
+// ParseOKXTicker reads a GET /api/v5/market/ticker response. OKX writes
+// prices as strings, and an empty string for a side of the book with no
+// orders, which is read as zero.
+func ParseOKXTicker(data []byte) (Ticker, error) {
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			InstID string `json:"instId"`
+			Last   string `json:"last"`
+			BidPx  string `json:"bidPx"`
+			AskPx  string `json:"askPx"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Ticker{}, fmt.Errorf("failed to decode okx ticker: %w", err)
+	}
+	if resp.Code != "0" {
+		return Ticker{}, fmt.Errorf("okx ticker: code %s: %s", resp.Code, resp.Msg)
+	}
+	if len(resp.Data) == 0 {
+		return Ticker{}, fmt.Errorf("okx ticker: no data in the response")
+	}
+	raw := resp.Data[0]
+	ticker := Ticker{Symbol: raw.InstID}
+	for _, field := range []struct {
+		name  string
+		value string
+		out   *decimal.Decimal
+	}{
+		{"last", raw.Last, &ticker.Last},
+		{"bidPx", raw.BidPx, &ticker.Bid},
+		{"askPx", raw.AskPx, &ticker.Ask},
+	} {
+		if field.value == "" {
+			continue
+		}
+		price, err := decimal.NewFromString(field.value)
+		if err != nil {
+			return Ticker{}, fmt.Errorf("okx ticker: invalid %s %q", field.name, field.value)
+		}
+		*field.out = price
+	}
+	return ticker, nil
+}
@@ -0,0 +1,121 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseOKXTicker(t *testing.T) {
+	ticker, err := ParseOKXTicker(readFixture(t, "okx_ticker.json"))
+	if err != nil {
+		t.Fatalf("ParseOKXTicker() error = %v", err)
+	}
+	want := Ticker{
+		Symbol: "BTC-USDT",
+		Last:   decimal.RequireFromString("62500.1"),
+		Bid:    decimal.RequireFromString("62500"),
+		Ask:    decimal.RequireFromString("62500.2"),
+	}
+	if ticker.Symbol != want.Symbol || !ticker.Last.Equal(want.Last) || !ticker.Bid.Equal(want.Bid) || !ticker.Ask.Equal(want.Ask) {
+		t.Errorf("ParseOKXTicker() = %+v, want %+v", ticker, want)
+	}
+}
+
+func TestParseOKXTicker_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantBid string
+		wantErr string
+	}{
+		{"empty book side", `{"code":"0","data":[{"instId":"NEW-USDT","last":"1.5","bidPx":"","askPx":"1.6"}]}`, "0", ""},
+		{"invalid price", `{"code":"0","data":[{"instId":"BTC-USDT","last":"n/a","bidPx":"1","askPx":"1"}]}`, "", `okx ticker: invalid last "n/a"`},
+		{"no data", `{"code":"0","data":[]}`, "", "okx ticker: no data in the response"},
+		{"error code", `{"code":"51001","msg":"Instrument ID does not exist","data":[]}`, "", "okx ticker: code 51001: Instrument ID does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticker, err := ParseOKXTicker([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseOKXTicker() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !ticker.Bid.Equal(decimal.RequireFromString(tt.wantBid)) {
+				t.Errorf("ParseOKXTicker() = %+v, %v, want bid %s", ticker, err, tt.wantBid)
+			}
+		})
+	}
+}
+
+func TestOKXMarket_GetTicker(t *testing.T) {
+	var instID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v5/market/ticker" {
+			t.Errorf("path = %s, want the ticker endpoint", r.URL.Path)
+		}
+		instID = r.URL.Query().Get("instId")
+		w.Write(readFixture(t, "okx_ticker.json"))
+	}))
+	defer server.Close()
+	okx := &OKXMarket{BaseURL: server.URL}
+
+	for _, symbol := range []string{"BTC-USDT", "BTCUSDT", "btc-usdt"} {
+		ticker, err := okx.GetTicker(context.Background(), symbol)
+		if err != nil {
+			t.Fatalf("GetTicker(%s) error = %v", symbol, err)
+		}
+		if instID != "BTC-USDT" {
+			t.Errorf("GetTicker(%s) requested instId %s, want BTC-USDT", symbol, instID)
+		}
+		if ticker.Symbol != symbol || !ticker.Last.Equal(decimal.RequireFromString("62500.1")) {
+			t.Errorf("GetTicker(%s) = %+v", symbol, ticker)
+		}
+	}
+}
+
+func TestOKXMarket_GetTickerUnknownSymbol(t *testing.T) {
+	for _, status := range []int{http.StatusOK, http.StatusBadRequest} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"code":"51001","msg":"Instrument ID does not exist","data":[]}`))
+		}))
+		_, err := (&OKXMarket{BaseURL: server.URL}).GetTicker(context.Background(), "FOO-USDT")
+		server.Close()
+		if !errors.Is(err, ErrSymbolNotFound) {
+			t.Errorf("HTTP %d: GetTicker() error = %v, want ErrSymbolNotFound", status, err)
+		}
+	}
+}
+
+func TestOKXMarket_GetSymbolInfo(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if q := r.URL.Query(); r.URL.Path != "/api/v5/public/instruments" || q.Get("instType") != "SPOT" || q.Get("instId") != "BTC-USDT" {
+			t.Errorf("request = %s, want the BTC-USDT spot instrument", r.URL)
+		}
+		w.Write(readFixture(t, "okx_instruments.json"))
+	}))
+	defer server.Close()
+	okx := &OKXMarket{BaseURL: server.URL}
+
+	for range 2 {
+		info, err := okx.GetSymbolInfo(context.Background(), "BTCUSDT")
+		if err != nil {
+			t.Fatalf("GetSymbolInfo() error = %v", err)
+		}
+		if info.Symbol != "BTC-USDT" || !info.Rules.TickSize.Equal(decimal.RequireFromString("0.1")) {
+			t.Errorf("GetSymbolInfo() = %+v", info)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("instruments read %d times, want once per client", requests)
+	}
+}
@@ -0,0 +1,73 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// ParseOKXOrder reads a GET /api/v5/trade/order response: the order's
+// cumulative fill, average price and fee
+func ParseOKXOrder(data []byte) (*Order, error) {
+	var resp struct {
+		Code string            `json:"code"`
+		Msg  string            `json:"msg"`
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode okx order: %w", err)
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("okx order: code %s: %s", resp.Code, resp.Msg)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("okx order: no data in the response")
+	}
+	var details okxOrderPush
+	if err := json.Unmarshal(resp.Data[0], &details); err != nil {
+		return nil, fmt.Errorf("failed to decode okx order: %w", err)
+	}
+	order := details.order(resp.Data[0])
+	return &order, nil
+}
+
+// ParseOKXFills reads a GET /api/v5/trade/fills response, the trades of
+// an order queried by ordId, oldest first. OKX lists them newest first and
+// writes a charged fee as negative.
+func ParseOKXFills(data []byte) ([]Fill, error) {
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			TradeID string `json:"tradeId"`
+			FillPx  string `json:"fillPx"`
+			FillSz  string `json:"fillSz"`
+			Fee     string `json:"fee"`
+			FeeCcy  string `json:"feeCcy"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode okx fills: %w", err)
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("okx fills: code %s: %s", resp.Code, resp.Msg)
+	}
+	fills := make([]Fill, len(resp.Data))
+	for i, f := range resp.Data {
+		price, err := decimal.NewFromString(f.FillPx)
+		if err != nil {
+			return nil, fmt.Errorf("okx fill %s: invalid fillPx %q", f.TradeID, f.FillPx)
+		}
+		quantity, err := decimal.NewFromString(f.FillSz)
+		if err != nil {
+			return nil, fmt.Errorf("okx fill %s: invalid fillSz %q", f.TradeID, f.FillSz)
+		}
+		fee, err := decimal.NewFromString(f.Fee)
+		if err != nil {
+			return nil, fmt.Errorf("okx fill %s: invalid fee %q", f.TradeID, f.Fee)
+		}
+		fills[len(fills)-1-i] = Fill{TradeID: f.TradeID, Price: price, Quantity: quantity, FeeAmount: fee.Abs(), FeeAsset: f.FeeCcy}
+	}
+	return fills, nil
+}
@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseOKXOrder(t *testing.T) {
+	order, err := ParseOKXOrder(readFixture(t, "okx_order.json"))
+	if err != nil {
+		t.Fatalf("ParseOKXOrder() error = %v", err)
+	}
+	if order.ID != "1839524416473268224" || order.Status != OrderStatusFilled || !order.Quantity.Equal(decimal.RequireFromString("0.0008")) ||
+		!order.Price.Equal(decimal.RequireFromString("62499.875")) {
+		t.Errorf("order = %s %s %s at %s", order.ID, order.Status, order.Quantity, order.Price)
+	}
+	if !order.FeeAmount.Equal(decimal.RequireFromString("0.0000008")) || order.FeeAsset != "BTC" {
+		t.Errorf("fee = %s %s, want 0.0000008 BTC", order.FeeAmount, order.FeeAsset)
+	}
+	if net := order.NetQuantity(); !net.Equal(decimal.RequireFromString("0.0007992")) {
+		t.Errorf("NetQuantity() = %s, want 0.0007992", net)
+	}
+
+	if _, err := ParseOKXOrder([]byte(`{"code":"51603","msg":"Order does not exist","data":[]}`)); err == nil {
+		t.Error("ParseOKXOrder() accepted an error response")
+	}
+}
+
+func TestParseOKXFills(t *testing.T) {
+	fills, err := ParseOKXFills(readFixture(t, "okx_fills.json"))
+	if err != nil {
+		t.Fatalf("ParseOKXFills() error = %v", err)
+	}
+	if len(fills) != 2 || fills[0].TradeID != "98212" || !fills[0].Price.Equal(decimal.RequireFromString("62499")) {
+		t.Fatalf("fills = %+v, want both oldest first", fills)
+	}
+	fee, feeAsset := SumFees(fills)
+	if !fee.Equal(decimal.RequireFromString("0.0000008")) || feeAsset != "BTC" {
+		t.Errorf("SumFees() = %s %s, want 0.0000008 BTC", fee, feeAsset)
+	}
+
+	if _, err := ParseOKXFills([]byte(`{"code":"0","data":[{"tradeId":"1","fillPx":"","fillSz":"1","fee":"0"}]}`)); err == nil {
+		t.Error("ParseOKXFills() accepted a fill without a price")
+	}
+}
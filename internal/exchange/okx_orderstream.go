@@ -0,0 +1,205 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// OKXPrivateStreamURL is the production OKX private websocket
+const OKXPrivateStreamURL = "wss://ws.okx.com:8443/ws/v5/private"
+
+// OKXOrderStream follows orders on OKX's private websocket: after logging
+// in with the API key, it subscribes to the spot orders channel
+type OKXOrderStream struct {
+	StreamURL  string
+	APIKey     string
+	APISecret  string
+	Passphrase string
+	Now        func() time.Time // login timestamps; defaults to time.Now
+}
+
+// NewOKXOrderStream creates an order streamer for the production API
+func NewOKXOrderStream(apiKey, apiSecret, passphrase string) *OKXOrderStream {
+	return &OKXOrderStream{
+		StreamURL:  OKXPrivateStreamURL,
+		APIKey:     apiKey,
+		APISecret:  apiSecret,
+		Passphrase: passphrase,
+	}
+}
+
+// okxOrderStates maps orders channel states to Order statuses
+var okxOrderStates = map[string]string{
+	"live":             OrderStatusOpen,
+	"partially_filled": OrderStatusPartial,
+	"filled":           OrderStatusFilled,
+	"canceled":         OrderStatusCanceled,
+	"mmp_canceled":     OrderStatusCanceled,
+}
+
+// okxEvent is a message of the private websocket: an event such as the
+// login or subscribe response, or pushed data
+type okxEvent struct {
+	Event string            `json:"event"`
+	Code  string            `json:"code"`
+	Msg   string            `json:"msg"`
+	Data  []json.RawMessage `json:"data"`
+}
+
+// OpenOrderStream logs in and subscribes to the spot orders channel,
+// waiting for both to be confirmed
+func (o *OKXOrderStream) OpenOrderStream(ctx context.Context) (OrderStream, error) {
+	ctx, end := run.StartSpan(ctx, "exchange.okx orders stream")
+	defer end()
+
+	conn, err := dialWebsocket(ctx, o.StreamURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the private websocket: %w", err)
+	}
+	stream := &okxStream{conn: conn}
+
+	now := o.Now
+	if now == nil {
+		now = time.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	login := map[string]any{"op": "login", "args": []map[string]string{{
+		"apiKey":     o.APIKey,
+		"passphrase": o.Passphrase,
+		"timestamp":  timestamp,
+		"sign":       sign.SignRequestBase64(o.APISecret, timestamp, "GET", "/users/self/verify", "", ""),
+	}}}
+	subscribe := map[string]any{"op": "subscribe", "args": []map[string]string{{"channel": "orders", "instType": "SPOT"}}}
+	for _, step := range []struct {
+		request map[string]any
+		event   string
+	}{{login, "login"}, {subscribe, "subscribe"}} {
+		if err := stream.call(ctx, step.request, step.event); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return stream, nil
+}
+
+// okxStream is a logged in, subscribed private websocket
+type okxStream struct {
+	conn    *wsConn
+	pending []json.RawMessage // pushed orders not returned yet
+}
+
+// call sends request and waits for the event confirming it
+func (s *okxStream) call(ctx context.Context, request map[string]any, event string) error {
+	if err := s.conn.WriteJSON(ctx, request); err != nil {
+		return fmt.Errorf("failed to send %s: %w", event, err)
+	}
+	for {
+		msg, err := s.read(ctx)
+		if err != nil {
+			return fmt.Errorf("no %s response: %w", event, err)
+		}
+		switch msg.Event {
+		case event:
+			return nil
+		case "error":
+			return fmt.Errorf("okx %s failed: %s (code %s)", event, msg.Msg, msg.Code)
+		}
+	}
+}
+
+// read returns the next JSON message, skipping OKX's plain-text pongs
+func (s *okxStream) read(ctx context.Context) (*okxEvent, error) {
+	for {
+		data, err := s.conn.Read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if string(data) == "pong" {
+			continue
+		}
+		var msg okxEvent
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, fmt.Errorf("invalid websocket message: %w", err)
+		}
+		return &msg, nil
+	}
+}
+
+// okxOrderPush is an order of the orders channel
+type okxOrderPush struct {
+	InstID    string `json:"instId"`
+	OrdID     string `json:"ordId"`
+	ClOrdID   string `json:"clOrdId"`
+	Side      string `json:"side"`
+	OrdType   string `json:"ordType"`
+	State     string `json:"state"`
+	AccFillSz string `json:"accFillSz"`
+	AvgPx     string `json:"avgPx"` // "" before the first fill
+	Fee       string `json:"fee"`   // cumulative, negative when charged
+	FeeCcy    string `json:"feeCcy"`
+}
+
+// Next returns the next pushed order; a push may carry several
+func (s *okxStream) Next(ctx context.Context) (*OrderUpdate, error) {
+	for len(s.pending) == 0 {
+		msg, err := s.read(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Event == "error" {
+			return nil, fmt.Errorf("okx orders stream: %s (code %s)", msg.Msg, msg.Code)
+		}
+		s.pending = msg.Data
+	}
+	raw := s.pending[0]
+	s.pending = s.pending[1:]
+
+	var push okxOrderPush
+	if err := json.Unmarshal(raw, &push); err != nil {
+		return nil, fmt.Errorf("invalid order push: %w", err)
+	}
+	// Fees are cumulative, so complete whenever the stream opened
+	return &OrderUpdate{Order: push.order(raw)}, nil
+}
+
+// order converts the push, or an order of GET /api/v5/trade/order, which
+// has the same fields
+func (push okxOrderPush) order(raw json.RawMessage) Order {
+	status, ok := okxOrderStates[push.State]
+	if !ok {
+		status = push.State
+	}
+	order := Order{
+		ID:            push.OrdID,
+		ClientOrderID: push.ClOrdID,
+		Symbol:        push.InstID,
+		Side:          push.Side,
+		Type:          push.OrdType,
+		Status:        status,
+		FeeAsset:      push.FeeCcy,
+		Raw:           raw,
+	}
+	// Before the first fill the decimals may be empty strings
+	if quantity, err := decimal.NewFromString(push.AccFillSz); err == nil {
+		order.Quantity = quantity
+	}
+	if price, err := decimal.NewFromString(push.AvgPx); err == nil {
+		order.Price = price
+	}
+	if fee, err := decimal.NewFromString(push.Fee); err == nil {
+		order.FeeAmount = fee.Abs()
+	}
+	return order
+}
+
+// Close closes the stream's websocket
+func (s *okxStream) Close() error {
+	return s.conn.Close()
+}
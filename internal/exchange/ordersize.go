@@ -0,0 +1,147 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// OrderSize is the size of a market order, either an amount of the quote
+// asset to spend or a quantity of the base asset to buy. Exactly one of
+// Quote and Base is positive.
+type OrderSize struct {
+	Quote decimal.Decimal `json:"quote,omitzero"`
+	Base  decimal.Decimal `json:"base,omitzero"`
+}
+
+// QuoteSize sizes an order by the quote amount to spend
+func QuoteSize(amount decimal.Decimal) OrderSize {
+	return OrderSize{Quote: amount}
+}
+
+// BaseSize sizes an order by the base quantity to buy
+func BaseSize(quantity decimal.Decimal) OrderSize {
+	return OrderSize{Base: quantity}
+}
+
+// IsQuote reports whether the size is a quote amount
+func (s OrderSize) IsQuote() bool {
+	return !s.Quote.IsZero()
+}
+
+// String is the size with its kind, e.g. "25 quote" or "0.0005 base"
+func (s OrderSize) String() string {
+	if s.IsQuote() {
+		return s.Quote.String() + " quote"
+	}
+	return s.Base.String() + " base"
+}
+
+func (s OrderSize) validate() error {
+	if s.Quote.IsPositive() == s.Base.IsPositive() || s.Quote.IsNegative() || s.Base.IsNegative() {
+		return fmt.Errorf("invalid order size: exactly one of quote and base must be positive")
+	}
+	return nil
+}
+
+// ConversionSlippage is the price move allowed between the ticker a quote
+// amount is converted at and the fill, for exchanges that only accept base
+// quantities. The conversion leaves this much headroom, so the order stays
+// within the quote amount unless the price moves further.
+var ConversionSlippage = decimal.RequireFromString("0.005")
+
+// MarketBuy places a market buy of size on exc. A quote amount on an
+// exchange that only accepts base quantities is converted at the last
+// price, less ConversionSlippage and rounded down to the lot step; a fill
+// more than ConversionSlippage above that price is recorded as a warning.
+func MarketBuy(ctx context.Context, exc Exchange, symbol string, size OrderSize) (*Order, error) {
+	if err := size.validate(); err != nil {
+		return nil, err
+	}
+	if !size.IsQuote() || exc.Capabilities().Has(QuoteSizedMarketBuy) {
+		return exc.PlaceMarketBuyOrder(ctx, symbol, size)
+	}
+
+	base, price, err := ConvertQuoteSize(ctx, exc, symbol, size.Quote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size %s in %s: %w", size, symbol, err)
+	}
+	order, err := exc.PlaceMarketBuyOrder(ctx, symbol, base)
+	if err != nil {
+		return nil, err
+	}
+	limit := price.Mul(decimal.NewFromInt(1).Add(ConversionSlippage))
+	if order.Price.GreaterThan(limit) {
+		run.Warn(ctx, "sizing", "conversion", fmt.Errorf("%s filled at %s, more than %s above the %s it was sized at",
+			symbol, order.Price, ConversionSlippage.Shift(2).String()+"%", price))
+	}
+	return order, nil
+}
+
+// ConvertQuoteSize converts a quote amount to a base quantity at the last
+// price of symbol, leaving ConversionSlippage of headroom and rounding down
+// to the lot step. It returns the quantity and the price used.
+func ConvertQuoteSize(ctx context.Context, exc Exchange, symbol string, quote decimal.Decimal) (OrderSize, decimal.Decimal, error) {
+	ticker, ok := exc.(PriceTicker)
+	if !ok {
+		return OrderSize{}, decimal.Zero, fmt.Errorf("exchange cannot convert quote amounts: no price ticker")
+	}
+	price, err := ticker.LastPrice(ctx, symbol)
+	if err != nil {
+		return OrderSize{}, decimal.Zero, fmt.Errorf("failed to get price: %w", err)
+	}
+	if !price.IsPositive() {
+		return OrderSize{}, decimal.Zero, fmt.Errorf("invalid price %s", price)
+	}
+	step := DefaultLotStep
+	if sizer, ok := exc.(LotSizer); ok {
+		if step, err = sizer.LotStep(ctx, symbol); err != nil {
+			return OrderSize{}, decimal.Zero, fmt.Errorf("failed to get lot step: %w", err)
+		}
+	}
+
+	quantity := quote.Div(price.Mul(decimal.NewFromInt(1).Add(ConversionSlippage)))
+	quantity = quantity.Div(step).Floor().Mul(step)
+	if !quantity.IsPositive() {
+		return OrderSize{}, decimal.Zero, fmt.Errorf("%s at %s is less than one lot of %s", quote, price, step)
+	}
+	return BaseSize(quantity), price, nil
+}
+
+// BinanceMarketBuyParams are the POST /api/v3/order parameters of a market
+// buy: quoteOrderQty for a quote amount, quantity for a base quantity
+func BinanceMarketBuyParams(symbol string, size OrderSize) url.Values {
+	params := url.Values{
+		"symbol": {strings.ReplaceAll(symbol, "-", "")},
+		"side":   {"BUY"},
+		"type":   {"MARKET"},
+	}
+	if size.IsQuote() {
+		params.Set("quoteOrderQty", size.Quote.String())
+	} else {
+		params.Set("quantity", size.Base.String())
+	}
+	return params
+}
+
+// OKXMarketBuyParams is the POST /api/v5/trade/order body of a spot market
+// buy. OKX sizes market buys in the quote asset by default; tgtCcy makes
+// the unit explicit either way.
+func OKXMarketBuyParams(symbol string, size OrderSize) map[string]string {
+	params := map[string]string{
+		"instId":  symbol,
+		"tdMode":  "cash",
+		"side":    "buy",
+		"ordType": "market",
+	}
+	if size.IsQuote() {
+		params["sz"], params["tgtCcy"] = size.Quote.String(), "quote_ccy"
+	} else {
+		params["sz"], params["tgtCcy"] = size.Base.String(), "base_ccy"
+	}
+	return params
+}
@@ -0,0 +1,143 @@
+package exchange
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestMarketBuyParams(t *testing.T) {
+	quote, base := QuoteSize(decimal.RequireFromString("25")), BaseSize(decimal.RequireFromString("0.0005"))
+
+	binance := []struct {
+		size OrderSize
+		want url.Values
+	}{
+		{quote, url.Values{"symbol": {"BTCUSDT"}, "side": {"BUY"}, "type": {"MARKET"}, "quoteOrderQty": {"25"}}},
+		{base, url.Values{"symbol": {"BTCUSDT"}, "side": {"BUY"}, "type": {"MARKET"}, "quantity": {"0.0005"}}},
+	}
+	for _, tt := range binance {
+		if got := BinanceMarketBuyParams("BTC-USDT", tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BinanceMarketBuyParams(%s) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+
+	okx := []struct {
+		size OrderSize
+		want map[string]string
+	}{
+		{quote, map[string]string{"instId": "BTC-USDT", "tdMode": "cash", "side": "buy", "ordType": "market", "sz": "25", "tgtCcy": "quote_ccy"}},
+		{base, map[string]string{"instId": "BTC-USDT", "tdMode": "cash", "side": "buy", "ordType": "market", "sz": "0.0005", "tgtCcy": "base_ccy"}},
+	}
+	for _, tt := range okx {
+		if got := OKXMarketBuyParams("BTC-USDT", tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("OKXMarketBuyParams(%s) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestMockExchange_OrderSize(t *testing.T) {
+	ctx := context.Background()
+	mock := &MockExchange{Prices: map[string]decimal.Decimal{"BTC-USDT": decimal.NewFromInt(50000)}}
+
+	order, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(25)))
+	if err != nil || !order.Quantity.Equal(decimal.RequireFromString("0.0005")) {
+		t.Errorf("quote-sized buy = %v, %v; want 0.0005 BTC", order, err)
+	}
+	order, err = mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", BaseSize(decimal.RequireFromString("0.001")))
+	if err != nil || !order.Quantity.Equal(decimal.RequireFromString("0.001")) {
+		t.Errorf("base-sized buy = %v, %v; want 0.001 BTC", order, err)
+	}
+
+	mock.BaseOnly = true
+	if _, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(25))); err == nil {
+		t.Error("base-only mock accepted a quote-sized buy")
+	}
+	for _, invalid := range []OrderSize{{}, {Quote: decimal.NewFromInt(1), Base: decimal.NewFromInt(1)}, QuoteSize(decimal.NewFromInt(-1))} {
+		if _, err := MarketBuy(ctx, mock, "BTC-USDT", invalid); err == nil || !strings.Contains(err.Error(), "invalid order size") {
+			t.Errorf("MarketBuy(%+v) error = %v, want an invalid order size", invalid, err)
+		}
+	}
+}
+
+// slippingExchange fills market buys above the price it reports
+type slippingExchange struct {
+	*MockExchange
+	fillPrice decimal.Decimal
+}
+
+func (s *slippingExchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, size OrderSize) (*Order, error) {
+	order, err := s.MockExchange.PlaceMarketBuyOrder(ctx, symbol, size)
+	if err == nil {
+		order.Price = s.fillPrice
+	}
+	return order, err
+}
+
+// TestMarketBuy_Conformance checks that every way of sizing a quote amount
+// spends no more than it and no less than the conversion headroom and one
+// lot allow
+func TestMarketBuy_Conformance(t *testing.T) {
+	quote := decimal.NewFromInt(25)
+	rounding := decimal.New(1, -8) // the mock fills at quote / price, rounded by decimal division
+	prices := map[string]decimal.Decimal{"BTC-USDT": decimal.NewFromInt(50000), "ETH-USDT": decimal.RequireFromString("3187.43")}
+
+	for _, baseOnly := range []bool{false, true} {
+		for symbol, price := range prices {
+			mock := &MockExchange{Prices: prices, BaseOnly: baseOnly}
+			order, err := MarketBuy(context.Background(), mock, symbol, QuoteSize(quote))
+			if err != nil {
+				t.Fatalf("MarketBuy(%s, baseOnly %v) error = %v", symbol, baseOnly, err)
+			}
+			spent := order.Quantity.Mul(order.Price)
+			floor := quote.Mul(decimal.NewFromInt(1).Sub(ConversionSlippage)).Sub(mockLotStep.Mul(price))
+			if spent.GreaterThan(quote.Add(rounding)) || spent.LessThan(floor) {
+				t.Errorf("MarketBuy(%s, baseOnly %v) spent %s, want between %s and %s", symbol, baseOnly, spent, floor, quote)
+			}
+			if !baseOnly && spent.Sub(quote).Abs().GreaterThan(rounding) {
+				t.Errorf("MarketBuy(%s) on a quote-sizing exchange spent %s, want exactly %s", symbol, spent, quote)
+			}
+		}
+	}
+}
+
+func TestMarketBuy_ConversionSlippage(t *testing.T) {
+	prices := map[string]decimal.Decimal{"BTC-USDT": decimal.NewFromInt(50000)}
+	tests := []struct {
+		name      string
+		fillPrice string
+		wantWarn  bool
+	}{
+		{"within_headroom", "50200", false},
+		{"beyond_headroom", "50300", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := &run.Warnings{}
+			ctx := run.WithWarnings(context.Background(), warnings)
+			exc := &slippingExchange{MockExchange: &MockExchange{Prices: prices, BaseOnly: true}, fillPrice: decimal.RequireFromString(tt.fillPrice)}
+
+			order, err := MarketBuy(ctx, exc, "BTC-USDT", QuoteSize(decimal.NewFromInt(25)))
+			if err != nil {
+				t.Fatalf("MarketBuy() error = %v", err)
+			}
+			// 25 / (50000 * 1.005) = 0.000497..., rounded down to the 0.00001 lot
+			if !order.Quantity.Equal(decimal.RequireFromString("0.00049")) {
+				t.Errorf("Quantity = %s, want 0.00049", order.Quantity)
+			}
+			if got := len(warnings.List()) == 1; got != tt.wantWarn {
+				t.Errorf("warnings = %+v, want a conversion warning: %v", warnings.List(), tt.wantWarn)
+			}
+		})
+	}
+
+	tiny := &MockExchange{Prices: prices, BaseOnly: true}
+	if _, err := MarketBuy(context.Background(), tiny, "BTC-USDT", QuoteSize(decimal.RequireFromString("0.1"))); err == nil || !strings.Contains(err.Error(), "less than one lot") {
+		t.Errorf("MarketBuy() of less than a lot error = %v", err)
+	}
+}
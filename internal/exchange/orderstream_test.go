@@ -0,0 +1,174 @@
+package exchange
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+)
+
+// nextUpdates reads n updates from stream
+func nextUpdates(t *testing.T, stream OrderStream, n int) []*OrderUpdate {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var updates []*OrderUpdate
+	for range n {
+		update, err := stream.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next() error = %v after %d updates", err, len(updates))
+		}
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+func TestBinanceOrderStream(t *testing.T) {
+	var apiKey string
+	rest := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v3/userDataStream" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		apiKey = r.Header.Get("X-MBX-APIKEY")
+		w.Write([]byte(`{"listenKey":"pqia91ma19a5s61cv6a81va65sdf19v8a65a1a5s61cv6a81va65sdf19v8a65a1"}`))
+	}
+	server := wsTestServer(t, rest, func(p *wsPeer, r *http.Request) {
+		if r.URL.Path != "/ws/pqia91ma19a5s61cv6a81va65sdf19v8a65a1a5s61cv6a81va65sdf19v8a65a1" {
+			t.Errorf("stream path = %s, want the listen key's", r.URL.Path)
+		}
+		p.replay("binance_user_data.jsonl")
+		p.receive() // the client's close
+	})
+	defer server.Close()
+
+	streamer := &BinanceOrderStream{BaseURL: server.URL, StreamURL: wsURL(server), APIKey: "key"}
+	stream, err := streamer.OpenOrderStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if apiKey != "key" {
+		t.Errorf("listen key requested with API key %q", apiKey)
+	}
+
+	// The account update is skipped; another bot's order is reported too
+	updates := nextUpdates(t, stream, 4)
+	want := []struct {
+		id, status, quantity, price, fee string
+	}{
+		{"28457112", OrderStatusOpen, "0", "0", "0"},
+		{"9911", OrderStatusOpen, "0", "0", "0"},
+		{"28457112", OrderStatusPartial, "0.0003", "62500", "0.0000003"},
+		{"28457112", OrderStatusFilled, "0.0008", "62500", "0.0000008"},
+	}
+	for i, w := range want {
+		got := updates[i].Order
+		if got.ID != w.id || got.Status != w.status || !got.Quantity.Equal(decimal.RequireFromString(w.quantity)) ||
+			!got.Price.Equal(decimal.RequireFromString(w.price)) || !got.FeeAmount.Equal(decimal.RequireFromString(w.fee)) {
+			t.Errorf("update %d = %s %s %s at %s fee %s, want %+v", i, got.ID, got.Status, got.Quantity, got.Price, got.FeeAmount, w)
+		}
+		if updates[i].FeesIncomplete {
+			t.Errorf("update %d has incomplete fees, but every trade was seen", i)
+		}
+	}
+	if filled := updates[3].Order; filled.ClientOrderID != "dca-7f3a9c2e" || filled.FeeAsset != "BTC" || filled.Type != "market" || filled.Side != "buy" {
+		t.Errorf("filled order = %+v", filled)
+	}
+}
+
+// An order that traded before the stream opened reports only later fees
+func TestBinanceOrderStream_FeesIncomplete(t *testing.T) {
+	stream := &binanceStream{seen: map[string]binanceTrades{}}
+	update := stream.update(binanceExecutionReport{
+		Event: "executionReport", OrderID: 1, Status: "FILLED",
+		LastQuantity:   decimal.RequireFromString("0.0005"),
+		FilledQuantity: decimal.RequireFromString("0.0008"),
+		QuoteFilled:    decimal.RequireFromString("50"),
+		Commission:     decimal.RequireFromString("0.0000005"),
+	}, nil)
+	if !update.FeesIncomplete || update.Order.Status != OrderStatusFilled {
+		t.Errorf("update = %+v, want a fill with incomplete fees", update)
+	}
+}
+
+func TestOKXOrderStream(t *testing.T) {
+	now := time.Unix(1760600000, 0)
+	server := wsTestServer(t, nil, func(p *wsPeer, r *http.Request) {
+		var login struct {
+			Op   string              `json:"op"`
+			Args []map[string]string `json:"args"`
+		}
+		p.receiveJSON(&login)
+		if login.Op != "login" || len(login.Args) != 1 {
+			t.Errorf("first message = %+v, want a login", login)
+			return
+		}
+		args := login.Args[0]
+		wantSign := sign.SignRequestBase64("secret", "1760600000", "GET", "/users/self/verify", "", "")
+		if args["apiKey"] != "key" || args["passphrase"] != "pass" || args["timestamp"] != "1760600000" || args["sign"] != wantSign {
+			t.Errorf("login args = %v", args)
+		}
+		p.text(`{"event":"login","code":"0","msg":"","connId":"a4d3ae55"}`)
+
+		var subscribe struct {
+			Op   string              `json:"op"`
+			Args []map[string]string `json:"args"`
+		}
+		p.receiveJSON(&subscribe)
+		if subscribe.Op != "subscribe" || len(subscribe.Args) != 1 || subscribe.Args[0]["channel"] != "orders" || subscribe.Args[0]["instType"] != "SPOT" {
+			t.Errorf("second message = %+v, want the orders subscription", subscribe)
+		}
+		p.text(`{"event":"subscribe","arg":{"channel":"orders","instType":"SPOT"},"connId":"a4d3ae55"}`)
+		p.text("pong")
+		p.replay("okx_orders.jsonl")
+		p.receive()
+	})
+	defer server.Close()
+
+	streamer := &OKXOrderStream{StreamURL: wsURL(server), APIKey: "key", APISecret: "secret", Passphrase: "pass", Now: func() time.Time { return now }}
+	stream, err := streamer.OpenOrderStream(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	updates := nextUpdates(t, stream, 4)
+	want := []struct {
+		id, status, quantity, price, fee string
+	}{
+		{"1839524416473268224", OrderStatusOpen, "0", "0", "0"},
+		{"1839524416473260000", OrderStatusOpen, "0", "0", "0"},
+		{"1839524416473268224", OrderStatusPartial, "0.0003", "62500", "0.0000003"},
+		{"1839524416473268224", OrderStatusFilled, "0.0008", "62500", "0.0000008"},
+	}
+	for i, w := range want {
+		got := updates[i].Order
+		if got.ID != w.id || got.Status != w.status || !got.Quantity.Equal(decimal.RequireFromString(w.quantity)) ||
+			!got.Price.Equal(decimal.RequireFromString(w.price)) || !got.FeeAmount.Equal(decimal.RequireFromString(w.fee)) {
+			t.Errorf("update %d = %s %s %s at %s fee %s, want %+v", i, got.ID, got.Status, got.Quantity, got.Price, got.FeeAmount, w)
+		}
+	}
+	if filled := updates[3].Order; filled.Symbol != "BTC-USDT" || filled.FeeAsset != "BTC" || filled.ClientOrderID != "dca7f3a9c2e" {
+		t.Errorf("filled order = %+v", filled)
+	}
+}
+
+func TestOKXOrderStream_LoginFails(t *testing.T) {
+	server := wsTestServer(t, nil, func(p *wsPeer, r *http.Request) {
+		var login map[string]any
+		p.receiveJSON(&login)
+		p.text(`{"event":"error","code":"60009","msg":"Login failed.","connId":"a4d3ae55"}`)
+		p.receive()
+	})
+	defer server.Close()
+
+	streamer := &OKXOrderStream{StreamURL: wsURL(server), APIKey: "key", APISecret: "wrong", Passphrase: "pass"}
+	_, err := streamer.OpenOrderStream(context.Background())
+	if err == nil || err.Error() != "okx login failed: Login failed. (code 60009)" {
+		t.Errorf("OpenOrderStream() error = %v, want the login failure", err)
+	}
+}
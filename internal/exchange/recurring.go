@@ -0,0 +1,48 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// RecurringPlan is an exchange-native recurring buy, such as a Binance
+// Auto-Invest plan, that spends Amount of SourceAsset on TargetAsset at
+// each execution
+type RecurringPlan struct {
+	ID          string          `json:"id,omitempty"` // empty for a plan not created yet
+	SourceAsset string          `json:"sourceAsset"`  // e.g. "USDT"
+	TargetAsset string          `json:"targetAsset"`  // e.g. "BTC"
+	Amount      decimal.Decimal `json:"amount"`
+	Cadence     config.Cadence  `json:"cadence"`
+
+	Status        string    `json:"status,omitempty"` // as reported by the exchange, e.g. "ONGOING"
+	NextExecution time.Time `json:"nextExecution,omitzero"`
+}
+
+// Buys reports whether the plan spends source on target
+func (p RecurringPlan) Buys(source, target string) bool {
+	return p.SourceAsset == source && p.TargetAsset == target
+}
+
+// Same reports whether p and o buy the same amount at the same cadence
+func (p RecurringPlan) Same(o RecurringPlan) bool {
+	return p.Buys(o.SourceAsset, o.TargetAsset) && p.Amount.Equal(o.Amount) && p.Cadence == o.Cadence
+}
+
+// RecurringBuyer is implemented by exchanges with native recurring-buy
+// plans
+type RecurringBuyer interface {
+	// RecurringPlans lists the account's plans, including paused ones
+	RecurringPlans(ctx context.Context) ([]RecurringPlan, error)
+
+	// CreateRecurringPlan creates plan and returns it with its ID and next
+	// execution
+	CreateRecurringPlan(ctx context.Context, plan RecurringPlan) (*RecurringPlan, error)
+
+	// UpdateRecurringPlan changes the amount and cadence of the plan with
+	// plan.ID
+	UpdateRecurringPlan(ctx context.Context, plan RecurringPlan) (*RecurringPlan, error)
+}
@@ -0,0 +1,99 @@
+// Package sign implements the request-signing schemes used by HMAC-based
+// exchange APIs. Exchange clients must build their signatures through this
+// package rather than calling crypto/hmac directly, so each canonicalization
+// rule lives in exactly one place and is covered by the vectors in testdata.
+package sign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// HMACSHA256 returns the raw HMAC-SHA256 digest of message keyed by secret
+func HMACSHA256(secret, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// CanonicalQuery encodes params as a query string with keys sorted, which is
+// the form the exchanges expect to be both signed and sent verbatim
+func CanonicalQuery(params url.Values) string {
+	return params.Encode()
+}
+
+// SignQueryHMACHex signs a query string (or form body) as used by Binance and
+// MEXC: hex(HMAC-SHA256(secret, totalParams)). The caller must send exactly
+// the string that was signed and append the result as the signature parameter.
+func SignQueryHMACHex(secret, query string) string {
+	return hex.EncodeToString(HMACSHA256(secret, query))
+}
+
+// BybitPrehash builds Bybit v5's canonical string:
+// timestamp + apiKey + recvWindow + (queryString for GET | JSON body for POST)
+func BybitPrehash(timestamp, apiKey, recvWindow, payload string) string {
+	return timestamp + apiKey + recvWindow + payload
+}
+
+// SignBybitHex signs a Bybit v5 request and returns the lowercase hex digest
+// for the X-BAPI-SIGN header
+func SignBybitHex(secret, timestamp, apiKey, recvWindow, payload string) string {
+	return hex.EncodeToString(HMACSHA256(secret, BybitPrehash(timestamp, apiKey, recvWindow, payload)))
+}
+
+// Prehash builds the canonical string shared by OKX, Bitget and KuCoin:
+// timestamp + UPPERCASE method + request path (with "?query" when present) + body.
+// The timestamp format differs per exchange (ISO-8601 for OKX, Unix
+// milliseconds for Bitget and KuCoin) and is passed through untouched.
+func Prehash(timestamp, method, path, query, body string) string {
+	requestPath := path
+	if query != "" {
+		requestPath += "?" + query
+	}
+	return timestamp + strings.ToUpper(method) + requestPath + body
+}
+
+// SignPrehashBase64 signs an already built prehash string and returns
+// base64(HMAC-SHA256(secret, prehash))
+func SignPrehashBase64(secret, prehash string) string {
+	return base64.StdEncoding.EncodeToString(HMACSHA256(secret, prehash))
+}
+
+// SignRequestBase64 is a convenience over Prehash and SignPrehashBase64
+func SignRequestBase64(secret, timestamp, method, path, query, body string) string {
+	return SignPrehashBase64(secret, Prehash(timestamp, method, path, query, body))
+}
+
+// SignPrehashHex signs an already built prehash string and returns the
+// lowercase hex digest, as Coinbase's v2 API expects in CB-ACCESS-SIGN and
+// notification webhooks send in X-Signature
+func SignPrehashHex(secret, prehash string) string {
+	return hex.EncodeToString(HMACSHA256(secret, prehash))
+}
+
+// SignKrakenBase64 signs a Kraken private request for the API-Sign header:
+// base64(HMAC-SHA512(base64decode(secret), path + SHA256(nonce + body))).
+// body is the form-encoded request, nonce included.
+func SignKrakenBase64(secret, path, nonce, body string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid kraken secret: %w", err)
+	}
+	digest := sha256.Sum256([]byte(nonce + body))
+	mac := hmac.New(sha512.New, key)
+	mac.Write([]byte(path))
+	mac.Write(digest[:])
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SignPassphraseBase64 encrypts an API passphrase as required by KuCoin's
+// key version 2: base64(HMAC-SHA256(secret, passphrase))
+func SignPassphraseBase64(secret, passphrase string) string {
+	return base64.StdEncoding.EncodeToString(HMACSHA256(secret, passphrase))
+}
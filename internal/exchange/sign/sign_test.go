@@ -0,0 +1,138 @@
+package sign
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"testing"
+)
+
+type vector struct {
+	Name       string `json:"name"`
+	Exchange   string `json:"exchange"`
+	Scheme     string `json:"scheme"`
+	Secret     string `json:"secret"`
+	APIKey     string `json:"apiKey"`
+	Passphrase string `json:"passphrase"`
+	Timestamp  string `json:"timestamp"`
+	RecvWindow string `json:"recvWindow"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Query      string `json:"query"`
+	Body       string `json:"body"`
+	Payload    string `json:"payload"`
+	Expected   string `json:"expected"`
+	Source     string `json:"source"`
+}
+
+func loadVectors(t *testing.T) []vector {
+	t.Helper()
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("failed to read vectors: %v", err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("failed to parse vectors: %v", err)
+	}
+	return vectors
+}
+
+func TestVectors(t *testing.T) {
+	vectors := loadVectors(t)
+	if len(vectors) == 0 {
+		t.Fatal("no signing vectors found")
+	}
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			var got string
+			switch v.Scheme {
+			case "query-hmac-hex":
+				got = SignQueryHMACHex(v.Secret, v.Query)
+			case "bybit-hmac-hex":
+				got = SignBybitHex(v.Secret, v.Timestamp, v.APIKey, v.RecvWindow, v.Payload)
+			case "prehash-base64":
+				got = SignRequestBase64(v.Secret, v.Timestamp, v.Method, v.Path, v.Query, v.Body)
+			case "passphrase-base64":
+				got = SignPassphraseBase64(v.Secret, v.Passphrase)
+			case "prehash-hex":
+				got = SignPrehashHex(v.Secret, Prehash(v.Timestamp, v.Method, v.Path, v.Query, v.Body))
+			case "kraken-base64":
+				var err error
+				if got, err = SignKrakenBase64(v.Secret, v.Path, v.Timestamp, v.Body); err != nil {
+					t.Fatal(err)
+				}
+			default:
+				t.Fatalf("unknown scheme %q", v.Scheme)
+			}
+			if got != v.Expected {
+				t.Errorf("%s signature = %s, want %s (source: %s)", v.Exchange, got, v.Expected, v.Source)
+			}
+		})
+	}
+}
+
+func TestSignKrakenBase64_InvalidSecret(t *testing.T) {
+	if _, err := SignKrakenBase64("not base64!", "/0/private/Balance", "1", "nonce=1"); err == nil {
+		t.Error("SignKrakenBase64() accepted a secret that is not base64")
+	}
+}
+
+func TestPrehash(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+		method    string
+		path      string
+		query     string
+		body      string
+		expected  string
+	}{
+		{
+			name:      "get_with_query",
+			timestamp: "2020-12-08T09:08:57.715Z",
+			method:    "get",
+			path:      "/api/v5/account/balance",
+			query:     "ccy=BTC",
+			expected:  "2020-12-08T09:08:57.715ZGET/api/v5/account/balance?ccy=BTC",
+		},
+		{
+			name:      "post_with_body",
+			timestamp: "1695808949356",
+			method:    "POST",
+			path:      "/api/v2/spot/trade/place-order",
+			body:      `{"symbol":"BTCUSDT"}`,
+			expected:  `1695808949356POST/api/v2/spot/trade/place-order{"symbol":"BTCUSDT"}`,
+		},
+		{
+			name:      "no_query_no_body",
+			timestamp: "1547015186532",
+			method:    "GET",
+			path:      "/api/v1/accounts",
+			expected:  "1547015186532GET/api/v1/accounts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Prehash(tt.timestamp, tt.method, tt.path, tt.query, tt.body)
+			if got != tt.expected {
+				t.Errorf("Prehash() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	params := url.Values{}
+	params.Set("timestamp", "1499827319559")
+	params.Set("symbol", "LTCBTC")
+	params.Set("recvWindow", "5000")
+
+	got := CanonicalQuery(params)
+	want := "recvWindow=5000&symbol=LTCBTC&timestamp=1499827319559"
+	if got != want {
+		t.Errorf("CanonicalQuery() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Simulation delays and fails MockExchange calls, so timeout handling can
+// be rehearsed locally. It is safe for concurrent use.
+type Simulation struct {
+	latency     map[string]latencyRange
+	failureRate float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+type latencyRange struct {
+	min, max time.Duration
+}
+
+// NewSimulation builds a simulation from flags.mock. A zero seed picks a
+// random one; any other seed repeats the same delays and failures.
+func NewSimulation(flags config.MockFlags) (*Simulation, error) {
+	s := &Simulation{latency: map[string]latencyRange{}, failureRate: flags.FailureRate}
+	for method, l := range flags.Latency {
+		min, max, err := l.Range()
+		if err != nil {
+			return nil, fmt.Errorf("latency.%s: %w", method, err)
+		}
+		s.latency[method] = latencyRange{min, max}
+	}
+	seed := flags.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	s.rand = rand.New(rand.NewPCG(seed, seed))
+	return s, nil
+}
+
+// call waits out method's latency, then fails with the failure rate. A
+// context ending mid-wait returns its error, as a real request would.
+// A nil simulation does nothing.
+func (s *Simulation) call(ctx context.Context, method string) error {
+	if s == nil {
+		return nil
+	}
+	delay, fail := s.draw(method)
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("mock %s: %w", method, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if fail {
+		return &UnavailableError{Exchange: "mock", Reason: "simulated outage", Err: fmt.Errorf("%s failed", method)}
+	}
+	return nil
+}
+
+// draw picks the delay and outcome of one call
+func (s *Simulation) draw(method string) (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.latency[method]
+	if !ok {
+		l = s.latency[config.MockDefaultMethod]
+	}
+	delay := l.min
+	if l.max > l.min {
+		delay += time.Duration(s.rand.Int64N(int64(l.max-l.min) + 1))
+	}
+	fail := s.rand.Float64() < s.failureRate
+	return delay, fail
+}
@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func newTestSimulation(t *testing.T, flags config.MockFlags) *Simulation {
+	t.Helper()
+	sim, err := NewSimulation(flags)
+	if err != nil {
+		t.Fatalf("NewSimulation() error = %v", err)
+	}
+	return sim
+}
+
+func TestSimulation_Deterministic(t *testing.T) {
+	flags := config.MockFlags{
+		Latency: map[string]config.MockLatency{
+			"PlaceMarketBuyOrder":    {Min: "1s", Max: "9s"},
+			config.MockDefaultMethod: {Fixed: "250ms"},
+		},
+		FailureRate: 0.3,
+		Seed:        42,
+	}
+
+	type outcome struct {
+		delay time.Duration
+		fail  bool
+	}
+	draws := func() []outcome {
+		sim := newTestSimulation(t, flags)
+		var out []outcome
+		for range 20 {
+			delay, fail := sim.draw("PlaceMarketBuyOrder")
+			if delay < time.Second || delay > 9*time.Second {
+				t.Errorf("delay %s outside [1s, 9s]", delay)
+			}
+			out = append(out, outcome{delay, fail})
+		}
+		if delay, _ := sim.draw("GetBalance"); delay != 250*time.Millisecond {
+			t.Errorf("default delay = %s, want 250ms", delay)
+		}
+		return out
+	}
+
+	first, second := draws(), draws()
+	failures := 0
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("draw %d = %+v then %+v; a seed must repeat the same draws", i, first[i], second[i])
+		}
+		if first[i].fail {
+			failures++
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("%d of %d calls failed at a 0.3 failure rate", failures, len(first))
+	}
+}
+
+func TestSimulation_FailureRate(t *testing.T) {
+	ctx := context.Background()
+
+	always := &MockExchange{Sim: newTestSimulation(t, config.MockFlags{FailureRate: 1, Seed: 1})}
+	_, err := always.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(25)))
+	if !IsRetriable(err) || !IsUnavailable(err) {
+		t.Errorf("PlaceMarketBuyOrder() error = %v, want a retriable unavailable error", err)
+	}
+
+	never := &MockExchange{Sim: newTestSimulation(t, config.MockFlags{Seed: 1})}
+	for range 50 {
+		if _, err := never.GetBalance(ctx, "USDT"); err != nil {
+			t.Fatalf("GetBalance() error = %v, want no failures at a zero rate", err)
+		}
+	}
+}
+
+func TestSimulation_ContextCancelledMidSleep(t *testing.T) {
+	m := &MockExchange{Sim: newTestSimulation(t, config.MockFlags{
+		Latency: map[string]config.MockLatency{"PlaceMarketBuyOrder": {Fixed: "8s"}},
+		Seed:    1,
+	})}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := m.PlaceMarketBuyOrder(ctx, "BTC-USDT", QuoteSize(decimal.NewFromInt(25)))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("call returned after %s; the deadline must cut the sleep short", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || !IsTimeout(err) {
+		t.Errorf("PlaceMarketBuyOrder() error = %v, want the deadline", err)
+	}
+}
+
+func TestNewExchange_MockFlags(t *testing.T) {
+	payload := &config.DCAPayload{Flags: config.RuntimeFlags{DryRun: true, Mock: &config.MockFlags{FailureRate: 1, Seed: 7}}}
+	exc, err := NewExchange(payload, Credentials{})
+	if err != nil {
+		t.Fatalf("NewExchange() error = %v", err)
+	}
+	if _, err := exc.GetBalance(context.Background(), "USDT"); !IsUnavailable(err) {
+		t.Errorf("GetBalance() error = %v, want the simulated outage", err)
+	}
+
+	payload.Flags.Mock = &config.MockFlags{Halted: []string{"BTC-USDT"}}
+	if exc, _ = NewExchange(payload, Credentials{}); !errors.Is(CheckTradable(context.Background(), exc, "BTC-USDT"), ErrTradingSuspended) {
+		t.Error("mock trades a symbol in flags.mock.halted")
+	}
+
+	// Without flags.mock the mock never fails or waits
+	payload.Flags.Mock = nil
+	if exc, _ = NewExchange(payload, Credentials{}); exc.(*MockExchange).Sim != nil {
+		t.Error("mock has a simulation without flags.mock")
+	}
+}
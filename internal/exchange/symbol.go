@@ -0,0 +1,29 @@
+package exchange
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commonQuotes are tried, in order, as suffixes of symbols without a separator
+var commonQuotes = []string{"USDT", "USDC", "BUSD", "USD", "BTC", "ETH", "FDUSD"}
+
+// SplitSymbol splits a trading pair into base and quote asset.
+// Handles both "BTC-USDT" and "BTCUSDT"; the latter only for common quote assets.
+func SplitSymbol(symbol string) (base, quote string, err error) {
+	if strings.Contains(symbol, "-") {
+		parts := strings.Split(symbol, "-")
+		if len(parts) != 2 {
+			return "", "", fmt.Errorf("invalid symbol format: %s", symbol)
+		}
+		return parts[0], parts[1], nil
+	}
+
+	for _, quote := range commonQuotes {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return strings.TrimSuffix(symbol, quote), quote, nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unable to extract quote currency from symbol: %s", symbol)
+}
@@ -0,0 +1,30 @@
+package exchange
+
+import "testing"
+
+func TestSplitSymbol(t *testing.T) {
+	tests := []struct {
+		symbol        string
+		base, quote   string
+		expectedError bool
+	}{
+		{"BTC-USDT", "BTC", "USDT", false},
+		{"ETH-BTC", "ETH", "BTC", false},
+		{"BTCUSDT", "BTC", "USDT", false},
+		{"BTC-USDT-X", "", "", true},
+		{"USDT", "", "", true},
+		{"BTCXYZ", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			base, quote, err := SplitSymbol(tt.symbol)
+			if (err != nil) != tt.expectedError {
+				t.Fatalf("SplitSymbol() error = %v, expectedError %v", err, tt.expectedError)
+			}
+			if base != tt.base || quote != tt.quote {
+				t.Errorf("SplitSymbol() = %s, %s, want %s, %s", base, quote, tt.base, tt.quote)
+			}
+		})
+	}
+}
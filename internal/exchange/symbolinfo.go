@@ -0,0 +1,352 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrTradingSuspended marks a symbol that is listed but cannot be traded
+// right now, e.g. halted for maintenance or about to be delisted
+var ErrTradingSuspended = errors.New("trading suspended")
+
+// SymbolStatus is whether a listed symbol can be traded
+type SymbolStatus string
+
+const (
+	SymbolTrading SymbolStatus = "trading"
+	SymbolHalted  SymbolStatus = "halted"
+)
+
+// SymbolInfo describes a listed symbol
+type SymbolInfo struct {
+	Symbol    string       // BASE-QUOTE, e.g. "BTC-USDT"
+	Base      string       // e.g. "BTC"
+	Quote     string       // e.g. "USDT"
+	Status    SymbolStatus // mapped from RawStatus
+	RawStatus string       // the exchange's own status, e.g. "BREAK" or "suspend"
+	Rules     TradingRules // what the exchange accepts in orders of the symbol
+}
+
+// TradingRules are the limits an exchange checks orders of a symbol
+// against. A zero field is a rule the exchange does not report, which is
+// not enforced.
+type TradingRules struct {
+	MinNotional    decimal.Decimal // smallest order value, in the quote asset
+	MinQuantity    decimal.Decimal // smallest order quantity, in the base asset
+	LotStep        decimal.Decimal // quantities are a multiple of it
+	TickSize       decimal.Decimal // prices are a multiple of it
+	BasePrecision  int32           // decimal places of base quantities
+	QuotePrecision int32           // decimal places of quote amounts
+}
+
+// CheckNotional returns an error when a quote amount of symbol is below
+// the minimum order value
+func (r TradingRules) CheckNotional(symbol string, quote decimal.Decimal) error {
+	if r.MinNotional.IsPositive() && quote.LessThan(r.MinNotional) {
+		return fmt.Errorf("quoteAmount %s below minimum notional %s for %s", atLeastCents(quote), atLeastCents(r.MinNotional), symbol)
+	}
+	return nil
+}
+
+// CheckQuantity returns an error when a base quantity of symbol is below
+// the minimum order quantity
+func (r TradingRules) CheckQuantity(symbol string, quantity decimal.Decimal) error {
+	if r.MinQuantity.IsPositive() && quantity.LessThan(r.MinQuantity) {
+		return fmt.Errorf("quantity %s below minimum quantity %s for %s", quantity.String(), r.MinQuantity.String(), symbol)
+	}
+	return nil
+}
+
+// QuantizeQuantity rounds a base quantity down to the lot step, or to the
+// base precision when the exchange reports no step
+func (r TradingRules) QuantizeQuantity(quantity decimal.Decimal) decimal.Decimal {
+	if r.LotStep.IsPositive() {
+		return quantity.Div(r.LotStep).Floor().Mul(r.LotStep)
+	}
+	if r.BasePrecision > 0 {
+		return quantity.Truncate(r.BasePrecision)
+	}
+	return quantity
+}
+
+// QuantizePrice rounds a price down to the tick size
+func (r TradingRules) QuantizePrice(price decimal.Decimal) decimal.Decimal {
+	if r.TickSize.IsPositive() {
+		return price.Div(r.TickSize).Floor().Mul(r.TickSize)
+	}
+	return price
+}
+
+// QuantizeQuote rounds a quote amount down to the quote precision
+func (r TradingRules) QuantizeQuote(quote decimal.Decimal) decimal.Decimal {
+	if r.QuotePrecision > 0 {
+		return quote.Truncate(r.QuotePrecision)
+	}
+	return quote
+}
+
+// atLeastCents renders d with at least two decimals, e.g. "3.00" or "0.0005"
+func atLeastCents(d decimal.Decimal) string {
+	return d.StringFixed(max(2, stepPlaces(d)))
+}
+
+// stepPlaces returns the decimal places of d without trailing zeros, e.g.
+// 8 for a step of 0.00000001 and 2 for 0.010
+func stepPlaces(d decimal.Decimal) int32 {
+	var places int32
+	for !d.Shift(places).IsInteger() && places < 18 {
+		places++
+	}
+	return places
+}
+
+// SymbolInfoer is implemented by exchanges that report the trading status
+// and rules of their symbols. GetSymbolInfo returns an error wrapping
+// ErrSymbolNotFound for symbols that are not listed.
+type SymbolInfoer interface {
+	GetSymbolInfo(ctx context.Context, symbol string) (SymbolInfo, error)
+	ListSymbols(ctx context.Context) ([]SymbolInfo, error)
+}
+
+// Rules returns the trading rules of symbol on exc, or zero rules when
+// exc does not report them
+func Rules(ctx context.Context, exc Exchange, symbol string) (TradingRules, error) {
+	infoer, ok := exc.(SymbolInfoer)
+	if !ok {
+		return TradingRules{}, nil
+	}
+	info, err := infoer.GetSymbolInfo(ctx, symbol)
+	if err != nil {
+		return TradingRules{}, err
+	}
+	return info.Rules, nil
+}
+
+// symbolCache keeps the symbol infos a client has read. Clients live for
+// one invocation, and so do the rules they cache; the zero value is ready
+// to use.
+type symbolCache struct {
+	mu    sync.Mutex
+	infos map[string]SymbolInfo
+}
+
+// get returns the cached info under key, reading it with fetch the first
+// time. Failures are not cached.
+func (c *symbolCache) get(key string, fetch func() (SymbolInfo, error)) (SymbolInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if info, ok := c.infos[key]; ok {
+		return info, nil
+	}
+	info, err := fetch()
+	if err != nil {
+		return SymbolInfo{}, err
+	}
+	if c.infos == nil {
+		c.infos = make(map[string]SymbolInfo)
+	}
+	c.infos[key] = info
+	return info, nil
+}
+
+// MaxSuggestions caps the symbols suggested for one that is not listed
+const MaxSuggestions = 3
+
+// CheckTradable checks that symbol is listed on exc and trading. A halted
+// symbol returns an error wrapping ErrTradingSuspended; one that is not
+// listed wraps ErrSymbolNotFound and suggests listed symbols of the same
+// base. Exchanges that report no status fall back to SymbolChecker, and
+// those that cannot tell at all are assumed to trade every symbol.
+func CheckTradable(ctx context.Context, exc Exchange, symbol string) error {
+	infoer, ok := exc.(SymbolInfoer)
+	if !ok {
+		if checker, ok := exc.(SymbolChecker); ok {
+			return checker.CheckSymbol(ctx, symbol)
+		}
+		return nil
+	}
+
+	info, err := infoer.GetSymbolInfo(ctx, symbol)
+	if errors.Is(err, ErrSymbolNotFound) {
+		// Suggestions are a courtesy; failing to list keeps the plain error
+		if listed, listErr := infoer.ListSymbols(ctx); listErr == nil {
+			if similar := Suggest(symbol, listed); len(similar) > 0 {
+				return fmt.Errorf("%w (did you mean %s?)", err, strings.Join(similar, ", "))
+			}
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if info.Status != SymbolTrading {
+		return fmt.Errorf("%s (%s): %w", symbol, info.RawStatus, ErrTradingSuspended)
+	}
+	return nil
+}
+
+// suggestedQuotes orders suggestions by how common the quote asset is
+var suggestedQuotes = []string{"USDT", "USDC", "FDUSD", "USD", "EUR", "BTC", "ETH"}
+
+// Suggest returns up to MaxSuggestions trading symbols from listed with the
+// same base as symbol and a different quote, common quotes first
+func Suggest(symbol string, listed []SymbolInfo) []string {
+	base, quote, err := SplitSymbol(symbol)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []SymbolInfo
+	for _, info := range listed {
+		if strings.EqualFold(info.Base, base) && !strings.EqualFold(info.Quote, quote) && info.Status == SymbolTrading {
+			candidates = append(candidates, info)
+		}
+	}
+	rank := func(quote string) int {
+		if i := slices.Index(suggestedQuotes, strings.ToUpper(quote)); i >= 0 {
+			return i
+		}
+		return len(suggestedQuotes)
+	}
+	slices.SortFunc(candidates, func(a, b SymbolInfo) int {
+		if ra, rb := rank(a.Quote), rank(b.Quote); ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(a.Symbol, b.Symbol)
+	})
+
+	var out []string
+	for _, info := range candidates[:min(len(candidates), MaxSuggestions)] {
+		out = append(out, info.Symbol)
+	}
+	return out
+}
+
+// BinanceSymbolStatus maps a Binance exchangeInfo status. Only TRADING is
+// tradable; BREAK, HALT and the auction and end-of-day states are not.
+func BinanceSymbolStatus(status string) SymbolStatus {
+	if status == "TRADING" {
+		return SymbolTrading
+	}
+	return SymbolHalted
+}
+
+// OKXSymbolStatus maps an OKX instrument state. Only live is tradable;
+// suspend, preopen and test are not.
+func OKXSymbolStatus(state string) SymbolStatus {
+	if state == "live" {
+		return SymbolTrading
+	}
+	return SymbolHalted
+}
+
+// ParseBinanceExchangeInfo reads the symbols of a GET /api/v3/exchangeInfo
+// response
+func ParseBinanceExchangeInfo(data []byte) ([]SymbolInfo, error) {
+	var resp struct {
+		Symbols []struct {
+			Symbol              string `json:"symbol"`
+			Status              string `json:"status"`
+			BaseAsset           string `json:"baseAsset"`
+			QuoteAsset          string `json:"quoteAsset"`
+			BaseAssetPrecision  int32  `json:"baseAssetPrecision"`
+			QuoteAssetPrecision int32  `json:"quoteAssetPrecision"`
+			Filters             []struct {
+				FilterType  string          `json:"filterType"`
+				TickSize    decimal.Decimal `json:"tickSize"`
+				StepSize    decimal.Decimal `json:"stepSize"`
+				MinQty      decimal.Decimal `json:"minQty"`
+				MinNotional decimal.Decimal `json:"minNotional"`
+			} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode binance exchangeInfo: %w", err)
+	}
+	infos := make([]SymbolInfo, 0, len(resp.Symbols))
+	for _, s := range resp.Symbols {
+		rules := TradingRules{BasePrecision: s.BaseAssetPrecision, QuotePrecision: s.QuoteAssetPrecision}
+		for _, f := range s.Filters {
+			switch f.FilterType {
+			case "PRICE_FILTER":
+				rules.TickSize = f.TickSize
+			case "LOT_SIZE":
+				rules.LotStep, rules.MinQuantity = f.StepSize, f.MinQty
+			case "NOTIONAL", "MIN_NOTIONAL":
+				// MIN_NOTIONAL is the filter's older name
+				rules.MinNotional = f.MinNotional
+			}
+		}
+		infos = append(infos, SymbolInfo{
+			Symbol:    s.BaseAsset + "-" + s.QuoteAsset,
+			Base:      s.BaseAsset,
+			Quote:     s.QuoteAsset,
+			Status:    BinanceSymbolStatus(s.Status),
+			RawStatus: s.Status,
+			Rules:     rules,
+		})
+	}
+	return infos, nil
+}
+
+// ParseOKXInstruments reads a GET /api/v5/public/instruments?instType=SPOT
+// response
+func ParseOKXInstruments(data []byte) ([]SymbolInfo, error) {
+	var resp struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			InstID   string          `json:"instId"`
+			BaseCcy  string          `json:"baseCcy"`
+			QuoteCcy string          `json:"quoteCcy"`
+			State    string          `json:"state"`
+			TickSz   decimal.Decimal `json:"tickSz"`
+			LotSz    decimal.Decimal `json:"lotSz"`
+			MinSz    decimal.Decimal `json:"minSz"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode okx instruments: %w", err)
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("okx instruments: code %s: %s", resp.Code, resp.Msg)
+	}
+	infos := make([]SymbolInfo, 0, len(resp.Data))
+	for _, inst := range resp.Data {
+		infos = append(infos, SymbolInfo{
+			Symbol:    inst.InstID,
+			Base:      inst.BaseCcy,
+			Quote:     inst.QuoteCcy,
+			Status:    OKXSymbolStatus(inst.State),
+			RawStatus: inst.State,
+			// OKX limits spot orders by base quantity, not value, and
+			// reports no precisions: the steps imply them
+			Rules: TradingRules{
+				MinQuantity:    inst.MinSz,
+				LotStep:        inst.LotSz,
+				TickSize:       inst.TickSz,
+				BasePrecision:  stepPlaces(inst.LotSz),
+				QuotePrecision: stepPlaces(inst.TickSz),
+			},
+		})
+	}
+	return infos, nil
+}
+
+// FindSymbol returns the info of symbol in listed, or an error wrapping
+// ErrSymbolNotFound
+func FindSymbol(listed []SymbolInfo, symbol string) (SymbolInfo, error) {
+	for _, info := range listed {
+		if strings.EqualFold(info.Symbol, symbol) {
+			return info, nil
+		}
+	}
+	return SymbolInfo{}, fmt.Errorf("%s: %w", symbol, ErrSymbolNotFound)
+}
@@ -0,0 +1,199 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseSymbolInfo(t *testing.T) {
+	binance, err := ParseBinanceExchangeInfo(readFixture(t, "binance_exchange_info.json"))
+	if err != nil {
+		t.Fatalf("ParseBinanceExchangeInfo() error = %v", err)
+	}
+	okx, err := ParseOKXInstruments(readFixture(t, "okx_instruments.json"))
+	if err != nil {
+		t.Fatalf("ParseOKXInstruments() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		listed     []SymbolInfo
+		symbol     string
+		wantStatus SymbolStatus
+		wantRaw    string
+	}{
+		{"binance_trading", binance, "BTC-USDT", SymbolTrading, "TRADING"},
+		{"binance_halt", binance, "BTC-USDC", SymbolHalted, "HALT"},
+		{"binance_break", binance, "LUNA-USDT", SymbolHalted, "BREAK"},
+		{"binance_end_of_day", binance, "SOL-BTC", SymbolHalted, "END_OF_DAY"},
+		{"okx_live", okx, "BTC-USDT", SymbolTrading, "live"},
+		{"okx_suspend", okx, "XYZ-USDT", SymbolHalted, "suspend"},
+		{"okx_preopen", okx, "NEW-USDT", SymbolHalted, "preopen"},
+		{"okx_test", okx, "TST-USDT", SymbolHalted, "test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := FindSymbol(tt.listed, tt.symbol)
+			if err != nil {
+				t.Fatalf("FindSymbol() error = %v", err)
+			}
+			if info.Status != tt.wantStatus || info.RawStatus != tt.wantRaw {
+				t.Errorf("status = %s (%s), want %s (%s)", info.Status, info.RawStatus, tt.wantStatus, tt.wantRaw)
+			}
+		})
+	}
+
+	if _, err := FindSymbol(okx, "DOGE-USDT"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("FindSymbol(DOGE-USDT) error = %v, want ErrSymbolNotFound", err)
+	}
+	if _, err := ParseOKXInstruments([]byte(`{"code": "51001", "msg": "Instrument ID does not exist"}`)); err == nil || !strings.Contains(err.Error(), "51001") {
+		t.Errorf("ParseOKXInstruments() error = %v, want the OKX code", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	binance, _ := ParseBinanceExchangeInfo(readFixture(t, "binance_exchange_info.json"))
+	okx, _ := ParseOKXInstruments(readFixture(t, "okx_instruments.json"))
+
+	tests := []struct {
+		name   string
+		listed []SymbolInfo
+		symbol string
+		want   []string
+	}{
+		// Halted BTC-USDC is left out; TRY ranks after the common quotes
+		{"binance_btc", binance, "BTC-XYZ", []string{"BTC-USDT", "BTC-FDUSD", "BTC-EUR"}},
+		{"binance_other_quote", binance, "ETH-BUSD", []string{"ETH-USDT"}},
+		{"binance_only_halted", binance, "LUNA-EUR", nil},
+		{"okx_btc", okx, "BTC-DAI", []string{"BTC-USDT", "BTC-USDC", "BTC-EUR"}},
+		{"okx_skips_own_quote", okx, "btc-usdt", []string{"BTC-USDC", "BTC-EUR"}},
+		{"unknown_base", okx, "DOGE-USDT", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Suggest(tt.symbol, tt.listed); !slices.Equal(got, tt.want) {
+				t.Errorf("Suggest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTradable(t *testing.T) {
+	mock := &MockExchange{
+		Symbols: []string{"BTC-USDT", "BTC-USDC", "XYZ-USDT"},
+		Halted:  []string{"XYZ-USDT"},
+	}
+	ctx := context.Background()
+
+	if err := CheckTradable(ctx, mock, "BTC-USDT"); err != nil {
+		t.Errorf("CheckTradable(BTC-USDT) error = %v", err)
+	}
+
+	err := CheckTradable(ctx, mock, "XYZ-USDT")
+	if !errors.Is(err, ErrTradingSuspended) || err.Error() != "XYZ-USDT (BREAK): trading suspended" {
+		t.Errorf("CheckTradable(XYZ-USDT) error = %v, want trading suspended", err)
+	}
+
+	err = CheckTradable(ctx, mock, "BTC-EUR")
+	if !errors.Is(err, ErrSymbolNotFound) || !strings.HasSuffix(err.Error(), "(did you mean BTC-USDT, BTC-USDC?)") {
+		t.Errorf("CheckTradable(BTC-EUR) error = %v, want suggestions", err)
+	}
+	if err := CheckTradable(ctx, mock, "DOGE-USDT"); !errors.Is(err, ErrSymbolNotFound) || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("CheckTradable(DOGE-USDT) error = %v, want not found without suggestions", err)
+	}
+
+	// Exchanges without a status fall back to SymbolChecker, or trade everything
+	checkerOnly := struct {
+		Exchange
+		SymbolChecker
+	}{mock, mock}
+	if err := CheckTradable(ctx, checkerOnly, "XYZ-USDT"); err != nil {
+		t.Errorf("CheckTradable() via SymbolChecker error = %v, want a listed symbol", err)
+	}
+	if err := CheckTradable(ctx, struct{ Exchange }{mock}, "DOGE-USDT"); err != nil {
+		t.Errorf("CheckTradable() without a checker error = %v", err)
+	}
+}
+
+func TestParseTradingRules(t *testing.T) {
+	binance, err := ParseBinanceExchangeInfo(readFixture(t, "binance_exchange_info.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	okx, err := ParseOKXInstruments(readFixture(t, "okx_instruments.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := decimal.RequireFromString
+	tests := []struct {
+		name   string
+		listed []SymbolInfo
+		symbol string
+		want   TradingRules
+	}{
+		{"binance_notional", binance, "BTC-USDT", TradingRules{MinNotional: d("5"), MinQuantity: d("0.00001"), LotStep: d("0.00001"), TickSize: d("0.01"), BasePrecision: 8, QuotePrecision: 8}},
+		{"binance_min_notional", binance, "ETH-USDT", TradingRules{MinNotional: d("10"), MinQuantity: d("0.0001"), LotStep: d("0.0001"), TickSize: d("0.01"), BasePrecision: 8, QuotePrecision: 8}},
+		{"binance_no_filters", binance, "SOL-BTC", TradingRules{}},
+		{"okx_steps", okx, "BTC-USDT", TradingRules{MinQuantity: d("0.00001"), LotStep: d("0.00000001"), TickSize: d("0.1"), BasePrecision: 8, QuotePrecision: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := FindSymbol(tt.listed, tt.symbol)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := info.Rules
+			if !got.MinNotional.Equal(tt.want.MinNotional) || !got.MinQuantity.Equal(tt.want.MinQuantity) ||
+				!got.LotStep.Equal(tt.want.LotStep) || !got.TickSize.Equal(tt.want.TickSize) ||
+				got.BasePrecision != tt.want.BasePrecision || got.QuotePrecision != tt.want.QuotePrecision {
+				t.Errorf("Rules = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingRules(t *testing.T) {
+	d := decimal.RequireFromString
+	rules := TradingRules{MinNotional: d("5"), MinQuantity: d("0.0001"), LotStep: d("0.0001"), TickSize: d("0.5"), QuotePrecision: 2}
+
+	if err := rules.CheckNotional("BTC-USDT", d("3")); err == nil || err.Error() != "quoteAmount 3.00 below minimum notional 5.00 for BTC-USDT" {
+		t.Errorf("CheckNotional(3) error = %v", err)
+	}
+	if err := rules.CheckNotional("BTC-USDT", d("5")); err != nil {
+		t.Errorf("CheckNotional(5) error = %v, want the minimum accepted", err)
+	}
+	if err := rules.CheckQuantity("BTC-USDT", d("0.00009")); err == nil {
+		t.Error("CheckQuantity(0.00009) accepted a quantity below the minimum")
+	}
+	if got := rules.QuantizeQuantity(d("0.00129999")); !got.Equal(d("0.0012")) {
+		t.Errorf("QuantizeQuantity() = %s, want 0.0012", got)
+	}
+	if got := rules.QuantizePrice(d("47500.37")); !got.Equal(d("47500")) {
+		t.Errorf("QuantizePrice() = %s, want 47500", got)
+	}
+	if got := rules.QuantizeQuote(d("24.999")); !got.Equal(d("24.99")) {
+		t.Errorf("QuantizeQuote() = %s, want 24.99", got)
+	}
+
+	// Rules the exchange does not report are not enforced
+	var none TradingRules
+	if err := none.CheckNotional("BTC-USDT", d("0.01")); err != nil || !none.QuantizePrice(d("1.234")).Equal(d("1.234")) {
+		t.Errorf("zero rules enforced something: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Trade is a single execution of an order, as listed in the account's
+// trade history. An order filled in several parts has several trades.
+type Trade struct {
+	ID            string          `json:"id"`
+	OrderID       string          `json:"orderId"`
+	ClientOrderID string          `json:"clientOrderId,omitempty"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"` // "buy" or "sell"
+	Quantity      decimal.Decimal `json:"quantity"`
+	Price         decimal.Decimal `json:"price"`
+	FeeAmount     decimal.Decimal `json:"feeAmount"`
+	FeeAsset      string          `json:"feeAsset,omitempty"`
+	Time          time.Time       `json:"time"`
+}
+
+// TradePage fetches up to limit trades of the account executed in
+// [start, end), oldest first, starting after the trade with ID after, or at
+// start when after is empty
+type TradePage func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error)
+
+// CollectTrades pages through the trades executed in [from, to) the way
+// exchange history endpoints require: in windows no longer than window
+// (Binance allows 24 hours), each read in pages of limit trades
+func CollectTrades(ctx context.Context, from, to time.Time, window time.Duration, limit int, page TradePage) ([]Trade, error) {
+	if window <= 0 || limit <= 0 {
+		return nil, fmt.Errorf("invalid trade paging: window %s, limit %d", window, limit)
+	}
+
+	var trades []Trade
+	for start := from; start.Before(to); start = start.Add(window) {
+		end := start.Add(window)
+		if end.After(to) {
+			end = to
+		}
+
+		after := ""
+		for {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			batch, err := page(ctx, start, end, after, limit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list trades from %s: %w", start.Format(time.RFC3339), err)
+			}
+			trades = append(trades, batch...)
+			if len(batch) < limit {
+				break
+			}
+			last := batch[len(batch)-1].ID
+			if last == after {
+				return nil, fmt.Errorf("trade history did not advance past trade %s", last)
+			}
+			after = last
+		}
+	}
+	return trades, nil
+}
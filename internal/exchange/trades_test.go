@@ -0,0 +1,70 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMockExchange_GetMyTradesPaginates(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock := &MockExchange{TradePageLimit: 2}
+	// Five trades on the first day (three pages, the last one short), two
+	// on the third (exactly one full page), none on the second
+	for i, at := range []time.Time{
+		day.Add(1 * time.Hour), day.Add(2 * time.Hour), day.Add(3 * time.Hour), day.Add(4 * time.Hour), day.Add(5 * time.Hour),
+		day.Add(50 * time.Hour), day.Add(51 * time.Hour),
+	} {
+		mock.Trades = append(mock.Trades, Trade{ID: fmt.Sprint(i + 1), Symbol: "BTC-USDT", Time: at, Quantity: decimal.NewFromInt(1)})
+	}
+	mock.Trades = append(mock.Trades, Trade{ID: "99", Symbol: "ETH-USDT", Time: day.Add(time.Hour)})
+
+	trades, err := mock.GetMyTrades(context.Background(), "BTC-USDT", day, day.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatalf("GetMyTrades() error = %v", err)
+	}
+	var ids []string
+	for _, tr := range trades {
+		ids = append(ids, tr.ID)
+	}
+	if got := strings.Join(ids, ","); got != "1,2,3,4,5,6,7" {
+		t.Errorf("trade IDs = %s, want 1..7 once each", got)
+	}
+}
+
+func TestCollectTrades_Windows(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var windows []string
+	_, err := CollectTrades(context.Background(), from, from.Add(60*time.Hour), 24*time.Hour, 10, func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error) {
+		windows = append(windows, fmt.Sprintf("%s-%s", start.Format("02T15"), end.Format("02T15")))
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("CollectTrades() error = %v", err)
+	}
+	if got := strings.Join(windows, " "); got != "01T00-02T00 02T00-03T00 03T00-03T12" {
+		t.Errorf("windows = %s", got)
+	}
+}
+
+func TestCollectTrades_Errors(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	stuck := func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error) {
+		return []Trade{{ID: "1"}}, nil
+	}
+	if _, err := CollectTrades(context.Background(), from, from.Add(time.Hour), time.Hour, 1, stuck); err == nil || !strings.Contains(err.Error(), "did not advance") {
+		t.Errorf("CollectTrades(stuck) error = %v, want did not advance", err)
+	}
+
+	failing := func(ctx context.Context, start, end time.Time, after string, limit int) ([]Trade, error) {
+		return nil, errors.New("rate limited")
+	}
+	if _, err := CollectTrades(context.Background(), from, from.Add(time.Hour), time.Hour, 1, failing); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("CollectTrades(failing) error = %v, want rate limited", err)
+	}
+}
@@ -0,0 +1,27 @@
+package exchange
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// Transfer is a move of funds into the trading account
+type Transfer struct {
+	Asset  string          `json:"asset"`
+	Amount decimal.Decimal `json:"amount"`
+	From   string          `json:"from"` // e.g. "funding", "simple earn"
+	ID     string          `json:"id"`   // the exchange's transfer or redemption IDs, comma-separated
+}
+
+// FundingTransferer is implemented by exchanges that hold funds outside
+// the trading account, such as a funding or earn account, and can move
+// them in
+type FundingTransferer interface {
+	// GetFundingBalance returns the amount of asset that TransferToTrading
+	// can move right away
+	GetFundingBalance(ctx context.Context, asset string) (decimal.Decimal, error)
+
+	// TransferToTrading moves amount of asset into the trading account
+	TransferToTrading(ctx context.Context, asset string, amount decimal.Decimal) (*Transfer, error)
+}
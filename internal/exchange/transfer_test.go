@@ -0,0 +1,132 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+)
+
+// transferNow is the request time of the transfer tests
+func transferNow() time.Time { return time.UnixMilli(1760600000000) }
+
+func TestBinanceEarn(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{
+		"GET /sapi/v1/simple-earn/flexible/position": "binance_earn_position.json",
+		"POST /sapi/v1/simple-earn/flexible/redeem":  "binance_earn_redeem.json",
+	}, &requests, &bodies)
+	defer server.Close()
+	earn := &BinanceEarn{BaseURL: server.URL, APIKey: "key", APISecret: "secret", Now: transferNow}
+	ctx := context.Background()
+
+	// The collateralised position cannot be redeemed
+	balance, err := earn.GetFundingBalance(ctx, "USDT")
+	if err != nil {
+		t.Fatalf("GetFundingBalance() error = %v", err)
+	}
+	if !balance.Equal(decimal.RequireFromString("42.5")) {
+		t.Errorf("GetFundingBalance() = %s, want 42.5", balance)
+	}
+	query, signature, _ := strings.Cut(requests[0].URL.RawQuery, "&signature=")
+	if query != "asset=USDT&recvWindow=5000&size=100&timestamp=1760600000000" || signature != sign.SignQueryHMACHex("secret", query) {
+		t.Errorf("query = %s, signature %s", query, signature)
+	}
+
+	// 35 spans both redeemable products
+	requests = nil
+	transfer, err := earn.TransferToTrading(ctx, "USDT", decimal.NewFromInt(35))
+	if err != nil {
+		t.Fatalf("TransferToTrading() error = %v", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("got %d requests, want the positions and two redemptions", len(requests))
+	}
+	for i, want := range []string{"USDT001 30.5", "USDT002 4.5"} {
+		q := requests[i+1].URL.Query()
+		if got := q.Get("productId") + " " + q.Get("amount"); got != want || q.Get("destAccount") != "SPOT" {
+			t.Errorf("redemption %d = %s to %s, want %s to SPOT", i, got, q.Get("destAccount"), want)
+		}
+	}
+	if transfer.ID != "40607,40607" || transfer.From != "simple earn" || !transfer.Amount.Equal(decimal.NewFromInt(35)) {
+		t.Errorf("TransferToTrading() = %+v", transfer)
+	}
+
+	// Nothing is redeemed when the positions fall short
+	requests = nil
+	if _, err := earn.TransferToTrading(ctx, "USDT", decimal.NewFromInt(50)); err == nil || !strings.Contains(err.Error(), "only 42.5 USDT is redeemable") {
+		t.Errorf("TransferToTrading(50) error = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("got %d requests, want only the positions", len(requests))
+	}
+}
+
+func TestOKXFunding(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{
+		"GET /api/v5/asset/balances":  "okx_funding_balances.json",
+		"POST /api/v5/asset/transfer": "okx_funding_transfer.json",
+	}, &requests, &bodies)
+	defer server.Close()
+	funding := &OKXFunding{BaseURL: server.URL, APIKey: "key", APISecret: "secret", Passphrase: "pass", Now: transferNow}
+	ctx := context.Background()
+
+	balance, err := funding.GetFundingBalance(ctx, "USDT")
+	if err != nil {
+		t.Fatalf("GetFundingBalance() error = %v", err)
+	}
+	if !balance.Equal(decimal.RequireFromString("37.11")) {
+		t.Errorf("GetFundingBalance() = %s, want the available 37.11", balance)
+	}
+	req := requests[0]
+	timestamp := req.Header.Get("OK-ACCESS-TIMESTAMP")
+	if timestamp != "2025-10-16T07:33:20.000Z" || req.Header.Get("OK-ACCESS-PASSPHRASE") != "pass" ||
+		req.Header.Get("OK-ACCESS-SIGN") != sign.SignRequestBase64("secret", timestamp, "GET", "/api/v5/asset/balances", "ccy=USDT", "") {
+		t.Errorf("headers = %v", req.Header)
+	}
+
+	transfer, err := funding.TransferToTrading(ctx, "USDT", decimal.RequireFromString("15.15"))
+	if err != nil {
+		t.Fatalf("TransferToTrading() error = %v", err)
+	}
+	var body map[string]string
+	if err := json.Unmarshal([]byte(bodies[1]), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["ccy"] != "USDT" || body["amt"] != "15.15" || body["from"] != "6" || body["to"] != "18" {
+		t.Errorf("transfer body = %s", bodies[1])
+	}
+	if requests[1].Header.Get("OK-ACCESS-SIGN") != sign.SignRequestBase64("secret", timestamp, "POST", "/api/v5/asset/transfer", "", bodies[1]) {
+		t.Error("transfer signature does not match the signed body")
+	}
+	if transfer.ID != "754147" || transfer.From != "funding" {
+		t.Errorf("TransferToTrading() = %+v", transfer)
+	}
+}
+
+func TestOKXFunding_Insufficient(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	server := fixtureServer(t, map[string]string{"POST /api/v5/asset/transfer": "okx_funding_insufficient.json"}, &requests, &bodies)
+	defer server.Close()
+	funding := &OKXFunding{BaseURL: server.URL, Now: transferNow}
+
+	_, err := funding.TransferToTrading(context.Background(), "USDT", decimal.NewFromInt(100))
+	if err == nil || !strings.Contains(err.Error(), "Insufficient balance") {
+		t.Errorf("TransferToTrading() error = %v, want OKX's rejection", err)
+	}
+	if explanation, ok := Explain(err); !ok || !strings.Contains(err.Error(), explanation.Fix) {
+		t.Errorf("TransferToTrading() error = %v, want 58350 explained", err)
+	}
+	if IsRetriable(err) {
+		t.Error("a rejected transfer is not retriable")
+	}
+}
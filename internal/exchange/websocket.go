@@ -0,0 +1,276 @@
+package exchange
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/useragent"
+)
+
+// ErrWebsocketClosed is returned by reads after the server closed the
+// websocket
+var ErrWebsocketClosed = errors.New("websocket closed")
+
+// wsMaxMessage bounds a websocket message; order updates are a few KB
+const wsMaxMessage = 1 << 20
+
+// wsAcceptGUID is appended to the handshake key to derive the accept key
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Websocket opcodes
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// wsConn is a client websocket connection (RFC 6455) for single-shot use:
+// it does not reconnect, and every read and write ends when its context
+// does. It is not safe for concurrent use.
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// dialWebsocket opens a websocket to a ws:// or wss:// URL, sending header
+// with the handshake
+func dialWebsocket(ctx context.Context, rawURL string, header http.Header) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket URL: %w", err)
+	}
+	host, port := u.Hostname(), u.Port()
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+		if port == "" {
+			port = "80"
+		}
+	case "wss":
+		u.Scheme = "https"
+		if port == "" {
+			port = "443"
+		}
+	default:
+		return nil, fmt.Errorf("invalid websocket URL scheme %q", u.Scheme)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	c := &wsConn{conn: conn, br: bufio.NewReader(conn)}
+	if err := c.handshake(ctx, u, header); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake upgrades the connection to a websocket
+func (c *wsConn) handshake(ctx context.Context, u *url.URL, header http.Header) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	useragent.Apply(req)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	return c.within(ctx, func() error {
+		if err := req.Write(c.conn); err != nil {
+			return err
+		}
+		resp, err := http.ReadResponse(c.br, req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+			return &HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+		}
+		if resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+			return fmt.Errorf("websocket handshake failed: wrong Sec-WebSocket-Accept")
+		}
+		return nil
+	})
+}
+
+// wsAccept returns the Sec-WebSocket-Accept of a handshake key
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// within runs a read or write on the connection, bounded by ctx's deadline
+// and interrupted when ctx ends, and returns ctx's error when it did
+func (c *wsConn) within(ctx context.Context, op func() error) error {
+	deadline, _ := ctx.Deadline() // zero: no deadline
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Now()) })
+	err := op()
+	if !stop() || ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// WriteJSON sends v as a text message
+func (c *wsConn) WriteJSON(ctx context.Context, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.within(ctx, func() error { return c.writeFrame(wsText, data) })
+}
+
+// Read returns the next text or binary message, answering pings while it
+// waits. It returns ErrWebsocketClosed once the server closed the socket.
+func (c *wsConn) Read(ctx context.Context) ([]byte, error) {
+	var message []byte
+	err := c.within(ctx, func() error {
+		for {
+			fin, opcode, payload, err := c.readFrame()
+			if err != nil {
+				return err
+			}
+			switch opcode {
+			case wsPing:
+				if err := c.writeFrame(wsPong, payload); err != nil {
+					return err
+				}
+				continue
+			case wsPong:
+				continue
+			case wsClose:
+				c.writeFrame(wsClose, payload) // echo, best effort
+				return ErrWebsocketClosed
+			case wsText, wsBinary:
+				message = payload
+			case wsContinuation:
+				if message == nil {
+					return fmt.Errorf("websocket: continuation frame without a message")
+				}
+				message = append(message, payload...)
+			default:
+				return fmt.Errorf("websocket: unknown opcode %#x", opcode)
+			}
+			if len(message) > wsMaxMessage {
+				return fmt.Errorf("websocket: message larger than %d bytes", wsMaxMessage)
+			}
+			if fin {
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Close sends a close frame, best effort, and closes the connection
+func (c *wsConn) Close() error {
+	c.conn.SetDeadline(time.Now().Add(time.Second))
+	c.writeFrame(wsClose, []byte{0x03, 0xe8}) // 1000, normal closure
+	return c.conn.Close()
+}
+
+// readFrame reads one frame. Server frames are not masked.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0f
+	if head[1]&0x80 != 0 {
+		return false, 0, nil, fmt.Errorf("websocket: masked frame from the server")
+	}
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessage {
+		return false, 0, nil, fmt.Errorf("websocket: frame larger than %d bytes", wsMaxMessage)
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single, final frame, masked as client frames must be
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
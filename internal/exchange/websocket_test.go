@@ -0,0 +1,213 @@
+package exchange
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsPeer is the server side of a test websocket
+type wsPeer struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+// wsTestServer serves websockets at any path, calling handle with the
+// server side once the handshake is done. Other requests go to rest.
+func wsTestServer(t *testing.T, rest http.HandlerFunc, handle func(p *wsPeer, r *http.Request)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			if rest == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			rest(w, r)
+			return
+		}
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			t.Errorf("handshake headers = %v", r.Header)
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + wsAccept(key) + "\r\n\r\n")
+		brw.Flush()
+		handle(&wsPeer{t: t, conn: conn, br: brw.Reader}, r)
+	}))
+}
+
+// wsURL returns the ws:// URL of server
+func wsURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// send writes a frame as a server does, unmasked
+func (p *wsPeer) send(opcode byte, fin bool, payload string) {
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head}
+	if n := len(payload); n < 126 {
+		frame = append(frame, byte(n))
+	} else {
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	if _, err := p.conn.Write(append(frame, payload...)); err != nil {
+		p.t.Error(err)
+	}
+}
+
+// text sends a text message
+func (p *wsPeer) text(payload string) {
+	p.send(wsText, true, payload)
+}
+
+// receive reads a client frame, checking it is masked
+func (p *wsPeer) receive() (byte, []byte) {
+	var head [2]byte
+	if _, err := io.ReadFull(p.br, head[:]); err != nil {
+		p.t.Errorf("reading client frame: %v", err)
+		return 0, nil
+	}
+	if head[1]&0x80 == 0 {
+		p.t.Error("client frame is not masked")
+	}
+	length := int(head[1] & 0x7f)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(p.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	var mask [4]byte
+	io.ReadFull(p.br, mask[:])
+	payload := make([]byte, length)
+	io.ReadFull(p.br, payload)
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0] & 0x0f, payload
+}
+
+// receiveJSON reads a client text message into v
+func (p *wsPeer) receiveJSON(v any) {
+	opcode, payload := p.receive()
+	if opcode != wsText {
+		p.t.Errorf("client opcode = %#x, want text", opcode)
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		p.t.Errorf("client message %s: %v", payload, err)
+	}
+}
+
+// replay sends each line of a testdata file as a text message
+func (p *wsPeer) replay(name string) {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		p.t.Error(err)
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		p.text(line)
+	}
+}
+
+func TestWebsocket_Read(t *testing.T) {
+	server := wsTestServer(t, nil, func(p *wsPeer, r *http.Request) {
+		p.text(`{"n":1}`)
+		p.send(wsPing, true, "are you there")
+		if opcode, payload := p.receive(); opcode != wsPong || string(payload) != "are you there" {
+			t.Errorf("ping answered with %#x %q, want a pong echoing it", opcode, payload)
+		}
+		p.send(wsText, false, `{"n":`)
+		p.send(wsContinuation, true, `2}`)
+		p.text(strings.Repeat("x", 300))
+		p.send(wsClose, true, "\x03\xe8")
+		p.receive() // the echoed close
+	})
+	defer server.Close()
+
+	ctx := context.Background()
+	conn, err := dialWebsocket(ctx, wsURL(server), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, want := range []string{`{"n":1}`, `{"n":2}`, strings.Repeat("x", 300)} {
+		got, err := conn.Read(ctx)
+		if err != nil || string(got) != want {
+			t.Fatalf("Read() = %.20q, %v, want %.20q", got, err, want)
+		}
+	}
+	if _, err := conn.Read(ctx); !errors.Is(err, ErrWebsocketClosed) {
+		t.Errorf("Read() after close error = %v, want ErrWebsocketClosed", err)
+	}
+}
+
+func TestWebsocket_Deadline(t *testing.T) {
+	done := make(chan struct{})
+	server := wsTestServer(t, nil, func(p *wsPeer, r *http.Request) {
+		<-done // never sends
+	})
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	conn, err := dialWebsocket(ctx, wsURL(server), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	start := time.Now()
+	if _, err := conn.Read(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Read() error = %v, want the context's deadline", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("Read() returned after %s, want it bounded by the context", waited)
+	}
+
+	// Canceling ends a read without a deadline too
+	ctx, cancel = context.WithCancel(context.Background())
+	conn, err = dialWebsocket(ctx, wsURL(server), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := conn.Read(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Read() error = %v, want the context's cancellation", err)
+	}
+}
+
+func TestWebsocket_HandshakeRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`))
+	}))
+	defer server.Close()
+
+	_, err := dialWebsocket(context.Background(), wsURL(server), nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("dialWebsocket() error = %v, want the HTTP 401", err)
+	}
+}
@@ -0,0 +1,143 @@
+package execlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Attributes of the lock table: the string partition key, and the time to
+// live the table must expire items by
+const (
+	DynamoKeyAttribute = "pk"
+	DynamoTTLAttribute = "expiresAt"
+)
+
+// DynamoAPI sends DynamoDB JSON API requests; *dynamo.Client implements it
+type DynamoAPI interface {
+	Call(ctx context.Context, operation string, params any, out any) error
+}
+
+// conditionFailed reports whether err is DynamoDB rejecting a write whose
+// condition did not hold, as *dynamo.APIError reports it
+func conditionFailed(err error) bool {
+	var apiErr interface{ Exception() string }
+	return errors.As(err, &apiErr) && apiErr.Exception() == "ConditionalCheckFailedException"
+}
+
+// DynamoStore keeps locks in a DynamoDB table, one item per window, taken
+// with a conditional write
+type DynamoStore struct {
+	client DynamoAPI
+	table  string
+	now    func() time.Time
+}
+
+// NewDynamoStore creates a store for table
+func NewDynamoStore(client DynamoAPI, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table, now: time.Now}
+}
+
+// attributeValue is a DynamoDB attribute of type S or N
+type attributeValue struct {
+	S string `json:"S,omitempty"`
+	N string `json:"N,omitempty"`
+}
+
+// Acquire puts lock unless an item holds its key that has not expired;
+// DynamoDB deletes expired items only eventually
+func (s *DynamoStore) Acquire(ctx context.Context, lock Lock) (*Lock, error) {
+	err := s.client.Call(ctx, "PutItem", map[string]any{
+		"TableName": s.table,
+		"Item": map[string]attributeValue{
+			DynamoKeyAttribute: {S: lock.Key},
+			"executionId":      {S: lock.ExecutionID},
+			"at":               {S: lock.At.UTC().Format(time.RFC3339Nano)},
+			DynamoTTLAttribute: {N: strconv.FormatInt(lock.ExpiresAt.Unix(), 10)},
+		},
+		"ConditionExpression":       "attribute_not_exists(" + DynamoKeyAttribute + ") OR " + DynamoTTLAttribute + " < :now",
+		"ExpressionAttributeValues": map[string]attributeValue{":now": {N: strconv.FormatInt(s.now().Unix(), 10)}},
+	}, nil)
+	if !conditionFailed(err) {
+		return nil, err
+	}
+	held, err := s.load(ctx, lock.Key)
+	if err != nil {
+		return nil, fmt.Errorf("%w, but reading the lock failed: %w", ErrHeld, err)
+	}
+	if held == nil {
+		// Released since the write; the window is taken all the same
+		held = &Lock{Key: lock.Key}
+	}
+	return held, ErrHeld
+}
+
+// load reads the lock of key with a consistent read; nil when there is none
+func (s *DynamoStore) load(ctx context.Context, key string) (*Lock, error) {
+	var resp struct {
+		Item map[string]attributeValue `json:"Item"`
+	}
+	err := s.client.Call(ctx, "GetItem", map[string]any{
+		"TableName":      s.table,
+		"Key":            map[string]attributeValue{DynamoKeyAttribute: {S: key}},
+		"ConsistentRead": true,
+	}, &resp)
+	if err != nil || resp.Item == nil {
+		return nil, err
+	}
+
+	lock := &Lock{Key: key, ExecutionID: resp.Item["executionId"].S, OrderID: resp.Item["orderId"].S}
+	if at, err := time.Parse(time.RFC3339Nano, resp.Item["at"].S); err == nil {
+		lock.At = at
+	}
+	if expires, err := strconv.ParseInt(resp.Item[DynamoTTLAttribute].N, 10, 64); err == nil {
+		lock.ExpiresAt = time.Unix(expires, 0).UTC()
+	}
+	if order := resp.Item["order"].S; order != "" {
+		lock.Order = json.RawMessage(order)
+	}
+	return lock, nil
+}
+
+// Complete sets the order of the lock of key, if executionID still holds it
+func (s *DynamoStore) Complete(ctx context.Context, key, executionID, orderID string, order json.RawMessage) error {
+	values := map[string]attributeValue{":executionId": {S: executionID}, ":orderId": {S: orderID}}
+	update := "SET orderId = :orderId"
+	if len(order) > 0 {
+		// ORDER is a reserved word
+		update += ", #order = :order"
+		values[":order"] = attributeValue{S: string(order)}
+	}
+	params := map[string]any{
+		"TableName":                 s.table,
+		"Key":                       map[string]attributeValue{DynamoKeyAttribute: {S: key}},
+		"UpdateExpression":          update,
+		"ConditionExpression":       "executionId = :executionId",
+		"ExpressionAttributeValues": values,
+	}
+	if len(order) > 0 {
+		params["ExpressionAttributeNames"] = map[string]string{"#order": "order"}
+	}
+	err := s.client.Call(ctx, "UpdateItem", params, nil)
+	if conditionFailed(err) {
+		return fmt.Errorf("lock %s is no longer held by run %s", key, executionID)
+	}
+	return err
+}
+
+// Release deletes the lock of key if executionID holds it
+func (s *DynamoStore) Release(ctx context.Context, key, executionID string) error {
+	err := s.client.Call(ctx, "DeleteItem", map[string]any{
+		"TableName":                 s.table,
+		"Key":                       map[string]attributeValue{DynamoKeyAttribute: {S: key}},
+		"ConditionExpression":       "executionId = :executionId",
+		"ExpressionAttributeValues": map[string]attributeValue{":executionId": {S: executionID}},
+	}, nil)
+	if conditionFailed(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+// Package execlock keeps a strategy to one run per window, e.g. one buy a
+// day, with a lock per window that runs take before trading. The lock
+// records the order the run placed, so later runs of the window can return
+// it instead of ordering again.
+package execlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrHeld is returned by Acquire when another run took the window's lock
+var ErrHeld = errors.New("already executed for this window")
+
+// Lock is the lock of one window
+type Lock struct {
+	Key         string    `json:"key"`
+	ExecutionID string    `json:"executionId"` // the run that took it
+	At          time.Time `json:"at"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+
+	// OrderID and Order are the order the run placed, once it recorded it
+	OrderID string          `json:"orderId,omitempty"`
+	Order   json.RawMessage `json:"order,omitempty"`
+}
+
+// Store keeps locks
+type Store interface {
+	// Acquire saves lock unless an unexpired lock holds its key, which is
+	// then returned with ErrHeld
+	Acquire(ctx context.Context, lock Lock) (*Lock, error)
+
+	// Complete records the order the run executionID placed under key
+	Complete(ctx context.Context, key, executionID, orderID string, order json.RawMessage) error
+
+	// Release drops the lock of key if the run executionID holds it, so a
+	// later run of the window may trade
+	Release(ctx context.Context, key, executionID string) error
+}
+
+// MemoryStore keeps locks in process, for local runs
+type MemoryStore struct {
+	mu    sync.Mutex
+	locks map[string]Lock
+	now   func() time.Time
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{locks: map[string]Lock{}, now: time.Now}
+}
+
+// Acquire saves lock unless an unexpired lock holds its key
+func (s *MemoryStore) Acquire(ctx context.Context, lock Lock) (*Lock, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if held, ok := s.locks[lock.Key]; ok && held.ExpiresAt.After(s.now()) {
+		return &held, ErrHeld
+	}
+	s.locks[lock.Key] = lock
+	return nil, nil
+}
+
+// Complete records the order of the run holding key
+func (s *MemoryStore) Complete(ctx context.Context, key, executionID, orderID string, order json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if held, ok := s.locks[key]; ok && held.ExecutionID == executionID {
+		held.OrderID, held.Order = orderID, order
+		s.locks[key] = held
+	}
+	return nil
+}
+
+// Release drops the lock of key if executionID holds it
+func (s *MemoryStore) Release(ctx context.Context, key, executionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if held, ok := s.locks[key]; ok && held.ExecutionID == executionID {
+		delete(s.locks, key)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package execlock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// apiError is an exception of the DynamoDB API, as *dynamo.APIError reports it
+type apiError string
+
+func (e apiError) Error() string     { return string(e) }
+func (e apiError) Exception() string { return string(e) }
+
+// fakeDynamo serves the lock table from memory, enforcing the conditions
+// DynamoStore sends
+type fakeDynamo struct {
+	items map[string]map[string]attributeValue
+	err   error // fails every call when set
+	ops   []string
+}
+
+// request is the part of a DynamoDB request fakeDynamo reads
+type request struct {
+	Key                       map[string]attributeValue
+	Item                      map[string]attributeValue
+	ExpressionAttributeValues map[string]attributeValue
+}
+
+func (f *fakeDynamo) Call(ctx context.Context, operation string, params any, out any) error {
+	f.ops = append(f.ops, operation)
+	if f.err != nil {
+		return f.err
+	}
+	var req request
+	data, _ := json.Marshal(params)
+	json.Unmarshal(data, &req)
+	conflict := apiError("ConditionalCheckFailedException")
+
+	switch operation {
+	case "PutItem":
+		key := req.Item[DynamoKeyAttribute].S
+		if current, ok := f.items[key]; ok && current[DynamoTTLAttribute].N >= req.ExpressionAttributeValues[":now"].N {
+			return conflict
+		}
+		f.items[key] = req.Item
+	case "GetItem":
+		data, _ := json.Marshal(map[string]any{"Item": f.items[req.Key[DynamoKeyAttribute].S]})
+		return json.Unmarshal(data, out)
+	case "UpdateItem", "DeleteItem":
+		key := req.Key[DynamoKeyAttribute].S
+		current, ok := f.items[key]
+		if !ok || current["executionId"].S != req.ExpressionAttributeValues[":executionId"].S {
+			return conflict
+		}
+		if operation == "DeleteItem" {
+			delete(f.items, key)
+			return nil
+		}
+		current["orderId"] = req.ExpressionAttributeValues[":orderId"]
+		if order, ok := req.ExpressionAttributeValues[":order"]; ok {
+			current["order"] = order
+		}
+	}
+	return nil
+}
+
+var lockNow = time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+func newLock(executionID string) Lock {
+	return Lock{Key: "binance#BTC-USDT#2026-10-16", ExecutionID: executionID, At: lockNow, ExpiresAt: lockNow.Add(48 * time.Hour)}
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func() (Store, func(time.Time)){
+		"memory": func() (Store, func(time.Time)) {
+			s := NewMemoryStore()
+			s.now = func() time.Time { return lockNow }
+			return s, func(now time.Time) { s.now = func() time.Time { return now } }
+		},
+		"dynamodb": func() (Store, func(time.Time)) {
+			s := NewDynamoStore(&fakeDynamo{items: map[string]map[string]attributeValue{}}, "dca-locks")
+			s.now = func() time.Time { return lockNow }
+			return s, func(now time.Time) { s.now = func() time.Time { return now } }
+		},
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store, setNow := newStore()
+			ctx := context.Background()
+			if held, err := store.Acquire(ctx, newLock("run-1")); err != nil || held != nil {
+				t.Fatalf("Acquire() = %+v, %v, want the lock", held, err)
+			}
+
+			held, err := store.Acquire(ctx, newLock("run-2"))
+			if !errors.Is(err, ErrHeld) || held.ExecutionID != "run-1" || held.OrderID != "" {
+				t.Fatalf("second Acquire() = %+v, %v, want ErrHeld by run-1 without an order", held, err)
+			}
+
+			order := json.RawMessage(`{"id":"28457112"}`)
+			if err := store.Complete(ctx, held.Key, "run-1", "28457112", order); err != nil {
+				t.Fatal(err)
+			}
+			held, err = store.Acquire(ctx, newLock("run-3"))
+			if !errors.Is(err, ErrHeld) || held.OrderID != "28457112" || string(held.Order) != string(order) {
+				t.Errorf("Acquire() after Complete = %+v, %v, want the order of run-1", held, err)
+			}
+
+			// Only the run holding the lock releases it
+			if err := store.Release(ctx, held.Key, "run-3"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Acquire(ctx, newLock("run-4")); !errors.Is(err, ErrHeld) {
+				t.Errorf("Acquire() after another run's Release = %v, want ErrHeld", err)
+			}
+			if err := store.Release(ctx, held.Key, "run-1"); err != nil {
+				t.Fatal(err)
+			}
+			if held, err := store.Acquire(ctx, newLock("run-5")); err != nil {
+				t.Errorf("Acquire() after Release = %+v, %v, want the lock", held, err)
+			}
+
+			// An expired lock is taken over before it is deleted
+			setNow(lockNow.Add(49 * time.Hour))
+			later := newLock("run-6")
+			later.ExpiresAt = lockNow.Add(97 * time.Hour)
+			if held, err := store.Acquire(ctx, later); err != nil {
+				t.Errorf("Acquire() of an expired lock = %+v, %v, want the lock", held, err)
+			}
+		})
+	}
+}
+
+func TestDynamoStore_Unreachable(t *testing.T) {
+	fake := &fakeDynamo{err: errors.New("dial tcp: i/o timeout")}
+	held, err := NewDynamoStore(fake, "dca-locks").Acquire(context.Background(), newLock("run-1"))
+	if err == nil || errors.Is(err, ErrHeld) || held != nil {
+		t.Errorf("Acquire() = %+v, %v, want the transport error", held, err)
+	}
+}
+
+func TestDynamoStore_Item(t *testing.T) {
+	fake := &fakeDynamo{items: map[string]map[string]attributeValue{}}
+	store := NewDynamoStore(fake, "dca-locks")
+	if _, err := store.Acquire(context.Background(), newLock("run-1")); err != nil {
+		t.Fatal(err)
+	}
+	item := fake.items["binance#BTC-USDT#2026-10-16"]
+	if item["executionId"].S != "run-1" || item[DynamoTTLAttribute].N != strconv.FormatInt(lockNow.Add(48*time.Hour).Unix(), 10) {
+		t.Errorf("item = %+v, want run-1 expiring in 48h in epoch seconds", item)
+	}
+}
@@ -0,0 +1,118 @@
+// Package failure classifies why a run failed into a few stable codes, so
+// log metric filters and alarms can tell a payload someone is iterating on
+// from a trading failure.
+package failure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/withdrawal"
+)
+
+// Code is the class of a run failure
+type Code string
+
+const (
+	CodeConfigInvalid       Code = "CONFIG_INVALID"       // the payload or its settings are wrong
+	CodeCredentialsFailed   Code = "CREDENTIALS_FAILED"   // a secret could not be resolved or was refused
+	CodeExchangeUnavailable Code = "EXCHANGE_UNAVAILABLE" // the venue was down, in maintenance or timed out
+	CodeOrderRejected       Code = "ORDER_REJECTED"       // the exchange refused the order or another request
+	CodeCanceled            Code = "CANCELED"             // the run was interrupted, e.g. by Ctrl-C
+	CodeInternal            Code = "INTERNAL"             // anything else, including panics
+)
+
+// Error is a classified run failure. Its message starts with the code,
+// e.g. "CONFIG_INVALID: failed to parse payload: ...".
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap returns err as an *Error of its class; nil and errors that already
+// are an *Error are returned unchanged
+func Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Code: Classify(err), Err: err}
+}
+
+// marked carries a code chosen where the error arose
+type marked struct {
+	code Code
+	err  error
+}
+
+func (m *marked) Error() string { return m.err.Error() }
+func (m *marked) Unwrap() error { return m.err }
+
+// Mark records code as the class of err without changing its message. Use
+// it where the cause is known but not visible in the error's type, such as
+// a payload that failed to parse; nil stays nil.
+func Mark(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &marked{code: code, err: err}
+}
+
+// Classify returns the class of err: the code of an *Error or Mark in its
+// chain, or else the class its exchange errors imply. An order whose
+// outcome is unknown, or a failure after an order went through, is always
+// INTERNAL, even when a timeout caused it: the account needs a look, and
+// the alarm must not be mistaken for a passing outage. Otherwise a canceled
+// run is CANCELED, whatever the step it was interrupted in marked.
+func Classify(err error) Code {
+	var m *marked
+	var classified *Error
+	var httpErr *exchange.HTTPError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, exchange.ErrOrderOutcomeUnknown), errors.Is(err, exchange.ErrOrderPlaced):
+		return CodeInternal
+	case errors.Is(err, context.Canceled):
+		// Whatever it was doing, say fetching credentials, did not fail
+		return CodeCanceled
+	case errors.As(err, &classified):
+		return classified.Code
+	case errors.As(err, &m):
+		return m.code
+	case exchange.IsUnavailable(err):
+		return CodeExchangeUnavailable
+	case errors.As(err, &httpErr) && (httpErr.StatusCode == http.StatusUnauthorized || httpErr.StatusCode == http.StatusForbidden):
+		return CodeCredentialsFailed
+	case errors.As(err, &httpErr) && httpErr.StatusCode >= 400 && httpErr.StatusCode < 500:
+		return CodeOrderRejected
+	case errors.Is(err, exchange.ErrSymbolNotFound), errors.Is(err, withdrawal.ErrNotAllowed):
+		return CodeConfigInvalid
+	default:
+		return CodeInternal
+	}
+}
+
+// Details returns the error notification details of a failed run: its
+// class and its message, which carries the explanation of an exchange
+// error code the client recognized
+func Details(err error) []notify.Detail {
+	return []notify.Detail{
+		{Label: "Code", Value: string(Classify(err))},
+		{Label: "Error", Value: err.Error()},
+	}
+}
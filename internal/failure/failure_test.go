@@ -0,0 +1,128 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/withdrawal"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"nil", nil, ""},
+		{"unparsable payload", Mark(CodeConfigInvalid, fmt.Errorf("failed to parse payload: %w", errors.New("unknown mode"))), CodeConfigInvalid},
+		{"secret lookup", fmt.Errorf("failed to resolve api key: %w", Mark(CodeCredentialsFailed, errors.New("access denied"))), CodeCredentialsFailed},
+		{"maintenance", fmt.Errorf("failed to get balance: %w", &exchange.UnavailableError{Exchange: "okx", Reason: exchange.ReasonMaintenance}), CodeExchangeUnavailable},
+		{"timeout", fmt.Errorf("failed to get price: %w", context.DeadlineExceeded), CodeExchangeUnavailable},
+		{"server error", &exchange.HTTPError{StatusCode: 502, Body: "bad gateway"}, CodeExchangeUnavailable},
+		{"order lookup before placing", fmt.Errorf("failed to place order: %w for dca-1: %w: %w", exchange.ErrOrderLookup, exchange.ErrExchangeUnavailable, io.ErrUnexpectedEOF), CodeExchangeUnavailable},
+		{"bad key", fmt.Errorf("failed to get balance: %w", &exchange.HTTPError{StatusCode: 401, Body: "invalid API key"}), CodeCredentialsFailed},
+		{"forbidden", &exchange.HTTPError{StatusCode: 403, Body: "IP not whitelisted"}, CodeCredentialsFailed},
+		{"refused order", fmt.Errorf("failed to place order: %w", &exchange.HTTPError{StatusCode: 400, Body: "insufficient balance"}), CodeOrderRejected},
+		{"order timeout", fmt.Errorf("failed to place order: %w: %w", exchange.ErrOrderOutcomeUnknown, context.DeadlineExceeded), CodeInternal},
+		{"marked after an order", Mark(CodeOrderRejected, fmt.Errorf("second leg: %w", exchange.ErrOrderPlaced)), CodeInternal},
+		{"unknown symbol", fmt.Errorf("failed to get price: %w", exchange.ErrSymbolNotFound), CodeConfigInvalid},
+		{"address not allowed", fmt.Errorf("withdrawal check failed: %w", withdrawal.ErrNotAllowed), CodeConfigInvalid},
+		{"interrupted", fmt.Errorf("failed to get price: %w", context.Canceled), CodeCanceled},
+		{"interrupted secret lookup", Mark(CodeCredentialsFailed, fmt.Errorf("failed to resolve api key: %w", context.Canceled)), CodeCanceled},
+		{"interrupted order", fmt.Errorf("failed to place order: %w: %w", exchange.ErrOrderOutcomeUnknown, context.Canceled), CodeInternal},
+		{"anything else", errors.New("boom"), CodeInternal},
+		{"already classified", &Error{Code: CodeOrderRejected, Err: errors.New("boom")}, CodeOrderRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMark_KeepsMessage(t *testing.T) {
+	err := Mark(CodeConfigInvalid, exchange.ErrSymbolNotFound)
+	if err.Error() != exchange.ErrSymbolNotFound.Error() || !errors.Is(err, exchange.ErrSymbolNotFound) {
+		t.Errorf("Mark() = %v, want the error unchanged", err)
+	}
+	if Mark(CodeConfigInvalid, nil) != nil {
+		t.Error("Mark(nil) != nil")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	if Wrap(nil) != nil {
+		t.Fatal("Wrap(nil) != nil")
+	}
+
+	cause := &exchange.HTTPError{StatusCode: 401, Body: "invalid API key"}
+	err := Wrap(fmt.Errorf("failed to get balance: %w", cause))
+	if got, want := err.Error(), "CREDENTIALS_FAILED: failed to get balance: HTTP 401: invalid API key"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	var httpErr *exchange.HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Error("Wrap() hides the cause")
+	}
+
+	if again := Wrap(err); again != err || strings.Count(again.Error(), "CREDENTIALS_FAILED") != 1 {
+		t.Errorf("Wrap() twice = %v, want it unchanged", again)
+	}
+}
+
+// The error notification of a run shows the raw exchange error and, for
+// codes the client knows, what it means and how to fix it
+func TestDetails_ExplainsExchangeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		exchange string
+		status   int
+		body     string
+		want     []string // in the notification text
+	}{
+		{"binance bad key", "binance", 401, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`,
+			[]string{"Code: CREDENTIALS_FAILED", `"code":-2015`, "invalid API key, IP not whitelisted, or missing permission", "egress IP"}},
+		{"binance insufficient balance", "binance", 400, `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`,
+			[]string{"Code: ORDER_REJECTED", "-2010", "covers quoteAmount plus fees"}},
+		{"okx bad key", "okx", 401, `{"code":"50111","msg":"Invalid OK-ACCESS-KEY"}`,
+			[]string{"Invalid OK-ACCESS-KEY", "okx 50111: invalid API key"}},
+		{"coinbase bad signature", "coinbase", 401, `{"errors":[{"id":"authentication_error","message":"invalid signature"}]}`,
+			[]string{"invalid signature", "coinbase authentication_error: the API key or signature was refused"}},
+		{"kraken bad key", "kraken", 403, `{"error":["EAPI:Invalid key"]}`,
+			[]string{"kraken EAPI:Invalid key: invalid API key"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Wrap(fmt.Errorf("failed to get balance: %w", exchange.CheckResponse(tt.exchange, tt.status, []byte(tt.body))))
+			text, renderErr := notify.LogNotifier{}.Render(context.Background(), notify.Event{Type: notify.EventError, Details: Details(err)})
+			if renderErr != nil {
+				t.Fatal(renderErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("notification does not mention %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestDetails_UnknownCodeUnchanged(t *testing.T) {
+	body := `{"code":-9999,"msg":"Something new."}`
+	err := exchange.CheckResponse("binance", 400, []byte(body))
+	if _, ok := exchange.Explain(err); ok {
+		t.Errorf("Explain() found an explanation of %v", err)
+	}
+	details := Details(err)
+	if got, want := details[len(details)-1].Value, "HTTP 400: "+body; got != want {
+		t.Errorf("Error detail = %q, want the raw error %q", got, want)
+	}
+}
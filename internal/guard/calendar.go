@@ -0,0 +1,48 @@
+package guard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Calendar skips the run when now, in the strategy timezone, falls on one of
+// the configured skip weekdays or skip dates
+func Calendar(strategy config.DCAStrategy, now time.Time) (*Skip, error) {
+	if strategy.Calendar == nil {
+		return nil, nil
+	}
+
+	loc, err := strategy.Location()
+	if err != nil {
+		return nil, err
+	}
+	local := now.In(loc)
+
+	for _, name := range strategy.Calendar.SkipWeekdays {
+		day, err := config.ParseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		if local.Weekday() == day {
+			return &Skip{
+				Guard:  "calendar",
+				Reason: fmt.Sprintf("%s is a skipped weekday (%s)", strings.ToUpper(name), local.Format("2006-01-02 15:04 MST")),
+			}, nil
+		}
+	}
+
+	today := local.Format(config.DateLayout)
+	for _, date := range strategy.Calendar.SkipDates {
+		if date == today {
+			return &Skip{
+				Guard:  "calendar",
+				Reason: fmt.Sprintf("%s is a skipped date", today),
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,103 @@
+package guard
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestCalendar(t *testing.T) {
+	weekend := &config.CalendarConfig{SkipWeekdays: []string{"SAT", "sun"}}
+
+	tests := []struct {
+		name     string
+		timezone string
+		calendar *config.CalendarConfig
+		now      string // RFC3339 instant
+		skipped  bool
+	}{
+		{
+			name:    "no_calendar",
+			now:     "2025-06-07T12:00:00Z",
+			skipped: false,
+		},
+		{
+			name:     "utc_saturday",
+			calendar: weekend,
+			now:      "2025-06-07T12:00:00Z",
+			skipped:  true,
+		},
+		{
+			name:     "utc_friday",
+			calendar: weekend,
+			now:      "2025-06-06T12:00:00Z",
+			skipped:  false,
+		},
+		{
+			// 21:30 UTC is 23:30 Friday in Berlin (UTC+2 in summer)
+			name:     "local_friday_late_evening",
+			timezone: "Europe/Berlin",
+			calendar: weekend,
+			now:      "2025-06-06T21:30:00Z",
+			skipped:  false,
+		},
+		{
+			// 22:30 UTC is 00:30 Saturday in Berlin, while still Friday in UTC
+			name:     "local_saturday_just_after_midnight",
+			timezone: "Europe/Berlin",
+			calendar: weekend,
+			now:      "2025-06-06T22:30:00Z",
+			skipped:  true,
+		},
+		{
+			// 03:30 UTC Saturday is still 23:30 Friday in New York
+			name:     "utc_saturday_but_local_friday",
+			timezone: "America/New_York",
+			calendar: weekend,
+			now:      "2025-06-07T03:30:00Z",
+			skipped:  false,
+		},
+		{
+			name:     "skip_date",
+			calendar: &config.CalendarConfig{SkipDates: []string{"2025-12-31"}},
+			now:      "2025-12-31T08:00:00Z",
+			skipped:  true,
+		},
+		{
+			name:     "skip_date_in_timezone",
+			timezone: "Asia/Tokyo",
+			calendar: &config.CalendarConfig{SkipDates: []string{"2026-01-01"}},
+			now:      "2025-12-31T16:00:00Z", // 01:00 on Jan 1st in Tokyo
+			skipped:  true,
+		},
+		{
+			name:     "other_date",
+			calendar: &config.CalendarConfig{SkipDates: []string{"2025-12-31"}},
+			now:      "2025-12-30T08:00:00Z",
+			skipped:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			strategy := config.DCAStrategy{Timezone: tt.timezone, Calendar: tt.calendar}
+
+			skip, err := Calendar(strategy, now)
+			if err != nil {
+				t.Fatalf("Calendar() error = %v", err)
+			}
+			if (skip != nil) != tt.skipped {
+				t.Fatalf("Calendar() skip = %v, want skipped=%v", skip, tt.skipped)
+			}
+			if skip != nil && (skip.Guard != "calendar" || skip.Reason == "") {
+				t.Errorf("unexpected skip %+v", skip)
+			}
+		})
+	}
+}
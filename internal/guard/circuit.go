@@ -0,0 +1,65 @@
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// ErrNoCandles is returned by CircuitBreaker when no usable candle opened
+// within the window
+var ErrNoCandles = errors.New("no candles in the window")
+
+// CircuitBreaker skips a buy while the price of base has moved more than
+// cfg's limit within its window before now (strategy.circuitBreaker). The
+// move is the swing between the highest high and the lowest low of the
+// candles opened in the window, relative to whichever came first, so a
+// crash that has since recovered still trips it. Missing candles are
+// skipped over and candles with non-positive prices ignored as bad data;
+// the move is then measured over the span the remaining candles cover.
+func CircuitBreaker(cfg *config.CircuitBreakerConfig, base string, klines []exchange.Kline, now time.Time) (*Skip, error) {
+	start := now.Add(-cfg.Window())
+	var high, low exchange.Kline
+	var first time.Time
+	for _, k := range klines {
+		if k.OpenTime.Before(start) || !k.OpenTime.Before(now) || !usable(k) {
+			continue
+		}
+		if first.IsZero() || k.OpenTime.Before(first) {
+			first = k.OpenTime
+		}
+		if high.High.IsZero() || k.High.GreaterThan(high.High) {
+			high = k
+		}
+		if low.Low.IsZero() || k.Low.LessThan(low.Low) {
+			low = k
+		}
+	}
+	if first.IsZero() {
+		return nil, fmt.Errorf("%w of %dm before %s", ErrNoCandles, cfg.WindowMinutes, now.UTC().Format(time.RFC3339))
+	}
+
+	// A rise when the low came first, otherwise a fall from the high
+	from := high.High
+	if low.OpenTime.Before(high.OpenTime) {
+		from = low.Low
+	}
+	move := money.PercentOf(high.High.Sub(low.Low), from)
+	if move.Cmp(cfg.MaxMove()) <= 0 {
+		return nil, nil
+	}
+	return &Skip{
+		Guard: "circuitBreaker",
+		Reason: fmt.Sprintf("%s moved %s%% in %dm, more than the %s%% limit",
+			base, move.Round(1).String(), int(now.Sub(first).Round(time.Minute).Minutes()), cfg.MaxMove().String()),
+	}, nil
+}
+
+// usable reports whether all of k's prices are positive
+func usable(k exchange.Kline) bool {
+	return k.Open.IsPositive() && k.High.IsPositive() && k.Low.IsPositive() && k.Close.IsPositive()
+}
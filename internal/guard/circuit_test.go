@@ -0,0 +1,77 @@
+package guard
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+func loadCandles(t *testing.T, name string) []exchange.Kline {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var klines []exchange.Kline
+	if err := json.Unmarshal(data, &klines); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return klines
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		candles string
+		maxMove string
+		window  int
+		want    string // skip reason, empty when the breaker holds
+	}{
+		{name: "calm", candles: "circuit_calm.json", maxMove: "1", window: 60},
+		{name: "flash_crash", candles: "circuit_crash.json", maxMove: "10", window: 60, want: "BTC moved 14% in 60m, more than the 10% limit"},
+		{name: "crash_within_limit", candles: "circuit_crash.json", maxMove: "15", window: 60},
+		{name: "crash_before_window", candles: "circuit_crash.json", maxMove: "10", window: 10},
+		{name: "gaps", candles: "circuit_gaps.json", maxMove: "10", window: 60},
+		{name: "gaps_tripped", candles: "circuit_gaps.json", maxMove: "4", window: 60, want: "BTC moved 5% in 45m, more than the 4% limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.CircuitBreakerConfig{MaxMovePercent: tt.maxMove, WindowMinutes: tt.window}
+			skip, err := CircuitBreaker(cfg, "BTC", loadCandles(t, tt.candles), now)
+			if err != nil {
+				t.Fatalf("CircuitBreaker() error = %v", err)
+			}
+			if tt.want == "" {
+				if skip != nil {
+					t.Errorf("CircuitBreaker() = %v, want no skip", skip)
+				}
+				return
+			}
+			if skip == nil || skip.Guard != "circuitBreaker" || skip.Reason != tt.want {
+				t.Errorf("CircuitBreaker() = %v, want %q", skip, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_NoCandles(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	cfg := &config.CircuitBreakerConfig{MaxMovePercent: "10", WindowMinutes: 30}
+
+	// The fixture's first candle opened half an hour before the window
+	klines := loadCandles(t, "circuit_gaps.json")
+	if _, err := CircuitBreaker(cfg, "BTC", klines[:1], now); !errors.Is(err, ErrNoCandles) {
+		t.Errorf("CircuitBreaker() error = %v, want ErrNoCandles", err)
+	}
+	glitch := []exchange.Kline{{OpenTime: now.Add(-5 * time.Minute)}}
+	if _, err := CircuitBreaker(cfg, "BTC", glitch, now); !errors.Is(err, ErrNoCandles) {
+		t.Errorf("CircuitBreaker() error = %v, want ErrNoCandles for a zero candle", err)
+	}
+}
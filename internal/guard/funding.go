@@ -0,0 +1,84 @@
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// ErrInsufficientFunds is returned by Funding when no pending deposit
+// would cover the order
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// Funding checks the free balance of asset covers needed before a buy
+// (strategy.checkPendingDeposits). When it does not but pending deposits
+// will, the run is skipped until they settle; otherwise the shortfall is
+// returned as ErrInsufficientFunds.
+func Funding(asset string, free, needed decimal.Decimal, deposits []exchange.Deposit, now time.Time) (*Skip, error) {
+	if free.GreaterThanOrEqual(needed) {
+		return nil, nil
+	}
+	short := fmt.Sprintf("%s %s free, %s needed", free.String(), asset, needed.String())
+	if len(deposits) == 0 {
+		return nil, fmt.Errorf("%w: %s and no deposit is pending", ErrInsufficientFunds, short)
+	}
+
+	pending := decimal.Zero
+	var expected time.Time
+	known := true
+	for _, d := range deposits {
+		pending = pending.Add(d.Amount)
+		if d.ExpectedAt.IsZero() {
+			known = false
+		} else if d.ExpectedAt.After(expected) {
+			expected = d.ExpectedAt
+		}
+	}
+	what := fmt.Sprintf("deposit of %s %s pending", pending.String(), asset)
+	if len(deposits) > 1 {
+		what = fmt.Sprintf("%d deposits of %s %s pending", len(deposits), pending.String(), asset)
+	}
+	if free.Add(pending).LessThan(needed) {
+		return nil, fmt.Errorf("%w: %s; the %s would not cover it", ErrInsufficientFunds, short, what)
+	}
+	if known {
+		what += ", " + settlesIn(expected, now)
+	}
+	return &Skip{
+		Guard:  "funding",
+		Reason: fmt.Sprintf("%s; %s, the run should succeed once it settles", short, what),
+	}, nil
+}
+
+// settlesIn describes when a deposit expected at is credited, as of now
+func settlesIn(at time.Time, now time.Time) string {
+	d := at.Sub(now)
+	switch {
+	case d <= 0:
+		return "expected to have settled by now"
+	case d < 24*time.Hour:
+		return "expected to settle within a day"
+	}
+	days := int((d + 24*time.Hour - 1) / (24 * time.Hour))
+	return fmt.Sprintf("expected to settle in %d days", days)
+}
+
+// TopUp returns how much of asset strategy.autoTransfer moves from the
+// funding account before a buy: the shortfall of free against needed plus
+// buffer of it, as far as funding covers it. It is zero when free covers the
+// buy, and ErrInsufficientFunds when free and funding together fall short.
+func TopUp(asset string, free, funding, needed decimal.Decimal, buffer money.Percent) (decimal.Decimal, error) {
+	short := needed.Sub(free)
+	if !short.IsPositive() {
+		return decimal.Zero, nil
+	}
+	if funding.LessThan(short) {
+		return decimal.Zero, fmt.Errorf("%w: %s %s free and %s in the funding account, %s needed", ErrInsufficientFunds, free.String(), asset, funding.String(), needed.String())
+	}
+	buffered := buffer.OffsetAbove(short)
+	return decimal.Min(buffered, funding), nil
+}
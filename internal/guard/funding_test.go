@@ -0,0 +1,85 @@
+package guard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+func TestFunding(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	sepa := exchange.Deposit{Asset: "EUR", Amount: decimal.RequireFromString("200"), Method: "SEPA", CreatedAt: now.Add(-24 * time.Hour), ExpectedAt: now.Add(36 * time.Hour)}
+	unknown := exchange.Deposit{Asset: "EUR", Amount: decimal.RequireFromString("20"), CreatedAt: now.Add(-time.Hour)}
+	soon := sepa
+	soon.ExpectedAt = now.Add(3 * time.Hour)
+
+	tests := []struct {
+		name     string
+		free     string
+		deposits []exchange.Deposit
+		skip     string // substring of the skip reason
+		err      string // substring of the error
+	}{
+		{name: "enough", free: "50"},
+		{name: "no_deposit", free: "12.4", err: "12.4 EUR free, 50 needed and no deposit is pending"},
+		{name: "pending", free: "12.4", deposits: []exchange.Deposit{sepa}, skip: "12.4 EUR free, 50 needed; deposit of 200 EUR pending, expected to settle in 2 days, the run should succeed once it settles"},
+		{name: "within_a_day", free: "0", deposits: []exchange.Deposit{soon}, skip: "expected to settle within a day"},
+		{name: "several", free: "0", deposits: []exchange.Deposit{sepa, unknown}, skip: "2 deposits of 220 EUR pending, the run"},
+		{name: "too_small", free: "12.4", deposits: []exchange.Deposit{unknown}, err: "the deposit of 20 EUR pending would not cover it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skip, err := Funding("EUR", decimal.RequireFromString(tt.free), decimal.RequireFromString("50"), tt.deposits, now)
+			if tt.err != "" {
+				if !errors.Is(err, ErrInsufficientFunds) || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Funding() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Funding() error = %v", err)
+			}
+			if (skip != nil) != (tt.skip != "") {
+				t.Fatalf("Funding() = %v, want skipped %v", skip, tt.skip != "")
+			}
+			if skip != nil && (skip.Guard != "funding" || !strings.Contains(skip.Reason, tt.skip)) {
+				t.Errorf("Funding() = %v, want a funding skip with %q", skip, tt.skip)
+			}
+		})
+	}
+}
+
+func TestTopUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		free    string
+		funding string
+		want    string
+		err     string
+	}{
+		{name: "enough", free: "50", funding: "0", want: "0"},
+		{name: "buffered", free: "10", funding: "500", want: "40.4"},
+		{name: "buffer_capped", free: "10", funding: "40.2", want: "40.2"},
+		{name: "exact", free: "10", funding: "40", want: "40"},
+		{name: "insufficient_everywhere", free: "10", funding: "25", err: "10 USDT free and 25 in the funding account, 50 needed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TopUp("USDT", decimal.RequireFromString(tt.free), decimal.RequireFromString(tt.funding), decimal.RequireFromString("50"), money.NewPercent(decimal.NewFromInt(1)))
+			if tt.err != "" {
+				if !errors.Is(err, ErrInsufficientFunds) || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("TopUp() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("TopUp() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
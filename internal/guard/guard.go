@@ -0,0 +1,15 @@
+// Package guard holds the pre-trade checks that can decide a run should not
+// place an order. A tripped guard is not an error: the run ends as skipped.
+package guard
+
+import "fmt"
+
+// Skip describes why a run was skipped
+type Skip struct {
+	Guard  string `json:"guard"`  // which guard tripped, e.g. "calendar"
+	Reason string `json:"reason"` // human readable explanation
+}
+
+func (s *Skip) String() string {
+	return fmt.Sprintf("skipped (%s): %s", s.Guard, s.Reason)
+}
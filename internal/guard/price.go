@@ -0,0 +1,45 @@
+package guard
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// MaxPrice skips a buy while the last price is above the strategy's
+// maxPrice ceiling; a price at the ceiling buys
+func MaxPrice(strategy config.DCAStrategy, price decimal.Decimal) (*Skip, error) {
+	if strategy.MaxPrice == "" {
+		return nil, nil
+	}
+	ceiling, err := decimal.NewFromString(strategy.MaxPrice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maxPrice: %w", err)
+	}
+	if price.GreaterThan(ceiling) {
+		return &Skip{
+			Guard:  "maxPrice",
+			Reason: fmt.Sprintf("%s at %s above maxPrice %s", strategy.Symbol, price.String(), ceiling.String()),
+		}, nil
+	}
+	return nil, nil
+}
+
+// MinPrice skips a sell while price is below the strategy's minPrice floor
+func MinPrice(strategy config.DCAStrategy, price decimal.Decimal) (*Skip, error) {
+	if strategy.MinPrice == "" {
+		return nil, nil
+	}
+	floor, err := decimal.NewFromString(strategy.MinPrice)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minPrice: %w", err)
+	}
+	if price.LessThan(floor) {
+		return &Skip{
+			Guard:  "minPrice",
+			Reason: fmt.Sprintf("price %s is below the %s floor", price.String(), floor.String()),
+		}, nil
+	}
+	return nil, nil
+}
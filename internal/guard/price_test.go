@@ -0,0 +1,68 @@
+package guard
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestMinPrice(t *testing.T) {
+	strategy := config.DCAStrategy{Side: config.SideSell, MinPrice: "60000"}
+	tests := []struct {
+		name     string
+		strategy config.DCAStrategy
+		price    string
+		skipped  bool
+	}{
+		{"no_floor", config.DCAStrategy{Side: config.SideSell}, "1", false},
+		{"below_floor", strategy, "59999.99", true},
+		{"at_floor", strategy, "60000", false},
+		{"above_floor", strategy, "71000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skip, err := MinPrice(tt.strategy, decimal.RequireFromString(tt.price))
+			if err != nil {
+				t.Fatalf("MinPrice() error = %v", err)
+			}
+			if (skip != nil) != tt.skipped {
+				t.Errorf("MinPrice() = %v, want skipped %v", skip, tt.skipped)
+			}
+			if skip != nil && skip.Guard != "minPrice" {
+				t.Errorf("Guard = %q, want minPrice", skip.Guard)
+			}
+		})
+	}
+}
+
+func TestMaxPrice(t *testing.T) {
+	strategy := config.DCAStrategy{Symbol: "BTC-USDT", MaxPrice: "65000"}
+	tests := []struct {
+		name     string
+		strategy config.DCAStrategy
+		price    string
+		skipped  bool
+	}{
+		{"no_ceiling", config.DCAStrategy{Symbol: "BTC-USDT"}, "1000000", false},
+		{"above_ceiling", strategy, "71234", true},
+		{"just_above_ceiling", strategy, "65000.00000001", true},
+		{"at_ceiling", strategy, "65000.00", false},
+		{"below_ceiling", strategy, "64999.99", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skip, err := MaxPrice(tt.strategy, decimal.RequireFromString(tt.price))
+			if err != nil {
+				t.Fatalf("MaxPrice() error = %v", err)
+			}
+			if (skip != nil) != tt.skipped {
+				t.Errorf("MaxPrice() = %v, want skipped %v", skip, tt.skipped)
+			}
+			if skip != nil && (skip.Guard != "maxPrice" || !strings.HasPrefix(skip.Reason, "BTC-USDT at "+tt.price+" above maxPrice 65000")) {
+				t.Errorf("MaxPrice() = %+v, want the maxPrice guard naming the price and ceiling", skip)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package guard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Validity fails the run at or after the payload's expiresAt, so a
+// temporary payload left in place does not keep trading, and skips it
+// before its notBefore. Both are instants, so the comparison does not
+// depend on a timezone; the strategy timezone only renders the date.
+func Validity(payload *config.DCAPayload, now time.Time) (*Skip, error) {
+	loc, err := payload.Strategy.Location()
+	if err != nil {
+		return nil, err
+	}
+	if expires := payload.Expires(); !expires.IsZero() && !now.Before(expires) {
+		return nil, fmt.Errorf("payload expired on %s — refusing to trade on stale configuration", expires.In(loc).Format(config.DateLayout))
+	}
+	if from := payload.ValidFrom(); !from.IsZero() && now.Before(from) {
+		return &Skip{
+			Guard:  "validity",
+			Reason: fmt.Sprintf("payload not valid before %s", from.In(loc).Format("2006-01-02 15:04 MST")),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package guard
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestValidity(t *testing.T) {
+	tests := []struct {
+		name                 string
+		notBefore, expiresAt string
+		now                  string // RFC3339 instant; the strategy timezone is Europe/Berlin
+		wantErr              string
+		wantSkip             string
+	}{
+		{"unset", "", "", "2025-06-02T07:00:00Z", "", ""},
+		{"before_expiry", "", "2025-05-01T00:00:00Z", "2025-04-30T23:59:59Z", "", ""},
+		{"at_expiry", "", "2025-05-01T00:00:00Z", "2025-05-01T00:00:00Z", "payload expired on 2025-05-01 — refusing to trade on stale configuration", ""},
+		{"after_expiry", "", "2025-05-01T00:00:00Z", "2025-07-01T07:00:00Z", "payload expired on 2025-05-01 — refusing to trade on stale configuration", ""},
+		// 23:30 UTC is already the next day in Berlin
+		{"expiry_date_in_timezone", "", "2025-04-30T23:30:00Z", "2025-05-02T00:00:00Z", "payload expired on 2025-05-01 — refusing to trade on stale configuration", ""},
+		{"offset_expiry", "", "2025-05-01T02:00:00+02:00", "2025-05-01T00:00:00Z", "payload expired on 2025-05-01 — refusing to trade on stale configuration", ""},
+		{"before_start", "2025-05-01T00:00:00Z", "", "2025-04-30T23:59:59Z", "", "payload not valid before 2025-05-01 02:00 CEST"},
+		{"at_start", "2025-05-01T00:00:00Z", "", "2025-05-01T00:00:00Z", "", ""},
+		{"inside_window", "2025-05-01T00:00:00Z", "2025-05-08T00:00:00Z", "2025-05-04T07:00:00Z", "", ""},
+		{"after_window", "2025-05-01T00:00:00Z", "2025-05-08T00:00:00Z", "2025-05-08T00:00:00Z", "payload expired on 2025-05-08 — refusing to trade on stale configuration", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &config.DCAPayload{
+				NotBefore: tt.notBefore,
+				ExpiresAt: tt.expiresAt,
+				Strategy:  config.DCAStrategy{Timezone: "Europe/Berlin"},
+			}
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			skip, err := Validity(payload, now)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Validity() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validity() error = %v", err)
+			}
+			switch {
+			case tt.wantSkip == "" && skip != nil:
+				t.Errorf("Validity() = %v, want the run to go ahead", skip)
+			case tt.wantSkip != "" && (skip == nil || skip.Reason != tt.wantSkip):
+				t.Errorf("Validity() = %v, want skip %q", skip, tt.wantSkip)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package guard
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Window skips the run when now falls outside the strategy's execution
+// window: outside its after and before times of day in the strategy
+// timezone, or more than maxDelayMinutes after the schedule last fired.
+// The delay is measured in elapsed time, so a daylight saving change
+// between the fire time and now does not stretch or shrink it.
+func Window(strategy config.DCAStrategy, now time.Time) (*Skip, error) {
+	w := strategy.ExecutionWindow
+	if w == nil {
+		return nil, nil
+	}
+	loc, err := strategy.Location()
+	if err != nil {
+		return nil, err
+	}
+	local := now.In(loc)
+
+	if w.MaxDelayMinutes > 0 {
+		schedule, err := strategy.ParsedSchedule()
+		if err != nil {
+			return nil, err
+		}
+		scheduled, ok := schedule.Prev(local)
+		if ok && local.Sub(scheduled) <= time.Duration(w.MaxDelayMinutes)*time.Minute {
+			return nil, nil
+		}
+		if !ok {
+			return windowSkip(fmt.Sprintf("never scheduled, received %s", local.Format(config.TimeOfDayLayout))), nil
+		}
+		return windowSkip(fmt.Sprintf("scheduled %s, received %s", clock(scheduled, local), clock(local, scheduled))), nil
+	}
+
+	// Times of day are wall-clock times, which on daylight saving days
+	// differ from the time elapsed since midnight
+	hour, min, sec := local.Clock()
+	tod := time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute + time.Duration(sec)*time.Second
+	after, before := time.Duration(0), 24*time.Hour
+	if w.After != "" {
+		if after, err = config.ParseTimeOfDay(w.After); err != nil {
+			return nil, err
+		}
+	}
+	if w.Before != "" {
+		if before, err = config.ParseTimeOfDay(w.Before); err != nil {
+			return nil, err
+		}
+	}
+	inside := tod >= after && tod < before
+	if before <= after {
+		// The window wraps past midnight, e.g. 22:00 to 02:00
+		inside = tod >= after || tod < before
+	}
+	if inside {
+		return nil, nil
+	}
+	return windowSkip(fmt.Sprintf("window %s-%s, received %s", orDefault(w.After, "00:00"), orDefault(w.Before, "24:00"), local.Format(config.TimeOfDayLayout))), nil
+}
+
+func windowSkip(detail string) *Skip {
+	return &Skip{Guard: "executionWindow", Reason: fmt.Sprintf("invocation outside execution window (%s)", detail)}
+}
+
+// clock renders t as a time of day, with its date when other falls on a
+// different day
+func clock(t, other time.Time) string {
+	if t.Format(config.DateLayout) != other.Format(config.DateLayout) {
+		return t.Format(config.DateLayout + " " + config.TimeOfDayLayout)
+	}
+	return t.Format(config.TimeOfDayLayout)
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package guard
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestWindow(t *testing.T) {
+	delay := func(minutes int) *config.ExecutionWindowConfig {
+		return &config.ExecutionWindowConfig{MaxDelayMinutes: minutes}
+	}
+	daytime := &config.ExecutionWindowConfig{After: "08:30", Before: "10:00"}
+	overnight := &config.ExecutionWindowConfig{After: "22:00", Before: "02:00"}
+
+	tests := []struct {
+		name     string
+		schedule string
+		window   *config.ExecutionWindowConfig
+		now      string // RFC3339 instant; the strategy timezone is Europe/Berlin
+		reason   string // empty when the run goes ahead
+	}{
+		{"no_window", "0 9 * * *", nil, "2025-06-02T11:42:00Z", ""},
+		{"on_time", "0 9 * * *", delay(15), "2025-06-02T07:00:30Z", ""},
+		{"within_delay", "0 9 * * *", delay(15), "2025-06-02T07:15:00Z", ""},
+		{"late", "0 9 * * *", delay(15), "2025-06-02T11:42:00Z", "invocation outside execution window (scheduled 09:00, received 13:42)"},
+		{"after_midnight", "55 23 * * *", delay(15), "2025-06-01T22:05:00Z", ""},
+		{"late_after_midnight", "55 23 * * *", delay(15), "2025-06-02T00:00:00Z", "invocation outside execution window (scheduled 2025-06-01 23:55, received 2025-06-02 02:00)"},
+		// 01:00 CET to 03:30 CEST is 2.5 hours on the clock but 90 minutes elapsed
+		{"dst_spring_forward", "0 1 * * *", delay(120), "2025-03-30T01:30:00Z", ""},
+		// 01:00 CEST to 02:30 CET is 90 minutes on the clock but 2.5 hours elapsed
+		{"dst_fall_back", "0 1 * * *", delay(120), "2025-10-26T01:30:00Z", "invocation outside execution window (scheduled 01:00, received 02:30)"},
+		{"inside_hours", "", daytime, "2025-06-02T07:00:00Z", ""},
+		{"before_hours", "", daytime, "2025-06-02T06:29:00Z", "invocation outside execution window (window 08:30-10:00, received 08:29)"},
+		{"at_before", "", daytime, "2025-06-02T08:00:00Z", "invocation outside execution window (window 08:30-10:00, received 10:00)"},
+		{"overnight_evening", "", overnight, "2025-06-02T21:00:00Z", ""},
+		{"overnight_morning", "", overnight, "2025-06-02T23:59:00Z", ""},
+		{"overnight_after", "", overnight, "2025-06-03T00:00:00Z", "invocation outside execution window (window 22:00-02:00, received 02:00)"},
+		{"overnight_noon", "", overnight, "2025-06-02T10:00:00Z", "invocation outside execution window (window 22:00-02:00, received 12:00)"},
+		{"after_only", "", &config.ExecutionWindowConfig{After: "20:00"}, "2025-06-02T17:00:00Z", "invocation outside execution window (window 20:00-24:00, received 19:00)"},
+		{"before_only", "", &config.ExecutionWindowConfig{Before: "06:00"}, "2025-06-02T03:59:00Z", ""},
+		// 03:30 on the clock, although only 02:30 has elapsed since midnight
+		{"hours_on_dst_day", "", &config.ExecutionWindowConfig{After: "03:00", Before: "04:00"}, "2025-03-30T01:30:00Z", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			strategy := config.DCAStrategy{Timezone: "Europe/Berlin", Schedule: tt.schedule, ExecutionWindow: tt.window}
+
+			skip, err := Window(strategy, now)
+			if err != nil {
+				t.Fatalf("Window() error = %v", err)
+			}
+			var reason string
+			if skip != nil {
+				reason = skip.Reason
+				if skip.Guard != "executionWindow" {
+					t.Errorf("Guard = %q, want executionWindow", skip.Guard)
+				}
+			}
+			if reason != tt.reason {
+				t.Errorf("Window() reason = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
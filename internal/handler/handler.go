@@ -0,0 +1,374 @@
+// Package handler provides the invocation Handler type and composable
+// middleware for cross-cutting concerns (panic recovery, logging, timeouts,
+// timing, cost metering, error notification and classification, source
+// detection, envelope unwrapping), so the business function stays small and each concern can be
+// tested on its own.
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Handler processes a single raw invocation event
+type Handler func(ctx context.Context, event json.RawMessage) error
+
+// Middleware wraps a Handler with additional behavior
+type Middleware func(next Handler) Handler
+
+// Chain wraps h with the given middleware. The first middleware listed is
+// the outermost: Chain(h, A, B) runs A, then B, then h, and errors
+// propagate back out through B and then A.
+func Chain(h Handler, middleware ...Middleware) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// PanicError is returned by Recover when the wrapped handler panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover converts a panic in the wrapped handler into a *PanicError.
+// Middleware listed before it in Chain (logging, notification) observe the
+// panic as a regular error; middleware listed after it are unwound by it.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &PanicError{Value: r, Stack: debug.Stack()}
+					log.Printf("💥 Recovered from panic: %v\n%s", r, err.(*PanicError).Stack)
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
+
+// ExecutionID assigns a fresh execution ID to the run, stores it in the
+// context and prefixes every log line with it for the run's duration
+func ExecutionID() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			executionID := run.NewID()
+			ctx = run.WithID(ctx, executionID)
+			log.SetPrefix("[" + executionID + "] ")
+			defer log.SetPrefix("")
+
+			log.Printf("🆔 Execution ID: %s", executionID)
+			return next(ctx, event)
+		}
+	}
+}
+
+// NotificationScope gives each invocation a slot for its notification
+// dispatcher. The handler fills it once the payload is parsed; middleware
+// listed after this one (error notification) can then read it.
+func NotificationScope() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			return next(notify.WithScope(ctx), event)
+		}
+	}
+}
+
+// FlushNotifications sends the notifications held for the invocation's
+// digest once the wrapped handler returns, or panics. It must be listed
+// after NotificationScope and before NotifyOnError, so the error event of a
+// failed run is part of the digest.
+func FlushNotifications() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			defer func() {
+				if d := notify.FromContext(ctx); d != nil {
+					// An interrupted run still sends what it held
+					ctx, cancel := run.WrapUp(ctx)
+					defer cancel()
+					// Delivery failures are logged as run warnings
+					d.Flush(ctx)
+				}
+			}()
+			return next(ctx, event)
+		}
+	}
+}
+
+// Log logs the start, duration and outcome of each invocation
+func Log() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			start := time.Now()
+			log.Printf("▶️ Invocation started")
+
+			err := next(ctx, event)
+
+			if err != nil {
+				log.Printf("❌ Invocation failed after %s (code=%s): %v", time.Since(start).Round(time.Millisecond), failure.Classify(err), err)
+			} else {
+				log.Printf("🏁 Invocation finished in %s", time.Since(start).Round(time.Millisecond))
+			}
+			return err
+		}
+	}
+}
+
+// Classify wraps the error of a failed invocation in a *failure.Error, so
+// its message starts with a stable class code alarms can match. List it
+// after Log and NotifyOnError, which then report the code, and before
+// Recover, so panics are classified too.
+func Classify() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			return failure.Wrap(next(ctx, event))
+		}
+	}
+}
+
+// RecordTiming gives each invocation a timing recorder, so the handler and
+// everything it calls can record spans. An invocation whose entrypoint
+// already gave it one keeps it.
+func RecordTiming() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			if run.RecorderFrom(ctx) != nil {
+				return next(ctx, event)
+			}
+			return next(run.WithRecorder(ctx, run.NewRecorder()), event)
+		}
+	}
+}
+
+// MeterCosts gives each invocation a cost meter, so the AWS requests of
+// everything it calls are counted for the run's cost estimate
+func MeterCosts() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			return next(cost.WithMeter(ctx, cost.NewMeter()), event)
+		}
+	}
+}
+
+// CollectWarnings gives each invocation a warnings collector. Once the
+// wrapped handler succeeds, warnings recorded in a strict-mode run (see
+// run.Warnings.SetStrict) fail the invocation.
+func CollectWarnings() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			warnings := &run.Warnings{}
+			return warnings.Promote(next(run.WithWarnings(ctx, warnings), event))
+		}
+	}
+}
+
+// Timeout bounds the wrapped handler with a context deadline. A zero or
+// negative duration leaves the context untouched.
+func Timeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			if d <= 0 {
+				return next(ctx, event)
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, event)
+		}
+	}
+}
+
+// ErrorNotifier is called with the failure of an invocation
+type ErrorNotifier func(ctx context.Context, err error)
+
+// NotifyOnError calls notify when the wrapped handler returns an error,
+// with a context that is live even when the run's was canceled. The error
+// is still returned unchanged.
+func NotifyOnError(notify ErrorNotifier) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			err := next(ctx, event)
+			if err != nil {
+				// A run that was interrupted is still reported
+				ctx, cancel := run.WrapUp(ctx)
+				defer cancel()
+				notify(ctx, err)
+			}
+			return err
+		}
+	}
+}
+
+// Unwrapper extracts the DCA payload from a raw invocation event
+type Unwrapper func(ctx context.Context, event json.RawMessage) (json.RawMessage, error)
+
+// UnwrapEnvelope replaces the event with the payload extracted by unwrap
+// before calling the wrapped handler
+func UnwrapEnvelope(unwrap Unwrapper) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			spanCtx, end := run.StartSpan(ctx, "payload.unwrap")
+			payload, err := unwrap(spanCtx, event)
+			end()
+			if err != nil {
+				return fmt.Errorf("failed to unwrap event: %w", err)
+			}
+			return next(ctx, payload)
+		}
+	}
+}
+
+// EventBridgeUnwrapper extracts the "detail" of an EventBridge event and
+// passes any other event through unchanged
+func EventBridgeUnwrapper(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+	return EventBridgePathUnwrapper("detail")(ctx, event)
+}
+
+// EventBridgePathUnwrapper extracts the payload at path of an EventBridge
+// event, its keys separated by dots, e.g. "detail" or "detail.payload",
+// and passes any other event through unchanged. A payload written as a
+// JSON string, as input transformers often do, is decoded first.
+func EventBridgePathUnwrapper(path string) Unwrapper {
+	return func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+		payload := event
+		if isEventBridgeEnvelope(event) {
+			var err error
+			if payload, err = lookupPath(event, path); err != nil {
+				return nil, fmt.Errorf("EventBridge event: %w", err)
+			}
+		}
+		return decodeStringPayload(payload)
+	}
+}
+
+// isEventBridgeEnvelope reports whether event is an EventBridge event,
+// an object with "detail-type" and "source" keys
+func isEventBridgeEnvelope(event json.RawMessage) bool {
+	var envelope struct {
+		DetailType *string `json:"detail-type"`
+		Source     *string `json:"source"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil {
+		return false
+	}
+	return envelope.DetailType != nil && envelope.Source != nil
+}
+
+// lookupPath returns the value at the dotted path of the JSON object in
+// data
+func lookupPath(data json.RawMessage, path string) (json.RawMessage, error) {
+	value := data
+	for _, key := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(value, &obj); err != nil || obj == nil {
+			return nil, fmt.Errorf("%s: not an object", path)
+		}
+		var ok bool
+		if value, ok = obj[key]; !ok || string(value) == "null" {
+			return nil, fmt.Errorf("no payload at %s", path)
+		}
+	}
+	return value, nil
+}
+
+// decodeStringPayload decodes a payload written as a JSON string, e.g.
+// "{\"version\":\"v2\"}", and returns any other payload unchanged
+func decodeStringPayload(payload json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		return payload, nil
+	}
+	var s string
+	if err := json.Unmarshal(trimmed, &s); err != nil {
+		return nil, fmt.Errorf("invalid payload string: %w", err)
+	}
+	return json.RawMessage(s), nil
+}
+
+// eventBridgeDetail returns the "detail" and id of an EventBridge event,
+// or false when event is not one
+func eventBridgeDetail(event json.RawMessage) (json.RawMessage, string, bool) {
+	var envelope struct {
+		ID         string          `json:"id"`
+		DetailType *string         `json:"detail-type"`
+		Source     *string         `json:"source"`
+		Detail     json.RawMessage `json:"detail"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil {
+		// Not an object we understand; let the payload parser report it
+		return nil, "", false
+	}
+	if envelope.DetailType == nil || envelope.Source == nil || len(envelope.Detail) == 0 {
+		return nil, "", false
+	}
+	return envelope.Detail, envelope.ID, true
+}
+
+// DetectSource records what invoked the run, from the shape of the raw
+// event unless the entrypoint recorded it, so failures can be handed back in a way that source can retry.
+// It must run before any envelope is unwrapped.
+func DetectSource() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			source := detectSource(event)
+			if lc, ok := lambdacontext.FromContext(ctx); ok {
+				source.InvocationID = lc.AwsRequestID
+			}
+			// An entrypoint that knows the source, like the SQS worker,
+			// records it before the run; the message body alone looks direct
+			if known := run.SourceFrom(ctx); known.Trigger != run.TriggerDirect {
+				source.Trigger, source.InvocationID, source.EventTime = known.Trigger, known.InvocationID, known.EventTime
+			}
+			log.Printf("📥 Triggered by %s", source.Trigger)
+			return next(run.WithSource(ctx, source), event)
+		}
+	}
+}
+
+// detectSource classifies a raw invocation event
+func detectSource(event json.RawMessage) run.Source {
+	if detail, id, ok := eventBridgeDetail(event); ok {
+		var envelope struct {
+			Time time.Time `json:"time"`
+		}
+		// Not every EventBridge-shaped event has a valid time
+		_ = json.Unmarshal(event, &envelope)
+		return run.Source{Trigger: run.TriggerEventBridge, Event: detail, EventID: id, EventTime: envelope.Time}
+	}
+
+	var shape struct {
+		Records []struct {
+			EventSource string `json:"eventSource"`
+			Attributes  struct {
+				SentTimestamp string `json:"SentTimestamp"`
+			} `json:"attributes"`
+		} `json:"Records"`
+		RequestContext json.RawMessage `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &shape); err == nil {
+		if len(shape.Records) > 0 && shape.Records[0].EventSource == "aws:sqs" {
+			return run.Source{Trigger: run.TriggerSQS, Event: event, EventTime: run.SentTime(shape.Records[0].Attributes.SentTimestamp)}
+		}
+		if len(shape.RequestContext) > 0 {
+			return run.Source{Trigger: run.TriggerWebhook, Event: event}
+		}
+	}
+	return run.Source{Trigger: run.TriggerDirect, Event: event}
+}
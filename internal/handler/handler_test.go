@@ -0,0 +1,538 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/failure"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// trace returns a middleware that records when it is entered and left
+func trace(name string, calls *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			*calls = append(*calls, name+":before")
+			err := next(ctx, event)
+			*calls = append(*calls, name+":after")
+			return err
+		}
+	}
+}
+
+func TestChain_Ordering(t *testing.T) {
+	var calls []string
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		calls = append(calls, "handler")
+		return nil
+	}, trace("A", &calls), trace("B", &calls))
+
+	if err := h(context.Background(), nil); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+
+	expected := []string{"A:before", "B:before", "handler", "B:after", "A:after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("calls = %v, want %v", calls, expected)
+	}
+}
+
+func TestChain_ErrorPropagation(t *testing.T) {
+	sentinel := errors.New("boom")
+	var seen []error
+	observe := func(next Handler) Handler {
+		return func(ctx context.Context, event json.RawMessage) error {
+			err := next(ctx, event)
+			seen = append(seen, err)
+			return err
+		}
+	}
+
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		return sentinel
+	}, observe, observe)
+
+	err := h(context.Background(), nil)
+	if !errors.Is(err, sentinel) {
+		t.Fatalf("error = %v, want %v", err, sentinel)
+	}
+	if len(seen) != 2 || seen[0] != sentinel || seen[1] != sentinel {
+		t.Errorf("middleware saw %v, want the sentinel twice", seen)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	notified := false
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		panic("something broke")
+	}, Recover(), NotifyOnError(func(ctx context.Context, err error) {
+		notified = true
+	}))
+
+	err := h(context.Background(), nil)
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("error = %v, want *PanicError", err)
+	}
+	if panicErr.Value != "something broke" {
+		t.Errorf("PanicError.Value = %v", panicErr.Value)
+	}
+	// NotifyOnError sits inside Recover, so the panic unwinds past it
+	if notified {
+		t.Error("expected inner NotifyOnError to be skipped by the panic")
+	}
+}
+
+func TestRecover_OuterNotifySeesPanic(t *testing.T) {
+	var notifiedErr error
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		panic("something broke")
+	}, NotifyOnError(func(ctx context.Context, err error) {
+		notifiedErr = err
+	}), Recover())
+
+	if err := h(context.Background(), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	var panicErr *PanicError
+	if !errors.As(notifiedErr, &panicErr) {
+		t.Errorf("notified error = %v, want *PanicError", notifiedErr)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		panic("something broke")
+	}, Classify(), Recover())
+
+	err := h(context.Background(), nil)
+	var classified *failure.Error
+	if !errors.As(err, &classified) || classified.Code != failure.CodeInternal {
+		t.Fatalf("error = %v, want an INTERNAL *failure.Error", err)
+	}
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Errorf("error = %v, want the *PanicError kept", err)
+	}
+
+	ok := Chain(func(ctx context.Context, event json.RawMessage) error { return nil }, Classify())
+	if err := ok(context.Background(), nil); err != nil {
+		t.Errorf("error = %v on success", err)
+	}
+}
+
+func TestNotifyOnError_OnlyOnFailure(t *testing.T) {
+	calls := 0
+	notify := NotifyOnError(func(ctx context.Context, err error) { calls++ })
+
+	ok := Chain(func(ctx context.Context, event json.RawMessage) error { return nil }, notify)
+	if err := ok(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("notify called %d times on success", calls)
+	}
+
+	failing := Chain(func(ctx context.Context, event json.RawMessage) error { return errors.New("fail") }, notify)
+	if err := failing(context.Background(), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls != 1 {
+		t.Errorf("notify called %d times on failure, want 1", calls)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return errors.New("no deadline set")
+		}
+		if time.Until(deadline) > time.Second {
+			return errors.New("deadline too far away")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, Timeout(10*time.Millisecond))
+
+	if err := h(context.Background(), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+
+	noTimeout := Chain(func(ctx context.Context, event json.RawMessage) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("unexpected deadline")
+		}
+		return nil
+	}, Timeout(0))
+	if err := noTimeout(context.Background(), nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExecutionID(t *testing.T) {
+	var seen string
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		seen = run.ID(ctx)
+		return nil
+	}, ExecutionID())
+
+	if err := h(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 26 {
+		t.Errorf("execution ID = %q, want a 26 character ULID", seen)
+	}
+}
+
+func TestNotificationScope(t *testing.T) {
+	dispatcher := notify.NewDispatcher(config.NotificationConfig{})
+	var seen *notify.Dispatcher
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		notify.SetDispatcher(ctx, dispatcher)
+		return errors.New("failed")
+	}, NotificationScope(), NotifyOnError(func(ctx context.Context, err error) {
+		seen = notify.FromContext(ctx)
+	}))
+
+	h(context.Background(), nil)
+	if seen != dispatcher {
+		t.Errorf("error notifier saw dispatcher %p, want %p", seen, dispatcher)
+	}
+}
+
+// eventRecorder is a notifier that keeps what it receives
+type eventRecorder struct{ events []notify.Event }
+
+func (r *eventRecorder) Notify(ctx context.Context, event notify.Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestFlushNotifications_AfterPanic(t *testing.T) {
+	channel := &eventRecorder{}
+	dispatcher := notify.NewDispatcher(config.NotificationConfig{Digest: true}, channel)
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		notify.SetDispatcher(ctx, dispatcher)
+		dispatcher.Dispatch(ctx, notify.Event{Type: notify.EventPostTrade, Symbol: "BTC-USDT", Summary: "bought"})
+		panic("boom")
+	}, NotificationScope(), FlushNotifications(), NotifyOnError(func(ctx context.Context, err error) {
+		notify.FromContext(ctx).Dispatch(ctx, notify.Event{Type: notify.EventError, Summary: err.Error()})
+	}), Recover())
+
+	if err := h(context.Background(), nil); err == nil {
+		t.Fatal("handler error = nil, want panic error")
+	}
+	if len(channel.events) != 1 || channel.events[0].Type != notify.EventDigest || len(channel.events[0].Details) != 2 {
+		t.Fatalf("channel received %+v, want one digest of the fill and the error", channel.events)
+	}
+	if got := channel.events[0].Details[0].Value; got != "panic: boom" {
+		t.Errorf("first digest row = %q, want the error", got)
+	}
+}
+
+// ctxRecorder is a notifier that keeps the state of the context of each
+// delivery
+type ctxRecorder struct{ errs []error }
+
+func (r *ctxRecorder) Notify(ctx context.Context, event notify.Event) error {
+	r.errs = append(r.errs, ctx.Err())
+	return nil
+}
+
+// An interrupted run still flushes and reports, on a context that is not
+// done yet
+func TestNotifyOnError_Canceled(t *testing.T) {
+	var notified error
+	channel := &ctxRecorder{}
+	dispatcher := notify.NewDispatcher(config.NotificationConfig{Digest: true}, channel)
+	ctx, cancel := context.WithCancel(context.Background())
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		notify.SetDispatcher(ctx, dispatcher)
+		dispatcher.Dispatch(ctx, notify.Event{Type: notify.EventPostTrade, Symbol: "BTC-USDT", Summary: "bought"})
+		cancel()
+		return ctx.Err()
+	}, NotificationScope(), FlushNotifications(), NotifyOnError(func(ctx context.Context, err error) {
+		notified = ctx.Err()
+	}))
+
+	if err := h(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("handler error = %v, want the cancellation", err)
+	}
+	if notified != nil || len(channel.errs) != 1 || channel.errs[0] != nil {
+		t.Errorf("notified on a done context (%v), flushed on %v", notified, channel.errs)
+	}
+}
+
+func TestUnwrapEnvelope(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		expected string
+	}{
+		{
+			name:     "bare_payload",
+			event:    `{"version":"v2"}`,
+			expected: `{"version":"v2"}`,
+		},
+		{
+			name:     "eventbridge_envelope",
+			event:    `{"source":"aws.events","detail-type":"Scheduled Event","detail":{"version":"v2"}}`,
+			expected: `{"version":"v2"}`,
+		},
+		{
+			name:     "detail_without_envelope_keys",
+			event:    `{"detail":{"version":"v2"}}`,
+			expected: `{"detail":{"version":"v2"}}`,
+		},
+		{
+			name:     "scheduler_envelope",
+			event:    `{"source":"aws.scheduler","detail-type":"Scheduled Event","detail":{"version":"v2"}}`,
+			expected: `{"version":"v2"}`,
+		},
+		{
+			name:     "double_encoded_payload",
+			event:    `"{\"version\":\"v2\"}"`,
+			expected: `{"version":"v2"}`,
+		},
+		{
+			name:     "double_encoded_detail",
+			event:    `{"source":"aws.events","detail-type":"Scheduled Event","detail":"{\"version\":\"v2\"}"}`,
+			expected: `{"version":"v2"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := Chain(func(ctx context.Context, event json.RawMessage) error {
+				got = string(event)
+				return nil
+			}, UnwrapEnvelope(EventBridgeUnwrapper))
+
+			if err := h(context.Background(), json.RawMessage(tt.event)); err != nil {
+				t.Fatalf("handler error = %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("payload = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRecordTiming(t *testing.T) {
+	var rec *run.Recorder
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		rec = run.RecorderFrom(ctx)
+		return nil
+	}, RecordTiming(), UnwrapEnvelope(EventBridgeUnwrapper))
+
+	if err := h(context.Background(), json.RawMessage(`{"version":"v2"}`)); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if rec == nil {
+		t.Fatal("RecordTiming() did not put a recorder in the context")
+	}
+	if spans := rec.Timing().Spans; len(spans) != 1 || spans[0].Name != "payload.unwrap" || spans[0].Open {
+		t.Errorf("spans = %+v, want one finished payload.unwrap span", spans)
+	}
+
+	// A recorder the entrypoint set is kept
+	outer := run.NewRecorder()
+	if err := h(run.WithRecorder(context.Background(), outer), json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("handler error = %v", err)
+	}
+	if rec != outer {
+		t.Error("RecordTiming() replaced the recorder already in the context")
+	}
+}
+
+func TestCollectWarnings(t *testing.T) {
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{"lenient", false, false},
+		{"strict", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var warnings *run.Warnings
+			var notified error
+			h := Chain(func(ctx context.Context, event json.RawMessage) error {
+				warnings = run.WarningsFrom(ctx)
+				warnings.SetStrict(tt.strict)
+				run.Warn(ctx, "eventbridge", "publish", errors.New("throttled"))
+				return nil
+			}, CollectWarnings(), NotifyOnError(func(ctx context.Context, err error) {
+				notified = err
+			}))
+
+			err := h(context.Background(), nil)
+			if warnings == nil {
+				t.Fatal("CollectWarnings() did not put a collector in the context")
+			}
+			if got := warnings.List(); len(got) != 1 || got[0].String() != "eventbridge publish failed" {
+				t.Errorf("warnings = %+v, want the publish failure", got)
+			}
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, run.ErrStrictMode)) {
+				t.Errorf("handler error = %v, want strict mode failure %v", err, tt.wantErr)
+			}
+			if notified != nil {
+				// Middleware inside the collector sees the run succeed; the
+				// handler promotes warnings itself when they must be notified
+				t.Errorf("NotifyOnError() called with %v", notified)
+			}
+		})
+	}
+}
+
+func TestEventBridgePathUnwrapper(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		event   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "nested",
+			path:  "detail.payload",
+			event: `{"source":"custom","detail-type":"DCA","detail":{"payload":{"version":"v2"}}}`,
+			want:  `{"version":"v2"}`,
+		},
+		{
+			name:  "top_level",
+			path:  "input",
+			event: `{"source":"custom","detail-type":"DCA","input":"{\"version\":\"v2\"}"}`,
+			want:  `{"version":"v2"}`,
+		},
+		{
+			name:  "bare_payload",
+			path:  "detail.payload",
+			event: `{"version":"v2"}`,
+			want:  `{"version":"v2"}`,
+		},
+		{
+			name:    "missing",
+			path:    "detail.payload",
+			event:   `{"source":"custom","detail-type":"DCA","detail":{}}`,
+			wantErr: "EventBridge event: no payload at detail.payload",
+		},
+		{
+			name:    "not_an_object",
+			path:    "detail.payload",
+			event:   `{"source":"custom","detail-type":"DCA","detail":[]}`,
+			wantErr: "EventBridge event: detail.payload: not an object",
+		},
+		{
+			name:    "invalid_string",
+			path:    "detail",
+			event:   `"{\"version\"`,
+			wantErr: "invalid payload string",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EventBridgePathUnwrapper(tt.path)(context.Background(), json.RawMessage(tt.event))
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("payload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnwrapEnvelope_Error(t *testing.T) {
+	called := false
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		called = true
+		return nil
+	}, UnwrapEnvelope(func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+		return nil, errors.New("bad envelope")
+	}))
+
+	if err := h(context.Background(), nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if called {
+		t.Error("handler should not run when unwrapping fails")
+	}
+}
+
+func TestDetectSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     string
+		trigger   run.Trigger
+		wantEvent string
+		wantTime  string // RFC 3339; empty when the event has none
+	}{
+		{"direct", `{"version":"v2"}`, run.TriggerDirect, `{"version":"v2"}`, ""},
+		{"eventbridge", `{"id":"53dc4d37-cffa-4f76-80c9-8b7d4a4d2eaa","source":"aws.events","detail-type":"Scheduled Event","time":"2026-10-16T23:59:00Z","detail":{"version":"v2"}}`, run.TriggerEventBridge, `{"version":"v2"}`, "2026-10-16T23:59:00Z"},
+		{"sqs", `{"Records":[{"messageId":"1","eventSource":"aws:sqs","attributes":{"SentTimestamp":"1760601600000"},"body":"{}"}]}`, run.TriggerSQS, "", "2025-10-16T08:00:00Z"},
+		{"webhook", `{"rawPath":"/","requestContext":{"http":{"method":"POST"}},"body":"{}"}`, run.TriggerWebhook, "", ""},
+		{"kms_envelope", `{"kms":{"ciphertext":"AQID"}}`, run.TriggerDirect, `{"kms":{"ciphertext":"AQID"}}`, ""},
+		{"not_json", `nope`, run.TriggerDirect, `nope`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen run.Source
+			h := Chain(func(ctx context.Context, event json.RawMessage) error {
+				seen = run.SourceFrom(ctx)
+				return nil
+			}, DetectSource())
+
+			if err := h(context.Background(), json.RawMessage(tt.event)); err != nil {
+				t.Fatal(err)
+			}
+			if seen.Trigger != tt.trigger {
+				t.Errorf("Trigger = %s, want %s", seen.Trigger, tt.trigger)
+			}
+			if tt.wantEvent != "" && string(seen.Event) != tt.wantEvent {
+				t.Errorf("Event = %s, want %s", seen.Event, tt.wantEvent)
+			}
+			if wantID := tt.trigger == run.TriggerEventBridge; (seen.EventID == "53dc4d37-cffa-4f76-80c9-8b7d4a4d2eaa") != wantID {
+				t.Errorf("EventID = %q, want the event's id %v", seen.EventID, wantID)
+			}
+			if got := seen.EventTime; tt.wantTime == "" && !got.IsZero() || tt.wantTime != "" && got.Format(time.RFC3339) != tt.wantTime {
+				t.Errorf("EventTime = %s, want %q", got, tt.wantTime)
+			}
+		})
+	}
+
+	if got := run.SourceFrom(context.Background()).Trigger; got != run.TriggerDirect {
+		t.Errorf("SourceFrom() without source = %s, want %s", got, run.TriggerDirect)
+	}
+
+	// The SQS worker records the source of a bare message body
+	var seen run.Source
+	h := Chain(func(ctx context.Context, event json.RawMessage) error {
+		seen = run.SourceFrom(ctx)
+		return nil
+	}, DetectSource())
+	ctx := run.WithSource(context.Background(), run.Source{Trigger: run.TriggerSQS, InvocationID: "msg-1"})
+	if err := h(ctx, json.RawMessage(`{"source":"aws.events","detail-type":"Scheduled Event","detail":{"version":"v2"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if seen.Trigger != run.TriggerSQS || seen.InvocationID != "msg-1" || string(seen.Event) != `{"version":"v2"}` {
+		t.Errorf("source = %+v, want the worker's with the unwrapped event", seen)
+	}
+}
@@ -0,0 +1,150 @@
+// Package intent keeps a write-ahead record of each live order. A run
+// writes its intent before ordering and resolves it once its records are
+// saved; an intent still pending at the next run for the symbol on the same
+// exchange means that run died in between, and Resolve asks the exchange
+// what became of the order.
+package intent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/reconcile"
+)
+
+// Phases of an Intent
+const (
+	PhaseOrdering = "ordering" // about to order; the outcome is unknown
+	PhaseOrdered  = "ordered"  // the exchange returned the order
+	PhaseResolved = "resolved" // nothing left to recover
+)
+
+// Outcomes of a resolved intent or a Recovery
+const (
+	OutcomeCompleted = "completed"  // the run that wrote it saved its records
+	OutcomeFailed    = "failed"     // the order request failed and did not go through
+	OutcomeFilled    = "filled"     // recovered: the order went through
+	OutcomeNotPlaced = "not placed" // recovered: the order never reached the exchange
+	OutcomeAmbiguous = "ambiguous"  // the order cannot be found yet; the intent stays pending
+	OutcomeStale     = "stale"      // older than TTL; reported and given up
+)
+
+// TTL is how long a pending intent blocks the next runs. An older one is
+// reported and given up, since the trade history it is looked up in may
+// no longer reach back to it.
+const TTL = 24 * time.Hour
+
+// lookBack widens the trade lookup before the intent's time, allowing for
+// clock skew with the exchange
+const lookBack = time.Minute
+
+// Intent is the write-ahead record of a live order
+type Intent struct {
+	ExecutionID   string          `json:"executionId"`
+	ClientOrderID string          `json:"clientOrderId"`
+	Exchange      string          `json:"exchange"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`   // "buy" or "sell"
+	Amount        decimal.Decimal `json:"amount"` // quote amount of a buy, base quantity of a sell
+	Phase         string          `json:"phase"`
+	At            time.Time       `json:"at"` // when the order was about to be placed
+
+	Order *exchange.Order `json:"order,omitempty"` // set from PhaseOrdered on
+
+	Outcome    string    `json:"outcome,omitempty"` // set once resolved
+	ResolvedAt time.Time `json:"resolvedAt,omitzero"`
+}
+
+// Pending reports whether the intent is not resolved yet
+func (i Intent) Pending() bool {
+	return i.Phase != PhaseResolved
+}
+
+// Resolved returns the intent marked resolved with outcome at now
+func (i Intent) Resolved(outcome string, now time.Time) Intent {
+	i.Phase, i.Outcome, i.ResolvedAt = PhaseResolved, outcome, now.UTC()
+	return i
+}
+
+// Recovery is what became of a pending intent left by an earlier run
+type Recovery struct {
+	Intent  Intent          `json:"intent"`
+	Outcome string          `json:"outcome"`
+	Order   *exchange.Order `json:"order,omitempty"`  // the order when filled
+	Reason  string          `json:"reason,omitempty"` // why it is ambiguous or stale
+}
+
+// Blocking reports whether the current run must wait for the order to be
+// found
+func (r Recovery) Blocking() bool {
+	return r.Outcome == OutcomeAmbiguous
+}
+
+// Resolve looks up the order of a pending intent. A settled order the
+// intent already holds is taken as is, and one the run left pending
+// settlement is looked up by its ID where the exchange can; otherwise the
+// exchange's trades since the intent are searched for its client order ID.
+// The order is ambiguous while it is open or the exchange cannot be asked,
+// and stale past TTL. One rejected or canceled unfilled has failed.
+func Resolve(ctx context.Context, exc exchange.Exchange, in Intent, now time.Time) Recovery {
+	rec := Recovery{Intent: in}
+	if in.Order != nil && (in.Order.Settled() || len(in.Order.Legs) > 0) {
+		rec.Outcome, rec.Order = OutcomeFilled, in.Order
+		return rec
+	}
+	if age := now.Sub(in.At); age > TTL {
+		rec.Outcome = OutcomeStale
+		rec.Reason = fmt.Sprintf("pending for %s, longer than %s", age.Round(time.Minute), TTL)
+		return rec
+	}
+	if getter, ok := exc.(exchange.OrderGetter); ok && in.Order != nil {
+		return lookUp(ctx, getter, rec)
+	}
+
+	trades, err := exc.GetMyTrades(ctx, in.Symbol, in.At.Add(-lookBack), now)
+	if err != nil {
+		rec.Outcome, rec.Reason = OutcomeAmbiguous, fmt.Sprintf("failed to get trade history: %v", err)
+		return rec
+	}
+	for _, f := range reconcile.Fills(trades) {
+		if f.ClientOrderID == in.ClientOrderID {
+			rec.Outcome, rec.Order = OutcomeFilled, f.Order()
+			return rec
+		}
+	}
+
+	open, err := exc.OpenOrders(ctx, in.Symbol)
+	if err != nil {
+		rec.Outcome, rec.Reason = OutcomeAmbiguous, fmt.Sprintf("failed to list open orders: %v", err)
+		return rec
+	}
+	for _, o := range open {
+		if o.ClientOrderID == in.ClientOrderID {
+			rec.Outcome, rec.Reason = OutcomeAmbiguous, fmt.Sprintf("order %s is still open", o.ID)
+			return rec
+		}
+	}
+	rec.Outcome = OutcomeNotPlaced
+	return rec
+}
+
+// lookUp resolves the intent of an order left pending settlement from its
+// current state on the exchange
+func lookUp(ctx context.Context, getter exchange.OrderGetter, rec Recovery) Recovery {
+	order, err := getter.GetOrder(ctx, rec.Intent.Order.Symbol, rec.Intent.Order.ID)
+	switch {
+	case err != nil:
+		rec.Outcome, rec.Reason = OutcomeAmbiguous, fmt.Sprintf("failed to look order %s up: %v", rec.Intent.Order.ID, err)
+	case !order.Settled():
+		rec.Outcome, rec.Reason = OutcomeAmbiguous, fmt.Sprintf("order %s is still %s", order.ID, order.Status)
+	case order.Quantity.IsZero():
+		// Rejected or canceled before anything filled
+		rec.Outcome, rec.Reason = OutcomeFailed, fmt.Sprintf("order %s was %s", order.ID, order.Status)
+	default:
+		rec.Outcome, rec.Order = OutcomeFilled, order
+	}
+	return rec
+}
@@ -0,0 +1,150 @@
+package intent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// failingTrades is an exchange whose trade history cannot be read
+type failingTrades struct {
+	exchange.Exchange
+}
+
+func (failingTrades) GetMyTrades(ctx context.Context, symbol string, from, to time.Time) ([]exchange.Trade, error) {
+	return nil, errors.New("503 service unavailable")
+}
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+var at = time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+
+func pending() Intent {
+	return Intent{
+		ExecutionID:   "01K7PZ5B3Q69G5FAV",
+		ClientOrderID: "dca-Q69G5FAV",
+		Symbol:        "BTC-USDT",
+		Side:          "buy",
+		Amount:        d("50"),
+		Phase:         PhaseOrdering,
+		At:            at,
+	}
+}
+
+func TestResolve(t *testing.T) {
+	trades := []exchange.Trade{
+		{ID: "1", OrderID: "901", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.0004"), Price: d("62000"), FeeAmount: d("0.0000004"), FeeAsset: "BTC", Time: at.Add(time.Second)},
+		{ID: "2", OrderID: "901", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.0004"), Price: d("63000"), FeeAmount: d("0.0000004"), FeeAsset: "BTC", Time: at.Add(2 * time.Second)},
+		{ID: "3", OrderID: "902", ClientOrderID: "dca-OTHERRUN", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.001"), Price: d("61000"), Time: at.Add(-time.Hour)},
+	}
+	ordered := pending()
+	ordered.Phase, ordered.Order = PhaseOrdered, &exchange.Order{ID: "777", ClientOrderID: "dca-Q69G5FAV", Status: "filled"}
+	now := at.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		exc     exchange.Exchange
+		in      Intent
+		now     time.Time
+		outcome string
+		orderID string
+		reason  string
+	}{
+		{name: "filled", exc: &exchange.MockExchange{Trades: trades}, in: pending(), now: now, outcome: OutcomeFilled, orderID: "901"},
+		{name: "ordered before the crash", exc: failingTrades{}, in: ordered, now: now, outcome: OutcomeFilled, orderID: "777"},
+		{name: "never reached the exchange", exc: &exchange.MockExchange{Trades: trades[2:]}, in: pending(), now: now, outcome: OutcomeNotPlaced},
+		{
+			name: "still open", exc: &exchange.MockExchange{Open: []exchange.Order{{ID: "903", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Status: "open"}}},
+			in: pending(), now: now, outcome: OutcomeAmbiguous, reason: "order 903 is still open",
+		},
+		{name: "history unavailable", exc: failingTrades{&exchange.MockExchange{}}, in: pending(), now: now, outcome: OutcomeAmbiguous, reason: "503"},
+		{name: "stale", exc: failingTrades{}, in: pending(), now: at.Add(TTL + time.Hour), outcome: OutcomeStale, reason: "pending for 25h0m0s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := Resolve(context.Background(), tt.exc, tt.in, tt.now)
+			if rec.Outcome != tt.outcome {
+				t.Fatalf("Outcome = %q (%s), want %q", rec.Outcome, rec.Reason, tt.outcome)
+			}
+			if rec.Blocking() != (tt.outcome == OutcomeAmbiguous) {
+				t.Errorf("Blocking() = %v for %s", rec.Blocking(), rec.Outcome)
+			}
+			var orderID string
+			if rec.Order != nil {
+				orderID = rec.Order.ID
+			}
+			if orderID != tt.orderID {
+				t.Errorf("Order ID = %q, want %q", orderID, tt.orderID)
+			}
+			if !strings.Contains(rec.Reason, tt.reason) {
+				t.Errorf("Reason = %q, want it to mention %q", rec.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestResolve_FilledOrder(t *testing.T) {
+	exc := &exchange.MockExchange{Trades: []exchange.Trade{
+		{ID: "1", OrderID: "901", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.0004"), Price: d("62000"), FeeAmount: d("0.0000004"), FeeAsset: "BTC", Time: at.Add(time.Second)},
+		{ID: "2", OrderID: "901", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Side: "buy", Quantity: d("0.0004"), Price: d("63000"), FeeAmount: d("0.0000004"), FeeAsset: "BTC", Time: at.Add(2 * time.Second)},
+	}}
+	rec := Resolve(context.Background(), exc, pending(), at.Add(time.Minute))
+	o := rec.Order
+	if o == nil || !o.Quantity.Equal(d("0.0008")) || !o.Price.Equal(d("62500")) || !o.FeeAmount.Equal(d("0.0000008")) || o.Status != "filled" {
+		t.Errorf("Order = %+v, want the two trades combined", o)
+	}
+}
+
+func TestResolve_PendingSettlement(t *testing.T) {
+	tests := []struct {
+		name       string
+		settlement []string
+		outcome    string
+		quantity   string
+		reason     string
+	}{
+		{name: "filled since", settlement: []string{"open", "filled"}, outcome: OutcomeFilled, quantity: "0.001"},
+		{name: "still partial", settlement: []string{"partial"}, outcome: OutcomeAmbiguous, reason: "order mock-order-12345 is still partial"},
+		{name: "rejected", settlement: []string{"open", "rejected"}, outcome: OutcomeFailed, reason: "was rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			exc := &exchange.MockExchange{Settlement: tt.settlement}
+			order, err := exc.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(d("50")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := pending()
+			in.Phase, in.Order = PhaseOrdered, order
+
+			rec := Resolve(ctx, exc, in, at.Add(time.Hour))
+			if rec.Outcome != tt.outcome || !strings.Contains(rec.Reason, tt.reason) {
+				t.Fatalf("Outcome = %q (%s), want %q (%s)", rec.Outcome, rec.Reason, tt.outcome, tt.reason)
+			}
+			if tt.quantity != "" && (rec.Order == nil || !rec.Order.Quantity.Equal(d(tt.quantity))) {
+				t.Errorf("Order = %+v, want %s filled", rec.Order, tt.quantity)
+			}
+		})
+	}
+}
+
+func TestIntent_Resolved(t *testing.T) {
+	in := pending()
+	if !in.Pending() {
+		t.Fatal("Pending() = false for an ordering intent")
+	}
+	now := at.Add(time.Minute)
+	done := in.Resolved(OutcomeCompleted, now)
+	if done.Pending() || done.Outcome != OutcomeCompleted || !done.ResolvedAt.Equal(now) {
+		t.Errorf("Resolved() = %+v", done)
+	}
+	if !in.Pending() {
+		t.Error("Resolved() changed the original intent")
+	}
+}
@@ -0,0 +1,125 @@
+// Package kmspayload wraps a DCA payload in a KMS-encrypted envelope so the
+// whole invocation input can be ciphertext:
+//
+//	{"encryptedPayload": "<base64 ciphertext>", "keyId": "...", "encryptionContext": {"app": "dca-bot"}}
+package kmspayload
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// AppEncryptionContext is the only encryption context accepted on envelopes
+var AppEncryptionContext = map[string]string{"app": "dca-bot"}
+
+// KMSAPI is the subset of the KMS client used by this package
+type KMSAPI interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// Envelope is the wire format of an encrypted payload
+type Envelope struct {
+	EncryptedPayload  string            `json:"encryptedPayload"`
+	KeyID             string            `json:"keyId,omitempty"`
+	EncryptionContext map[string]string `json:"encryptionContext,omitempty"`
+}
+
+// Detect reports whether raw is an encrypted envelope and returns it
+func Detect(raw json.RawMessage) (*Envelope, bool) {
+	var envelope Envelope
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, false
+	}
+	if envelope.EncryptedPayload == "" {
+		return nil, false
+	}
+	return &envelope, true
+}
+
+// Decrypt returns the plaintext payload of an envelope. Error messages never
+// include the ciphertext or the plaintext.
+func Decrypt(ctx context.Context, client KMSAPI, envelope *Envelope) (json.RawMessage, error) {
+	if envelope.EncryptionContext != nil && !sameContext(envelope.EncryptionContext, AppEncryptionContext) {
+		return nil, fmt.Errorf("encryption context does not match the expected app context")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.EncryptedPayload)
+	if err != nil {
+		return nil, fmt.Errorf("encryptedPayload is not valid base64")
+	}
+
+	input := &kms.DecryptInput{
+		CiphertextBlob:    ciphertext,
+		EncryptionContext: envelope.EncryptionContext,
+	}
+	if envelope.KeyID != "" {
+		input.KeyId = &envelope.KeyID
+	}
+
+	out, err := client.Decrypt(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("kms decrypt failed for key %q: %w", envelope.KeyID, err)
+	}
+	if !json.Valid(out.Plaintext) {
+		return nil, fmt.Errorf("decrypted payload is not valid JSON")
+	}
+	return out.Plaintext, nil
+}
+
+// Encrypt encrypts payload under keyID with the app encryption context and
+// returns the envelope JSON
+func Encrypt(ctx context.Context, client KMSAPI, keyID string, payload []byte) ([]byte, error) {
+	if !json.Valid(payload) {
+		return nil, fmt.Errorf("payload is not valid JSON")
+	}
+
+	out, err := client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:             &keyID,
+		Plaintext:         payload,
+		EncryptionContext: AppEncryptionContext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kms encrypt failed for key %q: %w", keyID, err)
+	}
+
+	return json.MarshalIndent(Envelope{
+		EncryptedPayload:  base64.StdEncoding.EncodeToString(out.CiphertextBlob),
+		KeyID:             keyID,
+		EncryptionContext: AppEncryptionContext,
+	}, "", "  ")
+}
+
+// Unwrapper returns a handler unwrapper that decrypts envelopes and passes
+// any other event through unchanged. newClient is only called when an
+// envelope is present, so plain payloads never need KMS access.
+func Unwrapper(newClient func(ctx context.Context) (KMSAPI, error)) func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+	return func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+		envelope, ok := Detect(event)
+		if !ok {
+			return event, nil
+		}
+
+		client, err := newClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create KMS client: %w", err)
+		}
+		return Decrypt(ctx, client, envelope)
+	}
+}
+
+func sameContext(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package kmspayload
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// fakeKMS "encrypts" by reversing the plaintext and, like real KMS, binds the
+// ciphertext to the encryption context used at encryption time
+type fakeKMS struct {
+	contexts   map[string]map[string]string
+	decryptErr error
+}
+
+func newFakeKMS() *fakeKMS {
+	return &fakeKMS{contexts: map[string]map[string]string{}}
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func (f *fakeKMS) Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error) {
+	blob := append([]byte("fake:"), reverse(params.Plaintext)...)
+	f.contexts[string(blob)] = params.EncryptionContext
+	return &kms.EncryptOutput{CiphertextBlob: blob, KeyId: params.KeyId}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if f.decryptErr != nil {
+		return nil, f.decryptErr
+	}
+	expected, ok := f.contexts[string(params.CiphertextBlob)]
+	if !ok || !sameContext(expected, params.EncryptionContext) {
+		return nil, errors.New("InvalidCiphertextException")
+	}
+	return &kms.DecryptOutput{Plaintext: reverse(bytes.TrimPrefix(params.CiphertextBlob, []byte("fake:")))}, nil
+}
+
+const payload = `{"version":"v2","exchange":{"name":"binance"}}`
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	client := newFakeKMS()
+	ctx := context.Background()
+
+	envelopeJSON, err := Encrypt(ctx, client, "alias/dca-bot", []byte(payload))
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if strings.Contains(string(envelopeJSON), "binance") {
+		t.Fatal("envelope contains plaintext")
+	}
+
+	envelope, ok := Detect(envelopeJSON)
+	if !ok {
+		t.Fatal("Detect() did not recognize the envelope")
+	}
+	if envelope.KeyID != "alias/dca-bot" || envelope.EncryptionContext["app"] != "dca-bot" {
+		t.Errorf("unexpected envelope %+v", envelope)
+	}
+
+	plaintext, err := Decrypt(ctx, client, envelope)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if string(plaintext) != payload {
+		t.Errorf("Decrypt() = %s, want %s", plaintext, payload)
+	}
+}
+
+func TestDecrypt_Errors(t *testing.T) {
+	client := newFakeKMS()
+	ctx := context.Background()
+	envelopeJSON, err := Encrypt(ctx, client, "alias/dca-bot", []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	good, _ := Detect(envelopeJSON)
+
+	tests := []struct {
+		name        string
+		envelope    Envelope
+		client      *fakeKMS
+		expectedErr string
+	}{
+		{
+			name:        "wrong_context",
+			envelope:    Envelope{EncryptedPayload: good.EncryptedPayload, EncryptionContext: map[string]string{"app": "other"}},
+			client:      client,
+			expectedErr: "encryption context does not match",
+		},
+		{
+			name:        "missing_context",
+			envelope:    Envelope{EncryptedPayload: good.EncryptedPayload},
+			client:      client,
+			expectedErr: "kms decrypt failed",
+		},
+		{
+			name:        "invalid_base64",
+			envelope:    Envelope{EncryptedPayload: "%%%not-base64%%%"},
+			client:      client,
+			expectedErr: "not valid base64",
+		},
+		{
+			name:        "kms_failure",
+			envelope:    *good,
+			client:      &fakeKMS{decryptErr: errors.New("AccessDeniedException")},
+			expectedErr: "AccessDeniedException",
+		},
+		{
+			name:        "plaintext_not_json",
+			envelope:    Envelope{EncryptedPayload: base64.StdEncoding.EncodeToString([]byte("fake:!!")), EncryptionContext: AppEncryptionContext},
+			client:      &fakeKMS{contexts: map[string]map[string]string{"fake:!!": AppEncryptionContext}},
+			expectedErr: "not valid JSON",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(ctx, tt.client, &tt.envelope)
+			if err == nil {
+				t.Fatal("Decrypt() expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Decrypt() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+			if tt.envelope.EncryptedPayload != "" && strings.Contains(err.Error(), tt.envelope.EncryptedPayload) {
+				t.Errorf("error echoes ciphertext: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnwrapper(t *testing.T) {
+	client := newFakeKMS()
+	ctx := context.Background()
+	envelopeJSON, err := Encrypt(ctx, client, "alias/dca-bot", []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCreated := false
+	unwrap := Unwrapper(func(ctx context.Context) (KMSAPI, error) {
+		clientCreated = true
+		return client, nil
+	})
+
+	plain, err := unwrap(ctx, json.RawMessage(payload))
+	if err != nil {
+		t.Fatalf("unwrap(plain) error = %v", err)
+	}
+	if string(plain) != payload || clientCreated {
+		t.Errorf("plain payload should pass through without creating a KMS client")
+	}
+
+	decrypted, err := unwrap(ctx, envelopeJSON)
+	if err != nil {
+		t.Fatalf("unwrap(envelope) error = %v", err)
+	}
+	if string(decrypted) != payload {
+		t.Errorf("unwrap(envelope) = %s, want %s", decrypted, payload)
+	}
+}
@@ -0,0 +1,137 @@
+// Package market summarizes the market around a run for its execution
+// record, so that later analysis need not fetch the history again. The
+// summary is computed from the candles a guard already read: a figure
+// whose period those candles do not cover is left out rather than
+// estimated from part of it.
+package market
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// Periods of the averages and the change
+const (
+	Day   = 24 * time.Hour
+	Week  = 7 * Day
+	Month = 30 * Day
+)
+
+// Context is the market around a run, written with the execution record
+// under state.enrichWithContext. Each figure is omitted when the candles
+// did not cover its period.
+type Context struct {
+	Change24h  decimal.Decimal `json:"change24h,omitzero"`  // percent change over the last 24 hours
+	Average7d  decimal.Decimal `json:"average7d,omitzero"`  // simple average of closes over 7 days
+	Average30d decimal.Decimal `json:"average30d,omitzero"` // simple average of closes over 30 days
+	DayHigh    decimal.Decimal `json:"dayHigh,omitzero"`    // highest price of the UTC day so far
+	DayLow     decimal.Decimal `json:"dayLow,omitzero"`     // lowest price of the UTC day so far
+
+	// Since is the open of the oldest candle read, so a reader can tell
+	// why a figure is missing
+	Since time.Time `json:"since"`
+}
+
+// Summarize computes the context at now from klines of interval, oldest
+// first. It is nil when there are no usable candles or none of the figures
+// is covered.
+func Summarize(klines []exchange.Kline, interval time.Duration, now time.Time) *Context {
+	klines = Usable(klines)
+	if len(klines) == 0 {
+		return nil
+	}
+	c := &Context{Since: klines[0].OpenTime.UTC()}
+	var ok [5]bool
+	c.Change24h, ok[0] = Change(klines, interval, now.Add(-Day), now)
+	c.Average7d, ok[1] = Average(klines, interval, now.Add(-Week), now)
+	c.Average30d, ok[2] = Average(klines, interval, now.Add(-Month), now)
+	midnight := now.UTC().Truncate(Day)
+	c.DayHigh, ok[3] = High(klines, interval, midnight, now)
+	c.DayLow, ok[4] = Low(klines, interval, midnight, now)
+	if ok == [5]bool{} {
+		return nil
+	}
+	return c
+}
+
+// Usable drops candles without a positive price in every field, which
+// exchanges report for gaps in trading
+func Usable(klines []exchange.Kline) []exchange.Kline {
+	usable := make([]exchange.Kline, 0, len(klines))
+	for _, k := range klines {
+		if k.Open.IsPositive() && k.High.IsPositive() && k.Low.IsPositive() && k.Close.IsPositive() {
+			usable = append(usable, k)
+		}
+	}
+	return usable
+}
+
+// window returns the candles of interval opened in [from, to), and false
+// when klines, oldest first, do not reach back to the start of the period:
+// the oldest candle must have opened within one interval of from
+func window(klines []exchange.Kline, interval time.Duration, from, to time.Time) ([]exchange.Kline, bool) {
+	if len(klines) == 0 || klines[0].OpenTime.After(from.Add(interval)) {
+		return nil, false
+	}
+	var in []exchange.Kline
+	for _, k := range klines {
+		if !k.OpenTime.Before(from) && k.OpenTime.Before(to) {
+			in = append(in, k)
+		}
+	}
+	return in, len(in) > 0
+}
+
+// Average is the simple average of the closes of the candles opened in
+// [from, to), rounded to eight decimals
+func Average(klines []exchange.Kline, interval time.Duration, from, to time.Time) (decimal.Decimal, bool) {
+	in, ok := window(klines, interval, from, to)
+	if !ok {
+		return decimal.Zero, false
+	}
+	sum := decimal.Zero
+	for _, k := range in {
+		sum = sum.Add(k.Close)
+	}
+	return sum.Div(decimal.NewFromInt(int64(len(in)))).Round(8), true
+}
+
+// Change is the percent change from the open of the first candle opened in
+// [from, to) to the close of the last, rounded to two decimals
+func Change(klines []exchange.Kline, interval time.Duration, from, to time.Time) (decimal.Decimal, bool) {
+	in, ok := window(klines, interval, from, to)
+	if !ok {
+		return decimal.Zero, false
+	}
+	open, last := in[0].Open, in[len(in)-1].Close
+	return money.PercentOf(last.Sub(open), open).Round(2).Decimal(), true
+}
+
+// High is the highest price of the candles opened in [from, to)
+func High(klines []exchange.Kline, interval time.Duration, from, to time.Time) (decimal.Decimal, bool) {
+	in, ok := window(klines, interval, from, to)
+	if !ok {
+		return decimal.Zero, false
+	}
+	high := in[0].High
+	for _, k := range in[1:] {
+		high = decimal.Max(high, k.High)
+	}
+	return high, true
+}
+
+// Low is the lowest price of the candles opened in [from, to)
+func Low(klines []exchange.Kline, interval time.Duration, from, to time.Time) (decimal.Decimal, bool) {
+	in, ok := window(klines, interval, from, to)
+	if !ok {
+		return decimal.Zero, false
+	}
+	low := in[0].Low
+	for _, k := range in[1:] {
+		low = decimal.Min(low, k.Low)
+	}
+	return low, true
+}
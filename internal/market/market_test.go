@@ -0,0 +1,163 @@
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+var now = time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+
+// series returns candles of interval opening at start, one per close, each
+// opening at the previous close and ranging one unit around its own prices
+func series(start time.Time, interval time.Duration, closes ...int64) []exchange.Kline {
+	klines := make([]exchange.Kline, len(closes))
+	open := decimal.NewFromInt(closes[0])
+	for i, c := range closes {
+		last := decimal.NewFromInt(c)
+		klines[i] = exchange.Kline{
+			OpenTime: start.Add(time.Duration(i) * interval),
+			Open:     open,
+			High:     decimal.Max(open, last).Add(decimal.NewFromInt(1)),
+			Low:      decimal.Min(open, last).Sub(decimal.NewFromInt(1)),
+			Close:    last,
+		}
+		open = last
+	}
+	return klines
+}
+
+func TestAverage(t *testing.T) {
+	tests := []struct {
+		name   string
+		klines []exchange.Kline
+		from   time.Time
+		want   string // empty when not covered
+	}{
+		{"covered", series(now.Add(-4*time.Hour), time.Hour, 100, 110, 120, 130), now.Add(-4 * time.Hour), "115"},
+		{"older candles left out", series(now.Add(-4*time.Hour), time.Hour, 100, 110, 120, 130), now.Add(-2 * time.Hour), "125"},
+		{"within one interval", series(now.Add(-3*time.Hour-30*time.Minute), time.Hour, 100, 110, 120, 130), now.Add(-4 * time.Hour), "115"},
+		{"starts too late", series(now.Add(-3*time.Hour), time.Hour, 110, 120, 130), now.Add(-5 * time.Hour), ""},
+		{"repeating decimals", series(now.Add(-3*time.Hour), time.Hour, 1, 1, 2), now.Add(-3 * time.Hour), "1.33333333"},
+		{"no candles", nil, now.Add(-time.Hour), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Average(tt.klines, time.Hour, tt.from, now)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("Average() = %s, want not covered", got)
+				}
+				return
+			}
+			if !ok || !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("Average() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []int64
+		want   string
+	}{
+		{"up", []int64{100, 95, 104, 110}, "10"},
+		{"down", []int64{200, 210, 190}, "-5"},
+		{"flat", []int64{100, 100}, "0"},
+		{"rounded", []int64{300, 301}, "0.33"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := now.Add(-time.Duration(len(tt.closes)) * time.Hour)
+			got, ok := Change(series(start, time.Hour, tt.closes...), time.Hour, start, now)
+			if !ok || !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("Change() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighLow(t *testing.T) {
+	klines := series(now.Add(-5*time.Hour), time.Hour, 100, 140, 90, 120, 130)
+	tests := []struct {
+		name      string
+		from      time.Time
+		high, low string
+	}{
+		{"all", now.Add(-5 * time.Hour), "141", "89"},
+		{"after the spike", now.Add(-2 * time.Hour), "131", "89"},
+		{"last candle", now.Add(-time.Hour), "131", "119"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			high, ok := High(klines, time.Hour, tt.from, now)
+			if !ok || !high.Equal(decimal.RequireFromString(tt.high)) {
+				t.Errorf("High() = %s, %v, want %s", high, ok, tt.high)
+			}
+			low, ok := Low(klines, time.Hour, tt.from, now)
+			if !ok || !low.Equal(decimal.RequireFromString(tt.low)) {
+				t.Errorf("Low() = %s, %v, want %s", low, ok, tt.low)
+			}
+		})
+	}
+}
+
+// flat returns n closes at price
+func flat(n int, price int64) []int64 {
+	closes := make([]int64, n)
+	for i := range closes {
+		closes[i] = price
+	}
+	return closes
+}
+
+func TestSummarize(t *testing.T) {
+	month := flat(31*24, 100)
+	month[len(month)-1] = 110
+	breaker := series(now.Add(-12*time.Hour), time.Minute, flat(720, 100)...)
+
+	tests := []struct {
+		name     string
+		klines   []exchange.Kline
+		interval time.Duration
+		at       time.Time
+		check    func(t *testing.T, c *Context)
+	}{
+		{"a month of hourly candles", series(now.Add(-31*Day), time.Hour, month...), time.Hour, now, func(t *testing.T, c *Context) {
+			if c == nil || !c.Change24h.Equal(decimal.NewFromInt(10)) || c.Average7d.IsZero() || c.Average30d.IsZero() ||
+				!c.DayHigh.Equal(decimal.NewFromInt(111)) || !c.DayLow.Equal(decimal.NewFromInt(99)) {
+				t.Fatalf("Summarize() = %+v, want every figure", c)
+			}
+			if !c.Average7d.GreaterThan(c.Average30d) {
+				t.Errorf("7-day average %s, want above the 30-day %s after the last candle's rise", c.Average7d, c.Average30d)
+			}
+		}},
+		{"a circuit breaker's 12 hours", breaker, time.Minute, now, func(t *testing.T, c *Context) {
+			if c == nil || !c.Change24h.IsZero() || !c.Average7d.IsZero() || !c.Average30d.IsZero() || c.DayHigh.IsZero() || c.DayLow.IsZero() {
+				t.Fatalf("Summarize() = %+v, want the day's high and low only", c)
+			}
+			if !c.Since.Equal(now.Add(-12 * time.Hour)) {
+				t.Errorf("Since = %s, want the oldest candle", c.Since)
+			}
+		}},
+		{"12 hours after noon", series(now.Add(-6*time.Hour), time.Minute, flat(720, 100)...), time.Minute, now.Add(6 * time.Hour), func(t *testing.T, c *Context) {
+			if c != nil {
+				t.Errorf("Summarize() = %+v, want nil when nothing is covered", c)
+			}
+		}},
+		{"no usable candles", []exchange.Kline{{OpenTime: now.Add(-time.Minute)}}, time.Minute, now, func(t *testing.T, c *Context) {
+			if c != nil {
+				t.Errorf("Summarize() = %+v, want nil", c)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, Summarize(tt.klines, tt.interval, tt.at))
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package money
+
+// Locales exposes the locales to language_test.go, which is outside
+// package money because config, whose languages it covers, imports money
+var Locales = locales
@@ -0,0 +1,72 @@
+package money_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// samples cover every asset class at the sizes notifications show
+var samples = []struct {
+	amount string
+	code   string
+}{
+	{"62626.34782608695652", "USDT"}, // price from an average fill
+	{"25", "USDT"},
+	{"1234567.891", "USD"},
+	{"0.005", "EUR"},
+	{"-1500", "USDC"},
+	{"0.00041", "BTC"},
+	{"12345.123456789", "BTC"},
+	{"1.5", "ETH"},
+	{"1234.5678901", "SOL"},
+	{"100", "ADA"},
+	{"0", "DOGE"},
+}
+
+func TestFormatter_Golden(t *testing.T) {
+	for _, language := range config.NotificationLanguages {
+		t.Run(language, func(t *testing.T) {
+			f := money.New(language, nil)
+			var b strings.Builder
+			for _, s := range samples {
+				fmt.Fprintf(&b, "%s %s => %s\n", s.amount, s.code, f.Amount(decimal.RequireFromString(s.amount), s.code))
+			}
+			got := b.String()
+
+			golden := filepath.Join("testdata", language+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestLocaleFor_CoversEveryLanguage(t *testing.T) {
+	for _, language := range config.NotificationLanguages {
+		if _, ok := money.Locales[language]; !ok {
+			t.Errorf("no locale for notifications.language %q", language)
+		}
+	}
+	if money.LocaleFor("") != money.Locales["en"] || money.LocaleFor("DE") != money.Locales["de"] || money.LocaleFor("tlh") != money.Locales["en"] {
+		t.Error("money.LocaleFor() should fall back to English and ignore case")
+	}
+}
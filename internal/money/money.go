@@ -0,0 +1,169 @@
+// Package money writes amounts for people to read: notifications, log
+// lines and Telegram replies. Amounts are rounded to a per-asset precision
+// and grouped by thousands in the notifications.language style, so
+// 62626.34782608695652 USDT reads "62,626.35 USDT". Machine-facing output
+// (the ExecutionResult JSON, CSV exports, SNS messages) keeps full
+// precision and never goes through this package.
+package money
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Default precisions by asset class
+const (
+	FiatPrecision    = 2 // fiat currencies and USD stablecoins
+	BTCPrecision     = 8
+	DefaultPrecision = 6
+)
+
+// fixed lists fiat currencies and stablecoins. They always show every
+// decimal of their precision, like prices on a receipt; other assets drop
+// trailing zeros, so 0.00041000 BTC reads 0.00041 BTC.
+var fixed = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true, "JPY": true, "TRY": true, "CAD": true,
+	"AUD": true, "CHF": true, "CNY": true, "HKD": true, "KRW": true,
+	"USDT": true, "USDC": true, "FDUSD": true, "BUSD": true, "TUSD": true, "DAI": true, "USDP": true,
+}
+
+// Precision returns the default number of decimals shown for code
+func Precision(code string) int32 {
+	code = strings.ToUpper(code)
+	switch {
+	case fixed[code]:
+		return FiatPrecision
+	case code == "BTC":
+		return BTCPrecision
+	default:
+		return DefaultPrecision
+	}
+}
+
+// Locale is how a language writes numbers
+type Locale struct {
+	Group   string // thousands separator
+	Decimal string // decimal separator
+
+	// MinGrouping is the fewest integer digits that get grouped; 5 in
+	// Spanish, which writes 1234,56 but 12.345,67
+	MinGrouping int
+}
+
+// locales covers config.NotificationLanguages
+var locales = map[string]Locale{
+	"en": {Group: ",", Decimal: "."},
+	"de": {Group: ".", Decimal: ","},
+	"es": {Group: ".", Decimal: ",", MinGrouping: 5},
+	"fr": {Group: "\u202f", Decimal: ","}, // narrow no-break space
+	"ja": {Group: ",", Decimal: "."},
+	"ru": {Group: "\u00a0", Decimal: ","}, // no-break space
+	"zh": {Group: ",", Decimal: "."},
+}
+
+// LocaleFor returns the locale of a notifications.language; unknown and
+// empty languages use English
+func LocaleFor(language string) Locale {
+	if l, ok := locales[strings.ToLower(language)]; ok {
+		return l
+	}
+	return locales["en"]
+}
+
+// Formatter writes amounts in one locale with per-asset precision
+// overrides. The zero value and a nil Formatter use English and the
+// default precisions.
+type Formatter struct {
+	locale    Locale
+	precision map[string]int32
+}
+
+// New creates a formatter for a notifications.language and
+// notifications.displayPrecision
+func New(language string, precision map[string]int) *Formatter {
+	f := &Formatter{locale: LocaleFor(language), precision: make(map[string]int32, len(precision))}
+	for code, p := range precision {
+		f.precision[strings.ToUpper(strings.TrimSpace(code))] = int32(p)
+	}
+	return f
+}
+
+// Precision returns the number of decimals shown for code
+func (f *Formatter) Precision(code string) int32 {
+	if f != nil {
+		if p, ok := f.precision[strings.ToUpper(code)]; ok {
+			return p
+		}
+	}
+	return Precision(code)
+}
+
+// Number writes an amount of code without the code, e.g. a price in the
+// quote currency: "62,626.35"
+func (f *Formatter) Number(amount decimal.Decimal, code string) string {
+	places := f.Precision(code)
+	rounded := amount.Round(places)
+	var s string
+	if fixed[strings.ToUpper(code)] {
+		s = rounded.StringFixed(places)
+	} else {
+		s = rounded.String()
+	}
+	return f.localize(s)
+}
+
+// Amount writes an amount followed by its code: "62,626.35 USDT"
+func (f *Formatter) Amount(amount decimal.Decimal, code string) string {
+	return f.Number(amount, code) + " " + code
+}
+
+// localize groups the integer digits of a plain decimal string and swaps
+// in the locale's separators
+func (f *Formatter) localize(s string) string {
+	locale := locales["en"]
+	if f != nil && f.locale.Decimal != "" {
+		locale = f.locale
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+
+	minGrouping := max(locale.MinGrouping, 4)
+	if len(integer) >= minGrouping {
+		var b strings.Builder
+		head := len(integer) % 3
+		if head == 0 {
+			head = 3
+		}
+		b.WriteString(integer[:head])
+		for i := head; i < len(integer); i += 3 {
+			b.WriteString(locale.Group)
+			b.WriteString(integer[i : i+3])
+		}
+		integer = b.String()
+	}
+
+	if hasFraction {
+		return sign + integer + locale.Decimal + fraction
+	}
+	return sign + integer
+}
+
+type formatterKey struct{}
+
+// WithFormatter returns a copy of ctx whose amounts are written by f
+func WithFormatter(ctx context.Context, f *Formatter) context.Context {
+	return context.WithValue(ctx, formatterKey{}, f)
+}
+
+// FromContext returns the formatter set by WithFormatter, or nil, which
+// formats in English with the default precisions
+func FromContext(ctx context.Context) *Formatter {
+	f, _ := ctx.Value(formatterKey{}).(*Formatter)
+	return f
+}
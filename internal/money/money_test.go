@@ -0,0 +1,87 @@
+package money
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPrecision(t *testing.T) {
+	tests := []struct {
+		code string
+		want int32
+	}{
+		{"USDT", FiatPrecision},
+		{"eur", FiatPrecision},
+		{"BTC", BTCPrecision},
+		{"ETH", DefaultPrecision},
+		{"PEPE", DefaultPrecision},
+	}
+	for _, tt := range tests {
+		if got := Precision(tt.code); got != tt.want {
+			t.Errorf("Precision(%s) = %d, want %d", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFormatter_PrecisionOverride(t *testing.T) {
+	f := New("en", map[string]int{"eth": 2, "BTC": 4, "JPY": 0})
+	tests := []struct {
+		amount string
+		code   string
+		want   string
+	}{
+		{"1.23456", "ETH", "1.23 ETH"},
+		{"0.000049", "BTC", "0 BTC"},
+		{"0.00041", "BTC", "0.0004 BTC"},
+		{"15420.5", "JPY", "15,421 JPY"},
+		{"1.23456789", "SOL", "1.234568 SOL"}, // not overridden
+	}
+	for _, tt := range tests {
+		if got := f.Amount(decimal.RequireFromString(tt.amount), tt.code); got != tt.want {
+			t.Errorf("Amount(%s %s) = %q, want %q", tt.amount, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFormatter_NilAndContext(t *testing.T) {
+	amount := decimal.RequireFromString("62626.34782608695652")
+	var f *Formatter
+	if got := f.Number(amount, "USDT"); got != "62,626.35" {
+		t.Errorf("nil Formatter Number() = %q", got)
+	}
+	if got := (&Formatter{}).Number(amount, "USDT"); got != "62,626.35" {
+		t.Errorf("zero Formatter Number() = %q", got)
+	}
+
+	ctx := context.Background()
+	if FromContext(ctx) != nil {
+		t.Error("FromContext() without a formatter should be nil")
+	}
+	de := New("de", nil)
+	if got := FromContext(WithFormatter(ctx, de)).Number(amount, "USDT"); got != "62.626,35" {
+		t.Errorf("formatter from context Number() = %q", got)
+	}
+}
+
+func TestLocalize_Grouping(t *testing.T) {
+	en, es := New("en", nil), New("es", nil)
+	tests := []struct {
+		f    *Formatter
+		in   string
+		want string
+	}{
+		{en, "999", "999"},
+		{en, "1000", "1,000"},
+		{en, "123456", "123,456"},
+		{en, "-1234567.5", "-1,234,567.5"},
+		{es, "1234.5", "1234,5"},
+		{es, "12345.5", "12.345,5"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.localize(tt.in); got != tt.want {
+			t.Errorf("localize(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
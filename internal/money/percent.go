@@ -0,0 +1,194 @@
+package money
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	hundred     = decimal.NewFromInt(100)
+	tenThousand = decimal.NewFromInt(10000)
+)
+
+// Percent is a percentage as written in payloads, e.g. 5 for 5%. Code that
+// needs the fraction (0.05) asks for it with Fraction, so the two
+// conventions cannot be mixed up in a signature. The zero value is 0%.
+type Percent struct {
+	value decimal.Decimal
+}
+
+// HundredPercent is the whole of an amount
+var HundredPercent = Percent{hundred}
+
+// NewPercent returns the percentage d, e.g. 5 for 5%
+func NewPercent(d decimal.Decimal) Percent {
+	return Percent{d}
+}
+
+// PercentFromString parses a percentage such as "5" or "0.5"
+func PercentFromString(s string) (Percent, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return Percent{}, fmt.Errorf("invalid percentage %q", s)
+	}
+	return Percent{d}, nil
+}
+
+// PercentOf returns what percentage part is of whole, which must not be
+// zero; negative when their signs differ
+func PercentOf(part, whole decimal.Decimal) Percent {
+	return Percent{part.Div(whole).Mul(hundred)}
+}
+
+// Decimal returns the percentage as a number, 5 for 5%
+func (p Percent) Decimal() decimal.Decimal {
+	return p.value
+}
+
+// Fraction returns the percentage as a fraction, 0.05 for 5%
+func (p Percent) Fraction() decimal.Decimal {
+	return p.value.Div(hundred)
+}
+
+// BasisPoints returns the percentage in basis points, 500 for 5%
+func (p Percent) BasisPoints() BasisPoints {
+	return BasisPoints{p.value.Mul(hundred)}
+}
+
+// ApplyTo returns p of amount, 5 for 5% of 100
+func (p Percent) ApplyTo(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(p.value).Div(hundred)
+}
+
+// OffsetBelow returns price lowered by p of itself, 95 for 5% below 100. A
+// negative p raises it.
+func (p Percent) OffsetBelow(price decimal.Decimal) decimal.Decimal {
+	return price.Mul(hundred.Sub(p.value)).Div(hundred)
+}
+
+// OffsetAbove returns price raised by p of itself, 105 for 5% above 100. A
+// negative p lowers it.
+func (p Percent) OffsetAbove(price decimal.Decimal) decimal.Decimal {
+	return price.Mul(hundred.Add(p.value)).Div(hundred)
+}
+
+// Add returns p + q
+func (p Percent) Add(q Percent) Percent {
+	return Percent{p.value.Add(q.value)}
+}
+
+// Sub returns p - q
+func (p Percent) Sub(q Percent) Percent {
+	return Percent{p.value.Sub(q.value)}
+}
+
+// Round rounds p to places decimals of a percent
+func (p Percent) Round(places int32) Percent {
+	return Percent{p.value.Round(places)}
+}
+
+// Cmp compares p and q: -1 when p is smaller, 0 when equal, +1 when larger
+func (p Percent) Cmp(q Percent) int {
+	return p.value.Cmp(q.value)
+}
+
+// IsZero reports whether p is 0%
+func (p Percent) IsZero() bool {
+	return p.value.IsZero()
+}
+
+// IsPositive reports whether p is above 0%
+func (p Percent) IsPositive() bool {
+	return p.value.IsPositive()
+}
+
+// IsNegative reports whether p is below 0%
+func (p Percent) IsNegative() bool {
+	return p.value.IsNegative()
+}
+
+// String returns the percentage without a sign, "5" for 5%
+func (p Percent) String() string {
+	return p.value.String()
+}
+
+// MarshalJSON encodes p like the decimal.Decimal it replaces
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return p.value.MarshalJSON()
+}
+
+// UnmarshalJSON decodes a percentage written as a decimal.Decimal
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	return p.value.UnmarshalJSON(data)
+}
+
+// BasisPoints is a rate in hundredths of a percent, e.g. 10 for a 0.1% fee.
+// The zero value is 0 bps.
+type BasisPoints struct {
+	value decimal.Decimal
+}
+
+// NewBasisPoints returns d basis points
+func NewBasisPoints(d decimal.Decimal) BasisPoints {
+	return BasisPoints{d}
+}
+
+// BasisPointsFromString parses basis points such as "10" or "7.5"
+func BasisPointsFromString(s string) (BasisPoints, error) {
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return BasisPoints{}, fmt.Errorf("invalid basis points %q", s)
+	}
+	return BasisPoints{d}, nil
+}
+
+// Decimal returns the number of basis points, 10 for 10 bps
+func (b BasisPoints) Decimal() decimal.Decimal {
+	return b.value
+}
+
+// Fraction returns the rate as a fraction, 0.001 for 10 bps
+func (b BasisPoints) Fraction() decimal.Decimal {
+	return b.value.Div(tenThousand)
+}
+
+// Percent returns the rate as a percentage, 0.1 for 10 bps
+func (b BasisPoints) Percent() Percent {
+	return Percent{b.value.Div(hundred)}
+}
+
+// ApplyTo returns b of amount, 0.1 for 10 bps of 100
+func (b BasisPoints) ApplyTo(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(b.value).Div(tenThousand)
+}
+
+// Cmp compares b and c: -1 when b is smaller, 0 when equal, +1 when larger
+func (b BasisPoints) Cmp(c BasisPoints) int {
+	return b.value.Cmp(c.value)
+}
+
+// IsZero reports whether b is 0 bps
+func (b BasisPoints) IsZero() bool {
+	return b.value.IsZero()
+}
+
+// IsNegative reports whether b is below 0 bps
+func (b BasisPoints) IsNegative() bool {
+	return b.value.IsNegative()
+}
+
+// String returns the number of basis points, "10" for 10 bps
+func (b BasisPoints) String() string {
+	return b.value.String()
+}
+
+// MarshalJSON encodes b like the decimal.Decimal it replaces
+func (b BasisPoints) MarshalJSON() ([]byte, error) {
+	return b.value.MarshalJSON()
+}
+
+// UnmarshalJSON decodes basis points written as a decimal.Decimal
+func (b *BasisPoints) UnmarshalJSON(data []byte) error {
+	return b.value.UnmarshalJSON(data)
+}
@@ -0,0 +1,205 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func pct(s string) Percent { return NewPercent(d(s)) }
+
+func TestPercentFromString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // empty for an error
+	}{
+		{"5", "5"},
+		{"0.5", "0.5"},
+		{"12.25", "12.25"},
+		{" 5", ""},
+		{"0", "0"},
+		{"-3", "-3"},
+		{"", ""},
+		{"5%", ""},
+		{"five", ""},
+	}
+	for _, tt := range tests {
+		got, err := PercentFromString(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("PercentFromString(%q) = %s, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || !got.Decimal().Equal(d(tt.want)) {
+			t.Errorf("PercentFromString(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestPercent_Conversions(t *testing.T) {
+	tests := []struct {
+		percent  string
+		fraction string
+		bps      string
+	}{
+		{"5", "0.05", "500"},
+		{"0.1", "0.001", "10"},
+		{"100", "1", "10000"},
+		{"0", "0", "0"},
+		{"-2.5", "-0.025", "-250"},
+	}
+	for _, tt := range tests {
+		p := pct(tt.percent)
+		if got := p.Fraction(); !got.Equal(d(tt.fraction)) {
+			t.Errorf("%s%%.Fraction() = %s, want %s", tt.percent, got, tt.fraction)
+		}
+		if got := p.BasisPoints().Decimal(); !got.Equal(d(tt.bps)) {
+			t.Errorf("%s%%.BasisPoints() = %s, want %s", tt.percent, got, tt.bps)
+		}
+		if got := p.BasisPoints().Percent(); got.Cmp(p) != 0 {
+			t.Errorf("%s%% round trip through basis points = %s", tt.percent, got)
+		}
+	}
+}
+
+func TestPercent_Apply(t *testing.T) {
+	tests := []struct {
+		name                string
+		percent, amount     string
+		applied, below, abv string
+	}{
+		{"plain", "5", "100", "5", "95", "105"},
+		{"fractional", "0.5", "50000", "250", "49750", "50250"},
+		{"zero percent", "0", "62500", "0", "62500", "62500"},
+		{"zero amount", "5", "0", "0", "0", "0"},
+		{"whole", "100", "80", "80", "0", "160"},
+		{"negative offset", "-2", "100", "-2", "102", "98"},
+		{"more than whole", "150", "10", "15", "-5", "25"},
+		{"negative amount", "10", "-40", "-4", "-36", "-44"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, amount := pct(tt.percent), d(tt.amount)
+			if got := p.ApplyTo(amount); !got.Equal(d(tt.applied)) {
+				t.Errorf("ApplyTo(%s) = %s, want %s", tt.amount, got, tt.applied)
+			}
+			if got := p.OffsetBelow(amount); !got.Equal(d(tt.below)) {
+				t.Errorf("OffsetBelow(%s) = %s, want %s", tt.amount, got, tt.below)
+			}
+			if got := p.OffsetAbove(amount); !got.Equal(d(tt.abv)) {
+				t.Errorf("OffsetAbove(%s) = %s, want %s", tt.amount, got, tt.abv)
+			}
+		})
+	}
+}
+
+// Multiplying before dividing keeps amounts exact where a fraction would
+// repeat
+func TestPercent_ApplyToKeepsPrecision(t *testing.T) {
+	if got := pct("1").ApplyTo(d("1")).Mul(d("3")); !got.Equal(d("0.03")) {
+		t.Errorf("1%% of 1, tripled = %s", got)
+	}
+	if got := pct("5").OffsetBelow(d("50000.37")).Truncate(2); !got.Equal(d("47500.35")) {
+		t.Errorf("5%% below 50000.37 = %s, want 47500.35", got)
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		part, whole, want string
+	}{
+		{"5", "100", "5"},
+		{"12", "10", "120"},
+		{"0", "62500", "0"},
+		{"-2.5", "50", "-5"},
+		{"1", "3", "33.33333333333333"},
+	}
+	for _, tt := range tests {
+		if got := PercentOf(d(tt.part), d(tt.whole)); !got.Decimal().Equal(d(tt.want)) {
+			t.Errorf("PercentOf(%s, %s) = %s, want %s", tt.part, tt.whole, got, tt.want)
+		}
+	}
+}
+
+func TestPercent_Arithmetic(t *testing.T) {
+	if got := pct("5").Add(pct("0.5")); got.Cmp(pct("5.5")) != 0 {
+		t.Errorf("5%% + 0.5%% = %s", got)
+	}
+	if got := pct("5").Sub(pct("7")); got.Cmp(pct("-2")) != 0 || !got.IsNegative() {
+		t.Errorf("5%% - 7%% = %s", got)
+	}
+	if got := pct("12.345").Round(1); got.String() != "12.3" {
+		t.Errorf("Round(1) = %s", got)
+	}
+	if pct("99.9").Cmp(HundredPercent) >= 0 || HundredPercent.Cmp(pct("100.0")) != 0 {
+		t.Error("Cmp() against HundredPercent is wrong")
+	}
+
+	var zero Percent
+	if !zero.IsZero() || zero.IsPositive() || zero.IsNegative() || zero.String() != "0" {
+		t.Errorf("zero Percent = %s, want 0%%", zero)
+	}
+	if !zero.ApplyTo(d("100")).IsZero() || !zero.OffsetBelow(d("100")).Equal(d("100")) {
+		t.Error("zero Percent changes amounts")
+	}
+}
+
+func TestBasisPoints(t *testing.T) {
+	tests := []struct {
+		in, fraction, percent, applied string
+	}{
+		{"10", "0.001", "0.1", "0.1"},
+		{"7.5", "0.00075", "0.075", "0.075"},
+		{"0", "0", "0", "0"},
+		{"10000", "1", "100", "100"},
+		{"-5", "-0.0005", "-0.05", "-0.05"},
+	}
+	for _, tt := range tests {
+		b, err := BasisPointsFromString(tt.in)
+		if err != nil {
+			t.Fatalf("BasisPointsFromString(%q) error = %v", tt.in, err)
+		}
+		if got := b.Fraction(); !got.Equal(d(tt.fraction)) {
+			t.Errorf("%s bps Fraction() = %s, want %s", tt.in, got, tt.fraction)
+		}
+		if got := b.Percent().Decimal(); !got.Equal(d(tt.percent)) {
+			t.Errorf("%s bps Percent() = %s, want %s", tt.in, got, tt.percent)
+		}
+		if got := b.ApplyTo(d("100")); !got.Equal(d(tt.applied)) {
+			t.Errorf("%s bps ApplyTo(100) = %s, want %s", tt.in, got, tt.applied)
+		}
+	}
+	if _, err := BasisPointsFromString("10bps"); err == nil {
+		t.Error("BasisPointsFromString(10bps) succeeded")
+	}
+	var zero BasisPoints
+	if !zero.IsZero() || zero.IsNegative() || zero.Cmp(NewBasisPoints(d("1"))) != -1 {
+		t.Error("zero BasisPoints is wrong")
+	}
+}
+
+func TestPercent_JSON(t *testing.T) {
+	type record struct {
+		Percent Percent     `json:"percent"`
+		Rate    BasisPoints `json:"rate"`
+		Unset   Percent     `json:"unset,omitzero"`
+	}
+	data, err := json.Marshal(record{Percent: pct("5.5"), Rate: NewBasisPoints(d("10"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"percent":"5.5","rate":"10"}` {
+		t.Errorf("Marshal() = %s, want decimals as the fields had", data)
+	}
+	var back record
+	if err := json.Unmarshal([]byte(`{"percent":"5.5","rate":10}`), &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Percent.Cmp(pct("5.5")) != 0 || back.Rate.Cmp(NewBasisPoints(d("10"))) != 0 {
+		t.Errorf("Unmarshal() = %+v", back)
+	}
+}
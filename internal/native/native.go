@@ -0,0 +1,113 @@
+// Package native runs a strategy through the exchange's own recurring-buy
+// plan (strategy.engine "native"): instead of placing an order, each run
+// makes the plan match the strategy's amount, schedule and symbol.
+package native
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// Actions of a Report
+const (
+	ActionCreate = "create" // no plan bought the asset yet
+	ActionUpdate = "update" // the plan's amount or cadence changed
+	ActionNone   = "none"   // the plan already matches
+)
+
+// Report records how the plan was brought in line with the strategy
+type Report struct {
+	Action   string                  `json:"action"`
+	DryRun   bool                    `json:"dryRun"`
+	Plan     exchange.RecurringPlan  `json:"plan"`               // as saved, or as it would be in a dry run
+	Previous *exchange.RecurringPlan `json:"previous,omitempty"` // the plan before an update
+
+	// Duplicates are further plans buying the same asset, left alone; each
+	// buys on top of the strategy
+	Duplicates []exchange.RecurringPlan `json:"duplicates,omitempty"`
+}
+
+// Desired is the plan the strategy asks for as of now
+func Desired(strategy config.DCAStrategy, now time.Time) (exchange.RecurringPlan, error) {
+	base, quote, err := exchange.SplitSymbol(strategy.Symbol)
+	if err != nil {
+		return exchange.RecurringPlan{}, err
+	}
+	amount, err := decimal.NewFromString(strategy.QuoteAmount)
+	if err != nil {
+		return exchange.RecurringPlan{}, fmt.Errorf("invalid quote amount: %w", err)
+	}
+	cadence, err := strategy.NativeCadence(now)
+	if err != nil {
+		return exchange.RecurringPlan{}, err
+	}
+	return exchange.RecurringPlan{SourceAsset: quote, TargetAsset: base, Amount: amount, Cadence: cadence}, nil
+}
+
+// Matching returns the plans that spend source on target
+func Matching(plans []exchange.RecurringPlan, source, target string) []exchange.RecurringPlan {
+	var matching []exchange.RecurringPlan
+	for _, p := range plans {
+		if p.Buys(source, target) {
+			matching = append(matching, p)
+		}
+	}
+	return matching
+}
+
+// Sync brings the exchange's plan for want's assets in line with want,
+// creating it when there is none. An existing plan is adopted rather than
+// duplicated: one that already matches is kept, otherwise the first is
+// updated. In a dry run the change is only described.
+func Sync(ctx context.Context, buyer exchange.RecurringBuyer, want exchange.RecurringPlan, dryRun bool) (*Report, error) {
+	plans, err := buyer.RecurringPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Action: ActionCreate, DryRun: dryRun}
+
+	matching := Matching(plans, want.SourceAsset, want.TargetAsset)
+	if len(matching) > 0 {
+		current := 0
+		for i, p := range matching {
+			if p.Same(want) {
+				current = i
+				break
+			}
+		}
+		for i, p := range matching {
+			if i != current {
+				report.Duplicates = append(report.Duplicates, p)
+			}
+		}
+
+		plan := matching[current]
+		if plan.Same(want) {
+			report.Action, report.Plan = ActionNone, plan
+			return report, nil
+		}
+		report.Action, report.Previous = ActionUpdate, &plan
+		want.ID, want.Status = plan.ID, plan.Status
+	}
+
+	if dryRun {
+		report.Plan = want
+		return report, nil
+	}
+	var saved *exchange.RecurringPlan
+	if report.Action == ActionCreate {
+		saved, err = buyer.CreateRecurringPlan(ctx, want)
+	} else {
+		saved, err = buyer.UpdateRecurringPlan(ctx, want)
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Plan = *saved
+	return report, nil
+}
@@ -0,0 +1,129 @@
+package native
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// stubBuyer keeps plans in memory and records the writes
+type stubBuyer struct {
+	plans   []exchange.RecurringPlan
+	err     error
+	created []exchange.RecurringPlan
+	updated []exchange.RecurringPlan
+}
+
+var nextExecution = time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)
+
+func (s *stubBuyer) RecurringPlans(ctx context.Context) ([]exchange.RecurringPlan, error) {
+	return s.plans, s.err
+}
+
+func (s *stubBuyer) CreateRecurringPlan(ctx context.Context, plan exchange.RecurringPlan) (*exchange.RecurringPlan, error) {
+	s.created = append(s.created, plan)
+	plan.ID, plan.NextExecution = "new", nextExecution
+	return &plan, nil
+}
+
+func (s *stubBuyer) UpdateRecurringPlan(ctx context.Context, plan exchange.RecurringPlan) (*exchange.RecurringPlan, error) {
+	s.updated = append(s.updated, plan)
+	plan.NextExecution = nextExecution
+	return &plan, nil
+}
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+var weekly = config.Cadence{Cycle: config.CycleWeekly, Weekday: time.Monday, Hour: 9}
+
+func want() exchange.RecurringPlan {
+	return exchange.RecurringPlan{SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("50"), Cadence: weekly}
+}
+
+func TestDesired(t *testing.T) {
+	strategy := config.DCAStrategy{Symbol: "BTC-USDT", QuoteAmount: "50", Schedule: "0 10 * * 1", Timezone: "Europe/Berlin"}
+	got, err := Desired(strategy, time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Same(exchange.RecurringPlan{SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("50"), Cadence: config.Cadence{Cycle: config.CycleWeekly, Weekday: time.Monday, Hour: 8}}) {
+		t.Errorf("Desired() = %+v, want 50 USDT of BTC on Mondays at 08:00 UTC", got)
+	}
+
+	strategy.Schedule = "15 10 * * 1"
+	if _, err := Desired(strategy, time.Now()); err == nil {
+		t.Error("Desired() accepted a schedule off the hour")
+	}
+}
+
+func TestSync(t *testing.T) {
+	eth := exchange.RecurringPlan{ID: "1", SourceAsset: "USDT", TargetAsset: "ETH", Amount: d("50"), Cadence: weekly}
+	current := exchange.RecurringPlan{ID: "2", SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("50"), Cadence: weekly, Status: "ONGOING"}
+	stale := exchange.RecurringPlan{ID: "3", SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("20"), Cadence: config.Cadence{Cycle: config.CycleDaily, Hour: 9}, Status: "PAUSED"}
+
+	tests := []struct {
+		name           string
+		plans          []exchange.RecurringPlan
+		dryRun         bool
+		wantAction     string
+		wantID         string
+		wantDuplicates int
+		wantWrites     int
+	}{
+		{name: "no plan", plans: []exchange.RecurringPlan{eth}, wantAction: ActionCreate, wantID: "new", wantWrites: 1},
+		{name: "no plan, dry run", plans: []exchange.RecurringPlan{eth}, dryRun: true, wantAction: ActionCreate},
+		{name: "matching plan", plans: []exchange.RecurringPlan{eth, current}, wantAction: ActionNone, wantID: "2"},
+		{name: "stale plan", plans: []exchange.RecurringPlan{stale}, wantAction: ActionUpdate, wantID: "3", wantWrites: 1},
+		{name: "stale plan, dry run", plans: []exchange.RecurringPlan{stale}, dryRun: true, wantAction: ActionUpdate, wantID: "3"},
+		{name: "matching plan kept over a duplicate", plans: []exchange.RecurringPlan{stale, current}, wantAction: ActionNone, wantID: "2", wantDuplicates: 1},
+		{name: "first of two stale plans updated", plans: []exchange.RecurringPlan{stale, {ID: "4", SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("10"), Cadence: weekly}}, wantAction: ActionUpdate, wantID: "3", wantDuplicates: 1, wantWrites: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buyer := &stubBuyer{plans: tt.plans}
+			report, err := Sync(context.Background(), buyer, want(), tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Action != tt.wantAction || report.Plan.ID != tt.wantID || len(report.Duplicates) != tt.wantDuplicates {
+				t.Errorf("Sync() = %s of plan %q with %d duplicates, want %s of %q with %d", report.Action, report.Plan.ID, len(report.Duplicates), tt.wantAction, tt.wantID, tt.wantDuplicates)
+			}
+			if writes := len(buyer.created) + len(buyer.updated); writes != tt.wantWrites {
+				t.Errorf("%d writes, want %d", writes, tt.wantWrites)
+			}
+			if report.DryRun != tt.dryRun || report.Action != ActionNone && !report.Plan.Same(want()) {
+				t.Errorf("report = %+v, want the desired plan", report)
+			}
+		})
+	}
+}
+
+func TestSync_Update(t *testing.T) {
+	stale := exchange.RecurringPlan{ID: "3", SourceAsset: "USDT", TargetAsset: "BTC", Amount: d("20"), Cadence: weekly, Status: "PAUSED"}
+	buyer := &stubBuyer{plans: []exchange.RecurringPlan{stale}}
+	report, err := Sync(context.Background(), buyer, want(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buyer.updated) != 1 || buyer.updated[0].ID != "3" || !buyer.updated[0].Amount.Equal(d("50")) {
+		t.Errorf("updated = %+v, want plan 3 at 50", buyer.updated)
+	}
+	if report.Previous == nil || !report.Previous.Amount.Equal(d("20")) {
+		t.Errorf("Previous = %+v, want the plan before the update", report.Previous)
+	}
+	if report.Plan.Status != "PAUSED" || !report.Plan.NextExecution.Equal(nextExecution) {
+		t.Errorf("Plan = %+v, want its status kept and the next execution", report.Plan)
+	}
+}
+
+func TestSync_ListError(t *testing.T) {
+	buyer := &stubBuyer{err: errors.New("Invalid API-key")}
+	if _, err := Sync(context.Background(), buyer, want(), true); err == nil {
+		t.Error("Sync() error = nil, want the listing failure")
+	}
+}
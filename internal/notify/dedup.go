@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Deliveries records when each channel delivery of an event went out, by
+// the event's Key and the channel's position in its route. It is kept in
+// state.status, so a retried invocation does not send it again.
+type Deliveries struct {
+	Delivered map[string]time.Time `json:"delivered"`
+}
+
+// Has reports whether key was delivered
+func (d *Deliveries) Has(key string) bool {
+	_, ok := d.Delivered[key]
+	return ok
+}
+
+// Add records key as delivered at now and drops the keys delivered more
+// than config.NotificationDedupRetention before, whose invocations are no
+// longer retried
+func (d *Deliveries) Add(key string, now time.Time) {
+	if d.Delivered == nil {
+		d.Delivered = map[string]time.Time{}
+	}
+	maps.DeleteFunc(d.Delivered, func(_ string, at time.Time) bool {
+		return now.Sub(at) > config.NotificationDedupRetention
+	})
+	d.Delivered[key] = now.UTC()
+}
+
+// DeliveryStore keeps the Deliveries across invocations
+type DeliveryStore interface {
+	Deliveries(ctx context.Context) (*Deliveries, error)
+	SaveDeliveries(ctx context.Context, deliveries *Deliveries) error
+}
+
+// SetDeliveryStore makes d record delivered events in store and skip those
+// an earlier attempt of the invocation already delivered. Without a store
+// an event is delivered at most once per invocation.
+func (d *Dispatcher) SetDeliveryStore(store DeliveryStore) {
+	d.store = store
+}
+
+// invocationID identifies the invocation across its retries: the Lambda
+// request ID, which the retries of an asynchronous invocation keep, or the
+// execution ID where there is none
+func invocationID(ctx context.Context) string {
+	if id := run.SourceFrom(ctx).InvocationID; id != "" {
+		return id
+	}
+	return run.ID(ctx)
+}
+
+// dedupKey is the Key of the n-th event of its type raised for strategy
+// in the invocation, "run" standing in for run-level events, e.g.
+// "<invocation>/BTC-USDT/postTrade/1". Retries raise their events in the
+// same order, so each gets the key of the event it repeats.
+func dedupKey(ctx context.Context, strategy string, eventType EventType, n int) string {
+	if strategy == "" {
+		strategy = "run"
+	}
+	return invocationID(ctx) + "/" + strategy + "/" + string(eventType) + "/" + strconv.Itoa(n)
+}
+
+// assignKey sets the Key of an event raised under ctx that has none
+func (d *Dispatcher) assignKey(ctx context.Context, event *Event) {
+	if event.Key != "" {
+		return
+	}
+	strategy := StrategyFrom(ctx)
+	d.mu.Lock()
+	counter := strategy + "/" + string(event.Type)
+	d.raised[counter]++
+	n := d.raised[counter]
+	d.mu.Unlock()
+	event.Key = dedupKey(ctx, strategy, event.Type, n)
+}
+
+// deliveryKey is the key of delivering event to the i-th notifier of its
+// route
+func deliveryKey(event Event, i int) string {
+	return event.Key + "#" + strconv.Itoa(i)
+}
+
+// delivered reports whether key went out already, in this invocation or,
+// with a DeliveryStore, an earlier attempt of it. A store that cannot be
+// read is only a warning: sending twice beats not sending.
+func (d *Dispatcher) delivered(ctx context.Context, key string) bool {
+	d.deliveryMu.Lock()
+	defer d.deliveryMu.Unlock()
+	d.loadDeliveries(ctx)
+	return d.deliveries.Has(key)
+}
+
+// record marks key delivered, saving it to the DeliveryStore if any
+func (d *Dispatcher) record(ctx context.Context, key string) {
+	d.deliveryMu.Lock()
+	defer d.deliveryMu.Unlock()
+	d.loadDeliveries(ctx)
+	d.deliveries.Add(key, time.Now())
+	if d.store == nil {
+		return
+	}
+	if err := d.store.SaveDeliveries(ctx, d.deliveries); err != nil {
+		run.Warn(ctx, "notify", "dedup", fmt.Errorf("failed to record delivery of %s: %w", key, err))
+	}
+}
+
+// loadDeliveries reads the store once per dispatcher; the caller holds
+// deliveryMu
+func (d *Dispatcher) loadDeliveries(ctx context.Context) {
+	if d.deliveries != nil {
+		return
+	}
+	d.deliveries = &Deliveries{}
+	if d.store == nil {
+		return
+	}
+	deliveries, err := d.store.Deliveries(ctx)
+	if err != nil {
+		run.Warn(ctx, "notify", "dedup", err)
+		return
+	}
+	if deliveries != nil {
+		d.deliveries = deliveries
+	}
+}
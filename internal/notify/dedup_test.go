@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// memoryDeliveries is a DeliveryStore shared by the attempts of a test
+type memoryDeliveries struct {
+	saved *Deliveries
+	err   error
+}
+
+func (m *memoryDeliveries) Deliveries(ctx context.Context) (*Deliveries, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.saved == nil {
+		return &Deliveries{}, nil
+	}
+	// Every attempt reads its own copy, as from S3
+	copied := &Deliveries{Delivered: map[string]time.Time{}}
+	for k, v := range m.saved.Delivered {
+		copied.Delivered[k] = v
+	}
+	return copied, nil
+}
+
+func (m *memoryDeliveries) SaveDeliveries(ctx context.Context, deliveries *Deliveries) error {
+	m.saved = deliveries
+	return nil
+}
+
+// telegram stands in for the Telegram channel
+type telegram struct{ recorder }
+
+func (*telegram) Name() string { return "telegram" }
+
+// attempt is the context of one attempt of the Lambda invocation req-1,
+// each with its own execution ID
+func attempt(n int) context.Context {
+	ctx := run.WithSource(context.Background(), run.Source{Trigger: run.TriggerDirect, InvocationID: "req-1"})
+	return run.WithID(ctx, "exec-"+strconv.Itoa(n))
+}
+
+func TestDispatcher_Keys(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(config.NotificationConfig{}, r)
+	ctx := WithStrategy(attempt(1), "BTC-USDT")
+
+	d.Dispatch(ctx, Event{Type: EventPostTrade, Summary: "♻️ Recovered"})
+	d.Dispatch(ctx, Event{Type: EventPostTrade, Summary: "✅ Bought"})
+	d.Dispatch(attempt(1), Event{Type: EventError, Summary: "🚨 DCA run failed"})
+	d.Dispatch(WithStrategy(run.WithID(context.Background(), "exec-9"), "ETH-USDT"), Event{Type: EventSkip})
+
+	want := []string{"req-1/BTC-USDT/postTrade/1", "req-1/BTC-USDT/postTrade/2", "req-1/run/error/1", "exec-9/ETH-USDT/skip/1"}
+	if len(r.events) != len(want) {
+		t.Fatalf("delivered %d events, want %d", len(r.events), len(want))
+	}
+	for i, event := range r.events {
+		if event.Key != want[i] {
+			t.Errorf("event %d key = %q, want %q", i, event.Key, want[i])
+		}
+	}
+}
+
+func TestDispatcher_RetryDeliversOnce(t *testing.T) {
+	store := &memoryDeliveries{}
+	tg := &telegram{}
+	flaky := &recorder{err: errors.New("webhook timeout")}
+
+	// The first attempt notifies, then fails late; Lambda retries it
+	for n := 1; n <= 2; n++ {
+		d := NewDispatcher(config.NotificationConfig{}, tg, flaky)
+		d.SetDeliveryStore(store)
+		ctx := WithStrategy(attempt(n), "BTC-USDT")
+		d.Dispatch(ctx, Event{Type: EventPostTrade, Summary: "✅ Bought"})
+		flaky.err = nil
+	}
+
+	if len(tg.events) != 1 {
+		t.Errorf("Telegram called %d times, want exactly once", len(tg.events))
+	}
+	if len(flaky.events) != 2 {
+		t.Errorf("failed channel called %d times, want the retry to deliver it again", len(flaky.events))
+	}
+	if len(store.saved.Delivered) != 2 {
+		t.Errorf("deliveries = %v, want both channels recorded", store.saved.Delivered)
+	}
+}
+
+func TestDispatcher_RetryDigest(t *testing.T) {
+	store := &memoryDeliveries{}
+	tg := &telegram{}
+	for n := 1; n <= 2; n++ {
+		d := NewDispatcher(config.NotificationConfig{Digest: true}, tg)
+		d.SetDeliveryStore(store)
+		ctx := attempt(n)
+		d.Dispatch(ctx, Event{Type: EventLowBalance, Summary: "⚠️ Low"})
+		d.Dispatch(ctx, Event{Type: EventError, Summary: "🚨 DCA run failed"})
+		d.Flush(ctx)
+	}
+	if len(tg.events) != 1 || tg.events[0].Type != EventDigest || tg.events[0].Key != "req-1/run/digest/1" {
+		t.Errorf("Telegram received %+v, want one digest", tg.events)
+	}
+}
+
+func TestDispatcher_DedupWithoutStore(t *testing.T) {
+	tg := &telegram{}
+	d := NewDispatcher(config.NotificationConfig{}, tg)
+	event := Event{Type: EventPostTrade, Summary: "✅ Bought", Key: "req-1/BTC-USDT/postTrade/1"}
+	d.Dispatch(attempt(1), event)
+	d.Dispatch(attempt(1), event)
+	if len(tg.events) != 1 {
+		t.Errorf("Telegram called %d times, want once within the invocation", len(tg.events))
+	}
+
+	// A store that cannot be read does not stop delivery
+	warnings := &run.Warnings{}
+	ctx := run.WithWarnings(attempt(2), warnings)
+	d = NewDispatcher(config.NotificationConfig{}, tg)
+	d.SetDeliveryStore(&memoryDeliveries{err: errors.New("access denied")})
+	d.Dispatch(ctx, Event{Type: EventError})
+	if len(tg.events) != 2 || len(warnings.List()) != 1 {
+		t.Errorf("Telegram called %d times with warnings %+v, want the event sent and a warning", len(tg.events), warnings.List())
+	}
+}
+
+func TestDeliveries_Add(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 8, 0, 0, 0, time.UTC)
+	d := &Deliveries{}
+	d.Add("old", now.Add(-config.NotificationDedupRetention-time.Minute))
+	d.Add("recent", now.Add(-time.Hour))
+	d.Add("new", now)
+	if d.Has("old") || !d.Has("recent") || !d.Has("new") {
+		t.Errorf("Delivered = %v, want the expired key dropped", d.Delivered)
+	}
+}
@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// EventDigest is the combined event sent by Flush
+const EventDigest EventType = "digest"
+
+// digestKinds orders the events of a digest: errors first, then fills,
+// balance alerts and skips. name is used to count them in the summary.
+var digestKinds = []struct {
+	event EventType
+	name  string
+}{
+	{EventError, "error"},
+	{EventPostTrade, "fill"},
+	{EventLowBalance, "low balance"},
+	{EventHoldings, "holdings alert"},
+	{EventSkip, "skip"},
+	{EventPreTrade, "announcement"},
+}
+
+// Digest combines the events of one invocation into a single event whose
+// details are a per-strategy table, one row per event. Error rows carry the
+// error's details, since the digest is all the owner gets to see.
+func Digest(events []Event) Event {
+	sorted := append([]Event(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := digestRank(sorted[i].Type), digestRank(sorted[j].Type)
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Symbol < sorted[j].Symbol
+	})
+
+	digest := Event{Type: EventDigest}
+	var counts []string
+	for _, kind := range digestKinds {
+		if n := countType(events, kind.event); n > 0 {
+			counts = append(counts, plural(n, kind.name))
+		}
+	}
+	digest.Summary = "📋 Run digest: " + strings.Join(counts, ", ")
+
+	symbols := map[string]bool{}
+	for _, event := range sorted {
+		row := Detail{Label: event.Symbol, Value: event.Summary}
+		if row.Label == "" {
+			row.Label = "run"
+		}
+		if event.Type == EventError {
+			var parts []string
+			for _, detail := range event.Details {
+				parts = append(parts, detail.Label+": "+detail.Value)
+			}
+			if len(parts) > 0 {
+				row.Value += " (" + strings.Join(parts, "; ") + ")"
+			}
+		}
+		digest.Details = append(digest.Details, row)
+		if event.Symbol != "" {
+			symbols[event.Symbol] = true
+		}
+	}
+	if len(symbols) == 1 {
+		digest.Symbol = sorted[0].Symbol
+	}
+	return digest
+}
+
+// Text renders an event as plain text: the summary, then its details as a
+// table with aligned labels
+func (e Event) Text() string {
+	width := 0
+	for _, detail := range e.Details {
+		width = max(width, utf8.RuneCountInString(detail.Label))
+	}
+	var b strings.Builder
+	b.WriteString(e.Summary)
+	b.WriteByte('\n')
+	for _, detail := range e.Details {
+		pad := width - utf8.RuneCountInString(detail.Label)
+		fmt.Fprintf(&b, "%s%s  %s\n", detail.Label, strings.Repeat(" ", pad), detail.Value)
+	}
+	return b.String()
+}
+
+func digestRank(event EventType) int {
+	for i, kind := range digestKinds {
+		if kind.event == event {
+			return i
+		}
+	}
+	return len(digestKinds)
+}
+
+func countType(events []Event, event EventType) int {
+	n := 0
+	for _, e := range events {
+		if e.Type == event {
+			n++
+		}
+	}
+	return n
+}
+
+func plural(n int, name string) string {
+	if n == 1 {
+		return "1 " + name
+	}
+	return fmt.Sprintf("%d %ss", n, name)
+}
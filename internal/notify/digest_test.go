@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// runEvents is what a five-symbol run with one failure raises, in order
+var runEvents = []Event{
+	{Type: EventPostTrade, Symbol: "BTC-USDT", Notional: decimal.NewFromInt(25), Summary: "✅ Bought 0.0005 BTC-USDT for 25 USDT"},
+	{Type: EventSkip, Symbol: "SOL-USDT", Summary: "⏭️ SOL-USDT run skipped (calendar): SAT is a skipped weekday"},
+	{Type: EventPostTrade, Symbol: "ETH-USDT", Notional: decimal.NewFromInt(25), Summary: "✅ Bought 0.0075 ETH-USDT for 25 USDT"},
+	{Type: EventLowBalance, Symbol: "ETH-USDT", Summary: "⚠️ USDT balance is below threshold", Details: []Detail{{Label: "Current Balance", Value: "40 USDT"}}},
+	{Type: EventError, Symbol: "DOGE-USDT", Summary: "🚨 DCA run failed", Details: []Detail{{Label: "Error", Value: "insufficient balance"}}},
+	{Type: EventPostTrade, Symbol: "ADA-USDT", Notional: decimal.NewFromInt(25), Summary: "✅ Bought 60 ADA-USDT for 25 USDT"},
+}
+
+func TestDigest_Golden(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []Event
+	}{
+		{"digest", runEvents},
+		{"digest_single_symbol", []Event{
+			{Type: EventLowBalance, Symbol: "BTC-EUR", Summary: "⚠️ EUR balance is below threshold"},
+			{Type: EventPostTrade, Symbol: "BTC-EUR", Summary: "✅ Bought 0.0004 BTC-EUR for 20 EUR"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Digest(tt.events).Text()
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestDispatcher_Digest(t *testing.T) {
+	cfg := config.NotificationConfig{
+		Digest:         true,
+		DigestExcludes: []string{config.NotifyError},
+		Events:         map[string]config.EventRule{config.NotifyPreTrade: {Enabled: boolPtr(true)}},
+	}
+	a, b := &recorder{}, &recorder{}
+	d := NewDispatcher(cfg, a, b)
+	ctx := context.Background()
+
+	for _, event := range append([]Event{{Type: EventPreTrade, Summary: "about to buy"}}, runEvents...) {
+		if err := d.Dispatch(ctx, event); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	}
+	// preTrade and the excluded error went out immediately
+	if len(a.events) != 2 || a.events[0].Type != EventPreTrade || a.events[1].Type != EventError {
+		t.Fatalf("before Flush notifier received %+v, want preTrade and error", a.events)
+	}
+
+	if err := d.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	for _, r := range []*recorder{a, b} {
+		last := r.events[len(r.events)-1]
+		if last.Type != EventDigest || len(last.Details) != 5 {
+			t.Errorf("notifier received %+v, want a digest of 5 events", last)
+		}
+	}
+
+	// Nothing left to flush
+	if err := d.Flush(ctx); err != nil || len(a.events) != 3 {
+		t.Errorf("second Flush() sent %d events, error = %v", len(a.events)-3, err)
+	}
+}
+
+func TestDispatcher_DigestSingleEvent(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(config.NotificationConfig{Digest: true}, r)
+	event := Event{Type: EventPostTrade, Summary: "✅ Bought"}
+	d.Dispatch(context.Background(), event)
+	d.Flush(context.Background())
+	if len(r.events) != 1 || r.events[0].Summary != event.Summary {
+		t.Errorf("notifier received %+v, want the event unchanged", r.events)
+	}
+}
@@ -0,0 +1,317 @@
+// Package notify routes run events (pre-trade, post-trade, skip, error, low
+// balance, holdings) to notification channels. The Dispatcher decides per event
+// whether to send at all, then fans out to every configured Notifier.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// EventType identifies a kind of notification
+type EventType string
+
+const (
+	EventPreTrade   EventType = config.NotifyPreTrade
+	EventPostTrade  EventType = config.NotifyPostTrade
+	EventSkip       EventType = config.NotifySkip
+	EventError      EventType = config.NotifyError
+	EventLowBalance EventType = config.NotifyLowBalance
+	EventHoldings   EventType = config.NotifyHoldings
+)
+
+// Detail is a labelled value shown below the event summary
+type Detail struct {
+	Label string
+	Value string
+}
+
+// Event is a single notification
+type Event struct {
+	Type     EventType
+	Symbol   string
+	Notional decimal.Decimal // quote amount of the order involved, zero if none
+	Summary  string          // one line, e.g. "About to buy 25 USDT of BTC-USDT"
+	Details  []Detail
+
+	// Key identifies the event across retries of the invocation, so it is
+	// delivered once; Dispatch sets it
+	Key string
+}
+
+// Notifier delivers events to one channel
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Dispatcher filters events by the notifications config and fans them out.
+// Events raised under WithStrategy for a strategy added with Route use that
+// strategy's merged config and channels; all others, such as run-level
+// errors, use the global ones.
+type Dispatcher struct {
+	global     *route
+	strategies map[string]*route
+
+	mu     sync.Mutex     // guards the pending events of every route and raised
+	raised map[string]int // events raised so far, by strategy and type
+
+	store      DeliveryStore
+	deliveries *Deliveries // loaded from store on first use
+	deliveryMu sync.Mutex  // guards deliveries
+}
+
+// route is the config and channels events are sent with
+type route struct {
+	name      string // the strategy, "" for the global route
+	cfg       config.NotificationConfig
+	notifiers []Notifier
+	pending   []Event // held for the digest until Flush
+}
+
+// NewDispatcher creates a dispatcher for the given config and channels
+func NewDispatcher(cfg config.NotificationConfig, notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{global: &route{cfg: cfg, notifiers: notifiers}, strategies: map[string]*route{}, raised: map[string]int{}}
+}
+
+// Route sends the events of one strategy with its notifications override
+// merged over the global config (see config.NotificationConfig.Merge).
+// notifiers are the override's own channels: without any the global
+// channels are kept, with override.channels "append" they are added to
+// the global channels, and otherwise they replace them.
+func (d *Dispatcher) Route(strategy string, override config.NotificationConfig, notifiers ...Notifier) {
+	r := &route{name: strategy, cfg: d.global.cfg.Merge(override), notifiers: d.global.notifiers}
+	switch {
+	case len(notifiers) == 0:
+	case override.AppendsChannels():
+		r.notifiers = append(slices.Clip(d.global.notifiers), notifiers...)
+	default:
+		r.notifiers = notifiers
+	}
+	d.strategies[strategy] = r
+}
+
+// route picks the route of the strategy in ctx, or the global one
+func (d *Dispatcher) route(ctx context.Context) *route {
+	if r, ok := d.strategies[StrategyFrom(ctx)]; ok {
+		return r
+	}
+	return d.global
+}
+
+// Enabled reports whether an event passes its global toggle and conditions
+func (d *Dispatcher) Enabled(event Event) bool {
+	return enabled(d.global.cfg, event)
+}
+
+func enabled(cfg config.NotificationConfig, event Event) bool {
+	rule, ok := cfg.Events[string(event.Type)]
+	if !ok {
+		return config.EventEnabledByDefault(string(event.Type))
+	}
+
+	enabled := config.EventEnabledByDefault(string(event.Type))
+	if rule.Enabled != nil {
+		enabled = *rule.Enabled
+	}
+	if !enabled {
+		return false
+	}
+
+	if min, ok := rule.MinNotional(); ok && event.Notional.LessThan(min) {
+		return false
+	}
+	return true
+}
+
+// Dispatch sends an enabled event to every notifier of its route. All
+// notifiers are tried; their failures are returned joined. With
+// notifications.digest set the event is held until Flush instead.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	d.assignKey(ctx, &event)
+	r := d.route(ctx)
+	if !enabled(r.cfg, event) {
+		log.Printf("🔕 %s notification disabled by config", event.Type)
+		return nil
+	}
+	if r.cfg.Digested(string(event.Type)) {
+		d.mu.Lock()
+		r.pending = append(r.pending, event)
+		d.mu.Unlock()
+		log.Printf("📥 %s notification held for the digest", event.Type)
+		return nil
+	}
+	return d.send(ctx, r, event)
+}
+
+// Flush sends the events held for the digest as one message per notifier,
+// separately for each route. A single held event is sent as it is.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	routes := []*route{d.global}
+	for _, strategy := range slices.Sorted(maps.Keys(d.strategies)) {
+		routes = append(routes, d.strategies[strategy])
+	}
+
+	var errs []error
+	for _, r := range routes {
+		d.mu.Lock()
+		pending := r.pending
+		r.pending = nil
+		d.mu.Unlock()
+
+		switch len(pending) {
+		case 0:
+		case 1:
+			errs = append(errs, d.send(ctx, r, pending[0]))
+		default:
+			digest := Digest(pending)
+			d.assignKey(WithStrategy(ctx, r.name), &digest)
+			errs = append(errs, d.send(ctx, r, digest))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// send fans event out to every notifier that has not received it yet.
+// Success notifications carry the run's warnings so far; a failed delivery
+// is recorded as a run warning.
+func (d *Dispatcher) send(ctx context.Context, r *route, event Event) error {
+	if event.Type == EventPostTrade || event.Type == EventDigest {
+		if summary := run.WarningSummary(run.WarningsFrom(ctx).List()); summary != "" {
+			event.Details = append(slices.Clip(event.Details), Detail{Label: "Warnings", Value: summary})
+		}
+	}
+
+	var errs []error
+	for i, n := range r.notifiers {
+		key := deliveryKey(event, i)
+		if d.delivered(ctx, key) {
+			log.Printf("🔁 %s notification already delivered to %s (%s)", event.Type, notifierName(n), key)
+			continue
+		}
+		spanCtx, end := run.StartSpan(ctx, "notify."+string(event.Type))
+		err := simulateDelivery(ctx, event)
+		if err == nil {
+			err = n.Notify(spanCtx, event)
+		}
+		end()
+		if err != nil {
+			run.Warn(ctx, notifierName(n), "delivery", err)
+			errs = append(errs, err)
+			continue
+		}
+		d.record(ctx, key)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", event.Type, err)
+	}
+	return nil
+}
+
+// simulateDelivery fails the delivery of event when the run simulates a
+// notification failure. The error notification is still delivered, so the
+// simulated failure is reported like a real one.
+func simulateDelivery(ctx context.Context, event Event) error {
+	if event.Type == EventError {
+		return nil
+	}
+	return run.Simulate(ctx, config.SimulateNotification)
+}
+
+// Named is implemented by notifiers that name their channel in warnings
+type Named interface {
+	Name() string
+}
+
+func notifierName(n Notifier) string {
+	if named, ok := n.(Named); ok {
+		return named.Name()
+	}
+	return "notifier"
+}
+
+// LogNotifier writes events to the log in place of a real channel
+type LogNotifier struct {
+	Channel string // optional label of the channel stood in for, e.g. "telegram (BTC-USDT)"
+}
+
+// Name is "log"
+func (LogNotifier) Name() string {
+	return "log"
+}
+
+// Notify logs the event
+func (n LogNotifier) Notify(ctx context.Context, event Event) error {
+	for _, line := range n.lines(ctx, event) {
+		log.Print(line)
+	}
+	return nil
+}
+
+// Render returns the lines Notify logs
+func (n LogNotifier) Render(ctx context.Context, event Event) (string, error) {
+	return strings.Join(n.lines(ctx, event), "\n") + "\n", nil
+}
+
+func (n LogNotifier) lines(ctx context.Context, event Event) []string {
+	lines := []string{fmt.Sprintf("📢 Would send %s notification: %s", event.Type, event.Summary)}
+	if n.Channel != "" {
+		lines[0] = fmt.Sprintf("📢 Would send %s notification to %s: %s", event.Type, n.Channel, event.Summary)
+	}
+	for _, detail := range event.Details {
+		lines = append(lines, fmt.Sprintf("   %s: %s", detail.Label, detail.Value))
+	}
+	return append(lines, fmt.Sprintf("   Execution ID: %s", run.ID(ctx)))
+}
+
+type strategyKey struct{}
+
+// WithStrategy returns a copy of ctx whose events come from the named
+// strategy, so the dispatcher sends them on that strategy's route
+func WithStrategy(ctx context.Context, strategy string) context.Context {
+	return context.WithValue(ctx, strategyKey{}, strategy)
+}
+
+// StrategyFrom returns the strategy set by WithStrategy, or "" for
+// run-level events
+func StrategyFrom(ctx context.Context) string {
+	strategy, _ := ctx.Value(strategyKey{}).(string)
+	return strategy
+}
+
+type scopeKey struct{}
+
+type scope struct {
+	dispatcher *Dispatcher
+}
+
+// WithScope returns a context that can hold the dispatcher of one invocation
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{})
+}
+
+// SetDispatcher stores d in the invocation scope so code outside the
+// handler (error middleware) uses the same configured dispatcher.
+// Without a scope it does nothing.
+func SetDispatcher(ctx context.Context, d *Dispatcher) {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.dispatcher = d
+	}
+}
+
+// FromContext returns the dispatcher stored in the invocation scope, or nil
+func FromContext(ctx context.Context) *Dispatcher {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		return s.dispatcher
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+type recorder struct {
+	events []Event
+	err    error
+}
+
+func (r *recorder) Notify(ctx context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return r.err
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestDispatcher_Enabled(t *testing.T) {
+	cfg := config.NotificationConfig{
+		Events: map[string]config.EventRule{
+			config.NotifyPreTrade: {Enabled: boolPtr(true), Conditions: map[string]string{"minNotional": "100"}},
+			config.NotifySkip:     {Enabled: boolPtr(false)},
+			config.NotifyError:    {Enabled: boolPtr(true)},
+		},
+	}
+	d := NewDispatcher(cfg)
+
+	tests := []struct {
+		name     string
+		event    Event
+		expected bool
+	}{
+		{"pre_trade_above_min", Event{Type: EventPreTrade, Notional: decimal.NewFromInt(250)}, true},
+		{"pre_trade_at_min", Event{Type: EventPreTrade, Notional: decimal.NewFromInt(100)}, true},
+		{"pre_trade_below_min", Event{Type: EventPreTrade, Notional: decimal.NewFromInt(25)}, false},
+		{"skip_disabled", Event{Type: EventSkip}, false},
+		{"error_enabled", Event{Type: EventError}, true},
+		{"post_trade_default", Event{Type: EventPostTrade, Notional: decimal.NewFromInt(1)}, true},
+		{"low_balance_default", Event{Type: EventLowBalance}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.Enabled(tt.event); got != tt.expected {
+				t.Errorf("Enabled() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDispatcher_PreTradeOffByDefault(t *testing.T) {
+	d := NewDispatcher(config.NotificationConfig{})
+	if d.Enabled(Event{Type: EventPreTrade, Notional: decimal.NewFromInt(1000)}) {
+		t.Error("preTrade must be opt-in")
+	}
+	if !d.Enabled(Event{Type: EventError}) {
+		t.Error("error must be enabled by default")
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	cfg := config.NotificationConfig{
+		Events: map[string]config.EventRule{config.NotifySkip: {Enabled: boolPtr(false)}},
+	}
+	a, b := &recorder{}, &recorder{}
+	d := NewDispatcher(cfg, a, b)
+	ctx := context.Background()
+
+	if err := d.Dispatch(ctx, Event{Type: EventSkip}); err != nil {
+		t.Fatalf("Dispatch(skip) error = %v", err)
+	}
+	if err := d.Dispatch(ctx, Event{Type: EventError, Summary: "boom"}); err != nil {
+		t.Fatalf("Dispatch(error) error = %v", err)
+	}
+
+	for _, r := range []*recorder{a, b} {
+		if len(r.events) != 1 || r.events[0].Type != EventError {
+			t.Errorf("notifier received %+v, want only the error event", r.events)
+		}
+	}
+}
+
+func TestDispatcher_DispatchFailure(t *testing.T) {
+	failing := &recorder{err: errors.New("chat not found")}
+	working := &recorder{}
+	d := NewDispatcher(config.NotificationConfig{}, failing, working)
+
+	err := d.Dispatch(context.Background(), Event{Type: EventLowBalance})
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("Dispatch() error = %v, want chat not found", err)
+	}
+	if len(working.events) != 1 {
+		t.Error("a failing notifier must not stop the others")
+	}
+}
+
+func TestDispatcher_Warnings(t *testing.T) {
+	warnings := &run.Warnings{}
+	ctx := run.WithWarnings(context.Background(), warnings)
+	run.Warn(ctx, "balance", "check", errors.New("timeout"))
+
+	failing := &recorder{err: errors.New("chat not found")}
+	working := &recorder{}
+	d := NewDispatcher(config.NotificationConfig{}, failing, working, LogNotifier{})
+
+	details := []Detail{{Label: "Order ID", Value: "42"}}
+	d.Dispatch(ctx, Event{Type: EventPostTrade, Summary: "✅ Bought", Details: details})
+	d.Dispatch(ctx, Event{Type: EventLowBalance, Summary: "⚠️ Low"})
+
+	got := working.events[0].Details
+	if len(got) != 2 || got[1].Label != "Warnings" || got[1].Value != "⚠️ 1 warning: balance check failed" {
+		t.Errorf("postTrade details = %+v, want the warnings summary appended", got)
+	}
+	if len(details) != 1 {
+		t.Error("Dispatch() modified the caller's details")
+	}
+	if got := working.events[1].Details; len(got) != 0 {
+		t.Errorf("lowBalance details = %+v, want no warnings summary", got)
+	}
+
+	list := warnings.List()
+	if len(list) != 3 || list[1].String() != "notifier delivery failed" || list[1].Error != "chat not found" {
+		t.Errorf("warnings = %+v, want each failed delivery recorded", list)
+	}
+}
+
+func TestDispatcher_SimulatedFailure(t *testing.T) {
+	warnings := &run.Warnings{}
+	ctx := run.WithWarnings(context.Background(), warnings)
+	ctx = run.WithSimulatedFailure(ctx, config.SimulateNotification)
+
+	r := &recorder{}
+	d := NewDispatcher(config.NotificationConfig{}, r)
+	if err := d.Dispatch(ctx, Event{Type: EventPostTrade, Summary: "✅ Bought"}); !run.IsSimulated(err) {
+		t.Errorf("Dispatch(postTrade) error = %v, want the simulated failure", err)
+	}
+	if err := d.Dispatch(ctx, Event{Type: EventError, Summary: "🧪 SIMULATED: DCA run failed"}); err != nil {
+		t.Errorf("Dispatch(error) error = %v, want the error notification delivered", err)
+	}
+
+	if len(r.events) != 1 || r.events[0].Type != EventError {
+		t.Errorf("delivered %+v, want only the error notification", r.events)
+	}
+	if list := warnings.List(); len(list) != 1 || !strings.Contains(list[0].Error, "SIMULATED notification failure") {
+		t.Errorf("warnings = %+v, want the simulated delivery failure", list)
+	}
+	if !run.IsSimulated(run.SimulatedFailure(ctx)) {
+		t.Error("SimulatedFailure() = nil, want the injected failure so the run fails")
+	}
+}
+
+func TestDispatcher_Route(t *testing.T) {
+	global := config.NotificationConfig{
+		Events: map[string]config.EventRule{
+			config.NotifySkip:      {Enabled: boolPtr(false)},
+			config.NotifyPostTrade: {Conditions: map[string]string{"minNotional": "100"}},
+		},
+	}
+	override := config.NotificationConfig{
+		Telegram: &config.TelegramConfig{Type: "inline"},
+		Events:   map[string]config.EventRule{config.NotifySkip: {Enabled: boolPtr(true)}},
+	}
+
+	tests := []struct {
+		name       string
+		channels   string
+		own        bool // the override has its own channel
+		wantGlobal int  // events the global channel receives
+		wantOwn    int  // events the strategy's channel receives
+	}{
+		{"replace", config.ChannelsReplace, true, 1, 1},
+		{"append", config.ChannelsAppend, true, 2, 1},
+		{"inherit", "", false, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			globalCh, ownCh := &recorder{}, &recorder{}
+			d := NewDispatcher(global, globalCh)
+			o := override
+			o.Channels = tt.channels
+			if tt.own {
+				d.Route("BTC-USDT", o, ownCh)
+			} else {
+				d.Route("BTC-USDT", o)
+			}
+
+			strategyCtx := WithStrategy(context.Background(), "BTC-USDT")
+			d.Dispatch(strategyCtx, Event{Type: EventSkip, Symbol: "BTC-USDT"})                   // enabled by the override
+			d.Dispatch(strategyCtx, Event{Type: EventPostTrade, Notional: decimal.NewFromInt(5)}) // global condition still applies
+			d.Dispatch(context.Background(), Event{Type: EventError, Summary: "run failed"})      // run-level
+			d.Dispatch(context.Background(), Event{Type: EventSkip})                              // global toggle
+
+			if len(globalCh.events) != tt.wantGlobal || globalCh.events[len(globalCh.events)-1].Type != EventError {
+				t.Errorf("global channel received %+v, want %d events ending with the run error", globalCh.events, tt.wantGlobal)
+			}
+			if len(ownCh.events) != tt.wantOwn {
+				t.Errorf("strategy channel received %+v, want %d events", ownCh.events, tt.wantOwn)
+			}
+		})
+	}
+}
+
+func TestDispatcher_RouteDigest(t *testing.T) {
+	globalCh, ownCh := &recorder{}, &recorder{}
+	d := NewDispatcher(config.NotificationConfig{Digest: true}, globalCh)
+	d.Route("BTC-USDT", config.NotificationConfig{Telegram: &config.TelegramConfig{Type: "inline"}}, ownCh)
+
+	ctx := WithStrategy(context.Background(), "BTC-USDT")
+	for _, event := range runEvents[:3] {
+		d.Dispatch(ctx, event)
+	}
+	d.Dispatch(context.Background(), Event{Type: EventError, Summary: "🚨 DCA run failed"})
+	if err := d.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if len(ownCh.events) != 1 || ownCh.events[0].Type != EventDigest || len(ownCh.events[0].Details) != 3 {
+		t.Errorf("strategy channel received %+v, want a digest of its 3 events", ownCh.events)
+	}
+	if len(globalCh.events) != 1 || globalCh.events[0].Type != EventError {
+		t.Errorf("global channel received %+v, want only the run error", globalCh.events)
+	}
+}
+
+func TestScope(t *testing.T) {
+	d := NewDispatcher(config.NotificationConfig{})
+
+	ctx := context.Background()
+	SetDispatcher(ctx, d) // no scope: ignored
+	if FromContext(ctx) != nil {
+		t.Error("FromContext() without scope should be nil")
+	}
+
+	ctx = WithScope(ctx)
+	SetDispatcher(ctx, d)
+	if FromContext(ctx) != d {
+		t.Error("FromContext() did not return the stored dispatcher")
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Renderer is implemented by notifiers that can show what they would
+// deliver without delivering it. Notify sends exactly what Render returns.
+type Renderer interface {
+	Render(ctx context.Context, event Event) (string, error)
+}
+
+// Rendering is an event as one channel would deliver it
+type Rendering struct {
+	Channel string
+	Output  string
+}
+
+// Preview is what the dispatcher would do with one event
+type Preview struct {
+	Enabled  bool // false when the config turns the event off
+	Digested bool // held for the digest instead of sent at once
+	Channels []Rendering
+}
+
+// Preview renders event for every channel of its route, deciding as
+// Dispatch would whether it is sent. Nothing is delivered. Channels that
+// cannot render are listed without output.
+func (d *Dispatcher) Preview(ctx context.Context, event Event) (Preview, error) {
+	r := d.route(ctx)
+	p := Preview{Enabled: enabled(r.cfg, event), Digested: r.cfg.Digested(string(event.Type))}
+	for _, n := range r.notifiers {
+		rendering := Rendering{Channel: notifierName(n)}
+		if renderer, ok := n.(Renderer); ok {
+			output, err := renderer.Render(ctx, event)
+			if err != nil {
+				return p, fmt.Errorf("%s: %w", rendering.Channel, err)
+			}
+			rendering.Output = output
+		}
+		p.Channels = append(p.Channels, rendering)
+	}
+	return p, nil
+}
+
+// sampleEvents are built-in examples of each event type, worded as the
+// bot words them
+var sampleEvents = map[EventType]Event{
+	EventPreTrade: {
+		Type: EventPreTrade, Symbol: "BTC-USDT", Notional: decimal.NewFromInt(25),
+		Summary: "⏳ About to buy 25.00 USDT of BTC-USDT",
+	},
+	EventPostTrade: {
+		Type: EventPostTrade, Symbol: "BTC-USDT", Notional: decimal.NewFromInt(25),
+		Summary: "✅ Bought 0.00038 BTC for 25.00 USDT",
+		Details: []Detail{
+			{Label: "Order ID", Value: "28457139"},
+			{Label: "Price", Value: "65789.12"},
+			{Label: "Status", Value: "FILLED"},
+			{Label: "Dry Run", Value: "false"},
+		},
+	},
+	EventSkip: {
+		Type: EventSkip, Symbol: "BTC-USDT",
+		Summary: "⏭️ BTC-USDT run skipped",
+		Details: []Detail{
+			{Label: "Skipped by", Value: "circuitBreaker"},
+			{Label: "Reason", Value: "BTC moved 14% in 60m, more than the 10% limit"},
+		},
+	},
+	EventError: {
+		Type: EventError, Symbol: "BTC-USDT",
+		Summary: "🚨 DCA run failed",
+		Details: []Detail{
+			{Label: "Code", Value: "INSUFFICIENT_BALANCE"},
+			{Label: "Error", Value: "insufficient USDT balance: have 12.40, need 25.00"},
+		},
+	},
+	EventLowBalance: {
+		Type: EventLowBalance, Symbol: "BTC-USDT",
+		Summary: "⚠️ USDT balance is below threshold",
+		Details: []Detail{
+			{Label: "Currency", Value: "USDT"},
+			{Label: "Current Balance", Value: "40.00"},
+			{Label: "Threshold", Value: "100"},
+			{Label: "Symbol", Value: "BTC-USDT"},
+			{Label: "Run", Value: "Bought 0.00038 BTC-USDT for 25.00 USDT"},
+		},
+	},
+	EventHoldings: {
+		Type: EventHoldings, Symbol: "BTC-USDT",
+		Summary: "🏦 0.52 BTC accumulated, above the 0.5 BTC alert",
+		Details: []Detail{
+			{Label: "Asset", Value: "BTC"},
+			{Label: "Balance", Value: "0.52 BTC"},
+			{Label: "Threshold", Value: "0.5 BTC"},
+			{Label: "Symbol", Value: "BTC-USDT"},
+			{Label: "Tip", Value: "set strategy.withdrawal to have bought coins sent to your own wallet"},
+		},
+	},
+}
+
+// SampleEvent returns a built-in example of an event type
+func SampleEvent(eventType EventType) (Event, bool) {
+	event, ok := sampleEvents[eventType]
+	return event, ok
+}
+
+// sampleData is the JSON form of an event's data, as in a preview data file
+type sampleData struct {
+	Symbol   string          `json:"symbol"`
+	Notional decimal.Decimal `json:"notional"`
+	Summary  string          `json:"summary"`
+	Details  []struct {
+		Label string `json:"label"`
+		Value string `json:"value"`
+	} `json:"details"`
+}
+
+// ParseSampleData reads an event of the given type from a data file such as
+//
+//	{"symbol": "BTC-USDT", "notional": "25", "summary": "...", "details": [{"label": "...", "value": "..."}]}
+//
+// Errors give the line and column of the offending JSON.
+func ParseSampleData(eventType EventType, data []byte) (Event, error) {
+	var s sampleData
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Event{}, positionError(data, dec.InputOffset(), err)
+	}
+	if s.Summary == "" {
+		return Event{}, errors.New("summary is required")
+	}
+	event := Event{Type: eventType, Symbol: s.Symbol, Notional: s.Notional, Summary: s.Summary}
+	for _, d := range s.Details {
+		event.Details = append(event.Details, Detail{Label: d.Label, Value: d.Value})
+	}
+	return event, nil
+}
+
+// positionError prefixes err with the line and column it occurred at: the
+// offending character of a syntax error, the end of a value of the wrong
+// type, and otherwise where the decoder stopped
+func positionError(data []byte, offset int64, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = max(syntaxErr.Offset-1, 0)
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	}
+	offset = min(offset, int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// String lists each channel's rendering under its name
+func (p Preview) String() string {
+	var b strings.Builder
+	switch {
+	case !p.Enabled:
+		b.WriteString("🔕 disabled by config; it would not be sent\n")
+	case p.Digested:
+		b.WriteString("📥 held for the digest; it would be sent as below only if alone\n")
+	}
+	for _, c := range p.Channels {
+		fmt.Fprintf(&b, "── %s ──\n", c.Channel)
+		if c.Output == "" {
+			b.WriteString("(this channel cannot be previewed)\n")
+			continue
+		}
+		b.WriteString(c.Output)
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestPreview_Golden(t *testing.T) {
+	webhook := NewWebhook(http.DefaultClient, "https://hooks.example.com/dca", []WebhookKey{{ID: "2026-10"}, {ID: "2026-04"}})
+	telegram := ForChannel("telegram", NewTelegram(http.DefaultClient, "", "42"))
+	d := NewDispatcher(config.NotificationConfig{}, LogNotifier{}, webhook, telegram)
+	ctx := run.WithID(context.Background(), "01JAPREVIEW")
+
+	for _, eventType := range config.NotificationEvents {
+		t.Run(eventType, func(t *testing.T) {
+			event, ok := SampleEvent(EventType(eventType))
+			if !ok {
+				t.Fatalf("no sample for %s", eventType)
+			}
+			p, err := d.Preview(ctx, event)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+			got := p.String()
+
+			golden := filepath.Join("testdata", "preview_"+eventType+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestPreview_Route(t *testing.T) {
+	d := NewDispatcher(config.NotificationConfig{}, LogNotifier{})
+	d.Route("BTC-USDT", config.NotificationConfig{Events: map[string]config.EventRule{config.NotifySkip: {Enabled: boolPtr(false)}}},
+		LogNotifier{Channel: "telegram (BTC-USDT)"})
+	event, _ := SampleEvent(EventSkip)
+
+	p, err := d.Preview(WithStrategy(context.Background(), "BTC-USDT"), event)
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if p.Enabled || len(p.Channels) != 1 || !strings.Contains(p.Channels[0].Output, "to telegram (BTC-USDT)") {
+		t.Errorf("Preview() = %+v, want the strategy's disabled telegram route", p)
+	}
+}
+
+func TestParseSampleData(t *testing.T) {
+	event, err := ParseSampleData(EventPostTrade, []byte(`{"symbol": "ETH-EUR", "notional": "20", "summary": "✅ Bought", "details": [{"label": "Price", "value": "2400"}]}`))
+	if err != nil {
+		t.Fatalf("ParseSampleData() error = %v", err)
+	}
+	if event.Type != EventPostTrade || event.Notional.String() != "20" || len(event.Details) != 1 {
+		t.Errorf("ParseSampleData() = %+v", event)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"syntax", "{\n  \"summary\": \"x\",\n  \"symbol\" \"BTC-USDT\"\n}", "line 3, column 12"},
+		{"type", "{\n  \"summary\": 42\n}", "line 2, column 16"},
+		{"unknown field", "{\"summary\": \"x\", \"colour\": \"red\"}", "line 1, column 34"},
+		{"no summary", `{"symbol": "BTC-USDT"}`, "summary is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSampleData(EventSkip, []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseSampleData() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
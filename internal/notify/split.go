@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Message size limits of chat channels, in UTF-16 code units as Telegram
+// counts them
+const (
+	TelegramMessageLimit = 4096
+	DiscordMessageLimit  = 2000
+	SlackMessageLimit    = 3000 // the text of one section block
+)
+
+// PartDelay spaces the parts of a split event, within the per-chat rate
+// limits of Telegram and Slack of about one message a second
+const PartDelay = time.Second
+
+// partReserve is the room every part's summary keeps for its number
+const partReserve = " (999/999)"
+
+// Split divides event into parts whose rendering is at most limit long,
+// for channels whose messages are capped. It works on the event rather
+// than its rendered text: each part repeats the summary numbered as in
+// "(2/3)" and carries whole details, keeping a run of details with the
+// same label, such as one strategy's rows of a digest, in one part where
+// it fits. Only a detail too long for any part is cut, between words
+// where possible. Every part is rendered on its own, so formatting such as
+// MarkdownV2 entities is closed within it. An event that fits is returned
+// as is.
+func Split(event Event, limit int, render func(Event) string) []Event {
+	if textLength(render(event)) <= limit {
+		return []Event{event}
+	}
+	header := event
+	header.Details = nil
+	header.Summary = fitSummary(header, limit, render)
+	fits := func(details ...Detail) bool {
+		part := header
+		part.Summary += partReserve
+		part.Details = details
+		return textLength(render(part)) <= limit
+	}
+
+	var details []Detail
+	for _, detail := range event.Details {
+		details = append(details, cutDetail(detail, fits)...)
+	}
+
+	var parts [][]Detail
+	var current []Detail
+	for _, detail := range details {
+		if len(current) == 0 || fits(append(slices.Clip(current), detail)...) {
+			current = append(current, detail)
+			continue
+		}
+		// Carry the rows sharing the label of detail into the next part
+		// along with it, when they fit there together
+		keep := len(current)
+		for keep > 0 && current[keep-1].Label == detail.Label {
+			keep--
+		}
+		if keep == 0 || !fits(append(slices.Clone(current[keep:]), detail)...) {
+			keep = len(current)
+		}
+		parts = append(parts, current[:keep])
+		current = append(slices.Clone(current[keep:]), detail)
+	}
+	if len(current) > 0 {
+		parts = append(parts, current)
+	}
+	if len(parts) <= 1 {
+		header.Details = details
+		return []Event{header}
+	}
+
+	events := make([]Event, len(parts))
+	for i, part := range parts {
+		events[i] = header
+		events[i].Summary = fmt.Sprintf("%s (%d/%d)", header.Summary, i+1, len(parts))
+		events[i].Details = part
+	}
+	return events
+}
+
+// fitSummary shortens the summary of header, an event without details,
+// with an ellipsis until it renders within half of limit with a part
+// number, leaving the other half to details
+func fitSummary(header Event, limit int, render func(Event) string) string {
+	limit /= 2
+	summary := []rune(header.Summary)
+	fits := func(n int) bool {
+		header.Summary = string(summary[:n]) + "…" + partReserve
+		return textLength(render(header)) <= limit
+	}
+	header.Summary += partReserve
+	if textLength(render(header)) <= limit {
+		return string(summary)
+	}
+	n := longest(len(summary), fits)
+	return string(summary[:n]) + "…"
+}
+
+// cutDetail splits detail into details with the same label whose values
+// each fit a part on their own. Values are cut between words, and within a
+// word only when it is too long by itself.
+func cutDetail(detail Detail, fits func(...Detail) bool) []Detail {
+	if fits(detail) {
+		return []Detail{detail}
+	}
+	var pieces []Detail
+	piece := ""
+	flush := func() {
+		if piece != "" {
+			pieces = append(pieces, Detail{Label: detail.Label, Value: piece})
+			piece = ""
+		}
+	}
+	for _, word := range strings.Fields(detail.Value) {
+		candidate := word
+		if piece != "" {
+			candidate = piece + " " + word
+		}
+		if fits(Detail{Label: detail.Label, Value: candidate}) {
+			piece = candidate
+			continue
+		}
+		flush()
+		for !fits(Detail{Label: detail.Label, Value: word}) {
+			runes := []rune(word)
+			n := longest(len(runes), func(n int) bool {
+				return fits(Detail{Label: detail.Label, Value: string(runes[:n])})
+			})
+			if n == 0 {
+				// Not even one character fits beside the label; send it
+				// whole and let the channel refuse it
+				break
+			}
+			pieces = append(pieces, Detail{Label: detail.Label, Value: string(runes[:n])})
+			word = string(runes[n:])
+		}
+		piece = word
+	}
+	flush()
+	return pieces
+}
+
+// longest returns the largest n in [0, max] for which fits holds, fits
+// being monotonic
+func longest(max int, fits func(n int) bool) int {
+	lo, hi := 0, max
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// textLength is the length of s in UTF-16 code units, as Telegram measures
+// messages; no other channel counts more
+func textLength(s string) int {
+	n := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		n += max(utf16.RuneLen(r), 1)
+		s = s[size:]
+	}
+	return n
+}
+
+// MarkdownV2 renders an event for Telegram's MarkdownV2 parse mode: the
+// summary in bold, then a line per detail with its label in italics
+func MarkdownV2(event Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n", markdownV2Escaper.Replace(event.Summary))
+	for _, detail := range event.Details {
+		fmt.Fprintf(&b, "_%s_: %s\n", markdownV2Escaper.Replace(detail.Label), markdownV2Escaper.Replace(detail.Value))
+	}
+	return b.String()
+}
+
+// markdownV2Escaper escapes the characters MarkdownV2 reserves
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`,
+	"=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// chatFormats are how the chat channels render events and how long their
+// messages may be
+var chatFormats = map[string]struct {
+	limit  int
+	render func(Event) string
+}{
+	"telegram": {TelegramMessageLimit, MarkdownV2},
+	"discord":  {DiscordMessageLimit, Event.Text},
+	"slack":    {SlackMessageLimit, Event.Text},
+}
+
+// Chunked sends an event too long for one message of its channel as the
+// parts of Split, in order and Delay apart
+type Chunked struct {
+	Notifier Notifier
+	Limit    int
+	Format   func(Event) string // how the channel renders an event
+	Delay    time.Duration
+
+	// Sleep waits between parts; it defaults to a timer honouring ctx
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// ForChannel wraps n, the notifier of the named chat channel ("telegram",
+// "discord" or "slack"), to split events too long for its messages. Other
+// notifiers are returned as is.
+func ForChannel(channel string, n Notifier) Notifier {
+	format, ok := chatFormats[channel]
+	if !ok {
+		return n
+	}
+	return &Chunked{Notifier: n, Limit: format.limit, Format: format.render, Delay: PartDelay}
+}
+
+// Name is the name of the wrapped notifier
+func (c *Chunked) Name() string {
+	return notifierName(c.Notifier)
+}
+
+// Notify sends the parts of event, stopping at the first that fails
+func (c *Chunked) Notify(ctx context.Context, event Event) error {
+	parts := Split(event, c.Limit, c.Format)
+	for i, part := range parts {
+		if i > 0 {
+			if err := c.wait(ctx); err != nil {
+				return fmt.Errorf("part %d/%d: %w", i+1, len(parts), err)
+			}
+		}
+		if err := c.Notifier.Notify(ctx, part); err != nil {
+			if len(parts) == 1 {
+				return err
+			}
+			return fmt.Errorf("part %d/%d: %w", i+1, len(parts), err)
+		}
+	}
+	return nil
+}
+
+// Render returns the renderings of the parts Notify sends, one after the
+// other, or "" when the wrapped notifier cannot render
+func (c *Chunked) Render(ctx context.Context, event Event) (string, error) {
+	renderer, ok := c.Notifier.(Renderer)
+	if !ok {
+		return "", nil
+	}
+	var b strings.Builder
+	for _, part := range Split(event, c.Limit, c.Format) {
+		output, err := renderer.Render(ctx, part)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(output)
+	}
+	return b.String(), nil
+}
+
+func (c *Chunked) wait(ctx context.Context) error {
+	if c.Delay <= 0 {
+		return nil
+	}
+	if c.Sleep != nil {
+		return c.Sleep(ctx, c.Delay)
+	}
+	timer := time.NewTimer(c.Delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
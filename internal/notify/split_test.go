@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rows returns a digest-like event with n rows, each label repeated per
+// the labels pattern
+func rows(n int, labels ...string) Event {
+	event := Event{Type: EventDigest, Summary: "📋 Run digest: " + plural(n, "fill")}
+	for i := range n {
+		label := labels[i%len(labels)]
+		event.Details = append(event.Details, Detail{Label: label, Value: fmt.Sprintf("✅ Bought 0.000%03d %s for 25.00 USDT", i, label)})
+	}
+	return event
+}
+
+// checkParts verifies the parts of event fit limit, are numbered in order
+// and together hold every detail in order
+func checkParts(t *testing.T, event Event, parts []Event, limit int, render func(Event) string) {
+	t.Helper()
+	var details []Detail
+	for i, part := range parts {
+		if n := textLength(render(part)); n > limit {
+			t.Errorf("part %d renders %d long, over %d", i+1, n, limit)
+		}
+		if want := fmt.Sprintf(" (%d/%d)", i+1, len(parts)); len(parts) > 1 && !strings.HasSuffix(part.Summary, want) {
+			t.Errorf("part %d summary = %q, want it numbered %q", i+1, part.Summary, want)
+		}
+		if part.Type != event.Type || part.Symbol != event.Symbol {
+			t.Errorf("part %d = %s %s, want %s %s", i+1, part.Type, part.Symbol, event.Type, event.Symbol)
+		}
+		details = append(details, part.Details...)
+	}
+	if len(details) != len(event.Details) {
+		t.Fatalf("parts hold %d details, want %d", len(details), len(event.Details))
+	}
+	for i := range details {
+		if details[i] != event.Details[i] {
+			t.Errorf("detail %d = %+v, want %+v", i, details[i], event.Details[i])
+		}
+	}
+}
+
+func TestSplit_Fits(t *testing.T) {
+	event := rows(3, "BTC-USDT")
+	parts := Split(event, TelegramMessageLimit, MarkdownV2)
+	if len(parts) != 1 || parts[0].Summary != event.Summary || len(parts[0].Details) != 3 {
+		t.Errorf("Split() = %+v, want the event as is", parts)
+	}
+}
+
+func TestSplit_Rows(t *testing.T) {
+	for channel, format := range chatFormats {
+		t.Run(channel, func(t *testing.T) {
+			event := rows(400, "BTC-USDT", "ETH-USDT", "SOL-USDT")
+			parts := Split(event, format.limit, format.render)
+			if len(parts) < 2 {
+				t.Fatalf("Split() = %d parts, want several", len(parts))
+			}
+			checkParts(t, event, parts, format.limit, format.render)
+		})
+	}
+}
+
+func TestSplit_KeepsLabelRuns(t *testing.T) {
+	event := Event{Summary: "📋 Run digest"}
+	for _, label := range []string{"BTC-USDT", "BTC-USDT", "ETH-USDT", "ETH-USDT", "ETH-USDT"} {
+		event.Details = append(event.Details, Detail{Label: label, Value: "✅ Bought"})
+	}
+	// Room for four rows: the ETH-USDT rows move on together
+	four := event
+	four.Summary += partReserve
+	four.Details = event.Details[:4]
+	limit := textLength(four.Text())
+
+	parts := Split(event, limit, Event.Text)
+	checkParts(t, event, parts, limit, Event.Text)
+	if len(parts) != 2 || len(parts[0].Details) != 2 || len(parts[1].Details) != 3 {
+		t.Errorf("Split() = %+v, want the BTC-USDT and ETH-USDT rows apart", parts)
+	}
+
+	// A run that cannot fit one part is cut where the part is full
+	limit = textLength(Event{Summary: event.Summary + partReserve, Details: event.Details[:2]}.Text())
+	parts = Split(event, limit, Event.Text)
+	checkParts(t, event, parts, limit, Event.Text)
+	if len(parts) != 3 || len(parts[1].Details) != 2 || len(parts[2].Details) != 1 {
+		t.Errorf("Split() = %d parts, want the ETH-USDT rows over two", len(parts))
+	}
+}
+
+func TestSplit_LongValue(t *testing.T) {
+	words := strings.Repeat("binance returned code -1013 Filter failure: NOTIONAL ", 200)
+	event := Event{Type: EventError, Summary: "🚨 DCA run failed", Details: []Detail{{Label: "Error", Value: words}, {Label: "Exchange", Value: "binance"}}}
+	parts := Split(event, DiscordMessageLimit, Event.Text)
+	if len(parts) < 2 {
+		t.Fatalf("Split() = %d parts, want several", len(parts))
+	}
+
+	var pieces []string
+	for i, part := range parts {
+		if n := textLength(part.Text()); n > DiscordMessageLimit {
+			t.Errorf("part %d renders %d long", i+1, n)
+		}
+		for _, detail := range part.Details {
+			if detail.Label == "Error" {
+				pieces = append(pieces, detail.Value)
+			}
+		}
+	}
+	for i, piece := range pieces {
+		// Cut between words: every piece starts and ends on a word of the value
+		if !strings.HasPrefix(piece, "binance") || !strings.HasSuffix(piece, "NOTIONAL") {
+			t.Errorf("piece %d = %q..., cut mid-word", i, piece[:20])
+		}
+	}
+	if got := strings.Join(pieces, " "); got != strings.TrimSpace(words) {
+		t.Errorf("pieces do not add up to the value")
+	}
+	if last := parts[len(parts)-1].Details; last[len(last)-1].Label != "Exchange" {
+		t.Errorf("last detail = %+v, want Exchange after the error", last[len(last)-1])
+	}
+}
+
+func TestSplit_LongWord(t *testing.T) {
+	word := strings.Repeat("0123456789", 500)
+	event := Event{Summary: "Raw response", Details: []Detail{{Label: "Body", Value: "prefix " + word}}}
+	parts := Split(event, TelegramMessageLimit, MarkdownV2)
+	var joined string
+	for i, part := range parts {
+		if n := textLength(MarkdownV2(part)); n > TelegramMessageLimit {
+			t.Errorf("part %d renders %d long", i+1, n)
+		}
+		for _, detail := range part.Details {
+			joined += detail.Value + "|"
+		}
+	}
+	if !strings.HasPrefix(joined, "prefix|") || strings.ReplaceAll(strings.TrimPrefix(joined, "prefix|"), "|", "") != word {
+		t.Errorf("pieces = %q..., want the word cut only where it must be", joined[:40])
+	}
+}
+
+func TestSplit_LongSummary(t *testing.T) {
+	event := Event{Type: EventError, Summary: strings.Repeat("failed to place order: ", 200), Details: []Detail{{Label: "Exchange", Value: "okx"}}}
+	parts := Split(event, DiscordMessageLimit, Event.Text)
+	if len(parts) != 1 || !strings.HasSuffix(parts[0].Summary, "…") || textLength(parts[0].Text()) > DiscordMessageLimit {
+		t.Errorf("Split() = %+v, want the summary shortened", parts)
+	}
+	if len(parts[0].Details) != 1 {
+		t.Errorf("details = %+v, want them kept", parts[0].Details)
+	}
+}
+
+// unescaped matches MarkdownV2 entity markers that are not escaped
+var unescaped = regexp.MustCompile(`(^|[^\\])(\\\\)*[*_]`)
+
+func TestSplit_MarkdownV2Balanced(t *testing.T) {
+	event := Event{Type: EventDigest, Summary: "📋 Run digest: *3* fills_today (see #ops)."}
+	for i := range 300 {
+		event.Details = append(event.Details, Detail{Label: "BTC_USDT", Value: fmt.Sprintf("✅ Bought *%d* @ 62_000.5 [fee] \\ done!", i)})
+	}
+	parts := Split(event, TelegramMessageLimit, MarkdownV2)
+	checkParts(t, event, parts, TelegramMessageLimit, MarkdownV2)
+	for i, part := range parts {
+		text := MarkdownV2(part)
+		bold, italic := 0, 0
+		for _, m := range unescaped.FindAllString(text, -1) {
+			switch m[len(m)-1] {
+			case '*':
+				bold++
+			case '_':
+				italic++
+			}
+		}
+		if bold%2 != 0 || italic%2 != 0 {
+			t.Errorf("part %d has %d bold and %d italic markers, want them paired", i+1, bold, italic)
+		}
+	}
+}
+
+func TestMarkdownV2(t *testing.T) {
+	got := MarkdownV2(Event{Summary: "✅ Bought 0.001 BTC-USDT", Details: []Detail{{Label: "Order_ID", Value: "(a*b)!"}}})
+	want := "*✅ Bought 0\\.001 BTC\\-USDT*\n_Order\\_ID_: \\(a\\*b\\)\\!\n"
+	if got != want {
+		t.Errorf("MarkdownV2() = %q, want %q", got, want)
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	for s, want := range map[string]int{"abc": 3, "₿": 1, "📋": 2, "✅ ok": 4} {
+		if got := textLength(s); got != want {
+			t.Errorf("textLength(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestChunked_Notify(t *testing.T) {
+	r := &recorder{}
+	var slept []time.Duration
+	c := ForChannel("discord", r).(*Chunked)
+	c.Sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+	event := rows(100, "BTC-USDT")
+	if err := c.Notify(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	n := len(r.events)
+	if n < 2 || len(slept) != n-1 || slept[0] != PartDelay {
+		t.Fatalf("sent %d parts with waits %v, want a wait between parts", n, slept)
+	}
+	checkParts(t, event, r.events, DiscordMessageLimit, Event.Text)
+
+	// A failed part stops the rest
+	r.events, r.err = nil, errors.New("HTTP 429")
+	err := c.Notify(context.Background(), event)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("part 1/%d: HTTP 429", n)) || len(r.events) != 1 {
+		t.Errorf("Notify() error = %v after %d parts, want it to stop at the first", err, len(r.events))
+	}
+}
+
+func TestChunked_Render(t *testing.T) {
+	c := ForChannel("telegram", LogNotifier{Channel: "telegram"})
+	if c.(Named).Name() != "log" {
+		t.Errorf("Name() = %q, want the wrapped notifier's", c.(Named).Name())
+	}
+	output, err := c.(Renderer).Render(context.Background(), rows(300, "BTC-USDT"))
+	if err != nil || strings.Count(output, "Would send digest") < 2 || !strings.Contains(output, "fills (1/") {
+		t.Errorf("Render() = %q, %v, want every part", output[:80], err)
+	}
+	if n := ForChannel("webhook", LogNotifier{}); n != (LogNotifier{}) {
+		t.Errorf("ForChannel(webhook) = %T, want the notifier as is", n)
+	}
+}
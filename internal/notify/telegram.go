@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TelegramAPIURL is the Telegram Bot API
+const TelegramAPIURL = "https://api.telegram.org"
+
+// TelegramError is an error response of the Bot API, such as 401 for a bad
+// token, 400 for a chat that does not exist or 429 when rate limited
+type TelegramError struct {
+	Code        int
+	Description string
+	RetryAfter  time.Duration // how long to wait after a 429
+}
+
+func (e *TelegramError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("telegram error %d: %s (retry after %s)", e.Code, e.Description, e.RetryAfter)
+	}
+	return fmt.Sprintf("telegram error %d: %s", e.Code, e.Description)
+}
+
+// Telegram sends events to a chat with the Bot API's sendMessage, rendered
+// as MarkdownV2. Wrap it with ForChannel to split events too long for one
+// message.
+type Telegram struct {
+	APIURL string // defaults to TelegramAPIURL
+
+	client *http.Client
+	token  string
+	chatID string // numeric ID or "@channelusername"
+}
+
+// NewTelegram creates a Telegram notifier for the bot with token. The
+// client's timeout, if any, bounds each message.
+func NewTelegram(client *http.Client, token, chatID string) *Telegram {
+	return &Telegram{client: client, token: token, chatID: chatID}
+}
+
+// Name is "telegram"
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// Notify sends the event as one message. A refusal by the Bot API is a
+// *TelegramError. Errors never include the request URL, which carries the
+// token.
+func (t *Telegram) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(t.message(event))
+	if err != nil {
+		return fmt.Errorf("sendMessage: %w", err)
+	}
+	base := t.APIURL
+	if base == "" {
+		base = TelegramAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/bot"+t.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("sendMessage: invalid request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("sendMessage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out telegramResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&out); err != nil {
+		return fmt.Errorf("sendMessage: HTTP %d with invalid response: %w", resp.StatusCode, err)
+	}
+	if !out.OK {
+		return fmt.Errorf("sendMessage: %w", &TelegramError{
+			Code:        out.ErrorCode,
+			Description: out.Description,
+			RetryAfter:  time.Duration(out.Parameters.RetryAfter) * time.Second,
+		})
+	}
+	return nil
+}
+
+// Render returns the message Notify sends, without the token: previews do
+// not resolve it
+func (t *Telegram) Render(ctx context.Context, event Event) (string, error) {
+	return fmt.Sprintf("sendMessage to chat %s (MarkdownV2)\n\n%s", t.chatID, t.message(event).Text), nil
+}
+
+func (t *Telegram) message(event Event) telegramMessage {
+	return telegramMessage{ChatID: t.chatID, Text: MarkdownV2(event), ParseMode: "MarkdownV2", DisableWebPagePreview: true}
+}
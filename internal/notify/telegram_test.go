@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegram_Notify(t *testing.T) {
+	var path string
+	var got telegramMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":7}}`))
+	}))
+	defer server.Close()
+
+	event := Event{
+		Type: EventLowBalance, Symbol: "BTC-USDT",
+		Summary: "⚠️ USDT balance is below threshold",
+		Details: []Detail{
+			{Label: "Currency", Value: "USDT"},
+			{Label: "Current Balance", Value: "40.00"},
+			{Label: "Threshold", Value: "100"},
+			{Label: "Symbol", Value: "BTC-USDT"},
+			{Label: "Run", Value: "Bought 0.00038 BTC-USDT for 25.00 USDT"},
+		},
+	}
+	tg := NewTelegram(server.Client(), "123:abc", "-100200300")
+	tg.APIURL = server.URL
+	if err := tg.Notify(context.Background(), event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if path != "/bot123:abc/sendMessage" {
+		t.Errorf("path = %s, want the bot's sendMessage", path)
+	}
+	want := "*⚠️ USDT balance is below threshold*\n" +
+		"_Currency_: USDT\n" +
+		"_Current Balance_: 40\\.00\n" +
+		"_Threshold_: 100\n" +
+		"_Symbol_: BTC\\-USDT\n" +
+		"_Run_: Bought 0\\.00038 BTC\\-USDT for 25\\.00 USDT\n"
+	if got.ChatID != "-100200300" || got.ParseMode != "MarkdownV2" || got.Text != want {
+		t.Errorf("message = %+v, want chat -100200300 in MarkdownV2 with text:\n%s", got, want)
+	}
+}
+
+func TestTelegram_NotifyErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		want     TelegramError
+	}{
+		{"bad token", http.StatusUnauthorized, `{"ok":false,"error_code":401,"description":"Unauthorized"}`, TelegramError{Code: 401, Description: "Unauthorized"}},
+		{"chat not found", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, TelegramError{Code: 400, Description: "Bad Request: chat not found"}},
+		{"rate limited", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`, TelegramError{Code: 429, Description: "Too Many Requests: retry after 3", RetryAfter: 3 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			tg := NewTelegram(server.Client(), "123:secret-token", "42")
+			tg.APIURL = server.URL
+			err := tg.Notify(context.Background(), Event{Type: EventLowBalance, Summary: "low"})
+			var tgErr *TelegramError
+			if !errors.As(err, &tgErr) || *tgErr != tt.want {
+				t.Fatalf("Notify() error = %v, want %+v", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "secret-token") {
+				t.Errorf("Notify() error %q includes the token", err)
+			}
+		})
+	}
+}
+
+func TestTelegram_NotifyUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	tg := NewTelegram(http.DefaultClient, "123:secret-token", "42")
+	tg.APIURL = server.URL
+	err := tg.Notify(context.Background(), Event{Type: EventError, Summary: "failed"})
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("Notify() error = %v, want a failure without the token", err)
+	}
+}
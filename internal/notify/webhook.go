@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/exchange/sign"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Headers of a webhook request. A receiver looks up its key ID in
+// WebhookSignatureHeader and compares the hex HMAC-SHA256 of the raw body
+// under its secret, as VerifyWebhook does.
+const (
+	// WebhookSignatureHeader carries "<keyId>=<signature>" for every
+	// configured secret, comma-separated, the current secret first
+	WebhookSignatureHeader = "X-Signature"
+	// WebhookKeyIDHeader names the current secret, the one receivers
+	// should move to during a rotation
+	WebhookKeyIDHeader = "X-Signature-Key-Id"
+	// WebhookKeyIDsHeader lists the key IDs the request is signed with,
+	// comma-separated. A receiver whose key drops out of it has missed a
+	// rotation.
+	WebhookKeyIDsHeader = "X-Signature-Key-Ids"
+)
+
+// WebhookKey is a resolved signing secret
+type WebhookKey struct {
+	ID     string
+	Secret string
+}
+
+// Webhook posts events as JSON to a URL, signed with every key so that
+// receivers holding either the current or a previous secret accept them
+type Webhook struct {
+	client *http.Client
+	url    string
+	keys   []WebhookKey // current first
+}
+
+// NewWebhook creates a webhook notifier. The client's timeout, if any,
+// bounds each delivery.
+func NewWebhook(client *http.Client, url string, keys []WebhookKey) *Webhook {
+	return &Webhook{client: client, url: url, keys: keys}
+}
+
+// Name is "webhook"
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+type webhookDetail struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type webhookBody struct {
+	Type        EventType       `json:"type"`
+	Symbol      string          `json:"symbol,omitempty"`
+	Notional    string          `json:"notional,omitempty"`
+	Summary     string          `json:"summary"`
+	Details     []webhookDetail `json:"details,omitempty"`
+	Strategy    string          `json:"strategy,omitempty"`
+	ExecutionID string          `json:"executionId"`
+}
+
+// Notify posts the event. Any non-2xx response is an error.
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	body, err := encodeWebhook(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	SignWebhook(req.Header, body, w.keys)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("POST failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Render returns the request Notify sends, with placeholders for the
+// signatures: previews do not resolve the secrets
+func (w *Webhook) Render(ctx context.Context, event Event) (string, error) {
+	body, err := encodeWebhook(ctx, event)
+	if err != nil {
+		return "", err
+	}
+	signatures := make([]string, len(w.keys))
+	ids := make([]string, len(w.keys))
+	for i, key := range w.keys {
+		signatures[i] = key.ID + "=<signature>"
+		ids[i] = key.ID
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "POST %s\n", w.url)
+	fmt.Fprintf(&b, "Content-Type: application/json\n")
+	if len(w.keys) > 0 {
+		fmt.Fprintf(&b, "%s: %s\n", WebhookSignatureHeader, strings.Join(signatures, ","))
+		fmt.Fprintf(&b, "%s: %s\n", WebhookKeyIDHeader, ids[0])
+		fmt.Fprintf(&b, "%s: %s\n", WebhookKeyIDsHeader, strings.Join(ids, ","))
+	}
+	fmt.Fprintf(&b, "\n%s\n", body)
+	return b.String(), nil
+}
+
+// encodeWebhook returns the JSON body of event
+func encodeWebhook(ctx context.Context, event Event) ([]byte, error) {
+	payload := webhookBody{
+		Type:        event.Type,
+		Symbol:      event.Symbol,
+		Summary:     event.Summary,
+		Strategy:    StrategyFrom(ctx),
+		ExecutionID: run.ID(ctx),
+	}
+	if !event.Notional.IsZero() {
+		payload.Notional = event.Notional.String()
+	}
+	for _, d := range event.Details {
+		payload.Details = append(payload.Details, webhookDetail{Label: d.Label, Value: d.Value})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+	return body, nil
+}
+
+// SignWebhook sets the signature headers of body on h, the first key
+// being the current one
+func SignWebhook(h http.Header, body []byte, keys []WebhookKey) {
+	if len(keys) == 0 {
+		return
+	}
+	signatures := make([]string, len(keys))
+	ids := make([]string, len(keys))
+	for i, key := range keys {
+		signatures[i] = key.ID + "=" + sign.SignPrehashHex(key.Secret, string(body))
+		ids[i] = key.ID
+	}
+	h.Set(WebhookSignatureHeader, strings.Join(signatures, ","))
+	h.Set(WebhookKeyIDHeader, keys[0].ID)
+	h.Set(WebhookKeyIDsHeader, strings.Join(ids, ","))
+}
+
+// VerifyWebhook reports whether h carries a valid signature of body under
+// key, as a receiver holding only that key would check it
+func VerifyWebhook(h http.Header, body []byte, key WebhookKey) bool {
+	want := sign.SignPrehashHex(key.Secret, string(body))
+	for _, entry := range strings.Split(h.Get(WebhookSignatureHeader), ",") {
+		id, signature, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if ok && id == key.ID {
+			return hmac.Equal([]byte(signature), []byte(want))
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	current := WebhookKey{ID: "2026-10", Secret: "new-secret"}
+	previous := WebhookKey{ID: "2026-04", Secret: "old-secret"}
+
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	ctx := WithStrategy(context.Background(), "BTC-USDT")
+	event := Event{Type: EventPostTrade, Symbol: "BTC-USDT", Notional: decimal.RequireFromString("25"), Summary: "Bought 25 USDT of BTC-USDT", Details: []Detail{{Label: "Price", Value: "61000"}}}
+	if err := NewWebhook(server.Client(), server.URL, []WebhookKey{current, previous}).Notify(ctx, event); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	var got webhookBody
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("invalid body %s: %v", body, err)
+	}
+	if got.Type != EventPostTrade || got.Notional != "25" || got.Strategy != "BTC-USDT" || len(got.Details) != 1 {
+		t.Errorf("body = %s", body)
+	}
+	if header.Get(WebhookKeyIDHeader) != "2026-10" || header.Get(WebhookKeyIDsHeader) != "2026-10,2026-04" {
+		t.Errorf("key ID headers = %q, %q, want the current key and both", header.Get(WebhookKeyIDHeader), header.Get(WebhookKeyIDsHeader))
+	}
+
+	// During the rotation receivers still on the old secret and those
+	// already on the new one both accept the event
+	for _, key := range []WebhookKey{current, previous} {
+		if !VerifyWebhook(header, body, key) {
+			t.Errorf("VerifyWebhook() = false for key %s", key.ID)
+		}
+	}
+	for _, key := range []WebhookKey{{ID: "2026-10", Secret: "old-secret"}, {ID: "2025-10", Secret: "new-secret"}} {
+		if VerifyWebhook(header, body, key) {
+			t.Errorf("VerifyWebhook() = true for %+v", key)
+		}
+	}
+	if VerifyWebhook(header, append(body, ' '), current) {
+		t.Error("VerifyWebhook() = true for a modified body")
+	}
+}
+
+func TestWebhook_NotifyRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.Client(), server.URL, []WebhookKey{{ID: "k1", Secret: "s"}}).Notify(context.Background(), Event{Type: EventError, Summary: "failed"})
+	if err == nil || err.Error() != "unexpected HTTP 401" {
+		t.Errorf("Notify() error = %v, want the HTTP status", err)
+	}
+}
@@ -0,0 +1,117 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Kinds of Change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one field that differs between two snapshots
+type Change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // Added, Removed or Changed
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// String renders the change as "~ path: old → new", "+ path = new" or
+// "- path = old"
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s = %s", c.Path, value(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s = %s", c.Path, value(c.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", c.Path, value(c.Old), value(c.New))
+	}
+}
+
+func value(v any) string {
+	switch v {
+	case nil:
+		return "null"
+	case "":
+		return `""`
+	}
+	return fmt.Sprint(v)
+}
+
+// Diff lists the fields whose values differ between before and after,
+// sorted by path. Origins are ignored: a default spelled out in the
+// payload changes nothing.
+func Diff(before, after config.Snapshot) []Change {
+	var changes []Change
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case j == len(after) || (i < len(before) && before[i].Path < after[j].Path):
+			changes = append(changes, Change{Path: before[i].Path, Kind: Removed, Old: before[i].Value})
+			i++
+		case i == len(before) || after[j].Path < before[i].Path:
+			changes = append(changes, Change{Path: after[j].Path, Kind: Added, New: after[j].Value})
+			j++
+		default:
+			if fmt.Sprint(before[i].Value) != fmt.Sprint(after[j].Value) {
+				changes = append(changes, Change{Path: before[i].Path, Kind: Changed, Old: before[i].Value, New: after[j].Value})
+			}
+			i++
+			j++
+		}
+	}
+	return changes
+}
+
+// Comparison is how two payloads differ in configuration and in what a
+// run of each would do
+type Comparison struct {
+	Config []Change `json:"config"`
+	Plan   []Change `json:"plan"`
+}
+
+// Compare diffs the effective configurations of two parsed payloads and
+// their plans
+func Compare(before, after *config.DCAPayload, beforePlan, afterPlan *Plan) (*Comparison, error) {
+	oldConfig, err := before.Effective()
+	if err != nil {
+		return nil, err
+	}
+	newConfig, err := after.Effective()
+	if err != nil {
+		return nil, err
+	}
+	oldRun, err := config.SnapshotOf(beforePlan, config.OriginDerived)
+	if err != nil {
+		return nil, err
+	}
+	newRun, err := config.SnapshotOf(afterPlan, config.OriginDerived)
+	if err != nil {
+		return nil, err
+	}
+	return &Comparison{Config: Diff(oldConfig, newConfig), Plan: Diff(oldRun, newRun)}, nil
+}
+
+// String renders the comparison in two sections, one change per line
+func (c *Comparison) String() string {
+	var b strings.Builder
+	section := func(title string, changes []Change) {
+		b.WriteString(title + ":\n")
+		if len(changes) == 0 {
+			b.WriteString("  (no changes)\n")
+		}
+		for _, change := range changes {
+			b.WriteString("  " + change.String() + "\n")
+		}
+	}
+	section("Configuration", c.Config)
+	section("Plan", c.Plan)
+	return b.String()
+}
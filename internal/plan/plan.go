@@ -0,0 +1,297 @@
+// Package plan works out what a run of a payload would do at a given time
+// against a given market, without placing orders: the amount it would buy
+// or sell and the guards that would skip it. Plans of two payloads can be
+// compared with their effective configurations; see Compare.
+package plan
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/budget"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/dip"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// quantityPlaces rounds the estimated quantity of a buy, which the
+// exchange only fixes when the order fills
+const quantityPlaces = 8
+
+// Market is the market state plans are evaluated against
+type Market struct {
+	Prices  map[string]decimal.Decimal // last price per symbol
+	FeeRate decimal.Decimal            // taker fee rate; zero when the exchange reports none
+	LotStep decimal.Decimal            // lot step of sells; zero means exchange.DefaultLotStep
+
+	// Balances is the free balance of each symbol's quote asset and
+	// MinNotional the smallest order value, zero when the exchange reports
+	// none; both size percent-sized buys
+	Balances    map[string]decimal.Decimal
+	MinNotional decimal.Decimal
+}
+
+// Gather reads the market of symbols from exc. Prices, the fee rate and the
+// minimum order value are left out when exc cannot report them.
+func Gather(ctx context.Context, exc exchange.Exchange, symbols ...string) (Market, error) {
+	m := Market{Prices: map[string]decimal.Decimal{}, Balances: map[string]decimal.Decimal{}}
+	for _, symbol := range symbols {
+		_, quote, err := exchange.SplitSymbol(symbol)
+		if err != nil {
+			return Market{}, err
+		}
+		if _, ok := m.Balances[quote]; ok {
+			continue
+		}
+		if m.Balances[quote], err = exc.GetBalance(ctx, quote); err != nil {
+			return Market{}, fmt.Errorf("failed to get %s balance: %w", quote, err)
+		}
+	}
+	if ticker, ok := exc.(exchange.PriceTicker); ok {
+		for _, symbol := range symbols {
+			price, err := ticker.LastPrice(ctx, symbol)
+			if err != nil {
+				return Market{}, fmt.Errorf("failed to get %s price: %w", symbol, err)
+			}
+			m.Prices[symbol] = price
+		}
+	}
+	if rater, ok := exc.(exchange.FeeRater); ok && len(symbols) > 0 {
+		rate, err := rater.TakerFeeRate(ctx, symbols[0])
+		if err != nil {
+			return Market{}, fmt.Errorf("failed to get fee rate: %w", err)
+		}
+		m.FeeRate = rate
+	}
+	if limiter, ok := exc.(exchange.NotionalLimiter); ok && len(symbols) > 0 {
+		min, err := limiter.MinNotional(ctx, symbols[0])
+		if err != nil {
+			return Market{}, fmt.Errorf("failed to get minimum order value: %w", err)
+		}
+		m.MinNotional = min
+	}
+	return m, nil
+}
+
+// Plan is what a run would do. Amounts are in the quote asset.
+type Plan struct {
+	Mode     string          `json:"mode"`
+	Exchange string          `json:"exchange"`
+	Symbol   string          `json:"symbol"`
+	Side     string          `json:"side"`
+	DryRun   bool            `json:"dryRun"`
+	Price    decimal.Decimal `json:"price,omitzero"`
+
+	// Amount is what a buy spends or a sell raises, after the monthly
+	// budget, percent sizing or dip multiplier; OrderAmount is the buy's
+	// order after fee deduction
+	Amount      decimal.Decimal `json:"amount,omitzero"`
+	OrderAmount decimal.Decimal `json:"orderAmount,omitzero"`
+	FeeRate     decimal.Decimal `json:"feeRate,omitzero"`
+	Quantity    decimal.Decimal `json:"quantity,omitzero"` // base bought or sold at Price
+
+	Budget   *budget.Plan    `json:"budget,omitempty"`
+	Percent  *sizing.Percent `json:"percent,omitempty"`
+	Dip      *dip.Dip        `json:"dip,omitempty"`
+	Skip     *guard.Skip     `json:"skip,omitempty"`     // the guard that would skip the run
+	StopLoss bool            `json:"stopLoss,omitempty"` // a stop-loss would be placed after the buy
+
+	// DipUnplanned marks a buy whose dip multiplier the plan cannot work
+	// out: the reference is the high of candles it does not read, or there
+	// is no price. Amount is then the base amount.
+	DipUnplanned bool `json:"dipUnplanned,omitempty"`
+}
+
+// Evaluate plans a run of payload at now against m. spent is the
+// month-to-date spend of a budgeted strategy. Only DCA runs are planned;
+// other modes place no DCA order, so their plan names just the mode.
+func Evaluate(payload *config.DCAPayload, m Market, spent decimal.Decimal, now time.Time) (*Plan, error) {
+	s := payload.Strategy
+	p := &Plan{
+		Mode:     payload.Mode,
+		Exchange: payload.Exchange.Name,
+		Symbol:   s.Symbol,
+		Side:     s.Side,
+		DryRun:   payload.Flags.DryRun,
+	}
+	if payload.Mode != config.ModeDCA {
+		return p, nil
+	}
+
+	// Guards in the order a run checks them
+	switch {
+	case s.Budgeted():
+		plan, skip, err := Budget(s, spent, now)
+		if err != nil {
+			return nil, err
+		}
+		p.Budget, p.Skip = &plan, skip
+		if skip != nil {
+			return p, nil
+		}
+		p.Amount = plan.Amount
+	case s.PercentSized():
+		// Sized below, against the balance the run would read on the exchange
+	default:
+		amount, err := decimal.NewFromString(s.QuoteAmount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoteAmount %q", s.QuoteAmount)
+		}
+		p.Amount = amount
+	}
+
+	var err error
+	if p.Skip, err = guard.Calendar(s, now); err != nil || p.Skip != nil {
+		return p, err
+	}
+	if p.Skip, err = guard.Window(s, now); err != nil || p.Skip != nil {
+		return p, err
+	}
+
+	p.Price = m.Prices[s.Symbol]
+	_, quote, err := exchange.SplitSymbol(s.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if s.PercentSized() {
+		percent, skip, err := Percent(s, m.Balances[quote], m.MinNotional)
+		if err != nil {
+			return nil, err
+		}
+		p.Percent, p.Skip = &percent, skip
+		if skip != nil {
+			return p, nil
+		}
+		p.Amount = percent.Amount
+	}
+	amount := p.Amount
+
+	if s.Selling() {
+		if !p.Price.IsPositive() {
+			return nil, fmt.Errorf("no %s price to plan a sell", s.Symbol)
+		}
+		if p.Skip, err = guard.MinPrice(s, p.Price); err != nil || p.Skip != nil {
+			return p, err
+		}
+		step := m.LotStep
+		if !step.IsPositive() {
+			step = exchange.DefaultLotStep
+		}
+		if p.Quantity, err = sizing.SellQuantity(amount, p.Price, step); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	if p.Price.IsPositive() {
+		if p.Skip, err = guard.MaxPrice(s, p.Price); err != nil || p.Skip != nil {
+			return p, err
+		}
+	}
+	if dm := s.DipMultipliers; dm != nil {
+		if dm.ReferencePrice == "" || !p.Price.IsPositive() {
+			p.DipUnplanned = true
+		} else {
+			d, err := dip.Apply(*dm, amount, decimal.RequireFromString(dm.ReferencePrice), p.Price, sizing.QuotePlaces(asset.Canonical("", quote)))
+			if err != nil {
+				return nil, err
+			}
+			d.ReferenceSource = dip.SourcePayload
+			p.Dip, p.Amount, amount = &d, d.Amount, d.Amount
+		}
+	}
+	p.OrderAmount = amount
+	if s.FeeHandling == sizing.FeeDeduct {
+		p.FeeRate = m.FeeRate
+		if !p.FeeRate.IsPositive() && s.FeeRateBps != "" {
+			bps, err := money.BasisPointsFromString(s.FeeRateBps)
+			if err != nil {
+				return nil, fmt.Errorf("invalid feeRateBps: %w", err)
+			}
+			p.FeeRate = bps.Fraction()
+		}
+		if p.OrderAmount, err = sizing.DeductFee(amount, p.FeeRate, sizing.QuotePlaces(asset.Canonical("", quote))); err != nil {
+			return nil, err
+		}
+	}
+	if p.Price.IsPositive() {
+		p.Quantity = p.OrderAmount.Div(p.Price).RoundDown(quantityPlaces)
+	}
+	p.StopLoss = s.StopLoss != nil && payload.Flags.AllowProtectiveOrders
+	return p, nil
+}
+
+// Budget derives the amount of a run at now from the strategy's monthly
+// budget and the month-to-date spend. The skip is set once the month's
+// budget is spent.
+func Budget(s config.DCAStrategy, spent decimal.Decimal, now time.Time) (budget.Plan, *guard.Skip, error) {
+	loc, err := s.Location()
+	if err != nil {
+		return budget.Plan{}, nil, err
+	}
+	schedule, err := s.ParsedSchedule()
+	if err != nil {
+		return budget.Plan{}, nil, err
+	}
+	monthly, err := decimal.NewFromString(s.MonthlyBudget)
+	if err != nil {
+		return budget.Plan{}, nil, fmt.Errorf("invalid monthlyBudget %q", s.MonthlyBudget)
+	}
+	max := decimal.Zero
+	if s.MaxQuoteAmount != "" {
+		if max, err = decimal.NewFromString(s.MaxQuoteAmount); err != nil {
+			return budget.Plan{}, nil, fmt.Errorf("invalid maxQuoteAmount %q", s.MaxQuoteAmount)
+		}
+	}
+
+	local := now.In(loc)
+	_, quote, _ := exchange.SplitSymbol(s.Symbol)
+	plan := budget.PerRun(monthly, spent, max, schedule, local, sizing.QuotePlaces(asset.Canonical("", quote)))
+	if !plan.Amount.IsPositive() {
+		return plan, &guard.Skip{
+			Guard:  "budget",
+			Reason: fmt.Sprintf("the %s %s budget for %s is spent", plan.Monthly, quote, local.Format("January 2006")),
+		}, nil
+	}
+	return plan, nil, nil
+}
+
+// Percent derives the amount of a run from the strategy's percentage of
+// the free quote balance, capped by maxQuoteAmount. The skip is set when
+// the balance is empty or the amount is below minNotional, the exchange's
+// minimum order value; a zero minNotional is not checked.
+func Percent(s config.DCAStrategy, balance, minNotional decimal.Decimal) (sizing.Percent, *guard.Skip, error) {
+	percent, err := money.PercentFromString(s.QuoteAmountPercent)
+	if err != nil {
+		return sizing.Percent{}, nil, fmt.Errorf("invalid quoteAmountPercent %q", s.QuoteAmountPercent)
+	}
+	max := decimal.Zero
+	if s.MaxQuoteAmount != "" {
+		if max, err = decimal.NewFromString(s.MaxQuoteAmount); err != nil {
+			return sizing.Percent{}, nil, fmt.Errorf("invalid maxQuoteAmount %q", s.MaxQuoteAmount)
+		}
+	}
+
+	_, quote, _ := exchange.SplitSymbol(s.Symbol)
+	sized := sizing.PercentOfBalance(balance, percent, max, sizing.QuotePlaces(asset.Canonical("", quote)))
+	sized.MinNotional = minNotional
+	switch {
+	case !balance.IsPositive():
+		return sized, &guard.Skip{
+			Guard:  "balance",
+			Reason: fmt.Sprintf("there is no free %s balance to take %s%% of", quote, percent),
+		}, nil
+	case sized.Amount.LessThan(minNotional):
+		return sized, &guard.Skip{
+			Guard:  "minNotional",
+			Reason: fmt.Sprintf("%s%% of %s %s is %s %s, below the %s %s minimum order", percent, balance, quote, sized.Amount, quote, minNotional, quote),
+		}, nil
+	}
+	return sized, nil, nil
+}
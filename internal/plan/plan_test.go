@@ -0,0 +1,212 @@
+package plan
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+// fixture is the market every test plans against
+var fixture = Market{
+	Prices:      map[string]decimal.Decimal{"BTC-USDT": d("50000")},
+	FeeRate:     d("0.001"),
+	Balances:    map[string]decimal.Decimal{"USDT": d("1234.56")},
+	MinNotional: d("5"),
+}
+
+// sunday is a Sunday morning in Berlin, the timezone of the test payloads
+var sunday = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+
+func parse(t *testing.T, path string) *config.DCAPayload {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := config.ParseDCAPayload(raw)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return payload
+}
+
+func TestCompare_Golden(t *testing.T) {
+	for _, name := range []string{"quote_amount", "calendar_skip", "fee_deduct", "telegram_added", "monthly_budget", "percent"} {
+		t.Run(name, func(t *testing.T) {
+			before := parse(t, filepath.Join("testdata", "base.json"))
+			after := parse(t, filepath.Join("testdata", name+".new.json"))
+			beforePlan, err := Evaluate(before, fixture, decimal.Zero, sunday)
+			if err != nil {
+				t.Fatalf("Evaluate(before) error = %v", err)
+			}
+			afterPlan, err := Evaluate(after, fixture, d("100"), sunday)
+			if err != nil {
+				t.Fatalf("Evaluate(after) error = %v", err)
+			}
+			comparison, err := Compare(before, after, beforePlan, afterPlan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := comparison.String()
+
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", path, got)
+			}
+		})
+	}
+}
+
+func TestCompare_Identical(t *testing.T) {
+	payload := parse(t, filepath.Join("testdata", "base.json"))
+	p, err := Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comparison, err := Compare(payload, payload, p, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comparison.Config) != 0 || len(comparison.Plan) != 0 {
+		t.Errorf("Compare() = %+v, want no changes", comparison)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	payload := parse(t, filepath.Join("testdata", "base.json"))
+	p, err := Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Amount.Equal(d("10")) || !p.OrderAmount.Equal(d("10")) || !p.Quantity.Equal(d("0.0002")) || p.Skip != nil {
+		t.Errorf("Evaluate() = %+v, want a 10 USDT buy of 0.0002 BTC", p)
+	}
+
+	payload.Strategy.MaxPrice = "45000"
+	p, err = Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Skip == nil || p.Skip.Guard != "maxPrice" {
+		t.Errorf("Skip = %v, want the maxPrice guard", p.Skip)
+	}
+
+	payload.Strategy.MaxPrice = ""
+	payload.Strategy.DipMultipliers = &config.DipMultipliersConfig{Tiers: []config.DipTier{{DrawdownPercent: "20", Multiplier: "2"}}, ReferencePrice: "62500"}
+	p, err = Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Dip == nil || !p.Amount.Equal(d("20")) || !p.OrderAmount.Equal(d("20")) || p.DipUnplanned {
+		t.Errorf("Evaluate() = %+v, want the ×2 tier at 20%% below 62500", p)
+	}
+	payload.Strategy.DipMultipliers.ReferencePrice = ""
+	p, err = Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Dip != nil || !p.Amount.Equal(d("10")) || !p.DipUnplanned {
+		t.Errorf("Evaluate() = %+v, want the base amount with the dip unplanned", p)
+	}
+
+	payload.Strategy.DipMultipliers = nil
+	payload.Strategy.Side, payload.Strategy.MinPrice = config.SideSell, "60000"
+	p, err = Evaluate(payload, fixture, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Skip == nil || p.Skip.Guard != "minPrice" {
+		t.Errorf("Skip = %v, want the minPrice guard", p.Skip)
+	}
+
+	payload.Mode = config.ModeReport
+	if p, err = Evaluate(payload, fixture, decimal.Zero, sunday); err != nil || !p.Amount.IsZero() {
+		t.Errorf("Evaluate() = %+v, %v in report mode, want no order", p, err)
+	}
+}
+
+func TestPercent(t *testing.T) {
+	s := config.DCAStrategy{Symbol: "BTC-USDT", QuoteAmountPercent: "5", MaxQuoteAmount: "40"}
+	tests := []struct {
+		name      string
+		balance   string
+		want      string
+		wantGuard string
+	}{
+		{"percent of balance", "600", "30", ""},
+		{"capped", "1000", "40", ""},
+		{"zero balance", "0", "0", "balance"},
+		{"below min notional", "80", "4", "minNotional"},
+		{"at min notional", "100", "5", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skip, err := Percent(s, d(tt.balance), d("5"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Amount.Equal(d(tt.want)) {
+				t.Errorf("Amount = %s, want %s", got.Amount, tt.want)
+			}
+			guard := ""
+			if skip != nil {
+				guard = skip.Guard
+			}
+			if guard != tt.wantGuard {
+				t.Errorf("Skip = %v, want guard %q", skip, tt.wantGuard)
+			}
+		})
+	}
+
+	_, skip, _ := Percent(s, d("80"), d("5"))
+	if want := "5% of 80 USDT is 4 USDT, below the 5 USDT minimum order"; skip == nil || skip.Reason != want {
+		t.Errorf("Skip = %v, want reason %q", skip, want)
+	}
+}
+
+func TestEvaluate_Percent(t *testing.T) {
+	payload := parse(t, filepath.Join("testdata", "percent.new.json"))
+	market := fixture
+	market.Balances = map[string]decimal.Decimal{"USDT": d("0")}
+	p, err := Evaluate(payload, market, decimal.Zero, sunday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Skip == nil || p.Skip.Guard != "balance" || !p.Amount.IsZero() {
+		t.Errorf("Evaluate() = %+v, want a skip for the empty balance", p)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := config.Snapshot{{Path: "a", Value: "1"}, {Path: "b", Value: "x"}, {Path: "c", Value: nil}}
+	after := config.Snapshot{{Path: "a", Value: "2"}, {Path: "c", Value: nil, Origin: config.OriginDefault}, {Path: "d", Value: ""}}
+	want := []string{"~ a: 1 → 2", "- b = x", `+ d = ""`}
+
+	changes := Diff(before, after)
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %v, want %v", changes, want)
+	}
+	for i, c := range changes {
+		if c.String() != want[i] {
+			t.Errorf("change %d = %q, want %q", i, c, want[i])
+		}
+	}
+}
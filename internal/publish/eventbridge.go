@@ -0,0 +1,92 @@
+// Package publish sends execution results to downstream integrations so other
+// automation can react to each run without polling logs.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+// MaxEventBridgeEntrySize is the PutEvents limit for a single entry
+const MaxEventBridgeEntrySize = 256 * 1024
+
+// eventBridgeTimeSize is what EventBridge counts for the entry timestamp
+const eventBridgeTimeSize = 14
+
+// EventBridgeAPI is the subset of the EventBridge client used here
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridge publishes execution results as EventBridge events
+type EventBridge struct {
+	client EventBridgeAPI
+	cfg    config.EventBridgeConfig
+}
+
+// NewEventBridge creates an EventBridge publisher
+func NewEventBridge(client EventBridgeAPI, cfg config.EventBridgeConfig) *EventBridge {
+	return &EventBridge{client: client, cfg: cfg}
+}
+
+// DetailType returns the detail-type for a result, e.g. "DCA Execution Executed"
+func (p *EventBridge) DetailType(status result.Status) string {
+	s := string(status)
+	if s == "" {
+		return p.cfg.DetailType
+	}
+	return p.cfg.DetailType + " " + strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Publish puts a single event whose detail is the result JSON. When the
+// entry exceeds the size limit the raw exchange response is dropped first;
+// if it still does not fit, an error is returned and nothing is sent.
+func (p *EventBridge) Publish(ctx context.Context, res *result.ExecutionResult) error {
+	detailType := p.DetailType(res.Status)
+
+	detail, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if p.entrySize(detailType, detail) > MaxEventBridgeEntrySize {
+		if detail, err = json.Marshal(res.WithoutRaw()); err != nil {
+			return fmt.Errorf("failed to marshal result: %w", err)
+		}
+		if size := p.entrySize(detailType, detail); size > MaxEventBridgeEntrySize {
+			return fmt.Errorf("event is %d bytes, exceeds the %d byte EventBridge limit", size, MaxEventBridgeEntrySize)
+		}
+	}
+
+	out, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(p.cfg.BusName),
+			Source:       aws.String(p.cfg.Source),
+			DetailType:   aws.String(detailType),
+			Detail:       aws.String(string(detail)),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("eventbridge PutEvents failed: %w", err)
+	}
+	// PutEvents reports per-entry failures in the response, not as an error
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		entry := out.Entries[0]
+		return fmt.Errorf("eventbridge rejected event: %s: %s",
+			aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
+
+// entrySize approximates how EventBridge sizes an entry: source,
+// detail-type, detail and timestamp bytes
+func (p *EventBridge) entrySize(detailType string, detail []byte) int {
+	return eventBridgeTimeSize + len(p.cfg.Source) + len(detailType) + len(detail)
+}
@@ -0,0 +1,177 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+type stubEventBridge struct {
+	inputs []*eventbridge.PutEventsInput
+	out    *eventbridge.PutEventsOutput
+	err    error
+}
+
+func (s *stubEventBridge) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	s.inputs = append(s.inputs, params)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.out != nil {
+		return s.out, nil
+	}
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+var testConfig = config.EventBridgeConfig{BusName: "dca", Source: "dca-bot", DetailType: "DCA Execution"}
+
+func executedResult(raw json.RawMessage) *result.ExecutionResult {
+	return &result.ExecutionResult{
+		SchemaVersion: result.SchemaVersion,
+		ExecutionID:   "01ARYZ6S410000000000000000",
+		Status:        result.StatusExecuted,
+		Exchange:      "binance",
+		Symbol:        "BTC-USDT",
+		Order:         &exchange.Order{ID: "42", Symbol: "BTC-USDT", Raw: raw},
+	}
+}
+
+func TestEventBridge_Publish(t *testing.T) {
+	stub := &stubEventBridge{}
+	p := NewEventBridge(stub, testConfig)
+
+	if err := p.Publish(context.Background(), executedResult(json.RawMessage(`{"orderId":42}`))); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(stub.inputs) != 1 || len(stub.inputs[0].Entries) != 1 {
+		t.Fatalf("expected one PutEvents call with one entry, got %+v", stub.inputs)
+	}
+
+	entry := stub.inputs[0].Entries[0]
+	if aws.ToString(entry.EventBusName) != "dca" || aws.ToString(entry.Source) != "dca-bot" {
+		t.Errorf("unexpected bus/source: %s/%s", aws.ToString(entry.EventBusName), aws.ToString(entry.Source))
+	}
+	if got := aws.ToString(entry.DetailType); got != "DCA Execution Executed" {
+		t.Errorf("DetailType = %q", got)
+	}
+
+	var detail result.ExecutionResult
+	if err := json.Unmarshal([]byte(aws.ToString(entry.Detail)), &detail); err != nil {
+		t.Fatalf("detail is not a result: %v", err)
+	}
+	if detail.SchemaVersion != result.SchemaVersion || detail.Order == nil || string(detail.Order.Raw) != `{"orderId":42}` {
+		t.Errorf("unexpected detail %+v", detail)
+	}
+}
+
+func TestEventBridge_Publish_Decimals(t *testing.T) {
+	stub := &stubEventBridge{}
+	res := executedResult(nil)
+	res.Order.Quantity = decimal.RequireFromString("0.00000001")
+	res.Order.Price = decimal.RequireFromString("123456789.123456789")
+	if err := NewEventBridge(stub, testConfig).Publish(context.Background(), res); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	detail := aws.ToString(stub.inputs[0].Entries[0].Detail)
+	if !strings.Contains(detail, `"quantity":"0.00000001","price":"123456789.123456789"`) {
+		t.Errorf("decimals are not plain strings in %s", detail)
+	}
+	var back result.ExecutionResult
+	if err := json.Unmarshal([]byte(detail), &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.Order.Quantity.Equal(res.Order.Quantity) || !back.Order.Price.Equal(res.Order.Price) {
+		t.Errorf("order = %s @ %s after the round trip", back.Order.Quantity, back.Order.Price)
+	}
+}
+
+func TestEventBridge_DetailType(t *testing.T) {
+	p := NewEventBridge(&stubEventBridge{}, testConfig)
+	tests := map[result.Status]string{
+		result.StatusExecuted: "DCA Execution Executed",
+		result.StatusSkipped:  "DCA Execution Skipped",
+		result.StatusFailed:   "DCA Execution Failed",
+	}
+	for status, expected := range tests {
+		if got := p.DetailType(status); got != expected {
+			t.Errorf("DetailType(%s) = %q, want %q", status, got, expected)
+		}
+	}
+}
+
+func TestEventBridge_Publish_Oversize(t *testing.T) {
+	big := json.RawMessage(`"` + strings.Repeat("x", MaxEventBridgeEntrySize) + `"`)
+
+	t.Run("raw_truncated", func(t *testing.T) {
+		stub := &stubEventBridge{}
+		res := executedResult(big)
+		if err := NewEventBridge(stub, testConfig).Publish(context.Background(), res); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		detail := aws.ToString(stub.inputs[0].Entries[0].Detail)
+		if strings.Contains(detail, "xxxx") || !strings.Contains(detail, `"rawTruncated":true`) {
+			t.Errorf("raw payload was not truncated: %.200s", detail)
+		}
+		if len(res.Order.Raw) == 0 {
+			t.Error("Publish() must not modify the caller's result")
+		}
+	})
+
+	t.Run("still_too_large", func(t *testing.T) {
+		stub := &stubEventBridge{}
+		res := executedResult(nil)
+		res.Error = strings.Repeat("e", MaxEventBridgeEntrySize)
+		err := NewEventBridge(stub, testConfig).Publish(context.Background(), res)
+		if err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("Publish() error = %v, want size error", err)
+		}
+		if len(stub.inputs) != 0 {
+			t.Error("oversized event must not be sent")
+		}
+	})
+}
+
+func TestEventBridge_Publish_Failure(t *testing.T) {
+	tests := []struct {
+		name        string
+		stub        *stubEventBridge
+		expectedErr string
+	}{
+		{
+			name:        "call_error",
+			stub:        &stubEventBridge{err: errors.New("AccessDeniedException")},
+			expectedErr: "AccessDeniedException",
+		},
+		{
+			name: "entry_rejected",
+			stub: &stubEventBridge{out: &eventbridge.PutEventsOutput{
+				FailedEntryCount: 1,
+				Entries: []types.PutEventsResultEntry{{
+					ErrorCode:    aws.String("InternalFailure"),
+					ErrorMessage: aws.String("try again"),
+				}},
+			}},
+			expectedErr: "InternalFailure",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewEventBridge(tt.stub, testConfig).Publish(context.Background(), executedResult(nil))
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Publish() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+// HeartbeatFailSuffix is appended to the ping URL for failed runs, as
+// healthchecks.io expects
+const HeartbeatFailSuffix = "/fail"
+
+// HeartbeatExecutionIDParam is the query parameter carrying the execution ID
+const HeartbeatExecutionIDParam = "executionId"
+
+// Heartbeat pings a dead man's switch with the outcome of each run
+type Heartbeat struct {
+	client *http.Client
+	url    string
+}
+
+// NewHeartbeat creates a heartbeat pinging pingURL. The client's timeout,
+// if any, bounds each ping.
+func NewHeartbeat(client *http.Client, pingURL string) *Heartbeat {
+	return &Heartbeat{client: client, url: pingURL}
+}
+
+// PingURL returns the URL pinged for res: the configured URL, with
+// HeartbeatFailSuffix when the run failed or was canceled, and the execution ID as a query
+// parameter. Skipped and deferred runs count as alive.
+func (h *Heartbeat) PingURL(res *result.ExecutionResult) (string, error) {
+	u, err := url.Parse(h.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid heartbeat URL: %w", withoutURL(err))
+	}
+	if res.Status == result.StatusFailed || res.Status == result.StatusCanceled {
+		u.Path = strings.TrimSuffix(u.Path, "/") + HeartbeatFailSuffix
+		u.RawPath = ""
+	}
+	query := u.Query()
+	query.Set(HeartbeatExecutionIDParam, res.ExecutionID)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Ping reports res to the heartbeat URL. Any non-2xx response is an error.
+func (h *Heartbeat) Ping(ctx context.Context, res *result.ExecutionResult) error {
+	pingURL, err := h.PingURL(res)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build heartbeat request: %w", err)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// withoutURL drops the URL from a *url.Error. The ping URL is a secret, and
+// heartbeat errors end up in warnings and results.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
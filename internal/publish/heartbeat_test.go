@@ -0,0 +1,86 @@
+package publish
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+func TestHeartbeat_Ping(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string // configured path on the test server
+		status   result.Status
+		reply    int
+		wantPath string
+		wantErr  string
+	}{
+		{"executed", "/ping/abc", result.StatusExecuted, http.StatusOK, "/ping/abc", ""},
+		{"skipped_is_alive", "/ping/abc", result.StatusSkipped, http.StatusOK, "/ping/abc", ""},
+		{"deferred_is_alive", "/ping/abc", result.StatusDeferred, http.StatusOK, "/ping/abc", ""},
+		{"failed", "/ping/abc", result.StatusFailed, http.StatusOK, "/ping/abc/fail", ""},
+		{"canceled", "/ping/abc", result.StatusCanceled, http.StatusOK, "/ping/abc/fail", ""},
+		{"failed_trailing_slash", "/ping/abc/", result.StatusFailed, http.StatusOK, "/ping/abc/fail", ""},
+		{"rejected", "/ping/abc", result.StatusExecuted, http.StatusNotFound, "/ping/abc", "HTTP 404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.WriteHeader(tt.reply)
+			}))
+			defer srv.Close()
+
+			res := &result.ExecutionResult{ExecutionID: "01ARYZ6S410000000000000000", Status: tt.status}
+			err := NewHeartbeat(srv.Client(), srv.URL+tt.path+"?source=bot").Ping(context.Background(), res)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Ping() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Ping() error = %v, want %q", err, tt.wantErr)
+			}
+			if got.Method != http.MethodGet || got.URL.Path != tt.wantPath {
+				t.Errorf("request = %s %s, want GET %s", got.Method, got.URL.Path, tt.wantPath)
+			}
+			if q := got.URL.Query(); q.Get(HeartbeatExecutionIDParam) != res.ExecutionID || q.Get("source") != "bot" {
+				t.Errorf("query = %s, want the execution ID next to the configured parameters", got.URL.RawQuery)
+			}
+		})
+	}
+}
+
+func TestHeartbeat_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	pingURL := srv.URL + "/ping/secret-uuid"
+	srv.Close()
+
+	res := &result.ExecutionResult{ExecutionID: "01ARYZ6S410000000000000000", Status: result.StatusExecuted}
+	err := NewHeartbeat(&http.Client{Timeout: time.Second}, pingURL).Ping(context.Background(), res)
+	if err == nil || !strings.HasPrefix(err.Error(), "GET failed") {
+		t.Fatalf("Ping() error = %v, want a ping failure", err)
+	}
+	if strings.Contains(err.Error(), "secret-uuid") {
+		t.Errorf("Ping() error %q reveals the ping URL", err)
+	}
+}
+
+func TestHeartbeat_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer srv.Close()
+	defer close(release)
+
+	client := srv.Client()
+	client.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	err := NewHeartbeat(client, srv.URL).Ping(context.Background(), &result.ExecutionResult{Status: result.StatusExecuted})
+	if err == nil || time.Since(start) > time.Second {
+		t.Errorf("Ping() error = %v after %s, want a timeout", err, time.Since(start))
+	}
+}
@@ -0,0 +1,72 @@
+// Package rampup phases in a changed strategy: after its hash changes, the
+// next orders are scaled linearly from a start percentage up to the full
+// amount, limiting the damage of a mistaken edit
+package rampup
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// State is the ramp progress kept in the state store between runs
+type State struct {
+	Hash      string `json:"hash"`      // config.DCAPayload.StrategyHash of the strategy ramping up
+	Completed int    `json:"completed"` // orders placed since the hash last changed
+}
+
+// Step is where a run stands in the ramp
+type Step struct {
+	Step    int           `json:"step"` // 1-based
+	Runs    int           `json:"runs"`
+	Percent money.Percent `json:"percent"` // of the configured amount
+}
+
+// String renders the step as in notifications, e.g. "ramp-up 2/3 (70%)"
+func (s Step) String() string {
+	return fmt.Sprintf("ramp-up %d/%d (%s%%)", s.Step, s.Runs, s.Percent)
+}
+
+// Percent is the size of step of runs, linear from start at the first step
+// to 100% at the last, rounded to two decimals
+func Percent(step, runs int, start money.Percent) money.Percent {
+	if runs <= 1 {
+		return start
+	}
+	span := money.HundredPercent.Sub(start).Decimal().Mul(decimal.NewFromInt(int64(step - 1))).Div(decimal.NewFromInt(int64(runs - 1)))
+	return start.Add(money.NewPercent(span)).Round(2)
+}
+
+// Next decides the step of a run of the strategy with hash, given the state
+// recorded so far (nil before the first run). It returns the state to keep
+// and the step, nil when the order is placed at full size. The first
+// recorded strategy is taken as it is; only later changes ramp.
+func Next(prev *State, hash string, cfg config.RampUpConfig) (State, *Step, error) {
+	start, err := money.PercentFromString(cfg.StartPercent)
+	if err != nil {
+		return State{}, nil, fmt.Errorf("invalid startPercent %q", cfg.StartPercent)
+	}
+	state := State{Hash: hash}
+	switch {
+	case prev == nil:
+		state.Completed = cfg.Runs
+	case prev.Hash == hash:
+		state.Completed = prev.Completed
+	}
+	if state.Completed >= cfg.Runs {
+		return state, nil, nil
+	}
+	step := state.Completed + 1
+	return state, &Step{Step: step, Runs: cfg.Runs, Percent: Percent(step, cfg.Runs, start)}, nil
+}
+
+// Scale returns the share of amount a step places, truncated to places
+// decimals; the full amount without a step
+func Scale(amount decimal.Decimal, step *Step, places int32) decimal.Decimal {
+	if step == nil {
+		return amount
+	}
+	return step.Percent.ApplyTo(amount).Truncate(places)
+}
@@ -0,0 +1,83 @@
+package rampup
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		runs  int
+		start string
+		want  []string
+	}{
+		{3, "40", []string{"40", "70", "100"}},
+		{4, "50", []string{"50", "66.67", "83.33", "100"}},
+		{2, "10", []string{"10", "100"}},
+		{1, "50", []string{"50"}},
+	}
+	for _, tt := range tests {
+		for i, want := range tt.want {
+			if got := Percent(i+1, tt.runs, money.NewPercent(d(tt.start))); !got.Decimal().Equal(d(want)) {
+				t.Errorf("Percent(%d, %d, %s) = %s, want %s", i+1, tt.runs, tt.start, got, want)
+			}
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	cfg := config.RampUpConfig{Runs: 3, StartPercent: "40"}
+	tests := []struct {
+		name      string
+		prev      *State
+		hash      string
+		wantState State
+		wantStep  int // 0 for full size
+	}{
+		{"first recorded strategy", nil, "a", State{Hash: "a", Completed: 3}, 0},
+		{"unchanged", &State{Hash: "a", Completed: 3}, "a", State{Hash: "a", Completed: 3}, 0},
+		{"changed", &State{Hash: "a", Completed: 3}, "b", State{Hash: "b"}, 1},
+		{"changed mid ramp", &State{Hash: "a", Completed: 1}, "b", State{Hash: "b"}, 1},
+		{"second run", &State{Hash: "b", Completed: 1}, "b", State{Hash: "b", Completed: 1}, 2},
+		{"last run", &State{Hash: "b", Completed: 2}, "b", State{Hash: "b", Completed: 2}, 3},
+		{"done", &State{Hash: "b", Completed: 3}, "b", State{Hash: "b", Completed: 3}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, step, err := Next(tt.prev, tt.hash, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state != tt.wantState {
+				t.Errorf("state = %+v, want %+v", state, tt.wantState)
+			}
+			got := 0
+			if step != nil {
+				got = step.Step
+			}
+			if got != tt.wantStep {
+				t.Errorf("step = %v, want %d", step, tt.wantStep)
+			}
+		})
+	}
+
+	_, step, _ := Next(&State{Hash: "a", Completed: 1}, "a", cfg)
+	if step.String() != "ramp-up 2/3 (70%)" {
+		t.Errorf("String() = %q", step)
+	}
+}
+
+func TestScale(t *testing.T) {
+	step := &Step{Step: 2, Runs: 4, Percent: money.NewPercent(d("66.67"))}
+	if got := Scale(d("25"), step, 2); !got.Equal(d("16.66")) {
+		t.Errorf("Scale() = %s, want 16.66", got)
+	}
+	if got := Scale(d("25"), nil, 2); !got.Equal(d("25")) {
+		t.Errorf("Scale() without a step = %s, want the full amount", got)
+	}
+}
@@ -0,0 +1,54 @@
+// Package ratelimit spaces out exchange requests with a token bucket that
+// can be shared by several invocations through a conditional-update store.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit is a token bucket: up to Burst tokens, refilled at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst float64
+}
+
+// PerMinute returns a limit refilling n tokens a minute with a burst of burst
+func PerMinute(n, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: float64(burst)}
+}
+
+// Bucket is the stored state of a token bucket
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time // when Tokens was last computed; zero for a new bucket
+	Version int64     // incremented on every save; 0 for a bucket never saved
+}
+
+// Take refills b up to now and takes cost tokens from it. When there are
+// not enough, it returns b refilled but untouched and how long until there
+// will be. A new bucket starts full; a cost above Burst can never be met.
+func (l Limit) Take(b Bucket, cost float64, now time.Time) (Bucket, time.Duration) {
+	tokens := l.Burst
+	if !b.Updated.IsZero() {
+		tokens = b.Tokens
+		if elapsed := now.Sub(b.Updated); elapsed > 0 {
+			tokens = math.Min(l.Burst, tokens+elapsed.Seconds()*l.Rate)
+		}
+	}
+	if !now.After(b.Updated) {
+		// Clocks of different invocations disagree; never go back in time
+		now = b.Updated
+	}
+	b.Tokens, b.Updated = tokens, now
+
+	if tokens >= cost {
+		b.Tokens -= cost
+		return b, 0
+	}
+	if cost > l.Burst || l.Rate <= 0 {
+		return b, time.Duration(math.MaxInt64)
+	}
+	wait := time.Duration(math.Ceil((cost - tokens) / l.Rate * float64(time.Second)))
+	return b, wait
+}
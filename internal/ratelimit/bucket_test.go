@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLimit_Take(t *testing.T) {
+	t0 := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+	limit := PerMinute(60, 10) // 1 token a second, bursts of 10
+
+	tests := []struct {
+		name       string
+		bucket     Bucket
+		cost       float64
+		now        time.Time
+		wantTokens float64
+		wantWait   time.Duration
+	}{
+		{"new_bucket_starts_full", Bucket{}, 1, t0, 9, 0},
+		{"takes_available", Bucket{Tokens: 3, Updated: t0}, 2, t0, 1, 0},
+		{"refills_with_time", Bucket{Tokens: 0, Updated: t0}, 1, t0.Add(2500 * time.Millisecond), 1.5, 0},
+		{"refill_capped_at_burst", Bucket{Tokens: 5, Updated: t0}, 1, t0.Add(time.Hour), 9, 0},
+		{"short_waits_for_refill", Bucket{Tokens: 0.25, Updated: t0}, 1, t0, 0.25, 750 * time.Millisecond},
+		{"clock_behind_stored_bucket", Bucket{Tokens: 0, Updated: t0}, 1, t0.Add(-5 * time.Second), 0, time.Second},
+		{"cost_above_burst_never_met", Bucket{Tokens: 10, Updated: t0}, 11, t0, 10, time.Duration(math.MaxInt64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, wait := limit.Take(tt.bucket, tt.cost, tt.now)
+			if math.Abs(got.Tokens-tt.wantTokens) > 1e-9 || wait != tt.wantWait {
+				t.Errorf("Take() = %v tokens, wait %s; want %v tokens, wait %s", got.Tokens, wait, tt.wantTokens, tt.wantWait)
+			}
+			if got.Updated.Before(tt.bucket.Updated) {
+				t.Errorf("Updated = %s, moved back from %s", got.Updated, tt.bucket.Updated)
+			}
+			if got.Version != tt.bucket.Version {
+				t.Errorf("Version = %d, want it left to the store", got.Version)
+			}
+		})
+	}
+}
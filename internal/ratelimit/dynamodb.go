@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sudowanderer/dca-bot-go/internal/dynamo"
+)
+
+// DynamoKeyAttribute is the partition key of the rate limit table, a string
+const DynamoKeyAttribute = "pk"
+
+// DynamoStore keeps buckets in a DynamoDB table, one item per key, saved
+// with a condition on the item's version
+type DynamoStore struct {
+	client *dynamo.Client
+	table  string
+}
+
+// NewDynamoStore creates a store for table. Requests go to cfg.BaseEndpoint
+// when set, the regional endpoint otherwise.
+func NewDynamoStore(cfg aws.Config, table string) *DynamoStore {
+	return &DynamoStore{client: dynamo.New(cfg), table: table}
+}
+
+// attributeValue is a DynamoDB attribute of type S or N
+type attributeValue struct {
+	S string `json:"S,omitempty"`
+	N string `json:"N,omitempty"`
+}
+
+// Load reads the bucket under key with a consistent read
+func (s *DynamoStore) Load(ctx context.Context, key string) (Bucket, error) {
+	var resp struct {
+		Item map[string]attributeValue `json:"Item"`
+	}
+	err := s.client.Call(ctx, "GetItem", map[string]any{
+		"TableName":      s.table,
+		"Key":            map[string]attributeValue{DynamoKeyAttribute: {S: key}},
+		"ConsistentRead": true,
+	}, &resp)
+	if err != nil || resp.Item == nil {
+		return Bucket{}, err
+	}
+
+	tokens, err := strconv.ParseFloat(resp.Item["tokens"].N, 64)
+	if err != nil {
+		return Bucket{}, fmt.Errorf("invalid tokens in %s: %w", key, err)
+	}
+	updated, err := strconv.ParseInt(resp.Item["updated"].N, 10, 64)
+	if err != nil {
+		return Bucket{}, fmt.Errorf("invalid updated in %s: %w", key, err)
+	}
+	version, err := strconv.ParseInt(resp.Item["version"].N, 10, 64)
+	if err != nil {
+		return Bucket{}, fmt.Errorf("invalid version in %s: %w", key, err)
+	}
+	return Bucket{Tokens: tokens, Updated: time.Unix(0, updated), Version: version}, nil
+}
+
+// Save writes b under key unless another invocation saved it since b was
+// loaded, in which case it returns ErrConflict
+func (s *DynamoStore) Save(ctx context.Context, key string, b Bucket) error {
+	params := map[string]any{
+		"TableName": s.table,
+		"Item": map[string]attributeValue{
+			DynamoKeyAttribute: {S: key},
+			"tokens":           {N: strconv.FormatFloat(b.Tokens, 'f', -1, 64)},
+			"updated":          {N: strconv.FormatInt(b.Updated.UnixNano(), 10)},
+			"version":          {N: strconv.FormatInt(b.Version+1, 10)},
+		},
+		"ConditionExpression": "attribute_not_exists(" + DynamoKeyAttribute + ")",
+	}
+	if b.Version > 0 {
+		params["ConditionExpression"] = "#version = :version"
+		params["ExpressionAttributeNames"] = map[string]string{"#version": "version"}
+		params["ExpressionAttributeValues"] = map[string]attributeValue{":version": {N: strconv.FormatInt(b.Version, 10)}}
+	}
+	err := s.client.Call(ctx, "PutItem", params, nil)
+	if dynamo.IsType(err, "ConditionalCheckFailedException") {
+		return ErrConflict
+	}
+	return err
+}
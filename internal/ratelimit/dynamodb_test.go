@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// fakeDynamo serves GetItem and PutItem for one table, enforcing the
+// conditions the store sends
+type fakeDynamo struct {
+	mu    sync.Mutex
+	items map[string]map[string]attributeValue
+	auth  string
+}
+
+func (f *fakeDynamo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+
+	var req struct {
+		Key                       map[string]attributeValue
+		Item                      map[string]attributeValue
+		ConditionExpression       string
+		ExpressionAttributeValues map[string]attributeValue
+	}
+	body, _ := io.ReadAll(r.Body)
+	json.Unmarshal(body, &req)
+
+	switch r.Header.Get("X-Amz-Target") {
+	case "DynamoDB_20120810.GetItem":
+		item := f.items[req.Key[DynamoKeyAttribute].S]
+		json.NewEncoder(w).Encode(map[string]any{"Item": item})
+	case "DynamoDB_20120810.PutItem":
+		key := req.Item[DynamoKeyAttribute].S
+		current, exists := f.items[key]
+		ok := !exists
+		if req.ConditionExpression == "#version = :version" {
+			ok = exists && current["version"].N == req.ExpressionAttributeValues[":version"].N
+		}
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+			return
+		}
+		f.items[key] = req.Item
+		w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"com.amazon.coral.service#UnknownOperationException"}`))
+	}
+}
+
+func TestDynamoStore(t *testing.T) {
+	fake := &fakeDynamo{items: map[string]map[string]attributeValue{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store := NewDynamoStore(aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		})),
+	}, "dca-rate-limits")
+	ctx := context.Background()
+	key := "binance:nat-a"
+
+	b, err := store.Load(ctx, key)
+	if err != nil || b != (Bucket{}) {
+		t.Fatalf("Load() of a new key = %+v, %v; want an empty bucket", b, err)
+	}
+	if !strings.Contains(fake.auth, "/eu-west-1/dynamodb/aws4_request") {
+		t.Errorf("Authorization = %s, want a SigV4 dynamodb signature", fake.auth)
+	}
+
+	updated := time.Date(2026, time.March, 2, 8, 0, 0, 123, time.UTC)
+	if err := store.Save(ctx, key, Bucket{Tokens: 9.5, Updated: updated}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, key, Bucket{Tokens: 8}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a second new bucket error = %v, want ErrConflict", err)
+	}
+
+	b, err = store.Load(ctx, key)
+	if err != nil || b.Tokens != 9.5 || !b.Updated.Equal(updated) || b.Version != 1 {
+		t.Fatalf("Load() = %+v, %v; want the saved bucket at version 1", b, err)
+	}
+	stale := b
+	b.Tokens = 8.5
+	if err := store.Save(ctx, key, b); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, key, stale); !errors.Is(err, ErrConflict) {
+		t.Errorf("Save() of a stale bucket error = %v, want ErrConflict", err)
+	}
+
+	// Two limiters sharing the table never hand out more than the burst
+	clock := &fakeClock{now: updated}
+	a, c := New(store, key, PerMinute(60, 10)), New(store, key, PerMinute(60, 10))
+	for _, l := range []*Bucketed{a, c} {
+		l.Now, l.Sleep, l.MaxWait = clock.Now, clock.Sleep, time.Millisecond
+	}
+	taken := 0
+	for _, l := range []*Bucketed{a, c, a, c, a, c, a, c, a, c} {
+		if err := l.Wait(ctx, 1); err == nil {
+			taken++
+		} else if !errors.Is(err, ErrRateLimited) {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	if taken != 8 {
+		t.Errorf("took %d tokens, want the 8 left in the shared bucket", taken)
+	}
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request would have to wait longer than
+// the limiter's MaxWait or the context's deadline allow
+var ErrRateLimited = errors.New("rate limited")
+
+// ErrConflict is returned by Store.Save when the bucket changed since it was
+// loaded
+var ErrConflict = errors.New("bucket changed concurrently")
+
+// MaxConflicts bounds how often Wait reloads a bucket another invocation
+// keeps updating before giving up
+const MaxConflicts = 10
+
+// Store keeps token buckets. Save must write b only if the stored bucket is
+// still at b.Version (absent for version 0), storing it as b.Version+1.
+type Store interface {
+	Load(ctx context.Context, key string) (Bucket, error)
+	Save(ctx context.Context, key string, b Bucket) error
+}
+
+// Limiter waits until a request may be sent
+type Limiter interface {
+	Wait(ctx context.Context, cost float64) error
+}
+
+// Bucketed is a Limiter over the bucket stored under a key
+type Bucketed struct {
+	Store Store
+	Key   string
+	Limit Limit
+
+	// MaxWait fails requests that would wait longer; 0 waits as long as
+	// the context's deadline allows
+	MaxWait time.Duration
+
+	Now   func() time.Time                                 // defaults to time.Now
+	Sleep func(ctx context.Context, d time.Duration) error // defaults to a timer honouring ctx
+}
+
+// New creates a limiter over the bucket stored under key in store
+func New(store Store, key string, limit Limit) *Bucketed {
+	return &Bucketed{Store: store, Key: key, Limit: limit}
+}
+
+// Wait takes cost tokens, waiting for them when the bucket is short. It
+// fails fast with ErrRateLimited when the wait would exceed MaxWait or the
+// context's deadline.
+func (l *Bucketed) Wait(ctx context.Context, cost float64) error {
+	now, sleep := l.Now, l.Sleep
+	if now == nil {
+		now = time.Now
+	}
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	conflicts := 0
+	for {
+		b, err := l.Store.Load(ctx, l.Key)
+		if err != nil {
+			return fmt.Errorf("failed to load rate limit %s: %w", l.Key, err)
+		}
+		t := now()
+		b, wait := l.Limit.Take(b, cost, t)
+		if wait > 0 {
+			if l.MaxWait > 0 && wait > l.MaxWait {
+				return fmt.Errorf("%w: %s would wait %s, more than %s", ErrRateLimited, l.Key, wait.Round(time.Millisecond), l.MaxWait)
+			}
+			if deadline, ok := ctx.Deadline(); ok && t.Add(wait).After(deadline) {
+				return fmt.Errorf("%w: %s would wait %s, past the deadline", ErrRateLimited, l.Key, wait.Round(time.Millisecond))
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = l.Store.Save(ctx, l.Key, b)
+		if errors.Is(err, ErrConflict) {
+			// Another invocation took tokens in between; recompute from its state
+			if conflicts++; conflicts >= MaxConflicts {
+				return fmt.Errorf("failed to update rate limit %s after %d attempts: %w", l.Key, conflicts, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update rate limit %s: %w", l.Key, err)
+		}
+		return nil
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// MemoryStore keeps buckets in process, for local runs where there is no
+// other invocation to share them with
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]Bucket
+}
+
+// NewMemoryStore creates an empty in-process store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]Bucket{}}
+}
+
+// Load returns the bucket under key, or a new one
+func (s *MemoryStore) Load(ctx context.Context, key string) (Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buckets[key], nil
+}
+
+// Save stores b if the bucket under key is still at b.Version
+func (s *MemoryStore) Save(ctx context.Context, key string, b Bucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[key].Version != b.Version {
+		return ErrConflict
+	}
+	b.Version++
+	s.buckets[key] = b
+	return nil
+}
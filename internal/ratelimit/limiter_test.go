@@ -0,0 +1,195 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// fakeClock is a clock that only moves when the limiter sleeps
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newTestLimiter(store Store, clock *fakeClock) *Bucketed {
+	l := New(store, "binance:nat-a", PerMinute(60, 2))
+	l.Now, l.Sleep = clock.Now, clock.Sleep
+	return l
+}
+
+func TestBucketed_Wait(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	l := newTestLimiter(store, clock)
+	ctx := context.Background()
+
+	for i := range 3 {
+		if err := l.Wait(ctx, 1); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i+1, err)
+		}
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != time.Second {
+		t.Errorf("slept %v, want one second once the burst is used", clock.slept)
+	}
+	if b, _ := store.Load(ctx, l.Key); b.Version != 3 || b.Tokens != 0 {
+		t.Errorf("stored bucket = %+v, want version 3 and no tokens left", b)
+	}
+}
+
+func TestBucketed_FailFast(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+	empty := func() *MemoryStore {
+		s := NewMemoryStore()
+		s.Save(context.Background(), "binance:nat-a", Bucket{Tokens: 0, Updated: start})
+		return s
+	}
+
+	t.Run("max_wait", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		l := newTestLimiter(empty(), clock)
+		l.MaxWait = 500 * time.Millisecond
+		if err := l.Wait(context.Background(), 1); !errors.Is(err, ErrRateLimited) || len(clock.slept) != 0 {
+			t.Errorf("Wait() error = %v after sleeping %v, want ErrRateLimited without waiting", err, clock.slept)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		l := newTestLimiter(empty(), clock)
+		ctx, cancel := context.WithDeadline(context.Background(), start.Add(900*time.Millisecond))
+		defer cancel()
+		if err := l.Wait(ctx, 1); !errors.Is(err, ErrRateLimited) || len(clock.slept) != 0 {
+			t.Errorf("Wait() error = %v after sleeping %v, want ErrRateLimited without waiting", err, clock.slept)
+		}
+	})
+
+	t.Run("within_deadline", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		l := newTestLimiter(empty(), clock)
+		ctx, cancel := context.WithDeadline(context.Background(), start.Add(2*time.Second))
+		defer cancel()
+		if err := l.Wait(ctx, 1); err != nil {
+			t.Errorf("Wait() error = %v, want it to wait the second", err)
+		}
+	})
+}
+
+// racingStore lets a competing invocation take a token between each Load
+// and Save, for the first races saves
+type racingStore struct {
+	*MemoryStore
+	races int
+	clock *fakeClock
+}
+
+func (s *racingStore) Save(ctx context.Context, key string, b Bucket) error {
+	if s.races > 0 {
+		s.races--
+		current, _ := s.MemoryStore.Load(ctx, key)
+		taken, _ := PerMinute(60, 2).Take(current, 1, s.clock.now)
+		s.MemoryStore.Save(ctx, key, taken)
+	}
+	return s.MemoryStore.Save(ctx, key, b)
+}
+
+func TestBucketed_Contention(t *testing.T) {
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+
+	t.Run("recomputes_from_the_winner", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		store := &racingStore{MemoryStore: NewMemoryStore(), races: 2, clock: clock}
+		l := newTestLimiter(store, clock)
+		if err := l.Wait(context.Background(), 1); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+		// Both racers took the burst; the limiter then had to wait for a refill
+		b, _ := store.Load(context.Background(), l.Key)
+		if b.Version != 3 || b.Tokens != 0 || len(clock.slept) != 1 {
+			t.Errorf("stored bucket = %+v after sleeping %v, want version 3, no tokens and one wait", b, clock.slept)
+		}
+	})
+
+	t.Run("gives_up", func(t *testing.T) {
+		clock := &fakeClock{now: start}
+		store := &racingStore{MemoryStore: NewMemoryStore(), races: 1000, clock: clock}
+		l := newTestLimiter(store, clock)
+		l.Limit = PerMinute(60_000, 1_000_000)
+		err := l.Wait(context.Background(), 1)
+		if !errors.Is(err, ErrConflict) || errors.Is(err, ErrRateLimited) {
+			t.Errorf("Wait() error = %v, want ErrConflict after %d attempts", err, MaxConflicts)
+		}
+		if used := 1000 - store.races; used != MaxConflicts {
+			t.Errorf("saved %d times, want %d", used, MaxConflicts)
+		}
+	})
+}
+
+// failingStore cannot be reached
+type failingStore struct{}
+
+func (failingStore) Load(ctx context.Context, key string) (Bucket, error) {
+	return Bucket{}, errors.New("connection refused")
+}
+
+func (failingStore) Save(ctx context.Context, key string, b Bucket) error {
+	return errors.New("connection refused")
+}
+
+func TestTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	get := func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	start := time.Date(2026, time.March, 2, 8, 0, 0, 0, time.UTC)
+	limited := newTestLimiter(NewMemoryStore(), &fakeClock{now: start})
+	limited.MaxWait = time.Millisecond
+	ctx := WithLimiter(context.Background(), limited)
+	for range 2 {
+		if err := get(ctx); err != nil {
+			t.Fatalf("request within the burst: error = %v", err)
+		}
+	}
+	if err := get(ctx); !errors.Is(err, ErrRateLimited) || requests != 2 {
+		t.Errorf("request past the burst: error = %v with %d requests sent, want ErrRateLimited before sending", err, requests)
+	}
+
+	warnings := &run.Warnings{}
+	ctx = run.WithWarnings(WithLimiter(context.Background(), New(failingStore{}, "binance:nat-a", PerMinute(60, 2))), warnings)
+	if err := get(ctx); err != nil || requests != 3 {
+		t.Errorf("unreachable store: error = %v, want the request sent anyway", err)
+	}
+	if list := warnings.List(); len(list) != 1 || !strings.Contains(list[0].Error, "connection refused") {
+		t.Errorf("warnings = %+v, want the store failure", list)
+	}
+
+	if err := get(context.Background()); err != nil || requests != 4 {
+		t.Errorf("no limiter: error = %v, want the request sent", err)
+	}
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+type limiterKey struct{}
+
+// WithLimiter returns a copy of ctx whose exchange requests wait for l
+func WithLimiter(ctx context.Context, l Limiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+// FromContext returns the limiter stored in ctx, or nil when requests are
+// not limited
+func FromContext(ctx context.Context) Limiter {
+	l, _ := ctx.Value(limiterKey{}).(Limiter)
+	return l
+}
+
+// Transport makes each request take a token from the Limiter in its context
+// before passing it to Base, and counts it with the context's Usage. A
+// limiter whose store fails lets the request through with a warning: the
+// shared budget is a courtesy, the run is not.
+type Transport struct {
+	Base http.RoundTripper // defaults to http.DefaultTransport
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip waits for the limiter, then sends req and records its usage
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	ctx := req.Context()
+	if l := FromContext(ctx); l != nil {
+		if err := l.Wait(ctx, 1); err != nil {
+			if errors.Is(err, ErrRateLimited) || ctx.Err() != nil {
+				return nil, err
+			}
+			run.Warn(ctx, "ratelimit", "update", err)
+		}
+	}
+	usage := UsageFrom(ctx)
+	usage.sent()
+	resp, err := base.RoundTrip(req)
+	if err == nil {
+		usage.observe(ctx, resp.Header)
+	}
+	return resp, err
+}
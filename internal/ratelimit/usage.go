@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Binance reports the request weight used by the caller's IP in the current
+// minute on every response; older API versions only send the plain header
+const (
+	UsedWeightHeader       = "X-MBX-USED-WEIGHT-1M"
+	LegacyUsedWeightHeader = "X-MBX-USED-WEIGHT"
+)
+
+// UsedWeight reads the used request weight from a response's headers; ok is
+// false when the exchange sent none
+func UsedWeight(h http.Header) (weight int, ok bool) {
+	for _, name := range []string{UsedWeightHeader, LegacyUsedWeightHeader} {
+		if v := strings.TrimSpace(h.Get(name)); v != "" {
+			if w, err := strconv.Atoi(v); err == nil && w >= 0 {
+				return w, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Throttle reports whether used weight has reached fraction of limit. A
+// limit of 0, for exchanges that report no weight, never throttles.
+func Throttle(used, limit int, fraction decimal.Decimal) bool {
+	if limit <= 0 {
+		return false
+	}
+	return decimal.NewFromInt(int64(used)).GreaterThanOrEqual(decimal.NewFromInt(int64(limit)).Mul(fraction))
+}
+
+// Usage counts the exchange requests of one invocation and the highest
+// request weight the exchange reported. Once the weight reaches the warning
+// fraction of the limit it warns once and stays throttled for the rest of
+// the run. It is safe for concurrent use.
+type Usage struct {
+	limit    int
+	fraction decimal.Decimal
+
+	mu         sync.Mutex
+	requests   int
+	usedWeight int
+	throttled  bool
+}
+
+// NewUsage starts counting against a weight limit per minute, 0 when the
+// exchange reports no weight, warning at fraction of it
+func NewUsage(limit int, fraction decimal.Decimal) *Usage {
+	return &Usage{limit: limit, fraction: fraction}
+}
+
+type usageKey struct{}
+
+// WithUsage returns a copy of ctx whose exchange requests are counted by u
+func WithUsage(ctx context.Context, u *Usage) context.Context {
+	return context.WithValue(ctx, usageKey{}, u)
+}
+
+// UsageFrom returns the usage stored in ctx, or nil when requests are not
+// counted
+func UsageFrom(ctx context.Context) *Usage {
+	u, _ := ctx.Value(usageKey{}).(*Usage)
+	return u
+}
+
+// sent counts a request; nil-safe
+func (u *Usage) sent() {
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	u.requests++
+	u.mu.Unlock()
+}
+
+// observe records the weight reported in a response's headers and warns the
+// first time it crosses the threshold; nil-safe
+func (u *Usage) observe(ctx context.Context, h http.Header) {
+	if u == nil {
+		return
+	}
+	weight, ok := UsedWeight(h)
+	if !ok {
+		return
+	}
+	u.mu.Lock()
+	u.usedWeight = max(u.usedWeight, weight)
+	crossed := !u.throttled && Throttle(weight, u.limit, u.fraction)
+	u.throttled = u.throttled || crossed
+	u.mu.Unlock()
+
+	if crossed {
+		run.AddWarning(ctx, run.Warning{
+			Subsystem: "exchange",
+			Operation: "api usage",
+			Error:     fmt.Sprintf("request weight %d of %d used; skipping optional calls for the rest of the run", weight, u.limit),
+		})
+	}
+}
+
+// Throttled reports whether calls the run can do without should be
+// skipped; nil-safe
+func (u *Usage) Throttled() bool {
+	if u == nil {
+		return false
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.throttled
+}
+
+// Totals returns the usage so far, or nil when no request was sent; nil-safe
+func (u *Usage) Totals() *run.APIUsage {
+	if u == nil {
+		return nil
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.requests == 0 {
+		return nil
+	}
+	return &run.APIUsage{Requests: u.requests, UsedWeight: u.usedWeight, WeightLimit: u.limit, Throttled: u.throttled}
+}
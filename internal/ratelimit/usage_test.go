@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestUsedWeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+		wantOK  bool
+	}{
+		{"1m header", map[string]string{"X-Mbx-Used-Weight-1m": "1200"}, 1200, true},
+		{"legacy header", map[string]string{"X-MBX-USED-WEIGHT": "35"}, 35, true},
+		{"1m preferred", map[string]string{"X-MBX-USED-WEIGHT-1M": "40", "X-MBX-USED-WEIGHT": "900"}, 40, true},
+		{"padded", map[string]string{"X-MBX-USED-WEIGHT-1M": " 7 "}, 7, true},
+		{"none", map[string]string{"Content-Type": "application/json"}, 0, false},
+		{"garbage", map[string]string{"X-MBX-USED-WEIGHT-1M": "lots"}, 0, false},
+		{"negative", map[string]string{"X-MBX-USED-WEIGHT-1M": "-1"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			got, ok := UsedWeight(h)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("UsedWeight() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	fraction := decimal.RequireFromString("0.8")
+	tests := []struct {
+		used, limit int
+		want        bool
+	}{
+		{4799, 6000, false},
+		{4800, 6000, true},
+		{6100, 6000, true},
+		{10000, 0, false}, // no documented limit
+	}
+	for _, tt := range tests {
+		if got := Throttle(tt.used, tt.limit, fraction); got != tt.want {
+			t.Errorf("Throttle(%d, %d, 0.8) = %v, want %v", tt.used, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// weightTransport answers every request with the next used weight
+type weightTransport struct {
+	weights []string
+}
+
+func (w *weightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	h := http.Header{}
+	if len(w.weights) > 0 {
+		h.Set(UsedWeightHeader, w.weights[0])
+		w.weights = w.weights[1:]
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: h, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTransport_Usage(t *testing.T) {
+	usage := NewUsage(1000, decimal.RequireFromString("0.5"))
+	warnings := &run.Warnings{}
+	ctx := run.WithWarnings(WithUsage(context.Background(), usage), warnings)
+	client := &http.Client{Transport: NewTransport(&weightTransport{weights: []string{"100", "600", "700", "20"}})}
+
+	for i := range 5 {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.binance.com/api/v3/ticker/price", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if want := i >= 1; usage.Throttled() != want {
+			t.Errorf("after request %d: Throttled() = %v, want %v", i+1, usage.Throttled(), want)
+		}
+	}
+
+	totals := usage.Totals()
+	if totals == nil || totals.Requests != 5 || totals.UsedWeight != 700 || totals.WeightLimit != 1000 || !totals.Throttled {
+		t.Errorf("Totals() = %+v, want 5 requests at weight 700 of 1000, throttled", totals)
+	}
+	if list := warnings.List(); len(list) != 1 || !strings.Contains(list[0].Error, "600 of 1000") {
+		t.Errorf("warnings = %+v, want one warning when the threshold was crossed", list)
+	}
+}
+
+func TestUsage_Nil(t *testing.T) {
+	var usage *Usage
+	if usage.Throttled() || usage.Totals() != nil {
+		t.Error("a nil Usage must count nothing")
+	}
+	if NewUsage(6000, decimal.RequireFromString("0.8")).Totals() != nil {
+		t.Error("Totals() must be nil before any request")
+	}
+}
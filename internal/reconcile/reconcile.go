@@ -0,0 +1,209 @@
+// Package reconcile compares the exchange's trade history with the bot's
+// execution history, finding fills the bot placed but never recorded (e.g.
+// the Lambda timed out after ordering) and trades the bot did not place.
+package reconcile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/stoploss"
+)
+
+// botPrefixes start the client order IDs of every order the bot places
+var botPrefixes = []string{"dca-", stoploss.ClientOrderPrefix + "-"}
+
+// BotOrder reports whether a client order ID was generated by the bot
+func BotOrder(clientOrderID string) bool {
+	for _, prefix := range botPrefixes {
+		if strings.HasPrefix(clientOrderID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fill is the trades of one exchange order combined
+type Fill struct {
+	OrderID       string          `json:"orderId"`
+	ClientOrderID string          `json:"clientOrderId,omitempty"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Quantity      decimal.Decimal `json:"quantity"`
+	Price         decimal.Decimal `json:"price"` // volume-weighted average
+	FeeAmount     decimal.Decimal `json:"feeAmount"`
+	FeeAsset      string          `json:"feeAsset,omitempty"`
+	Time          time.Time       `json:"time"` // last trade
+	Trades        int             `json:"trades"`
+}
+
+// Order returns the fill as a filled market order
+func (f Fill) Order() *exchange.Order {
+	return &exchange.Order{
+		ID:            f.OrderID,
+		ClientOrderID: f.ClientOrderID,
+		Symbol:        f.Symbol,
+		Side:          f.Side,
+		Type:          exchange.OrderTypeMarket,
+		Quantity:      f.Quantity,
+		Price:         f.Price,
+		Status:        "filled",
+		FeeAmount:     f.FeeAmount,
+		FeeAsset:      f.FeeAsset,
+	}
+}
+
+// Fills groups trades by order, oldest fill first. Fees charged in more than
+// one asset keep only the first asset's total.
+func Fills(trades []exchange.Trade) []Fill {
+	var fills []Fill
+	index := map[string]int{}
+	cost := map[string]decimal.Decimal{}
+	for _, t := range trades {
+		i, ok := index[t.OrderID]
+		if !ok {
+			i = len(fills)
+			index[t.OrderID] = i
+			fills = append(fills, Fill{OrderID: t.OrderID, ClientOrderID: t.ClientOrderID, Symbol: t.Symbol, Side: t.Side, FeeAsset: t.FeeAsset})
+		}
+		f := &fills[i]
+		f.Quantity = f.Quantity.Add(t.Quantity)
+		cost[t.OrderID] = cost[t.OrderID].Add(t.Quantity.Mul(t.Price))
+		if t.FeeAsset == f.FeeAsset {
+			f.FeeAmount = f.FeeAmount.Add(t.FeeAmount)
+		}
+		if t.Time.After(f.Time) {
+			f.Time = t.Time
+		}
+		f.Trades++
+	}
+	for i := range fills {
+		if fills[i].Quantity.IsPositive() {
+			fills[i].Price = cost[fills[i].OrderID].DivRound(fills[i].Quantity, 8)
+		}
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+	return fills
+}
+
+// Entry is an order recorded in the execution history
+type Entry struct {
+	OrderID       string
+	ClientOrderID string
+	ExecutionID   string
+}
+
+// Report is the outcome of a reconciliation
+type Report struct {
+	Symbol string    `json:"symbol"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"` // exclusive
+	DryRun bool      `json:"dryRun"`
+
+	Trades  int    `json:"trades"`            // trades listed by the exchange
+	Matched int    `json:"matched"`           // fills already in the history
+	Missing []Fill `json:"missing,omitempty"` // bot fills absent from the history
+	Foreign []Fill `json:"foreign,omitempty"` // fills of orders the bot did not place
+
+	// ForeignImported is set when foreign fills are imported along with the
+	// missing ones (flags.importForeignTrades)
+	ForeignImported bool `json:"foreignImported,omitempty"`
+
+	// Unmatched are execution IDs of history orders in range that the
+	// exchange has no trades for
+	Unmatched []string `json:"unmatched,omitempty"`
+}
+
+// Match compares the fills of trades with the history entries of the same
+// symbol and range
+func Match(trades []exchange.Trade, history []Entry, importForeign bool) *Report {
+	report := &Report{Trades: len(trades), ForeignImported: importForeign}
+
+	recorded := map[string]bool{}
+	for _, e := range history {
+		if e.OrderID != "" {
+			recorded["id:"+e.OrderID] = true
+		}
+		if e.ClientOrderID != "" {
+			recorded["client:"+e.ClientOrderID] = true
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, f := range Fills(trades) {
+		seen["id:"+f.OrderID] = true
+		if f.ClientOrderID != "" {
+			seen["client:"+f.ClientOrderID] = true
+		}
+		switch {
+		case recorded["id:"+f.OrderID] || (f.ClientOrderID != "" && recorded["client:"+f.ClientOrderID]):
+			report.Matched++
+		case BotOrder(f.ClientOrderID):
+			report.Missing = append(report.Missing, f)
+		default:
+			report.Foreign = append(report.Foreign, f)
+		}
+	}
+
+	for _, e := range history {
+		if !seen["id:"+e.OrderID] && !(e.ClientOrderID != "" && seen["client:"+e.ClientOrderID]) {
+			report.Unmatched = append(report.Unmatched, e.ExecutionID)
+		}
+	}
+	return report
+}
+
+// Imports returns the fills to add to the history: the missing ones, and
+// the foreign ones when they are imported
+func (r *Report) Imports() []Fill {
+	imports := append([]Fill(nil), r.Missing...)
+	if r.ForeignImported {
+		imports = append(imports, r.Foreign...)
+	}
+	return imports
+}
+
+// Clean reports whether the history and the exchange agree
+func (r *Report) Clean() bool {
+	return len(r.Missing) == 0 && len(r.Foreign) == 0 && len(r.Unmatched) == 0
+}
+
+// Lines renders the report for the log and notifications
+func (r *Report) Lines() []string {
+	lines := []string{
+		fmt.Sprintf("%s %s through %s: %d trade(s), %d fill(s) matched", r.Symbol, r.From.Format(time.DateOnly), r.To.Add(-time.Nanosecond).Format(time.DateOnly), r.Trades, r.Matched),
+	}
+	action := "imported"
+	if r.DryRun {
+		action = "would import"
+	}
+	for _, f := range r.Missing {
+		lines = append(lines, fmt.Sprintf("missing (%s): %s", action, describe(f)))
+	}
+	foreignAction := "listed only"
+	if r.ForeignImported {
+		foreignAction = action
+	}
+	for _, f := range r.Foreign {
+		lines = append(lines, fmt.Sprintf("foreign (%s): %s", foreignAction, describe(f)))
+	}
+	for _, id := range r.Unmatched {
+		lines = append(lines, fmt.Sprintf("not on exchange: execution %s", id))
+	}
+	if r.Clean() {
+		lines = append(lines, "history matches the exchange")
+	}
+	return lines
+}
+
+func describe(f Fill) string {
+	client := f.ClientOrderID
+	if client == "" {
+		client = "-"
+	}
+	return fmt.Sprintf("%s %s %s @ %s, order %s (%s), %s", f.Side, f.Quantity.String(), f.Symbol, f.Price.String(), f.OrderID, client, f.Time.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,149 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func loadTrades(t *testing.T) []exchange.Trade {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "trades.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trades []exchange.Trade
+	if err := json.Unmarshal(data, &trades); err != nil {
+		t.Fatal(err)
+	}
+	return trades
+}
+
+// history recorded the first buy by order ID and an execution whose order
+// never reached the exchange; the partially filled second buy was lost
+var history = []Entry{
+	{OrderID: "5001", ClientOrderID: "dca-7Q2M4KXZ", ExecutionID: "01JA0000000000000000000001"},
+	{OrderID: "5999", ClientOrderID: "dca-9S4P6MZB", ExecutionID: "01JA0000000000000000000003"},
+}
+
+func TestFills(t *testing.T) {
+	fills := Fills(loadTrades(t))
+	if len(fills) != 4 {
+		t.Fatalf("Fills() = %d fills, want 4", len(fills))
+	}
+	partial := fills[1]
+	if partial.OrderID != "5002" || partial.Trades != 2 || !partial.Quantity.Equal(decimal.RequireFromString("0.0004")) {
+		t.Errorf("partial fill = %+v, want order 5002 of two trades", partial)
+	}
+	if !partial.Price.Equal(decimal.RequireFromString("62005")) || !partial.FeeAmount.Equal(decimal.RequireFromString("0.0000004")) {
+		t.Errorf("partial fill price = %s, fee = %s, want 62005 and 0.0000004", partial.Price, partial.FeeAmount)
+	}
+	if !partial.Time.Equal(time.Date(2025, 3, 10, 8, 0, 2, 0, time.UTC)) {
+		t.Errorf("partial fill time = %s, want the last trade", partial.Time)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		history       []Entry
+		importForeign bool
+		matched       int
+		missing       string
+		foreign       string
+		imports       int
+		unmatched     int
+	}{
+		{"lost_fills", history, false, 1, "5002,5004", "5003", 2, 1},
+		{"import_foreign", history, true, 1, "5002,5004", "5003", 3, 1},
+		// A fill recorded only by its client order ID still matches
+		{"match_by_client_id", []Entry{{ClientOrderID: "dca-8R3N5LYA", ExecutionID: "x"}}, false, 1, "5001,5004", "5003", 2, 0},
+		{"empty_history", nil, false, 0, "5001,5002,5004", "5003", 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Match(loadTrades(t), tt.history, tt.importForeign)
+			if report.Trades != 5 || report.Matched != tt.matched {
+				t.Errorf("Trades = %d, Matched = %d, want 5 and %d", report.Trades, report.Matched, tt.matched)
+			}
+			if got := orderIDs(report.Missing); got != tt.missing {
+				t.Errorf("Missing = %s, want %s", got, tt.missing)
+			}
+			if got := orderIDs(report.Foreign); got != tt.foreign {
+				t.Errorf("Foreign = %s, want %s", got, tt.foreign)
+			}
+			if len(report.Imports()) != tt.imports || len(report.Unmatched) != tt.unmatched {
+				t.Errorf("Imports = %d, Unmatched = %v, want %d and %d", len(report.Imports()), report.Unmatched, tt.imports, tt.unmatched)
+			}
+		})
+	}
+}
+
+func TestBotOrder(t *testing.T) {
+	for id, want := range map[string]bool{"dca-7Q2M4KXZ": true, "dcasl-7Q2M4KXZ": true, "web_4f1c9a": false, "dcaX": false, "": false} {
+		if BotOrder(id) != want {
+			t.Errorf("BotOrder(%q) = %v, want %v", id, !want, want)
+		}
+	}
+}
+
+func TestReport_Golden(t *testing.T) {
+	tests := []struct {
+		name          string
+		history       []Entry
+		dryRun        bool
+		importForeign bool
+	}{
+		{"report", history, false, false},
+		{"report_dry_run_foreign", history, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Match(loadTrades(t), tt.history, tt.importForeign)
+			report.Symbol = "BTC-USDT"
+			report.From = time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+			report.To = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+			report.DryRun = tt.dryRun
+			got := strings.Join(report.Lines(), "\n") + "\n"
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(expected) {
+				t.Errorf("output differs from %s:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+func TestReport_Clean(t *testing.T) {
+	report := Match(nil, nil, false)
+	if !report.Clean() || !strings.Contains(strings.Join(report.Lines(), "\n"), "history matches the exchange") {
+		t.Errorf("empty report Lines() = %v, want clean", report.Lines())
+	}
+}
+
+func orderIDs(fills []Fill) string {
+	var ids []string
+	for _, f := range fills {
+		ids = append(ids, f.OrderID)
+	}
+	return strings.Join(ids, ",")
+}
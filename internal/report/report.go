@@ -0,0 +1,255 @@
+// Package report builds read-only portfolio reports: the holdings of a
+// strategy valued at market, their cost basis from the execution history
+// and the runs coming up. Gathering only reads balances and prices, so it
+// works with read-only API keys. Every report is rendered by Event, whether
+// a report run or a periodic summary sends it.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+)
+
+// Report is a snapshot of one strategy's holdings
+type Report struct {
+	Symbol string           `json:"symbol"`
+	Base   exchange.Balance `json:"base"`
+	Quote  exchange.Balance `json:"quote"`
+	Price  decimal.Decimal  `json:"price,omitzero"` // last price of Symbol; zero when the exchange reports none
+	Value  decimal.Decimal  `json:"value,omitzero"` // Base.Total at Price, in the quote asset
+
+	CostBasis *CostBasis  `json:"costBasis,omitempty"` // omitted without an execution history
+	NextRuns  []time.Time `json:"nextRuns,omitempty"`  // upcoming scheduled runs
+
+	// RunCost is the estimated cost of this month's runs of the strategy,
+	// per the execution history; omitted when none recorded one
+	RunCost *cost.Summary `json:"runCost,omitempty"`
+
+	// LastBuy is the latest buy in the execution history whose run
+	// recorded the market around it; omitted when none did
+	LastBuy *LastBuy `json:"lastBuy,omitempty"`
+}
+
+// LastBuy is a buy and the market around it
+type LastBuy struct {
+	At     time.Time       `json:"at"`
+	Price  decimal.Decimal `json:"price"`
+	Market *market.Context `json:"market"`
+}
+
+// CostBasis is the average cost of the base asset the bot bought, per the
+// execution history. Sells take base out at the average cost; fees are not
+// included.
+type CostBasis struct {
+	Buys         int             `json:"buys"`
+	Sells        int             `json:"sells,omitempty"`
+	Quantity     decimal.Decimal `json:"quantity"`     // base bought and not sold again
+	Cost         decimal.Decimal `json:"cost"`         // quote paid for Quantity
+	AveragePrice decimal.Decimal `json:"averagePrice"` // Cost per unit of Quantity; zero when nothing is held
+
+	// UnrealizedPnL is Quantity at the report's price minus Cost; zero
+	// without a price
+	UnrealizedPnL decimal.Decimal `json:"unrealizedPnl,omitzero"`
+}
+
+// Gather reads the balances of symbol's base and quote assets and, when
+// exc reports prices, values the base holdings at the last price. It never
+// places or cancels orders.
+func Gather(ctx context.Context, exc exchange.Exchange, symbol string) (*Report, error) {
+	base, quote, err := exchange.SplitSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Symbol: symbol}
+	if r.Base, err = exc.GetBalanceDetail(ctx, base); err != nil {
+		return nil, fmt.Errorf("failed to get %s balance: %w", base, err)
+	}
+	if r.Quote, err = exc.GetBalanceDetail(ctx, quote); err != nil {
+		return nil, fmt.Errorf("failed to get %s balance: %w", quote, err)
+	}
+
+	if ticker, ok := exc.(exchange.PriceTicker); ok {
+		price, err := ticker.LastPrice(ctx, symbol)
+		switch {
+		case errors.Is(err, exchange.ErrSymbolNotFound):
+		case err != nil:
+			return nil, fmt.Errorf("failed to get %s price: %w", symbol, err)
+		default:
+			r.Price = price
+			r.Value = r.Base.Total.Mul(price)
+		}
+	}
+	return r, nil
+}
+
+// SetOrders computes the cost basis from the bot's filled orders of the
+// report's symbol, oldest first, valuing it at the report's price
+func (r *Report) SetOrders(orders []exchange.Order) {
+	b := Basis(orders)
+	if r.Price.IsPositive() {
+		b.UnrealizedPnL = b.Quantity.Mul(r.Price).Sub(b.Cost)
+	}
+	r.CostBasis = b
+}
+
+// Basis computes the average-cost basis of filled orders of one symbol,
+// oldest first
+func Basis(orders []exchange.Order) *CostBasis {
+	b := &CostBasis{}
+	for _, o := range orders {
+		if !o.Quantity.IsPositive() {
+			continue
+		}
+		if o.Side == "sell" {
+			b.Sells++
+			sold := decimal.Min(o.Quantity, b.Quantity)
+			if b.Quantity.IsPositive() {
+				b.Cost = b.Cost.Sub(b.Cost.Mul(sold).Div(b.Quantity))
+			}
+			b.Quantity = b.Quantity.Sub(sold)
+			continue
+		}
+		b.Buys++
+		b.Quantity = b.Quantity.Add(o.Quantity)
+		b.Cost = b.Cost.Add(o.Quantity.Mul(o.Price))
+	}
+	if b.Quantity.IsPositive() {
+		b.AveragePrice = b.Cost.Div(b.Quantity)
+	} else {
+		b.Cost = decimal.Zero
+	}
+	return b
+}
+
+// Event renders the report as a notification, with amounts written by f
+// and scheduled runs in loc
+func (r *Report) Event(f *money.Formatter, loc *time.Location) notify.Event {
+	base := asset.Canonical("", r.Base.Asset)
+	quote := asset.Canonical("", r.Quote.Asset)
+
+	summary := fmt.Sprintf("📊 %s: %s", r.Symbol, f.Amount(r.Base.Total, base))
+	if r.Price.IsPositive() {
+		summary += fmt.Sprintf(" worth %s", f.Amount(r.Value, quote))
+	}
+
+	var details []notify.Detail
+	if r.Price.IsPositive() {
+		details = append(details, notify.Detail{Label: "Price", Value: f.Number(r.Price, quote)})
+	}
+	details = append(details,
+		notify.Detail{Label: base + " Balance", Value: describeBalance(f, r.Base)},
+		notify.Detail{Label: quote + " Balance", Value: describeBalance(f, r.Quote)},
+	)
+
+	if b := r.CostBasis; b != nil {
+		if b.Buys == 0 {
+			details = append(details, notify.Detail{Label: "Cost Basis", Value: "no buys in the history"})
+		} else {
+			details = append(details, notify.Detail{
+				Label: "Cost Basis",
+				Value: fmt.Sprintf("%s for %s (%s, average %s)", f.Amount(b.Cost, quote), f.Amount(b.Quantity, base),
+					plural(b.Buys, "buy"), f.Number(b.AveragePrice, quote)),
+			})
+			if r.Price.IsPositive() && b.Cost.IsPositive() {
+				pct := money.PercentOf(b.UnrealizedPnL, b.Cost).Decimal()
+				details = append(details, notify.Detail{
+					Label: "Unrealized PnL",
+					Value: fmt.Sprintf("%s (%s%%)", signed(f.Amount(b.UnrealizedPnL, quote), b.UnrealizedPnL), signed(f.Number(pct.Round(2), ""), pct)),
+				})
+			}
+		}
+	}
+
+	if c := r.RunCost; c != nil {
+		details = append(details, notify.Detail{
+			Label: "Estimated Cost",
+			Value: fmt.Sprintf("%s over %s in %s, %s per run", c.Total, plural(c.Runs, "run"), c.Period, c.PerRun),
+		})
+	}
+
+	if b := r.LastBuy; b != nil {
+		details = append(details, notify.Detail{Label: "Last Buy Context", Value: describeLastBuy(f, b, quote, loc)})
+	}
+
+	if len(r.NextRuns) > 0 {
+		runs := make([]string, len(r.NextRuns))
+		for i, t := range r.NextRuns {
+			runs[i] = t.In(loc).Format("Mon 2 Jan 15:04 MST")
+		}
+		details = append(details, notify.Detail{Label: "Next Runs", Value: strings.Join(runs, ", ")})
+	}
+
+	return notify.Event{
+		Type:    notify.EventPostTrade,
+		Symbol:  r.Symbol,
+		Summary: summary,
+		Details: details,
+	}
+}
+
+// describeBalance renders the total of a balance and how much of it is locked
+func describeBalance(f *money.Formatter, b exchange.Balance) string {
+	code := asset.Canonical("", b.Asset)
+	if b.Locked.IsPositive() {
+		return fmt.Sprintf("%s (%s locked in open orders)", f.Amount(b.Total, code), f.Amount(b.Locked, code))
+	}
+	return f.Amount(b.Total, code)
+}
+
+// describeLastBuy compares the price of b with the market figures its run
+// recorded, e.g. "Tue 14 Oct at 61,000.00: 24h -2.15%, 3.62% above the
+// 30-day average 58,870.10, day range 60,120.00 to 61,480.00"
+func describeLastBuy(f *money.Formatter, b *LastBuy, quote string, loc *time.Location) string {
+	m := b.Market
+	var parts []string
+	if !m.Change24h.IsZero() {
+		parts = append(parts, "24h "+signed(f.Number(m.Change24h, ""), m.Change24h)+"%")
+	}
+	for _, avg := range []struct {
+		days  int
+		price decimal.Decimal
+	}{{7, m.Average7d}, {30, m.Average30d}} {
+		if !avg.price.IsPositive() || !b.Price.IsPositive() {
+			continue
+		}
+		pct := money.PercentOf(b.Price.Sub(avg.price), avg.price).Round(2).Decimal()
+		side := "above"
+		if pct.IsNegative() {
+			side = "below"
+		}
+		parts = append(parts, fmt.Sprintf("%s%% %s the %d-day average %s", f.Number(pct.Abs(), ""), side, avg.days, f.Number(avg.price, quote)))
+	}
+	if m.DayHigh.IsPositive() && m.DayLow.IsPositive() {
+		parts = append(parts, fmt.Sprintf("day range %s to %s", f.Number(m.DayLow, quote), f.Number(m.DayHigh, quote)))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "24h 0%")
+	}
+	return fmt.Sprintf("%s at %s: %s", b.At.In(loc).Format("Mon 2 Jan"), f.Number(b.Price, quote), strings.Join(parts, ", "))
+}
+
+// signed prefixes a formatted positive amount with "+"
+func signed(s string, d decimal.Decimal) string {
+	if d.IsPositive() {
+		return "+" + s
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,198 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func mockExchange() *exchange.MockExchange {
+	return &exchange.MockExchange{
+		Symbols: []string{"BTC-USDT"},
+		Prices:  map[string]decimal.Decimal{"BTC-USDT": d("60000")},
+		Balances: map[string]exchange.Balance{
+			"BTC":  exchange.NewBalance("BTC", d("0.02"), decimal.Zero),
+			"USDT": exchange.NewBalance("USDT", d("150"), d("50")),
+		},
+	}
+}
+
+func TestGather(t *testing.T) {
+	r, err := Gather(context.Background(), mockExchange(), "BTC-USDT")
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if !r.Base.Total.Equal(d("0.02")) || !r.Quote.Total.Equal(d("200")) {
+		t.Errorf("balances = %s BTC, %s USDT", r.Base.Total, r.Quote.Total)
+	}
+	if !r.Price.Equal(d("60000")) || !r.Value.Equal(d("1200")) {
+		t.Errorf("Price = %s, Value = %s, want 60000 and 1200", r.Price, r.Value)
+	}
+	if r.CostBasis != nil {
+		t.Error("Gather() must leave the cost basis to SetOrders")
+	}
+}
+
+// balanceOnly reports balances but no prices
+type balanceOnly struct{ exchange.Exchange }
+
+func TestGather_NoPrices(t *testing.T) {
+	r, err := Gather(context.Background(), balanceOnly{mockExchange()}, "BTC-USDT")
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	if !r.Price.IsZero() || !r.Value.IsZero() {
+		t.Errorf("Price = %s, Value = %s, want none", r.Price, r.Value)
+	}
+	r.SetOrders([]exchange.Order{{Side: "buy", Quantity: d("0.01"), Price: d("50000")}})
+	if !r.CostBasis.UnrealizedPnL.IsZero() {
+		t.Errorf("UnrealizedPnL = %s without a price, want zero", r.CostBasis.UnrealizedPnL)
+	}
+}
+
+type failingBalances struct{ exchange.Exchange }
+
+func (failingBalances) GetBalanceDetail(ctx context.Context, asset string) (exchange.Balance, error) {
+	return exchange.Balance{}, errors.New("invalid API key")
+}
+
+func TestGather_Error(t *testing.T) {
+	if _, err := Gather(context.Background(), failingBalances{mockExchange()}, "BTC-USDT"); err == nil || !strings.Contains(err.Error(), "invalid API key") {
+		t.Errorf("Gather() error = %v, want the balance error", err)
+	}
+}
+
+func TestBasis(t *testing.T) {
+	tests := []struct {
+		name             string
+		orders           []exchange.Order
+		wantQty, wantAvg string
+		wantCost         string
+		wantBuys         int
+	}{
+		{"none", nil, "0", "0", "0", 0},
+		{
+			name: "buys",
+			orders: []exchange.Order{
+				{Side: "buy", Quantity: d("0.01"), Price: d("50000")},
+				{Side: "buy", Quantity: d("0.01"), Price: d("70000")},
+			},
+			wantQty: "0.02", wantAvg: "60000", wantCost: "1200", wantBuys: 2,
+		},
+		{
+			name: "sell at average cost",
+			orders: []exchange.Order{
+				{Side: "buy", Quantity: d("0.01"), Price: d("50000")},
+				{Side: "buy", Quantity: d("0.01"), Price: d("70000")},
+				{Side: "sell", Quantity: d("0.005"), Price: d("80000")},
+			},
+			wantQty: "0.015", wantAvg: "60000", wantCost: "900", wantBuys: 2,
+		},
+		{
+			name: "sold out",
+			orders: []exchange.Order{
+				{Side: "buy", Quantity: d("0.01"), Price: d("50000")},
+				{Side: "sell", Quantity: d("0.02"), Price: d("80000")},
+			},
+			wantQty: "0", wantAvg: "0", wantCost: "0", wantBuys: 1,
+		},
+		{
+			name:    "unfilled orders ignored",
+			orders:  []exchange.Order{{Side: "buy", Quantity: decimal.Zero, Price: d("50000")}},
+			wantQty: "0", wantAvg: "0", wantCost: "0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Basis(tt.orders)
+			if !b.Quantity.Equal(d(tt.wantQty)) || !b.AveragePrice.Equal(d(tt.wantAvg)) || !b.Cost.Equal(d(tt.wantCost)) || b.Buys != tt.wantBuys {
+				t.Errorf("Basis() = %s for %s (avg %s, %d buys), want %s for %s (avg %s, %d buys)",
+					b.Cost, b.Quantity, b.AveragePrice, b.Buys, tt.wantCost, tt.wantQty, tt.wantAvg, tt.wantBuys)
+			}
+		})
+	}
+}
+
+func detail(event notify.Event, label string) (string, bool) {
+	for _, d := range event.Details {
+		if d.Label == label {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
+
+func TestEvent(t *testing.T) {
+	r, err := Gather(context.Background(), mockExchange(), "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.SetOrders([]exchange.Order{
+		{Side: "buy", Quantity: d("0.01"), Price: d("50000")},
+		{Side: "buy", Quantity: d("0.01"), Price: d("50000")},
+	})
+	berlin := time.FixedZone("CET", 3600)
+	r.NextRuns = []time.Time{
+		time.Date(2026, 3, 16, 7, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 23, 7, 0, 0, 0, time.UTC),
+	}
+	r.RunCost = cost.Summarize("March 2026", []*cost.Estimate{
+		{AWS: d("0.004"), Fees: []cost.Fee{{Asset: "USDT", Amount: d("0.01")}}},
+		{AWS: d("0.002"), Fees: []cost.Fee{{Asset: "USDT", Amount: d("0.01")}}},
+	})
+	r.LastBuy = &LastBuy{
+		At:     time.Date(2026, 3, 9, 7, 0, 1, 0, time.UTC),
+		Price:  d("50000"),
+		Market: &market.Context{Change24h: d("-2.5"), Average7d: d("52000"), Average30d: d("48000"), DayHigh: d("51000"), DayLow: d("49500")},
+	}
+
+	event := r.Event(money.New("en", nil), berlin)
+	if event.Summary != "📊 BTC-USDT: 0.02 BTC worth 1,200.00 USDT" || event.Symbol != "BTC-USDT" {
+		t.Errorf("Summary = %q", event.Summary)
+	}
+	want := map[string]string{
+		"Price":          "60,000.00",
+		"BTC Balance":    "0.02 BTC",
+		"USDT Balance":   "200.00 USDT (50.00 USDT locked in open orders)",
+		"Cost Basis":     "1,000.00 USDT for 0.02 BTC (2 buys, average 50,000.00)",
+		"Unrealized PnL": "+200.00 USDT (+20%)",
+		"Next Runs":      "Mon 16 Mar 08:00 CET, Mon 23 Mar 08:00 CET",
+		"Estimated Cost": "$0.0060 AWS + 0.02 USDT fees over 2 runs in March 2026, $0.0030 AWS + 0.01 USDT fees per run",
+		"Last Buy Context": "Mon 9 Mar at 50,000.00: 24h -2.5%, 3.85% below the 7-day average 52,000.00, " +
+			"4.17% above the 30-day average 48,000.00, day range 49,500.00 to 51,000.00",
+	}
+	for label, value := range want {
+		if got, _ := detail(event, label); got != value {
+			t.Errorf("%s = %q, want %q", label, got, value)
+		}
+	}
+}
+
+func TestEvent_WithoutHistory(t *testing.T) {
+	r, err := Gather(context.Background(), mockExchange(), "BTC-USDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := r.Event(nil, time.UTC)
+	for _, label := range []string{"Cost Basis", "Unrealized PnL", "Next Runs", "Estimated Cost", "Last Buy Context"} {
+		if _, ok := detail(event, label); ok {
+			t.Errorf("%s shown without history or schedule", label)
+		}
+	}
+
+	r.SetOrders(nil)
+	if got, _ := detail(r.Event(nil, time.UTC), "Cost Basis"); got != "no buys in the history" {
+		t.Errorf("Cost Basis = %q for an empty history", got)
+	}
+}
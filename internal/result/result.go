@@ -0,0 +1,306 @@
+// Package result defines ExecutionResult, the versioned summary of a single
+// run that is published to downstream integrations.
+package result
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/budget"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/dip"
+	"github.com/sudowanderer/dca-bot-go/internal/dust"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/execlock"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/intent"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/native"
+	"github.com/sudowanderer/dca-bot-go/internal/rampup"
+	"github.com/sudowanderer/dca-bot-go/internal/reconcile"
+	"github.com/sudowanderer/dca-bot-go/internal/report"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+	"github.com/sudowanderer/dca-bot-go/internal/stoploss"
+	"github.com/sudowanderer/dca-bot-go/internal/threshold"
+)
+
+// SchemaVersion is bumped whenever a field is removed or changes meaning.
+// Adding fields does not change the version.
+const SchemaVersion = "1"
+
+// Status is the outcome of a run
+type Status string
+
+const (
+	StatusExecuted Status = "executed"
+	StatusSkipped  Status = "skipped"
+	StatusFailed   Status = "failed"
+	StatusDeferred Status = "deferred" // failed retriably; a retry is scheduled
+	StatusCanceled Status = "canceled" // interrupted, e.g. by Ctrl-C, before it finished
+)
+
+// ExecutionResult summarizes a run. It never carries credentials or the
+// raw payload, so it is safe to publish as-is. Decimals are written as JSON
+// strings in plain notation, e.g. "0.00000001", so no consumer reads them
+// as floats.
+type ExecutionResult struct {
+	SchemaVersion string `json:"schemaVersion"`
+	ExecutionID   string `json:"executionId"`
+	Status        Status `json:"status"`
+	Mode          string `json:"mode,omitempty"` // "dca", "dust", "reconcile" or "report"
+
+	Exchange    string `json:"exchange"`          // the exchange that executed, after any failover
+	Account     string `json:"account,omitempty"` // label of the exchange account the run was for
+	Symbol      string `json:"symbol"`
+	QuoteAmount string `json:"quoteAmount"`
+	DryRun      bool   `json:"dryRun"`
+
+	Sizing   *sizing.Sizing     `json:"sizing,omitempty"`   // how the order amount was derived
+	Budget   *budget.Plan       `json:"budget,omitempty"`   // how quoteAmount was derived from strategy.monthlyBudget
+	Percent  *sizing.Percent    `json:"percent,omitempty"`  // how quoteAmount was derived from strategy.quoteAmountPercent
+	RampUp   *rampup.Step       `json:"rampUp,omitempty"`   // how quoteAmount was scaled by flags.rampUp
+	Dip      *dip.Dip           `json:"dip,omitempty"`      // how quoteAmount was multiplied by strategy.dipMultipliers
+	Order    *exchange.Order    `json:"order,omitempty"`    // set when an order was placed
+	Ticker   *exchange.Ticker   `json:"ticker,omitempty"`   // the symbol's ticker just before the order
+	Transfer *exchange.Transfer `json:"transfer,omitempty"` // funds moved in by strategy.autoTransfer before the order
+	Skip     *guard.Skip        `json:"skip,omitempty"`     // set when a guard skipped the run
+	Dust     *dust.Report       `json:"dust,omitempty"`     // set by dust runs
+	Native   *native.Report     `json:"native,omitempty"`   // set when the exchange's recurring-buy plan ran the strategy
+	Error    string             `json:"error,omitempty"`    // set when the run failed
+
+	// Duplicate is the idempotency lock of the run that already executed
+	// the window, with its order; set when the run was skipped for it
+	Duplicate *execlock.Lock `json:"duplicate,omitempty"`
+
+	// Step is the step a failed run failed in, the timing span it started
+	// last, e.g. "exchange.order"
+	Step string `json:"step,omitempty"`
+
+	Reconcile *reconcile.Report `json:"reconcile,omitempty"` // set by reconcile runs
+	Report    *report.Report    `json:"report,omitempty"`    // set by report runs
+
+	// Reconciled marks a record imported by a reconcile run from the
+	// exchange's trade history rather than written by the run that ordered
+	Reconciled bool `json:"reconciled,omitempty"`
+
+	// Recovery is set when the run first recovered the order of an earlier
+	// run that died before saving its records
+	Recovery *intent.Recovery `json:"recovery,omitempty"`
+
+	// Recovered marks the record of that earlier run, written by the run
+	// that recovered its order
+	Recovered bool `json:"recovered,omitempty"`
+
+	// PendingSettlement marks a run whose order the exchange had not
+	// settled by the end of its wait; the order's quantity and price are
+	// provisional until the next run recovers it
+	PendingSettlement bool `json:"pendingSettlement,omitempty"`
+
+	StopLoss *stoploss.Result `json:"stopLoss,omitempty"` // protective order placed after the buy
+
+	// Balance is the watched balance after the order, the quote asset's
+	// for a buy, and whether it fell below strategy.balanceThreshold; set
+	// when a threshold or notifications.projectRunway is configured
+	Balance *threshold.Check `json:"balance,omitempty"`
+
+	// Market is the market around the run under state.enrichWithContext,
+	// from the candles the circuit breaker read; omitted when it read none
+	Market *market.Context `json:"market,omitempty"`
+
+	// Accounts holds one result per exchange account, in order, when the
+	// strategy ran for several accounts; the run fails when any of them did
+	Accounts []*ExecutionResult `json:"accounts,omitempty"`
+
+	Failovers []exchange.Failover `json:"failovers,omitempty"` // unavailable exchanges skipped, in order
+	RetryAt   *time.Time          `json:"retryAt,omitempty"`   // set when the run was re-scheduled
+
+	Timing *run.Timing `json:"timing,omitempty"` // where the run's time went
+
+	// Cost is the estimated AWS cost of the invocation and the trading fees
+	// of its orders, its accounts' included
+	Cost *cost.Estimate `json:"cost,omitempty"`
+
+	// Config is the effective configuration of the run, with the origin of
+	// each value and inline credentials redacted
+	Config config.Snapshot `json:"config,omitempty"`
+
+	// Warnings are failures of optional subsystems that did not fail the
+	// run, recorded up to the end of the run; a failed publish of this
+	// result is not among them
+	Warnings []run.Warning `json:"warnings,omitempty"`
+
+	RawTruncated bool `json:"rawTruncated,omitempty"` // Order.Raw was dropped to fit a size limit
+
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// New starts a result for the run in ctx
+func New(ctx context.Context, payload *config.DCAPayload) *ExecutionResult {
+	return &ExecutionResult{
+		SchemaVersion: SchemaVersion,
+		ExecutionID:   run.ID(ctx),
+		Mode:          payload.Mode,
+		Exchange:      payload.Exchange.Name,
+		Account:       payload.Account,
+		Symbol:        payload.Strategy.Symbol,
+		QuoteAmount:   payload.Strategy.QuoteAmount,
+		DryRun:        payload.Flags.DryRun,
+		StartedAt:     time.Now().UTC(),
+	}
+}
+
+// Finish records the end of the run and derives its status: canceled when
+// err is a cancellation before any order went out, failed when it is
+// another error, deferred when a retry was scheduled, skipped when a guard
+// tripped or every account was skipped, executed otherwise
+func (r *ExecutionResult) Finish(err error) {
+	r.FinishedAt = time.Now().UTC()
+	switch {
+	case errors.Is(err, context.Canceled) && !errors.Is(err, exchange.ErrOrderOutcomeUnknown) && !errors.Is(err, exchange.ErrOrderPlaced):
+		r.Status = StatusCanceled
+		r.Error = err.Error()
+	case err != nil:
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	case r.RetryAt != nil:
+		r.Status = StatusDeferred
+	case r.Skip != nil, r.allSkipped():
+		r.Status = StatusSkipped
+	default:
+		r.Status = StatusExecuted
+	}
+}
+
+// allSkipped reports whether the run had accounts and all were skipped
+func (r *ExecutionResult) allSkipped() bool {
+	for _, account := range r.Accounts {
+		if account.Status != StatusSkipped {
+			return false
+		}
+	}
+	return len(r.Accounts) > 0
+}
+
+// Orders returns the order of the run and those of its accounts
+func (r *ExecutionResult) Orders() []*exchange.Order {
+	var orders []*exchange.Order
+	if r.Order != nil {
+		orders = append(orders, r.Order)
+	}
+	for _, account := range r.Accounts {
+		orders = append(orders, account.Orders()...)
+	}
+	return orders
+}
+
+// WithoutRaw returns a copy of the result with the raw exchange responses
+// removed, its accounts' included, marking it as truncated
+func (r *ExecutionResult) WithoutRaw() *ExecutionResult {
+	c := *r
+	if r.Order != nil && len(r.Order.Raw) > 0 {
+		order := *r.Order
+		order.Raw = nil
+		c.Order = &order
+		c.RawTruncated = true
+	}
+	if len(r.Accounts) > 0 {
+		c.Accounts = make([]*ExecutionResult, len(r.Accounts))
+		for i, account := range r.Accounts {
+			c.Accounts[i] = account.WithoutRaw()
+			c.RawTruncated = c.RawTruncated || c.Accounts[i].RawTruncated
+		}
+	}
+	return &c
+}
+
+// Runs lists the runs recorded in history, with a run for several exchange
+// accounts replaced by its account results, as Settled
+func Runs(history []ExecutionResult) []*ExecutionResult {
+	var runs []*ExecutionResult
+	for i := range history {
+		if len(history[i].Accounts) > 0 {
+			runs = append(runs, history[i].Accounts...)
+		} else {
+			runs = append(runs, &history[i])
+		}
+	}
+	return Settled(runs)
+}
+
+// Settled drops from runs those left pending settlement whose order a later
+// run recovered: the recovered record holds the settled fill
+func Settled(runs []*ExecutionResult) []*ExecutionResult {
+	type key struct{ execution, account, symbol string }
+	recovered := map[key]bool{}
+	for _, r := range runs {
+		if r.Recovered {
+			recovered[key{r.ExecutionID, r.Account, r.Symbol}] = true
+		}
+	}
+	return slices.DeleteFunc(runs, func(r *ExecutionResult) bool {
+		return r.PendingSettlement && recovered[key{r.ExecutionID, r.Account, r.Symbol}]
+	})
+}
+
+// MonthCost sums the cost estimates of the invocations for symbol in
+// history that finished in the calendar month of now, in now's location.
+// Every invocation costs, so dry, skipped and failed runs count too; nil
+// when none recorded an estimate.
+func MonthCost(history []ExecutionResult, symbol string, now time.Time) *cost.Summary {
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := from.AddDate(0, 1, 0)
+	var estimates []*cost.Estimate
+	for _, r := range history {
+		if r.Symbol != symbol || r.FinishedAt.Before(from) || !r.FinishedAt.Before(to) {
+			continue
+		}
+		estimates = append(estimates, r.Cost)
+	}
+	return cost.Summarize(from.Format("January 2006"), estimates)
+}
+
+// LastBuy returns the latest live buy of symbol for account in history,
+// oldest first, whose run recorded the market around it; nil when none did
+func LastBuy(history []ExecutionResult, symbol, account string) *report.LastBuy {
+	var last *report.LastBuy
+	for _, r := range Runs(history) {
+		if r.DryRun || r.Status != StatusExecuted || r.Order == nil || r.Order.Side == "sell" || r.Market == nil ||
+			r.Symbol != symbol || r.Account != account {
+			continue
+		}
+		last = &report.LastBuy{At: r.FinishedAt, Price: r.Order.Price, Market: r.Market}
+	}
+	return last
+}
+
+type scopeKey struct{}
+
+type scope struct {
+	result *ExecutionResult
+}
+
+// WithScope returns a context that can hold the result of one invocation
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{})
+}
+
+// Record stores r in the invocation scope so the entrypoint can render it
+// once the handler returns. Without a scope it does nothing.
+func Record(ctx context.Context, r *ExecutionResult) {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.result = r
+	}
+}
+
+// FromContext returns the result recorded in the invocation scope, or nil
+func FromContext(ctx context.Context) *ExecutionResult {
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		return s.result
+	}
+	return nil
+}
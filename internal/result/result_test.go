@@ -0,0 +1,243 @@
+package result
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/budget"
+	"github.com/sudowanderer/dca-bot-go/internal/cost"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+	"github.com/sudowanderer/dca-bot-go/internal/stoploss"
+)
+
+// exponent matches a number written in exponent notation, e.g. 1e-8 or 1.5E+8
+var exponent = regexp.MustCompile(`[0-9][eE][+-]?[0-9]`)
+
+func decimalResult(v decimal.Decimal) *ExecutionResult {
+	order := &exchange.Order{
+		ID:        "1",
+		Quantity:  v,
+		Price:     v,
+		StopPrice: v,
+		FeeAmount: v,
+		Legs:      []exchange.Order{{ID: "2", Quantity: v, Price: v, FeeAmount: v}},
+	}
+	return &ExecutionResult{
+		SchemaVersion: SchemaVersion,
+		ExecutionID:   "exec-1",
+		Status:        StatusExecuted,
+		Order:         order,
+		Sizing:        &sizing.Sizing{FeeRate: v, RequestedAmount: v, OrderAmount: v, DebitedAmount: v, Price: v, OrderQuantity: v, ReceivedAmount: v},
+		Budget:        &budget.Plan{Monthly: v, Spent: v, Amount: v},
+		StopLoss:      &stoploss.Result{Order: &exchange.Order{ID: "3", Quantity: v, Price: v, StopPrice: v}},
+		Cost:          &cost.Estimate{AWS: v, LambdaGBSeconds: v, Fees: []cost.Fee{{Asset: "BNB", Amount: v}}},
+		Market:        &market.Context{Change24h: v, Average7d: v, Average30d: v, DayHigh: v, DayLow: v},
+	}
+}
+
+func TestExecutionResult_DecimalRoundTrip(t *testing.T) {
+	values := []string{
+		"0.00000001",
+		"123456789.123456789",
+		"0.000000000000000001",
+		"1000000000000000000000000",
+		"-42.5",
+		"50000",
+	}
+	for _, s := range values {
+		t.Run(s, func(t *testing.T) {
+			v := decimal.RequireFromString(s)
+			data, err := json.Marshal(decimalResult(v))
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if loc := exponent.FindIndex(data); loc != nil {
+				t.Fatalf("output has exponent notation near %q: %s", data[loc[0]:], data)
+			}
+
+			// Decimals are strings, so consumers never parse them as floats
+			var raw struct {
+				Order struct {
+					Quantity json.RawMessage `json:"quantity"`
+				} `json:"order"`
+			}
+			if err := json.Unmarshal(data, &raw); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := string(raw.Order.Quantity), `"`+s+`"`; got != want {
+				t.Errorf("order.quantity = %s, want %s", got, want)
+			}
+
+			var back ExecutionResult
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			got := []decimal.Decimal{
+				back.Order.Quantity, back.Order.Price, back.Order.StopPrice, back.Order.FeeAmount,
+				back.Order.Legs[0].Quantity, back.Order.Legs[0].Price, back.Order.Legs[0].FeeAmount,
+				back.Sizing.FeeRate, back.Sizing.RequestedAmount, back.Sizing.OrderAmount, back.Sizing.DebitedAmount,
+				back.Sizing.Price, back.Sizing.OrderQuantity, back.Sizing.ReceivedAmount,
+				back.Budget.Monthly, back.Budget.Spent, back.Budget.Amount,
+				back.StopLoss.Order.Quantity, back.StopLoss.Order.Price, back.StopLoss.Order.StopPrice,
+			}
+			for i, d := range got {
+				if !d.Equal(v) {
+					t.Errorf("decimal %d = %s after the round trip, want %s", i, d, s)
+				}
+			}
+		})
+	}
+}
+
+// Exchanges sometimes answer in exponent notation; it must not leak into
+// the result
+func TestExecutionResult_ExponentInput(t *testing.T) {
+	var order exchange.Order
+	if err := json.Unmarshal([]byte(`{"quantity":1e-8,"price":"1.5E+8","feeAmount":"2.5e-7"}`), &order); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	data, err := json.Marshal(&ExecutionResult{Order: &order})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exponent.Match(data) {
+		t.Errorf("output has exponent notation: %s", data)
+	}
+	var back ExecutionResult
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Order.Quantity.String() != "0.00000001" || back.Order.Price.String() != "150000000" || back.Order.FeeAmount.String() != "0.00000025" {
+		t.Errorf("order = %s @ %s fee %s", back.Order.Quantity, back.Order.Price, back.Order.FeeAmount)
+	}
+}
+
+func TestDecimalsMarshalQuoted(t *testing.T) {
+	if decimal.MarshalJSONWithoutQuotes {
+		t.Fatal("decimal.MarshalJSONWithoutQuotes is set; decimals would be written as floats")
+	}
+}
+
+func TestFinish_Accounts(t *testing.T) {
+	r := &ExecutionResult{Accounts: []*ExecutionResult{{Status: StatusSkipped}, {Status: StatusSkipped}}}
+	r.Finish(nil)
+	if r.Status != StatusSkipped {
+		t.Errorf("Status = %s, want skipped when every account was skipped", r.Status)
+	}
+
+	r.Accounts = append(r.Accounts, &ExecutionResult{Status: StatusExecuted})
+	r.Finish(nil)
+	if r.Status != StatusExecuted {
+		t.Errorf("Status = %s, want executed when an account executed", r.Status)
+	}
+}
+
+func TestFinish_Canceled(t *testing.T) {
+	r := &ExecutionResult{}
+	r.Finish(fmt.Errorf("failed to get price: %w", context.Canceled))
+	if r.Status != StatusCanceled || r.Error == "" {
+		t.Errorf("Status = %s, Error = %q, want canceled with the error", r.Status, r.Error)
+	}
+
+	// Once an order may have gone out, the run failed and needs a look
+	r = &ExecutionResult{}
+	r.Finish(fmt.Errorf("failed to place order: %w: %w", exchange.ErrOrderOutcomeUnknown, context.Canceled))
+	if r.Status != StatusFailed {
+		t.Errorf("Status = %s, want failed for an order of unknown outcome", r.Status)
+	}
+}
+
+func TestRuns(t *testing.T) {
+	history := []ExecutionResult{
+		{ExecutionID: "1"},
+		{ExecutionID: "2", Accounts: []*ExecutionResult{{ExecutionID: "2", Account: "a"}, {ExecutionID: "2", Account: "b"}}},
+	}
+	var got []string
+	for _, r := range Runs(history) {
+		got = append(got, r.ExecutionID+r.Account)
+	}
+	if len(got) != 3 || got[0] != "1" || got[1] != "2a" || got[2] != "2b" {
+		t.Errorf("Runs() = %v, want the single run and both accounts", got)
+	}
+}
+
+func TestRuns_PendingSettlement(t *testing.T) {
+	history := []ExecutionResult{
+		{ExecutionID: "1", Symbol: "BTC-USDT", PendingSettlement: true},
+		{ExecutionID: "2", Symbol: "BTC-USDT", PendingSettlement: true},
+		{ExecutionID: "3", Symbol: "BTC-USDT"},
+		{ExecutionID: "1", Symbol: "BTC-USDT", Recovered: true},
+	}
+	var got []string
+	for _, r := range Runs(history) {
+		got = append(got, r.ExecutionID)
+	}
+	if len(got) != 3 || got[0] != "2" || got[1] != "3" || got[2] != "1" || Runs(history)[2].PendingSettlement {
+		t.Errorf("Runs() = %v, want the recovered record in place of the provisional one", got)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	r := &ExecutionResult{
+		Order:    &exchange.Order{ID: "1"},
+		Accounts: []*ExecutionResult{{Order: &exchange.Order{ID: "2"}}, {}, {Order: &exchange.Order{ID: "3"}}},
+	}
+	orders := r.Orders()
+	if len(orders) != 3 || orders[0].ID != "1" || orders[2].ID != "3" {
+		t.Errorf("Orders() = %v, want the run's and its accounts' orders", orders)
+	}
+}
+
+func TestMonthCost(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	estimate := func(aws string) *cost.Estimate {
+		return &cost.Estimate{PriceVersion: "2026-10", AWS: decimal.RequireFromString(aws)}
+	}
+	history := []ExecutionResult{
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 9, 30, 23, 30, 0, 0, time.UTC), Cost: estimate("0.001")}, // 1 October in Berlin
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 9, 30, 22, 59, 0, 0, time.UTC), Cost: estimate("0.1")},   // still September
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC), Cost: estimate("0.002"), DryRun: true},
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), Status: StatusFailed, Cost: estimate("0.003")},
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC), Reconciled: true},
+		{Symbol: "ETH-USDT", FinishedAt: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC), Cost: estimate("0.1")},
+		{Symbol: "BTC-USDT", FinishedAt: time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC), Cost: estimate("0.1")},
+	}
+	s := MonthCost(history, "BTC-USDT", time.Date(2026, 10, 16, 12, 0, 0, 0, berlin))
+	if s == nil || s.Runs != 3 || !s.Total.AWS.Equal(decimal.RequireFromString("0.006")) || s.Period != "October 2026" {
+		t.Errorf("MonthCost() = %+v, want the three October runs", s)
+	}
+
+	if s := MonthCost(history, "BTC-USDT", time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)); s != nil {
+		t.Errorf("MonthCost() of a month without runs = %+v, want nil", s)
+	}
+}
+
+func TestLastBuy(t *testing.T) {
+	buy := func(price string, side string) *exchange.Order {
+		return &exchange.Order{ID: price, Side: side, Quantity: decimal.RequireFromString("0.001"), Price: decimal.RequireFromString(price)}
+	}
+	around := &market.Context{DayHigh: decimal.NewFromInt(62000), DayLow: decimal.NewFromInt(60000)}
+	history := []ExecutionResult{
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("60000", "buy"), Market: around, FinishedAt: time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)},
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("61000", "buy"), Market: around, FinishedAt: time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)},
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("61500", "buy"), FinishedAt: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("62000", "buy"), Market: around, DryRun: true},
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("62500", "sell"), Market: around},
+		{Symbol: "BTC-USDT", Status: StatusExecuted, Order: buy("63000", "buy"), Market: around, Account: "family"},
+		{Symbol: "ETH-USDT", Status: StatusExecuted, Order: buy("2500", "buy"), Market: around},
+	}
+	b := LastBuy(history, "BTC-USDT", "")
+	if b == nil || !b.Price.Equal(decimal.NewFromInt(61000)) || b.Market != around || b.At.Day() != 15 {
+		t.Errorf("LastBuy() = %+v, want the 61000 buy, the latest with market context", b)
+	}
+	if b := LastBuy(history[2:3], "BTC-USDT", ""); b != nil {
+		t.Errorf("LastBuy() without market context = %+v, want nil", b)
+	}
+}
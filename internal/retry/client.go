@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// schedulerSigningName is the SigV4 service name of EventBridge Scheduler
+const schedulerSigningName = "scheduler"
+
+// Client calls the EventBridge Scheduler REST API with SigV4-signed requests
+type Client struct {
+	cfg    aws.Config
+	signer *v4.Signer
+}
+
+// NewClient creates a Scheduler client from an AWS configuration. Requests
+// go to cfg.BaseEndpoint when set, the regional endpoint otherwise.
+func NewClient(cfg aws.Config) *Client {
+	return &Client{cfg: cfg, signer: v4.NewSigner()}
+}
+
+// CreateSchedule creates a schedule
+func (c *Client) CreateSchedule(ctx context.Context, params *CreateScheduleInput) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://scheduler.%s.amazonaws.com", c.cfg.Region)
+	if c.cfg.BaseEndpoint != nil {
+		endpoint = *c.cfg.BaseEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/schedules/"+url.PathEscape(params.Name), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), schedulerSigningName, c.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	httpClient := c.cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package retry hands runs that failed because the exchange was unavailable
+// back to their invocation source in a way it can retry: by returning the
+// error, or by re-scheduling the run as a one-shot EventBridge Scheduler
+// schedule.
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// MaxInputSize is the Scheduler limit for a target's input
+const MaxInputSize = 8192
+
+// Action is how a retriable failure is handed back to the invocation source
+type Action string
+
+const (
+	// ActionReturnError fails the invocation so the source's built-in
+	// retry (async invoke retries, SQS redrive) runs it again
+	ActionReturnError Action = "returnError"
+
+	// ActionSchedule re-schedules the run and ends the invocation cleanly,
+	// so it is not retried twice
+	ActionSchedule Action = "schedule"
+)
+
+// Decide picks the action for a run started by trigger. Only EventBridge
+// rule runs are re-scheduled, and only when a retry scheduler is configured.
+func Decide(trigger run.Trigger, cfg *config.RetrySchedulerConfig) Action {
+	if trigger == run.TriggerEventBridge && cfg != nil {
+		return ActionSchedule
+	}
+	return ActionReturnError
+}
+
+// SchedulerAPI is the subset of the EventBridge Scheduler API used here
+type SchedulerAPI interface {
+	CreateSchedule(ctx context.Context, params *CreateScheduleInput) error
+}
+
+// CreateScheduleInput is the CreateSchedule request for a one-shot schedule
+type CreateScheduleInput struct {
+	Name                       string             `json:"-"` // part of the request path
+	GroupName                  string             `json:"GroupName"`
+	ScheduleExpression         string             `json:"ScheduleExpression"` // "at(2006-01-02T15:04:05)"
+	ScheduleExpressionTimezone string             `json:"ScheduleExpressionTimezone"`
+	FlexibleTimeWindow         FlexibleTimeWindow `json:"FlexibleTimeWindow"`
+	ActionAfterCompletion      string             `json:"ActionAfterCompletion"`
+	ClientToken                string             `json:"ClientToken,omitempty"`
+	Target                     Target             `json:"Target"`
+}
+
+// FlexibleTimeWindow is the window a schedule may fire in
+type FlexibleTimeWindow struct {
+	Mode string `json:"Mode"` // "OFF" fires at the exact time
+}
+
+// Target is what the schedule invokes
+type Target struct {
+	Arn     string `json:"Arn"`
+	RoleArn string `json:"RoleArn"`
+	Input   string `json:"Input"`
+}
+
+// Scheduler creates one-shot retry schedules
+type Scheduler struct {
+	client SchedulerAPI
+	cfg    config.RetrySchedulerConfig
+}
+
+// NewScheduler creates a Scheduler for a retryScheduler config
+func NewScheduler(client SchedulerAPI, cfg config.RetrySchedulerConfig) *Scheduler {
+	return &Scheduler{client: client, cfg: cfg}
+}
+
+// Schedule re-runs event against the target after the configured delay and
+// returns when it will run. The schedule is named after the execution so a
+// repeated call for the same run does not schedule twice, and it deletes
+// itself once it has fired. defaultTarget is used when the config names no
+// target, typically the ARN of the running function.
+func (s *Scheduler) Schedule(ctx context.Context, event json.RawMessage, defaultTarget string, now time.Time) (time.Time, error) {
+	target := s.cfg.TargetARN
+	if target == "" {
+		target = defaultTarget
+	}
+	if target == "" {
+		return time.Time{}, fmt.Errorf("no target ARN configured and the function ARN is unknown")
+	}
+	if len(event) > MaxInputSize {
+		return time.Time{}, fmt.Errorf("event is %d bytes, exceeds the %d byte Scheduler input limit", len(event), MaxInputSize)
+	}
+
+	executionID := run.ID(ctx)
+	// "at" expressions have minute precision at best; round up so the
+	// retry never fires earlier than the configured delay
+	at := now.UTC().Add(s.cfg.Delay()).Truncate(time.Minute).Add(time.Minute)
+	err := s.client.CreateSchedule(ctx, &CreateScheduleInput{
+		Name:                       "dca-retry-" + executionID,
+		GroupName:                  s.cfg.GroupName,
+		ScheduleExpression:         "at(" + at.Format("2006-01-02T15:04:05") + ")",
+		ScheduleExpressionTimezone: "UTC",
+		FlexibleTimeWindow:         FlexibleTimeWindow{Mode: "OFF"},
+		ActionAfterCompletion:      "DELETE",
+		ClientToken:                executionID,
+		Target: Target{
+			Arn:     target,
+			RoleArn: s.cfg.RoleARN,
+			Input:   string(event),
+		},
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("scheduler CreateSchedule failed: %w", err)
+	}
+	return at, nil
+}
@@ -0,0 +1,156 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+type stubScheduler struct {
+	inputs []*CreateScheduleInput
+	err    error
+}
+
+func (s *stubScheduler) CreateSchedule(ctx context.Context, params *CreateScheduleInput) error {
+	s.inputs = append(s.inputs, params)
+	return s.err
+}
+
+var testConfig = config.RetrySchedulerConfig{
+	RoleARN:      "arn:aws:iam::123456789012:role/dca-scheduler",
+	GroupName:    "default",
+	DelayMinutes: 10,
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		trigger  run.Trigger
+		cfg      *config.RetrySchedulerConfig
+		expected Action
+	}{
+		{"eventbridge_with_scheduler", run.TriggerEventBridge, &testConfig, ActionSchedule},
+		{"eventbridge_without_scheduler", run.TriggerEventBridge, nil, ActionReturnError},
+		{"direct_async_invoke", run.TriggerDirect, &testConfig, ActionReturnError},
+		{"sqs", run.TriggerSQS, &testConfig, ActionReturnError},
+		{"webhook", run.TriggerWebhook, &testConfig, ActionReturnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decide(tt.trigger, tt.cfg); got != tt.expected {
+				t.Errorf("Decide(%s) = %s, want %s", tt.trigger, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScheduler_Schedule(t *testing.T) {
+	stub := &stubScheduler{}
+	ctx := run.WithID(context.Background(), "01ARYZ6S410000000000000000")
+	now := time.Date(2026, 10, 16, 9, 29, 30, 0, time.UTC)
+	event := json.RawMessage(`{"version":"v2"}`)
+
+	at, err := NewScheduler(stub, testConfig).Schedule(ctx, event, "arn:aws:lambda:eu-west-1:123456789012:function:dca", now)
+	if err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	if want := time.Date(2026, 10, 16, 9, 40, 0, 0, time.UTC); !at.Equal(want) {
+		t.Errorf("at = %s, want %s", at, want)
+	}
+
+	if len(stub.inputs) != 1 {
+		t.Fatalf("expected one CreateSchedule call, got %d", len(stub.inputs))
+	}
+	in := stub.inputs[0]
+	if in.Name != "dca-retry-01ARYZ6S410000000000000000" || in.ClientToken != "01ARYZ6S410000000000000000" {
+		t.Errorf("Name = %s, ClientToken = %s", in.Name, in.ClientToken)
+	}
+	if in.ScheduleExpression != "at(2026-10-16T09:40:00)" || in.ScheduleExpressionTimezone != "UTC" {
+		t.Errorf("expression = %s %s", in.ScheduleExpression, in.ScheduleExpressionTimezone)
+	}
+	if in.ActionAfterCompletion != "DELETE" || in.FlexibleTimeWindow.Mode != "OFF" {
+		t.Errorf("one-shot settings = %s, %s", in.ActionAfterCompletion, in.FlexibleTimeWindow.Mode)
+	}
+	if in.Target.Arn != "arn:aws:lambda:eu-west-1:123456789012:function:dca" || in.Target.RoleArn != testConfig.RoleARN || in.Target.Input != string(event) {
+		t.Errorf("Target = %+v", in.Target)
+	}
+}
+
+func TestScheduler_ScheduleErrors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := NewScheduler(&stubScheduler{}, testConfig).Schedule(ctx, json.RawMessage(`{}`), "", now); err == nil {
+		t.Error("expected error without a target ARN")
+	}
+
+	cfg := testConfig
+	cfg.TargetARN = "arn:aws:lambda:eu-west-1:123456789012:function:other"
+	stub := &stubScheduler{}
+	if _, err := NewScheduler(stub, cfg).Schedule(ctx, json.RawMessage(`{}`), "", now); err != nil || stub.inputs[0].Target.Arn != cfg.TargetARN {
+		t.Errorf("configured target not used: %v", err)
+	}
+
+	big := json.RawMessage(`"` + strings.Repeat("x", MaxInputSize) + `"`)
+	if _, err := NewScheduler(&stubScheduler{}, cfg).Schedule(ctx, big, "", now); err == nil {
+		t.Error("expected error for oversized input")
+	}
+
+	failing := &stubScheduler{err: errors.New("AccessDenied")}
+	if _, err := NewScheduler(failing, cfg).Schedule(ctx, json.RawMessage(`{}`), "", now); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Schedule() error = %v, want AccessDenied", err)
+	}
+}
+
+func TestClient_CreateSchedule(t *testing.T) {
+	var gotPath, gotAuth string
+	var got CreateScheduleInput
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		if got.GroupName == "conflict" {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"Message":"Schedule already exists."}`))
+			return
+		}
+		w.Write([]byte(`{"ScheduleArn":"arn"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(aws.Config{
+		Region:       "eu-west-1",
+		BaseEndpoint: aws.String(server.URL),
+		Credentials: aws.NewCredentialsCache(aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		})),
+	})
+
+	input := &CreateScheduleInput{Name: "dca-retry-1", GroupName: "default", Target: Target{Arn: "arn:fn"}}
+	if err := client.CreateSchedule(context.Background(), input); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+	if gotPath != "/schedules/dca-retry-1" || got.Target.Arn != "arn:fn" {
+		t.Errorf("path = %s, body = %+v", gotPath, got)
+	}
+	if !strings.Contains(gotAuth, "Credential=AKID/") || !strings.Contains(gotAuth, "/eu-west-1/scheduler/aws4_request") {
+		t.Errorf("Authorization = %s, want a SigV4 scheduler signature", gotAuth)
+	}
+
+	input.GroupName = "conflict"
+	if err := client.CreateSchedule(context.Background(), input); err == nil || !strings.Contains(err.Error(), "HTTP 409") {
+		t.Errorf("CreateSchedule() error = %v, want HTTP 409", err)
+	}
+}
@@ -0,0 +1,157 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// Exchange places buys along a route; every other call goes to the wrapped
+// exchange, so balances are still read in the strategy's quote asset
+type Exchange struct {
+	exchange.Exchange
+	Route *Route
+}
+
+// NewExchange wraps exc so market buys follow route
+func NewExchange(exc exchange.Exchange, route *Route) *Exchange {
+	return &Exchange{Exchange: exc, Route: route}
+}
+
+// PlaceMarketBuyOrder spends size.Quote of the route's first asset and
+// executes the legs in order, sizing each from the previous leg's actual
+// fill. The returned order combines the legs; see exchange.Order.Legs.
+// Routed buys cannot be sized in the base asset. Under an idempotency key
+// each leg is placed once (exchange.PlaceOnce) with its own client order
+// ID, so a redelivery reuses the legs already placed.
+func (e *Exchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, size exchange.OrderSize) (*exchange.Order, error) {
+	if !size.IsQuote() {
+		return nil, fmt.Errorf("routed buys must be sized in the quote asset, got %s", size)
+	}
+	var legs []exchange.Order
+	amount := size.Quote
+	for i, leg := range e.Route.Legs {
+		order, err := e.placeLeg(legContext(ctx, i), leg, amount)
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("leg %s: %w", leg.Symbol, err)
+			}
+			// Earlier legs filled; the bridge asset is left in the account
+			return nil, fmt.Errorf("%w: leg %s failed after receiving %s %s: %w",
+				exchange.ErrOrderPlaced, leg.Symbol, amount.String(), leg.From, err)
+		}
+		legs = append(legs, *order)
+
+		amount = Received(order, leg).Truncate(sizing.QuotePlaces(leg.To))
+		log.Printf("🔗 Leg %d/%d %s %s: received %s %s", i+1, len(e.Route.Legs), leg.Side, leg.Symbol, amount.String(), leg.To)
+		if i < len(e.Route.Legs)-1 && !amount.IsPositive() {
+			return nil, fmt.Errorf("%w: leg %s received nothing to continue with", exchange.ErrOrderPlaced, leg.Symbol)
+		}
+	}
+	return Combine(symbol, e.Route, legs)
+}
+
+// placeLeg spends amount of leg.From on one leg
+func (e *Exchange) placeLeg(ctx context.Context, leg Leg, amount decimal.Decimal) (*exchange.Order, error) {
+	return exchange.PlaceOnce(ctx, e.Exchange, leg.Symbol, func(ctx context.Context) (*exchange.Order, error) {
+		if leg.Side == SideBuy {
+			return exchange.MarketBuy(ctx, e.Exchange, leg.Symbol, exchange.QuoteSize(amount))
+		}
+		seller, ok := e.Exchange.(exchange.MarketSeller)
+		if !ok {
+			return nil, fmt.Errorf("exchange does not support market sells")
+		}
+		return seller.PlaceMarketSellOrder(ctx, leg.Symbol, amount)
+	})
+}
+
+// legContext returns ctx for placing leg i of a route. A run with an
+// idempotency key gives each leg a key of its own, so the legs are not
+// placed under the same client order ID.
+func legContext(ctx context.Context, i int) context.Context {
+	key := run.IdempotencyKey(ctx)
+	if key == "" {
+		return ctx
+	}
+	return run.WithIdempotencyKey(ctx, fmt.Sprintf("%s|leg%d", key, i+1))
+}
+
+// GetOrderByClientID returns the routed buy of the run in ctx when the
+// wrapped exchange finds every leg placed under the run's idempotency key,
+// combined as PlaceMarketBuyOrder returns it. It is nil while a leg is
+// missing, so placing the buy again reuses the legs placed and places the
+// rest. clientOrderID names no leg and is ignored.
+func (e *Exchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*exchange.Order, error) {
+	getter, ok := e.Exchange.(exchange.ClientOrderGetter)
+	if !ok || run.IdempotencyKey(ctx) == "" {
+		return nil, nil
+	}
+	var legs []exchange.Order
+	for i, leg := range e.Route.Legs {
+		legCtx := legContext(ctx, i)
+		order, err := getter.GetOrderByClientID(legCtx, leg.Symbol, run.ClientOrderID(legCtx, "dca"))
+		if err != nil {
+			return nil, fmt.Errorf("leg %s: %w", leg.Symbol, err)
+		}
+		if order == nil {
+			return nil, nil
+		}
+		legs = append(legs, *order)
+	}
+	return Combine(symbol, e.Route, legs)
+}
+
+// TakerFeeRate compounds the taker fee rates of every leg
+func (e *Exchange) TakerFeeRate(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	rater, ok := e.Exchange.(exchange.FeeRater)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("exchange does not report fee rates")
+	}
+	one := decimal.NewFromInt(1)
+	kept := one
+	for _, leg := range e.Route.Legs {
+		rate, err := rater.TakerFeeRate(ctx, leg.Symbol)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("leg %s: %w", leg.Symbol, err)
+		}
+		kept = kept.Mul(one.Sub(rate))
+	}
+	return one.Sub(kept), nil
+}
+
+// Combine summarizes the filled legs of route as a single order for symbol:
+// Quantity is the base asset received net of fees and Price what each unit
+// effectively cost in the quote asset, fees and slippage of every leg included
+func Combine(symbol string, route *Route, legs []exchange.Order) (*exchange.Order, error) {
+	if len(legs) != len(route.Legs) || len(legs) == 0 {
+		return nil, fmt.Errorf("route has %d legs, got %d orders", len(route.Legs), len(legs))
+	}
+	first, last := &legs[0], &legs[len(legs)-1]
+	spent := Spent(first, route.Legs[0])
+	received := Received(last, route.Legs[len(legs)-1])
+	if !received.IsPositive() {
+		return nil, fmt.Errorf("route received no %s", route.Legs[len(legs)-1].To)
+	}
+
+	ids := make([]string, len(legs))
+	for i, leg := range legs {
+		ids[i] = leg.ID
+	}
+	return &exchange.Order{
+		ID:            strings.Join(ids, "+"),
+		ClientOrderID: last.ClientOrderID,
+		Symbol:        symbol,
+		Side:          SideBuy,
+		Type:          "market",
+		Quantity:      received,
+		Price:         spent.Div(received),
+		Status:        last.Status,
+		Legs:          legs,
+	}, nil
+}
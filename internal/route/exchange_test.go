@@ -0,0 +1,184 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// feeExchange fills at fixed prices and charges feeRate in the received
+// asset. With orders set it keeps the orders by client order ID.
+type feeExchange struct {
+	exchange.MockExchange
+	prices  map[string]decimal.Decimal
+	feeRate decimal.Decimal
+	failOn  string
+	calls   []string
+	orders  map[string]exchange.Order
+}
+
+func (f *feeExchange) PlaceMarketBuyOrder(ctx context.Context, symbol string, size exchange.OrderSize) (*exchange.Order, error) {
+	quoteAmount := size.Quote
+	f.calls = append(f.calls, "buy "+symbol+" "+quoteAmount.String())
+	if symbol == f.failOn {
+		return nil, &exchange.HTTPError{StatusCode: 503}
+	}
+	base, _, _ := exchange.SplitSymbol(symbol)
+	qty := quoteAmount.Div(f.prices[symbol])
+	return f.keep(ctx, &exchange.Order{ID: symbol, Symbol: symbol, Side: "buy", Quantity: qty, Price: f.prices[symbol],
+		FeeAmount: qty.Mul(f.feeRate), FeeAsset: base, Status: "filled"}), nil
+}
+
+func (f *feeExchange) PlaceMarketSellOrder(ctx context.Context, symbol string, quantity decimal.Decimal) (*exchange.Order, error) {
+	f.calls = append(f.calls, "sell "+symbol+" "+quantity.String())
+	_, quote, _ := exchange.SplitSymbol(symbol)
+	proceeds := quantity.Mul(f.prices[symbol])
+	return f.keep(ctx, &exchange.Order{ID: symbol, Symbol: symbol, Side: "sell", Quantity: quantity, Price: f.prices[symbol],
+		FeeAmount: proceeds.Mul(f.feeRate), FeeAsset: quote, Status: "filled"}), nil
+}
+
+// keep stamps order with the client order ID of ctx and keeps it
+func (f *feeExchange) keep(ctx context.Context, order *exchange.Order) *exchange.Order {
+	order.ClientOrderID = run.ClientOrderID(ctx, "dca")
+	if f.orders != nil {
+		f.orders[order.ClientOrderID] = *order
+	}
+	return order
+}
+
+func (f *feeExchange) GetOrderByClientID(ctx context.Context, symbol, clientOrderID string) (*exchange.Order, error) {
+	order, ok := f.orders[clientOrderID]
+	if !ok || order.Symbol != symbol {
+		return nil, nil
+	}
+	return &order, nil
+}
+
+func (f *feeExchange) TakerFeeRate(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	return f.feeRate, nil
+}
+
+var usdcViaUSDT = &Route{Legs: []Leg{
+	{Symbol: "USDC-USDT", Side: SideSell, From: "USDC", To: "USDT"},
+	{Symbol: "BTC-USDT", Side: SideBuy, From: "USDT", To: "BTC"},
+}}
+
+func TestExchange_PlaceMarketBuyOrder(t *testing.T) {
+	inner := &feeExchange{
+		prices:  map[string]decimal.Decimal{"USDC-USDT": d("0.9995"), "BTC-USDT": d("50000")},
+		feeRate: d("0.001"),
+	}
+
+	order, err := NewExchange(inner, usdcViaUSDT).PlaceMarketBuyOrder(context.Background(), "BTC-USDC", exchange.QuoteSize(d("100")))
+	if err != nil {
+		t.Fatalf("PlaceMarketBuyOrder() error = %v", err)
+	}
+
+	// 100 USDC sells for 99.95 USDT, less 0.09995 fee = 99.85005, truncated to 99.85;
+	// the second leg is sized from that actual fill, not from the 100 requested
+	if got := strings.Join(inner.calls, "; "); got != "sell USDC-USDT 100; buy BTC-USDT 99.85" {
+		t.Errorf("calls = %s", got)
+	}
+	if len(order.Legs) != 2 || order.ID != "USDC-USDT+BTC-USDT" || order.Symbol != "BTC-USDC" {
+		t.Fatalf("order = %+v", order)
+	}
+
+	// 99.85 / 50000 = 0.001997 BTC, less 0.1% fee = 0.001995003
+	if !order.Quantity.Equal(d("0.001995003")) {
+		t.Errorf("Quantity = %s, want 0.001995003", order.Quantity)
+	}
+	// Effective price: 100 USDC for 0.001995003 BTC, both fees and the USDC discount included
+	if want := d("100").Div(d("0.001995003")); !order.Price.Equal(want) {
+		t.Errorf("Price = %s, want %s", order.Price, want)
+	}
+	if !order.Price.GreaterThan(d("50000")) {
+		t.Errorf("effective price %s should exceed the second leg's 50000", order.Price)
+	}
+	if !order.FeeAmount.IsZero() {
+		t.Errorf("FeeAmount = %s, want fees folded into the price", order.FeeAmount)
+	}
+
+	rate, err := NewExchange(inner, usdcViaUSDT).TakerFeeRate(context.Background(), "BTC-USDC")
+	if err != nil || !rate.Equal(d("0.001999")) {
+		t.Errorf("TakerFeeRate() = %s, %v; want 0.001999", rate, err)
+	}
+}
+
+func TestExchange_LegFailures(t *testing.T) {
+	prices := map[string]decimal.Decimal{"USDC-USDT": d("1"), "BTC-USDT": d("50000")}
+
+	// A failing first leg has bought nothing, so it stays retriable
+	first := &feeExchange{prices: prices, failOn: "BTC-USDT"}
+	onlyBuy := &Route{Legs: []Leg{{Symbol: "BTC-USDT", Side: SideBuy, From: "USDT", To: "BTC"}}}
+	_, err := NewExchange(first, onlyBuy).PlaceMarketBuyOrder(context.Background(), "BTC-USDT", exchange.QuoteSize(d("100")))
+	if err == nil || !exchange.IsRetriable(err) {
+		t.Errorf("first leg error = %v, want retriable", err)
+	}
+
+	// A failing second leg strands the bridge asset and must not be retried
+	second := &feeExchange{prices: prices, failOn: "BTC-USDT"}
+	_, err = NewExchange(second, usdcViaUSDT).PlaceMarketBuyOrder(context.Background(), "BTC-USDC", exchange.QuoteSize(d("100")))
+	if !errors.Is(err, exchange.ErrOrderPlaced) || exchange.IsRetriable(err) {
+		t.Errorf("second leg error = %v, want ErrOrderPlaced and not retriable", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "after receiving 100 USDT") {
+		t.Errorf("error %q should say how much of the bridge asset is left", err)
+	}
+}
+
+func TestExchange_Redelivery(t *testing.T) {
+	prices := map[string]decimal.Decimal{"USDC-USDT": d("1"), "BTC-USDT": d("50000")}
+	inner := &feeExchange{prices: prices, orders: map[string]exchange.Order{}}
+	key := "2026-10-16T08:00:00Z|BTC-USDC|buy"
+	// Each delivery runs with a new execution ID and a new route exchange
+	buy := func() (*exchange.Order, error) {
+		ctx := run.WithIdempotencyKey(run.WithID(context.Background(), run.NewID()), key)
+		exc := NewExchange(inner, usdcViaUSDT)
+		return exchange.PlaceOnce(ctx, exc, "BTC-USDC", func(ctx context.Context) (*exchange.Order, error) {
+			return exc.PlaceMarketBuyOrder(ctx, "BTC-USDC", exchange.QuoteSize(d("100")))
+		})
+	}
+
+	first, err := buy()
+	if err != nil {
+		t.Fatalf("first delivery error = %v", err)
+	}
+	if a, b := first.Legs[0].ClientOrderID, first.Legs[1].ClientOrderID; a == b {
+		t.Errorf("both legs placed as %s, want a client order ID per leg", a)
+	}
+	inner.calls = nil
+	again, err := buy()
+	if err != nil || len(inner.calls) != 0 {
+		t.Fatalf("redelivery = %v after %v, want no order placed", err, inner.calls)
+	}
+	if again.ID != first.ID || !again.Quantity.Equal(first.Quantity) {
+		t.Errorf("redelivery = %+v, want the first delivery's %+v", again, first)
+	}
+
+	// A delivery that failed after the first leg only places the second
+	inner.orders, inner.failOn, key = map[string]exchange.Order{}, "BTC-USDT", "2026-10-17T08:00:00Z|BTC-USDC|buy"
+	if _, err := buy(); !errors.Is(err, exchange.ErrOrderPlaced) {
+		t.Fatalf("failed delivery error = %v, want ErrOrderPlaced", err)
+	}
+	inner.calls, inner.failOn = nil, ""
+	if _, err := buy(); err != nil {
+		t.Fatalf("redelivery error = %v", err)
+	}
+	if got := strings.Join(inner.calls, "; "); got != "buy BTC-USDT 100" {
+		t.Errorf("redelivery calls = %s, want only the second leg", got)
+	}
+}
+
+func TestExchange_SellUnsupported(t *testing.T) {
+	// MockExchange sells; wrapping it in a type without the method hides that
+	inner := struct{ exchange.Exchange }{&exchange.MockExchange{}}
+	_, err := NewExchange(inner, usdcViaUSDT).PlaceMarketBuyOrder(context.Background(), "BTC-USDC", exchange.QuoteSize(d("100")))
+	if err == nil || !strings.Contains(err.Error(), "market sells") {
+		t.Errorf("error = %v, want market sells unsupported", err)
+	}
+}
@@ -0,0 +1,154 @@
+// Package route buys a symbol the exchange does not list by going through a
+// bridge asset in two sequential legs, e.g. USDC → USDT → BTC for BTC-USDC.
+package route
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// Order sides of a leg
+const (
+	SideBuy  = "buy"  // spends the pair's quote asset
+	SideSell = "sell" // spends the pair's base asset
+)
+
+// Leg is one order of a route
+type Leg struct {
+	Symbol string `json:"symbol"` // pair traded, e.g. "USDC-USDT"
+	Side   string `json:"side"`
+	From   string `json:"from"` // asset spent
+	To     string `json:"to"`   // asset received
+}
+
+// Route is a sequence of legs from the strategy's quote to its base asset
+type Route struct {
+	Legs []Leg `json:"legs"`
+}
+
+// String renders the assets the route passes through, e.g. "USDC → USDT → BTC"
+func (r *Route) String() string {
+	if len(r.Legs) == 0 {
+		return ""
+	}
+	assets := []string{r.Legs[0].From}
+	for _, leg := range r.Legs {
+		assets = append(assets, leg.To)
+	}
+	return strings.Join(assets, " → ")
+}
+
+// Lister reports whether a symbol is listed on the exchange
+type Lister func(ctx context.Context, symbol string) (bool, error)
+
+// NoRouteError is returned by Find when no bridge connects the two assets
+type NoRouteError struct {
+	From, To string
+	Checked  []string // pairs looked up, in order
+}
+
+func (e *NoRouteError) Error() string {
+	return fmt.Sprintf("no route from %s to %s; checked %s", e.From, e.To, strings.Join(e.Checked, ", "))
+}
+
+// Find returns the first two-leg route that spends quote and receives base,
+// trying bridges in order. The first leg buys the bridge with quote (pair
+// BRIDGE-QUOTE) or, when canSell is set, sells quote for it (pair
+// QUOTE-BRIDGE); the second leg buys base with the bridge (pair BASE-BRIDGE).
+func Find(ctx context.Context, base, quote string, bridges []string, canSell bool, listed Lister) (*Route, error) {
+	var checked []string
+	lookup := func(symbol string) (bool, error) {
+		checked = append(checked, symbol)
+		return listed(ctx, symbol)
+	}
+
+	for _, bridge := range bridges {
+		if bridge == base || bridge == quote {
+			continue
+		}
+
+		first, err := firstLeg(quote, bridge, canSell, lookup)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			continue
+		}
+
+		second := Leg{Symbol: base + "-" + bridge, Side: SideBuy, From: bridge, To: base}
+		ok, err := lookup(second.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &Route{Legs: []Leg{*first, second}}, nil
+		}
+	}
+	return nil, &NoRouteError{From: quote, To: base, Checked: checked}
+}
+
+// firstLeg finds the pair that turns quote into bridge, or nil when neither
+// direction is listed
+func firstLeg(quote, bridge string, canSell bool, lookup func(string) (bool, error)) (*Leg, error) {
+	buy := Leg{Symbol: bridge + "-" + quote, Side: SideBuy, From: quote, To: bridge}
+	ok, err := lookup(buy.Symbol)
+	if err != nil || ok {
+		return &buy, err
+	}
+	if !canSell {
+		return nil, nil
+	}
+
+	sell := Leg{Symbol: quote + "-" + bridge, Side: SideSell, From: quote, To: bridge}
+	ok, err = lookup(sell.Symbol)
+	if err != nil || ok {
+		return &sell, err
+	}
+	return nil, nil
+}
+
+// Spent is the amount of leg.From a fill debited, including any fee charged in it
+func Spent(order *exchange.Order, leg Leg) decimal.Decimal {
+	feeInFrom := sameAsset(order.FeeAsset, leg.From)
+	if leg.Side == SideSell {
+		if feeInFrom {
+			return order.Quantity.Add(order.FeeAmount)
+		}
+		return order.Quantity
+	}
+	return sizing.DebitedAmount(order.Quantity, order.Price, order.FeeAmount, feeInFrom)
+}
+
+// Received is the amount of leg.To a fill credited, net of any fee charged in it
+func Received(order *exchange.Order, leg Leg) decimal.Decimal {
+	received := order.Quantity
+	if leg.Side == SideSell {
+		received = order.Quantity.Mul(order.Price)
+	}
+	if sameAsset(order.FeeAsset, leg.To) {
+		received = received.Sub(order.FeeAmount)
+	}
+	return received
+}
+
+// CompoundRate is the fee rate of legs consecutive orders each charged rate:
+// 1 - (1 - rate)^legs
+func CompoundRate(rate decimal.Decimal, legs int) decimal.Decimal {
+	one := decimal.NewFromInt(1)
+	kept := one
+	for i := 0; i < legs; i++ {
+		kept = kept.Mul(one.Sub(rate))
+	}
+	return one.Sub(kept)
+}
+
+// sameAsset compares asset codes after canonicalization
+func sameAsset(a, b string) bool {
+	return a != "" && asset.Canonical("", a) == asset.Canonical("", b)
+}
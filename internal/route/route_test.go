@@ -0,0 +1,168 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// universe is a mocked set of listed symbols
+func universe(symbols ...string) Lister {
+	listed := make(map[string]bool, len(symbols))
+	for _, s := range symbols {
+		listed[s] = true
+	}
+	return func(ctx context.Context, symbol string) (bool, error) {
+		return listed[symbol], nil
+	}
+}
+
+func d(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestFind(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      string
+		quote     string
+		bridges   []string
+		canSell   bool
+		listed    []string
+		wantRoute string
+		wantLegs  string
+	}{
+		{
+			name: "buy_then_buy", base: "ETH", quote: "USDC", bridges: []string{"BTC"},
+			listed:    []string{"BTC-USDC", "ETH-BTC"},
+			wantRoute: "USDC → BTC → ETH", wantLegs: "buy BTC-USDC, buy ETH-BTC",
+		},
+		{
+			name: "sell_then_buy", base: "PEPE", quote: "USDC", bridges: []string{"USDT"}, canSell: true,
+			listed:    []string{"USDC-USDT", "PEPE-USDT"},
+			wantRoute: "USDC → USDT → PEPE", wantLegs: "sell USDC-USDT, buy PEPE-USDT",
+		},
+		{
+			name: "prefers_buy_direction", base: "PEPE", quote: "USDC", bridges: []string{"USDT"}, canSell: true,
+			listed:    []string{"USDT-USDC", "USDC-USDT", "PEPE-USDT"},
+			wantRoute: "USDC → USDT → PEPE", wantLegs: "buy USDT-USDC, buy PEPE-USDT",
+		},
+		{
+			name: "bridges_in_order", base: "ETH", quote: "USDC", bridges: []string{"USDT", "BTC"}, canSell: true,
+			listed:    []string{"USDC-USDT", "ETH-USDT", "BTC-USDC", "ETH-BTC"},
+			wantRoute: "USDC → USDT → ETH", wantLegs: "sell USDC-USDT, buy ETH-USDT",
+		},
+		{
+			name: "falls_through_to_second_bridge", base: "PEPE", quote: "USDC", bridges: []string{"USDT", "BTC"}, canSell: true,
+			listed:    []string{"USDC-USDT", "BTC-USDC", "PEPE-BTC"},
+			wantRoute: "USDC → BTC → PEPE", wantLegs: "buy BTC-USDC, buy PEPE-BTC",
+		},
+		{
+			name: "skips_bridge_equal_to_asset", base: "BTC", quote: "USDC", bridges: []string{"BTC", "USDT"}, canSell: true,
+			listed:    []string{"USDC-USDT", "BTC-USDT"},
+			wantRoute: "USDC → USDT → BTC", wantLegs: "sell USDC-USDT, buy BTC-USDT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Find(context.Background(), tt.base, tt.quote, tt.bridges, tt.canSell, universe(tt.listed...))
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if r.String() != tt.wantRoute {
+				t.Errorf("route = %s, want %s", r, tt.wantRoute)
+			}
+			var legs []string
+			for _, leg := range r.Legs {
+				legs = append(legs, leg.Side+" "+leg.Symbol)
+			}
+			if got := strings.Join(legs, ", "); got != tt.wantLegs {
+				t.Errorf("legs = %s, want %s", got, tt.wantLegs)
+			}
+		})
+	}
+}
+
+func TestFind_NoRoute(t *testing.T) {
+	tests := []struct {
+		name        string
+		canSell     bool
+		listed      []string
+		wantChecked string
+	}{
+		{"nothing_listed", true, nil, "USDT-USDC, USDC-USDT, BTC-USDC, USDC-BTC"},
+		{"sell_unsupported", false, []string{"USDC-USDT", "PEPE-USDT"}, "USDT-USDC, BTC-USDC"},
+		{"second_leg_missing", true, []string{"USDC-USDT", "BTC-USDC"}, "USDT-USDC, USDC-USDT, PEPE-USDT, BTC-USDC, PEPE-BTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Find(context.Background(), "PEPE", "USDC", []string{"USDT", "BTC"}, tt.canSell, universe(tt.listed...))
+			var noRoute *NoRouteError
+			if !errors.As(err, &noRoute) {
+				t.Fatalf("Find() error = %v, want *NoRouteError", err)
+			}
+			if got := strings.Join(noRoute.Checked, ", "); got != tt.wantChecked {
+				t.Errorf("Checked = %s, want %s", got, tt.wantChecked)
+			}
+			if !strings.Contains(err.Error(), "no route from USDC to PEPE; checked "+tt.wantChecked) {
+				t.Errorf("Error() = %s", err)
+			}
+		})
+	}
+}
+
+func TestFind_LookupError(t *testing.T) {
+	boom := errors.New("exchange down")
+	_, err := Find(context.Background(), "PEPE", "USDC", []string{"USDT"}, true, func(ctx context.Context, symbol string) (bool, error) {
+		return false, boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("Find() error = %v, want %v", err, boom)
+	}
+}
+
+func TestSpentAndReceived(t *testing.T) {
+	buy := Leg{Symbol: "BTC-USDC", Side: SideBuy, From: "USDC", To: "BTC"}
+	sell := Leg{Symbol: "USDC-USDT", Side: SideSell, From: "USDC", To: "USDT"}
+
+	tests := []struct {
+		name         string
+		order        exchange.Order
+		leg          Leg
+		wantSpent    string
+		wantReceived string
+	}{
+		{"buy_fee_in_received", exchange.Order{Quantity: d("0.002"), Price: d("50000"), FeeAmount: d("0.000002"), FeeAsset: "BTC"}, buy, "100", "0.001998"},
+		{"buy_fee_in_spent", exchange.Order{Quantity: d("0.002"), Price: d("50000"), FeeAmount: d("0.1"), FeeAsset: "USDC"}, buy, "100.1", "0.002"},
+		{"buy_fee_in_third_asset", exchange.Order{Quantity: d("0.002"), Price: d("50000"), FeeAmount: d("0.0002"), FeeAsset: "BNB"}, buy, "100", "0.002"},
+		{"sell_fee_in_received", exchange.Order{Quantity: d("100"), Price: d("0.9995"), FeeAmount: d("0.09995"), FeeAsset: "USDT"}, sell, "100", "99.85005"},
+		{"sell_fee_in_spent", exchange.Order{Quantity: d("100"), Price: d("0.9995"), FeeAmount: d("0.1"), FeeAsset: "USDC"}, sell, "100.1", "99.95"},
+		{"alias_fee_asset", exchange.Order{Quantity: d("0.002"), Price: d("50000"), FeeAmount: d("0.000002"), FeeAsset: "XBT"}, buy, "100", "0.001998"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Spent(&tt.order, tt.leg); !got.Equal(d(tt.wantSpent)) {
+				t.Errorf("Spent() = %s, want %s", got, tt.wantSpent)
+			}
+			if got := Received(&tt.order, tt.leg); !got.Equal(d(tt.wantReceived)) {
+				t.Errorf("Received() = %s, want %s", got, tt.wantReceived)
+			}
+		})
+	}
+}
+
+func TestCompoundRate(t *testing.T) {
+	if got := CompoundRate(d("0.001"), 2); !got.Equal(d("0.001999")) {
+		t.Errorf("CompoundRate(0.001, 2) = %s, want 0.001999", got)
+	}
+	if got := CompoundRate(d("0.001"), 1); !got.Equal(d("0.001")) {
+		t.Errorf("CompoundRate(0.001, 1) = %s, want 0.001", got)
+	}
+}
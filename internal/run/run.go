@@ -0,0 +1,163 @@
+// Package run carries per-invocation metadata, such as the execution ID,
+// through the context so every component obtains it the same way.
+package run
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// suffixLength is how many trailing ID characters are embedded in client order IDs
+const suffixLength = 8
+
+type idKey struct{}
+
+// NewID returns a new execution ID in ULID format (26 characters, time-sortable)
+func NewID() string {
+	return newID(time.Now(), rand.Reader)
+}
+
+// newID encodes a 48-bit millisecond timestamp followed by 80 random bits
+func newID(t time.Time, entropy io.Reader) string {
+	var raw [16]byte
+	ms := uint64(t.UnixMilli())
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (8 * (5 - i)))
+	}
+	if _, err := io.ReadFull(entropy, raw[6:]); err != nil {
+		// crypto/rand does not fail on supported platforms; fall back to the
+		// nanosecond clock so the ID is still unique enough for correlation
+		ns := uint64(t.UnixNano())
+		for i := 6; i < 16; i++ {
+			raw[i] = byte(ns >> (8 * (i % 8)))
+		}
+	}
+
+	// 128 bits are encoded as 26 characters of 5 bits; the first character
+	// only carries the top 3 bits
+	out := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		bit := 128 - 5*(26-i)
+		out[i] = crockford[bitsAt(raw, bit)]
+	}
+	return string(out)
+}
+
+// bitsAt reads the 5-bit group starting at bit offset (from the MSB); negative
+// offsets are treated as leading zero bits
+func bitsAt(raw [16]byte, offset int) byte {
+	var v byte
+	for j := 0; j < 5; j++ {
+		pos := offset + j
+		v <<= 1
+		if pos < 0 {
+			continue
+		}
+		if raw[pos/8]&(0x80>>(pos%8)) != 0 {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// WithID returns a copy of ctx carrying the execution ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, idKey{}, id)
+}
+
+// ID returns the execution ID stored in ctx, or "" when none is set
+func ID(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Suffix returns the short form of an execution ID used where space is limited
+func Suffix(id string) string {
+	if len(id) <= suffixLength {
+		return id
+	}
+	return id[len(id)-suffixLength:]
+}
+
+// ClientOrderID derives a client order ID for the run in ctx, e.g.
+// "dca-7Q2M4KXZ", or "dca-alice-7Q2M4KXZ" for an exchange account's run.
+// The suffix is taken from the execution ID, or hashed from the
+// idempotency key when one is set. Without either the prefix is returned
+// unchanged.
+func ClientOrderID(ctx context.Context, prefix string) string {
+	id := ID(ctx)
+	if key := IdempotencyKey(ctx); key != "" {
+		id = keySuffix(key)
+	}
+	if id == "" {
+		return prefix
+	}
+	if account := Account(ctx); account != "" {
+		prefix += "-" + account
+	}
+	return prefix + "-" + Suffix(id)
+}
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx whose client order IDs derive
+// from key instead of the execution ID, so every run with the same key,
+// such as the redeliveries of one scheduled event, uses the same ones
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKey returns the idempotency key stored in ctx, or "" when
+// none is set
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
+
+// keySuffix hashes key into suffixLength Crockford characters
+func keySuffix(key string) string {
+	var raw [16]byte
+	sum := sha256.Sum256([]byte(key))
+	copy(raw[:], sum[:])
+	out := make([]byte, suffixLength)
+	for i := range out {
+		out[i] = crockford[bitsAt(raw, 5*i)]
+	}
+	return string(out)
+}
+
+type accountKey struct{}
+
+// WithAccount returns a copy of ctx for the run of one exchange account,
+// named by its label
+func WithAccount(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, accountKey{}, label)
+}
+
+// Account returns the account label stored in ctx, or "" outside an
+// account's run
+func Account(ctx context.Context) string {
+	label, _ := ctx.Value(accountKey{}).(string)
+	return label
+}
+
+// WrapUpGrace bounds the work a run still does once its context ended:
+// recording its result and sending its notifications
+const WrapUpGrace = 10 * time.Second
+
+// WrapUp returns the context to wrap a run up in: ctx itself while it is
+// live, or, once it was canceled or timed out, a copy that is not, bounded
+// by WrapUpGrace. An interrupted run then still records that it was, and
+// never hangs doing so.
+func WrapUp(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), WrapUpGrace)
+}
@@ -0,0 +1,123 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewID_Format(t *testing.T) {
+	id := NewID()
+	if len(id) != 26 {
+		t.Fatalf("len(NewID()) = %d, want 26", len(id))
+	}
+	for _, c := range id {
+		if !strings.ContainsRune(crockford, c) {
+			t.Errorf("NewID() = %s contains invalid character %q", id, c)
+		}
+	}
+	if NewID() == id {
+		t.Error("NewID() returned the same ID twice")
+	}
+}
+
+func TestNewID_KnownValue(t *testing.T) {
+	// All-zero entropy at a fixed time makes the encoding fully deterministic
+	ts := time.UnixMilli(1469918176385)
+	id := newID(ts, bytes.NewReader(make([]byte, 10)))
+	if id != "01ARYZ6S410000000000000000" {
+		t.Errorf("newID() = %s, want 01ARYZ6S410000000000000000", id)
+	}
+}
+
+func TestNewID_SortsByTime(t *testing.T) {
+	earlier := newID(time.UnixMilli(1700000000000), bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	later := newID(time.UnixMilli(1700000000001), bytes.NewReader(make([]byte, 10)))
+	if earlier >= later {
+		t.Errorf("expected %s < %s", earlier, later)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if ID(ctx) != "" {
+		t.Errorf("ID() on empty context = %q, want empty", ID(ctx))
+	}
+	if got := ClientOrderID(ctx, "dca"); got != "dca" {
+		t.Errorf("ClientOrderID() without ID = %q, want dca", got)
+	}
+
+	ctx = WithID(ctx, "01ARYZ6S41TSV4RRFFQ69G5FAV")
+	if ID(ctx) != "01ARYZ6S41TSV4RRFFQ69G5FAV" {
+		t.Errorf("ID() = %q", ID(ctx))
+	}
+	if got := ClientOrderID(ctx, "dca"); got != "dca-Q69G5FAV" {
+		t.Errorf("ClientOrderID() = %q, want dca-Q69G5FAV", got)
+	}
+
+	ctx = WithAccount(ctx, "alice")
+	if got := ClientOrderID(ctx, "dca"); got != "dca-alice-Q69G5FAV" {
+		t.Errorf("ClientOrderID() for an account = %q, want dca-alice-Q69G5FAV", got)
+	}
+}
+
+func TestClientOrderID_IdempotencyKey(t *testing.T) {
+	key := "2026-10-16T08:00:00Z|BTC-USDT|buy"
+	first := ClientOrderID(WithIdempotencyKey(WithID(context.Background(), NewID()), key), "dca")
+	redelivered := ClientOrderID(WithIdempotencyKey(WithID(context.Background(), NewID()), key), "dca")
+	if first != redelivered {
+		t.Errorf("ClientOrderID() = %q, then %q for the same key", first, redelivered)
+	}
+	suffix, ok := strings.CutPrefix(first, "dca-")
+	if !ok || len(suffix) != suffixLength || strings.Trim(suffix, crockford) != "" {
+		t.Errorf("ClientOrderID() = %q, want dca- and %d Crockford characters", first, suffixLength)
+	}
+	if other := ClientOrderID(WithIdempotencyKey(context.Background(), "2026-10-17T08:00:00Z|BTC-USDT|buy"), "dca"); other == first {
+		t.Errorf("ClientOrderID() = %q for different keys", other)
+	}
+}
+
+func TestSource_OccurredAt(t *testing.T) {
+	// A retry after midnight still dates the run by its event
+	event := time.Date(2026, 10, 16, 23, 59, 0, 0, time.UTC)
+	retry := event.Add(2 * time.Minute)
+	if got := (Source{EventTime: event}).OccurredAt(retry); !got.Equal(event) {
+		t.Errorf("OccurredAt() = %s, want the event time %s", got, event)
+	}
+	if got := (Source{}).OccurredAt(retry); !got.Equal(retry) {
+		t.Errorf("OccurredAt() without an event time = %s, want now", got)
+	}
+	if got := SentTime("1760601600000"); !got.Equal(time.UnixMilli(1760601600000)) {
+		t.Errorf("SentTime() = %s", got)
+	}
+	for _, invalid := range []string{"", "soon", "-1"} {
+		if got := SentTime(invalid); !got.IsZero() {
+			t.Errorf("SentTime(%q) = %s, want zero", invalid, got)
+		}
+	}
+}
+
+func TestWrapUp(t *testing.T) {
+	live := context.Background()
+	ctx, cancel := WrapUp(live)
+	cancel()
+	if ctx != live {
+		t.Error("WrapUp() replaced a live context")
+	}
+
+	parent, cancelParent := context.WithCancel(WithID(context.Background(), "01ARYZ6S41TSV4RRFFQ69G5FAV"))
+	cancelParent()
+	ctx, cancel = WrapUp(parent)
+	defer cancel()
+	if ctx.Err() != nil {
+		t.Errorf("WrapUp() of a canceled context is done: %v", ctx.Err())
+	}
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > WrapUpGrace {
+		t.Errorf("WrapUp() deadline = %v, want within %s", deadline, WrapUpGrace)
+	}
+	if ID(ctx) != "01ARYZ6S41TSV4RRFFQ69G5FAV" {
+		t.Error("WrapUp() lost the context's values")
+	}
+}
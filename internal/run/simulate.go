@@ -0,0 +1,68 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SimulatedError is the failure injected by flags.simulateFailure
+type SimulatedError struct {
+	Stage string // e.g. "order"
+}
+
+func (e *SimulatedError) Error() string {
+	return "SIMULATED " + e.Stage + " failure (flags.simulateFailure)"
+}
+
+// IsSimulated reports whether err is or wraps a *SimulatedError
+func IsSimulated(err error) bool {
+	var simulated *SimulatedError
+	return errors.As(err, &simulated)
+}
+
+// simulation is the failure a run simulates and whether it was injected
+type simulation struct {
+	stage string
+
+	mu       sync.Mutex
+	injected *SimulatedError
+}
+
+type simulationKey struct{}
+
+// WithSimulatedFailure returns a copy of ctx whose run fails at stage
+func WithSimulatedFailure(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, simulationKey{}, &simulation{stage: stage})
+}
+
+// Simulate returns a *SimulatedError when the run in ctx simulates a failure
+// at stage, and nil otherwise
+func Simulate(ctx context.Context, stage string) error {
+	s, _ := ctx.Value(simulationKey{}).(*simulation)
+	if s == nil || s.stage != stage {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.injected == nil {
+		s.injected = &SimulatedError{Stage: stage}
+	}
+	return s.injected
+}
+
+// SimulatedFailure returns the error Simulate injected into the run in ctx,
+// or nil. Stages whose failures are only warnings, such as notifications,
+// fail the run with it.
+func SimulatedFailure(ctx context.Context) error {
+	s, _ := ctx.Value(simulationKey{}).(*simulation)
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.injected == nil {
+		return nil
+	}
+	return s.injected
+}
@@ -0,0 +1,34 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	if err := Simulate(context.Background(), "order"); err != nil {
+		t.Errorf("Simulate() without a simulation = %v, want nil", err)
+	}
+
+	ctx := WithSimulatedFailure(context.Background(), "order")
+	if err := Simulate(ctx, "credentials"); err != nil {
+		t.Errorf("Simulate(credentials) = %v, want nil for another stage", err)
+	}
+	if err := SimulatedFailure(ctx); err != nil {
+		t.Errorf("SimulatedFailure() before injection = %v, want nil", err)
+	}
+
+	err := Simulate(ctx, "order")
+	if err == nil || !strings.Contains(err.Error(), "SIMULATED order failure") {
+		t.Fatalf("Simulate(order) = %v, want a SIMULATED order failure", err)
+	}
+	wrapped := fmt.Errorf("failed to place order: %w", err)
+	if !IsSimulated(wrapped) || IsSimulated(fmt.Errorf("failed to place order")) {
+		t.Error("IsSimulated() must match wrapped simulated errors only")
+	}
+	if got := SimulatedFailure(ctx); got != err {
+		t.Errorf("SimulatedFailure() = %v, want the injected error", got)
+	}
+}
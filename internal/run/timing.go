@@ -0,0 +1,282 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recorder collects timed spans for a run. Spans nest through the context:
+// a span started from a context carrying another span becomes its child, so
+// exchange clients and notifiers attribute their time to whatever phase
+// called them. It is safe for concurrent use.
+type Recorder struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	start time.Time
+	root  span
+	last  string // name of the span started last
+}
+
+type span struct {
+	name     string
+	strategy string // symbol, set on strategy spans
+	start    time.Time
+	end      time.Time // zero while open
+	children []*span
+}
+
+// current is the recorder and innermost open span of a context
+type current struct {
+	rec    *Recorder
+	parent *span
+}
+
+type timingKey struct{}
+
+// NewRecorder starts a recorder; the run's total time is measured from now
+func NewRecorder() *Recorder {
+	return newRecorder(time.Now)
+}
+
+func newRecorder(now func() time.Time) *Recorder {
+	return &Recorder{now: now, start: now()}
+}
+
+// WithRecorder returns a copy of ctx recording spans into rec
+func WithRecorder(ctx context.Context, rec *Recorder) context.Context {
+	return context.WithValue(ctx, timingKey{}, current{rec: rec, parent: &rec.root})
+}
+
+// RecorderFrom returns the recorder stored in ctx, or nil when none is set
+func RecorderFrom(ctx context.Context) *Recorder {
+	c, _ := ctx.Value(timingKey{}).(current)
+	return c.rec
+}
+
+// StartSpan starts timing a phase called name. The returned end function
+// stops it and may be called more than once; spans started from the returned
+// context are nested under it. Without a recorder in ctx nothing is recorded.
+func StartSpan(ctx context.Context, name string) (context.Context, func()) {
+	return startSpan(ctx, name, "")
+}
+
+// StartStrategySpan starts timing the work of one strategy. Its phases are
+// broken down separately in Timing.Strategies.
+func StartStrategySpan(ctx context.Context, symbol string) (context.Context, func()) {
+	return startSpan(ctx, "strategy", symbol)
+}
+
+func startSpan(ctx context.Context, name, strategy string) (context.Context, func()) {
+	c, ok := ctx.Value(timingKey{}).(current)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	c.rec.mu.Lock()
+	s := &span{name: name, strategy: strategy, start: c.rec.now()}
+	c.parent.children = append(c.parent.children, s)
+	c.rec.last = name
+	c.rec.mu.Unlock()
+
+	end := func() {
+		c.rec.mu.Lock()
+		defer c.rec.mu.Unlock()
+		if s.end.IsZero() {
+			s.end = c.rec.now()
+		}
+	}
+	return context.WithValue(ctx, timingKey{}, current{rec: c.rec, parent: s}), end
+}
+
+// LastStep returns the name of the span started last, e.g.
+// "exchange.order": for a run that failed, the step it failed in. It is
+// empty when no span was started.
+func (r *Recorder) LastStep() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// LastStep returns the span started last in the run of ctx, or "" when ctx
+// has no recorder
+func LastStep(ctx context.Context) string {
+	if rec := RecorderFrom(ctx); rec != nil {
+		return rec.LastStep()
+	}
+	return ""
+}
+
+// Timing is the timing breakdown of a run. Durations are in milliseconds.
+type Timing struct {
+	TotalMs        float64          `json:"totalMs"`
+	UnattributedMs float64          `json:"unattributedMs"` // time outside any top-level span
+	Phases         []Phase          `json:"phases"`         // every span, aggregated by name
+	Strategies     []StrategyTiming `json:"strategies,omitempty"`
+	Spans          []SpanTiming     `json:"spans"`
+	API            *APIUsage        `json:"api,omitempty"` // exchange requests, when any were sent
+}
+
+// APIUsage is the exchange API usage of a run
+type APIUsage struct {
+	Requests    int  `json:"requests"`
+	UsedWeight  int  `json:"usedWeight,omitempty"`  // highest request weight the exchange reported as used
+	WeightLimit int  `json:"weightLimit,omitempty"` // the limit UsedWeight counts towards
+	Throttled   bool `json:"throttled,omitempty"`   // calls the run can do without were skipped
+}
+
+// Phase is the time spent in spans of one name. SelfMs excludes time spent
+// in nested spans, so the phases of a run add up to its attributed time.
+type Phase struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	TotalMs float64 `json:"totalMs"`
+	SelfMs  float64 `json:"selfMs"`
+}
+
+// StrategyTiming is the breakdown of one strategy span
+type StrategyTiming struct {
+	Symbol  string  `json:"symbol"`
+	TotalMs float64 `json:"totalMs"`
+	Phases  []Phase `json:"phases"`
+}
+
+// SpanTiming is one recorded span and the spans nested under it
+type SpanTiming struct {
+	Name     string       `json:"name"`
+	Strategy string       `json:"strategy,omitempty"`
+	StartMs  float64      `json:"startMs"` // offset from the start of the run
+	TotalMs  float64      `json:"totalMs"`
+	SelfMs   float64      `json:"selfMs"`
+	Open     bool         `json:"open,omitempty"` // had not ended when the timing was taken
+	Spans    []SpanTiming `json:"spans,omitempty"`
+}
+
+// Timing snapshots the spans recorded so far; spans still open are timed up
+// to now
+func (r *Recorder) Timing() *Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	total := now.Sub(r.start)
+	t := &Timing{TotalMs: ms(total)}
+
+	var attributed time.Duration
+	for _, s := range r.root.children {
+		st := r.snapshot(s, now)
+		attributed += s.duration(now)
+		t.Spans = append(t.Spans, st)
+	}
+	t.UnattributedMs = ms(max(total-attributed, 0))
+	t.Phases = phases(t.Spans)
+	walk(t.Spans, func(st SpanTiming) {
+		if st.Strategy != "" {
+			t.Strategies = append(t.Strategies, StrategyTiming{Symbol: st.Strategy, TotalMs: st.TotalMs, Phases: phases(st.Spans)})
+		}
+	})
+	return t
+}
+
+func (r *Recorder) snapshot(s *span, now time.Time) SpanTiming {
+	total := s.duration(now)
+	st := SpanTiming{
+		Name:     s.name,
+		Strategy: s.strategy,
+		StartMs:  ms(s.start.Sub(r.start)),
+		TotalMs:  ms(total),
+		Open:     s.end.IsZero(),
+	}
+	self := total
+	for _, child := range s.children {
+		self -= child.duration(now)
+		st.Spans = append(st.Spans, r.snapshot(child, now))
+	}
+	// Children running in parallel can overlap their parent's whole time
+	st.SelfMs = ms(max(self, 0))
+	return st
+}
+
+func (s *span) duration(now time.Time) time.Duration {
+	if s.end.IsZero() {
+		return now.Sub(s.start)
+	}
+	return s.end.Sub(s.start)
+}
+
+// phases aggregates spans and everything nested under them by name, in
+// order of first appearance
+func phases(spans []SpanTiming) []Phase {
+	var out []Phase
+	index := map[string]int{}
+	walk(spans, func(st SpanTiming) {
+		i, ok := index[st.Name]
+		if !ok {
+			i = len(out)
+			index[st.Name] = i
+			out = append(out, Phase{Name: st.Name})
+		}
+		out[i].Count++
+		out[i].TotalMs = round(out[i].TotalMs + st.TotalMs)
+		out[i].SelfMs = round(out[i].SelfMs + st.SelfMs)
+	})
+	return out
+}
+
+// walk visits spans depth-first
+func walk(spans []SpanTiming, fn func(SpanTiming)) {
+	for _, st := range spans {
+		fn(st)
+		walk(st.Spans, fn)
+	}
+}
+
+// Table renders the breakdown as aligned text lines for the log, nested
+// spans indented under their parent, followed by the slowest phases
+func (t *Timing) Table() []string {
+	lines := []string{fmt.Sprintf("%-40s %10s %10s", "span", "total ms", "self ms")}
+	var add func(spans []SpanTiming, depth int)
+	add = func(spans []SpanTiming, depth int) {
+		for _, st := range spans {
+			name := strings.Repeat("  ", depth) + st.Name
+			if st.Strategy != "" {
+				name += " " + st.Strategy
+			}
+			lines = append(lines, fmt.Sprintf("%-40s %10.1f %10.1f", name, st.TotalMs, st.SelfMs))
+			add(st.Spans, depth+1)
+		}
+	}
+	add(t.Spans, 0)
+	lines = append(lines, fmt.Sprintf("%-40s %10.1f", "(unattributed)", t.UnattributedMs))
+	lines = append(lines, fmt.Sprintf("%-40s %10.1f", "total", t.TotalMs))
+	if api := t.API; api != nil {
+		usage := fmt.Sprintf("%d requests", api.Requests)
+		if api.WeightLimit > 0 {
+			usage += fmt.Sprintf(", weight %d/%d", api.UsedWeight, api.WeightLimit)
+		}
+		if api.Throttled {
+			usage += ", throttled"
+		}
+		lines = append(lines, fmt.Sprintf("%-40s %s", "exchange api", usage))
+	}
+
+	slowest := append([]Phase(nil), t.Phases...)
+	sort.SliceStable(slowest, func(i, j int) bool { return slowest[i].SelfMs > slowest[j].SelfMs })
+	for _, p := range slowest {
+		lines = append(lines, fmt.Sprintf("%-40s %10s %10.1f", fmt.Sprintf("phase %s ×%d", p.Name, p.Count), "", p.SelfMs))
+	}
+	return lines
+}
+
+// ms converts d to milliseconds with microsecond precision
+func ms(d time.Duration) float64 {
+	return round(float64(d) / float64(time.Millisecond))
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,197 @@
+package run
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when told to
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(ms int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(time.Duration(ms) * time.Millisecond)
+}
+
+func TestTiming_NestedSpans(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := newRecorder(clock.Now)
+	ctx := WithRecorder(context.Background(), rec)
+
+	clock.Advance(5) // unattributed
+	_, end := StartSpan(ctx, "payload.parse")
+	clock.Advance(10)
+	end()
+
+	sctx, endStrategy := StartStrategySpan(ctx, "BTC-USDT")
+	clock.Advance(20)
+	octx, endOrder := StartSpan(sctx, "order")
+	_, endCall := StartSpan(octx, "exchange.placeOrder")
+	clock.Advance(300)
+	endCall()
+	clock.Advance(50)
+	endOrder()
+	_, endNotify := StartSpan(sctx, "notify.post_trade")
+	clock.Advance(100)
+	endNotify()
+	endNotify() // ending twice keeps the first end
+	clock.Advance(15)
+	endStrategy()
+
+	timing := rec.Timing()
+	if timing.TotalMs != 500 || timing.UnattributedMs != 5 {
+		t.Errorf("TotalMs = %v, UnattributedMs = %v, want 500 and 5", timing.TotalMs, timing.UnattributedMs)
+	}
+
+	strategy := timing.Spans[1]
+	if strategy.TotalMs != 485 || strategy.SelfMs != 35 || strategy.StartMs != 15 {
+		t.Errorf("strategy span = %+v, want 485 total, 35 self from 15", strategy)
+	}
+	order := strategy.Spans[0]
+	if order.TotalMs != 350 || order.SelfMs != 50 || order.Spans[0].Name != "exchange.placeOrder" || order.Spans[0].TotalMs != 300 {
+		t.Errorf("order span = %+v, want 350 total, 50 self, 300 in exchange.placeOrder", order)
+	}
+
+	// Self times of every phase plus unattributed time add up to the run
+	sum := timing.UnattributedMs
+	for _, p := range timing.Phases {
+		sum += p.SelfMs
+	}
+	if sum != timing.TotalMs {
+		t.Errorf("phases sum to %v, want %v", sum, timing.TotalMs)
+	}
+
+	if len(timing.Strategies) != 1 || timing.Strategies[0].Symbol != "BTC-USDT" || len(timing.Strategies[0].Phases) != 3 {
+		t.Errorf("Strategies = %+v, want BTC-USDT with order, exchange.placeOrder and notify phases", timing.Strategies)
+	}
+}
+
+func TestTiming_OpenSpan(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := newRecorder(clock.Now)
+	StartSpan(WithRecorder(context.Background(), rec), "exchange.balance")
+	clock.Advance(40)
+
+	timing := rec.Timing()
+	if !timing.Spans[0].Open || timing.Spans[0].TotalMs != 40 {
+		t.Errorf("open span = %+v, want 40ms and open", timing.Spans[0])
+	}
+}
+
+func TestLastStep(t *testing.T) {
+	if step := LastStep(context.Background()); step != "" {
+		t.Errorf("LastStep() without a recorder = %q, want empty", step)
+	}
+	ctx := WithRecorder(context.Background(), NewRecorder())
+	if step := LastStep(ctx); step != "" {
+		t.Errorf("LastStep() before any span = %q, want empty", step)
+	}
+	sctx, end := StartSpan(ctx, "order")
+	_, endCall := StartSpan(sctx, "exchange.placeOrder")
+	endCall()
+	end()
+	if step := LastStep(ctx); step != "exchange.placeOrder" {
+		t.Errorf("LastStep() = %q, want exchange.placeOrder", step)
+	}
+}
+
+func TestTiming_AggregatesByName(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := newRecorder(clock.Now)
+	ctx := WithRecorder(context.Background(), rec)
+	for _, symbol := range []string{"BTC-USDT", "ETH-USDT"} {
+		sctx, end := StartStrategySpan(ctx, symbol)
+		_, endCall := StartSpan(sctx, "exchange.placeOrder")
+		clock.Advance(100)
+		endCall()
+		end()
+	}
+
+	phases := rec.Timing().Phases
+	if len(phases) != 2 || phases[1].Name != "exchange.placeOrder" || phases[1].Count != 2 || phases[1].TotalMs != 200 {
+		t.Errorf("Phases = %+v, want exchange.placeOrder twice for 200ms", phases)
+	}
+}
+
+func TestTiming_Concurrent(t *testing.T) {
+	rec := NewRecorder()
+	ctx := WithRecorder(context.Background(), rec)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for _, symbol := range []string{"BTC-USDT", "ETH-USDT", "SOL-USDT", "BNB-USDT"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sctx, end := StartStrategySpan(ctx, symbol)
+			defer end()
+			for i := 0; i < 10; i++ {
+				_, endCall := StartSpan(sctx, "exchange.balance")
+				time.Sleep(time.Millisecond)
+				endCall()
+			}
+		}()
+	}
+	wg.Wait()
+
+	timing := rec.Timing()
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+	if timing.TotalMs < elapsed-1 || timing.TotalMs > elapsed+50 {
+		t.Errorf("TotalMs = %v, want about %v", timing.TotalMs, elapsed)
+	}
+	if len(timing.Strategies) != 4 {
+		t.Fatalf("Strategies = %d, want 4", len(timing.Strategies))
+	}
+	for _, s := range timing.Strategies {
+		if len(s.Phases) != 1 || s.Phases[0].Count != 10 {
+			t.Errorf("strategy %s phases = %+v, want 10 exchange.balance spans", s.Symbol, s.Phases)
+		}
+		// Each strategy's spans account for nearly all of its time
+		if math.Abs(s.TotalMs-s.Phases[0].TotalMs) > s.TotalMs/2 {
+			t.Errorf("strategy %s: %vms total, %vms in spans", s.Symbol, s.TotalMs, s.Phases[0].TotalMs)
+		}
+	}
+}
+
+func TestTiming_WithoutRecorder(t *testing.T) {
+	ctx := context.Background()
+	spanCtx, end := StartSpan(ctx, "payload.parse")
+	end()
+	if spanCtx != ctx || RecorderFrom(ctx) != nil {
+		t.Error("StartSpan without a recorder should leave the context untouched")
+	}
+}
+
+func TestTiming_Table(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	rec := newRecorder(clock.Now)
+	ctx := WithRecorder(context.Background(), rec)
+	sctx, end := StartStrategySpan(ctx, "BTC-USDT")
+	_, endCall := StartSpan(sctx, "exchange.placeOrder")
+	clock.Advance(120)
+	endCall()
+	end()
+
+	timing := rec.Timing()
+	timing.API = &APIUsage{Requests: 3, UsedWeight: 5000, WeightLimit: 6000, Throttled: true}
+	table := strings.Join(timing.Table(), "\n")
+	for _, want := range []string{"strategy BTC-USDT", "  exchange.placeOrder", "120.0", "phase exchange.placeOrder ×1", "3 requests, weight 5000/6000, throttled"} {
+		if !strings.Contains(table, want) {
+			t.Errorf("Table() missing %q:\n%s", want, table)
+		}
+	}
+}
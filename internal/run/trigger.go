@@ -0,0 +1,79 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Trigger identifies what kind of source invoked the function
+type Trigger string
+
+const (
+	TriggerDirect      Trigger = "direct"      // Invoke API, CLI, Scheduler or a local run
+	TriggerEventBridge Trigger = "eventbridge" // an EventBridge rule
+	TriggerSQS         Trigger = "sqs"         // an SQS event source mapping or the SQS worker
+	TriggerWebhook     Trigger = "webhook"     // a Function URL request
+)
+
+// Source describes the invocation that started the run
+type Source struct {
+	Trigger Trigger
+
+	// Event is the payload as received, with any EventBridge envelope
+	// removed. KMS envelopes are left encrypted, so it is safe to store
+	// and re-send.
+	Event json.RawMessage
+
+	// InvocationID stays the same when the invocation is retried: the
+	// Lambda request ID, which retries of an asynchronous invocation keep,
+	// or the ID of the SQS worker's message, which redeliveries keep. It is
+	// empty otherwise.
+	InvocationID string
+
+	// EventID is the id of the EventBridge event that triggered the run,
+	// which every delivery of the event keeps
+	EventID string
+
+	// EventTime is when the event that triggered the run occurred, which
+	// its retries and redeliveries keep: the time of the EventBridge event,
+	// for scheduled events the scheduled time, or when the SQS message was
+	// first sent. It is zero when the trigger does not say.
+	EventTime time.Time
+}
+
+// OccurredAt returns EventTime, or now when the trigger did not say
+func (s Source) OccurredAt(now time.Time) time.Time {
+	if s.EventTime.IsZero() {
+		return now
+	}
+	return s.EventTime
+}
+
+// SentTime parses the SentTimestamp attribute of an SQS message,
+// milliseconds since the epoch; zero when it is missing or invalid
+func SentTime(timestamp string) time.Time {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+type sourceKey struct{}
+
+// WithSource returns a copy of ctx carrying the invocation source
+func WithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFrom returns the invocation source stored in ctx; without one the
+// run is treated as a direct invocation
+func SourceFrom(ctx context.Context) Source {
+	source, ok := ctx.Value(sourceKey{}).(Source)
+	if !ok {
+		return Source{Trigger: TriggerDirect}
+	}
+	return source
+}
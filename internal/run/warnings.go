@@ -0,0 +1,120 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ErrStrictMode marks a run failed only because flags.strictMode promoted
+// its warnings
+var ErrStrictMode = errors.New("strict mode")
+
+// Warning is a failure of an optional subsystem that did not fail the run,
+// such as a notifier or a result publisher
+type Warning struct {
+	Subsystem string `json:"subsystem"` // e.g. "eventbridge", "telegram"
+	Operation string `json:"operation"` // e.g. "publish", "delivery"
+	Error     string `json:"error"`
+	Retried   bool   `json:"retried,omitempty"` // the operation was retried before giving up
+}
+
+// String is the short form used in summaries, e.g. "eventbridge publish failed"
+func (w Warning) String() string {
+	return w.Subsystem + " " + w.Operation + " failed"
+}
+
+// Warnings collects the warnings of a run. It is safe for concurrent use.
+type Warnings struct {
+	mu     sync.Mutex
+	list   []Warning
+	strict bool
+}
+
+type warningsKey struct{}
+
+// WithWarnings returns a copy of ctx collecting warnings into w
+func WithWarnings(ctx context.Context, w *Warnings) context.Context {
+	return context.WithValue(ctx, warningsKey{}, w)
+}
+
+// WarningsFrom returns the collector stored in ctx, or nil when none is set
+func WarningsFrom(ctx context.Context) *Warnings {
+	w, _ := ctx.Value(warningsKey{}).(*Warnings)
+	return w
+}
+
+// Warn logs a subsystem failure and records it as a warning of the run in ctx
+func Warn(ctx context.Context, subsystem, operation string, err error) {
+	AddWarning(ctx, Warning{Subsystem: subsystem, Operation: operation, Error: err.Error()})
+}
+
+// AddWarning logs w and records it for the run in ctx, if any
+func AddWarning(ctx context.Context, w Warning) {
+	log.Printf("⚠️ %s: %s", w, w.Error)
+	if c := WarningsFrom(ctx); c != nil {
+		c.mu.Lock()
+		c.list = append(c.list, w)
+		c.mu.Unlock()
+	}
+}
+
+// List returns the warnings recorded so far; nil-safe
+func (c *Warnings) List() []Warning {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Warning(nil), c.list...)
+}
+
+// SetStrict makes Promote turn warnings into a run failure; nil-safe
+func (c *Warnings) SetStrict(strict bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strict = strict
+}
+
+// Promote returns err unchanged, or in strict mode an ErrStrictMode error
+// when the run would otherwise succeed with warnings; nil-safe
+func (c *Warnings) Promote(err error) error {
+	if err != nil || c == nil {
+		return err
+	}
+	c.mu.Lock()
+	strict, list := c.strict, append([]Warning(nil), c.list...)
+	c.mu.Unlock()
+	if !strict || len(list) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrStrictMode, describeWarnings(list))
+}
+
+// WarningSummary renders warnings as one line for notifications, e.g.
+// "⚠️ 2 warnings: eventbridge publish failed, telegram delivery failed".
+// It is empty without warnings.
+func WarningSummary(warnings []Warning) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+	return "⚠️ " + describeWarnings(warnings)
+}
+
+func describeWarnings(warnings []Warning) string {
+	names := make([]string, len(warnings))
+	for i, w := range warnings {
+		names[i] = w.String()
+	}
+	noun := "warnings"
+	if len(warnings) == 1 {
+		noun = "warning"
+	}
+	return fmt.Sprintf("%d %s: %s", len(warnings), noun, strings.Join(names, ", "))
+}
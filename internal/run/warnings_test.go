@@ -0,0 +1,103 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestWarnings(t *testing.T) {
+	w := &Warnings{}
+	ctx := WithWarnings(context.Background(), w)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Warn(ctx, "telegram", "delivery", errors.New("chat not found"))
+		}()
+	}
+	wg.Wait()
+	AddWarning(ctx, Warning{Subsystem: "eventbridge", Operation: "publish", Error: "throttled", Retried: true})
+
+	list := w.List()
+	if len(list) != 11 {
+		t.Fatalf("recorded %d warnings, want 11", len(list))
+	}
+	if last := list[10]; last.String() != "eventbridge publish failed" || !last.Retried {
+		t.Errorf("last warning = %+v", last)
+	}
+
+	// Without a collector warnings are only logged
+	Warn(context.Background(), "telegram", "delivery", errors.New("ignored"))
+	if WarningsFrom(context.Background()).List() != nil {
+		t.Error("List() on a nil collector returned warnings")
+	}
+}
+
+func TestWarningSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		warnings []Warning
+		want     string
+	}{
+		{"none", nil, ""},
+		{"one", []Warning{{Subsystem: "eventbridge", Operation: "publish"}}, "⚠️ 1 warning: eventbridge publish failed"},
+		{"two", []Warning{{Subsystem: "dynamodb", Operation: "write"}, {Subsystem: "discord", Operation: "delivery"}},
+			"⚠️ 2 warnings: dynamodb write failed, discord delivery failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WarningSummary(tt.warnings); got != tt.want {
+				t.Errorf("WarningSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarnings_Promote(t *testing.T) {
+	runErr := errors.New("order rejected")
+	warning := Warning{Subsystem: "telegram", Operation: "delivery", Error: "timeout"}
+
+	tests := []struct {
+		name       string
+		strict     bool
+		warnings   []Warning
+		err        error
+		wantErr    error
+		wantStrict bool
+	}{
+		{"lenient", false, []Warning{warning}, nil, nil, false},
+		{"strict_clean", true, nil, nil, nil, false},
+		{"strict_warning", true, []Warning{warning}, nil, nil, true},
+		{"strict_failed_anyway", true, []Warning{warning}, runErr, runErr, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Warnings{}
+			w.SetStrict(tt.strict)
+			ctx := WithWarnings(context.Background(), w)
+			for _, warning := range tt.warnings {
+				AddWarning(ctx, warning)
+			}
+
+			err := w.Promote(tt.err)
+			if tt.wantStrict {
+				if !errors.Is(err, ErrStrictMode) || err.Error() != "strict mode: 1 warning: telegram delivery failed" {
+					t.Errorf("Promote() = %v, want the strict mode failure", err)
+				}
+				return
+			}
+			if err != tt.wantErr {
+				t.Errorf("Promote() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	var none *Warnings
+	if err := none.Promote(nil); err != nil {
+		t.Errorf("nil Promote() = %v, want nil", err)
+	}
+}
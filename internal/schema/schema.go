@@ -0,0 +1,174 @@
+// Package schema describes the payload as a JSON Schema (draft 2020-12),
+// for editor completion and CI checks of payload files. The schema is
+// generated from the config types' JSON tags and config.FieldRules, the
+// lists and patterns ParseDCAPayload validates against, so it follows the
+// parser rather than being written alongside it.
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Draft is the JSON Schema dialect of the generated schema
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// ID identifies the payload schema
+const ID = "https://github.com/sudowanderer/dca-bot-go/payload.schema.json"
+
+// Schema is the subset of JSON Schema the payload needs
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema            `json:"propertyNames,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             int                `json:"minItems,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// decimalDescription marks the strings config.FieldRule.Decimal covers
+const decimalDescription = `a decimal number written as a string, e.g. "10.5"`
+
+// Payload returns the schema of a config.DCAPayload
+func Payload() *Schema {
+	s, _ := generate()
+	return s
+}
+
+// generate returns the payload schema and the config.FieldRules keys it
+// applied
+func generate() (*Schema, map[string]bool) {
+	g := &generator{defs: map[string]*Schema{}, used: map[string]bool{}}
+	root := g.object(reflect.TypeOf(config.DCAPayload{}))
+	root.Schema, root.ID, root.Title = Draft, ID, "DCA bot payload"
+	root.Defs = g.defs
+	return root, g.used
+}
+
+// generator walks the config types, keeping each named struct but the
+// root in defs
+type generator struct {
+	defs map[string]*Schema
+	used map[string]bool
+}
+
+// of returns the schema of values of t
+func (g *generator) of(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.of(t.Elem())
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // placeholder, for types that contain themselves
+			g.defs[name] = g.object(t)
+		}
+		return &Schema{Ref: "#/$defs/" + name}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.of(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	return &Schema{} // interface{}: any value
+}
+
+// object returns the schema of struct t, its embedded structs' fields
+// included as encoding/json flattens them
+func (g *generator) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(t, s)
+	return s
+}
+
+func (g *generator) fields(t reflect.Type, s *Schema) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			g.fields(f.Type, s)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		key := t.Name() + "." + name
+		rule, ok := config.FieldRules[key]
+		if ok {
+			g.used[key] = true
+		}
+		s.Properties[name] = g.field(g.of(f.Type), rule)
+		if rule.Required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// field applies rule to the schema of a field
+func (g *generator) field(s *Schema, rule config.FieldRule) *Schema {
+	if rule.Keys != nil {
+		s.PropertyNames = &Schema{Enum: rule.Keys}
+	}
+
+	// Enums and patterns constrain a string, or the strings it holds
+	target := s
+	switch {
+	case s.Type == "array":
+		target = s.Items
+	case s.Type == "object" && rule.Decimal:
+		target = s.AdditionalProperties
+	}
+	if rule.Decimal {
+		target.Pattern, target.Description = config.DecimalPattern, decimalDescription
+	}
+	if rule.Pattern != "" {
+		target.Pattern = rule.Pattern
+	}
+	if rule.Enum != nil {
+		target.Enum = slices.Clone(rule.Enum)
+	}
+	// The parser reads an empty optional string as a missing one
+	if target == s && s.Type == "string" && !rule.Required {
+		if s.Enum != nil {
+			s.Enum = append(s.Enum, "")
+		}
+		if s.Pattern != "" {
+			s.Pattern = "^$|" + s.Pattern
+		}
+	}
+
+	if rule.ObjectForm != nil {
+		s = &Schema{OneOf: []*Schema{s, g.of(reflect.TypeOf(rule.ObjectForm))}}
+	}
+	if rule.ListForm {
+		s = &Schema{OneOf: []*Schema{s, {Type: "array", Items: s, MinItems: 1}}}
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// fixtures are the payload files other packages' tests parse
+var fixtures = []string{
+	"../../local_event.json",
+	"../config/testdata/effective_dca.json",
+	"../config/testdata/effective_dust.json",
+	"../plan/testdata/*.json",
+}
+
+func decode(t *testing.T, data []byte) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestPayload_UsesEveryFieldRule(t *testing.T) {
+	_, used := generate()
+	for key := range config.FieldRules {
+		if !used[key] {
+			t.Errorf("FieldRules[%q] matches no payload field", key)
+		}
+	}
+}
+
+func TestPayload_Fixtures(t *testing.T) {
+	root := Payload()
+	var paths []string
+	for _, pattern := range fixtures {
+		matches, err := filepath.Glob(pattern)
+		if err != nil || len(matches) == 0 {
+			t.Fatalf("no fixtures match %s", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			payload, err := config.ParseDCAPayload(data)
+			if err != nil {
+				t.Fatalf("ParseDCAPayload() error = %v", err)
+			}
+			if err := validate(root, root, decode(t, data), "$"); err != nil {
+				t.Errorf("fixture does not match the schema: %v", err)
+			}
+
+			// The parsed payload, defaults filled in, still matches
+			encoded, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validate(root, root, decode(t, encoded), "$"); err != nil {
+				t.Errorf("re-encoded payload does not match the schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestPayload_BadFixture(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "bad_payload.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.ParseDCAPayload(data); err == nil {
+		t.Error("ParseDCAPayload() accepted the bad fixture")
+	}
+	root := Payload()
+	if err := validate(root, root, decode(t, data), "$"); err == nil {
+		t.Error("the bad fixture matches the schema")
+	}
+}
+
+func TestPayload_Rules(t *testing.T) {
+	root := Payload()
+	tests := []struct {
+		name    string
+		payload string
+		wantErr string // empty for valid
+	}{
+		{"minimal", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT","quoteAmount":"10"}}`, ""},
+		{"exchange list", `{"version":"v2","exchange":[{"name":"okx"},{"name":"binance"}],"strategy":{"symbol":"BTCUSDT"}}`, ""},
+		{"threshold object", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT","balanceThreshold":{"amount":"50","currency":"USD"}}}`, ""},
+		{"unknown keys pass", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT"},"comment":"weekly"}`, ""},
+		{"empty optional enum", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT","side":""}}`, ""},
+		{"missing version", `{"exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT"}}`, `"version"`},
+		{"empty exchange list", `{"version":"v2","exchange":[],"strategy":{"symbol":"BTC-USDT"}}`, "oneOf"},
+		{"unknown mode", `{"version":"v2","mode":"hodl","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT"}}`, "$.mode"},
+		{"bad symbol", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC/USDT"}}`, "$.strategy.symbol"},
+		{"number amount", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT","quoteAmount":10}}`, "$.strategy.quoteAmount"},
+		{"unknown event", `{"version":"v2","exchange":{"name":"okx"},"strategy":{"symbol":"BTC-USDT"},"notifications":{"events":{"sent":{}}}}`, "$.notifications.events.sent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(root, root, decode(t, []byte(tt.payload)), "$")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validate() error = %v, want it to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
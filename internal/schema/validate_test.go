@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// validate checks a decoded JSON value against s, covering the keywords
+// the generator emits, and returns the first violation with its path
+func validate(root, s *Schema, v any, path string) error {
+	if s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("%s: unknown $ref %s", path, s.Ref)
+		}
+		return validate(root, def, v, path)
+	}
+	if s.OneOf != nil {
+		matched := 0
+		for _, alt := range s.OneOf {
+			if validate(root, alt, v, path) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas, want 1", path, matched)
+		}
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want an object, got %T", path, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s: missing required %q", path, name)
+			}
+		}
+		for key, value := range obj {
+			if s.PropertyNames != nil {
+				if err := validate(root, s.PropertyNames, key, path+"."+key); err != nil {
+					return err
+				}
+			}
+			prop, ok := s.Properties[key]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if err := validate(root, prop, value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: want an array, got %T", path, v)
+		}
+		if len(items) < s.MinItems {
+			return fmt.Errorf("%s: want at least %d items", path, s.MinItems)
+		}
+		for i, item := range items {
+			if err := validate(root, s.Items, item, fmt.Sprintf("%s.%d", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: want a string, got %T", path, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: want a boolean, got %T", path, v)
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: want an integer, got %v", path, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: want a number, got %T", path, v)
+		}
+	}
+
+	if s.Enum != nil && !slices.Contains(s.Enum, fmt.Sprint(v)) {
+		return fmt.Errorf("%s: %v is not one of %q", path, v, s.Enum)
+	}
+	if str, ok := v.(string); ok && s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+		return fmt.Errorf("%s: %q does not match %s", path, str, s.Pattern)
+	}
+	return nil
+}
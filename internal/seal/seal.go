@@ -0,0 +1,175 @@
+// Package seal encrypts the files the bot keeps on local disk, such as the
+// status directory and local execution histories, with AES-256-GCM under a
+// key from DCA_LOCAL_ENCRYPTION_KEY. Sealed files start with a header
+// naming the key they were sealed with, so a wrong key is told apart from
+// a damaged file. Files without the header are plaintext and read as they
+// are, which lets existing state be encrypted in place with rekey.
+package seal
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KeyEnv holds the base64 encoded 32-byte key local files are sealed with
+const KeyEnv = "DCA_LOCAL_ENCRYPTION_KEY"
+
+// KeySize is the length of a key, for AES-256
+const KeySize = 32
+
+// magic starts every sealed file
+var magic = []byte("DCASEAL1")
+
+// idSize is the length of the key ID in the header
+const idSize = 8
+
+var (
+	// ErrNoKey is returned when opening a sealed file without a key
+	ErrNoKey = errors.New("file is encrypted; set " + KeyEnv)
+	// ErrWrongKey is returned when a file was sealed with another key
+	ErrWrongKey = errors.New("file was encrypted with a different " + KeyEnv)
+	// ErrCorrupt is returned when a sealed file fails authentication
+	ErrCorrupt = errors.New("encrypted file is corrupted")
+)
+
+// ParseKey decodes a base64 encoded key
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid base64: %w", KeyEnv, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%s: key is %d bytes, want %d", KeyEnv, len(key), KeySize)
+	}
+	return key, nil
+}
+
+// KeyFromEnv reads the key in name; it is nil when name is unset
+func KeyFromEnv(name string) ([]byte, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return nil, nil
+	}
+	return ParseKey(s)
+}
+
+// keyID identifies key in a sealed file's header without revealing it
+func keyID(key []byte) []byte {
+	sum := sha256.Sum256(append([]byte("dca-seal-key-id:"), key...))
+	return sum[:idSize]
+}
+
+// IsSealed reports whether data is a sealed file
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Seal encrypts plaintext under key. The header is authenticated with the
+// ciphertext.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := append(append([]byte{}, magic...), keyID(key)...)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	out := append(bytes.Clone(header), nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// Open decrypts a sealed file under key. Plaintext data is returned as it
+// is, with or without a key.
+func Open(key, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	if key == nil {
+		return nil, ErrNoKey
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	headerSize := len(magic) + idSize
+	if len(data) < headerSize+gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrCorrupt
+	}
+	header := data[:headerSize]
+	if !bytes.Equal(header[len(magic):], keyID(key)) {
+		return nil, ErrWrongKey
+	}
+	nonce := data[headerSize : headerSize+gcm.NonceSize()]
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize+gcm.NonceSize():], header)
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ReadFile reads path and opens it under key
+func ReadFile(path string, key []byte) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(key, data)
+}
+
+// WriteFile replaces path with data, sealed under key unless key is nil.
+// It writes a temporary file and renames it, so a reader never sees half
+// a file.
+func WriteFile(path string, data, key []byte) error {
+	if key != nil {
+		sealed, err := Seal(key, data)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Rekey re-seals path from oldKey to newKey; a nil oldKey reads a
+// plaintext file and a nil newKey writes one back
+func Rekey(path string, oldKey, newKey []byte) error {
+	data, err := ReadFile(path, oldKey)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, newKey)
+}
@@ -0,0 +1,119 @@
+package seal
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func key(b byte) []byte { return bytes.Repeat([]byte{b}, KeySize) }
+
+func TestSealOpen(t *testing.T) {
+	plaintext := []byte(`{"paused":true}`)
+	sealed, err := Seal(key(1), plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, plaintext) {
+		t.Fatalf("Seal() = %q, want a sealed file without the plaintext", sealed)
+	}
+	again, _ := Seal(key(1), plaintext)
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice gave the same bytes; the nonce must be random")
+	}
+
+	got, err := Open(key(1), sealed)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("Open() = %q, %v, want the plaintext", got, err)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	sealed, err := Seal(key(1), []byte("ledger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-1] ^= 0x01
+	header := bytes.Clone(sealed)
+	header[len(magic)] ^= 0x01 // names another key
+
+	tests := []struct {
+		name string
+		key  []byte
+		data []byte
+		want error
+	}{
+		{"no key", nil, sealed, ErrNoKey},
+		{"wrong key", key(2), sealed, ErrWrongKey},
+		{"flipped ciphertext", key(1), flipped, ErrCorrupt},
+		{"tampered key id", key(1), header, ErrWrongKey},
+		{"truncated", key(1), sealed[:len(magic)+idSize+4], ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Open(tt.key, tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("Open() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpen_Plaintext(t *testing.T) {
+	for _, k := range [][]byte{nil, key(1)} {
+		if got, err := Open(k, []byte(`{"a":1}`)); err != nil || string(got) != `{"a":1}` {
+			t.Errorf("Open() = %q, %v, want the plaintext as it is", got, err)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	if k, err := ParseKey(base64.StdEncoding.EncodeToString(key(7)) + "\n"); err != nil || !bytes.Equal(k, key(7)) {
+		t.Errorf("ParseKey() = %x, %v", k, err)
+	}
+	if _, err := ParseKey(base64.StdEncoding.EncodeToString(key(7)[:16])); err == nil || !strings.Contains(err.Error(), "16 bytes") {
+		t.Errorf("ParseKey(16 bytes) error = %v", err)
+	}
+	if _, err := ParseKey("not base64!"); err == nil {
+		t.Error("ParseKey(garbage) error = nil")
+	}
+}
+
+func TestRekey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("line\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct{ old, new []byte }{
+		{nil, key(1)},    // encrypt a plaintext file
+		{key(1), key(2)}, // rotate
+		{key(2), nil},    // decrypt
+	}
+	for _, step := range steps {
+		if err := Rekey(path, step.old, step.new); err != nil {
+			t.Fatalf("Rekey() error = %v", err)
+		}
+		raw, _ := os.ReadFile(path)
+		if IsSealed(raw) != (step.new != nil) {
+			t.Fatalf("after Rekey() sealed = %v, want %v", IsSealed(raw), step.new != nil)
+		}
+		if got, err := ReadFile(path, step.new); err != nil || string(got) != "line\n" {
+			t.Fatalf("ReadFile() = %q, %v", got, err)
+		}
+	}
+
+	if err := WriteFile(path, []byte("line\n"), key(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rekey(path, key(3), key(2)); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Rekey() with the wrong old key error = %v, want %v", err, ErrWrongKey)
+	}
+	if got, err := ReadFile(path, key(1)); err != nil || string(got) != "line\n" {
+		t.Errorf("a failed Rekey() changed the file: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,198 @@
+// Package settle waits for market orders that an exchange acknowledges
+// before they fill, as some venues do when busy, so a run reports the
+// filled quantity and price rather than the zeros of the acknowledgement.
+// Cancel stops an order that did not settle in time.
+package settle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// Polls start FirstPoll after the order, or Waiter.Interval, and back off
+// by doubling, up to MaxPoll apart or the interval when it is longer
+const (
+	FirstPoll = 500 * time.Millisecond
+	MaxPoll   = 2 * time.Second
+)
+
+// Result is the order once settled, or as last seen when the wait ended
+type Result struct {
+	Order *exchange.Order
+
+	// Provisional is set when the order was still not final; its quantity
+	// and price may grow until it is
+	Provisional bool
+
+	Polls  int    // GetOrder calls made
+	Reason string // why it is provisional
+
+	Streamed  bool  // settled by an update on the user-data stream
+	StreamErr error // why the stream gave no final update, when one was opened
+}
+
+// Waiter polls an unsettled order until it is final or Timeout passes
+type Waiter struct {
+	Timeout  time.Duration
+	Interval time.Duration // before the first lookup; FirstPoll when zero
+
+	// Stream, when set, is followed for the order's final update first;
+	// polling gets whatever time is left when the stream fails
+	Stream exchange.OrderStreamer
+
+	Now   func() time.Time                                 // defaults to time.Now
+	Sleep func(ctx context.Context, d time.Duration) error // defaults to a timer honouring ctx
+}
+
+// Wait returns order once the exchange reports it final. A settled order
+// is returned as is. Lookups that fail are retried until the timeout, and
+// an exchange that cannot look orders up leaves the order provisional
+// straight away, as does a context that ends.
+func (w Waiter) Wait(ctx context.Context, exc exchange.Exchange, order *exchange.Order) Result {
+	res := Result{Order: order}
+	if order.Settled() {
+		return res
+	}
+	res.Provisional = true
+	now, sleep := w.Now, w.Sleep
+	if now == nil {
+		now = time.Now
+	}
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	deadline := now().Add(w.Timeout)
+
+	if w.Stream != nil {
+		got, err := w.watch(ctx, exc, order, &res)
+		if err == nil {
+			res.Order, res.Provisional, res.Streamed = got, false, true
+			return res
+		}
+		res.StreamErr = err
+	}
+	getter, ok := exc.(exchange.OrderGetter)
+	if !ok {
+		res.Reason = fmt.Sprintf("the exchange reported it %s and cannot look orders up", res.Order.Status)
+		return res
+	}
+
+	first := w.Interval
+	if first <= 0 {
+		first = FirstPoll
+	}
+	var lastErr error
+	for delay := first; ; delay = min(2*delay, max(MaxPoll, first)) {
+		left := deadline.Sub(now())
+		if left <= 0 {
+			break
+		}
+		if err := sleep(ctx, min(delay, left)); err != nil {
+			res.Reason = fmt.Sprintf("stopped waiting while it was %s: %v", res.Order.Status, err)
+			return res
+		}
+		res.Polls++
+		got, err := getter.GetOrder(ctx, order.Symbol, order.ID)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+		res.Order = got
+		if got.Settled() {
+			res.Provisional = false
+			return res
+		}
+	}
+	res.Reason = fmt.Sprintf("still %s after %s", res.Order.Status, w.Timeout)
+	if lastErr != nil {
+		res.Reason += fmt.Sprintf(" (last lookup failed: %v)", lastErr)
+	}
+	return res
+}
+
+// watch follows order on the user-data stream until its final update or
+// the timeout, keeping the latest update in res. One lookup after the
+// stream opened catches an order that settled before; another completes
+// the fees of an order that traded before.
+func (w Waiter) watch(ctx context.Context, exc exchange.Exchange, order *exchange.Order, res *Result) (*exchange.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.Timeout)
+	defer cancel()
+	stream, err := w.Stream.OpenOrderStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	getter, canLookUp := exc.(exchange.OrderGetter)
+	if canLookUp {
+		res.Polls++
+		if got, err := getter.GetOrder(ctx, order.Symbol, order.ID); err == nil {
+			res.Order = got
+			if got.Settled() {
+				return got, nil
+			}
+		}
+	}
+	for {
+		update, err := stream.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if update.Order.ID != order.ID {
+			continue
+		}
+		got := update.Order
+		got.Symbol = order.Symbol // the stream writes it the exchange's way
+		res.Order = &got
+		if !got.Settled() {
+			continue
+		}
+		if update.FeesIncomplete && canLookUp {
+			res.Polls++
+			if full, err := getter.GetOrder(ctx, order.Symbol, order.ID); err == nil && full.Settled() {
+				return full, nil
+			}
+		}
+		return &got, nil
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel cancels an order that is still working and returns it as the
+// exchange last reported it, with what filled. A cancel refused because
+// the order settled meanwhile, as when it filled during the cancel, is
+// not an error: the order is returned as settled.
+func Cancel(ctx context.Context, exc exchange.Exchange, order *exchange.Order) (*exchange.Order, error) {
+	cancelErr := exc.CancelOrder(ctx, order.Symbol, order.ID)
+	last := order
+	if getter, ok := exc.(exchange.OrderGetter); ok {
+		if got, err := getter.GetOrder(ctx, order.Symbol, order.ID); err == nil {
+			last = got
+		}
+	}
+	if last.Settled() {
+		return last, nil
+	}
+	if cancelErr != nil {
+		return last, fmt.Errorf("cancel order %s: %w", order.ID, cancelErr)
+	}
+	// Canceled, though the lookup did not show it yet
+	canceled := *last
+	canceled.Status = exchange.OrderStatusCanceled
+	return &canceled, nil
+}
@@ -0,0 +1,315 @@
+package settle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// clock is a fake clock that sleeping advances
+type clock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Sleep(ctx context.Context, d time.Duration) error {
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+	return nil
+}
+
+// noLookup hides the mock's GetOrder
+type noLookup struct{ exchange.Exchange }
+
+// failingLookup is an exchange whose order lookups fail
+type failingLookup struct{ exchange.Exchange }
+
+func (failingLookup) GetOrder(ctx context.Context, symbol, orderID string) (*exchange.Order, error) {
+	return nil, errors.New("503 service unavailable")
+}
+
+func TestWaiter_Wait(t *testing.T) {
+	tests := []struct {
+		name        string
+		settlement  []string
+		hide        func(exchange.Exchange) exchange.Exchange
+		status      string
+		quantity    string
+		provisional bool
+		polls       int
+		reason      string
+	}{
+		{name: "filled at once", settlement: []string{"filled"}, status: "filled", quantity: "0.001"},
+		{name: "filled later", settlement: []string{"open", "filled"}, status: "filled", quantity: "0.001", polls: 1},
+		{name: "partial then filled", settlement: []string{"open", "partial", "filled"}, status: "filled", quantity: "0.001", polls: 2},
+		{name: "rejected", settlement: []string{"open", "rejected"}, status: "rejected", quantity: "0", polls: 1},
+		{name: "never fills", settlement: []string{"open"}, status: "open", quantity: "0", provisional: true, polls: 7, reason: "still open after 10s"},
+		{name: "stuck partial", settlement: []string{"partial"}, status: "partial", quantity: "0.0005", provisional: true, polls: 7, reason: "still partial after 10s"},
+		{
+			name: "no lookup", settlement: []string{"open", "filled"}, status: "open", quantity: "0", provisional: true,
+			hide:   func(exc exchange.Exchange) exchange.Exchange { return noLookup{exc} },
+			reason: "cannot look orders up",
+		},
+		{
+			name: "lookups fail", settlement: []string{"open", "filled"}, status: "open", quantity: "0", provisional: true, polls: 7,
+			hide:   func(exc exchange.Exchange) exchange.Exchange { return failingLookup{exc} },
+			reason: "last lookup failed: 503",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &exchange.MockExchange{Settlement: tt.settlement}
+			var exc exchange.Exchange = mock
+			if tt.hide != nil {
+				exc = tt.hide(mock)
+			}
+			order, err := exc.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &clock{now: time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)}
+			res := Waiter{Timeout: 10 * time.Second, Now: c.Now, Sleep: c.Sleep}.Wait(ctx, exc, order)
+
+			if res.Order.Status != tt.status || !res.Order.Quantity.Equal(decimal.RequireFromString(tt.quantity)) {
+				t.Errorf("order = %s of %s, want %s of %s", res.Order.Status, res.Order.Quantity, tt.status, tt.quantity)
+			}
+			if res.Provisional != tt.provisional || res.Polls != tt.polls {
+				t.Errorf("Provisional, Polls = %v, %d, want %v, %d", res.Provisional, res.Polls, tt.provisional, tt.polls)
+			}
+			if !strings.Contains(res.Reason, tt.reason) {
+				t.Errorf("Reason = %q, want it to mention %q", res.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestWaiter_WaitInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     []time.Duration
+	}{
+		{"default", 0, []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}},
+		{"shorter", 100 * time.Millisecond, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}},
+		{"longer than MaxPoll", 3 * time.Second, []time.Duration{3 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &exchange.MockExchange{Settlement: []string{"open", "partial", "partial", "filled"}}
+			order, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &clock{now: time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)}
+			res := Waiter{Timeout: time.Minute, Interval: tt.interval, Now: c.Now, Sleep: c.Sleep}.Wait(ctx, mock, order)
+
+			if res.Order.Status != "filled" || res.Provisional || res.Polls != 3 {
+				t.Errorf("Wait = %s after %d polls (provisional %v), want filled after 3", res.Order.Status, res.Polls, res.Provisional)
+			}
+			if !slices.Equal(c.sleeps, tt.want) {
+				t.Errorf("sleeps = %v, want %v", c.sleeps, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaiter_WaitCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mock := &exchange.MockExchange{Settlement: []string{"open", "filled"}}
+	order, err := mock.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := Waiter{Timeout: time.Minute}.Wait(ctx, mock, order)
+	if !res.Provisional || res.Polls != 0 || !strings.Contains(res.Reason, "context canceled") {
+		t.Errorf("Wait = %+v, want a provisional order without lookups", res)
+	}
+}
+
+// racingCancel is an exchange whose cancels are refused, as when the
+// order fills meanwhile
+type racingCancel struct{ *exchange.MockExchange }
+
+func (racingCancel) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	return errors.New("binance error -2011: Unknown order sent.")
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		settlement []string
+		wrap       func(*exchange.MockExchange) exchange.Exchange
+		status     string
+		quantity   string
+		wantErr    string
+	}{
+		{name: "partly filled", settlement: []string{"partial"}, status: "canceled", quantity: "0.0005"},
+		{name: "nothing filled", settlement: []string{"open"}, status: "canceled", quantity: "0"},
+		{
+			name: "filled during the cancel", settlement: []string{"open", "filled"}, status: "filled", quantity: "0.001",
+			wrap: func(m *exchange.MockExchange) exchange.Exchange { return racingCancel{m} },
+		},
+		{
+			name: "cancel refused", settlement: []string{"partial"}, status: "partial", quantity: "0.0005",
+			wrap:    func(m *exchange.MockExchange) exchange.Exchange { return racingCancel{m} },
+			wantErr: "cancel order mock-order-12345: binance error -2011",
+		},
+		{
+			name: "no lookup", settlement: []string{"open", "filled"}, status: "canceled", quantity: "0",
+			wrap: func(m *exchange.MockExchange) exchange.Exchange { return noLookup{m} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &exchange.MockExchange{Settlement: tt.settlement}
+			var exc exchange.Exchange = mock
+			if tt.wrap != nil {
+				exc = tt.wrap(mock)
+			}
+			order, err := exc.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Cancel(ctx, exc, order)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Cancel() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Cancel() error = %v", err)
+			}
+			if got.Status != tt.status || !got.Quantity.Equal(decimal.RequireFromString(tt.quantity)) {
+				t.Errorf("Cancel() = %s of %s, want %s of %s", got.Status, got.Quantity, tt.status, tt.quantity)
+			}
+		})
+	}
+}
+
+// script is an order streamer replaying updates, then failing with err
+type script struct {
+	openErr error
+	updates []exchange.OrderUpdate
+	err     error
+	closed  bool
+}
+
+func (s *script) OpenOrderStream(ctx context.Context) (exchange.OrderStream, error) {
+	if s.openErr != nil {
+		return nil, s.openErr
+	}
+	return s, nil
+}
+
+func (s *script) Next(ctx context.Context) (*exchange.OrderUpdate, error) {
+	if len(s.updates) == 0 {
+		return nil, s.err
+	}
+	update := s.updates[0]
+	s.updates = s.updates[1:]
+	return &update, nil
+}
+
+func (s *script) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestWaiter_WaitStream(t *testing.T) {
+	pushed := func(id, status, quantity string) exchange.OrderUpdate {
+		return exchange.OrderUpdate{Order: exchange.Order{ID: id, Symbol: "BTCUSDT", Status: status, Quantity: decimal.RequireFromString(quantity)}}
+	}
+	closed := errors.New("websocket closed")
+	tests := []struct {
+		name       string
+		settlement []string
+		stream     *script
+		hide       func(exchange.Exchange) exchange.Exchange
+		status     string
+		quantity   string
+		streamed   bool
+		polls      int
+		streamErr  string
+	}{
+		{
+			name: "filled on the stream", settlement: []string{"open"},
+			stream: &script{updates: []exchange.OrderUpdate{pushed("other", "filled", "1"), pushed("placed", "partial", "0.0004"), pushed("placed", "filled", "0.0008")}},
+			status: "filled", quantity: "0.0008", streamed: true, polls: 1,
+		},
+		{
+			name: "settled before the stream opened", settlement: []string{"open", "filled"},
+			stream: &script{err: closed},
+			status: "filled", quantity: "0.001", streamed: true, polls: 1,
+		},
+		{
+			name: "fees incomplete", settlement: []string{"open", "open", "filled"},
+			stream: &script{updates: []exchange.OrderUpdate{{Order: exchange.Order{ID: "placed", Status: "filled", Quantity: decimal.RequireFromString("0.0008")}, FeesIncomplete: true}}},
+			status: "filled", quantity: "0.001", streamed: true, polls: 2,
+		},
+		{
+			name: "no lookup", settlement: []string{"open"},
+			hide:   func(exc exchange.Exchange) exchange.Exchange { return noLookup{exc} },
+			stream: &script{updates: []exchange.OrderUpdate{pushed("placed", "filled", "0.0008")}},
+			status: "filled", quantity: "0.0008", streamed: true,
+		},
+		{
+			name: "stream fails to open", settlement: []string{"open", "open", "filled"},
+			stream: &script{openErr: errors.New("listen key: HTTP 401")},
+			status: "filled", quantity: "0.001", polls: 2, streamErr: "listen key",
+		},
+		{
+			name: "socket drops", settlement: []string{"open", "open", "filled"},
+			stream: &script{updates: []exchange.OrderUpdate{pushed("placed", "partial", "0.0004")}, err: closed},
+			status: "filled", quantity: "0.001", polls: 2, streamErr: "websocket closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			mock := &exchange.MockExchange{Settlement: tt.settlement}
+			var exc exchange.Exchange = mock
+			if tt.hide != nil {
+				exc = tt.hide(mock)
+			}
+			order, err := exc.PlaceMarketBuyOrder(ctx, "BTC-USDT", exchange.QuoteSize(decimal.NewFromInt(50)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// "placed" stands for the ID the mock gave the order
+			for i := range tt.stream.updates {
+				if tt.stream.updates[i].Order.ID == "placed" {
+					tt.stream.updates[i].Order.ID = order.ID
+				}
+			}
+			c := &clock{now: time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)}
+			res := Waiter{Timeout: 10 * time.Second, Stream: tt.stream, Now: c.Now, Sleep: c.Sleep}.Wait(ctx, exc, order)
+
+			if res.Order.Status != tt.status || !res.Order.Quantity.Equal(decimal.RequireFromString(tt.quantity)) {
+				t.Errorf("order = %s of %s, want %s of %s", res.Order.Status, res.Order.Quantity, tt.status, tt.quantity)
+			}
+			if res.Order.Symbol != "BTC-USDT" {
+				t.Errorf("order symbol = %s, want the one it was placed with", res.Order.Symbol)
+			}
+			if res.Provisional || res.Streamed != tt.streamed || res.Polls != tt.polls {
+				t.Errorf("Provisional, Streamed, Polls = %v, %v, %d, want false, %v, %d", res.Provisional, res.Streamed, res.Polls, tt.streamed, tt.polls)
+			}
+			if tt.streamErr == "" && res.StreamErr != nil || tt.streamErr != "" && (res.StreamErr == nil || !strings.Contains(res.StreamErr.Error(), tt.streamErr)) {
+				t.Errorf("StreamErr = %v, want %q", res.StreamErr, tt.streamErr)
+			}
+			if tt.stream.openErr == nil && !tt.stream.closed {
+				t.Error("the stream was left open")
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package sizing decides how much quote currency an order actually spends,
+// e.g. shrinking it so that order plus fee stays within the configured amount.
+package sizing
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// Fee handling modes for strategy.feeHandling
+const (
+	FeeInclude = "include" // order the full amount; the fee is charged on top
+	FeeDeduct  = "deduct"  // shrink the order so order plus fee fits in the amount
+)
+
+// Fee rate sources recorded in Sizing
+const (
+	RateSourceExchange = "exchange"
+	RateSourceConfig   = "config"
+)
+
+// Sizing records how an order amount was derived
+type Sizing struct {
+	FeeHandling     string          `json:"feeHandling"`
+	FeeRate         decimal.Decimal `json:"feeRate"`                 // estimated taker rate, e.g. 0.001
+	FeeRateSource   string          `json:"feeRateSource,omitempty"` // "exchange" or "config"
+	RequestedAmount decimal.Decimal `json:"requestedAmount"`         // strategy quoteAmount
+	OrderAmount     decimal.Decimal `json:"orderAmount"`             // quote amount sent to the exchange
+	DebitedAmount   decimal.Decimal `json:"debitedAmount"`           // actual quote spent incl. quote fees, once known
+
+	// Sells are sized in the base asset from the requested proceeds
+	Price          decimal.Decimal `json:"price,omitzero"`          // ticker price used to convert proceeds to base
+	OrderQuantity  decimal.Decimal `json:"orderQuantity,omitzero"`  // base quantity sent to the exchange
+	ReceivedAmount decimal.Decimal `json:"receivedAmount,omitzero"` // actual quote received net of quote fees, once known
+}
+
+// DeductFee returns the largest order amount, truncated to places decimals,
+// whose cost plus a fee of rate stays within amount
+func DeductFee(amount, rate decimal.Decimal, places int32) (decimal.Decimal, error) {
+	if rate.IsNegative() || rate.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return decimal.Zero, fmt.Errorf("fee rate %s out of range", rate.String())
+	}
+	order := amount.Div(decimal.NewFromInt(1).Add(rate)).Truncate(places)
+	if !order.IsPositive() {
+		return decimal.Zero, fmt.Errorf("amount %s is too small to cover a %s fee at %d decimals", amount.String(), rate.String(), places)
+	}
+	return order, nil
+}
+
+// DebitedAmount is the quote actually spent on a fill: its cost plus the fee
+// when the fee was charged in the quote asset
+func DebitedAmount(quantity, price, fee decimal.Decimal, feeInQuote bool) decimal.Decimal {
+	debited := quantity.Mul(price)
+	if feeInQuote {
+		debited = debited.Add(fee)
+	}
+	return debited
+}
+
+// SellQuantity returns the base quantity expected to raise proceeds at
+// price, rounded down to a multiple of the exchange's lot step
+func SellQuantity(proceeds, price, step decimal.Decimal) (decimal.Decimal, error) {
+	if !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("price %s is not positive", price.String())
+	}
+	if !step.IsPositive() {
+		return decimal.Zero, fmt.Errorf("lot step %s is not positive", step.String())
+	}
+	quantity := proceeds.Div(price).Div(step).Floor().Mul(step)
+	if !quantity.IsPositive() {
+		return decimal.Zero, fmt.Errorf("proceeds %s buy less than one lot of %s at %s", proceeds.String(), step.String(), price.String())
+	}
+	return quantity, nil
+}
+
+// Percent records how a buy sized as a percentage of the free quote balance
+// was resolved
+type Percent struct {
+	Percent     money.Percent   `json:"percent"`              // e.g. 5 for 5%
+	Balance     decimal.Decimal `json:"balance"`              // free quote balance read before the order
+	Amount      decimal.Decimal `json:"amount"`               // resolved quote amount
+	Capped      bool            `json:"capped,omitempty"`     // maxQuoteAmount lowered the amount
+	MinNotional decimal.Decimal `json:"minNotional,omitzero"` // exchange minimum order value, when known
+}
+
+// PercentOfBalance takes percent of balance, truncated to places decimals
+// and capped at max unless max is zero
+func PercentOfBalance(balance decimal.Decimal, percent money.Percent, max decimal.Decimal, places int32) Percent {
+	p := Percent{Percent: percent, Balance: balance}
+	p.Amount = percent.ApplyTo(balance).Truncate(places)
+	if max.IsPositive() && p.Amount.GreaterThan(max) {
+		p.Amount, p.Capped = max, true
+	}
+	return p
+}
+
+// stableQuotes settle in cents on every supported venue
+var stableQuotes = map[string]bool{
+	"USDT": true, "USDC": true, "FDUSD": true, "BUSD": true, "TUSD": true, "DAI": true,
+	"USD": true, "EUR": true, "GBP": true, "TRY": true,
+}
+
+// QuotePlaces is the precision used for quote amounts of an asset until the
+// exchange reports the exact precision: cents for stablecoins and fiat,
+// 8 decimals otherwise
+func QuotePlaces(quote string) int32 {
+	if stableQuotes[quote] {
+		return 2
+	}
+	return 8
+}
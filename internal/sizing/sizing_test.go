@@ -0,0 +1,145 @@
+package sizing
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func TestDeductFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		rate     string
+		places   int32
+		expected string
+	}{
+		{"ten_usdt_10bps", "10", "0.001", 2, "9.99"},          // 9.99001 truncated
+		{"exactly_representable", "100.1", "0.001", 2, "100"}, // no rounding needed
+		{"truncates_not_rounds", "10", "0.0075", 2, "9.92"},   // 9.92555 must not round up to 9.93
+		{"eight_places", "0.01", "0.001", 8, "0.00999000"},
+		{"zero_rate", "25", "0", 2, "25"},
+		{"whole_units", "10", "0.001", 0, "9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DeductFee(d(tt.amount), d(tt.rate), tt.places)
+			if err != nil {
+				t.Fatalf("DeductFee() error = %v", err)
+			}
+			if !got.Equal(d(tt.expected)) {
+				t.Errorf("DeductFee() = %s, want %s", got, tt.expected)
+			}
+			// The whole point: order plus fee never exceeds the amount
+			if debit := got.Add(got.Mul(d(tt.rate))); debit.GreaterThan(d(tt.amount)) {
+				t.Errorf("debit %s exceeds amount %s", debit, tt.amount)
+			}
+		})
+	}
+}
+
+func TestDeductFee_NeverExceedsAmount(t *testing.T) {
+	rate := d("0.001")
+	for cents := int64(1); cents <= 5000; cents++ {
+		amount := decimal.New(cents, -2)
+		order, err := DeductFee(amount, rate, 2)
+		if err != nil {
+			if cents > 1 {
+				t.Fatalf("DeductFee(%s) error = %v", amount, err)
+			}
+			continue
+		}
+		if debit := order.Add(order.Mul(rate)); debit.GreaterThan(amount) {
+			t.Fatalf("DeductFee(%s) = %s, debit %s exceeds amount", amount, order, debit)
+		}
+		if order.Exponent() < -2 {
+			t.Fatalf("DeductFee(%s) = %s has more than 2 decimals", amount, order)
+		}
+	}
+}
+
+func TestDeductFee_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount string
+		rate   string
+		places int32
+	}{
+		{"negative_rate", "10", "-0.001", 2},
+		{"rate_of_one", "10", "1", 2},
+		{"too_small", "0.01", "0.001", 2}, // 0.00999 truncates to 0
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DeductFee(d(tt.amount), d(tt.rate), tt.places); err == nil {
+				t.Error("DeductFee() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestDebitedAmount(t *testing.T) {
+	if got := DebitedAmount(d("0.0002"), d("49950"), d("0.00999"), true); !got.Equal(d("9.99999")) {
+		t.Errorf("DebitedAmount(fee in quote) = %s, want 9.99999", got)
+	}
+	if got := DebitedAmount(d("0.0002"), d("49950"), d("0.00001"), false); !got.Equal(d("9.99")) {
+		t.Errorf("DebitedAmount(fee in BNB) = %s, want 9.99", got)
+	}
+}
+
+func TestQuotePlaces(t *testing.T) {
+	if QuotePlaces("USDT") != 2 || QuotePlaces("EUR") != 2 || QuotePlaces("BTC") != 8 {
+		t.Error("unexpected quote precision")
+	}
+}
+
+func TestPercentOfBalance(t *testing.T) {
+	tests := []struct {
+		balance, percent, max string
+		places                int32
+		want                  string
+		capped                bool
+	}{
+		{"1000", "5", "0", 2, "50", false},
+		{"1234.56", "2.5", "0", 2, "30.86", false}, // 30.864 truncated
+		{"1000", "5", "40", 2, "40", true},
+		{"1000", "5", "50", 2, "50", false}, // at the cap is not capped
+		{"0.5", "10", "0", 8, "0.05", false},
+		{"0", "5", "0", 2, "0", false},
+	}
+	for _, tt := range tests {
+		got := PercentOfBalance(d(tt.balance), money.NewPercent(d(tt.percent)), d(tt.max), tt.places)
+		if !got.Amount.Equal(d(tt.want)) || got.Capped != tt.capped {
+			t.Errorf("PercentOfBalance(%s, %s, %s) = %s (capped %v), want %s (capped %v)", tt.balance, tt.percent, tt.max, got.Amount, got.Capped, tt.want, tt.capped)
+		}
+	}
+}
+
+func TestSellQuantity(t *testing.T) {
+	tests := []struct {
+		proceeds, price, step string
+		want                  string
+	}{
+		{"50", "62345.67", "0.00001", "0.0008"},
+		{"50", "2500", "0.0001", "0.02"},
+		{"100", "0.3", "1", "333"},
+	}
+	for _, tt := range tests {
+		got, err := SellQuantity(d(tt.proceeds), d(tt.price), d(tt.step))
+		if err != nil || !got.Equal(d(tt.want)) {
+			t.Errorf("SellQuantity(%s, %s, %s) = %s, %v, want %s", tt.proceeds, tt.price, tt.step, got, err, tt.want)
+		}
+	}
+
+	if _, err := SellQuantity(d("0.1"), d("62345"), d("0.00001")); err == nil {
+		t.Error("SellQuantity() below one lot error = nil")
+	}
+	if _, err := SellQuantity(d("50"), decimal.Zero, d("0.00001")); err == nil {
+		t.Error("SellQuantity() with zero price error = nil")
+	}
+}
@@ -0,0 +1,286 @@
+// Package status keeps what the bot's Telegram commands read and flip
+// between runs: the result of the last run and the pause switch. Runs save
+// their result here and are skipped while the switch is on. It also keeps
+// the write-ahead intent of each symbol's live orders, when balance alerts
+// were last sent, which notifications were delivered and which strategy
+// was last approved.
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/alert"
+	"github.com/sudowanderer/dca-bot-go/internal/approval"
+	"github.com/sudowanderer/dca-bot-go/internal/intent"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+	"github.com/sudowanderer/dca-bot-go/internal/rampup"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/seal"
+)
+
+// ErrNotFound is returned by Store.Get for keys that do not exist
+var ErrNotFound = errors.New("status object not found")
+
+// Store keeps status objects by key
+type Store interface {
+	// Get reads key, returning ErrNotFound when it does not exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put writes or replaces key
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// Keys of the status objects, below the configured prefix
+const (
+	LastResultKey    = "last-result.json"
+	PauseKey         = "pause.json"
+	RampUpKey        = "ramp-up.json"
+	AlertsKey        = "alerts.json"
+	NotificationsKey = "notifications.json"
+	ApprovalKey      = "approval.json"
+)
+
+// IntentKey is the key of the order intent for symbol on an exchange
+func IntentKey(exchange, symbol string) string {
+	return "intent-" + strings.ToLower(exchange) + "-" + symbol + ".json"
+}
+
+// PauseExpiry is who a pause that ran out is recorded as ended by
+const PauseExpiry = "expiry"
+
+// Pause is the state of the pause switch
+type Pause struct {
+	Paused bool      `json:"paused"`
+	By     string    `json:"by,omitempty"` // who flipped it, e.g. "telegram chat 42"
+	At     time.Time `json:"at"`
+
+	// Until ends the pause on its own; a pause without it lasts until
+	// resumed
+	Until time.Time `json:"until,omitzero"`
+}
+
+// Active reports whether the pause skips a run at now
+func (p Pause) Active(now time.Time) bool {
+	return p.Paused && (p.Until.IsZero() || now.Before(p.Until))
+}
+
+// Expired reports whether the pause is still on but its Until has passed
+func (p Pause) Expired(now time.Time) bool {
+	return p.Paused && !p.Until.IsZero() && !now.Before(p.Until)
+}
+
+// Reason describes an active pause as a skip reason, with times in loc
+func (p Pause) Reason(loc *time.Location) string {
+	if p.Until.IsZero() {
+		return fmt.Sprintf("paused by %s since %s; send /resume to continue", p.By, p.At.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("paused until %s (set by %s on %s)", p.Until.In(loc).Format("2006-01-02 15:04 MST"), p.By, p.At.In(loc).Format("2006-01-02"))
+}
+
+// Status reads and writes the status objects of one bot
+type Status struct {
+	store  Store
+	prefix string
+}
+
+// New creates a Status keeping its objects in store under prefix
+func New(store Store, prefix string) *Status {
+	return &Status{store: store, prefix: prefix}
+}
+
+// LastResult returns the result saved by the last run, or nil before the
+// first one
+func (s *Status) LastResult(ctx context.Context) (*result.ExecutionResult, error) {
+	var res result.ExecutionResult
+	found, err := s.get(ctx, LastResultKey, &res)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SaveResult replaces the last result with res. The raw exchange response
+// is dropped; /status does not show it.
+func (s *Status) SaveResult(ctx context.Context, res *result.ExecutionResult) error {
+	return s.put(ctx, LastResultKey, res.WithoutRaw())
+}
+
+// Pause returns the pause switch; it is off until first flipped
+func (s *Status) Pause(ctx context.Context) (Pause, error) {
+	var p Pause
+	_, err := s.get(ctx, PauseKey, &p)
+	return p, err
+}
+
+// SetPaused flips the pause switch, recording who did it and when. A pause
+// set this way lasts until resumed.
+func (s *Status) SetPaused(ctx context.Context, paused bool, by string, at time.Time) error {
+	return s.put(ctx, PauseKey, Pause{Paused: paused, By: by, At: at.UTC()})
+}
+
+// PauseUntil turns the pause switch on until the given instant, recording
+// who did it and when
+func (s *Status) PauseUntil(ctx context.Context, until time.Time, by string, at time.Time) error {
+	return s.put(ctx, PauseKey, Pause{Paused: true, By: by, At: at.UTC(), Until: until.UTC()})
+}
+
+// CurrentPause returns the pause switch as it stands at now. A pause whose
+// Until has passed is switched off first, recorded as ended by
+// PauseExpiry at its Until; a failure to do so is returned with the
+// expired pause, which no longer applies either way.
+func (s *Status) CurrentPause(ctx context.Context, now time.Time) (Pause, error) {
+	p, err := s.Pause(ctx)
+	if err != nil || !p.Expired(now) {
+		return p, err
+	}
+	if err := s.SetPaused(ctx, false, PauseExpiry, p.Until); err != nil {
+		return p, fmt.Errorf("failed to clear expired pause: %w", err)
+	}
+	log.Printf("▶️ Pause set by %s ended at %s; switched it off", p.By, p.Until.Format(time.RFC3339))
+	return Pause{By: PauseExpiry, At: p.Until}, nil
+}
+
+// RampUp returns the ramp-up progress, or nil before the first run with
+// flags.rampUp
+func (s *Status) RampUp(ctx context.Context) (*rampup.State, error) {
+	var state rampup.State
+	found, err := s.get(ctx, RampUpKey, &state)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveRampUp replaces the ramp-up progress with state
+func (s *Status) SaveRampUp(ctx context.Context, state rampup.State) error {
+	return s.put(ctx, RampUpKey, state)
+}
+
+// Approval returns the approval state; it is empty before the first run
+// with flags.requireApproval
+func (s *Status) Approval(ctx context.Context) (*approval.State, error) {
+	var state approval.State
+	if _, err := s.get(ctx, ApprovalKey, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveApproval replaces the approval state with state
+func (s *Status) SaveApproval(ctx context.Context, state approval.State) error {
+	return s.put(ctx, ApprovalKey, state)
+}
+
+// Alerts returns when the balance alerts still firing were last sent; it
+// is empty before the first one
+func (s *Status) Alerts(ctx context.Context) (*alert.State, error) {
+	var state alert.State
+	if _, err := s.get(ctx, AlertsKey, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SaveAlerts replaces the alert state with state
+func (s *Status) SaveAlerts(ctx context.Context, state *alert.State) error {
+	return s.put(ctx, AlertsKey, state)
+}
+
+// Deliveries returns the notifications delivered recently; it is empty
+// before the first one
+func (s *Status) Deliveries(ctx context.Context) (*notify.Deliveries, error) {
+	var deliveries notify.Deliveries
+	if _, err := s.get(ctx, NotificationsKey, &deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries, nil
+}
+
+// SaveDeliveries replaces the delivered notifications with deliveries
+func (s *Status) SaveDeliveries(ctx context.Context, deliveries *notify.Deliveries) error {
+	return s.put(ctx, NotificationsKey, deliveries)
+}
+
+// Intent returns the last order intent written for symbol on an exchange,
+// resolved or not, or nil before the first live order
+func (s *Status) Intent(ctx context.Context, exchange, symbol string) (*intent.Intent, error) {
+	var in intent.Intent
+	found, err := s.get(ctx, IntentKey(exchange, symbol), &in)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// SaveIntent replaces the intent of in.Symbol on in.Exchange with in. It is
+// a single write, so resolving an intent never leaves it half updated.
+func (s *Status) SaveIntent(ctx context.Context, in intent.Intent) error {
+	return s.put(ctx, IntentKey(in.Exchange, in.Symbol), in)
+}
+
+func (s *Status) get(ctx context.Context, key string, v any) (bool, error) {
+	data, err := s.store.Get(ctx, s.prefix+key)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return true, nil
+}
+
+func (s *Status) put(ctx context.Context, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	if err := s.store.Put(ctx, s.prefix+key, data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return nil
+}
+
+// FileStore keeps status objects as files in a directory
+type FileStore struct {
+	dir string
+	key []byte // seals the files when set; see package seal
+}
+
+// NewFileStore creates a store in dir, which is created on first write
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// NewSealedFileStore creates a store in dir whose files are encrypted
+// under key. Plaintext files already there are still read.
+func NewSealedFileStore(dir string, key []byte) *FileStore {
+	return &FileStore{dir: dir, key: key}
+}
+
+// Get reads the file called key
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := seal.ReadFile(filepath.Join(s.dir, key), s.key)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put replaces the file called key. It writes a temporary file and renames
+// it, so a reader never sees half an object.
+func (s *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	return seal.WriteFile(filepath.Join(s.dir, key), data, s.key)
+}
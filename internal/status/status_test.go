@@ -0,0 +1,333 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/intent"
+	"github.com/sudowanderer/dca-bot-go/internal/rampup"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/seal"
+)
+
+func TestStatus_Empty(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	res, err := s.LastResult(ctx)
+	if err != nil || res != nil {
+		t.Errorf("LastResult() = %v, %v, want nil before the first run", res, err)
+	}
+	pause, err := s.Pause(ctx)
+	if err != nil || pause.Paused {
+		t.Errorf("Pause() = %+v, %v, want the switch off", pause, err)
+	}
+}
+
+func TestStatus_LastResult(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	saved := &result.ExecutionResult{
+		ExecutionID: "exec-1",
+		Status:      result.StatusExecuted,
+		Symbol:      "BTC-USDT",
+		Order:       &exchange.Order{ID: "1", Raw: []byte(`{"orderId": 1}`)},
+	}
+	if err := s.SaveResult(ctx, saved); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	res, err := s.LastResult(ctx)
+	if err != nil {
+		t.Fatalf("LastResult() error = %v", err)
+	}
+	if res.ExecutionID != "exec-1" || res.Order == nil || res.Order.ID != "1" {
+		t.Errorf("LastResult() = %+v, want the saved result", res)
+	}
+	if len(res.Order.Raw) != 0 {
+		t.Error("the raw exchange response must not be saved")
+	}
+	if saved.Order.Raw == nil {
+		t.Error("SaveResult() must not modify the run's result")
+	}
+}
+
+func TestStatus_LastResultDecimals(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	qty := decimal.RequireFromString("0.00000001")
+	price := decimal.RequireFromString("123456789.123456789")
+	if err := s.SaveResult(ctx, &result.ExecutionResult{Order: &exchange.Order{Quantity: qty, Price: price}}); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	res, err := s.LastResult(ctx)
+	if err != nil {
+		t.Fatalf("LastResult() error = %v", err)
+	}
+	if !res.Order.Quantity.Equal(qty) || !res.Order.Price.Equal(price) {
+		t.Errorf("LastResult() order = %s @ %s, want %s @ %s", res.Order.Quantity, res.Order.Price, qty, price)
+	}
+}
+
+func TestStatus_SetPaused(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	if err := s.SetPaused(ctx, true, "telegram chat 42", at); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	pause, err := s.Pause(ctx)
+	if err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if !pause.Paused || pause.By != "telegram chat 42" || !pause.At.Equal(at) || pause.At.Location() != time.UTC {
+		t.Errorf("Pause() = %+v, want paused by chat 42 at %s in UTC", pause, at)
+	}
+
+	if err := s.SetPaused(ctx, false, "telegram chat 42", at.Add(time.Hour)); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	if pause, _ := s.Pause(ctx); pause.Paused {
+		t.Error("Pause() still on after resuming")
+	}
+}
+
+func TestStatus_PauseUntil(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+	at := time.Date(2025, 6, 14, 8, 0, 0, 0, time.UTC)
+	until := at.Add(14 * 24 * time.Hour)
+
+	if err := s.PauseUntil(ctx, until, "anna", at); err != nil {
+		t.Fatalf("PauseUntil() error = %v", err)
+	}
+	// Before its end the pause applies and keeps who set it and when
+	pause, err := s.CurrentPause(ctx, until.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CurrentPause() error = %v", err)
+	}
+	if !pause.Active(until.Add(-time.Minute)) || pause.By != "anna" || !pause.At.Equal(at) || !pause.Until.Equal(until) {
+		t.Errorf("CurrentPause() = %+v, want anna's pause until %s", pause, until)
+	}
+	if got, want := pause.Reason(time.UTC), "paused until 2025-06-28 08:00 UTC (set by anna on 2025-06-14)"; got != want {
+		t.Errorf("Reason() = %q, want %q", got, want)
+	}
+
+	// From its end on it is switched off, for good
+	pause, err = s.CurrentPause(ctx, until)
+	if err != nil {
+		t.Fatalf("CurrentPause() error = %v", err)
+	}
+	if pause.Active(until) || pause.Paused {
+		t.Errorf("CurrentPause() at the end = %+v, want the switch off", pause)
+	}
+	stored, _ := s.Pause(ctx)
+	if stored.Paused || stored.By != PauseExpiry || !stored.At.Equal(until) || !stored.Until.IsZero() {
+		t.Errorf("stored pause = %+v, want it ended by expiry at %s", stored, until)
+	}
+}
+
+func TestPause_Active(t *testing.T) {
+	at := time.Date(2025, 6, 14, 8, 0, 0, 0, time.UTC)
+	until := at.Add(time.Hour)
+	tests := []struct {
+		name            string
+		pause           Pause
+		now             time.Time
+		active, expired bool
+	}{
+		{"off", Pause{}, at, false, false},
+		{"open-ended", Pause{Paused: true, At: at}, at.AddDate(1, 0, 0), true, false},
+		{"before its end", Pause{Paused: true, At: at, Until: until}, until.Add(-time.Nanosecond), true, false},
+		{"at its end", Pause{Paused: true, At: at, Until: until}, until, false, true},
+		{"resumed early", Pause{Paused: false, At: at, Until: until}, until, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pause.Active(tt.now); got != tt.active {
+				t.Errorf("Active() = %v, want %v", got, tt.active)
+			}
+			if got := tt.pause.Expired(tt.now); got != tt.expired {
+				t.Errorf("Expired() = %v, want %v", got, tt.expired)
+			}
+		})
+	}
+}
+
+func TestStatus_RampUp(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	if state, err := s.RampUp(ctx); err != nil || state != nil {
+		t.Errorf("RampUp() = %v, %v, want nil before the first run", state, err)
+	}
+	if err := s.SaveRampUp(ctx, rampup.State{Hash: "9f2c", Completed: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := s.RampUp(ctx); err != nil || state == nil || *state != (rampup.State{Hash: "9f2c", Completed: 2}) {
+		t.Errorf("RampUp() = %+v, %v, want the saved progress", state, err)
+	}
+}
+
+func TestStatus_Deliveries(t *testing.T) {
+	s := New(NewFileStore(t.TempDir()), "")
+	ctx := context.Background()
+
+	deliveries, err := s.Deliveries(ctx)
+	if err != nil || deliveries.Has("req-1/run/error/1#0") {
+		t.Errorf("Deliveries() = %+v, %v, want none before the first", deliveries, err)
+	}
+	deliveries.Add("req-1/run/error/1#0", time.Now())
+	if err := s.SaveDeliveries(ctx, deliveries); err != nil {
+		t.Fatal(err)
+	}
+	if deliveries, err := s.Deliveries(ctx); err != nil || !deliveries.Has("req-1/run/error/1#0") {
+		t.Errorf("Deliveries() = %+v, %v, want the saved delivery", deliveries, err)
+	}
+}
+
+func TestStatus_Intent(t *testing.T) {
+	store := &memoryStore{objects: map[string][]byte{}}
+	s := New(store, "")
+	ctx := context.Background()
+
+	if in, err := s.Intent(ctx, "binance", "BTC-USDT"); err != nil || in != nil {
+		t.Errorf("Intent() = %v, %v, want nil before the first order", in, err)
+	}
+	at := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC)
+	in := intent.Intent{Exchange: "binance", ExecutionID: "01K7PZ5B3Q69G5FAV", ClientOrderID: "dca-Q69G5FAV", Symbol: "BTC-USDT", Side: "buy", Amount: decimal.RequireFromString("50"), Phase: intent.PhaseOrdering, At: at}
+	if err := s.SaveIntent(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveIntent(ctx, intent.Intent{Exchange: "binance", Symbol: "ETH-USDT", Phase: intent.PhaseResolved}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Intent(ctx, "binance", "BTC-USDT")
+	if err != nil || got == nil || !got.Pending() || got.ClientOrderID != in.ClientOrderID || !got.Amount.Equal(in.Amount) || !got.At.Equal(at) {
+		t.Fatalf("Intent() = %+v, %v, want the pending BTC-USDT intent", got, err)
+	}
+
+	if err := s.SaveIntent(ctx, got.Resolved(intent.OutcomeCompleted, at.Add(time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Intent(ctx, "binance", "BTC-USDT"); err != nil || got.Pending() || got.Outcome != intent.OutcomeCompleted {
+		t.Errorf("Intent() = %+v, %v, want it resolved", got, err)
+	}
+	if _, ok := store.objects["intent-binance-BTC-USDT.json"]; !ok || len(store.objects) != 2 {
+		t.Errorf("objects = %v, want one per exchange and symbol", store.objects)
+	}
+}
+
+func TestStatus_Prefix(t *testing.T) {
+	store := &memoryStore{objects: map[string][]byte{}}
+	s := New(store, "status/")
+	if err := s.SetPaused(context.Background(), true, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.objects["status/"+PauseKey]; !ok {
+		t.Errorf("objects = %v, want %s under the prefix", store.objects, PauseKey)
+	}
+}
+
+func TestStatus_StoreErrors(t *testing.T) {
+	sentinel := errors.New("access denied")
+	s := New(&memoryStore{err: sentinel}, "")
+	ctx := context.Background()
+
+	if _, err := s.Pause(ctx); !errors.Is(err, sentinel) {
+		t.Errorf("Pause() error = %v, want %v", err, sentinel)
+	}
+	if _, err := s.LastResult(ctx); !errors.Is(err, sentinel) {
+		t.Errorf("LastResult() error = %v, want %v", err, sentinel)
+	}
+	if err := s.SetPaused(ctx, true, "test", time.Now()); !errors.Is(err, sentinel) {
+		t.Errorf("SetPaused() error = %v, want %v", err, sentinel)
+	}
+}
+
+func TestStatus_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, PauseKey), []byte("paused"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(NewFileStore(dir), "").Pause(context.Background()); err == nil {
+		t.Error("Pause() error = nil for a corrupt object")
+	}
+}
+
+func TestFileStore_CreatesDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "state")
+	store := NewFileStore(dir)
+	if err := store.Put(context.Background(), "a.json", []byte(`{}`)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	data, err := store.Get(context.Background(), "a.json")
+	if err != nil || string(data) != `{}` {
+		t.Errorf("Get() = %q, %v", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the object", len(entries))
+	}
+}
+
+func TestFileStore_Sealed(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{9}, seal.KeySize)
+	ctx := context.Background()
+	if err := os.WriteFile(filepath.Join(dir, PauseKey), []byte(`{"paused":true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(NewSealedFileStore(dir, key), "")
+	if p, err := s.Pause(ctx); err != nil || !p.Paused {
+		t.Fatalf("Pause() = %+v, %v, want the plaintext switch read as it is", p, err)
+	}
+	if err := s.SetPaused(ctx, false, "test", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, PauseKey))
+	if !seal.IsSealed(raw) {
+		t.Errorf("%s = %q, want it sealed", PauseKey, raw)
+	}
+	if p, err := s.Pause(ctx); err != nil || p.Paused {
+		t.Errorf("Pause() = %+v, %v, want the sealed switch off", p, err)
+	}
+	if _, err := New(NewFileStore(dir), "").Pause(ctx); !errors.Is(err, seal.ErrNoKey) {
+		t.Errorf("Pause() without the key error = %v, want %v", err, seal.ErrNoKey)
+	}
+}
+
+// memoryStore is a Store in memory that fails every call with err, if set
+type memoryStore struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+func (s *memoryStore) Put(ctx context.Context, key string, data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.objects[key] = data
+	return nil
+}
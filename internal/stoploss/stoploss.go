@@ -0,0 +1,118 @@
+// Package stoploss protects each buy with a stop-limit sell. The stop an
+// earlier run placed for the same symbol is replaced, so a single stop
+// covers everything the bot has bought.
+package stoploss
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/sizing"
+)
+
+// ClientOrderPrefix starts the client order ID of every stop the bot
+// places; it is how later runs recognize their predecessors' stops
+const ClientOrderPrefix = "dcasl"
+
+// Result records the protective order placed after a buy
+type Result struct {
+	Order    *exchange.Order  `json:"order,omitempty"`    // the new stop, when placed
+	Replaced []exchange.Order `json:"replaced,omitempty"` // earlier stops canceled and folded into it
+	Error    string           `json:"error,omitempty"`    // why the stop is missing or incomplete
+}
+
+// Prices returns the stop price below the fill and the limit price offset
+// further below the stop, both of the fill and rounded down to places
+func Prices(fill decimal.Decimal, below, offset money.Percent, places int32) (stop, limit decimal.Decimal, err error) {
+	stop = below.OffsetBelow(fill).Truncate(places)
+	limit = below.Add(offset).OffsetBelow(fill).Truncate(places)
+	if !limit.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("limit price for a fill at %s rounds to zero", fill.String())
+	}
+	return stop, limit, nil
+}
+
+// IsBotStop reports whether an open order is a stop placed by the bot
+func IsBotStop(order exchange.Order) bool {
+	return order.Type == exchange.OrderTypeStopLossLimit && strings.HasPrefix(order.ClientOrderID, ClientOrderPrefix+"-")
+}
+
+// Protect places a stop-limit sell below buy's fill price. The bot's open
+// stops for the symbol are canceled first and their quantity added to the
+// new stop. The returned Result is never nil; an error means the holdings
+// are not (fully) protected, and Result.Error says why. The buy itself
+// stands either way.
+func Protect(ctx context.Context, exc exchange.Exchange, cfg config.StopLossConfig, buy *exchange.Order) (*Result, error) {
+	res := &Result{}
+	fail := func(err error) (*Result, error) {
+		res.Error = err.Error()
+		return res, err
+	}
+
+	base, quote, err := exchange.SplitSymbol(buy.Symbol)
+	if err != nil {
+		return fail(err)
+	}
+	stop, limit, err := Prices(buy.Price, cfg.Below(), cfg.LimitOffset(), sizing.QuotePlaces(asset.Canonical("", quote)))
+	if err != nil {
+		return fail(err)
+	}
+	// The exchange rejects prices off its tick and quantities off its lot
+	// step; without its rules, the order goes out as computed
+	rules, err := exchange.Rules(ctx, exc, buy.Symbol)
+	if err != nil {
+		log.Printf("⚠️ Could not read the trading rules of %s: %v", buy.Symbol, err)
+	}
+	stop, limit = rules.QuantizePrice(stop), rules.QuantizePrice(limit)
+	if !limit.IsPositive() {
+		return fail(fmt.Errorf("limit price for a fill at %s rounds to zero at tick size %s", buy.Price.String(), rules.TickSize.String()))
+	}
+
+	quantity := buy.NetQuantity()
+
+	open, err := exc.OpenOrders(ctx, buy.Symbol)
+	if err != nil {
+		return fail(fmt.Errorf("failed to list open orders: %w", err))
+	}
+	var kept []string
+	for _, order := range open {
+		if !IsBotStop(order) {
+			continue
+		}
+		if err := exc.CancelOrder(ctx, order.Symbol, order.ID); err != nil {
+			// The old stop still protects its quantity; just don't fold it in
+			log.Printf("⚠️ Could not cancel previous stop %s: %v", order.ID, err)
+			kept = append(kept, order.ID)
+			continue
+		}
+		log.Printf("🛡️ Canceled previous stop %s for %s %s", order.ID, order.Quantity.String(), base)
+		res.Replaced = append(res.Replaced, order)
+		quantity = quantity.Add(order.Quantity)
+	}
+
+	quantity = rules.QuantizeQuantity(quantity)
+	if err := rules.CheckQuantity(buy.Symbol, quantity); err != nil {
+		return fail(fmt.Errorf("failed to place stop-loss: %w", err))
+	}
+	order, err := exc.PlaceStopLossOrder(ctx, buy.Symbol, quantity, stop, limit, run.ClientOrderID(ctx, ClientOrderPrefix))
+	if err != nil {
+		err = fmt.Errorf("failed to place stop-loss for %s %s: %w", quantity.String(), base, err)
+		if len(res.Replaced) > 0 {
+			err = fmt.Errorf("%w; %d previous stop(s) were already canceled, holdings are unprotected", err, len(res.Replaced))
+		}
+		return fail(err)
+	}
+	res.Order = order
+	if len(kept) > 0 {
+		res.Error = fmt.Sprintf("previous stop(s) %s could not be canceled and remain open", strings.Join(kept, ", "))
+	}
+	return res, nil
+}
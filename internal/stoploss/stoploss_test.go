@@ -0,0 +1,167 @@
+package stoploss
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func d(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+var testConfig = config.StopLossConfig{PercentBelowFill: "5", LimitOffsetPercent: "0.5"}
+
+func buyOrder() *exchange.Order {
+	return &exchange.Order{ID: "42", Symbol: "BTC-USDT", Side: "buy", Type: "market",
+		Quantity: d("0.002"), Price: d("50000.37"), FeeAmount: d("0.000002"), FeeAsset: "BTC", Status: "filled"}
+}
+
+func stopOrder(id, clientOrderID, quantity string) exchange.Order {
+	return exchange.Order{ID: id, ClientOrderID: clientOrderID, Symbol: "BTC-USDT", Side: "sell",
+		Type: exchange.OrderTypeStopLossLimit, Quantity: d(quantity), Status: "open"}
+}
+
+func TestPrices(t *testing.T) {
+	stop, limit, err := Prices(d("50000.37"), testConfig.Below(), testConfig.LimitOffset(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stop.Equal(d("47500.35")) || !limit.Equal(d("47250.34")) {
+		t.Errorf("Prices() = %s, %s; want 47500.35, 47250.34", stop, limit)
+	}
+
+	if _, _, err := Prices(d("0.01"), money.NewPercent(d("99")), money.Percent{}, 2); err == nil {
+		t.Error("expected error when the limit price rounds to zero")
+	}
+}
+
+func TestProtect_FirstStop(t *testing.T) {
+	mock := &exchange.MockExchange{}
+	ctx := run.WithID(context.Background(), "01ARYZ6S410000000000000000")
+
+	res, err := Protect(ctx, mock, testConfig, buyOrder())
+	if err != nil {
+		t.Fatalf("Protect() error = %v", err)
+	}
+	o := res.Order
+	// The fee was taken in BTC, so only the net quantity is protected,
+	// rounded down to the mock's lot step of 0.00001
+	if !o.Quantity.Equal(d("0.00199")) || !o.StopPrice.Equal(d("47500.35")) || !o.Price.Equal(d("47250.34")) {
+		t.Errorf("stop = %+v", o)
+	}
+	if o.ClientOrderID != "dcasl-00000000" || !IsBotStop(*o) {
+		t.Errorf("ClientOrderID = %s, want the dcasl prefix", o.ClientOrderID)
+	}
+	if len(res.Replaced) != 0 || len(mock.Open) != 1 {
+		t.Errorf("Replaced = %v, open = %v", res.Replaced, mock.Open)
+	}
+}
+
+func TestProtect_QuantizesToTradingRules(t *testing.T) {
+	rules := exchange.TradingRules{LotStep: d("0.001"), TickSize: d("0.5"), MinQuantity: d("0.001")}
+	mock := &exchange.MockExchange{Rules: map[string]exchange.TradingRules{"BTC-USDT": rules}}
+
+	res, err := Protect(context.Background(), mock, testConfig, buyOrder())
+	if err != nil {
+		t.Fatalf("Protect() error = %v", err)
+	}
+	if o := res.Order; !o.Quantity.Equal(d("0.001")) || !o.StopPrice.Equal(d("47500")) || !o.Price.Equal(d("47250")) {
+		t.Errorf("stop = %s @ %s / %s, want 0.001 @ 47500 / 47250", o.Quantity, o.StopPrice, o.Price)
+	}
+
+	// Below one lot once rounded, there is nothing the exchange would take
+	mock.Rules["BTC-USDT"] = exchange.TradingRules{LotStep: d("0.001"), MinQuantity: d("0.01")}
+	if _, err := Protect(context.Background(), mock, testConfig, buyOrder()); err == nil || !strings.Contains(err.Error(), "below minimum quantity 0.01") {
+		t.Errorf("Protect() error = %v, want the minimum quantity", err)
+	}
+}
+
+func TestProtect_ReplacesPreviousStop(t *testing.T) {
+	mock := &exchange.MockExchange{Open: []exchange.Order{
+		stopOrder("1", "dcasl-7Q2M4KXZ", "0.004"),
+		stopOrder("2", "manual-stop", "1"), // the user's own stop is left alone
+		{ID: "3", ClientOrderID: "dcasl-AAAAAAAA", Symbol: "ETH-USDT", Type: exchange.OrderTypeStopLossLimit, Quantity: d("1")},
+	}}
+
+	res, err := Protect(context.Background(), mock, testConfig, buyOrder())
+	if err != nil {
+		t.Fatalf("Protect() error = %v", err)
+	}
+	if len(res.Replaced) != 1 || res.Replaced[0].ID != "1" {
+		t.Fatalf("Replaced = %+v, want the previous bot stop only", res.Replaced)
+	}
+	// New stop covers this buy plus the canceled stop's quantity
+	if !res.Order.Quantity.Equal(d("0.00599")) {
+		t.Errorf("Quantity = %s, want 0.00599", res.Order.Quantity)
+	}
+
+	var ids []string
+	for _, o := range mock.Open {
+		ids = append(ids, o.ID)
+	}
+	if got := strings.Join(ids, ","); got != "2,3,"+res.Order.ID {
+		t.Errorf("open orders = %s", got)
+	}
+}
+
+// failingExchange fails the configured stop-loss calls
+type failingExchange struct {
+	exchange.MockExchange
+	failPlace  bool
+	failCancel bool
+}
+
+func (f *failingExchange) PlaceStopLossOrder(ctx context.Context, symbol string, quantity, stopPrice, limitPrice decimal.Decimal, clientOrderID string) (*exchange.Order, error) {
+	if f.failPlace {
+		return nil, errors.New("STOP_LOSS_LIMIT not allowed")
+	}
+	return f.MockExchange.PlaceStopLossOrder(ctx, symbol, quantity, stopPrice, limitPrice, clientOrderID)
+}
+
+func (f *failingExchange) CancelOrder(ctx context.Context, symbol, orderID string) error {
+	if f.failCancel {
+		return errors.New("unknown order")
+	}
+	return f.MockExchange.CancelOrder(ctx, symbol, orderID)
+}
+
+func TestProtect_PlacementFailsAfterCancel(t *testing.T) {
+	exc := &failingExchange{failPlace: true}
+	exc.Open = []exchange.Order{stopOrder("1", "dcasl-7Q2M4KXZ", "0.004")}
+
+	res, err := Protect(context.Background(), exc, testConfig, buyOrder())
+	if err == nil || !strings.Contains(err.Error(), "holdings are unprotected") {
+		t.Fatalf("Protect() error = %v, want unprotected warning", err)
+	}
+	if res == nil || res.Order != nil || res.Error == "" || len(res.Replaced) != 1 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestProtect_CancelFailsKeepsOldStop(t *testing.T) {
+	exc := &failingExchange{failCancel: true}
+	exc.Open = []exchange.Order{stopOrder("1", "dcasl-7Q2M4KXZ", "0.004")}
+
+	res, err := Protect(context.Background(), exc, testConfig, buyOrder())
+	if err != nil {
+		t.Fatalf("Protect() error = %v", err)
+	}
+	// The old stop still covers its own quantity, so the new one covers this buy only
+	if !res.Order.Quantity.Equal(d("0.00199")) || len(res.Replaced) != 0 {
+		t.Errorf("Quantity = %s, Replaced = %v", res.Order.Quantity, res.Replaced)
+	}
+	if !strings.Contains(res.Error, "1 could not be canceled") {
+		t.Errorf("Error = %q", res.Error)
+	}
+	if len(exc.Open) != 2 {
+		t.Errorf("open orders = %d, want old and new stop", len(exc.Open))
+	}
+}
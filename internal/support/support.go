@@ -0,0 +1,225 @@
+// Package support gathers what a bug report needs into one bundle that is
+// safe to attach to a public issue: the effective configuration, the build
+// and runtime, the last run's result and warnings, and which endpoints the
+// bot can reach. Credentials are redacted by the config package's
+// sanitizer, and any inline credential value left in the bundle, e.g.
+// quoted in an error, is scrubbed when it is encoded.
+package support
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+	"github.com/sudowanderer/dca-bot-go/internal/telegram"
+)
+
+// Redacted replaces inline credential values scrubbed from a bundle
+const Redacted = "[REDACTED]"
+
+// ProbeTimeout bounds each connectivity probe
+const ProbeTimeout = 5 * time.Second
+
+// Bundle is the support bundle of one payload
+type Bundle struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Build       Build     `json:"build"`
+	Runtime     string    `json:"runtime"`
+
+	// Config is the effective configuration, or ConfigError why the
+	// payload did not parse
+	Config      config.Snapshot  `json:"config,omitempty"`
+	ConfigError string           `json:"configError,omitempty"`
+	Lint        []config.Finding `json:"lint,omitempty"`
+
+	// LastResult is the result the last run saved to state.status, if
+	// asked for; LastResultError why it could not be read
+	LastResult      *result.ExecutionResult `json:"lastResult,omitempty"`
+	LastResultError string                  `json:"lastResultError,omitempty"`
+	Warnings        []run.Warning           `json:"warnings,omitempty"` // of the last run
+
+	Probes []Probe `json:"probes,omitempty"`
+
+	secrets []string
+}
+
+// New starts the bundle of a raw payload, with the build and runtime and,
+// when the payload parses, its effective configuration and lint findings.
+// The payload is returned for the caller to gather the rest with; it is
+// nil when parsing failed.
+func New(raw []byte, rt string, now time.Time) (*Bundle, *config.DCAPayload) {
+	b := &Bundle{GeneratedAt: now.UTC(), Build: ReadBuild(), Runtime: rt}
+	secrets, err := config.InlineSecrets(raw)
+	if err != nil {
+		b.ConfigError = err.Error()
+		return b, nil
+	}
+	b.secrets = secrets
+
+	payload, err := config.ParseDCAPayload(raw)
+	if err != nil {
+		b.ConfigError = err.Error()
+		return b, nil
+	}
+	if b.Config, err = payload.Effective(); err != nil {
+		b.ConfigError = err.Error()
+	}
+	b.Lint = payload.Lint()
+	return b, payload
+}
+
+// SetLastResult records the last run's result, as status.Status.LastResult
+// returned it, and its warnings
+func (b *Bundle) SetLastResult(res *result.ExecutionResult, err error) {
+	if err != nil {
+		b.LastResultError = err.Error()
+		return
+	}
+	if res == nil {
+		return
+	}
+	b.LastResult, b.Warnings = res, res.Warnings
+}
+
+// Encode renders the bundle as indented JSON with every inline credential
+// value replaced by Redacted
+func (b *Bundle) Encode() ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode support bundle: %w", err)
+	}
+	for _, secret := range b.secrets {
+		// Match the value as it appears inside JSON strings
+		quoted, _ := json.Marshal(secret)
+		data = bytes.ReplaceAll(data, quoted[1:len(quoted)-1], []byte(Redacted))
+	}
+	return data, nil
+}
+
+// Build identifies the binary
+type Build struct {
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+}
+
+// ReadBuild returns the build information embedded in the binary
+func ReadBuild() Build {
+	b := Build{GoVersion: runtime.Version(), OS: runtime.GOOS, Arch: runtime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	b.Module, b.Version = info.Main.Path, info.Main.Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Revision = s.Value
+		case "vcs.time":
+			b.Time = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+}
+
+// exchangeURLs are the API endpoints probed per exchange
+var exchangeURLs = map[string]string{
+	"binance":  exchange.BinanceBaseURL,
+	"coinbase": exchange.CoinbaseBaseURL,
+	"kraken":   exchange.KrakenBaseURL,
+	"okx":      exchange.OKXBaseURL,
+}
+
+// Endpoint is a host the bot talks to
+type Endpoint struct {
+	Name string // e.g. "exchange binance"
+	URL  string // scheme and host only
+}
+
+// Endpoints lists the hosts payload makes the bot talk to: its exchanges,
+// Telegram and the notification webhook. Paths are dropped, as URLs such
+// as webhooks can carry tokens in them.
+func Endpoints(payload *config.DCAPayload) []Endpoint {
+	var endpoints []Endpoint
+	add := func(name, raw string) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			return
+		}
+		e := Endpoint{Name: name, URL: u.Scheme + "://" + u.Host}
+		if !slices.Contains(endpoints, e) {
+			endpoints = append(endpoints, e)
+		}
+	}
+	venues := append([]config.ExchangeConfig{payload.Exchange}, payload.Failover...)
+	for _, venue := range venues {
+		name := strings.ToLower(venue.Name)
+		add("exchange "+name, exchangeURLs[name])
+	}
+	if payload.Notifications.Telegram != nil {
+		add("telegram", telegram.DefaultAPIURL)
+	}
+	if webhook := payload.Notifications.Webhook; webhook != nil {
+		add("webhook", webhook.URL)
+	}
+	return endpoints
+}
+
+// Probe is whether an endpoint answered
+type Probe struct {
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`        // any HTTP response counts
+	Status    int    `json:"status,omitempty"` // HTTP status of the response
+	LatencyMS int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ProbeAll sends a GET to each endpoint in turn. The client's timeout, if
+// any, bounds each probe.
+func ProbeAll(ctx context.Context, client *http.Client, endpoints []Endpoint) []Probe {
+	probes := make([]Probe, 0, len(endpoints))
+	for _, e := range endpoints {
+		start := time.Now()
+		status, err := probe(ctx, client, e.URL)
+		p := Probe{Name: e.Name, URL: e.URL, Reachable: err == nil, Status: status, LatencyMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			p.Error = err.Error()
+		}
+		probes = append(probes, p)
+	}
+	return probes
+}
+
+// probe returns the HTTP status of a GET of target
+func probe(ctx context.Context, client *http.Client, target string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
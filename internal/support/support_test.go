@@ -0,0 +1,129 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// secrets are the inline credential values of testPayload
+var secrets = []string{
+	"binance-api-key-1234",
+	"binance-api-secret-5678",
+	"123456:telegram-bot-token",
+	"whsec-current-abcd",
+	"https://hc-ping.com/ping-token-efgh",
+}
+
+const testPayload = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "credentials": {"type": "inline", "config": {"apiKey": "binance-api-key-1234", "apiSecret": "binance-api-secret-5678"}}},
+	"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+	"notifications": {
+		"telegram": {"type": "inline", "config": {"botToken": "123456:telegram-bot-token", "chatId": "42"}},
+		"webhook": {"url": "https://hooks.example.com/dca", "secrets": [
+			{"keyId": "2026-10", "type": "inline", "config": {"hmacSecret": "whsec-current-abcd"}},
+			{"keyId": "2026-04", "type": "env", "config": {"hmacSecretEnv": "WEBHOOK_SECRET_OLD"}}]}
+	},
+	"integrations": {"heartbeat": {"url": {"type": "inline", "config": {"url": "https://hc-ping.com/ping-token-efgh"}}}}
+}`
+
+// assertRedacted fails when any of secrets appears in the bundle
+func assertRedacted(t *testing.T, data []byte) {
+	t.Helper()
+	for _, secret := range secrets {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("bundle contains the secret %q:\n%s", secret, data)
+		}
+	}
+}
+
+func TestBundle_Redacted(t *testing.T) {
+	b, payload := New([]byte(testPayload), "local", time.Date(2026, time.October, 16, 9, 0, 0, 0, time.UTC))
+	if payload == nil {
+		t.Fatalf("New() config error = %s", b.ConfigError)
+	}
+	// Errors of the last run can quote credentials the sanitizer never saw
+	b.SetLastResult(&result.ExecutionResult{
+		ExecutionID: "exec-1",
+		Status:      result.StatusFailed,
+		Error:       "telegram: POST https://api.telegram.org/bot123456:telegram-bot-token/sendMessage failed",
+		Warnings:    []run.Warning{{Subsystem: "heartbeat", Operation: "ping", Error: `GET "https://hc-ping.com/ping-token-efgh": timeout`}},
+	}, nil)
+
+	data, err := b.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	assertRedacted(t, data)
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("bundle is not valid JSON: %v", err)
+	}
+	for _, want := range []string{`"path": "exchange.credentials.config.apiKey"`, `"WEBHOOK_SECRET_OLD"`, `"executionId": "exec-1"`, `"subsystem": "heartbeat"`, `"goVersion"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("bundle lacks %s", want)
+		}
+	}
+}
+
+func TestBundle_InvalidPayload(t *testing.T) {
+	// The error of a payload that does not parse quotes the inline URL
+	raw := strings.Replace(testPayload, "https://hc-ping.com/ping-token-efgh", "hc-ping.com/ping-token-efgh", 1)
+	b, payload := New([]byte(raw), "local", time.Now())
+	if payload != nil || !strings.Contains(b.ConfigError, "heartbeat") {
+		t.Fatalf("New() = %v, config error %q, want the invalid heartbeat URL", payload, b.ConfigError)
+	}
+	data, err := b.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if strings.Contains(string(data), "ping-token-efgh") {
+		t.Errorf("bundle contains the heartbeat URL:\n%s", data)
+	}
+
+	b, _ = New([]byte("{not json"), "local", time.Now())
+	if b.ConfigError == "" {
+		t.Error("New() accepted invalid JSON")
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	_, payload := New([]byte(testPayload), "local", time.Now())
+	var got []string
+	for _, e := range Endpoints(payload) {
+		got = append(got, e.Name+" "+e.URL)
+	}
+	want := "exchange binance https://api.binance.com, telegram https://api.telegram.org, webhook https://hooks.example.com"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Endpoints() = %v, want %s", got, want)
+	}
+}
+
+func TestProbeAll(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer up.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	probes := ProbeAll(context.Background(), &http.Client{Timeout: time.Second}, []Endpoint{{Name: "up", URL: up.URL}, {Name: "down", URL: down.URL}})
+	if len(probes) != 2 {
+		t.Fatalf("got %d probes", len(probes))
+	}
+	if p := probes[0]; !p.Reachable || p.Status != http.StatusNotFound || p.Error != "" {
+		t.Errorf("probes[0] = %+v, want reachable with any status", p)
+	}
+	if p := probes[1]; p.Reachable || p.Error == "" {
+		t.Errorf("probes[1] = %+v, want unreachable", p)
+	}
+}
@@ -0,0 +1,238 @@
+// Package taxexport turns the bot's execution history into CSV files that
+// tax tools (Koinly, CoinTracking) import directly, or into a plain ledger
+// of the buys with the market around each.
+package taxexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/market"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+)
+
+// Lot is a single acquisition of the base asset
+type Lot struct {
+	Time       time.Time // UTC
+	Exchange   string
+	OrderID    string
+	BaseAsset  string
+	QuoteAsset string
+	Quantity   decimal.Decimal // base asset received, before fees
+	Cost       decimal.Decimal // quote asset spent
+	FeeAmount  decimal.Decimal
+	FeeAsset   string // may differ from both base and quote, e.g. BNB
+
+	Price  decimal.Decimal // average fill price
+	Market *market.Context // nil unless the run recorded it
+}
+
+// ReadHistory reads execution results stored one JSON object per line
+func ReadHistory(r io.Reader) ([]result.ExecutionResult, error) {
+	var results []result.ExecutionResult
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var res result.ExecutionResult
+		if err := json.Unmarshal(text, &res); err != nil {
+			return nil, fmt.Errorf("history line %d: %w", line, err)
+		}
+		results = append(results, res)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return results, nil
+}
+
+// Lots selects the live, executed orders finished in [from, to) and converts
+// them to lots sorted by time. A zero from or to leaves that end open.
+// Dry runs, skips, failures and sales are excluded, as are orders left
+// pending settlement that a later run recovered; a partially filled order
+// is a single lot of its filled quantity.
+func Lots(results []result.ExecutionResult, from, to time.Time) ([]Lot, error) {
+	runs := make([]*result.ExecutionResult, len(results))
+	for i := range results {
+		runs[i] = &results[i]
+	}
+	var lots []Lot
+	for _, res := range result.Settled(runs) {
+		if res.DryRun || res.Status != result.StatusExecuted || res.Order == nil {
+			continue
+		}
+		if !res.Order.Quantity.IsPositive() || res.Order.Side == "sell" {
+			// DCA-out sales dispose of lots rather than acquire them
+			continue
+		}
+		at := res.FinishedAt.UTC()
+		if (!from.IsZero() && at.Before(from)) || (!to.IsZero() && !at.Before(to)) {
+			continue
+		}
+
+		base, quote, err := exchange.SplitSymbol(res.Order.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("execution %s: %w", res.ExecutionID, err)
+		}
+
+		lots = append(lots, Lot{
+			Time:       at,
+			Exchange:   res.Exchange,
+			OrderID:    res.Order.ID,
+			BaseAsset:  asset.Canonical(res.Exchange, base),
+			QuoteAsset: asset.Canonical(res.Exchange, quote),
+			Quantity:   res.Order.Quantity,
+			Cost:       res.Order.Quantity.Mul(res.Order.Price),
+			FeeAmount:  res.Order.FeeAmount,
+			FeeAsset:   feeAsset(res.Exchange, res.Order.FeeAsset),
+			Price:      res.Order.Price,
+			Market:     res.Market,
+		})
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool { return lots[i].Time.Before(lots[j].Time) })
+	return lots, nil
+}
+
+// format describes the columns of one tax tool's import file
+type format struct {
+	header []string
+	row    func(Lot) []string
+}
+
+var formats = map[string]format{
+	// Koinly universal template
+	"koinly": {
+		header: []string{"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency",
+			"Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency", "Label", "Description", "TxHash"},
+		row: func(l Lot) []string {
+			feeAmount, feeAsset := fee(l)
+			return []string{
+				l.Time.Format("2006-01-02 15:04:05") + " UTC",
+				l.Cost.String(), l.QuoteAsset,
+				l.Quantity.String(), l.BaseAsset,
+				feeAmount, feeAsset,
+				"", "", "",
+				fmt.Sprintf("DCA buy on %s, order %s", l.Exchange, l.OrderID),
+				"",
+			}
+		},
+	},
+	// CoinTracking CSV import
+	"cointracking": {
+		header: []string{"Type", "Buy Amount", "Buy Currency", "Sell Amount", "Sell Currency",
+			"Fee", "Fee Currency", "Exchange", "Trade-Group", "Comment", "Date"},
+		row: func(l Lot) []string {
+			feeAmount, feeAsset := fee(l)
+			return []string{
+				"Trade",
+				l.Quantity.String(), l.BaseAsset,
+				l.Cost.String(), l.QuoteAsset,
+				feeAmount, feeAsset,
+				exchangeLabel(l.Exchange),
+				"DCA Bot",
+				"order " + l.OrderID,
+				l.Time.Format("02.01.2006 15:04:05"),
+			}
+		},
+	},
+	// The buys with the market around each, for analysis rather than taxes
+	"ledger": {
+		header: []string{"Date", "Exchange", "Order", "Bought", "Bought Currency", "Paid", "Paid Currency", "Price",
+			"Fee Amount", "Fee Currency", "24h Change %", "7d Average", "30d Average", "Day High", "Day Low"},
+		row: func(l Lot) []string {
+			feeAmount, feeAsset := fee(l)
+			var m market.Context
+			if l.Market != nil {
+				m = *l.Market
+			}
+			return []string{
+				l.Time.Format(time.RFC3339),
+				l.Exchange, l.OrderID,
+				l.Quantity.String(), l.BaseAsset,
+				l.Cost.String(), l.QuoteAsset,
+				l.Price.String(),
+				feeAmount, feeAsset,
+				optional(m.Change24h), optional(m.Average7d), optional(m.Average30d), optional(m.DayHigh), optional(m.DayLow),
+			}
+		},
+	},
+}
+
+// Formats lists the supported export formats
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write writes lots as CSV in the given format
+func Write(w io.Writer, formatName string, lots []Lot) error {
+	f, ok := formats[formatName]
+	if !ok {
+		return fmt.Errorf("unknown export format %q (want one of %s)", formatName, strings.Join(Formats(), ", "))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(f.header); err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if err := cw.Write(f.row(lot)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// feeAsset canonicalizes a fee asset code, keeping empty codes empty
+func feeAsset(exchange, code string) string {
+	if code == "" {
+		return ""
+	}
+	return asset.Canonical(exchange, code)
+}
+
+// fee returns the fee columns, empty when no fee was charged
+func fee(l Lot) (amount, asset string) {
+	if !l.FeeAmount.IsPositive() {
+		return "", ""
+	}
+	return l.FeeAmount.String(), l.FeeAsset
+}
+
+// optional writes d, or nothing for a figure the run did not record
+func optional(d decimal.Decimal) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+// exchangeLabel returns the display name of an exchange, e.g. "Binance"
+func exchangeLabel(name string) string {
+	switch name {
+	case "okx":
+		return "OKX"
+	case "":
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,103 @@
+package taxexport
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func loadLots(t *testing.T) []Lot {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results, err := ReadHistory(f)
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+
+	// January 2025 only; the February buy is out of range
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	lots, err := Lots(results, from, to)
+	if err != nil {
+		t.Fatalf("Lots() error = %v", err)
+	}
+	return lots
+}
+
+func TestWrite_Golden(t *testing.T) {
+	lots := loadLots(t)
+
+	for _, name := range Formats() {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, name, lots); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			golden := filepath.Join("testdata", name+".csv")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), expected) {
+				t.Errorf("output differs from %s:\n%s", golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestLots(t *testing.T) {
+	lots := loadLots(t)
+
+	// Dry run, skip, failure and out-of-range records are excluded
+	var ids []string
+	for _, lot := range lots {
+		ids = append(ids, lot.OrderID)
+	}
+	if got := strings.Join(ids, ","); got != "1001,2005,1004" {
+		t.Fatalf("lot order IDs = %s, want 1001,2005,1004 (sorted by time)", got)
+	}
+
+	// Timestamps are normalized to UTC
+	if got := lots[1].Time; got.Location() != time.UTC || got.Hour() != 22 {
+		t.Errorf("partial fill time = %v, want 22:59:59 UTC", got)
+	}
+	// The partial fill is one lot of its filled quantity
+	if got := lots[1].Cost.String(); got != "13" {
+		t.Errorf("partial fill cost = %s, want 13", got)
+	}
+	// BNB fees stay in their own currency
+	if lots[2].FeeAsset != "BNB" || lots[2].BaseAsset != "ETH" || lots[2].QuoteAsset != "USDT" {
+		t.Errorf("unexpected lot %+v", lots[2])
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "turbotax", nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown export format") {
+		t.Errorf("Write() error = %v, want unknown export format", err)
+	}
+}
+
+func TestReadHistory_InvalidLine(t *testing.T) {
+	_, err := ReadHistory(strings.NewReader("{}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadHistory() error = %v, want line 2", err)
+	}
+}
@@ -0,0 +1,382 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/status"
+)
+
+// Commands the bot answers
+const (
+	CommandStatus  = "status"
+	CommandBalance = "balance"
+	CommandPause   = "pause"
+	CommandResume  = "resume"
+	CommandApprove = "approve"
+)
+
+// PollTimeout is how long a getUpdates call waits for an update
+const PollTimeout = 30 * time.Second
+
+// pollRetryDelay is the wait after a failed getUpdates call
+const pollRetryDelay = 5 * time.Second
+
+// timeFormat renders times in replies
+const timeFormat = "2006-01-02 15:04 MST"
+
+// StatusStore is the state the commands read. The pause switch is the only
+// thing a command changes.
+type StatusStore interface {
+	LastResult(ctx context.Context) (*result.ExecutionResult, error)
+	Pause(ctx context.Context) (status.Pause, error)
+	SetPaused(ctx context.Context, paused bool, by string, at time.Time) error
+	PauseUntil(ctx context.Context, until time.Time, by string, at time.Time) error
+}
+
+// MaxPause is the longest pause /pause takes a duration for; longer ones
+// are open-ended, until /resume
+const MaxPause = 366 * 24 * time.Hour
+
+// BalanceReader reads balances. It is all the bot sees of the exchange, so
+// no command can place or cancel an order.
+type BalanceReader interface {
+	GetBalanceDetail(ctx context.Context, asset string) (exchange.Balance, error)
+}
+
+// Approver approves a held strategy change by its hash
+type Approver interface {
+	Approve(ctx context.Context, hash, by string) error
+}
+
+// Sender sends replies
+type Sender interface {
+	SendMessage(ctx context.Context, chatID int64, text string) error
+}
+
+// Bot answers the commands of allowed chats
+type Bot struct {
+	Commands *config.TelegramCommandsConfig
+	Status   StatusStore
+
+	// Exchange and Symbol are what /balance reports: the base and quote
+	// balances of Symbol on the exchange Balances connects to
+	Exchange string
+	Symbol   string
+	Balances func(ctx context.Context) (BalanceReader, error)
+
+	// Approvals answers /approve; nil without flags.requireApproval
+	Approvals Approver
+
+	Format *money.Formatter // writes amounts; nil uses English and the default precisions
+	Now    func() time.Time // defaults to time.Now
+}
+
+// Reply is a message answering an update
+type Reply struct {
+	ChatID int64
+	Text   string
+}
+
+// Handle answers one update. It returns false for updates that get no
+// reply: anything but a command, and commands from chats not on the
+// allow-list, which are logged and otherwise ignored.
+func (b *Bot) Handle(ctx context.Context, u Update) (Reply, bool) {
+	if u.Message == nil {
+		return Reply{}, false
+	}
+	command, ok := parseCommand(u.Message.Text)
+	if !ok {
+		return Reply{}, false
+	}
+	chatID := u.Message.Chat.ID
+	if !b.Commands.Allowed(chatID) {
+		log.Printf("🚫 Ignoring /%s from chat %d: not in allowedChatIds", command, chatID)
+		return Reply{}, false
+	}
+
+	log.Printf("💬 /%s from chat %d", command, chatID)
+	var text string
+	var err error
+	switch command {
+	case CommandStatus:
+		text, err = b.status(ctx)
+	case CommandBalance:
+		text, err = b.balance(ctx)
+	case CommandPause:
+		text, err = b.pause(ctx, chatID, strings.Fields(u.Message.Text)[1:])
+	case CommandResume:
+		text, err = b.resume(ctx, chatID)
+	case CommandApprove:
+		text, err = b.approve(ctx, chatID, strings.Fields(u.Message.Text)[1:])
+	default:
+		text = help()
+	}
+	if err != nil {
+		log.Printf("⚠️ /%s failed: %v", command, err)
+		text = fmt.Sprintf("⚠️ /%s failed: %v", command, err)
+	}
+	return Reply{ChatID: chatID, Text: text}, true
+}
+
+// Answer handles u and sends the reply, if any
+func (b *Bot) Answer(ctx context.Context, sender Sender, u Update) error {
+	reply, ok := b.Handle(ctx, u)
+	if !ok {
+		return nil
+	}
+	if err := sender.SendMessage(ctx, reply.ChatID, reply.Text); err != nil {
+		return fmt.Errorf("failed to reply to chat %d: %w", reply.ChatID, err)
+	}
+	return nil
+}
+
+// Poll long-polls the bot's updates and answers them until ctx is done
+func (b *Bot) Poll(ctx context.Context, client *Client) error {
+	var offset int64
+	for {
+		updates, err := client.GetUpdates(ctx, offset, PollTimeout)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("⚠️ Failed to get updates, retrying in %s: %v", pollRetryDelay, err)
+			select {
+			case <-time.After(pollRetryDelay):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if err := b.Answer(ctx, client, u); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+		}
+	}
+}
+
+// parseCommand returns the command of a message such as "/status" or
+// "/status@MyDcaBot", lowercased
+func parseCommand(text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", false
+	}
+	command, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	if command == "" {
+		return "", false
+	}
+	return strings.ToLower(command), true
+}
+
+func (b *Bot) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// status describes the last run and the pause switch
+func (b *Bot) status(ctx context.Context) (string, error) {
+	res, err := b.Status.LastResult(ctx)
+	if err != nil {
+		return "", err
+	}
+	pause, err := b.Status.Pause(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	if res == nil {
+		lines = append(lines, "📊 No run recorded yet")
+	} else {
+		lines = append(lines, describeResult(b.Format, res)...)
+	}
+	lines = append(lines, describePause(pause, b.now()))
+	return strings.Join(lines, "\n"), nil
+}
+
+// describeResult renders the lines of /status about the last run
+func describeResult(f *money.Formatter, res *result.ExecutionResult) []string {
+	lines := []string{
+		fmt.Sprintf("📊 Last run: %s", res.Status),
+		fmt.Sprintf("Symbol: %s on %s", res.Symbol, res.Exchange),
+		fmt.Sprintf("Finished: %s", res.FinishedAt.UTC().Format(timeFormat)),
+	}
+	if res.DryRun {
+		lines = append(lines, "Dry run: yes")
+	}
+	if o := res.Order; o != nil {
+		base, quote, _ := exchange.SplitSymbol(o.Symbol)
+		lines = append(lines, fmt.Sprintf("Order: %s %s %s at %s (%s)", o.Side, f.Number(o.Quantity, base), o.Symbol, f.Number(o.Price, quote), o.ID))
+	}
+	if res.Skip != nil {
+		lines = append(lines, fmt.Sprintf("Skipped by %s: %s", res.Skip.Guard, res.Skip.Reason))
+	}
+	if res.Error != "" {
+		lines = append(lines, "Error: "+res.Error)
+	}
+	if res.RetryAt != nil {
+		lines = append(lines, "Retry at: "+res.RetryAt.UTC().Format(timeFormat))
+	}
+	if len(res.Warnings) > 0 {
+		lines = append(lines, fmt.Sprintf("Warnings: %d", len(res.Warnings)))
+	}
+	return lines
+}
+
+// describePause renders the pause switch as it stands at now
+func describePause(p status.Pause, now time.Time) string {
+	if !p.Active(now) {
+		return "▶️ Runs are not paused"
+	}
+	if !p.Until.IsZero() {
+		return fmt.Sprintf("⏸️ Paused by %s since %s until %s; /resume to continue early", p.By, p.At.UTC().Format(timeFormat), p.Until.UTC().Format(timeFormat))
+	}
+	return fmt.Sprintf("⏸️ Paused by %s since %s; /resume to continue", p.By, p.At.UTC().Format(timeFormat))
+}
+
+// balance reports the base and quote balances of the strategy's symbol
+func (b *Bot) balance(ctx context.Context) (string, error) {
+	base, quote, err := exchange.SplitSymbol(b.Symbol)
+	if err != nil {
+		return "", err
+	}
+	reader, err := b.Balances(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", b.Exchange, err)
+	}
+
+	lines := []string{fmt.Sprintf("💰 Balances on %s", b.Exchange)}
+	for _, code := range []string{base, quote} {
+		bal, err := reader.GetBalanceDetail(ctx, code)
+		if err != nil {
+			return "", fmt.Errorf("failed to get %s balance: %w", code, err)
+		}
+		line := fmt.Sprintf("%s: %s free", code, b.Format.Number(bal.Free, code))
+		if !bal.Locked.IsZero() {
+			line += fmt.Sprintf(", %s locked", b.Format.Number(bal.Locked, code))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// pause turns the pause switch on for chatID: until resumed, or for the
+// duration that is the only argument, as in "/pause 14d"
+func (b *Bot) pause(ctx context.Context, chatID int64, args []string) (string, error) {
+	if len(args) > 1 {
+		return "Usage: /pause, or /pause <duration> such as 14d, 2w or 36h", nil
+	}
+	var until time.Time
+	now := b.now()
+	if len(args) == 1 {
+		d, err := ParsePauseDuration(args[0])
+		if err != nil {
+			return fmt.Sprintf("%v. Usage: /pause <duration> such as 14d, 2w or 36h", err), nil
+		}
+		until = now.Add(d)
+	}
+
+	current, err := b.Status.Pause(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current.Active(now) && until.IsZero() && current.Until.IsZero() {
+		return "No change. " + describePause(current, now), nil
+	}
+	if until.IsZero() {
+		err = b.Status.SetPaused(ctx, true, chatName(chatID), now)
+	} else {
+		err = b.Status.PauseUntil(ctx, until, chatName(chatID), now)
+	}
+	if err != nil {
+		return "", err
+	}
+	if until.IsZero() {
+		log.Printf("🔀 Paused by chat %d", chatID)
+		return "⏸️ Paused. Runs are skipped until /resume.", nil
+	}
+	log.Printf("🔀 Paused by chat %d until %s", chatID, until.UTC().Format(time.RFC3339))
+	return fmt.Sprintf("⏸️ Paused until %s. Runs are skipped until then, or until /resume.", until.UTC().Format(timeFormat)), nil
+}
+
+// resume turns the pause switch off for chatID
+func (b *Bot) resume(ctx context.Context, chatID int64) (string, error) {
+	current, err := b.Status.Pause(ctx)
+	if err != nil {
+		return "", err
+	}
+	now := b.now()
+	if !current.Active(now) {
+		return "No change. " + describePause(current, now), nil
+	}
+	if err := b.Status.SetPaused(ctx, false, chatName(chatID), now); err != nil {
+		return "", err
+	}
+	log.Printf("🔀 Resumed by chat %d", chatID)
+	return "▶️ Resumed. The next run goes ahead.", nil
+}
+
+// ParsePauseDuration parses the duration of "/pause 14d": a whole number of
+// minutes, hours, days or weeks such as 90m, 36h, 14d or 2w, or any Go
+// duration such as 1h30m. It must be positive and at most MaxPause.
+func ParsePauseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if unit, ok := pauseUnits[strings.TrimLeft(s, "0123456789")]; ok && len(s) > 1 {
+		var n int
+		n, err = strconv.Atoi(s[:len(s)-1])
+		if n > int(MaxPause/unit) {
+			n = int(MaxPause/unit) + 1 // over the limit, without overflowing
+		}
+		d = time.Duration(n) * unit
+	}
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid duration %q", s)
+	case d <= 0:
+		return 0, fmt.Errorf("duration %q is not positive", s)
+	case d > MaxPause:
+		return 0, fmt.Errorf("duration %q is longer than %d days; use /pause without one", s, MaxPause/(24*time.Hour))
+	}
+	return d, nil
+}
+
+// pauseUnits are the units /pause takes beyond those of time.ParseDuration
+var pauseUnits = map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+
+// approve approves the held strategy change whose hash is the only argument
+func (b *Bot) approve(ctx context.Context, chatID int64, args []string) (string, error) {
+	if b.Approvals == nil {
+		return "Approvals are off: flags.requireApproval is not set", nil
+	}
+	if len(args) != 1 {
+		return "Usage: /approve <hash>, with the hash from the approval request", nil
+	}
+	if err := b.Approvals.Approve(ctx, args[0], chatName(chatID)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✅ Approved strategy %s. The next run goes ahead.", args[0]), nil
+}
+
+// help lists the commands
+func help() string {
+	return strings.Join([]string{
+		"/status - last run and pause state",
+		"/balance - balances of the strategy's assets",
+		"/pause [14d] - skip runs until resumed, or for a while",
+		"/resume - let runs go ahead again",
+		"/approve <hash> - approve a held strategy change",
+	}, "\n")
+}
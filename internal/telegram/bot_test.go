@@ -0,0 +1,440 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/guard"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/result"
+	"github.com/sudowanderer/dca-bot-go/internal/status"
+)
+
+// loadUpdate reads a recorded update from testdata/updates
+func loadUpdate(t *testing.T, name string) Update {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "updates", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u Update
+	if err := json.Unmarshal(data, &u); err != nil {
+		t.Fatalf("invalid update %s: %v", name, err)
+	}
+	return u
+}
+
+// fakeStatus is a StatusStore in memory that counts every change of the
+// pause switch
+type fakeStatus struct {
+	last  *result.ExecutionResult
+	pause status.Pause
+	sets  int
+	err   error
+}
+
+func (s *fakeStatus) LastResult(ctx context.Context) (*result.ExecutionResult, error) {
+	return s.last, s.err
+}
+
+func (s *fakeStatus) Pause(ctx context.Context) (status.Pause, error) {
+	return s.pause, s.err
+}
+
+func (s *fakeStatus) SetPaused(ctx context.Context, paused bool, by string, at time.Time) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sets++
+	s.pause = status.Pause{Paused: paused, By: by, At: at}
+	return nil
+}
+
+func (s *fakeStatus) PauseUntil(ctx context.Context, until time.Time, by string, at time.Time) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sets++
+	s.pause = status.Pause{Paused: true, By: by, At: at, Until: until}
+	return nil
+}
+
+// fakeBalances is a BalanceReader with fixed balances
+type fakeBalances map[string]exchange.Balance
+
+func (b fakeBalances) GetBalanceDetail(ctx context.Context, asset string) (exchange.Balance, error) {
+	bal, ok := b[asset]
+	if !ok {
+		return exchange.Balance{}, errors.New("unknown asset " + asset)
+	}
+	return bal, nil
+}
+
+var now = time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+// newTestBot returns a bot allowing chat 42 and the DCA ops group
+func newTestBot(st *fakeStatus) *Bot {
+	return &Bot{
+		Commands: &config.TelegramCommandsConfig{AllowedChatIDs: []string{"42", "-1001234567890"}},
+		Status:   st,
+		Exchange: "binance",
+		Symbol:   "BTC-USDT",
+		Balances: func(ctx context.Context) (BalanceReader, error) {
+			return fakeBalances{
+				"BTC":  exchange.NewBalance("BTC", decimal.RequireFromString("0.0123"), decimal.Zero),
+				"USDT": exchange.NewBalance("USDT", decimal.RequireFromString("12.5"), decimal.RequireFromString("200")),
+			}, nil
+		},
+		Now: func() time.Time { return now },
+	}
+}
+
+func lastRun() *result.ExecutionResult {
+	return &result.ExecutionResult{
+		Status:   result.StatusExecuted,
+		Exchange: "binance",
+		Symbol:   "BTC-USDT",
+		Order: &exchange.Order{
+			ID:       "28457",
+			Symbol:   "BTC-USDT",
+			Side:     "buy",
+			Quantity: decimal.RequireFromString("0.00041"),
+			Price:    decimal.RequireFromString("61000.12"),
+		},
+		FinishedAt: time.Date(2026, 3, 1, 9, 0, 4, 0, time.UTC),
+	}
+}
+
+func TestHandle_RecordedUpdates(t *testing.T) {
+	tests := []struct {
+		update string
+		chatID int64
+		want   []string // substrings of the reply; nil for no reply
+	}{
+		{"status", 42, []string{"📊 Last run: executed", "Symbol: BTC-USDT on binance", "Finished: 2026-03-01 09:00 UTC", "Order: buy 0.00041 BTC-USDT at 61,000.12 (28457)", "▶️ Runs are not paused"}},
+		{"status_group_mention", -1001234567890, []string{"📊 Last run: executed"}},
+		{"balance", 42, []string{"💰 Balances on binance", "BTC: 0.0123 free\n", "USDT: 12.50 free, 200.00 locked"}},
+		{"start", 42, []string{"/status", "/balance", "/pause", "/resume"}},
+		{"pause_unauthorized", 0, nil},
+		{"plain_text", 0, nil},
+		{"edited_message", 0, nil},
+		{"sticker", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.update, func(t *testing.T) {
+			st := &fakeStatus{last: lastRun()}
+			reply, ok := newTestBot(st).Handle(context.Background(), loadUpdate(t, tt.update))
+			if tt.want == nil {
+				if ok {
+					t.Errorf("Handle() replied %q, want no reply", reply.Text)
+				}
+			} else {
+				if !ok {
+					t.Fatal("Handle() gave no reply")
+				}
+				if reply.ChatID != tt.chatID {
+					t.Errorf("reply chat = %d, want %d", reply.ChatID, tt.chatID)
+				}
+				for _, want := range tt.want {
+					if !strings.Contains(reply.Text, want) {
+						t.Errorf("reply = %q, want it to contain %q", reply.Text, want)
+					}
+				}
+			}
+			if st.sets != 0 {
+				t.Errorf("%s changed the pause switch", tt.update)
+			}
+		})
+	}
+}
+
+func TestHandle_FormatsAmounts(t *testing.T) {
+	bot := newTestBot(&fakeStatus{last: lastRun()})
+	bot.Format = money.New("fr", map[string]int{"BTC": 3})
+
+	reply, _ := bot.Handle(context.Background(), loadUpdate(t, "status"))
+	if !strings.Contains(reply.Text, "Order: buy 0 BTC-USDT at 61\u202f000,12 (28457)") {
+		t.Errorf("/status = %q, want French separators and 3 BTC decimals", reply.Text)
+	}
+	reply, _ = bot.Handle(context.Background(), loadUpdate(t, "balance"))
+	if !strings.Contains(reply.Text, "BTC: 0,012 free") || !strings.Contains(reply.Text, "USDT: 12,50 free, 200,00 locked") {
+		t.Errorf("/balance = %q", reply.Text)
+	}
+}
+
+func TestHandle_PauseAndResume(t *testing.T) {
+	st := &fakeStatus{}
+	bot := newTestBot(st)
+	ctx := context.Background()
+
+	reply, ok := bot.Handle(ctx, loadUpdate(t, "pause"))
+	if !ok || !strings.Contains(reply.Text, "Paused") {
+		t.Fatalf("/pause reply = %q, %v", reply.Text, ok)
+	}
+	if !st.pause.Paused || st.pause.By != "telegram chat 42" || !st.pause.At.Equal(now) {
+		t.Errorf("pause switch = %+v, want paused by chat 42", st.pause)
+	}
+
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "status"))
+	if !strings.Contains(reply.Text, "📊 No run recorded yet") || !strings.Contains(reply.Text, "⏸️ Paused by telegram chat 42 since 2026-03-01 09:30 UTC") {
+		t.Errorf("/status while paused = %q", reply.Text)
+	}
+
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "pause"))
+	if !strings.HasPrefix(reply.Text, "No change.") || st.sets != 1 {
+		t.Errorf("second /pause = %q after %d sets, want no change", reply.Text, st.sets)
+	}
+
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "resume"))
+	if !strings.Contains(reply.Text, "Resumed") || st.pause.Paused || st.sets != 2 {
+		t.Errorf("/resume = %q, switch %+v", reply.Text, st.pause)
+	}
+}
+
+func TestHandle_PauseFor(t *testing.T) {
+	st := &fakeStatus{}
+	bot := newTestBot(st)
+	ctx := context.Background()
+
+	reply, _ := bot.Handle(ctx, loadUpdate(t, "pause_duration"))
+	until := now.Add(14 * 24 * time.Hour)
+	if reply.Text != "⏸️ Paused until 2026-03-15 09:30 UTC. Runs are skipped until then, or until /resume." {
+		t.Errorf("/pause 14d = %q", reply.Text)
+	}
+	if !st.pause.Paused || !st.pause.Until.Equal(until) || st.pause.By != "telegram chat 42" || !st.pause.At.Equal(now) {
+		t.Errorf("pause switch = %+v, want paused by chat 42 until %s", st.pause, until)
+	}
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "status"))
+	if !strings.Contains(reply.Text, "⏸️ Paused by telegram chat 42 since 2026-03-01 09:30 UTC until 2026-03-15 09:30 UTC") {
+		t.Errorf("/status while paused = %q", reply.Text)
+	}
+
+	// A bare /pause makes it open-ended; another duration replaces the end
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "pause"))
+	if !st.pause.Paused || !st.pause.Until.IsZero() || st.sets != 2 {
+		t.Errorf("/pause after /pause 14d = %q, switch %+v", reply.Text, st.pause)
+	}
+	bot.Handle(ctx, loadUpdate(t, "pause_duration"))
+	if !st.pause.Until.Equal(until) || st.sets != 3 {
+		t.Errorf("/pause 14d after /pause: switch %+v", st.pause)
+	}
+
+	// Once the end passed, the pause is over even before a run clears it
+	bot.Now = func() time.Time { return until }
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "status"))
+	if !strings.Contains(reply.Text, "▶️ Runs are not paused") {
+		t.Errorf("/status after the pause ended = %q", reply.Text)
+	}
+	reply, _ = bot.Handle(ctx, loadUpdate(t, "resume"))
+	if !strings.HasPrefix(reply.Text, "No change.") || st.sets != 3 {
+		t.Errorf("/resume after the pause ended = %q after %d sets", reply.Text, st.sets)
+	}
+
+	pause := func(text string) Update {
+		return Update{Message: &Message{Chat: Chat{ID: 42, Type: "private"}, Text: text}}
+	}
+	for _, text := range []string{"/pause soon", "/pause 2 weeks", "/pause 0d"} {
+		reply, _ := bot.Handle(ctx, pause(text))
+		if !strings.Contains(reply.Text, "Usage: /pause") || st.sets != 3 {
+			t.Errorf("%s = %q, want the usage and no change", text, reply.Text)
+		}
+	}
+}
+
+func TestParsePauseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  string
+	}{
+		{"14d", 14 * 24 * time.Hour, ""},
+		{"2w", 14 * 24 * time.Hour, ""},
+		{"36h", 36 * time.Hour, ""},
+		{"90m", 90 * time.Minute, ""},
+		{"1h30m", 90 * time.Minute, ""},
+		{"366d", MaxPause, ""},
+		{"367d", 0, "longer than 366 days"},
+		{"99999999999999999w", 0, "longer than 366 days"},
+		{"0d", 0, "not positive"},
+		{"-3h", 0, "not positive"},
+		{"-3d", 0, "invalid duration"},
+		{"1.5d", 0, "invalid duration"},
+		{"d", 0, "invalid duration"},
+		{"14", 0, "invalid duration"},
+		{"tomorrow", 0, "invalid duration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePauseDuration(tt.in)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ParsePauseDuration(%q) error = %v, want %q", tt.in, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParsePauseDuration(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// fakeApprover is an Approver that records the approvals it is asked for
+type fakeApprover struct {
+	hash, by string
+	err      error
+}
+
+func (a *fakeApprover) Approve(ctx context.Context, hash, by string) error {
+	a.hash, a.by = hash, by
+	return a.err
+}
+
+func TestHandle_Approve(t *testing.T) {
+	approve := func(text string) Update {
+		return Update{Message: &Message{Chat: Chat{ID: 42, Type: "private"}, Text: text}}
+	}
+	tests := []struct {
+		name     string
+		approver *fakeApprover
+		text     string
+		want     string
+		wantHash string
+	}{
+		{"off", nil, "/approve 3f2a9c", "Approvals are off", ""},
+		{"no hash", &fakeApprover{}, "/approve", "Usage: /approve <hash>", ""},
+		{"approved", &fakeApprover{}, "/approve 3f2a9c", "✅ Approved strategy 3f2a9c", "3f2a9c"},
+		{"refused", &fakeApprover{err: errors.New("no strategy change awaits approval")}, "/approve 3f2a9c", "⚠️ /approve failed: no strategy change awaits approval", "3f2a9c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := newTestBot(&fakeStatus{})
+			if tt.approver != nil {
+				bot.Approvals = tt.approver
+			}
+			reply, ok := bot.Handle(context.Background(), approve(tt.text))
+			if !ok || !strings.Contains(reply.Text, tt.want) {
+				t.Errorf("%s = %q, %v, want it to contain %q", tt.text, reply.Text, ok, tt.want)
+			}
+			if tt.approver == nil {
+				return
+			}
+			if tt.approver.hash != tt.wantHash {
+				t.Errorf("approved hash = %q, want %q", tt.approver.hash, tt.wantHash)
+			}
+			if tt.wantHash != "" && tt.approver.by != "telegram chat 42" {
+				t.Errorf("approved by %q, want telegram chat 42", tt.approver.by)
+			}
+		})
+	}
+}
+
+func TestHandle_UnauthorizedPauseChangesNothing(t *testing.T) {
+	st := &fakeStatus{}
+	if _, ok := newTestBot(st).Handle(context.Background(), loadUpdate(t, "pause_unauthorized")); ok {
+		t.Error("Handle() replied to a chat not on the allow-list")
+	}
+	if st.sets != 0 || st.pause.Paused {
+		t.Errorf("pause switch = %+v, want it untouched", st.pause)
+	}
+}
+
+func TestHandle_StatusDescribesSkipsAndFailures(t *testing.T) {
+	retryAt := time.Date(2026, 3, 1, 9, 15, 0, 0, time.UTC)
+	last := &result.ExecutionResult{
+		Status:     result.StatusDeferred,
+		Exchange:   "okx",
+		Symbol:     "ETH-USDT",
+		DryRun:     true,
+		Skip:       &guard.Skip{Guard: "calendar", Reason: "weekend"},
+		Error:      "exchange unavailable",
+		RetryAt:    &retryAt,
+		FinishedAt: retryAt.Add(-10 * time.Minute),
+	}
+	reply, _ := newTestBot(&fakeStatus{last: last}).Handle(context.Background(), loadUpdate(t, "status"))
+	for _, want := range []string{"Last run: deferred", "Dry run: yes", "Skipped by calendar: weekend", "Error: exchange unavailable", "Retry at: 2026-03-01 09:15 UTC"} {
+		if !strings.Contains(reply.Text, want) {
+			t.Errorf("reply = %q, want it to contain %q", reply.Text, want)
+		}
+	}
+}
+
+func TestHandle_Failures(t *testing.T) {
+	st := &fakeStatus{err: errors.New("access denied")}
+	bot := newTestBot(st)
+	for _, update := range []string{"status", "pause"} {
+		reply, ok := bot.Handle(context.Background(), loadUpdate(t, update))
+		if !ok || !strings.Contains(reply.Text, "failed: access denied") {
+			t.Errorf("%s reply = %q, want the failure", update, reply.Text)
+		}
+	}
+
+	bot = newTestBot(&fakeStatus{})
+	bot.Balances = func(ctx context.Context) (BalanceReader, error) {
+		return nil, errors.New("no credentials")
+	}
+	reply, _ := bot.Handle(context.Background(), loadUpdate(t, "balance"))
+	if !strings.Contains(reply.Text, "/balance failed: failed to connect to binance: no credentials") {
+		t.Errorf("reply = %q", reply.Text)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+		ok   bool
+	}{
+		{"/status", "status", true},
+		{"/Status@MyDcaBot", "status", true},
+		{"  /balance now", "balance", true},
+		{"status", "", false},
+		{"/", "", false},
+		{"/@MyDcaBot", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCommand(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseCommand(%q) = %q, %v, want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// recordingSender records the replies sent
+type recordingSender struct {
+	sent []Reply
+	err  error
+}
+
+func (s *recordingSender) SendMessage(ctx context.Context, chatID int64, text string) error {
+	s.sent = append(s.sent, Reply{ChatID: chatID, Text: text})
+	return s.err
+}
+
+func TestAnswer(t *testing.T) {
+	sender := &recordingSender{}
+	bot := newTestBot(&fakeStatus{})
+	for _, update := range []string{"status", "plain_text", "pause_unauthorized"} {
+		if err := bot.Answer(context.Background(), sender, loadUpdate(t, update)); err != nil {
+			t.Fatalf("Answer(%s) error = %v", update, err)
+		}
+	}
+	if len(sender.sent) != 1 || sender.sent[0].ChatID != 42 {
+		t.Errorf("sent = %+v, want one reply to chat 42", sender.sent)
+	}
+
+	sender.err = errors.New("blocked by user")
+	if err := bot.Answer(context.Background(), sender, loadUpdate(t, "status")); err == nil {
+		t.Error("Answer() error = nil, want the send failure")
+	}
+}
@@ -0,0 +1,198 @@
+// Package telegram lets the bot's owner query it from Telegram: /status
+// shows the last run, /balance the exchange balances, and /pause and
+// /resume flip the pause switch that skips runs, for good or, as with
+// "/pause 14d", for a while. Updates arrive by long
+// polling or through a webhook on the Lambda Function URL. Only chats on
+// the allow-list get answers, and no command can trade.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultAPIURL is the Telegram Bot API
+const DefaultAPIURL = "https://api.telegram.org"
+
+// SecretHeader carries the secret token configured with setWebhook
+const SecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// ErrUnauthorized is returned for webhook requests without the secret token
+var ErrUnauthorized = errors.New("telegram update rejected: invalid secret token")
+
+// Update is an incoming update. Only messages are used; other kinds, such
+// as edited messages, have a nil Message.
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+// Message is a message sent to the bot
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text,omitempty"`
+}
+
+// Chat is the chat a message was sent in
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // "private", "group", "supergroup" or "channel"
+}
+
+// User is the sender of a message
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// Client calls the Bot API methods the bot uses
+type Client struct {
+	APIURL string // defaults to DefaultAPIURL
+
+	http  *http.Client
+	token string
+}
+
+// NewClient creates a client for the bot with token. Long polls hold the
+// request open, so httpClient's timeout must exceed PollTimeout.
+func NewClient(httpClient *http.Client, token string) *Client {
+	return &Client{http: httpClient, token: token}
+}
+
+// apiResponse is the envelope of every Bot API response
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	Description string          `json:"description"`
+	ErrorCode   int             `json:"error_code"`
+}
+
+// GetUpdates waits up to timeout for updates from offset on
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := map[string]any{
+		"offset":          offset,
+		"timeout":         int(timeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}
+	var updates []Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// SendMessage sends text to chatID
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	return c.call(ctx, "sendMessage", map[string]any{"chat_id": chatID, "text": text}, nil)
+}
+
+// call invokes method with params, decoding the result into out unless it
+// is nil. Errors never include the request URL, which carries the token.
+func (c *Client) call(ctx context.Context, method string, params any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	base := c.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/bot"+c.token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: invalid request", method)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var envelope apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("%s: HTTP %d with invalid response: %w", method, resp.StatusCode, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("%s: error %d: %s", method, envelope.ErrorCode, envelope.Description)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Result, out); err != nil {
+		return fmt.Errorf("%s: invalid result: %w", method, err)
+	}
+	return nil
+}
+
+// SecretFunc returns the webhook's secret token
+type SecretFunc func(ctx context.Context) (string, error)
+
+// WebhookUpdate extracts the update from a Function URL request sent by
+// Telegram's webhook, checking its secret token. ok is false for any other
+// event, which is recognized by the missing secret header; secret is only
+// called for Telegram's requests.
+func WebhookUpdate(ctx context.Context, event json.RawMessage, secret SecretFunc) (update Update, ok bool, err error) {
+	var request events.LambdaFunctionURLRequest
+	if err := json.Unmarshal(event, &request); err != nil || request.RequestContext.HTTP.Method == "" {
+		return Update{}, false, nil
+	}
+	token, found := header(request.Headers, SecretHeader)
+	if !found {
+		return Update{}, false, nil
+	}
+	expected, err := secret(ctx)
+	if err != nil {
+		return Update{}, true, fmt.Errorf("failed to resolve webhook secret: %w", err)
+	}
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return Update{}, true, ErrUnauthorized
+	}
+
+	body := []byte(request.Body)
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(request.Body)
+		if err != nil {
+			return Update{}, true, fmt.Errorf("invalid base64 request body: %w", err)
+		}
+		body = decoded
+	}
+	if err := json.Unmarshal(body, &update); err != nil {
+		return Update{}, true, fmt.Errorf("invalid telegram update: %w", err)
+	}
+	return update, true, nil
+}
+
+// header looks name up in Function URL headers, which arrive lowercased
+func header(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// chatName describes a chat in the pause switch's history
+func chatName(id int64) string {
+	return "telegram chat " + strconv.FormatInt(id, 10)
+}
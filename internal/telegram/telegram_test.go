@@ -0,0 +1,205 @@
+package telegram
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testToken = "123456:ABC-secret-token"
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestClient_GetUpdates(t *testing.T) {
+	recorded := readTestdata(t, "get_updates.json")
+	var params map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+testToken+"/getUpdates" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		w.Write(recorded)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), testToken)
+	c.APIURL = srv.URL
+	updates, err := c.GetUpdates(context.Background(), 815730001, PollTimeout)
+	if err != nil {
+		t.Fatalf("GetUpdates() error = %v", err)
+	}
+	if len(updates) != 2 || updates[0].Message.Text != "/status" || updates[1].UpdateID != 815730003 {
+		t.Errorf("updates = %+v, want the recorded /status and /balance", updates)
+	}
+	if params["offset"] != float64(815730001) || params["timeout"] != float64(30) {
+		t.Errorf("params = %v, want offset and a 30s timeout", params)
+	}
+}
+
+func TestClient_SendMessage(t *testing.T) {
+	var params map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+testToken+"/sendMessage" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		io.WriteString(w, `{"ok": true, "result": {"message_id": 11, "chat": {"id": 42, "type": "private"}, "date": 1772355601, "text": "ok"}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), testToken)
+	c.APIURL = srv.URL
+	if err := c.SendMessage(context.Background(), 42, "▶️ Resumed"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if params["chat_id"] != float64(42) || params["text"] != "▶️ Resumed" {
+		t.Errorf("params = %v", params)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{"ok": false, "error_code": 409, "description": "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first"}`)
+	}))
+	c := NewClient(srv.Client(), testToken)
+	c.APIURL = srv.URL
+	_, err := c.GetUpdates(context.Background(), 0, PollTimeout)
+	if err == nil || !strings.Contains(err.Error(), "error 409: Conflict") {
+		t.Errorf("GetUpdates() error = %v, want the API error", err)
+	}
+	srv.Close()
+
+	// A transport failure must not leak the token through the request URL
+	_, err = c.GetUpdates(context.Background(), 0, PollTimeout)
+	if err == nil {
+		t.Fatal("GetUpdates() error = nil against a closed server")
+	}
+	if strings.Contains(err.Error(), testToken) {
+		t.Errorf("error %q contains the bot token", err)
+	}
+}
+
+// staticSecret returns a SecretFunc for value
+func staticSecret(value string) SecretFunc {
+	return func(ctx context.Context) (string, error) { return value, nil }
+}
+
+func TestWebhookUpdate(t *testing.T) {
+	event := readTestdata(t, "webhook_pause.json")
+
+	update, ok, err := WebhookUpdate(context.Background(), event, staticSecret("s3cret-token"))
+	if err != nil || !ok {
+		t.Fatalf("WebhookUpdate() = %v, %v", ok, err)
+	}
+	if update.UpdateID != 815730004 || update.Message.Text != "/pause" || update.Message.Chat.ID != 42 {
+		t.Errorf("update = %+v, want the recorded /pause", update)
+	}
+
+	if _, ok, err := WebhookUpdate(context.Background(), event, staticSecret("wrong")); !ok || !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("wrong secret: ok = %v, error = %v, want ErrUnauthorized", ok, err)
+	}
+	if _, ok, err := WebhookUpdate(context.Background(), event, staticSecret("")); !ok || !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("no configured secret: ok = %v, error = %v, want ErrUnauthorized", ok, err)
+	}
+	failing := func(ctx context.Context) (string, error) { return "", errors.New("parameter not found") }
+	if _, ok, err := WebhookUpdate(context.Background(), event, failing); !ok || err == nil || errors.Is(err, ErrUnauthorized) {
+		t.Errorf("unresolvable secret: ok = %v, error = %v, want the resolve error", ok, err)
+	}
+}
+
+func TestWebhookUpdate_Base64Body(t *testing.T) {
+	var request map[string]any
+	json.Unmarshal(readTestdata(t, "webhook_pause.json"), &request)
+	request["body"] = base64.StdEncoding.EncodeToString([]byte(request["body"].(string)))
+	request["isBase64Encoded"] = true
+	event, _ := json.Marshal(request)
+
+	update, ok, err := WebhookUpdate(context.Background(), event, staticSecret("s3cret-token"))
+	if err != nil || !ok || update.Message == nil || update.Message.Text != "/pause" {
+		t.Errorf("WebhookUpdate() = %+v, %v, %v", update, ok, err)
+	}
+}
+
+func TestWebhookUpdate_OtherEvents(t *testing.T) {
+	var request map[string]any
+	json.Unmarshal(readTestdata(t, "webhook_pause.json"), &request)
+	delete(request["headers"].(map[string]any), "x-telegram-bot-api-secret-token")
+	tradingView, _ := json.Marshal(request)
+
+	// Other events never need the secret, so a missing one cannot fail runs
+	noSecret := func(ctx context.Context) (string, error) {
+		t.Error("secret resolved for an event that is not a Telegram update")
+		return "", errors.New("webhookSecret is required")
+	}
+	for name, event := range map[string]string{
+		"payload":            `{"version": "v2", "exchange": {"name": "binance"}}`,
+		"eventbridge":        `{"detail-type": "Scheduled Event", "source": "aws.events", "detail": {}}`,
+		"function url alert": string(tradingView),
+	} {
+		if _, ok, err := WebhookUpdate(context.Background(), json.RawMessage(event), noSecret); ok || err != nil {
+			t.Errorf("%s: ok = %v, error = %v, want it passed over", name, ok, err)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	recorded := readTestdata(t, "get_updates.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var offsets []float64
+	var replies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]any
+		json.NewDecoder(r.Body).Decode(&params)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getUpdates"):
+			offsets = append(offsets, params["offset"].(float64))
+			if len(offsets) == 1 {
+				w.Write(recorded)
+				return
+			}
+			cancel() // the second poll ends the test
+			io.WriteString(w, `{"ok": true, "result": []}`)
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			replies = append(replies, params["text"].(string))
+			io.WriteString(w, `{"ok": true, "result": {}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.Client(), testToken)
+	c.APIURL = srv.URL
+	if err := newTestBot(&fakeStatus{}).Poll(ctx, c); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(offsets) != 2 || offsets[0] != 0 || offsets[1] != 815730004 {
+		t.Errorf("offsets = %v, want 0 then past the last update", offsets)
+	}
+	if len(replies) != 2 || !strings.HasPrefix(replies[0], "📊") || !strings.HasPrefix(replies[1], "💰") {
+		t.Errorf("replies = %q, want /status then /balance answered", replies)
+	}
+}
@@ -0,0 +1,42 @@
+package threshold
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+)
+
+// Holdings returns the holdings event of strategy.holdingsAlert when
+// balance of code exceeds limit. Without withdrawal configured the event
+// points at strategy.withdrawal, which moves bought coins out for good.
+// Amounts are written by the formatter in ctx; ok is false while the
+// balance is within limit.
+func Holdings(ctx context.Context, symbol, code string, balance, limit decimal.Decimal, withdrawal bool) (event notify.Event, ok bool) {
+	if !balance.GreaterThan(limit) {
+		return notify.Event{}, false
+	}
+	f := money.FromContext(ctx)
+	code = asset.Canonical("", code)
+	event = notify.Event{
+		Type:    notify.EventHoldings,
+		Symbol:  symbol,
+		Summary: fmt.Sprintf("🏦 %s accumulated, above the %s alert", f.Amount(balance, code), f.Amount(limit, code)),
+		Details: []notify.Detail{
+			{Label: "Asset", Value: code},
+			{Label: "Balance", Value: f.Amount(balance, code)},
+			{Label: "Threshold", Value: f.Amount(limit, code)},
+			{Label: "Symbol", Value: symbol},
+		},
+	}
+	if !withdrawal {
+		event.Details = append(event.Details, notify.Detail{
+			Label: "Tip",
+			Value: "set strategy.withdrawal to have bought coins sent to your own wallet",
+		})
+	}
+	return event, true
+}
@@ -0,0 +1,85 @@
+package threshold
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ProjectionMaxRuns bounds how many scheduled runs Project looks ahead
+const ProjectionMaxRuns = 1000
+
+// Why a projected run fails
+const (
+	ShortNow       = "now"       // the balance is already below the threshold
+	ShortThreshold = "threshold" // the run leaves the balance below the threshold
+	ShortFunds     = "funds"     // the balance cannot pay for the run
+)
+
+// Projection is how long the free balance lasts at the current pace
+type Projection struct {
+	// At is the first run that fails and Runs the number of runs before it
+	// that go through. When Beyond is set no run failed and At is the last
+	// run projected.
+	At     time.Time
+	Runs   int
+	Reason string // ShortNow, ShortThreshold or ShortFunds; empty when Beyond
+	Beyond bool
+}
+
+// Project steps the check's free balance through the scheduled runs,
+// spending PerRun at each, and returns the first run that cannot be paid
+// for or leaves the balance's value below the threshold; a balance already
+// below the threshold fails at the first run with ShortNow. ok is false
+// when PerRun is not positive or there are no runs, since nothing then
+// runs short.
+func Project(c Check, runs []time.Time) (p Projection, ok bool) {
+	if !c.PerRun.IsPositive() || len(runs) == 0 {
+		return Projection{}, false
+	}
+	balance := c.Balance.Free
+	if value(balance, c.Rate).LessThan(c.Threshold) {
+		return Projection{At: runs[0], Reason: ShortNow}, true
+	}
+	for i, at := range runs {
+		if balance.LessThan(c.PerRun) {
+			return Projection{At: at, Runs: i, Reason: ShortFunds}, true
+		}
+		balance = balance.Sub(c.PerRun)
+		if value(balance, c.Rate).LessThan(c.Threshold) {
+			return Projection{At: at, Runs: i, Reason: ShortThreshold}, true
+		}
+	}
+	return Projection{At: runs[len(runs)-1], Runs: len(runs), Beyond: true}, true
+}
+
+// value is balance in the threshold's currency; a zero rate is taken as a
+// threshold in the balance's own asset
+func value(balance, rate decimal.Decimal) decimal.Decimal {
+	if rate.IsZero() {
+		return balance
+	}
+	return balance.Mul(rate)
+}
+
+// Describe describes the projection as of now, e.g. "at this pace, funds
+// last until ~July 14 (below threshold)". The year is given when it is
+// not now's.
+func (p Projection) Describe(now time.Time) string {
+	layout := "January 2"
+	if p.At.Year() != now.Year() {
+		layout = "January 2, 2006"
+	}
+	date := p.At.Format(layout)
+	switch {
+	case p.Reason == ShortNow:
+		return "the balance is already below the threshold"
+	case p.Beyond:
+		return fmt.Sprintf("at this pace, funds last beyond ~%s", date)
+	case p.Reason == ShortFunds:
+		return fmt.Sprintf("at this pace, funds last until ~%s (not enough for that run)", date)
+	default:
+		return fmt.Sprintf("at this pace, funds last until ~%s (below threshold after that run)", date)
+	}
+}
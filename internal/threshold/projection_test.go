@@ -0,0 +1,120 @@
+package threshold
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+)
+
+// weekly returns n Mondays from June 1, 2026
+func weekly(n int) []time.Time {
+	runs := make([]time.Time, n)
+	for i := range runs {
+		runs[i] = time.Date(2026, time.June, 1+7*i, 9, 0, 0, 0, time.UTC)
+	}
+	return runs
+}
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name      string
+		free      string
+		perRun    string
+		threshold string
+		rate      string
+		runs      int
+		want      Projection
+		wantOK    bool
+	}{
+		{
+			name: "funds run out", free: "100", perRun: "25", threshold: "0", runs: 10,
+			want: Projection{At: weekly(5)[4], Runs: 4, Reason: ShortFunds}, wantOK: true,
+		},
+		{
+			name: "threshold crossed first", free: "100", perRun: "25", threshold: "30", runs: 10,
+			want: Projection{At: weekly(3)[2], Runs: 2, Reason: ShortThreshold}, wantOK: true,
+		},
+		{
+			name: "threshold reached exactly", free: "100", perRun: "25", threshold: "50", runs: 10,
+			want: Projection{At: weekly(3)[2], Runs: 2, Reason: ShortThreshold}, wantOK: true,
+		},
+		{
+			name: "remainder short of a run", free: "110", perRun: "25", threshold: "0", runs: 10,
+			want: Projection{At: weekly(5)[4], Runs: 4, Reason: ShortFunds}, wantOK: true,
+		},
+		{
+			name: "next run unaffordable", free: "20", perRun: "25", threshold: "0", runs: 10,
+			want: Projection{At: weekly(1)[0], Runs: 0, Reason: ShortFunds}, wantOK: true,
+		},
+		{
+			name: "next run crosses the threshold", free: "60", perRun: "25", threshold: "50", runs: 10,
+			want: Projection{At: weekly(1)[0], Runs: 0, Reason: ShortThreshold}, wantOK: true,
+		},
+		{
+			name: "already below threshold", free: "40", perRun: "25", threshold: "50", runs: 10,
+			want: Projection{At: weekly(1)[0], Reason: ShortNow}, wantOK: true,
+		},
+		{
+			name: "empty balance", free: "0", perRun: "25", threshold: "0", runs: 10,
+			want: Projection{At: weekly(1)[0], Runs: 0, Reason: ShortFunds}, wantOK: true,
+		},
+		{
+			name: "empty balance below threshold", free: "0", perRun: "25", threshold: "10", runs: 10,
+			want: Projection{At: weekly(1)[0], Reason: ShortNow}, wantOK: true,
+		},
+		{
+			name: "lasts beyond the horizon", free: "1000", perRun: "25", threshold: "0", runs: 5,
+			want: Projection{At: weekly(5)[4], Runs: 5, Beyond: true}, wantOK: true,
+		},
+		{
+			name: "last projected run empties it", free: "125", perRun: "25", threshold: "0", runs: 5,
+			want: Projection{At: weekly(5)[4], Runs: 5, Beyond: true}, wantOK: true,
+		},
+		{
+			name: "usd threshold", free: "0.01", perRun: "0.002", threshold: "250", rate: "50000", runs: 10,
+			want: Projection{At: weekly(3)[2], Runs: 2, Reason: ShortThreshold}, wantOK: true,
+		},
+		{name: "zero amount", free: "100", perRun: "0", threshold: "50", runs: 10},
+		{name: "zero amount below threshold", free: "10", perRun: "0", threshold: "50", runs: 10},
+		{name: "negative amount", free: "100", perRun: "-5", threshold: "0", runs: 10},
+		{name: "no runs", free: "100", perRun: "25", threshold: "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := Check{
+				Symbol:    "BTC-USDT",
+				Balance:   exchange.NewBalance("USDT", d(tt.free), decimal.Zero),
+				PerRun:    d(tt.perRun),
+				Threshold: d(tt.threshold),
+			}
+			if tt.rate != "" {
+				check.Rate = d(tt.rate)
+			}
+			got, ok := Project(check, weekly(tt.runs))
+			if ok != tt.wantOK || !got.At.Equal(tt.want.At) || got.Runs != tt.want.Runs || got.Reason != tt.want.Reason || got.Beyond != tt.want.Beyond {
+				t.Errorf("Project() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProjection_Describe(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	july := time.Date(2026, time.July, 14, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		p    Projection
+		want string
+	}{
+		{Projection{At: july, Runs: 6, Reason: ShortFunds}, "at this pace, funds last until ~July 14 (not enough for that run)"},
+		{Projection{At: july, Runs: 6, Reason: ShortThreshold}, "at this pace, funds last until ~July 14 (below threshold after that run)"},
+		{Projection{At: july, Reason: ShortNow}, "the balance is already below the threshold"},
+		{Projection{At: july.AddDate(1, 0, 0), Runs: 58, Beyond: true}, "at this pace, funds last beyond ~July 14, 2027"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Describe(now); got != tt.want {
+			t.Errorf("Describe() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package threshold
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+// Reserve records the part of the balance committed to open orders of the
+// check's symbol: the quote cost of buy orders, or the base quantity of
+// sell orders for a selling strategy. An open order's Quantity is its size;
+// market orders without a price reserve nothing that can be counted.
+func (c *Check) Reserve(orders []exchange.Order) {
+	c.Reserved = decimal.Zero
+	c.ReservedOrders = 0
+	for _, o := range orders {
+		var amount decimal.Decimal
+		switch {
+		case c.Selling && o.Side == "sell":
+			amount = o.Quantity
+		case !c.Selling && o.Side == "buy":
+			amount = o.Quantity.Mul(o.Price)
+		default:
+			continue
+		}
+		c.Reserved = c.Reserved.Add(amount)
+		c.ReservedOrders++
+	}
+}
+
+// RunsLeft estimates how many more runs the free balance pays for at
+// PerRun each; open orders are not counted since they may never fill. ok is
+// false when PerRun is unknown.
+func (c Check) RunsLeft() (n int, ok bool) {
+	if !c.PerRun.IsPositive() {
+		return 0, false
+	}
+	if !c.Balance.Free.IsPositive() {
+		return 0, true
+	}
+	return int(c.Balance.Free.Div(c.PerRun).IntPart()), true
+}
+
+// Runway describes the free and reserved balance and the runs left, e.g.
+// "120.00 USDT free, 60.00 USDT in 2 open orders — about 4 more buys".
+// It is empty when nothing is known beyond the balance itself.
+func (c Check) Runway(f *money.Formatter) string {
+	n, ok := c.RunsLeft()
+	if !ok && c.ReservedOrders == 0 {
+		return ""
+	}
+
+	code := asset.Canonical("", c.Balance.Asset)
+	s := f.Amount(c.Balance.Free, code) + " free"
+	if c.ReservedOrders > 0 {
+		s += fmt.Sprintf(", %s in %s", f.Amount(c.Reserved, code), plural(c.ReservedOrders, "open order"))
+	}
+	if !ok {
+		return s
+	}
+
+	run := "buy"
+	if c.Selling {
+		run = "sell"
+	}
+	switch {
+	case n == 1:
+		return s + fmt.Sprintf(" — about 1 more %s", run)
+	case n > 1:
+		return s + fmt.Sprintf(" — about %d more %ss", n, run)
+	case c.ReservedOrders > 0:
+		return s + fmt.Sprintf(" — no more %ss until the open orders fill or are canceled", run)
+	default:
+		return s + fmt.Sprintf(" — not enough for another %s", run)
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,136 @@
+package threshold
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+)
+
+func TestReserve(t *testing.T) {
+	orders := []exchange.Order{
+		{ID: "1", Side: "buy", Type: "limit", Quantity: d("0.001"), Price: d("30000")},
+		{ID: "2", Side: "buy", Type: "limit", Quantity: d("0.002"), Price: d("15000")},
+		{ID: "3", Side: "sell", Type: "limit", Quantity: d("0.01"), Price: d("25000"), StopPrice: d("26000")}, // a stop-loss
+	}
+
+	buying := Check{Symbol: "BTC-USDT"}
+	buying.Reserve(orders)
+	if !buying.Reserved.Equal(d("60")) || buying.ReservedOrders != 2 {
+		t.Errorf("buying Reserve() = %s in %d orders, want 60 in 2", buying.Reserved, buying.ReservedOrders)
+	}
+
+	selling := Check{Symbol: "BTC-USDT", Selling: true}
+	selling.Reserve(orders)
+	if !selling.Reserved.Equal(d("0.01")) || selling.ReservedOrders != 1 {
+		t.Errorf("selling Reserve() = %s in %d orders, want 0.01 in 1", selling.Reserved, selling.ReservedOrders)
+	}
+
+	buying.Reserve(nil)
+	if !buying.Reserved.IsZero() || buying.ReservedOrders != 0 {
+		t.Errorf("Reserve(nil) = %s in %d orders, want none", buying.Reserved, buying.ReservedOrders)
+	}
+}
+
+func TestRunway(t *testing.T) {
+	open := []exchange.Order{
+		{Side: "buy", Quantity: d("0.001"), Price: d("30000")},
+		{Side: "buy", Quantity: d("0.002"), Price: d("15000")},
+	}
+	tests := []struct {
+		name     string
+		free     string
+		locked   string
+		perRun   string
+		orders   []exchange.Order
+		selling  bool
+		wantRuns int
+		wantOK   bool
+		want     string
+	}{
+		{
+			name: "no open orders", free: "120", perRun: "25",
+			wantRuns: 4, wantOK: true,
+			want: "120.00 USDT free — about 4 more buys",
+		},
+		{
+			name: "some reserved", free: "120", locked: "60", perRun: "25", orders: open,
+			wantRuns: 4, wantOK: true,
+			want: "120.00 USDT free, 60.00 USDT in 2 open orders — about 4 more buys",
+		},
+		{
+			name: "all reserved", free: "0", locked: "60", perRun: "25", orders: open,
+			wantRuns: 0, wantOK: true,
+			want: "0.00 USDT free, 60.00 USDT in 2 open orders — no more buys until the open orders fill or are canceled",
+		},
+		{
+			name: "one run left", free: "30", perRun: "25",
+			wantRuns: 1, wantOK: true,
+			want: "30.00 USDT free — about 1 more buy",
+		},
+		{
+			name: "short of one run", free: "20", perRun: "25",
+			wantRuns: 0, wantOK: true,
+			want: "20.00 USDT free — not enough for another buy",
+		},
+		{
+			name: "unknown run size", free: "120", locked: "60", orders: open[:1],
+			want: "120.00 USDT free, 30.00 USDT in 1 open order",
+		},
+		{
+			name: "unknown run size without orders", free: "120",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked := decimal.Zero
+			if tt.locked != "" {
+				locked = d(tt.locked)
+			}
+			check := Check{Symbol: "BTC-USDT", Balance: exchange.NewBalance("USDT", d(tt.free), locked), Selling: tt.selling}
+			if tt.perRun != "" {
+				check.PerRun = d(tt.perRun)
+			}
+			check.Reserve(tt.orders)
+
+			n, ok := check.RunsLeft()
+			if n != tt.wantRuns || ok != tt.wantOK {
+				t.Errorf("RunsLeft() = %d, %v, want %d, %v", n, ok, tt.wantRuns, tt.wantOK)
+			}
+			if got := check.Runway(nil); got != tt.want {
+				t.Errorf("Runway() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunway_Selling(t *testing.T) {
+	check := Check{Symbol: "BTC-USDT", Balance: exchange.NewBalance("BTC", d("0.0025"), decimal.Zero), PerRun: d("0.001"), Selling: true}
+	if got, want := check.Runway(nil), "0.0025 BTC free — about 2 more sells"; got != want {
+		t.Errorf("Runway() = %q, want %q", got, want)
+	}
+}
+
+func TestAggregate_Runway(t *testing.T) {
+	check := Check{
+		Symbol:    "BTC-USDT",
+		Balance:   exchange.NewBalance("USDT", d("120"), d("60")),
+		Value:     d("120"),
+		Rate:      d("1"),
+		Threshold: d("200"),
+		Currency:  "USDT",
+		Low:       true,
+		PerRun:    d("25"),
+	}
+	check.Reserve([]exchange.Order{{Side: "buy", Quantity: d("0.002"), Price: d("30000")}})
+
+	ctx := money.WithFormatter(context.Background(), money.New("en", nil))
+	event, _ := Aggregate(ctx, []Check{check})
+	last := event.Details[len(event.Details)-1]
+	if last.Label != "Runway" || last.Value != "120.00 USDT free, 60.00 USDT in 1 open order — about 4 more buys" {
+		t.Errorf("last detail = %+v, want the runway", last)
+	}
+}
@@ -0,0 +1,224 @@
+// Package threshold evaluates low-balance thresholds, converting balances to
+// USD through exchange prices when the threshold is set in USD rather than
+// in the strategy's quote asset.
+package threshold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/asset"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+)
+
+// Where a conversion rate came from
+const (
+	RateNone   = "none"   // threshold is in the balance's own asset
+	RatePeg    = "peg"    // USD stablecoin counted 1:1
+	RateMarket = "market" // last traded price
+)
+
+// usdPegged lists stablecoins that are counted as 1 USD while they trade
+// within the peg tolerance
+var usdPegged = map[string]bool{
+	"USDT": true, "USDC": true, "FDUSD": true, "BUSD": true, "TUSD": true, "DAI": true, "USDP": true,
+}
+
+// bridges are the quotes tried, in order, to price assets without a USD pair
+var bridges = []string{"USD", "USDT", "USDC"}
+
+// Converter prices assets in USD
+type Converter struct {
+	Prices exchange.PriceTicker
+
+	// PegTolerance is how far a stablecoin may trade from 1 USD and still be
+	// counted 1:1
+	PegTolerance decimal.Decimal
+}
+
+// Rate returns the USD value of one unit of code and where it came from
+func (c *Converter) Rate(ctx context.Context, code string) (decimal.Decimal, string, error) {
+	code = asset.Canonical("", code)
+	if code == config.ThresholdCurrencyUSD {
+		return decimal.NewFromInt(1), RateNone, nil
+	}
+	if usdPegged[code] {
+		return c.pegRate(ctx, code)
+	}
+
+	var checked []string
+	for _, bridge := range bridges {
+		if bridge == code {
+			continue
+		}
+		// Bridges other than USD are stablecoins, counted 1:1
+		rate, ok, err := c.pairRate(ctx, code, bridge)
+		if err != nil {
+			return decimal.Zero, "", err
+		}
+		if ok {
+			return rate, RateMarket, nil
+		}
+		checked = append(checked, code+"-"+bridge, bridge+"-"+code)
+	}
+	return decimal.Zero, "", fmt.Errorf("no USD price for %s; checked %s", code, strings.Join(checked, ", "))
+}
+
+// pegRate is 1 for a stablecoin trading within tolerance of 1 USD or
+// without a USD market, and its market rate once it has depegged
+func (c *Converter) pegRate(ctx context.Context, code string) (decimal.Decimal, string, error) {
+	one := decimal.NewFromInt(1)
+	rate, ok, err := c.pairRate(ctx, code, config.ThresholdCurrencyUSD)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	if !ok || rate.Sub(one).Abs().LessThanOrEqual(c.PegTolerance) {
+		return one, RatePeg, nil
+	}
+	log.Printf("⚠️ %s trades at %s USD, outside the %s peg tolerance; using the market rate", code, rate.String(), c.PegTolerance.String())
+	return rate, RateMarket, nil
+}
+
+// pairRate prices one unit of code in quote from CODE-QUOTE or, failing
+// that, the inverse of QUOTE-CODE. ok is false when neither is listed.
+func (c *Converter) pairRate(ctx context.Context, code, quote string) (decimal.Decimal, bool, error) {
+	price, ok, err := c.lastPrice(ctx, code+"-"+quote)
+	if err != nil || ok {
+		return price, ok, err
+	}
+	price, ok, err = c.lastPrice(ctx, quote+"-"+code)
+	if err != nil || !ok {
+		return decimal.Zero, false, err
+	}
+	return decimal.NewFromInt(1).DivRound(price, 16), true, nil
+}
+
+func (c *Converter) lastPrice(ctx context.Context, symbol string) (decimal.Decimal, bool, error) {
+	price, err := c.Prices.LastPrice(ctx, symbol)
+	if errors.Is(err, exchange.ErrSymbolNotFound) {
+		return decimal.Zero, false, nil
+	}
+	if err != nil {
+		return decimal.Zero, false, fmt.Errorf("failed to get %s price: %w", symbol, err)
+	}
+	if !price.IsPositive() {
+		return decimal.Zero, false, nil
+	}
+	return price, true, nil
+}
+
+// Check is the outcome of comparing one balance against its threshold
+type Check struct {
+	Symbol     string           `json:"symbol"`
+	Balance    exchange.Balance `json:"balance"`
+	Value      decimal.Decimal  `json:"value"` // free balance in Currency
+	Rate       decimal.Decimal  `json:"rate"`  // Currency per unit of the balance's asset
+	RateSource string           `json:"rateSource"`
+	Threshold  decimal.Decimal  `json:"threshold"`
+	Currency   string           `json:"currency"`
+	Low        bool             `json:"low"`
+	Selling    bool             `json:"selling,omitempty"` // the balance is a DCA-out strategy's base asset
+
+	// Runway of the balance, in its own asset; see Reserve and RunsLeft
+	PerRun         decimal.Decimal `json:"perRun,omitzero"`          // spent (or sold) by one run
+	Reserved       decimal.Decimal `json:"reserved,omitzero"`        // committed to open orders of Symbol
+	ReservedOrders int             `json:"reservedOrders,omitempty"` // number of those orders
+}
+
+// Evaluate compares the free part of balance against t. A threshold without
+// a currency is in the balance's own asset; a USD threshold needs conv.
+func Evaluate(ctx context.Context, conv *Converter, symbol string, balance exchange.Balance, t config.Threshold) (Check, error) {
+	amount, err := decimal.NewFromString(t.Amount)
+	if err != nil {
+		return Check{}, fmt.Errorf("invalid balance threshold: %w", err)
+	}
+	check := Check{
+		Symbol:     symbol,
+		Balance:    balance,
+		Value:      balance.Free,
+		Rate:       decimal.NewFromInt(1),
+		RateSource: RateNone,
+		Threshold:  amount,
+		Currency:   asset.Canonical("", balance.Asset),
+	}
+
+	if t.Currency != "" {
+		if conv == nil {
+			return Check{}, fmt.Errorf("a %s threshold needs an exchange that reports prices", t.Currency)
+		}
+		check.Rate, check.RateSource, err = conv.Rate(ctx, balance.Asset)
+		if err != nil {
+			return Check{}, err
+		}
+		check.Value = balance.Free.Mul(check.Rate).Round(2)
+		check.Currency = t.Currency
+	}
+
+	check.Low = check.Value.LessThan(check.Threshold)
+	return check, nil
+}
+
+// Aggregate folds the low checks into a single low-balance event, so a run
+// never sends more than one. Amounts are written by the formatter in ctx.
+// ok is false when no balance is low.
+func Aggregate(ctx context.Context, checks []Check) (event notify.Event, ok bool) {
+	var low []Check
+	for _, c := range checks {
+		if c.Low {
+			low = append(low, c)
+		}
+	}
+	if len(low) == 0 {
+		return notify.Event{}, false
+	}
+
+	f := money.FromContext(ctx)
+	event = notify.Event{Type: notify.EventLowBalance}
+	if len(low) == 1 {
+		event.Symbol = low[0].Symbol
+		event.Summary = fmt.Sprintf("⚠️ %s balance is below threshold", low[0].Balance.Asset)
+		if low[0].Selling {
+			event.Summary = fmt.Sprintf("⚠️ Only %s left to sell", f.Amount(low[0].Balance.Free, asset.Canonical("", low[0].Balance.Asset)))
+		}
+	} else {
+		event.Summary = fmt.Sprintf("⚠️ %d balances are below threshold", len(low))
+	}
+	for _, c := range low {
+		event.Details = append(event.Details, c.details(f, len(low) > 1)...)
+	}
+	return event, true
+}
+
+// details describes a low check; prefixed labels keep several checks apart
+func (c Check) details(f *money.Formatter, prefixed bool) []notify.Detail {
+	code := asset.Canonical("", c.Balance.Asset)
+	current := f.Amount(c.Balance.Free, code)
+	if c.Currency != code {
+		current += fmt.Sprintf(" (%s at %s)", f.Amount(c.Value, c.Currency), f.Number(c.Rate, c.Currency))
+	}
+	if c.Balance.HasSignificantLocked() {
+		current += fmt.Sprintf(" free, %s locked in open orders", f.Amount(c.Balance.Locked, code))
+	}
+	details := []notify.Detail{
+		{Label: "Currency", Value: code},
+		{Label: "Current Balance", Value: current},
+		{Label: "Threshold", Value: f.Amount(c.Threshold, c.Currency)},
+		{Label: "Symbol", Value: c.Symbol},
+	}
+	if runway := c.Runway(f); runway != "" {
+		details = append(details, notify.Detail{Label: "Runway", Value: runway})
+	}
+	if prefixed {
+		for i := range details {
+			details[i].Label = c.Symbol + " " + details[i].Label
+		}
+	}
+	return details
+}
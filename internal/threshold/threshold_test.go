@@ -0,0 +1,171 @@
+package threshold
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+	"github.com/sudowanderer/dca-bot-go/internal/exchange"
+	"github.com/sudowanderer/dca-bot-go/internal/money"
+	"github.com/sudowanderer/dca-bot-go/internal/notify"
+)
+
+func d(s string) decimal.Decimal { return decimal.RequireFromString(s) }
+
+func converter(prices map[string]string) *Converter {
+	mock := &exchange.MockExchange{Symbols: []string{}, Prices: map[string]decimal.Decimal{}}
+	for symbol, price := range prices {
+		mock.Symbols = append(mock.Symbols, symbol)
+		mock.Prices[symbol] = d(price)
+	}
+	return &Converter{Prices: mock, PegTolerance: d("0.005")}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		asset      string
+		prices     map[string]string
+		wantRate   string
+		wantSource string
+	}{
+		{"usd", "USD", nil, "1", RateNone},
+		{"stablecoin without usd market", "USDT", nil, "1", RatePeg},
+		{"stablecoin within tolerance", "USDC", map[string]string{"USDC-USD": "0.998"}, "1", RatePeg},
+		{"depegged stablecoin", "USDC", map[string]string{"USDC-USD": "0.97"}, "0.97", RateMarket},
+		{"fiat usd pair", "EUR", map[string]string{"EUR-USD": "1.08"}, "1.08", RateMarket},
+		{"fiat via stablecoin", "EUR", map[string]string{"EUR-USDT": "1.07"}, "1.07", RateMarket},
+		{"inverse pair", "TRY", map[string]string{"USDT-TRY": "40"}, "0.025", RateMarket},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, source, err := converter(tt.prices).Rate(context.Background(), tt.asset)
+			if err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+			if !rate.Equal(d(tt.wantRate)) || source != tt.wantSource {
+				t.Errorf("Rate() = %s (%s), want %s (%s)", rate, source, tt.wantRate, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestRate_NoPrice(t *testing.T) {
+	_, _, err := converter(nil).Rate(context.Background(), "GBP")
+	if err == nil || !strings.Contains(err.Error(), "GBP-USD") {
+		t.Errorf("Rate() error = %v, want no USD price error", err)
+	}
+}
+
+type failingTicker struct{}
+
+func (failingTicker) LastPrice(ctx context.Context, symbol string) (decimal.Decimal, error) {
+	return decimal.Zero, errors.New("timeout")
+}
+
+func TestRate_TickerError(t *testing.T) {
+	conv := &Converter{Prices: failingTicker{}}
+	if _, _, err := conv.Rate(context.Background(), "EUR"); err == nil || !strings.Contains(err.Error(), "EUR-USD") {
+		t.Errorf("Rate() error = %v, want ticker error", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+	eur := exchange.NewBalance("EUR", d("180"), decimal.Zero)
+
+	// Plain amount compares in the quote asset without a converter
+	check, err := Evaluate(ctx, nil, "BTC-EUR", eur, config.Threshold{Amount: "200"})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if !check.Low || check.Currency != "EUR" || check.RateSource != RateNone {
+		t.Errorf("Evaluate() = %+v, want low EUR check", check)
+	}
+
+	// 180 EUR is 194.40 USD, below a 190 USD threshold it is not
+	usd := config.Threshold{Amount: "190", Currency: "USD"}
+	check, err = Evaluate(ctx, converter(map[string]string{"EUR-USD": "1.08"}), "BTC-EUR", eur, usd)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if check.Low || !check.Value.Equal(d("194.4")) || check.Currency != "USD" {
+		t.Errorf("Evaluate() = %+v, want 194.40 USD, not low", check)
+	}
+
+	if _, err := Evaluate(ctx, nil, "BTC-EUR", eur, usd); err == nil {
+		t.Error("Evaluate() without converter error = nil, want error")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	if _, ok := Aggregate(context.Background(), []Check{{Symbol: "BTC-USDT"}}); ok {
+		t.Error("Aggregate() ok = true with no low checks")
+	}
+
+	low := func(symbol, code string) Check {
+		return Check{Symbol: symbol, Balance: exchange.NewBalance(code, d("10"), decimal.Zero), Value: d("10"), Rate: d("1"), Threshold: d("100"), Currency: "USD", Low: true}
+	}
+	event, ok := Aggregate(context.Background(), []Check{low("BTC-USDT", "USDT"), {Symbol: "ETH-USDC"}, low("BTC-EUR", "EUR")})
+	if !ok {
+		t.Fatal("Aggregate() ok = false, want one event")
+	}
+	if event.Summary != "⚠️ 2 balances are below threshold" || event.Symbol != "" {
+		t.Errorf("Aggregate() = %+v", event)
+	}
+	if len(event.Details) != 8 || event.Details[4].Label != "BTC-EUR Currency" {
+		t.Errorf("Aggregate() details = %+v", event.Details)
+	}
+	if event.Details[5].Value != "10.00 EUR (10.00 USD at 1.00)" {
+		t.Errorf("Current Balance = %q", event.Details[5].Value)
+	}
+}
+
+func TestAggregate_Selling(t *testing.T) {
+	check := Check{Symbol: "BTC-USDT", Balance: exchange.NewBalance("BTC", d("0.002"), decimal.Zero), Value: d("0.002"), Threshold: d("0.01"), Currency: "BTC", Low: true, Selling: true}
+	event, ok := Aggregate(context.Background(), []Check{check})
+	if !ok || event.Summary != "⚠️ Only 0.002 BTC left to sell" {
+		t.Errorf("Aggregate() = %+v, %v", event, ok)
+	}
+}
+
+func TestAggregate_Formatted(t *testing.T) {
+	check := Check{
+		Symbol:    "BTC-EUR",
+		Balance:   exchange.NewBalance("BTC", d("0.0123456789"), d("1.5")),
+		Value:     d("766.98"),
+		Rate:      d("62125.123456"),
+		Threshold: d("1000"),
+		Currency:  "USD",
+		Low:       true,
+	}
+	ctx := money.WithFormatter(context.Background(), money.New("de", map[string]int{"BTC": 4}))
+	event, _ := Aggregate(ctx, []Check{check})
+	want := "0,0123 BTC (766,98 USD at 62.125,12) free, 1,5 BTC locked in open orders"
+	if event.Details[1].Value != want || event.Details[2].Value != "1.000,00 USD" {
+		t.Errorf("details = %+v, want %q in German with 4 BTC decimals", event.Details, want)
+	}
+}
+
+func TestHoldings(t *testing.T) {
+	if _, ok := Holdings(context.Background(), "BTC-USDT", "BTC", d("0.5"), d("0.5"), false); ok {
+		t.Error("Holdings() ok = true at the threshold")
+	}
+
+	event, ok := Holdings(context.Background(), "BTC-USDT", "BTC", d("0.52"), d("0.5"), false)
+	if !ok || event.Type != notify.EventHoldings || event.Summary != "🏦 0.52 BTC accumulated, above the 0.5 BTC alert" {
+		t.Fatalf("Holdings() = %+v, %v", event, ok)
+	}
+	if last := event.Details[len(event.Details)-1]; last.Label != "Tip" || !strings.Contains(last.Value, "strategy.withdrawal") {
+		t.Errorf("details = %+v, want the withdrawal tip last", event.Details)
+	}
+
+	event, _ = Holdings(context.Background(), "BTC-USDT", "BTC", d("0.52"), d("0.5"), true)
+	if len(event.Details) != 4 {
+		t.Errorf("details = %+v, want no tip with withdrawal configured", event.Details)
+	}
+}
@@ -0,0 +1,157 @@
+// Package tradingview turns TradingView webhook alerts, delivered through a
+// Lambda Function URL, into immediate buys. Alerts only select which
+// configured mapping runs: the amount always comes from the payload.
+package tradingview
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+var (
+	ErrUnauthorized  = errors.New("tradingview alert rejected: invalid passphrase")
+	ErrUnknownSymbol = errors.New("tradingview alert rejected: unknown symbol")
+	ErrRateLimited   = errors.New("tradingview alert rejected: rate limit reached")
+)
+
+// RateWindow is the window MaxTriggersPerHour applies to
+const RateWindow = time.Hour
+
+// Alert is the JSON body to configure as the TradingView alert message, e.g.
+// {"passphrase": "...", "ticker": "{{ticker}}"}
+type Alert struct {
+	Passphrase string `json:"passphrase"`
+	Ticker     string `json:"ticker"`
+}
+
+// SecretResolver returns the value of a credential source
+type SecretResolver func(ctx context.Context, source config.CredentialSource) (string, error)
+
+// Limiter records triggers per key and reports whether another one fits
+// within limit per window
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, error)
+}
+
+// Trigger translates alerts into payloads derived from Base
+type Trigger struct {
+	Base    *config.DCAPayload // must have integrations.tradingview
+	Resolve SecretResolver
+	Limiter Limiter
+	Now     func() time.Time // defaults to time.Now
+}
+
+// Translate verifies an alert body and returns the payload to execute: Base
+// with the mapped symbol and quote amount
+func (t *Trigger) Translate(ctx context.Context, body []byte) (*config.DCAPayload, error) {
+	tv := t.Base.Integrations.TradingView
+	if tv == nil {
+		return nil, fmt.Errorf("integrations.tradingview is not configured")
+	}
+
+	var alert Alert
+	if err := json.Unmarshal(body, &alert); err != nil {
+		return nil, fmt.Errorf("invalid tradingview alert body: %w", err)
+	}
+
+	expected, err := t.Resolve(ctx, tv.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve tradingview passphrase: %w", err)
+	}
+	if expected == "" || subtle.ConstantTimeCompare([]byte(alert.Passphrase), []byte(expected)) != 1 {
+		return nil, ErrUnauthorized
+	}
+
+	ticker := strings.ToUpper(strings.TrimSpace(alert.Ticker))
+	mapping, ok := tv.Symbols[ticker]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSymbol, alert.Ticker)
+	}
+
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	allowed, err := t.Limiter.Allow(ctx, "tradingview:"+mapping.Symbol, tv.MaxTriggersPerHour, RateWindow, now())
+	if err != nil {
+		return nil, fmt.Errorf("rate limit check failed: %w", err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %d per hour for %s", ErrRateLimited, tv.MaxTriggersPerHour, mapping.Symbol)
+	}
+
+	payload := *t.Base
+	payload.Strategy.Symbol = mapping.Symbol
+	payload.Strategy.QuoteAmount = mapping.QuoteAmount
+	payload.Strategy.QuoteAmountPercent = "" // the alert names the amount
+	return &payload, nil
+}
+
+// Unwrapper returns a handler unwrapper that translates Function URL
+// requests carrying an alert into a payload and passes any other event
+// through unchanged
+func (t *Trigger) Unwrapper() func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+	return func(ctx context.Context, event json.RawMessage) (json.RawMessage, error) {
+		var request events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(event, &request); err != nil || request.RequestContext.HTTP.Method == "" {
+			return event, nil
+		}
+
+		body := []byte(request.Body)
+		if request.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(request.Body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 request body: %w", err)
+			}
+			body = decoded
+		}
+
+		payload, err := t.Translate(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(payload)
+	}
+}
+
+// MemoryLimiter is a Limiter that keeps trigger times in memory. On Lambda
+// it only covers invocations served by the same warm instance.
+type MemoryLimiter struct {
+	mu   sync.Mutex
+	hits map[string][]time.Time
+}
+
+// NewMemoryLimiter creates an empty MemoryLimiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{hits: map[string][]time.Time{}}
+}
+
+// Allow records a trigger for key unless limit triggers already happened
+// within window before now
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := l.hits[key][:0]
+	for _, hit := range l.hits[key] {
+		if now.Sub(hit) < window {
+			recent = append(recent, hit)
+		}
+	}
+	if len(recent) >= limit {
+		l.hits[key] = recent
+		return false, nil
+	}
+	l.hits[key] = append(recent, now)
+	return true, nil
+}
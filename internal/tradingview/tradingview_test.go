@@ -0,0 +1,147 @@
+package tradingview
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+const basePayload = `{
+	"version": "v2",
+	"exchange": {"name": "binance", "credentials": {"type": "env", "config": {"apiKeyEnv": "BINANCE_KEY", "apiSecretEnv": "BINANCE_SECRET"}}},
+	"strategy": {"symbol": "BTC-USDT", "quoteAmount": "10"},
+	"integrations": {
+		"tradingview": {
+			"passphrase": {"type": "env", "config": {"passphraseEnv": "TV_PASSPHRASE"}},
+			"symbols": {
+				"BTCUSDT": {"symbol": "BTC-USDT", "quoteAmount": "25"},
+				"ETHUSDT": {"symbol": "ETH-USDT", "quoteAmount": "15"}
+			},
+			"maxTriggersPerHour": 2
+		}
+	}
+}`
+
+func newTrigger(t *testing.T, now *time.Time) *Trigger {
+	t.Helper()
+	base, err := config.ParseDCAPayload([]byte(basePayload))
+	if err != nil {
+		t.Fatalf("ParseDCAPayload() error = %v", err)
+	}
+	return &Trigger{
+		Base: base,
+		Resolve: func(ctx context.Context, source config.CredentialSource) (string, error) {
+			if source.Config["passphraseEnv"] != "TV_PASSPHRASE" {
+				return "", errors.New("unexpected passphrase source")
+			}
+			return "s3cret", nil
+		},
+		Limiter: NewMemoryLimiter(),
+		Now:     func() time.Time { return *now },
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	now := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	trigger := newTrigger(t, &now)
+
+	// quoteAmount in the body is ignored; only the mapping decides the amount
+	payload, err := trigger.Translate(context.Background(), []byte(`{"passphrase":"s3cret","ticker":"ethusdt","quoteAmount":"100000"}`))
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if payload.Strategy.Symbol != "ETH-USDT" || payload.Strategy.QuoteAmount != "15" {
+		t.Errorf("Strategy = %+v, want ETH-USDT for 15", payload.Strategy)
+	}
+	if trigger.Base.Strategy.Symbol != "BTC-USDT" {
+		t.Error("Translate() must not modify the base payload")
+	}
+}
+
+func TestTranslate_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{"wrong_passphrase", `{"passphrase":"guess","ticker":"BTCUSDT"}`, ErrUnauthorized},
+		{"missing_passphrase", `{"ticker":"BTCUSDT"}`, ErrUnauthorized},
+		{"unknown_symbol", `{"passphrase":"s3cret","ticker":"DOGEUSDT"}`, ErrUnknownSymbol},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			_, err := newTrigger(t, &now).Translate(context.Background(), []byte(tt.body))
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Translate() error = %v, want %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTranslate_RateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	trigger := newTrigger(t, &now)
+	ctx := context.Background()
+	btc := []byte(`{"passphrase":"s3cret","ticker":"BTCUSDT"}`)
+	eth := []byte(`{"passphrase":"s3cret","ticker":"ETHUSDT"}`)
+
+	for i := 0; i < 2; i++ {
+		if _, err := trigger.Translate(ctx, btc); err != nil {
+			t.Fatalf("trigger %d: Translate() error = %v", i+1, err)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	if _, err := trigger.Translate(ctx, btc); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("third trigger within the hour: error = %v, want ErrRateLimited", err)
+	}
+
+	// Limits are per symbol
+	if _, err := trigger.Translate(ctx, eth); err != nil {
+		t.Errorf("other symbol: Translate() error = %v", err)
+	}
+
+	// The first trigger leaves the window
+	now = now.Add(41 * time.Minute)
+	if _, err := trigger.Translate(ctx, btc); err != nil {
+		t.Errorf("after the window: Translate() error = %v", err)
+	}
+}
+
+func TestUnwrapper(t *testing.T) {
+	now := time.Now()
+	unwrap := newTrigger(t, &now).Unwrapper()
+	ctx := context.Background()
+
+	// Non-HTTP events pass through unchanged
+	event := json.RawMessage(basePayload)
+	out, err := unwrap(ctx, event)
+	if err != nil || string(out) != basePayload {
+		t.Errorf("unwrap(payload) = %s, %v; want passthrough", out, err)
+	}
+
+	body := base64.StdEncoding.EncodeToString([]byte(`{"passphrase":"s3cret","ticker":"BTCUSDT"}`))
+	request := `{"version":"2.0","rawPath":"/","requestContext":{"http":{"method":"POST"}},"body":"` + body + `","isBase64Encoded":true}`
+	out, err = unwrap(ctx, json.RawMessage(request))
+	if err != nil {
+		t.Fatalf("unwrap(request) error = %v", err)
+	}
+	payload, err := config.ParseDCAPayload(out)
+	if err != nil {
+		t.Fatalf("translated payload does not parse: %v", err)
+	}
+	if payload.Strategy.QuoteAmount != "25" {
+		t.Errorf("QuoteAmount = %s, want 25", payload.Strategy.QuoteAmount)
+	}
+
+	unauthorized := `{"version":"2.0","requestContext":{"http":{"method":"POST"}},"body":"{\"passphrase\":\"no\",\"ticker\":\"BTCUSDT\"}"}`
+	if _, err := unwrap(ctx, json.RawMessage(unauthorized)); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("unwrap(unauthorized) error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package useragent identifies the bot's requests to exchanges: the
+// User-Agent every request carries and the extra headers of
+// exchange.options.extraHeaders
+package useragent
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Product is the product token of the User-Agent
+const Product = "dca-bot-go"
+
+// Version returns the version of the running build, "dev" for builds
+// without one
+var Version = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" {
+		return "dev"
+	}
+	return info.Main.Version
+})
+
+// String returns the User-Agent of requests made under ctx,
+// "dca-bot-go/<version> (+<execution ID>)", without the comment when ctx
+// has no execution ID
+func String(ctx context.Context) string {
+	ua := Product + "/" + Version()
+	if id := run.ID(ctx); id != "" {
+		ua += " (+" + id + ")"
+	}
+	return ua
+}
+
+type extraKey struct{}
+
+// WithExtra returns a copy of ctx whose exchange requests also carry header
+func WithExtra(ctx context.Context, header http.Header) context.Context {
+	return context.WithValue(ctx, extraKey{}, header)
+}
+
+// Extra returns the extra headers stored in ctx, or nil
+func Extra(ctx context.Context) http.Header {
+	header, _ := ctx.Value(extraKey{}).(http.Header)
+	return header
+}
+
+// Apply sets the identification headers on req that it does not carry
+// yet: the extra headers of its context, then the default User-Agent
+// unless an extra header replaced it. Clients call it right after creating
+// a request and before signing it, so the signature sees the final header
+// set and the auth headers set afterwards win over any extra header of the
+// same name.
+func Apply(req *http.Request) {
+	for name, values := range Extra(req.Context()) {
+		if req.Header.Get(name) == "" {
+			req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", String(req.Context()))
+	}
+}
+
+// missing reports whether Apply would change req
+func missing(req *http.Request) bool {
+	if req.Header.Get("User-Agent") == "" {
+		return true
+	}
+	for name := range Extra(req.Context()) {
+		if req.Header.Get(name) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Transport applies the identification headers to requests whose client
+// did not, leaving every header already set untouched, and passes them to
+// Base
+type Transport struct {
+	Base http.RoundTripper // defaults to http.DefaultTransport
+}
+
+// NewTransport wraps base, or http.DefaultTransport when base is nil
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip sends req with the identification headers it lacks
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if missing(req) {
+		// A RoundTripper must not modify the caller's request
+		req = req.Clone(req.Context())
+		Apply(req)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,60 @@
+package useragent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+func TestString(t *testing.T) {
+	if got, want := String(context.Background()), "dca-bot-go/"+Version(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := String(run.WithID(context.Background(), "01JABC")), "dca-bot-go/"+Version()+" (+01JABC)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := WithExtra(run.WithID(context.Background(), "exec-1"), http.Header{"X-Proxy-Token": {"t0k"}, "X-Trace": {"extra"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.example.com", nil)
+	req.Header.Set("X-Trace", "client")
+
+	Apply(req)
+	if req.Header.Get("X-Proxy-Token") != "t0k" || req.Header.Get("User-Agent") != "dca-bot-go/"+Version()+" (+exec-1)" {
+		t.Errorf("headers = %v, want the extra header and default User-Agent", req.Header)
+	}
+	if req.Header.Get("X-Trace") != "client" {
+		t.Error("Apply() replaced a header the client set")
+	}
+
+	// An extra User-Agent replaces the default one
+	req, _ = http.NewRequestWithContext(WithExtra(ctx, http.Header{"User-Agent": {"proxy-ua"}}), http.MethodGet, "https://api.example.com", nil)
+	Apply(req)
+	if got := req.Header.Get("User-Agent"); got != "proxy-ua" {
+		t.Errorf("User-Agent = %q, want the extra one", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.Header }))
+	defer server.Close()
+
+	ctx := WithExtra(context.Background(), http.Header{"X-Proxy-Token": {"t0k"}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("X-Proxy-Token") != "t0k" || got.Get("User-Agent") != "dca-bot-go/"+Version() {
+		t.Errorf("sent headers = %v", got)
+	}
+	if len(req.Header) != 0 {
+		t.Errorf("Transport modified the caller's request: %v", req.Header)
+	}
+}
@@ -0,0 +1,90 @@
+package withdrawal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AllowListEnv names the SSM parameter holding the withdrawal allow-list.
+// The list lives outside the payload, so tampering with the payload alone
+// cannot redirect a withdrawal.
+const AllowListEnv = "DCA_WITHDRAWAL_ALLOWLIST"
+
+// ErrNotAllowed is returned for addresses missing from the allow-list
+var ErrNotAllowed = errors.New("withdrawal address is not on the allow-list")
+
+// AllowList holds the addresses withdrawals may go to
+type AllowList struct {
+	entries []allowed
+}
+
+type allowed struct {
+	network string // network code; empty allows the address on every network
+	address string
+}
+
+// ParseAllowList reads one address per line or comma-separated, each
+// optionally prefixed with its network, e.g. "ETH:0x5aAe...". Blank lines
+// and lines starting with # are skipped. Every entry must itself be a
+// valid address of its network, so a corrupted list fails loudly.
+func ParseAllowList(text string) (*AllowList, error) {
+	list := &AllowList{}
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.Split(line, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			entry := allowed{address: field}
+			if name, address, ok := strings.Cut(field, ":"); ok {
+				network, err := Network(name)
+				if err != nil {
+					return nil, fmt.Errorf("allow-list line %d: %w", n+1, err)
+				}
+				entry = allowed{network: network, address: strings.TrimSpace(address)}
+				if err := ValidateAddress(network, entry.address); err != nil {
+					return nil, fmt.Errorf("allow-list line %d: %w", n+1, err)
+				}
+			}
+			list.entries = append(list.entries, entry)
+		}
+	}
+	if len(list.entries) == 0 {
+		return nil, errors.New("allow-list is empty")
+	}
+	return list, nil
+}
+
+// Allows reports whether address may receive withdrawals on network
+func (l *AllowList) Allows(network, address string) bool {
+	network, err := Network(network)
+	if err != nil {
+		return false
+	}
+	for _, e := range l.entries {
+		if e.network != "" && e.network != network {
+			continue
+		}
+		if SameAddress(network, e.address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify checks an address right before it is used: it must still be
+// valid for network and, with a non-nil allow-list, appear in it
+func Verify(network, address string, list *AllowList) error {
+	if err := ValidateAddress(network, address); err != nil {
+		return err
+	}
+	if list != nil && !list.Allows(network, address) {
+		return fmt.Errorf("%w: %s on %s", ErrNotAllowed, address, strings.ToUpper(network))
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package withdrawal
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	ledgerBTC = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	coldETH   = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	otherETH  = "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"
+)
+
+func TestParseAllowList(t *testing.T) {
+	list, err := ParseAllowList(`
+# cold storage
+` + ledgerBTC + `
+ERC20:` + coldETH + `, 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa
+`)
+	if err != nil {
+		t.Fatalf("ParseAllowList() error = %v", err)
+	}
+
+	tests := []struct {
+		network string
+		address string
+		want    bool
+	}{
+		{"BTC", ledgerBTC, true},
+		{"BTC", strings.ToUpper(ledgerBTC), true},
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", true},
+		{"ETH", coldETH, true},
+		{"ethereum", strings.ToLower(coldETH), true},
+		{"ETH", otherETH, false},
+		{"BSC", coldETH, false}, // listed for ETH only
+		{"SOL", coldETH, false},
+	}
+	for _, tt := range tests {
+		if got := list.Allows(tt.network, tt.address); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.network, tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestParseAllowList_Invalid(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", "allow-list is empty"},
+		{"# nothing yet\n\n", "allow-list is empty"},
+		{ledgerBTC + "\nETH:0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAEd", "allow-list line 2: invalid ETH address"},
+		{"TRC20:TQn9Y2khEsLJW1ChVWFMSMeRDow5KcbLSE", "allow-list line 1: unsupported network"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseAllowList(tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseAllowList(%q) error = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	list, err := ParseAllowList("ETH:" + coldETH)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Verify("ETH", coldETH, list); err != nil {
+		t.Errorf("Verify() listed address error = %v", err)
+	}
+	if err := Verify("ETH", otherETH, list); !errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Verify() unlisted address error = %v, want ErrNotAllowed", err)
+	}
+	if err := Verify("ETH", otherETH, nil); err != nil {
+		t.Errorf("Verify() without an allow-list error = %v", err)
+	}
+	// A corrupted address fails before the allow-list is consulted
+	if err := Verify("ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAEd", nil); err == nil || errors.Is(err, ErrNotAllowed) {
+		t.Errorf("Verify() corrupted address error = %v, want a checksum error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package withdrawal
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// base58Alphabet is Bitcoin's, which leaves out 0, O, I and l
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58CheckDecode decodes a Base58Check string into its version byte and
+// payload, verifying the 4-byte double-SHA256 checksum
+func base58CheckDecode(s string) (version byte, payload []byte, err error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return 0, nil, errors.New("invalid base58 character " + string(r))
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	decoded := n.Bytes()
+	// Every leading '1' is a leading zero byte
+	for i := 0; i < len(s) && s[i] == '1'; i++ {
+		decoded = append([]byte{0}, decoded...)
+	}
+	if len(decoded) < 5 {
+		return 0, nil, errors.New("too short")
+	}
+
+	body, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(body)
+	second := sha256.Sum256(first[:])
+	if string(second[:4]) != string(checksum) {
+		return 0, nil, errors.New("checksum mismatch")
+	}
+	return body[0], body[1:], nil
+}
+
+// Bech32 checksum constants of BIP 173 and BIP 350
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := range len(hrp) {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := range len(hrp) {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// bech32Decode splits a bech32 or bech32m string into its human-readable
+// part and 5-bit data, without the checksum. constant tells which of the
+// two checksums matched.
+func bech32Decode(s string) (hrp string, data []byte, constant uint32, err error) {
+	if len(s) > 90 {
+		return "", nil, 0, errors.New("longer than 90 characters")
+	}
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, errors.New("mixed case")
+	}
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, 0, errors.New("missing separator or checksum")
+	}
+
+	hrp = s[:sep]
+	for i := range len(hrp) {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, errors.New("invalid prefix character")
+		}
+	}
+	for _, r := range s[sep+1:] {
+		v := strings.IndexRune(bech32Charset, r)
+		if v < 0 {
+			return "", nil, 0, errors.New("invalid bech32 character " + string(r))
+		}
+		data = append(data, byte(v))
+	}
+
+	constant = bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	if constant != bech32Const && constant != bech32mConst {
+		return "", nil, 0, errors.New("checksum mismatch")
+	}
+	return hrp, data[:len(data)-6], constant, nil
+}
+
+// convertBits regroups 5-bit groups into bytes, rejecting non-zero padding
+func convertBits(data []byte, from, to uint) ([]byte, error) {
+	var acc, nbits uint
+	maxv := uint(1)<<to - 1
+	var out []byte
+	for _, v := range data {
+		acc = acc<<from | uint(v)
+		nbits += from
+		for nbits >= to {
+			nbits -= to
+			out = append(out, byte(acc>>nbits&maxv))
+		}
+	}
+	if nbits >= from || acc<<(to-nbits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
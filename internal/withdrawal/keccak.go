@@ -0,0 +1,88 @@
+package withdrawal
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// keccakRate is the sponge rate of Keccak-256 in bytes
+const keccakRate = 136
+
+// keccakRC are the round constants of Keccak-f[1600]
+var keccakRC = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// keccakRotc and keccakPiln drive the rho and pi steps
+var (
+	keccakRotc = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakPiln = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccak256 is the original Keccak-256 Ethereum uses, which pads
+// differently from the standardized SHA3-256; EIP-55 checksums need it and
+// the standard library only has SHA3
+func keccak256(data []byte) [32]byte {
+	var state [25]uint64
+	for len(data) >= keccakRate {
+		keccakAbsorb(&state, data[:keccakRate])
+		data = data[keccakRate:]
+	}
+	var last [keccakRate]byte
+	copy(last[:], data)
+	last[len(data)] = 0x01
+	last[keccakRate-1] |= 0x80
+	keccakAbsorb(&state, last[:])
+
+	var out [32]byte
+	for i := range 4 {
+		binary.LittleEndian.PutUint64(out[i*8:], state[i])
+	}
+	return out
+}
+
+func keccakAbsorb(state *[25]uint64, block []byte) {
+	for i := range keccakRate / 8 {
+		state[i] ^= binary.LittleEndian.Uint64(block[i*8:])
+	}
+	keccakF(state)
+}
+
+// keccakF is the Keccak-f[1600] permutation
+func keccakF(a *[25]uint64) {
+	var c [5]uint64
+	for round := range 24 {
+		// theta
+		for x := range 5 {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := range 5 {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		// rho and pi
+		t := a[1]
+		for i := range 24 {
+			j := keccakPiln[i]
+			t, a[j] = a[j], bits.RotateLeft64(t, keccakRotc[i])
+		}
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := range 5 {
+				c[x] = a[y+x]
+			}
+			for x := range 5 {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+		// iota
+		a[0] ^= keccakRC[round]
+	}
+}
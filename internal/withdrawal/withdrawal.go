@@ -0,0 +1,227 @@
+// Package withdrawal guards the address coins are withdrawn to. A typo'd
+// or tampered address loses the coins for good, so an address must pass
+// the format and checksum rules of its network and, when an allow-list is
+// configured, appear in that independently stored list.
+package withdrawal
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Family is a group of networks sharing an address format
+type Family string
+
+const (
+	FamilyBitcoin Family = "bitcoin" // bech32/bech32m segwit or Base58Check legacy addresses
+	FamilyEVM     Family = "evm"     // 0x-prefixed EIP-55 checksummed addresses
+)
+
+// networks maps network codes to their family
+var networks = map[string]Family{
+	"BTC":      FamilyBitcoin,
+	"ETH":      FamilyEVM,
+	"BSC":      FamilyEVM,
+	"ARBITRUM": FamilyEVM,
+	"OPTIMISM": FamilyEVM,
+	"POLYGON":  FamilyEVM,
+	"BASE":     FamilyEVM,
+	"AVAXC":    FamilyEVM,
+}
+
+// networkAliases maps other names exchanges use to the network codes
+var networkAliases = map[string]string{
+	"BITCOIN":  "BTC",
+	"ERC20":    "ETH",
+	"ETHEREUM": "ETH",
+	"BEP20":    "BSC",
+	"ARB":      "ARBITRUM",
+	"OP":       "OPTIMISM",
+	"MATIC":    "POLYGON",
+}
+
+// Networks lists the supported network codes and aliases
+func Networks() []string {
+	var codes []string
+	for code := range networks {
+		codes = append(codes, code)
+	}
+	for alias := range networkAliases {
+		codes = append(codes, alias)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Network returns the code of a network name, resolving aliases such as
+// ERC20 for ETH, case-insensitively
+func Network(name string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(name))
+	if alias, ok := networkAliases[code]; ok {
+		code = alias
+	}
+	if _, ok := networks[code]; !ok {
+		return "", fmt.Errorf("unsupported network %q (want one of %s)", name, strings.Join(Networks(), ", "))
+	}
+	return code, nil
+}
+
+// NetworkFamily returns the family of a network name
+func NetworkFamily(name string) (Family, error) {
+	code, err := Network(name)
+	if err != nil {
+		return "", err
+	}
+	return networks[code], nil
+}
+
+// ValidateAddress checks address against the format and checksum of
+// network. Addresses are never corrected: surrounding whitespace is an
+// error, not something to trim.
+func ValidateAddress(network, address string) error {
+	family, err := NetworkFamily(network)
+	if err != nil {
+		return err
+	}
+	if address == "" {
+		return errors.New("address is required")
+	}
+	if strings.TrimSpace(address) != address {
+		return errors.New("address has surrounding whitespace")
+	}
+	switch family {
+	case FamilyBitcoin:
+		err = validateBitcoin(address)
+	case FamilyEVM:
+		err = validateEVM(address)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s address %q: %w", strings.ToUpper(strings.TrimSpace(network)), address, err)
+	}
+	return nil
+}
+
+// Bitcoin mainnet Base58Check versions
+const (
+	versionP2PKH = 0x00 // 1...
+	versionP2SH  = 0x05 // 3...
+)
+
+// validateBitcoin accepts mainnet segwit (bc1...) and legacy (1..., 3...)
+// addresses
+func validateBitcoin(address string) error {
+	if strings.HasPrefix(strings.ToLower(address), "bc1") {
+		return validateSegwit(address)
+	}
+	if strings.HasPrefix(strings.ToLower(address), "tb1") {
+		return errors.New("testnet address")
+	}
+
+	version, payload, err := base58CheckDecode(address)
+	if err != nil {
+		return err
+	}
+	if version != versionP2PKH && version != versionP2SH {
+		return fmt.Errorf("not a mainnet address (version %d)", version)
+	}
+	if len(payload) != 20 {
+		return fmt.Errorf("payload is %d bytes, want 20", len(payload))
+	}
+	return nil
+}
+
+// validateSegwit applies BIP 173 and BIP 350: witness version 0 uses
+// bech32 with a 20 or 32 byte program, later versions bech32m
+func validateSegwit(address string) error {
+	hrp, data, constant, err := bech32Decode(address)
+	if err != nil {
+		return err
+	}
+	if hrp != "bc" {
+		return fmt.Errorf("prefix %q is not bitcoin mainnet", hrp)
+	}
+	if len(data) < 1 {
+		return errors.New("missing witness version")
+	}
+	version := data[0]
+	if version > 16 {
+		return fmt.Errorf("invalid witness version %d", version)
+	}
+	program, err := convertBits(data[1:], 5, 8)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("witness program is %d bytes", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("version 0 witness program is %d bytes, want 20 or 32", len(program))
+	}
+	if version == 0 && constant != bech32Const {
+		return errors.New("version 0 address must use bech32, not bech32m")
+	}
+	if version != 0 && constant != bech32mConst {
+		return fmt.Errorf("version %d address must use bech32m, not bech32", version)
+	}
+	return nil
+}
+
+// validateEVM requires the EIP-55 mixed-case checksum. All-lowercase and
+// all-uppercase addresses carry no checksum, so a typo in them would go
+// unnoticed; they are rejected rather than accepted unchecked.
+func validateEVM(address string) error {
+	hexPart, ok := strings.CutPrefix(address, "0x")
+	if !ok {
+		return errors.New("missing 0x prefix")
+	}
+	if len(hexPart) != 40 {
+		return fmt.Errorf("%d hex digits, want 40", len(hexPart))
+	}
+	if _, err := hex.DecodeString(hexPart); err != nil {
+		return errors.New("not hexadecimal")
+	}
+	hasLetters := strings.ContainsAny(strings.ToLower(hexPart), "abcdef")
+	if hasLetters && (hexPart == strings.ToLower(hexPart) || hexPart == strings.ToUpper(hexPart)) {
+		return errors.New("no EIP-55 checksum; copy the mixed-case address from the wallet")
+	}
+	if checksumEVM(hexPart) != hexPart {
+		return errors.New("EIP-55 checksum mismatch")
+	}
+	return nil
+}
+
+// checksumEVM returns the EIP-55 capitalization of 40 hex digits: a letter
+// is upper-cased when the matching nibble of the lowercase address's
+// Keccak-256 hash is 8 or more
+func checksumEVM(hexPart string) string {
+	lower := strings.ToLower(hexPart)
+	hash := keccak256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && c <= 'f' && nibble >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// SameAddress reports whether two addresses of network are the same.
+// EVM and bech32 addresses compare case-insensitively; Base58Check
+// addresses are case-sensitive.
+func SameAddress(network, a, b string) bool {
+	family, err := NetworkFamily(network)
+	if err != nil {
+		return a == b
+	}
+	if family == FamilyEVM || strings.HasPrefix(strings.ToLower(a), "bc1") {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
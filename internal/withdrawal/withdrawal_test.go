@@ -0,0 +1,160 @@
+package withdrawal
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestKeccak256(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"},
+		{"abc", "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45"},
+		// Around and across the 136-byte block boundary
+		{strings.Repeat("a", 135), "34367dc248bbd832f4e3e69dfaac2f92638bd0bbd18f2912ba4ef454919cf446"},
+		{strings.Repeat("a", 136), "a6c4d403279fe3e0af03729caada8374b5ca54d8065329a3ebcaeb4b60aa386e"},
+		{strings.Repeat("a", 200), "96ea54061def936c4be90b518992fdc6f12f535068a256229aca54267b4d084d"},
+		{strings.Repeat("a", 272), "cf7fcd4f705ee749930d19ca84561a9bf62516bd90a471545fa2f49fdc7e63c8"},
+	}
+	for _, tt := range tests {
+		got := keccak256([]byte(tt.input))
+		if hex.EncodeToString(got[:]) != tt.want {
+			t.Errorf("keccak256 of %d bytes = %x, want %s", len(tt.input), got, tt.want)
+		}
+	}
+}
+
+func TestValidateAddress_Bitcoin(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr string // empty for a valid address
+	}{
+		// Valid mainnet addresses from BIP 173, BIP 350 and the genesis block
+		{"p2pkh", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", ""},
+		{"p2sh", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ""},
+		{"p2wpkh", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", ""},
+		{"p2wpkh upper case", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", ""},
+		{"p2wsh", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3", ""},
+		{"p2tr", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", ""},
+
+		// Corrupted copies of the valid ones
+		{"p2pkh last char changed", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", "checksum mismatch"},
+		{"p2pkh chars swapped", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DviNfa", "checksum mismatch"},
+		{"p2pkh char dropped", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfN", "checksum mismatch"},
+		{"p2pkh invalid base58 zero", "1A1zP1eP5QGefi2DMPTfTL5SLmv7Div0Na", "invalid base58 character 0"},
+		{"p2pkh invalid base58 l", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivlNa", "invalid base58 character l"},
+		{"p2sh char changed", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLz", "checksum mismatch"},
+		{"p2wpkh last char changed", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "checksum mismatch"},
+		{"p2wpkh chars swapped", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8ft34", "checksum mismatch"},
+		{"p2wpkh mixed case", "bc1qW508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "mixed case"},
+		{"p2wpkh invalid char b", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3tb", "invalid bech32 character b"},
+		{"p2wsh char dropped", "bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv", "checksum mismatch"},
+		{"p2tr last char changed", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj2", "checksum mismatch"},
+
+		// Well-formed but not a mainnet payment address (BIP 173 and BIP 350 invalid vectors)
+		{"testnet bech32", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", "testnet address"},
+		{"v1 with bech32 checksum", "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "must use bech32m"},
+		{"non-zero padding", "bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", "invalid padding"},
+		{"v0 with bech32m checksum", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "must use bech32, not bech32m"},
+		{"v0 program of 16 bytes", "BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "version 0 witness program is 16 bytes"},
+		{"testnet base58", "mipcBbFg9gMiCh81Kj8tqqdgoZub1ZJRfn", "not a mainnet address (version 111)"},
+		{"evm address on bitcoin", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "invalid base58 character 0"},
+		{"empty", "", "address is required"},
+		{"trailing space", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4 ", "surrounding whitespace"},
+		{"too short", "1A1z", "too short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAddress("BTC", tt.address)
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateAddress_EVM(t *testing.T) {
+	tests := []struct {
+		name    string
+		network string
+		address string
+		wantErr string
+	}{
+		// EIP-55's checksummed examples
+		{"eip-55 example 1", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ""},
+		{"eip-55 example 2", "ERC20", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", ""},
+		{"eip-55 example 3", "BSC", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", ""},
+		{"eip-55 example 4", "arbitrum", "0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb", ""},
+		{"digits only", "POLYGON", "0x1234567890123456789012345678901234567890", ""},
+
+		// Corrupted copies
+		{"one letter case flipped", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAEd", "EIP-55 checksum mismatch"},
+		{"one digit changed", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAee", "EIP-55 checksum mismatch"},
+		{"digits swapped", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeaEd", "EIP-55 checksum mismatch"},
+		{"char dropped", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", "38 hex digits, want 40"},
+		{"char added", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed0", "41 hex digits, want 40"},
+		{"non-hex", "ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeg", "not hexadecimal"},
+		{"missing prefix", "ETH", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "missing 0x prefix"},
+		{"upper-case prefix", "ETH", "0X5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "missing 0x prefix"},
+
+		// No checksum to check
+		{"all lower case", "ETH", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", "no EIP-55 checksum"},
+		{"all upper case", "BASE", "0x52908400098527886E0F7030069857D2E4169EE7", "no EIP-55 checksum"},
+
+		{"bitcoin address on evm", "ETH", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "missing 0x prefix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkErr(t, ValidateAddress(tt.network, tt.address), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateAddress_UnsupportedNetwork(t *testing.T) {
+	for _, network := range []string{"", "SOL", "TRC20", "LIGHTNING"} {
+		err := ValidateAddress(network, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+		checkErr(t, err, "unsupported network")
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	tests := map[string]string{"btc": "BTC", "Bitcoin": "BTC", "ERC20": "ETH", "ethereum": "ETH", "BEP20": "BSC", " arb ": "ARBITRUM", "MATIC": "POLYGON"}
+	for name, want := range tests {
+		if got, err := Network(name); err != nil || got != want {
+			t.Errorf("Network(%q) = %q, %v, want %s", name, got, err, want)
+		}
+	}
+}
+
+func TestSameAddress(t *testing.T) {
+	tests := []struct {
+		network string
+		a, b    string
+		want    bool
+	}{
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", true},
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1a1zp1ep5qgefi2dmptftl5slmv7divfna", false},
+		{"ETH", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", false},
+	}
+	for _, tt := range tests {
+		if got := SameAddress(tt.network, tt.a, tt.b); got != tt.want {
+			t.Errorf("SameAddress(%s, %s, %s) = %v, want %v", tt.network, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func checkErr(t *testing.T, err error, want string) {
+	t.Helper()
+	if want == "" {
+		if err != nil {
+			t.Errorf("error = %v, want a valid address", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
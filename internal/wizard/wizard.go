@@ -0,0 +1,257 @@
+// Package wizard builds ready-to-use DCA payloads from a handful of answers,
+// either asked interactively or supplied as flags. Every generated payload is
+// run through config.ParseDCAPayload before it is returned.
+package wizard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+// Exchanges the wizard offers; OKX joins once its exchange client exists
+var Exchanges = []string{"binance"}
+
+// Answers collects everything needed to generate a payload
+type Answers struct {
+	Exchange         string
+	Symbol           string
+	QuoteAmount      string
+	BalanceThreshold string
+
+	// CredentialType is "ssm", "env" or "inline". The two refs hold SSM
+	// parameter paths, environment variable names, or literal values
+	// accordingly.
+	CredentialType string
+	APIKeyRef      string
+	APISecretRef   string
+
+	// TelegramType is empty to disable notifications, otherwise "ssm",
+	// "env" or "inline" for how TelegramTokenRef is resolved
+	TelegramType     string
+	TelegramTokenRef string
+	TelegramChatID   string
+
+	DryRun bool
+}
+
+// credentialKeys maps a credential type to its config keys for key and secret
+var credentialKeys = map[string][2]string{
+	config.CredentialTypeSSM:    {"apiKeyPath", "apiSecretPath"},
+	config.CredentialTypeEnv:    {"apiKeyEnv", "apiSecretEnv"},
+	config.CredentialTypeInline: {"apiKey", "apiSecret"},
+}
+
+// telegramTokenKeys maps a credential type to the telegram bot token config key
+var telegramTokenKeys = map[string]string{
+	config.CredentialTypeSSM:    "botTokenPath",
+	config.CredentialTypeEnv:    "botTokenEnv",
+	config.CredentialTypeInline: "botToken",
+}
+
+// Build turns answers into a payload
+func Build(a Answers) (*config.DCAPayload, error) {
+	if err := validateExchange(a.Exchange); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateCredentialType(a.CredentialType); err != nil {
+		return nil, err
+	}
+
+	keys := credentialKeys[a.CredentialType]
+	credentials := map[string]interface{}{
+		keys[0]: a.APIKeyRef,
+		keys[1]: a.APISecretRef,
+	}
+
+	payload := &config.DCAPayload{
+		Version: "v2",
+		Exchange: config.ExchangeConfig{
+			Name: a.Exchange,
+			Credentials: config.CredentialSource{
+				Type:   a.CredentialType,
+				Config: credentials,
+			},
+		},
+		Strategy: config.DCAStrategy{
+			Symbol:           strings.ToUpper(a.Symbol),
+			QuoteAmount:      a.QuoteAmount,
+			BalanceThreshold: a.BalanceThreshold,
+			OrderType:        "market",
+		},
+		Flags: config.RuntimeFlags{DryRun: a.DryRun},
+	}
+
+	if a.TelegramType != "" {
+		if err := config.ValidateCredentialType(a.TelegramType); err != nil {
+			return nil, fmt.Errorf("telegram: %w", err)
+		}
+		payload.Notifications.Telegram = &config.TelegramConfig{
+			Type: a.TelegramType,
+			Config: map[string]interface{}{
+				telegramTokenKeys[a.TelegramType]: a.TelegramTokenRef,
+				"chatId":                          a.TelegramChatID,
+			},
+		}
+	}
+
+	return payload, nil
+}
+
+// Render builds the payload JSON and a matching example EventBridge event
+// wrapping it, after checking the payload parses
+func Render(a Answers) (payloadJSON, eventJSON []byte, err error) {
+	payload, err := Build(a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payloadJSON, err = json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := config.ParseDCAPayload(payloadJSON); err != nil {
+		return nil, nil, fmt.Errorf("generated payload does not parse: %w", err)
+	}
+
+	eventJSON, err = json.MarshalIndent(map[string]interface{}{
+		"source":      "dca-bot.scheduler",
+		"detail-type": "DCA Run",
+		"detail":      json.RawMessage(payloadJSON),
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return payloadJSON, eventJSON, nil
+}
+
+func validateExchange(name string) error {
+	if err := config.ValidateExchangeName(name); err != nil {
+		return err
+	}
+	for _, supported := range Exchanges {
+		if name == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported exchange %q (want one of %s)", name, strings.Join(Exchanges, ", "))
+}
+
+// Ask interactively collects answers, re-asking each question until the
+// answer passes validation
+func Ask(in io.Reader, out io.Writer) (Answers, error) {
+	p := &prompter{in: bufio.NewScanner(in), out: out}
+	var a Answers
+
+	steps := []func() error{
+		func() (err error) {
+			a.Exchange, err = p.ask("Exchange ("+strings.Join(Exchanges, "/")+")", "binance", validateExchange)
+			return
+		},
+		func() (err error) {
+			a.Symbol, err = p.ask("Symbol", "BTC-USDT", config.ValidateSymbol)
+			return
+		},
+		func() (err error) {
+			a.QuoteAmount, err = p.ask("Quote amount per run", "10", config.ValidateQuoteAmount)
+			return
+		},
+		func() (err error) {
+			a.BalanceThreshold, err = p.ask("Low balance threshold (empty to disable)", "", config.ValidateBalanceThreshold)
+			return
+		},
+		func() (err error) {
+			a.CredentialType, err = p.ask("Credential source (ssm/env/inline)", "ssm", config.ValidateCredentialType)
+			return
+		},
+		func() (err error) {
+			keys := credentialKeys[a.CredentialType]
+			if a.APIKeyRef, err = p.ask(keys[0], "", required(keys[0])); err != nil {
+				return
+			}
+			a.APISecretRef, err = p.ask(keys[1], "", required(keys[1]))
+			return
+		},
+		func() (err error) {
+			a.TelegramType, err = p.ask("Telegram bot token source (ssm/env/inline, empty for none)", "", func(s string) error {
+				if s == "" {
+					return nil
+				}
+				return config.ValidateCredentialType(s)
+			})
+			if err != nil || a.TelegramType == "" {
+				return
+			}
+			key := telegramTokenKeys[a.TelegramType]
+			if a.TelegramTokenRef, err = p.ask(key, "", required(key)); err != nil {
+				return
+			}
+			a.TelegramChatID, err = p.ask("chatId", "", required("chatId"))
+			return
+		},
+		func() error {
+			answer, err := p.ask("Dry run (y/n)", "y", func(s string) error {
+				switch strings.ToLower(s) {
+				case "y", "yes", "n", "no":
+					return nil
+				}
+				return fmt.Errorf("answer y or n")
+			})
+			a.DryRun = strings.HasPrefix(strings.ToLower(answer), "y")
+			return err
+		},
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return Answers{}, err
+		}
+	}
+	return a, nil
+}
+
+func required(name string) func(string) error {
+	return func(s string) error {
+		if s == "" {
+			return fmt.Errorf("%s is required", name)
+		}
+		return nil
+	}
+}
+
+type prompter struct {
+	in  *bufio.Scanner
+	out io.Writer
+}
+
+// ask prints a question and reads answers until validate accepts one
+func (p *prompter) ask(question, def string, validate func(string) error) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+
+		if !p.in.Scan() {
+			if err := p.in.Err(); err != nil {
+				return "", err
+			}
+			return "", fmt.Errorf("input ended before %q was answered", question)
+		}
+
+		answer := strings.TrimSpace(p.in.Text())
+		if answer == "" {
+			answer = def
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "  ✗ %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
@@ -0,0 +1,187 @@
+package wizard
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sudowanderer/dca-bot-go/internal/config"
+)
+
+func TestRender_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers Answers
+	}{
+		{
+			name: "binance_ssm_with_telegram",
+			answers: Answers{
+				Exchange:         "binance",
+				Symbol:           "btc-usdt",
+				QuoteAmount:      "10",
+				BalanceThreshold: "500",
+				CredentialType:   "ssm",
+				APIKeyRef:        "/dca/binance/apiKey",
+				APISecretRef:     "/dca/binance/apiSecret",
+				TelegramType:     "ssm",
+				TelegramTokenRef: "/dca/telegram/token",
+				TelegramChatID:   "123456789",
+				DryRun:           true,
+			},
+		},
+		{
+			name: "binance_env",
+			answers: Answers{
+				Exchange:       "binance",
+				Symbol:         "ETH-USDT",
+				QuoteAmount:    "25.5",
+				CredentialType: "env",
+				APIKeyRef:      "BINANCE_API_KEY",
+				APISecretRef:   "BINANCE_API_SECRET",
+			},
+		},
+		{
+			name: "binance_inline_env_telegram",
+			answers: Answers{
+				Exchange:         "binance",
+				Symbol:           "BTC-FDUSD",
+				QuoteAmount:      "5",
+				CredentialType:   "inline",
+				APIKeyRef:        "key",
+				APISecretRef:     "secret",
+				TelegramType:     "env",
+				TelegramTokenRef: "TELEGRAM_TOKEN",
+				TelegramChatID:   "42",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloadJSON, eventJSON, err := Render(tt.answers)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			payload, err := config.ParseDCAPayload(payloadJSON)
+			if err != nil {
+				t.Fatalf("generated payload rejected by ParseDCAPayload: %v\n%s", err, payloadJSON)
+			}
+			if payload.Exchange.Name != tt.answers.Exchange {
+				t.Errorf("Exchange.Name = %v, want %v", payload.Exchange.Name, tt.answers.Exchange)
+			}
+			if payload.Strategy.Symbol != strings.ToUpper(tt.answers.Symbol) {
+				t.Errorf("Strategy.Symbol = %v", payload.Strategy.Symbol)
+			}
+			if payload.Flags.DryRun != tt.answers.DryRun {
+				t.Errorf("Flags.DryRun = %v, want %v", payload.Flags.DryRun, tt.answers.DryRun)
+			}
+			if (payload.Notifications.Telegram != nil) != (tt.answers.TelegramType != "") {
+				t.Errorf("Telegram = %+v, want configured=%v", payload.Notifications.Telegram, tt.answers.TelegramType != "")
+			}
+			if _, err := payload.ToUnified(); err != nil {
+				t.Errorf("ToUnified() error = %v", err)
+			}
+
+			var event struct {
+				DetailType string          `json:"detail-type"`
+				Detail     json.RawMessage `json:"detail"`
+			}
+			if err := json.Unmarshal(eventJSON, &event); err != nil {
+				t.Fatalf("invalid event JSON: %v", err)
+			}
+			if _, err := config.ParseDCAPayload(event.Detail); err != nil {
+				t.Errorf("event detail rejected by ParseDCAPayload: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	valid := Answers{Exchange: "binance", Symbol: "BTC-USDT", QuoteAmount: "10", CredentialType: "ssm"}
+
+	tests := []struct {
+		name        string
+		modify      func(a *Answers)
+		expectedErr string
+	}{
+		{"unsupported_exchange", func(a *Answers) { a.Exchange = "kraken" }, "unsupported exchange"},
+		{"okx_not_offered", func(a *Answers) { a.Exchange = "okx" }, "unsupported exchange"},
+		{"unknown_credential_type", func(a *Answers) { a.CredentialType = "vault" }, "unknown credential type"},
+		{"unknown_telegram_type", func(a *Answers) { a.TelegramType = "vault" }, "telegram"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.modify(&a)
+			_, err := Build(a)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Errorf("Build() error = %v, want to contain %v", err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRender_InvalidAmount(t *testing.T) {
+	_, _, err := Render(Answers{Exchange: "binance", Symbol: "BTC-USDT", QuoteAmount: "ten", CredentialType: "ssm", APIKeyRef: "/dca/key", APISecretRef: "/dca/secret"})
+	if err == nil || !strings.Contains(err.Error(), "invalid quoteAmount") {
+		t.Errorf("Render() error = %v, want invalid quoteAmount", err)
+	}
+}
+
+func TestAsk(t *testing.T) {
+	// The first quote amount and credential type are invalid and must be re-asked
+	input := strings.Join([]string{
+		"binance",
+		"eth-usdt",
+		"abc",
+		"20",
+		"",
+		"vault",
+		"env",
+		"BINANCE_KEY",
+		"BINANCE_SECRET",
+		"ssm",
+		"/dca/telegram/token",
+		"987",
+		"n",
+	}, "\n") + "\n"
+
+	var out bytes.Buffer
+	answers, err := Ask(strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("Ask() error = %v\noutput:\n%s", err, out.String())
+	}
+
+	expected := Answers{
+		Exchange:         "binance",
+		Symbol:           "eth-usdt",
+		QuoteAmount:      "20",
+		CredentialType:   "env",
+		APIKeyRef:        "BINANCE_KEY",
+		APISecretRef:     "BINANCE_SECRET",
+		TelegramType:     "ssm",
+		TelegramTokenRef: "/dca/telegram/token",
+		TelegramChatID:   "987",
+		DryRun:           false,
+	}
+	if answers != expected {
+		t.Errorf("Ask() = %+v, want %+v", answers, expected)
+	}
+	if !strings.Contains(out.String(), "invalid quoteAmount") || !strings.Contains(out.String(), "unknown credential type") {
+		t.Errorf("expected validation errors in output, got:\n%s", out.String())
+	}
+
+	if _, _, err := Render(answers); err != nil {
+		t.Errorf("Render() error = %v", err)
+	}
+}
+
+func TestAsk_InputEnds(t *testing.T) {
+	_, err := Ask(strings.NewReader("binance\n"), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "input ended") {
+		t.Errorf("Ask() error = %v, want input ended", err)
+	}
+}
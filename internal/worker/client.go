@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// sqsSigningName is the SigV4 service name of SQS
+const sqsSigningName = "sqs"
+
+// sqsTargetPrefix selects the SQS JSON API
+const sqsTargetPrefix = "AmazonSQS."
+
+// Client calls the SQS JSON API with SigV4-signed requests
+type Client struct {
+	cfg    aws.Config
+	signer *v4.Signer
+}
+
+// NewClient creates an SQS client from an AWS configuration. Requests go
+// to cfg.BaseEndpoint when set, the regional endpoint otherwise.
+func NewClient(cfg aws.Config) *Client {
+	return &Client{cfg: cfg, signer: v4.NewSigner()}
+}
+
+// ReceiveMessage long-polls the queue for messages
+func (c *Client) ReceiveMessage(ctx context.Context, params *ReceiveMessageInput) ([]Message, error) {
+	var out struct {
+		Messages []Message `json:"Messages"`
+	}
+	if err := c.call(ctx, "ReceiveMessage", params, &out); err != nil {
+		return nil, err
+	}
+	return out.Messages, nil
+}
+
+// ChangeMessageVisibility sets how long a received message stays hidden,
+// counted from now
+func (c *Client) ChangeMessageVisibility(ctx context.Context, params *ChangeMessageVisibilityInput) error {
+	return c.call(ctx, "ChangeMessageVisibility", params, nil)
+}
+
+// DeleteMessage removes a received message from the queue
+func (c *Client) DeleteMessage(ctx context.Context, params *DeleteMessageInput) error {
+	return c.call(ctx, "DeleteMessage", params, nil)
+}
+
+// call sends an SQS JSON API request and decodes the response into out
+func (c *Client) call(ctx context.Context, operation string, params any, out any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://sqs.%s.amazonaws.com", c.cfg.Region)
+	if c.cfg.BaseEndpoint != nil {
+		endpoint = *c.cfg.BaseEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", sqsTargetPrefix+operation)
+
+	creds, err := c.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), sqsSigningName, c.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	httpClient := c.cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read %s response: %w", operation, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: HTTP %d: %s", operation, resp.StatusCode, bytes.TrimSpace(data))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", operation, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Package worker runs the invocation Handler in a container that long-polls
+// an SQS queue, for platforms such as ECS and Fargate where no function
+// runtime delivers the events. Each message body is an invocation event;
+// a message is deleted once its run succeeds and left on the queue for
+// redelivery, or the queue's dead-letter redrive, when it fails.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/entrypoint"
+	"github.com/sudowanderer/dca-bot-go/internal/handler"
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// Defaults of the Worker's timings
+const (
+	// DefaultVisibility hides a received message from other consumers;
+	// heartbeats keep extending it while its run lasts
+	DefaultVisibility = 60 * time.Second
+
+	// DefaultWait is the long-poll wait, the most SQS allows
+	DefaultWait = 20 * time.Second
+
+	// DefaultBackoff is the pause after a failed receive
+	DefaultBackoff = 5 * time.Second
+)
+
+// QueueAPI is the subset of the SQS API used here
+type QueueAPI interface {
+	ReceiveMessage(ctx context.Context, params *ReceiveMessageInput) ([]Message, error)
+	ChangeMessageVisibility(ctx context.Context, params *ChangeMessageVisibilityInput) error
+	DeleteMessage(ctx context.Context, params *DeleteMessageInput) error
+}
+
+// ReceiveMessageInput is the ReceiveMessage request
+type ReceiveMessageInput struct {
+	QueueURL                    string   `json:"QueueUrl"`
+	MaxNumberOfMessages         int      `json:"MaxNumberOfMessages"`
+	WaitTimeSeconds             int      `json:"WaitTimeSeconds"`
+	VisibilityTimeout           int      `json:"VisibilityTimeout"`
+	MessageSystemAttributeNames []string `json:"MessageSystemAttributeNames,omitempty"`
+}
+
+// ChangeMessageVisibilityInput is the ChangeMessageVisibility request
+type ChangeMessageVisibilityInput struct {
+	QueueURL          string `json:"QueueUrl"`
+	ReceiptHandle     string `json:"ReceiptHandle"`
+	VisibilityTimeout int    `json:"VisibilityTimeout"`
+}
+
+// DeleteMessageInput is the DeleteMessage request
+type DeleteMessageInput struct {
+	QueueURL      string `json:"QueueUrl"`
+	ReceiptHandle string `json:"ReceiptHandle"`
+}
+
+// Message is a received SQS message
+type Message struct {
+	MessageID     string            `json:"MessageId"`
+	ReceiptHandle string            `json:"ReceiptHandle"`
+	Body          string            `json:"Body"`
+	Attributes    map[string]string `json:"Attributes"` // e.g. "ApproximateReceiveCount"
+}
+
+// Worker receives messages one at a time and runs Handler on each, like
+// invocations of a single Lambda instance
+type Worker struct {
+	Queue    QueueAPI
+	QueueURL string
+	Handler  handler.Handler
+
+	// Out receives the result of each run as JSON; nil discards them
+	Out io.Writer
+
+	Visibility time.Duration // defaults to DefaultVisibility
+	Heartbeat  time.Duration // how often a run's message is extended; defaults to a third of Visibility
+	Wait       time.Duration // defaults to DefaultWait
+	Backoff    time.Duration // defaults to DefaultBackoff
+
+	mu       sync.Mutex
+	draining bool
+	lastErr  error // of the last receive
+	inFlight string
+}
+
+// Run receives and processes messages until ctx is done. A run in flight
+// when it ends is finished, detached from ctx, before Run returns.
+func (w *Worker) Run(ctx context.Context) {
+	// The health endpoint reports draining from the signal on, while the
+	// run in flight finishes
+	context.AfterFunc(ctx, func() { w.set(func() { w.draining = true }) })
+	defer w.set(func() { w.draining = true })
+	log.Printf("📬 Polling %s", w.QueueURL)
+	for ctx.Err() == nil {
+		msgs, err := w.Queue.ReceiveMessage(ctx, &ReceiveMessageInput{
+			QueueURL:                    w.QueueURL,
+			MaxNumberOfMessages:         1,
+			WaitTimeSeconds:             seconds(w.wait()),
+			VisibilityTimeout:           seconds(w.visibility()),
+			MessageSystemAttributeNames: []string{"ApproximateReceiveCount", "SentTimestamp"},
+		})
+		if ctx.Err() != nil {
+			break
+		}
+		w.set(func() { w.lastErr = err })
+		if err != nil {
+			log.Printf("⚠️ Failed to receive messages: %v", err)
+			sleep(ctx, w.backoff())
+			continue
+		}
+		for _, msg := range msgs {
+			w.process(context.WithoutCancel(ctx), msg)
+		}
+	}
+	log.Printf("🛑 Stopped polling")
+}
+
+// process runs the handler on msg, extending its visibility while the run
+// lasts, and deletes it once the run succeeded
+func (w *Worker) process(ctx context.Context, msg Message) {
+	w.set(func() { w.inFlight = msg.MessageID })
+	defer w.set(func() { w.inFlight = "" })
+	log.Printf("📨 Message %s (receive %s)", msg.MessageID, msg.Attributes["ApproximateReceiveCount"])
+
+	ctx = run.WithSource(ctx, run.Source{Trigger: run.TriggerSQS, InvocationID: msg.MessageID, EventTime: run.SentTime(msg.Attributes["SentTimestamp"])})
+	stop := w.heartbeat(ctx, msg)
+	res, err := entrypoint.Invoke(ctx, w.Handler, json.RawMessage(msg.Body))
+	stop()
+
+	if res != nil && w.Out != nil {
+		if renderErr := entrypoint.Render(w.Out, res); renderErr != nil {
+			log.Printf("⚠️ Failed to render result: %v", renderErr)
+		}
+	}
+	if err != nil {
+		log.Printf("🔁 Run failed; leaving message %s for redelivery", msg.MessageID)
+		return
+	}
+	if err := w.Queue.DeleteMessage(ctx, &DeleteMessageInput{QueueURL: w.QueueURL, ReceiptHandle: msg.ReceiptHandle}); err != nil {
+		// The message comes back once its visibility lapses and runs
+		// again, as it would under a Lambda SQS trigger
+		log.Printf("⚠️ Failed to delete message %s: %v", msg.MessageID, err)
+	}
+}
+
+// heartbeat extends the visibility of msg until the returned stop is
+// called, so a long run is not redelivered to another worker mid-run.
+// stop returns once no extension is in flight.
+func (w *Worker) heartbeat(ctx context.Context, msg Message) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(w.heartbeatEvery())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := w.Queue.ChangeMessageVisibility(ctx, &ChangeMessageVisibilityInput{
+				QueueURL:          w.QueueURL,
+				ReceiptHandle:     msg.ReceiptHandle,
+				VisibilityTimeout: seconds(w.visibility()),
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("⚠️ Failed to extend message %s: %v", msg.MessageID, err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// healthBody is the response of the health endpoint
+type healthBody struct {
+	Status    string `json:"status"`
+	InFlight  string `json:"inFlight,omitempty"` // message ID
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Health serves the worker's health for the orchestrator: 200 while it
+// polls, 503 while receiving fails or once it is shutting down
+func (w *Worker) Health() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		w.mu.Lock()
+		body := healthBody{Status: "ok", InFlight: w.inFlight}
+		status := http.StatusOK
+		switch {
+		case w.draining:
+			body.Status, status = "draining", http.StatusServiceUnavailable
+		case w.lastErr != nil:
+			body.Status, body.LastError, status = "failing", w.lastErr.Error(), http.StatusServiceUnavailable
+		}
+		w.mu.Unlock()
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		json.NewEncoder(rw).Encode(body)
+	})
+}
+
+// set changes the worker's state under its lock
+func (w *Worker) set(change func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	change()
+}
+
+func (w *Worker) visibility() time.Duration {
+	if w.Visibility > 0 {
+		return w.Visibility
+	}
+	return DefaultVisibility
+}
+
+func (w *Worker) heartbeatEvery() time.Duration {
+	if w.Heartbeat > 0 {
+		return w.Heartbeat
+	}
+	return w.visibility() / 3
+}
+
+func (w *Worker) wait() time.Duration {
+	if w.Wait > 0 {
+		return w.Wait
+	}
+	return DefaultWait
+}
+
+func (w *Worker) backoff() time.Duration {
+	if w.Backoff > 0 {
+		return w.Backoff
+	}
+	return DefaultBackoff
+}
+
+// seconds rounds d up to whole seconds, as SQS takes them
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,224 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sudowanderer/dca-bot-go/internal/run"
+)
+
+// fakeQueue replays scripted receives, then long-polls until canceled,
+// recording every call in order
+type fakeQueue struct {
+	mu       sync.Mutex
+	receives []receive
+	calls    []string
+	idle     chan struct{} // closed once the script is used up
+}
+
+type receive struct {
+	msgs []Message
+	err  error
+}
+
+func newFakeQueue(receives ...receive) *fakeQueue {
+	return &fakeQueue{receives: receives, idle: make(chan struct{})}
+}
+
+func (q *fakeQueue) record(call string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.calls = append(q.calls, call)
+}
+
+func (q *fakeQueue) ReceiveMessage(ctx context.Context, params *ReceiveMessageInput) ([]Message, error) {
+	q.record("receive")
+	q.mu.Lock()
+	if len(q.receives) == 0 {
+		select {
+		case <-q.idle:
+		default:
+			close(q.idle)
+		}
+		q.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	next := q.receives[0]
+	q.receives = q.receives[1:]
+	q.mu.Unlock()
+	return next.msgs, next.err
+}
+
+func (q *fakeQueue) ChangeMessageVisibility(ctx context.Context, params *ChangeMessageVisibilityInput) error {
+	q.record("extend " + params.ReceiptHandle)
+	return nil
+}
+
+func (q *fakeQueue) DeleteMessage(ctx context.Context, params *DeleteMessageInput) error {
+	q.record("delete " + params.ReceiptHandle)
+	return nil
+}
+
+// history returns the calls with repeated extensions collapsed
+func (q *fakeQueue) history() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var calls []string
+	for _, call := range q.calls {
+		if len(calls) > 0 && calls[len(calls)-1] == call && strings.HasPrefix(call, "extend") {
+			continue
+		}
+		calls = append(calls, call)
+	}
+	return strings.Join(calls, ", ")
+}
+
+func message(id string) Message {
+	return Message{MessageID: id, ReceiptHandle: "rh-" + id, Body: `{"version":"v2"}`, Attributes: map[string]string{"SentTimestamp": "1760601600000"}}
+}
+
+// runUntilIdle runs w until the queue has no more scripted receives
+func runUntilIdle(t *testing.T, w *Worker, q *fakeQueue) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-q.idle:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the worker did not use up the script")
+	}
+	cancel()
+	<-done
+}
+
+func TestWorker_Run(t *testing.T) {
+	q := newFakeQueue(
+		receive{msgs: []Message{message("slow")}},
+		receive{},
+		receive{msgs: []Message{message("failing")}},
+		receive{msgs: []Message{message("fast")}},
+	)
+	var sources []run.Source
+	w := &Worker{
+		Queue: q, QueueURL: "https://sqs.eu-west-1.amazonaws.com/123456789012/dca",
+		Heartbeat: 5 * time.Millisecond,
+		Handler: func(ctx context.Context, event json.RawMessage) error {
+			source := run.SourceFrom(ctx)
+			sources = append(sources, source)
+			switch source.InvocationID {
+			case "slow":
+				time.Sleep(50 * time.Millisecond) // a TWAP outlasting several heartbeats
+			case "failing":
+				return errors.New("exchange unavailable")
+			}
+			return nil
+		},
+	}
+	runUntilIdle(t, w, q)
+
+	// The slow run is extended until it ends, and deleted after; the
+	// failed one is left for redelivery
+	want := "receive, extend rh-slow, delete rh-slow, receive, receive, receive, delete rh-fast, receive"
+	if got := q.history(); got != want {
+		t.Errorf("calls = %s\nwant %s", got, want)
+	}
+	for _, source := range sources {
+		if source.Trigger != run.TriggerSQS {
+			t.Errorf("run of %s triggered by %s, want sqs", source.InvocationID, source.Trigger)
+		}
+		// Redeliveries keep when the message was sent
+		if !source.EventTime.Equal(time.UnixMilli(1760601600000)) {
+			t.Errorf("run of %s: EventTime = %s, want the message's SentTimestamp", source.InvocationID, source.EventTime)
+		}
+	}
+}
+
+func TestWorker_ReceiveFails(t *testing.T) {
+	q := newFakeQueue(receive{err: errors.New("AccessDenied")}, receive{msgs: []Message{message("m1")}})
+	w := &Worker{Queue: q, Backoff: time.Millisecond, Handler: func(ctx context.Context, event json.RawMessage) error { return nil }}
+	runUntilIdle(t, w, q)
+
+	if got, want := q.history(), "receive, receive, delete rh-m1, receive"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestWorker_Shutdown(t *testing.T) {
+	q := newFakeQueue(receive{msgs: []Message{message("m1")}})
+	ctx, cancel := context.WithCancel(context.Background())
+	started, finish := make(chan struct{}), make(chan struct{})
+	var runErr error
+	w := &Worker{
+		Queue:     q,
+		Heartbeat: time.Millisecond,
+		Handler: func(runCtx context.Context, event json.RawMessage) error {
+			close(started)
+			<-finish
+			runErr = runCtx.Err()
+			return nil
+		},
+	}
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	<-started
+	cancel() // SIGTERM mid-run
+	// The health endpoint reports draining while the run finishes
+	deadline := time.Now().Add(5 * time.Second)
+	for status(w) != http.StatusServiceUnavailable && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := status(w); got != http.StatusServiceUnavailable {
+		t.Errorf("health while draining = %d, want 503", got)
+	}
+	select {
+	case <-done:
+		t.Fatal("Run returned before the run in flight finished")
+	default:
+	}
+	close(finish)
+	<-done
+
+	if runErr != nil {
+		t.Errorf("the run in flight saw %v, want it detached from the shutdown", runErr)
+	}
+	// No receive follows the shutdown
+	if got := q.history(); !strings.HasSuffix(got, "delete rh-m1") || strings.Count(got, "receive") != 1 {
+		t.Errorf("calls = %s, want one receive and the message deleted last", got)
+	}
+}
+
+// status returns the status of the worker's health endpoint
+func status(w *Worker) int {
+	rec := httptest.NewRecorder()
+	w.Health().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	return rec.Code
+}
+
+func TestWorker_Health(t *testing.T) {
+	w := &Worker{}
+	if got := status(w); got != http.StatusOK {
+		t.Errorf("health = %d, want 200", got)
+	}
+	w.set(func() { w.lastErr = errors.New("AccessDenied") })
+	rec := httptest.NewRecorder()
+	w.Health().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"lastError":"AccessDenied"`) {
+		t.Errorf("health while receiving fails = %d %s", rec.Code, rec.Body)
+	}
+}